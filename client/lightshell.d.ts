@@ -15,6 +15,16 @@ interface LightShell {
   menu: LightShellMenu
   system: LightShellSystem
   app: LightShellApp
+  cache: LightShellCache
+  image: LightShellImage
+  pdf: LightShellPDF
+  codes: LightShellCodes
+  toolbar: LightShellToolbar
+  touchBar: LightShellTouchBar
+  power: LightShellPower
+  permissions: LightShellPermissions
+  hmr: LightShellHMR
+  share(content: LightShellShareContent): Promise<LightShellShareResult>
   on(event: string, callback: (data: any) => void): () => void
 }
 
@@ -29,6 +39,8 @@ interface LightShellWindow {
   fullscreen(): Promise<void>
   restore(): Promise<void>
   close(): Promise<void>
+  /** macOS only. Each call replaces the previous settings */
+  setTitlebar(options: LightShellTitlebar): Promise<void>
   onResize(callback: (data: { width: number; height: number }) => void): () => void
   onMove(callback: (data: { x: number; y: number }) => void): () => void
   onFocus(callback: () => void): () => void
@@ -43,6 +55,8 @@ interface LightShellFS {
   stat(path: string): Promise<FileStat>
   mkdir(path: string): Promise<void>
   remove(path: string): Promise<void>
+  /** Creates a directory that is deleted when the app quits. Returns its path */
+  createTempDir(prefix?: string): Promise<string>
   watch(path: string, callback: (event: FileWatchEvent) => void): () => void
 }
 
@@ -118,14 +132,89 @@ interface LightShellTray {
 
 interface MenuTemplate {
   label: string
+  id?: string
   click?: string
   role?: string
+  /** Defaults to the matching entry in lightshell.json "accelerators" */
+  accelerator?: string
+  type?: 'normal' | 'separator' | 'checkbox'
+  enabled?: boolean
+  checked?: boolean
+  items?: MenuTemplate[]
   submenu?: MenuTemplate[]
   separator?: boolean
 }
 
+interface LightShellTitlebar {
+  transparent?: boolean
+  fullSizeContent?: boolean
+  hideTitle?: boolean
+  toolbarStyle?: 'automatic' | 'expanded' | 'preference' | 'unified' | 'unifiedCompact'
+}
+
+interface LightShellBarItem {
+  /** Required for buttons; sent back in click events */
+  id?: string
+  /** Defaults to 'button'. 'separator' is toolbar-only, 'label' is Touch Bar-only */
+  type?: 'button' | 'label' | 'space' | 'flexibleSpace' | 'separator'
+  label?: string
+  /** SF Symbol name, e.g. 'square.and.arrow.up' */
+  icon?: string
+  tooltip?: string
+  enabled?: boolean
+}
+
+interface LightShellToolbar {
+  set(items: LightShellBarItem[], options?: { displayMode?: 'default' | 'iconAndLabel' | 'iconOnly' | 'labelOnly' }): Promise<void>
+  remove(): Promise<void>
+  onClick(callback: (data: { id: string }) => void): () => void
+}
+
+interface LightShellTouchBar {
+  set(items: LightShellBarItem[]): Promise<void>
+  remove(): Promise<void>
+  onClick(callback: (data: { id: string }) => void): () => void
+}
+
+interface LightShellPower {
+  /** Keeps the display awake until released. Resolves to the blocker's id */
+  preventSleep(reason: string): Promise<string>
+  /** Releases the blocker with id, or every blocker when id is omitted */
+  allowSleep(id?: string): Promise<void>
+  isPreventingSleep(): Promise<{ preventing: boolean; reasons: string[] }>
+}
+
+type LightShellPermissionDescriptor =
+  | { api: 'fs'; path: string; access?: 'read' | 'write' }
+  | { api: 'http'; url: string }
+  | { api: 'process'; cmd: string; args?: string[] }
+
+type LightShellPermissionState = 'granted' | 'denied' | 'prompt'
+
+interface LightShellPermissions {
+  /** Whether the path, URL, or command is allowed, without asking. 'prompt' means the user would be asked */
+  query(descriptor: LightShellPermissionDescriptor): Promise<LightShellPermissionState>
+  /** Asks the user when query would report 'prompt'. Resolves to the state after their answer */
+  request(descriptor: LightShellPermissionDescriptor): Promise<'granted' | 'denied'>
+}
+
+interface LightShellHMR {
+  /** True in lightshell dev with "dev": { "hmr": true }; elsewhere the methods do nothing */
+  enabled: boolean
+  /** Calls callback with the new version of module (a URL or path) when it changes */
+  accept(module: string | URL, callback: (newModule: any) => void): void
+  /** Calls callback before module is replaced */
+  dispose(module: string | URL, callback: () => void): void
+}
+
 interface LightShellMenu {
   set(template: MenuTemplate[]): Promise<void>
+  /** The current menu bar, including updateItem changes and toggled checkboxes */
+  get(): Promise<MenuTemplate[]>
+  updateItem(id: string, changes: { enabled?: boolean; checked?: boolean; label?: string }): Promise<void>
+  /** Shows a context menu at { x, y } in the window, or at the mouse pointer */
+  popup(template: MenuTemplate[], position?: { x: number; y: number }): Promise<void>
+  onClick(callback: (data: { id: string; checked: boolean; role?: string }) => void): () => void
 }
 
 interface LightShellSystem {
@@ -134,12 +223,238 @@ interface LightShellSystem {
   homeDir(): Promise<string>
   tempDir(): Promise<string>
   hostname(): Promise<string>
+  /** Whether the system has a usable network path */
+  isOnline(): Promise<boolean>
+  networkStatus(): Promise<LightShellNetworkStatus>
+  onNetworkChange(callback: (status: LightShellNetworkStatus) => void): () => void
+}
+
+interface LightShellNetworkStatus {
+  online: boolean
+  type: 'wifi' | 'ethernet' | 'cellular' | 'other' | 'none'
+  /** Metered connection, e.g. cellular or a personal hotspot (macOS only) */
+  expensive: boolean
+}
+
+interface LightShellAppPaths {
+  /** Persistent app data (e.g. ~/Library/Application Support/<app>, $XDG_DATA_HOME/<app>) */
+  data: string
+  /** Configuration and preferences */
+  config: string
+  /** Regenerable cached data */
+  cache: string
+  /** Log files */
+  log: string
+  home: string
+  temp: string
+}
+
+interface LightShellStartupMetrics {
+  /** 'dev' or 'production' */
+  mode: string
+  /** ISO timestamp of process start */
+  startedAt: string
+  /** Milliseconds since process start. Milestones not yet reached are omitted. */
+  milestones: {
+    processStart: number
+    windowCreated?: number
+    loadCommitted?: number
+    ready?: number
+    domContentLoaded?: number
+    firstPaint?: number
+  }
 }
 
 interface LightShellApp {
   quit(): Promise<void>
   version(): Promise<string>
   dataDir(): Promise<string>
+  paths(): Promise<LightShellAppPaths>
+  /** Cold-start milestones for this process */
+  getStartupMetrics(): Promise<LightShellStartupMetrics>
+  /** The app's cache directory (created on first call) */
+  cacheDir(): Promise<string>
+  setLaunchAtLogin(enabled: boolean): Promise<LightShellLaunchAtLogin>
+  getLaunchAtLogin(): Promise<LightShellLaunchAtLogin>
+  /** The app's command-line arguments, without the program name */
+  argv(): Promise<string[]>
+  /** argv parsed against launchArgs in lightshell.json */
+  launchOptions(): Promise<LightShellLaunchOptions>
+  /** True during the first launch of the app on this machine */
+  isFirstRun(): Promise<boolean>
+  launchInfo(): Promise<LightShellLaunchInfo>
+  /** Runs pending migrations, passed here or declared in lightshell.json, in version order */
+  migrate(migrations?: Record<string, (ctx: LightShellMigrationContext) => void | Promise<void>>): Promise<LightShellMigrationResult>
+}
+
+interface LightShellLaunchInfo {
+  isFirstRun: boolean
+  /** Version of the previous launch; '' on a first run */
+  previousVersion: string
+  version: string
+  /** Version the app's data was last migrated to */
+  migratedVersion: string
+  /** Migration modules declared in lightshell.json, by version */
+  migrations: Record<string, string>
+}
+
+interface LightShellMigrationContext {
+  /** The migration being run */
+  version: string
+  /** Version the data was at before migrate() was called */
+  fromVersion: string
+  /** The running app version */
+  toVersion: string
+}
+
+interface LightShellMigrationResult {
+  fromVersion: string
+  toVersion: string
+  /** Versions whose migrations ran, in order */
+  ran: string[]
+}
+
+interface LightShellLaunchOptions {
+  /** Declared flags by name, with defaults applied. Boolean flags are always present */
+  options: Record<string, string | number | boolean>
+  /** Positional arguments, including everything after "--" */
+  args: string[]
+  /** Flags not declared in launchArgs, as given */
+  unknown: string[]
+}
+
+interface LightShellLaunchAtLogin {
+  /** True only when the app will launch at the next login */
+  enabled: boolean
+  /** 'requiresApproval' means the user must allow the app in System Settings > General > Login Items */
+  status: 'enabled' | 'disabled' | 'requiresApproval' | 'notFound' | 'unsupported'
+}
+
+interface LightShellCacheOptions {
+  /** 'base64' to store/return binary data as base64 strings */
+  encoding?: 'utf8' | 'base64'
+}
+
+interface LightShellCache {
+  /** Returns null on a miss or if the entry has expired */
+  get(key: string, opts?: LightShellCacheOptions): Promise<string | null>
+  /** ttl is in milliseconds; omit for no expiry (entries may still be evicted for space) */
+  put(key: string, data: string, opts?: LightShellCacheOptions & { ttl?: number }): Promise<void>
+  delete(key: string): Promise<void>
+  clear(): Promise<void>
+  /** Total bytes used by cache entries */
+  size(): Promise<number>
+}
+
+/** A file path, or base64-encoded image bytes */
+type LightShellImageSource = string | { data: string }
+
+interface LightShellImageOutputOptions {
+  /** Write the result to this path (requires fs permission) instead of returning base64 */
+  dest?: string
+  /** Output format; defaults to the dest extension, then the source format */
+  format?: 'png' | 'jpeg' | 'jpg' | 'gif'
+  /** JPEG quality 1-100 (default 85) */
+  quality?: number
+}
+
+interface LightShellImageResult {
+  width: number
+  height: number
+  format: string
+  /** Base64 image bytes, when no dest was given */
+  data?: string
+  /** Written file path and size in bytes, when dest was given */
+  path?: string
+  size?: number
+}
+
+interface LightShellImage {
+  /** Dimensions and format; with pixels: true, also base64 RGBA pixels */
+  decode(src: LightShellImageSource, opts?: { pixels?: boolean }): Promise<{ width: number; height: number; format: string; pixels?: string }>
+  /** Pass 0 for width or height to preserve the aspect ratio */
+  resize(src: LightShellImageSource, width: number, height: number, opts?: LightShellImageOutputOptions & { fit?: 'contain' | 'cover' | 'fill' }): Promise<LightShellImageResult>
+  convert(src: LightShellImageSource, format: 'png' | 'jpeg' | 'jpg' | 'gif', opts?: LightShellImageOutputOptions): Promise<LightShellImageResult>
+  /** Fit within size x size (default 256) without enlarging */
+  thumbnail(src: LightShellImageSource, size?: number, opts?: LightShellImageOutputOptions): Promise<LightShellImageResult>
+}
+
+interface LightShellPDFOptions {
+  /** Where to write the PDF (requires fs permission and a matching write scope) */
+  path: string
+  /** Default 'letter' */
+  paper?: 'letter' | 'legal' | 'a4' | 'a3'
+  landscape?: boolean
+  /** Page margin in points (1/72 inch). Default 36 */
+  margin?: number
+  /** Base URL for resolving relative links, images, and stylesheets in the HTML */
+  baseURL?: string
+}
+
+interface LightShellPDF {
+  /** Render an HTML document offscreen and write it as a paginated PDF */
+  fromHTML(html: string, opts: LightShellPDFOptions): Promise<{ path: string; size: number }>
+}
+
+interface LightShellCodeOptions {
+  /** Write the PNG here instead of returning base64 (requires fs permission and a matching write scope) */
+  dest?: string
+  /** Output format. Default 'png' */
+  format?: 'png' | 'jpeg' | 'gif'
+  /** Dark module color as CSS hex. Default '#000000' */
+  color?: string
+  /** Light module color as CSS hex. Default '#ffffff' */
+  background?: string
+}
+
+interface LightShellQROptions extends LightShellCodeOptions {
+  /** Error correction level. Default 'M' */
+  level?: 'L' | 'M' | 'Q' | 'H'
+  /** Maximum image width and height in pixels. Default 256 */
+  size?: number
+  /** Quiet zone in modules. Default 4 */
+  margin?: number
+}
+
+interface LightShellBarcodeOptions extends LightShellCodeOptions {
+  /** Bar height in pixels. Default 80 */
+  height?: number
+  /** Pixels per module. Default 2 */
+  scale?: number
+  /** Quiet zone in modules. Default 10 */
+  margin?: number
+}
+
+interface LightShellCodeResult {
+  width: number
+  height: number
+  format: string
+  /** Base64 image data, when no dest was given */
+  data?: string
+  path?: string
+  size?: number
+}
+
+interface LightShellCodes {
+  /** Generate a QR code image for text */
+  generateQR(text: string, opts?: LightShellQROptions): Promise<LightShellCodeResult>
+  /** Generate a Code 128 barcode image for printable ASCII text */
+  generateBarcode(text: string, opts?: LightShellBarcodeOptions): Promise<LightShellCodeResult>
+}
+
+interface LightShellShareContent {
+  text?: string
+  url?: string
+  /** Files to share (requires fs permission and a matching read scope) */
+  files?: string[]
+}
+
+interface LightShellShareResult {
+  id: string
+  /** Title of the service the user picked; empty if the picker was dismissed */
+  service: string
+  completed: boolean
+  error?: string
 }
 
 export {}
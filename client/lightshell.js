@@ -45,11 +45,115 @@
         resolve(msg.result)
       }
     } else if (msg.event) {
-      const cbs = listeners.get(msg.event)
-      if (cbs) cbs.forEach(cb => cb(msg.data))
+      emit(msg.event, msg.data)
     }
   }
 
+  function emit(event, data) {
+    const cbs = listeners.get(event)
+    if (cbs) cbs.forEach(cb => cb(data))
+  }
+
+  // App-wide accelerators declared in lightshell.json. Built-in actions run
+  // here; any other action is emitted as an event to lightshell.on listeners.
+  const accelerators = window.__lightshell_accelerators || []
+  const acceleratorActions = {
+    reload: () => location.reload(),
+    toggleDevtools: () => { if (window.__lightshell_debug) window.__lightshell_debug.toggle() },
+    quit: () => call('app.quit'),
+  }
+  if (accelerators.length) {
+    window.addEventListener('keydown', (e) => {
+      const b = accelerators.find(b => b.code === e.code && b.meta === e.metaKey &&
+        b.ctrl === e.ctrlKey && b.alt === e.altKey && b.shift === e.shiftKey)
+      if (!b) return
+      e.preventDefault()
+      e.stopPropagation()
+      if (e.repeat) return
+      const builtin = acceleratorActions[b.action]
+      if (builtin) builtin()
+      else emit(b.action, { accelerator: b.accelerator })
+    }, true)
+  }
+
+  // Menu items whose role is a built-in accelerator action run it here
+  on('menu.click', (e) => {
+    const action = e.role && acceleratorActions[e.role]
+    if (action) action()
+  })
+
+  // withAccelerators shows configured accelerators on menu items whose role
+  // is the built-in action, or whose id is the custom event, they trigger.
+  function withAccelerators(items) {
+    return (items || []).map(item => {
+      const copy = Object.assign({}, item)
+      if (!copy.accelerator) {
+        const b = accelerators.find(b => acceleratorActions[b.action] ? b.action === item.role : b.action === item.id)
+        if (b) copy.accelerator = b.accelerator
+      }
+      if (item.items) copy.items = withAccelerators(item.items)
+      if (item.submenu) copy.submenu = withAccelerators(item.submenu)
+      return copy
+    })
+  }
+
+  // imageSource accepts a file path or { data: base64 }
+  function imageSource(src) {
+    return typeof src === 'string' ? { path: src } : { data: src && src.data }
+  }
+
+  // compareVersions orders major.minor.patch versions; a pre-release sorts
+  // before its release, as in lightshell version.
+  function compareVersions(a, b) {
+    const parse = (v) => {
+      const m = /^v?(\d+)\.(\d+)\.(\d+)(?:-([^+]*))?(?:\+.*)?$/.exec(String(v).trim())
+      if (!m) throw new Error('invalid version "' + v + '": expected major.minor.patch (e.g. 1.2.3)')
+      return [+m[1], +m[2], +m[3], m[4] || '']
+    }
+    const x = parse(a), y = parse(b)
+    for (let i = 0; i < 3; i++) {
+      if (x[i] !== y[i]) return x[i] < y[i] ? -1 : 1
+    }
+    if (x[3] === y[3]) return 0
+    if (!x[3]) return 1
+    if (!y[3]) return -1
+    return x[3] < y[3] ? -1 : 1
+  }
+
+  // Migrations newer than the version the data was last migrated to, up to
+  // the running version, run in version order. The runtime records each one
+  // as it finishes, so after a crash only the unfinished ones run again.
+  let migrating = null
+  function migrate(fns) {
+    if (migrating) return migrating
+    migrating = (async () => {
+      const info = await call('app.launchInfo')
+      const steps = Object.assign({}, info.migrations, fns)
+      const pending = Object.keys(steps)
+        .filter(v => compareVersions(v, info.migratedVersion) > 0 && compareVersions(v, info.version) <= 0)
+        .sort(compareVersions)
+      const result = { fromVersion: info.migratedVersion, toVersion: info.version, ran: [] }
+      for (const version of pending) {
+        let fn = steps[version]
+        if (typeof fn === 'string') fn = (await import(new URL(fn, location.origin + '/').href)).default
+        if (typeof fn !== 'function') throw new Error('migration ' + version + ' is not a function')
+        await fn({ version, fromVersion: result.fromVersion, toVersion: result.toVersion })
+        await call('app.setMigratedVersion', { version })
+        result.ran.push(version)
+      }
+      // A downgrade keeps the newer version so its migrations do not rerun
+      const last = result.ran.length ? result.ran[result.ran.length - 1] : info.migratedVersion
+      if (compareVersions(last, info.version) < 0) await call('app.setMigratedVersion', { version: info.version })
+      return result
+    })()
+    migrating.catch(() => { migrating = null })
+    return migrating
+  }
+
+  // The ID of this window: set by the runtime in windows opened with
+  // lightshell.window.create, absent in the main window
+  const windowId = window.__lightshell_window_id || 1
+
   window.lightshell = {
     window: {
       setTitle: (t) => call('window.setTitle', { title: t }),
@@ -57,11 +161,17 @@
       getSize: () => call('window.getSize'),
       setPosition: (x, y) => call('window.setPosition', { x, y }),
       getPosition: () => call('window.getPosition'),
+      setTitlebar: (opts) => call('window.setTitlebar', opts || {}),
       minimize: () => call('window.minimize'),
       maximize: () => call('window.maximize'),
       fullscreen: () => call('window.fullscreen'),
       restore: () => call('window.restore'),
-      close: () => call('window.close'),
+      id: windowId,
+      create: (opts) => call('window.create', opts || {}),
+      list: () => call('window.list'),
+      focus: (id) => call('window.focus', { id: id || windowId }),
+      close: (id) => call('window.close', { id: id || windowId }),
+      onClosed: (cb) => on('window.closed', cb),
       onResize: (cb) => on('window.resize', cb),
       onMove: (cb) => on('window.move', cb),
       onFocus: (cb) => on('window.focus', cb),
@@ -70,12 +180,38 @@
     fs: {
       readFile: (path, enc) => call('fs.readFile', { path, encoding: enc || 'utf-8' }),
       writeFile: (path, data) => call('fs.writeFile', { path, data }),
+      appendFile: (path, data, enc) => call('fs.appendFile', { path, data, encoding: enc || 'utf-8' }),
+      copy: (src, dest) => call('fs.copy', { src, dest }),
+      openRead: (path) => call('fs.openRead', { path }),
+      readChunk: (handle, opts) => call('fs.readChunk', Object.assign({ handle }, opts || {})),
+      close: (handle) => call('fs.close', { handle }),
+      readStream: async function* (path, opts) {
+        const { handle } = await call('fs.openRead', { path })
+        try {
+          for (;;) {
+            const chunk = await call('fs.readChunk', Object.assign({ handle }, opts || {}))
+            if (chunk.data) yield chunk.data
+            if (chunk.eof) return
+          }
+        } finally {
+          call('fs.close', { handle })
+        }
+      },
       readDir: (path) => call('fs.readDir', { path }),
       exists: (path) => call('fs.exists', { path }),
       stat: (path) => call('fs.stat', { path }),
       mkdir: (path) => call('fs.mkdir', { path }),
       remove: (path) => call('fs.remove', { path }),
-      watch: (path, cb) => { call('fs.watch', { path }); return on('fs.watch', cb) },
+      createTempDir: (prefix) => call('fs.createTempDir', { prefix: prefix || '' }),
+      watch: (path, cb, opts) => {
+        let id = null, stopped = false
+        const off = on('fs.change', (e) => { if (e.id === id) cb(e) })
+        call('fs.watch', Object.assign({ path }, opts || {})).then((r) => {
+          id = r.id
+          if (stopped) call('fs.unwatch', { id })
+        }).catch((err) => { off(); console.error('lightshell.fs.watch:', err.message) })
+        return () => { stopped = true; off(); if (id) call('fs.unwatch', { id }) }
+      },
     },
     dialog: {
       open: (opts) => call('dialog.open', opts || {}),
@@ -96,11 +232,19 @@
     },
     tray: {
       set: (opts) => call('tray.set', opts),
+      setIcon: (icon) => call('tray.setIcon', typeof icon === 'string' ? { icon } : icon),
+      setTooltip: (tooltip) => call('tray.setTooltip', { tooltip }),
+      setMenu: (menu, opts) => call('tray.setMenu', Object.assign({ menu }, opts || {})),
       remove: () => call('tray.remove'),
       onClick: (cb) => on('tray.click', cb),
+      onIconClick: (cb) => on('tray.iconClick', cb),
     },
     menu: {
-      set: (template) => call('menu.set', { template }),
+      set: (template) => call('menu.set', { template: withAccelerators(template) }),
+      get: () => call('menu.get'),
+      updateItem: (id, changes) => call('menu.updateItem', Object.assign({ id }, changes || {})),
+      popup: (template, pos) => call('menu.popup', Object.assign({ template: withAccelerators(template) }, pos || {})),
+      onClick: (cb) => on('menu.click', cb),
     },
     system: {
       platform: () => call('system.platform'),
@@ -108,12 +252,105 @@
       homeDir: () => call('system.homeDir'),
       tempDir: () => call('system.tempDir'),
       hostname: () => call('system.hostname'),
+      isOnline: () => call('system.isOnline'),
+      networkStatus: () => call('system.networkStatus'),
+      onNetworkChange: (cb) => on('system.networkChange', cb),
     },
     app: {
       quit: () => call('app.quit'),
+      hide: () => call('app.hide'),
+      show: () => call('app.show'),
+      onReopen: (cb) => on('app.reopen', cb),
+      onOpenFiles: (cb) => on('app.openFiles', cb),
+      onScriptAction: (cb) => on('app.scriptAction', cb),
       version: () => call('app.version'),
       dataDir: () => call('app.dataDir'),
+      paths: () => call('app.paths'),
+      getStartupMetrics: () => call('app.getStartupMetrics'),
+      cacheDir: () => call('app.cacheDir'),
+      setLaunchAtLogin: (enabled) => call('app.setLaunchAtLogin', { enabled: !!enabled }),
+      getLaunchAtLogin: () => call('app.getLaunchAtLogin'),
+      argv: () => call('app.argv'),
+      launchOptions: () => call('app.launchOptions'),
+      isFirstRun: () => call('app.isFirstRun'),
+      launchInfo: () => call('app.launchInfo'),
+      migrate: (migrations) => migrate(migrations || {}),
+    },
+    image: {
+      decode:    (src, opts)                => call('image.decode', Object.assign(imageSource(src), opts || {})),
+      resize:    (src, width, height, opts) => call('image.resize', Object.assign(imageSource(src), { width, height }, opts || {})),
+      convert:   (src, format, opts)        => call('image.convert', Object.assign(imageSource(src), { format }, opts || {})),
+      thumbnail: (src, size, opts)          => call('image.thumbnail', Object.assign(imageSource(src), { size }, opts || {})),
+    },
+    pdf: {
+      fromHTML: (html, opts) => call('pdf.fromHTML', Object.assign({ html }, opts || {})),
+    },
+    codes: {
+      generateQR:      (text, opts) => call('codes.generateQR', Object.assign({ text }, opts || {})),
+      generateBarcode: (text, opts) => call('codes.generateBarcode', Object.assign({ text }, opts || {})),
+    },
+    toolbar: {
+      set:     (items, opts) => call('toolbar.set', Object.assign({ items }, opts || {})),
+      remove:  ()            => call('toolbar.remove'),
+      onClick: (cb)          => on('toolbar.click', cb),
+    },
+    touchBar: {
+      set:     (items) => call('touchBar.set', { items }),
+      remove:  ()      => call('touchBar.remove'),
+      onClick: (cb)    => on('touchBar.click', cb),
+    },
+    power: {
+      preventSleep:      (reason) => call('power.preventSleep', { reason }).then(({ id }) => id),
+      allowSleep:        (id)     => call('power.allowSleep', id ? { id } : {}),
+      isPreventingSleep: ()       => call('power.isPreventingSleep'),
+    },
+    share: (content) => call('share.show', content || {}).then(({ id }) => new Promise(resolve => {
+      const off = on('share.completed', (e) => { if (e.id === id) { off(); resolve(e) } })
+    })),
+    cache: {
+      get:    (key, opts)        => call('cache.get', Object.assign({ key }, opts || {})),
+      put:    (key, data, opts)  => call('cache.put', Object.assign({ key, data }, opts || {})),
+      delete: (key)              => call('cache.delete', { key }),
+      clear:  ()                 => call('cache.clear'),
+      size:   ()                 => call('cache.size'),
+    },
+    // Hot module replacement; lightshell dev with "dev": { "hmr": true }
+    // replaces this with handlers that run when a module changes
+    hmr: {
+      enabled: false,
+      accept:  (mod, cb) => {},
+      dispose: (mod, cb) => {},
     },
     on,
   }
+
+  // Report cold-start milestones (epoch ms) once the first frame after
+  // DOMContentLoaded has painted. The runtime keeps the first report per process.
+  const readyAt = performance.timeOrigin + performance.now()
+  function reportStartup() {
+    requestAnimationFrame(() => setTimeout(() => {
+      const t = performance.timing
+      const fcp = performance.getEntriesByName('first-contentful-paint')[0]
+      call('app.startupMarks', { marks: {
+        loadCommitted: t.responseStart,
+        ready: readyAt,
+        domContentLoaded: t.domContentLoadedEventStart,
+        firstPaint: fcp ? performance.timeOrigin + fcp.startTime : performance.timeOrigin + performance.now(),
+      } }).catch(() => {})
+    }))
+  }
+  if (document.readyState === 'loading') {
+    document.addEventListener('DOMContentLoaded', reportStartup)
+  } else {
+    reportStartup()
+  }
+
+  // Report the system appearance so icons configured in themeIcons switch to
+  // their @dark variants. Apps without themeIcons ignore the report.
+  const darkScheme = window.matchMedia && window.matchMedia('(prefers-color-scheme: dark)')
+  if (darkScheme) {
+    const reportAppearance = () => call('app.appearanceChanged', { dark: darkScheme.matches }).catch(() => {})
+    darkScheme.addEventListener('change', reportAppearance)
+    reportAppearance()
+  }
 })()
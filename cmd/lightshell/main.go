@@ -3,82 +3,103 @@ package main
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/lightshell-dev/lightshell/internal/cli"
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
 )
 
-const version = "0.1.0"
+const version = runtime.Version
 
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
-		os.Exit(1)
+		os.Exit(lserrors.ExitUsage)
 	}
 
 	switch os.Args[1] {
 	case "version", "--version", "-v":
-		fmt.Printf("lightshell %s\n", version)
-	case "init":
-		name := ""
-		template := ""
-		for i := 2; i < len(os.Args); i++ {
-			arg := os.Args[i]
-			if arg == "--template" && i+1 < len(os.Args) {
-				template = os.Args[i+1]
-				i++
-			} else if !strings.HasPrefix(arg, "-") && name == "" {
-				name = arg
+		if len(os.Args) > 2 {
+			if err := cli.Version(os.Args[2:]); err != nil {
+				fail(err)
 			}
+			return
 		}
-		if err := cli.Init(name, template); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		fmt.Printf("lightshell %s\n", version)
+	case "init":
+		if err := cli.Init(os.Args[2:]); err != nil {
+			fail(err)
 		}
 	case "dev":
 		if err := cli.Dev(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			fail(err)
+		}
+	case "console":
+		if err := cli.Console(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "replay":
+		if err := cli.Replay(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "run":
+		if err := cli.Run(os.Args[2:]); err != nil {
+			fail(err)
 		}
 	case "build":
-		if err := cli.Build(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		if err := cli.Build(os.Args[2:]); err != nil {
+			fail(err)
+		}
+	case "icons":
+		if err := cli.Icons(os.Args[2:]); err != nil {
+			fail(err)
 		}
 	case "doctor":
-		if err := cli.Doctor(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		if err := cli.Doctor(os.Args[2:]); err != nil {
+			fail(err)
 		}
 	case "keys":
 		if err := cli.Keys(os.Args[2:]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			fail(err)
 		}
 	case "release":
 		if err := cli.Release(os.Args[2:]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			fail(err)
 		}
 	case "config":
 		if err := cli.Config(os.Args[2:]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			fail(err)
+		}
+	case "self-update":
+		if err := cli.SelfUpdate(os.Args[2:]); err != nil {
+			fail(err)
 		}
 	case "mcp":
 		if err := cli.MCP(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			fail(err)
 		}
 	case "help", "--help", "-h":
 		printUsage()
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", os.Args[1])
 		printUsage()
-		os.Exit(1)
+		os.Exit(lserrors.ExitUsage)
 	}
 }
 
+// fail reports err and exits with the status of its category. Commands run
+// with --json report it on stdout, as a JSON document with an error code.
+func fail(err error) {
+	switch {
+	case cli.Reported(err):
+	case cli.JSONRequested(os.Args[2:]):
+		cli.PrintErrorJSON(err)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	os.Exit(cli.ExitCode(err))
+}
+
 func printUsage() {
 	fmt.Println(`LightShell — Build desktop apps with JavaScript
 
@@ -88,14 +109,39 @@ Usage:
 Commands:
   init [name] [--template react|svelte]
                  Create a new LightShell project
-  dev            Run app with hot reload (dev mode)
+  dev [-- args]  Run app with hot reload (dev mode); args after -- go to the app
+                 (--daemon runs it in the background; dev status|logs|stop;
+                 --record records its control socket commands as a session)
+  console        Evaluate JavaScript interactively in the page of a detached
+                 dev app ([--window id] [--depth n] [--socket path])
+  replay <session>
+                 Replay a recorded session against a fresh dev app and report
+                 the responses that differ ([--realtime])
+  run [path] [-- args]
+                 Run app as built (real permissions, no hot reload or
+                 devtools) without building it
   build          Build app for current platform
+                 (--target default|app|dmg|nsis|appimage|deb|rpm|all,
+                 --platform darwin-arm64,windows-x64 to cross-compile,
+                 --report-size, and on macOS --sign [--notarize])
+  icons [image]  Check the app, window and tray icons and preview them
+                 without building ([--out dir] [--json])
   doctor         Check for cross-platform compatibility issues
+                 (--format text|json, --fail-on error|warning,
+                 --fix [--dry-run],
+                 --baseline | --update-baseline | --no-baseline)
   keys           Manage signing keys (keys generate)
   release        Sign and publish a release
-  config         Get/set global config (config get/set <key> [value])
+  config         Get/set global config (config get/set <key> [value]),
+                 or show lightshell.json (config show [--resolved] [--json])
+  self-update    Update lightshell itself ([--channel stable|beta] [--check]
+                 [--force], or --rollback to the previous version)
   mcp            Run MCP server for AI-assisted development
-  version        Print version
+  version        Print version, or update the app version
+                 (version set <x.y.z> | version bump <patch|minor|major> [--tag])
+
+init, build, doctor, icons, keys, release and config take --json to print
+their result, or their error, as a JSON document on stdout.
 
 Run 'lightshell help' for more information.`)
 }
@@ -86,6 +86,8 @@ export default defineConfig({
             { label: 'Error Handling', slug: 'guides/error-handling' },
             { label: 'React & Svelte', slug: 'guides/frameworks' },
             { label: 'Custom Go Handlers', slug: 'guides/custom-handlers' },
+            { label: 'Embedding in Go', slug: 'guides/go-sdk' },
+            { label: 'Custom MCP Tools', slug: 'guides/mcp-tools' },
             { label: 'Cross-Platform', slug: 'guides/cross-platform' },
             { label: 'Migrate from Electron', slug: 'guides/migration-from-electron' },
             { label: 'Migrate from Neutralinojs', slug: 'guides/migration-from-neutralinojs' },
@@ -100,6 +102,7 @@ export default defineConfig({
             { label: 'AppImage', slug: 'guides/packaging/appimage' },
             { label: '.deb Package', slug: 'guides/packaging/deb' },
             { label: '.rpm Package', slug: 'guides/packaging/rpm' },
+            { label: 'Windows Installer', slug: 'guides/packaging/nsis' },
             { label: 'Code Signing', slug: 'guides/packaging/code-signing' },
             { label: 'App Icons', slug: 'guides/packaging/icons' },
             { label: 'App Identifier', slug: 'guides/packaging/app-id' },
@@ -140,6 +143,14 @@ export default defineConfig({
             { label: 'Process', slug: 'api/process' },
             { label: 'Shortcuts', slug: 'api/shortcuts' },
             { label: 'Updater', slug: 'api/updater' },
+            { label: 'Cache', slug: 'api/cache' },
+            { label: 'Image', slug: 'api/image' },
+            { label: 'PDF', slug: 'api/pdf' },
+            { label: 'Share', slug: 'api/share' },
+            { label: 'Codes', slug: 'api/codes' },
+            { label: 'Toolbar', slug: 'api/toolbar' },
+            { label: 'Power', slug: 'api/power' },
+            { label: 'Permissions', slug: 'api/permissions' },
             { label: 'Events', slug: 'api/events' },
             { label: 'Configuration', slug: 'api/config' },
             { label: 'CLI', slug: 'api/cli' },
@@ -0,0 +1,185 @@
+// Package accel parses the app-wide keyboard accelerators declared in
+// lightshell.json and renders them as a script the client runtime matches
+// keydown events against. The same bindings work in dev and built apps.
+package accel
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Built-in actions. Any other action is a custom event emitted to
+// lightshell.on listeners.
+const (
+	ActionReload         = "reload"
+	ActionToggleDevtools = "toggleDevtools"
+	ActionQuit           = "quit"
+)
+
+// Binding is a parsed accelerator. Code is the KeyboardEvent.code of the
+// non-modifier key, so matching does not depend on keyboard layout or on the
+// character Shift or Option produce.
+type Binding struct {
+	Accelerator string `json:"accelerator"` // as written in lightshell.json
+	Code        string `json:"code"`
+	Meta        bool   `json:"meta"`
+	Ctrl        bool   `json:"ctrl"`
+	Alt         bool   `json:"alt"`
+	Shift       bool   `json:"shift"`
+	Action      string `json:"action"`
+}
+
+// namedCodes maps accepted key names (lowercased) to KeyboardEvent.code.
+var namedCodes = map[string]string{
+	"space": "Space", "tab": "Tab", "enter": "Enter", "return": "Enter",
+	"backspace": "Backspace", "delete": "Delete", "insert": "Insert",
+	"escape": "Escape", "esc": "Escape",
+	"up": "ArrowUp", "down": "ArrowDown", "left": "ArrowLeft", "right": "ArrowRight",
+	"home": "Home", "end": "End", "pageup": "PageUp", "pagedown": "PageDown",
+	",": "Comma", ".": "Period", "/": "Slash", ";": "Semicolon", "'": "Quote",
+	"[": "BracketLeft", "]": "BracketRight", "\\": "Backslash", "`": "Backquote",
+	"-": "Minus", "=": "Equal", "plus": "Equal",
+}
+
+// Parse parses an accelerator such as "CmdOrCtrl+Shift+R" for goos.
+// CmdOrCtrl (or CommandOrControl) is Cmd on darwin and Ctrl elsewhere.
+func Parse(accelerator, goos string) (Binding, error) {
+	b := Binding{Accelerator: accelerator}
+	parts := strings.Split(accelerator, "+")
+	// "CmdOrCtrl++" names the plus key itself
+	if strings.HasSuffix(accelerator, "++") {
+		parts = append(parts[:len(parts)-2], "plus")
+	}
+	for i, part := range parts {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if i < len(parts)-1 {
+			if err := b.setModifier(name, goos); err != nil {
+				return Binding{}, fmt.Errorf("accelerator %q: %w", accelerator, err)
+			}
+			continue
+		}
+		code, err := keyCode(name)
+		if err != nil {
+			return Binding{}, fmt.Errorf("accelerator %q: %w", accelerator, err)
+		}
+		b.Code = code
+	}
+	return b, nil
+}
+
+func (b *Binding) setModifier(name, goos string) error {
+	switch name {
+	case "cmdorctrl", "commandorcontrol":
+		if goos == "darwin" {
+			b.Meta = true
+		} else {
+			b.Ctrl = true
+		}
+	case "cmd", "command", "meta", "super":
+		b.Meta = true
+	case "ctrl", "control":
+		b.Ctrl = true
+	case "alt", "option":
+		b.Alt = true
+	case "shift":
+		b.Shift = true
+	case "":
+		return fmt.Errorf("empty modifier")
+	default:
+		return fmt.Errorf("unknown modifier %q", name)
+	}
+	return nil
+}
+
+func keyCode(name string) (string, error) {
+	if code, ok := namedCodes[name]; ok {
+		return code, nil
+	}
+	if len(name) == 1 {
+		switch c := name[0]; {
+		case c >= 'a' && c <= 'z':
+			return "Key" + strings.ToUpper(name), nil
+		case c >= '0' && c <= '9':
+			return "Digit" + name, nil
+		}
+	}
+	if n, ok := strings.CutPrefix(name, "f"); ok {
+		var num int
+		if _, err := fmt.Sscanf(n, "%d", &num); err == nil && fmt.Sprint(num) == n && num >= 1 && num <= 24 {
+			return "F" + n, nil
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("missing key")
+	}
+	return "", fmt.Errorf("unknown key %q", name)
+}
+
+// Compile parses every accelerator -> action entry and returns the bindings
+// sorted by accelerator. Two accelerators that resolve to the same keys on
+// goos are an error, as is an empty action.
+func Compile(accelerators map[string]string, goos string) ([]Binding, error) {
+	keys := make([]string, 0, len(accelerators))
+	for k := range accelerators {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	seen := make(map[Binding]string, len(keys))
+	bindings := make([]Binding, 0, len(keys))
+	for _, k := range keys {
+		b, err := Parse(k, goos)
+		if err != nil {
+			return nil, err
+		}
+		b.Action = strings.TrimSpace(accelerators[k])
+		if b.Action == "" {
+			return nil, fmt.Errorf("accelerator %q: action is empty", k)
+		}
+		keysOnly := Binding{Code: b.Code, Meta: b.Meta, Ctrl: b.Ctrl, Alt: b.Alt, Shift: b.Shift}
+		if prev, ok := seen[keysOnly]; ok {
+			return nil, fmt.Errorf("accelerators %q and %q are the same keys", prev, k)
+		}
+		seen[keysOnly] = k
+		bindings = append(bindings, b)
+	}
+	return bindings, nil
+}
+
+// Script returns JavaScript that publishes bindings to the client runtime.
+// It must be injected before lightshell.js.
+func Script(bindings []Binding) string {
+	if bindings == nil {
+		bindings = []Binding{}
+	}
+	data, _ := json.Marshal(bindings)
+	return "window.__lightshell_accelerators = " + string(data) + ";"
+}
+
+// macFunctionKeys are the NSEvent function-key characters for named codes.
+var macFunctionKeys = map[string]rune{
+	"ArrowUp": 0xF700, "ArrowDown": 0xF701, "ArrowLeft": 0xF702, "ArrowRight": 0xF703,
+	"Insert": 0xF727, "Delete": 0xF728, "Home": 0xF729, "End": 0xF72B,
+	"PageUp": 0xF72C, "PageDown": 0xF72D,
+	"Enter": '\r', "Tab": '\t', "Space": ' ', "Backspace": 0x08, "Escape": 0x1B,
+	"Comma": ',', "Period": '.', "Slash": '/', "Semicolon": ';', "Quote": '\'',
+	"BracketLeft": '[', "BracketRight": ']', "Backslash": '\\', "Backquote": '`',
+	"Minus": '-', "Equal": '=',
+}
+
+// KeyEquivalent returns the NSMenuItem keyEquivalent character for b's key.
+func (b Binding) KeyEquivalent() string {
+	switch {
+	case strings.HasPrefix(b.Code, "Key"):
+		return strings.ToLower(b.Code[3:])
+	case strings.HasPrefix(b.Code, "Digit"):
+		return b.Code[5:]
+	case len(b.Code) > 1 && b.Code[0] == 'F' && b.Code[1] >= '1' && b.Code[1] <= '9':
+		var n int
+		fmt.Sscanf(b.Code[1:], "%d", &n)
+		return string(rune(0xF704 + n - 1)) // NSF1FunctionKey
+	}
+	return string(macFunctionKeys[b.Code])
+}
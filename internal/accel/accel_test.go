@@ -0,0 +1,107 @@
+package accel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		accel string
+		goos  string
+		want  Binding
+	}{
+		{"CmdOrCtrl+R", "darwin", Binding{Code: "KeyR", Meta: true}},
+		{"CmdOrCtrl+R", "linux", Binding{Code: "KeyR", Ctrl: true}},
+		{"CommandOrControl+Shift+I", "linux", Binding{Code: "KeyI", Ctrl: true, Shift: true}},
+		{"Alt+F4", "linux", Binding{Code: "F4", Alt: true}},
+		{"Option+Cmd+1", "darwin", Binding{Code: "Digit1", Alt: true, Meta: true}},
+		{"F12", "darwin", Binding{Code: "F12"}},
+		{"Ctrl+Space", "darwin", Binding{Code: "Space", Ctrl: true}},
+		{"CmdOrCtrl+,", "darwin", Binding{Code: "Comma", Meta: true}},
+		{"CmdOrCtrl++", "linux", Binding{Code: "Equal", Ctrl: true}},
+		{"shift+escape", "linux", Binding{Code: "Escape", Shift: true}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.accel, tt.goos)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.accel, err)
+			continue
+		}
+		tt.want.Accelerator = tt.accel
+		if got != tt.want {
+			t.Errorf("Parse(%q, %s) = %+v, want %+v", tt.accel, tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, accel := range []string{"", "CmdOrCtrl+", "Hyper+K", "Ctrl+F25", "Ctrl+F01", "Ctrl+Foo", "Ctrl++Shift+K"} {
+		if _, err := Parse(accel, "linux"); err == nil {
+			t.Errorf("Parse(%q) should fail", accel)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	bindings, err := Compile(map[string]string{
+		"CmdOrCtrl+Shift+P": "openPalette",
+		"CmdOrCtrl+R":       "reload",
+	}, "darwin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bindings) != 2 {
+		t.Fatalf("expected 2 bindings, got %d", len(bindings))
+	}
+	if bindings[0].Accelerator != "CmdOrCtrl+R" || bindings[0].Action != ActionReload {
+		t.Errorf("unexpected first binding: %+v", bindings[0])
+	}
+	if bindings[1].Action != "openPalette" || !bindings[1].Shift {
+		t.Errorf("unexpected second binding: %+v", bindings[1])
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	if _, err := Compile(map[string]string{"Cmd+R": "reload", "CmdOrCtrl+R": "refresh"}, "darwin"); err == nil {
+		t.Error("expected duplicate keys to fail on darwin")
+	}
+	if _, err := Compile(map[string]string{"Cmd+R": "reload", "CmdOrCtrl+R": "refresh"}, "linux"); err != nil {
+		t.Errorf("Cmd+R and Ctrl+R are distinct on linux: %v", err)
+	}
+	if _, err := Compile(map[string]string{"F5": " "}, "linux"); err == nil {
+		t.Error("expected empty action to fail")
+	}
+}
+
+func TestScript(t *testing.T) {
+	if got := Script(nil); got != "window.__lightshell_accelerators = [];" {
+		t.Errorf("unexpected empty script: %s", got)
+	}
+	bindings, _ := Compile(map[string]string{"F5": "reload"}, "linux")
+	got := Script(bindings)
+	if !strings.Contains(got, `"code":"F5"`) || !strings.Contains(got, `"action":"reload"`) {
+		t.Errorf("unexpected script: %s", got)
+	}
+}
+
+func TestKeyEquivalent(t *testing.T) {
+	tests := map[string]string{
+		"Cmd+S":     "s",
+		"Cmd+5":     "5",
+		"F1":        "\uF704",
+		"F12":       "\uF70F",
+		"Cmd+,":     ",",
+		"Cmd+Up":    "\uF700",
+		"Cmd+Enter": "\r",
+	}
+	for accel, want := range tests {
+		b, err := Parse(accel, "darwin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := b.KeyEquivalent(); got != want {
+			t.Errorf("KeyEquivalent(%q) = %q, want %q", accel, got, want)
+		}
+	}
+}
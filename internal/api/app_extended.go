@@ -7,15 +7,36 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/lightshell-dev/lightshell/internal/autostart"
 	"github.com/lightshell-dev/lightshell/internal/ipc"
 )
 
 // RegisterAppExtended registers extended app API handlers.
-// These include badge count, second instance detection, and protocol handling.
+// These include badge count, launch at login, second instance detection, and
+// protocol handling.
 func RegisterAppExtended(router *ipc.Router, appName string) {
 	router.Handle("app.setBadgeCount", handleAppSetBadgeCount)
 
+	router.Handle("app.setLaunchAtLogin", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Enabled bool `json:"enabled"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		status, err := changeLaunchAtLogin(appName, p.Enabled)
+		if err != nil {
+			return nil, err
+		}
+		return launchAtLoginResult(status), nil
+	})
+
+	router.Handle("app.getLaunchAtLogin", func(params json.RawMessage) (any, error) {
+		return launchAtLoginResult(launchAtLoginStatus(appName)), nil
+	})
+
 	// Second instance detection via Unix domain socket lockfile
 	router.Handle("app.enableSingleInstance", func(params json.RawMessage) (any, error) {
 		sockPath := singleInstanceSocketPath(appName)
@@ -62,6 +83,34 @@ func RegisterAppExtended(router *ipc.Router, appName string) {
 	})
 }
 
+// changeLaunchAtLogin turns launch at login on or off and records the
+// change, even a failed one, so lightshell doctor can explain it.
+func changeLaunchAtLogin(appName string, enabled bool) (string, error) {
+	status, err := setLaunchAtLogin(appName, enabled)
+	state := autostart.State{Enabled: enabled, Status: status, UpdatedAt: time.Now()}
+	if err != nil {
+		state.Error = err.Error()
+	}
+	autostart.SaveState(autostart.StatePath(appName), state)
+	return status, err
+}
+
+// DefaultLaunchAtLogin turns launch at login on for an app whose
+// lightshell.json sets launchAtLogin, until the user has made a choice
+// through app.setLaunchAtLogin. Only an installed app should call it: the
+// login item is the running executable.
+func DefaultLaunchAtLogin(appName string) {
+	if _, err := os.Stat(autostart.StatePath(appName)); os.IsNotExist(err) {
+		changeLaunchAtLogin(appName, true)
+	}
+}
+
+// launchAtLoginResult is the JS-facing form of a launch-at-login status.
+// An item awaiting approval is registered but will not launch yet.
+func launchAtLoginResult(status string) map[string]any {
+	return map[string]any{"enabled": status == autostart.StatusEnabled, "status": status}
+}
+
 func singleInstanceSocketPath(appName string) string {
 	return filepath.Join(os.TempDir(), fmt.Sprintf("lightshell-%s.sock", appName))
 }
@@ -4,15 +4,21 @@ package api
 
 /*
 #cgo darwin CFLAGS: -x objective-c
-#cgo darwin LDFLAGS: -framework Cocoa
+#cgo darwin LDFLAGS: -framework Cocoa -framework ServiceManagement
 
 #include <stdlib.h>
 
 extern void AppSetBadgeCount(int count);
+extern int AppLaunchAtLoginStatus(void);
+extern char* AppSetLaunchAtLogin(int enabled);
 */
 import "C"
 import (
 	"encoding/json"
+	"errors"
+	"unsafe"
+
+	"github.com/lightshell-dev/lightshell/internal/autostart"
 )
 
 func handleAppSetBadgeCount(params json.RawMessage) (any, error) {
@@ -25,3 +31,31 @@ func handleAppSetBadgeCount(params json.RawMessage) (any, error) {
 	C.AppSetBadgeCount(C.int(p.Count))
 	return nil, nil
 }
+
+// setLaunchAtLogin registers or unregisters the app with SMAppService. In
+// lightshell dev there is no app bundle, so it fails with an explanation.
+func setLaunchAtLogin(appName string, enabled bool) (string, error) {
+	flag := 0
+	if enabled {
+		flag = 1
+	}
+	if cErr := C.AppSetLaunchAtLogin(C.int(flag)); cErr != nil {
+		defer C.free(unsafe.Pointer(cErr))
+		return launchAtLoginStatus(appName), errors.New(C.GoString(cErr))
+	}
+	return launchAtLoginStatus(appName), nil
+}
+
+func launchAtLoginStatus(appName string) string {
+	switch C.AppLaunchAtLoginStatus() {
+	case 0:
+		return autostart.StatusDisabled
+	case 1:
+		return autostart.StatusEnabled
+	case 2:
+		return autostart.StatusRequiresApproval
+	case 3:
+		return autostart.StatusNotFound
+	}
+	return autostart.StatusUnsupported
+}
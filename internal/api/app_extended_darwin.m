@@ -1,4 +1,5 @@
 #import <Cocoa/Cocoa.h>
+#import <ServiceManagement/ServiceManagement.h>
 
 void AppSetBadgeCount(int count) {
     dispatch_async(dispatch_get_main_queue(), ^{
@@ -9,3 +10,36 @@ void AppSetBadgeCount(int count) {
         }
     });
 }
+
+// launchAtLoginStatus values, matching SMAppServiceStatus with -1 for
+// systems before macOS 13.
+static int launchAtLoginStatus(void) {
+    if (@available(macOS 13.0, *)) {
+        return (int)[SMAppService mainAppService].status;
+    }
+    return -1;
+}
+
+// AppLaunchAtLoginStatus returns the login item status of this app.
+int AppLaunchAtLoginStatus(void) {
+    return launchAtLoginStatus();
+}
+
+// AppSetLaunchAtLogin registers or unregisters this app as a login item.
+// Returns NULL on success, or an error message the caller must free.
+char* AppSetLaunchAtLogin(int enabled) {
+    if ([[NSBundle mainBundle] bundleIdentifier] == nil) {
+        return strdup("launch at login requires a bundled app; run lightshell build");
+    }
+    if (@available(macOS 13.0, *)) {
+        NSError *error = nil;
+        SMAppService *service = [SMAppService mainAppService];
+        BOOL ok = enabled ? [service registerAndReturnError:&error] : [service unregisterAndReturnError:&error];
+        // Unregistering an item that was never registered is not a failure
+        if (!ok && !(enabled == 0 && service.status == SMAppServiceStatusNotRegistered)) {
+            return strdup([[error localizedDescription] UTF8String] ?: "login item registration failed");
+        }
+        return NULL;
+    }
+    return strdup("launch at login requires macOS 13 or later");
+}
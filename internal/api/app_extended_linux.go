@@ -5,8 +5,43 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+
+	"github.com/lightshell-dev/lightshell/internal/autostart"
 )
 
 func handleAppSetBadgeCount(params json.RawMessage) (any, error) {
 	return nil, fmt.Errorf("app.setBadgeCount not yet implemented on linux")
 }
+
+// setLaunchAtLogin adds or removes an XDG autostart entry that runs the
+// current command line from the current directory.
+func setLaunchAtLogin(appName string, enabled bool) (string, error) {
+	path := autostart.DesktopFilePath(appName)
+	if !enabled {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return launchAtLoginStatus(appName), fmt.Errorf("launch at login: %w", err)
+		}
+		return autostart.StatusDisabled, nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return autostart.StatusDisabled, fmt.Errorf("launch at login: %w", err)
+	}
+	dir, _ := os.Getwd()
+	entry := autostart.Entry{Name: appName, Exec: exe, Args: os.Args[1:], Dir: dir}
+	if err := autostart.WriteDesktopFile(path, entry); err != nil {
+		return launchAtLoginStatus(appName), fmt.Errorf("launch at login: %w", err)
+	}
+	return autostart.StatusEnabled, nil
+}
+
+// launchAtLoginStatus reports whether an active autostart entry exists.
+func launchAtLoginStatus(appName string) string {
+	d, err := autostart.ReadDesktopFile(autostart.DesktopFilePath(appName))
+	if err != nil || d.Hidden || !d.Enabled {
+		return autostart.StatusDisabled
+	}
+	return autostart.StatusEnabled
+}
@@ -0,0 +1,90 @@
+//go:build windows
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/lightshell-dev/lightshell/internal/autostart"
+)
+
+var (
+	advapi32           = syscall.NewLazyDLL("advapi32.dll")
+	procRegCreateKeyEx = advapi32.NewProc("RegCreateKeyExW")
+	procRegSetValueEx  = advapi32.NewProc("RegSetValueExW")
+	procRegDeleteValue = advapi32.NewProc("RegDeleteValueW")
+)
+
+// runKey is the per-user list of commands Windows starts at sign-in.
+const runKey = `Software\Microsoft\Windows\CurrentVersion\Run`
+
+func handleAppSetBadgeCount(params json.RawMessage) (any, error) {
+	return nil, fmt.Errorf("app.setBadgeCount not yet implemented on windows")
+}
+
+// setLaunchAtLogin adds or removes a Run entry, named after the app, that
+// runs the current command line. Run entries start in the user's profile
+// directory rather than the current one.
+func setLaunchAtLogin(appName string, enabled bool) (string, error) {
+	key, err := openRunKey()
+	if err != nil {
+		return autostart.StatusDisabled, fmt.Errorf("launch at login: %w", err)
+	}
+	defer syscall.RegCloseKey(key)
+	name, _ := syscall.UTF16PtrFromString(appName)
+
+	if !enabled {
+		r, _, _ := procRegDeleteValue.Call(uintptr(key), uintptr(unsafe.Pointer(name)))
+		if r != 0 && syscall.Errno(r) != syscall.ERROR_FILE_NOT_FOUND {
+			return launchAtLoginStatus(appName), fmt.Errorf("launch at login: %w", syscall.Errno(r))
+		}
+		return autostart.StatusDisabled, nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return autostart.StatusDisabled, fmt.Errorf("launch at login: %w", err)
+	}
+	parts := []string{syscall.EscapeArg(exe)}
+	for _, arg := range os.Args[1:] {
+		parts = append(parts, syscall.EscapeArg(arg))
+	}
+	data, _ := syscall.UTF16FromString(strings.Join(parts, " "))
+	r, _, _ := procRegSetValueEx.Call(uintptr(key), uintptr(unsafe.Pointer(name)), 0, syscall.REG_SZ,
+		uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)*2))
+	if r != 0 {
+		return launchAtLoginStatus(appName), fmt.Errorf("launch at login: %w", syscall.Errno(r))
+	}
+	return autostart.StatusEnabled, nil
+}
+
+// launchAtLoginStatus reports whether a Run entry for the app exists.
+func launchAtLoginStatus(appName string) string {
+	var key syscall.Handle
+	path, _ := syscall.UTF16PtrFromString(runKey)
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_CURRENT_USER, path, 0, syscall.KEY_READ, &key); err != nil {
+		return autostart.StatusDisabled
+	}
+	defer syscall.RegCloseKey(key)
+	name, _ := syscall.UTF16PtrFromString(appName)
+	if err := syscall.RegQueryValueEx(key, name, nil, nil, nil, nil); err != nil {
+		return autostart.StatusDisabled
+	}
+	return autostart.StatusEnabled
+}
+
+func openRunKey() (syscall.Handle, error) {
+	var key syscall.Handle
+	path, _ := syscall.UTF16PtrFromString(runKey)
+	r, _, _ := procRegCreateKeyEx.Call(uintptr(syscall.HKEY_CURRENT_USER), uintptr(unsafe.Pointer(path)), 0, 0, 0,
+		uintptr(syscall.KEY_READ|syscall.KEY_WRITE), 0, uintptr(unsafe.Pointer(&key)), 0)
+	if r != 0 {
+		return 0, syscall.Errno(r)
+	}
+	return key, nil
+}
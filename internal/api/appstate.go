@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/appstate"
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+)
+
+// RegisterAppState records this launch and registers the first-run and
+// migration handlers. migrations maps app versions to migration modules
+// declared in lightshell.json; the client runs them along with any passed
+// to lightshell.app.migrate.
+func RegisterAppState(router *ipc.Router, appName, version string, migrations map[string]string) {
+	path := appstate.Path(appName)
+	launch, recordErr := appstate.Record(path, version)
+	if migrations == nil {
+		migrations = map[string]string{}
+	}
+	var mu sync.Mutex
+
+	router.Handle("app.isFirstRun", func(params json.RawMessage) (any, error) {
+		if recordErr != nil {
+			return nil, recordErr
+		}
+		return launch.IsFirstRun, nil
+	})
+
+	router.Handle("app.launchInfo", func(params json.RawMessage) (any, error) {
+		if recordErr != nil {
+			return nil, recordErr
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return map[string]any{
+			"isFirstRun":      launch.IsFirstRun,
+			"previousVersion": launch.PreviousVersion,
+			"version":         launch.Version,
+			"migratedVersion": launch.MigratedVersion,
+			"migrations":      migrations,
+		}, nil
+	})
+
+	router.Handle("app.setMigratedVersion", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Version == "" {
+			return nil, fmt.Errorf("app.setMigratedVersion: version is required")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err := appstate.SetMigrated(path, p.Version); err != nil {
+			return nil, err
+		}
+		launch.MigratedVersion = p.Version
+		return nil, nil
+	})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/cache"
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/paths"
+)
+
+// RegisterCache registers the app cache directory and managed cache handlers.
+// The cache lives in the app's own cache dir, so no permission is required.
+// maxBytes <= 0 uses cache.DefaultMaxBytes.
+func RegisterCache(router *ipc.Router, appName string, maxBytes int64) {
+	dir := paths.CacheDir(appName)
+
+	// The cache dir is created lazily on first use.
+	var mu sync.Mutex
+	var c *cache.Cache
+	open := func() (*cache.Cache, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if c != nil {
+			return c, nil
+		}
+		var err error
+		c, err = cache.New(dir, maxBytes)
+		return c, err
+	}
+
+	router.Handle("app.cacheDir", func(params json.RawMessage) (any, error) {
+		if _, err := open(); err != nil {
+			return nil, err
+		}
+		return dir, nil
+	})
+
+	router.Handle("cache.put", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Key      string `json:"key"`
+			Data     string `json:"data"`
+			TTL      int64  `json:"ttl"` // milliseconds; 0 = no expiry
+			Encoding string `json:"encoding"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Key == "" {
+			return nil, fmt.Errorf("cache.put: key is required")
+		}
+		data := []byte(p.Data)
+		if p.Encoding == "base64" {
+			decoded, err := base64.StdEncoding.DecodeString(p.Data)
+			if err != nil {
+				return nil, fmt.Errorf("cache.put: invalid base64 data: %w", err)
+			}
+			data = decoded
+		}
+		c, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.Put(p.Key, data, time.Duration(p.TTL)*time.Millisecond)
+	})
+
+	router.Handle("cache.get", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Key      string `json:"key"`
+			Encoding string `json:"encoding"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		c, err := open()
+		if err != nil {
+			return nil, err
+		}
+		data, ok, err := c.Get(p.Key)
+		if err != nil || !ok {
+			return nil, err
+		}
+		if p.Encoding == "base64" {
+			return base64.StdEncoding.EncodeToString(data), nil
+		}
+		return string(data), nil
+	})
+
+	router.Handle("cache.delete", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Key string `json:"key"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		c, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.Delete(p.Key)
+	})
+
+	router.Handle("cache.clear", func(params json.RawMessage) (any, error) {
+		c, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.Clear()
+	})
+
+	router.Handle("cache.size", func(params json.RawMessage) (any, error) {
+		c, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return c.Size()
+	})
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func handleClipboardRead(params json.RawMessage) (any, error) {
+	return nil, fmt.Errorf("clipboard.read not yet implemented on windows")
+}
+
+func handleClipboardWrite(params json.RawMessage) (any, error) {
+	return nil, fmt.Errorf("clipboard.write not yet implemented on windows")
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/lightshell-dev/lightshell/internal/codes"
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+// codeColors are the optional CSS hex colors of a generated code.
+type codeColors struct {
+	Color      string `json:"color"`
+	Background string `json:"background"`
+}
+
+// RegisterCodes registers QR code and barcode generation handlers. Output is
+// PNG by default and follows the same dest/format rules as lightshell.image.
+func RegisterCodes(router *ipc.Router, policy *security.Policy) {
+	router.Handle("codes.generateQR", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermCodes); err != nil {
+			return nil, err
+		}
+		var p struct {
+			imageOutput
+			codeColors
+			Text   string `json:"text"`
+			Level  string `json:"level"`
+			Size   int    `json:"size"`
+			Margin *int   `json:"margin"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Text == "" {
+			return nil, fmt.Errorf("codes.generateQR: text is required")
+		}
+		level, err := codes.ParseLevel(p.Level)
+		if err != nil {
+			return nil, err
+		}
+		colors, err := p.codeColors.parse()
+		if err != nil {
+			return nil, err
+		}
+		if p.Size <= 0 {
+			p.Size = 256
+		}
+		margin := 4 // the quiet zone required by the spec
+		if p.Margin != nil {
+			margin = *p.Margin
+		}
+
+		q, err := codes.EncodeQR(p.Text, level)
+		if err != nil {
+			return nil, err
+		}
+		img := codes.QRImage(q, codes.QRScale(q, p.Size, margin), margin, colors)
+		return writeImageOutput(policy, img, "png", p.imageOutput)
+	})
+
+	router.Handle("codes.generateBarcode", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermCodes); err != nil {
+			return nil, err
+		}
+		var p struct {
+			imageOutput
+			codeColors
+			Text   string `json:"text"`
+			Height int    `json:"height"`
+			Scale  int    `json:"scale"`
+			Margin *int   `json:"margin"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		colors, err := p.codeColors.parse()
+		if err != nil {
+			return nil, err
+		}
+		if p.Height <= 0 {
+			p.Height = 80
+		}
+		if p.Scale <= 0 {
+			p.Scale = 2
+		}
+		margin := 10
+		if p.Margin != nil {
+			margin = *p.Margin
+		}
+
+		modules, err := codes.EncodeCode128(p.Text)
+		if err != nil {
+			return nil, err
+		}
+		img := codes.BarcodeImage(modules, p.Scale, p.Height, margin, colors)
+		return writeImageOutput(policy, img, "png", p.imageOutput)
+	})
+}
+
+func (c codeColors) parse() (codes.Colors, error) {
+	colors := codes.DefaultColors
+	var err error
+	if c.Color != "" {
+		if colors.Foreground, err = parseHexColor(c.Color); err != nil {
+			return colors, err
+		}
+	}
+	if c.Background != "" {
+		if colors.Background, err = parseHexColor(c.Background); err != nil {
+			return colors, err
+		}
+	}
+	return colors, nil
+}
+
+// parseHexColor parses "#rgb", "#rrggbb", or "#rrggbbaa".
+func parseHexColor(s string) (color.NRGBA, error) {
+	h := strings.TrimPrefix(s, "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) == 6 {
+		h += "ff"
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if len(h) != 8 || err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q (use #rgb, #rrggbb, or #rrggbbaa)", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func handleDialogOpen(params json.RawMessage) (any, error) {
+	return nil, fmt.Errorf("dialog.open not yet implemented on windows")
+}
+
+func handleDialogSave(params json.RawMessage) (any, error) {
+	return nil, fmt.Errorf("dialog.save not yet implemented on windows")
+}
+
+func handleDialogMessage(params json.RawMessage) (any, error) {
+	return nil, fmt.Errorf("dialog.message not yet implemented on windows")
+}
+
+func handleDialogConfirm(params json.RawMessage) (any, error) {
+	return nil, fmt.Errorf("dialog.confirm not yet implemented on windows")
+}
+
+func handleDialogPrompt(params json.RawMessage) (any, error) {
+	return nil, fmt.Errorf("dialog.prompt not yet implemented on windows")
+}
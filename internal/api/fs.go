@@ -3,11 +3,16 @@ package api
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/metrics"
 	"github.com/lightshell-dev/lightshell/internal/security"
+	"github.com/lightshell-dev/lightshell/internal/webview"
 )
 
 // RegisterFS registers file system API handlers with security checks.
@@ -30,6 +35,7 @@ func RegisterFS(router *ipc.Router, policy *security.Policy) {
 		if err != nil {
 			return nil, err
 		}
+		metrics.Add(metrics.FSReadBytes, nil, float64(len(data)))
 		switch p.Encoding {
 		case "base64", "binary":
 			return base64.StdEncoding.EncodeToString(data), nil
@@ -56,7 +62,78 @@ func RegisterFS(router *ipc.Router, policy *security.Policy) {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			return nil, err
 		}
-		return nil, os.WriteFile(p.Path, []byte(p.Data), 0o644)
+		if err := os.WriteFile(p.Path, []byte(p.Data), 0o644); err != nil {
+			return nil, err
+		}
+		metrics.Add(metrics.FSWrittenBytes, nil, float64(len(p.Data)))
+		return nil, nil
+	})
+
+	router.Handle("fs.appendFile", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermFS); err != nil {
+			return nil, err
+		}
+		var p struct {
+			Path     string `json:"path"`
+			Data     string `json:"data"`
+			Encoding string `json:"encoding"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if err := policy.CheckFSWrite(p.Path); err != nil {
+			return nil, err
+		}
+		data := []byte(p.Data)
+		if p.Encoding == "base64" || p.Encoding == "binary" {
+			decoded, err := base64.StdEncoding.DecodeString(p.Data)
+			if err != nil {
+				return nil, fmt.Errorf("fs.appendFile: data is not valid base64: %w", err)
+			}
+			data = decoded
+		}
+		if err := os.MkdirAll(filepath.Dir(p.Path), 0o755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(p.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		metrics.Add(metrics.FSWrittenBytes, nil, float64(len(data)))
+		return nil, nil
+	})
+
+	router.Handle("fs.copy", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermFS); err != nil {
+			return nil, err
+		}
+		var p struct {
+			Src  string `json:"src"`
+			Dest string `json:"dest"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if err := policy.CheckFSRead(p.Src); err != nil {
+			return nil, err
+		}
+		if err := policy.CheckFSWrite(p.Dest); err != nil {
+			return nil, err
+		}
+		size, err := copyFile(p.Src, p.Dest)
+		if err != nil {
+			return nil, err
+		}
+		metrics.Add(metrics.FSReadBytes, nil, float64(size))
+		metrics.Add(metrics.FSWrittenBytes, nil, float64(size))
+		return nil, nil
 	})
 
 	router.Handle("fs.readDir", func(params json.RawMessage) (any, error) {
@@ -166,4 +243,162 @@ func RegisterFS(router *ipc.Router, policy *security.Policy) {
 		}
 		return nil, os.Remove(p.Path)
 	})
+
+	var reads readHandles
+
+	router.Handle("fs.openRead", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermFS); err != nil {
+			return nil, err
+		}
+		var p struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if err := policy.CheckFSRead(p.Path); err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(p.Path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("fs.openRead: %s is a directory", p.Path)
+		}
+		handle, err := reads.open(p.Path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"handle": handle, "size": info.Size()}, nil
+	})
+
+	router.Handle("fs.readChunk", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Handle   string `json:"handle"`
+			Size     int    `json:"size"`
+			Encoding string `json:"encoding"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		h, err := reads.get(p.Handle)
+		if err != nil {
+			return nil, err
+		}
+		size := p.Size
+		if size <= 0 {
+			size = defaultReadChunkSize
+		}
+		if size > maxReadChunkSize {
+			size = maxReadChunkSize
+		}
+		data, n, eof, err := h.read(size, p.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		metrics.Add(metrics.FSReadBytes, nil, float64(n))
+		return map[string]any{"data": data, "bytesRead": n, "eof": eof}, nil
+	})
+
+	router.Handle("fs.close", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Handle string `json:"handle"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return reads.close(p.Handle), nil
+	})
+
+	router.OnShutdown(reads.closeAll)
+
+	var watches fsWatches
+
+	router.Handle("fs.watch", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermFS); err != nil {
+			return nil, err
+		}
+		var p struct {
+			Path      string `json:"path"`
+			Recursive bool   `json:"recursive"`
+			Debounce  *int   `json:"debounce"` // milliseconds
+			Window    int    `json:"window"`   // set by the client library
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if err := policy.CheckFSRead(p.Path); err != nil {
+			return nil, err
+		}
+		debounce := defaultFSWatchDebounce
+		if p.Debounce != nil && *p.Debounce >= 0 {
+			debounce = time.Duration(*p.Debounce) * time.Millisecond
+		}
+		if p.Window == 0 {
+			p.Window = webview.MainWindowID
+		}
+
+		w := &fsWatch{window: p.Window, root: filepath.Clean(p.Path), recursive: p.Recursive}
+		w.emit = func(path, kind string) {
+			router.SendEvent("fs.change", map[string]any{"id": w.id, "path": path, "kind": kind})
+		}
+		id, err := watches.add(w, debounce)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"id": id}, nil
+	})
+
+	router.Handle("fs.unwatch", func(params json.RawMessage) (any, error) {
+		var p struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return watches.remove(p.ID), nil
+	})
+
+	// Watches end with the window that started them
+	router.OnWindowRemoved(watches.removeWindow)
+	router.OnShutdown(watches.removeAll)
+}
+
+// copyFile streams src to dest, creating dest's directory. The copy is
+// written beside dest and renamed into place, so a failed copy never
+// leaves a partial file.
+func copyFile(src, dest string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.IsDir() {
+		return 0, fmt.Errorf("fs.copy: %s is a directory", src)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".*.part")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	size, err := io.Copy(tmp, in)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	return size, os.Rename(tmp.Name(), dest)
 }
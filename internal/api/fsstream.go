@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	defaultReadChunkSize = 1 << 20  // 1 MiB
+	maxReadChunkSize     = 16 << 20 // bounds one IPC message
+	// maxOpenReads bounds the handles a page can hold open, so a page
+	// that never calls fs.close cannot exhaust file descriptors.
+	maxOpenReads = 64
+)
+
+// readHandle is a file opened by fs.openRead. pending holds the bytes of a
+// UTF-8 character split across two chunks, which are sent with the next
+// chunk instead.
+type readHandle struct {
+	mu      sync.Mutex
+	file    *os.File
+	pending []byte
+}
+
+// read returns up to size bytes of the file, as text or base64, and
+// whether the end of the file was reached.
+func (h *readHandle) read(size int, encoding string) (data string, n int, eof bool, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buf := make([]byte, size)
+	n, err = io.ReadFull(h.file, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		eof, err = true, nil
+	}
+	if err != nil {
+		return "", 0, false, err
+	}
+	buf = buf[:n]
+
+	switch encoding {
+	case "base64", "binary":
+		return base64.StdEncoding.EncodeToString(buf), n, eof, nil
+	}
+	buf = append(h.pending, buf...)
+	h.pending = nil
+	if !eof {
+		// Hold back a trailing partial character; at the end of the file
+		// there is nothing to complete it, so it is sent as is.
+		cut := len(buf)
+		for i := len(buf) - 1; i >= 0 && i >= len(buf)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(buf[i]) {
+				if !utf8.FullRune(buf[i:]) {
+					cut = i
+				}
+				break
+			}
+		}
+		h.pending = append([]byte(nil), buf[cut:]...)
+		buf = buf[:cut]
+	}
+	return string(buf), n, eof, nil
+}
+
+// readHandles tracks the files opened by fs.openRead.
+type readHandles struct {
+	mu      sync.Mutex
+	next    int
+	handles map[string]*readHandle
+}
+
+func (s *readHandles) open(path string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.handles) >= maxOpenReads {
+		return "", fmt.Errorf("fs.openRead: too many open files (%d); close some with fs.close", maxOpenReads)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	if s.handles == nil {
+		s.handles = map[string]*readHandle{}
+	}
+	s.next++
+	id := "read-" + strconv.Itoa(s.next)
+	s.handles[id] = &readHandle{file: f}
+	return id, nil
+}
+
+func (s *readHandles) get(id string) (*readHandle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.handles[id]
+	if !ok {
+		return nil, fmt.Errorf("fs.readChunk: unknown or closed handle %q", id)
+	}
+	return h, nil
+}
+
+// close closes a handle and reports whether it was open.
+func (s *readHandles) close(id string) bool {
+	s.mu.Lock()
+	h, ok := s.handles[id]
+	delete(s.handles, id)
+	s.mu.Unlock()
+	if ok {
+		h.mu.Lock()
+		h.file.Close()
+		h.mu.Unlock()
+	}
+	return ok
+}
+
+func (s *readHandles) closeAll() {
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.handles))
+	for id := range s.handles {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+	for _, id := range ids {
+		s.close(id)
+	}
+}
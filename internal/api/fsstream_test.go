@@ -0,0 +1,139 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+type readChunk struct {
+	data string
+	n    int
+	eof  bool
+}
+
+func TestReadHandleRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		size     int
+		encoding string
+		want     []readChunk
+	}{
+		{"shorter than a chunk", "abc", 4, "", []readChunk{{"abc", 3, true}}},
+		// The end is only known once a read comes up short, so a file
+		// that fills its last chunk takes one more, empty, read
+		{"exact multiple of the chunk size", "abcd", 2, "", []readChunk{{"ab", 2, false}, {"cd", 2, false}, {"", 0, true}}},
+		{"empty file", "", 4, "", []readChunk{{"", 0, true}}},
+		// The first byte of é is held back until the next chunk
+		{"character split across chunks", "aé", 2, "", []readChunk{{"a", 2, false}, {"é", 1, true}}},
+		{"four-byte character split across chunks", "😀b", 3, "", []readChunk{{"", 3, false}, {"😀b", 2, true}}},
+		{"character ending a chunk", "éa", 2, "", []readChunk{{"é", 2, false}, {"a", 1, true}}},
+		// At the end nothing can complete a partial character
+		{"partial character at the end", "a\xc3", 4, "", []readChunk{{"a\xc3", 2, true}}},
+		{"base64", "\xff\x00\x01", 2, "base64", []readChunk{{"/wA=", 2, false}, {"AQ==", 1, true}}},
+		{"base64 splits no characters", "aé", 2, "base64", []readChunk{{"YcM=", 2, false}, {"qQ==", 1, true}}},
+		{"binary is base64", "ab", 4, "binary", []readChunk{{"YWI=", 2, true}}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "file")
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := &readHandle{file: f}
+		var got []readChunk
+		for i := 0; i < len(tt.want); i++ {
+			data, n, eof, err := h.read(tt.size, tt.encoding)
+			if err != nil {
+				t.Fatalf("%s: read %d: %v", tt.name, i+1, err)
+			}
+			got = append(got, readChunk{data, n, eof})
+		}
+		f.Close()
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d chunks, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: chunk %d = %+v, want %+v", tt.name, i+1, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func newFSReadRouter(t *testing.T, dir string) *ipc.Router {
+	t.Helper()
+	policy := security.NewPolicy([]string{"fs"}, dir, "", false)
+	policy.SetFSScope(security.FSScope{Read: []string{dir + "/**"}})
+	router := ipc.NewRouter()
+	RegisterFS(router, policy)
+	t.Cleanup(router.RunShutdownHooks)
+	return router
+}
+
+func TestFSReadHandleLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	router := newFSReadRouter(t, dir)
+
+	var first string
+	for i := 0; i < maxOpenReads; i++ {
+		resp := callRouter(t, router, "fs.openRead", map[string]any{"path": path})
+		if resp.Error != "" {
+			t.Fatalf("open %d: %s", i+1, resp.Error)
+		}
+		if i == 0 {
+			first = resp.Result.(map[string]any)["handle"].(string)
+		}
+	}
+	resp := callRouter(t, router, "fs.openRead", map[string]any{"path": path})
+	if !strings.Contains(resp.Error, "too many open files") {
+		t.Fatalf("open past the limit: error = %q", resp.Error)
+	}
+
+	// Closing a handle makes room for another
+	if resp := callRouter(t, router, "fs.close", map[string]any{"handle": first}); resp.Result != true {
+		t.Fatalf("close = %v, %q", resp.Result, resp.Error)
+	}
+	if resp := callRouter(t, router, "fs.openRead", map[string]any{"path": path}); resp.Error != "" {
+		t.Errorf("open after a close: %s", resp.Error)
+	}
+}
+
+func TestFSReadClosedHandle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	os.WriteFile(path, []byte("notes"), 0o644)
+	router := newFSReadRouter(t, dir)
+
+	resp := callRouter(t, router, "fs.openRead", map[string]any{"path": path})
+	if resp.Error != "" {
+		t.Fatalf("open failed: %s", resp.Error)
+	}
+	handle := resp.Result.(map[string]any)["handle"]
+	resp = callRouter(t, router, "fs.readChunk", map[string]any{"handle": handle})
+	if resp.Error != "" || resp.Result.(map[string]any)["data"] != "notes" {
+		t.Fatalf("readChunk = %v, %q", resp.Result, resp.Error)
+	}
+
+	if resp := callRouter(t, router, "fs.close", map[string]any{"handle": handle}); resp.Result != true {
+		t.Fatalf("close = %v, %q", resp.Result, resp.Error)
+	}
+	if resp := callRouter(t, router, "fs.readChunk", map[string]any{"handle": handle}); !strings.Contains(resp.Error, "unknown or closed handle") {
+		t.Errorf("read after close: error = %q", resp.Error)
+	}
+	// Closing again reports that nothing was open
+	if resp := callRouter(t, router, "fs.close", map[string]any{"handle": handle}); resp.Result != false {
+		t.Errorf("second close = %v, %q", resp.Result, resp.Error)
+	}
+}
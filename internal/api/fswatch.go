@@ -0,0 +1,287 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/watch"
+)
+
+// defaultFSWatchDebounce is how long a watch waits after a change for more
+// before reporting, so a burst of writes is one event.
+const defaultFSWatchDebounce = 100 * time.Millisecond
+
+// fileState is what a watch records about one path.
+type fileState struct {
+	modTime int64
+	size    int64
+	isDir   bool
+}
+
+// fsEvent is a change reported to the page.
+type fsEvent struct {
+	path string
+	kind string // "create", "modify", or "delete"
+}
+
+// fsWatch watches one file or directory on internal/watch, which uses the
+// OS's change notifications. A directory is watched with its entries, and
+// with every descendant when recursive is set. The watcher only says which
+// paths changed; comparing their state with what the watch knows of them
+// gives the kind of change.
+type fsWatch struct {
+	id        string
+	window    int // the window that started the watch
+	root      string
+	recursive bool
+	emit      func(path, kind string)
+
+	dir     string // the directory the watcher watches: root, or a file's parent
+	watcher *watch.Watcher
+	known   map[string]fileState
+	done    chan struct{}
+}
+
+// start begins watching. A file is watched through its directory, with
+// every other entry skipped; a directory that is not recursive skips what
+// is below its entries.
+func (w *fsWatch) start(debounce time.Duration) error {
+	info, err := os.Stat(w.root)
+	if err != nil {
+		return err
+	}
+	w.dir = w.root
+	var skip func(rel string, isDir bool) bool
+	switch {
+	case !info.IsDir():
+		w.dir = filepath.Dir(w.root)
+		name := filepath.Base(w.root)
+		skip = func(rel string, isDir bool) bool { return rel != name }
+	case !w.recursive:
+		skip = func(rel string, isDir bool) bool { return strings.Contains(rel, "/") }
+	}
+	watcher, err := watch.New(w.dir, watch.Options{Skip: skip, Debounce: max(debounce, time.Millisecond)})
+	if err != nil {
+		return err
+	}
+	// The state is read once the watcher is running, so a change made
+	// right after fs.watch returns is reported.
+	w.watcher = watcher
+	w.known = w.snapshot()
+	w.done = make(chan struct{})
+	go w.run()
+	return nil
+}
+
+func (w *fsWatch) run() {
+	defer close(w.done)
+	for paths := range w.watcher.Changes {
+		var events []fsEvent
+		for _, path := range paths {
+			if path == w.dir {
+				// The watcher reports its root when it may have missed
+				// events, so everything is compared again.
+				current := w.snapshot()
+				changes := diffSnapshots(w.known, current)
+				for _, p := range sortedKeys(changes) {
+					events = append(events, fsEvent{p, changes[p]})
+				}
+				w.known = current
+				continue
+			}
+			after, exists := w.stat(path)
+			events = append(events, applyChange(w.known, path, after, exists)...)
+		}
+		for _, e := range events {
+			w.emit(e.path, e.kind)
+		}
+	}
+}
+
+// stop ends the watch and waits for its last events.
+func (w *fsWatch) stop() {
+	w.watcher.Close()
+	<-w.done
+}
+
+func (w *fsWatch) stat(path string) (fileState, bool) {
+	stat := os.Lstat
+	if path == w.root {
+		stat = os.Stat
+	}
+	info, err := stat(path)
+	if err != nil {
+		return fileState{}, false
+	}
+	return stateOf(info), true
+}
+
+func (w *fsWatch) snapshot() map[string]fileState {
+	files := map[string]fileState{}
+	info, err := os.Stat(w.root)
+	if err != nil {
+		return files
+	}
+	files[w.root] = stateOf(info)
+	if !info.IsDir() {
+		return files
+	}
+	if w.recursive {
+		filepath.Walk(w.root, func(path string, info os.FileInfo, err error) error {
+			if err == nil && path != w.root {
+				files[path] = stateOf(info)
+			}
+			return nil
+		})
+		return files
+	}
+	entries, _ := os.ReadDir(w.root)
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil {
+			files[filepath.Join(w.root, e.Name())] = stateOf(info)
+		}
+	}
+	return files
+}
+
+func stateOf(info os.FileInfo) fileState {
+	return fileState{modTime: info.ModTime().UnixNano(), size: info.Size(), isDir: info.IsDir()}
+}
+
+// diffSnapshots returns the kind of change for each path that differs. A
+// directory whose entries change is not itself reported as modified.
+func diffSnapshots(before, after map[string]fileState) map[string]string {
+	changes := map[string]string{}
+	for path, a := range after {
+		b, ok := before[path]
+		switch {
+		case !ok:
+			changes[path] = "create"
+		case a.isDir != b.isDir:
+			changes[path] = "modify"
+		case !a.isDir && (a.modTime != b.modTime || a.size != b.size):
+			changes[path] = "modify"
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes[path] = "delete"
+		}
+	}
+	return changes
+}
+
+// applyChange records the state of a path the watcher reported, after is
+// its state when exists is set, and returns the events that makes. Since
+// the watcher reports a burst of changes once, a file created and then
+// written is a create, one deleted and created again is a modify, and one
+// created and deleted again is nothing. A deleted directory takes what is
+// known under it along.
+func applyChange(known map[string]fileState, path string, after fileState, exists bool) []fsEvent {
+	before, existed := known[path]
+	switch {
+	case exists && !existed:
+		known[path] = after
+		return []fsEvent{{path, "create"}}
+	case exists:
+		known[path] = after
+		if changes := diffSnapshots(map[string]fileState{path: before}, map[string]fileState{path: after}); changes[path] != "" {
+			return []fsEvent{{path, changes[path]}}
+		}
+		return nil
+	case existed:
+		var events []fsEvent
+		if before.isDir {
+			prefix := path + string(filepath.Separator)
+			var below []string
+			for p := range known {
+				if strings.HasPrefix(p, prefix) {
+					below = append(below, p)
+				}
+			}
+			sort.Strings(below)
+			for _, p := range below {
+				delete(known, p)
+				events = append(events, fsEvent{p, "delete"})
+			}
+		}
+		delete(known, path)
+		return append(events, fsEvent{path, "delete"})
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fsWatches tracks the active watches so they can be stopped by ID, with
+// the window that started them, and all at once on shutdown.
+type fsWatches struct {
+	mu      sync.Mutex
+	next    int
+	watches map[string]*fsWatch
+}
+
+func (s *fsWatches) add(w *fsWatch, debounce time.Duration) (string, error) {
+	s.mu.Lock()
+	s.next++
+	w.id = "watch-" + strconv.Itoa(s.next)
+	s.mu.Unlock()
+
+	if err := w.start(debounce); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watches == nil {
+		s.watches = map[string]*fsWatch{}
+	}
+	s.watches[w.id] = w
+	return w.id, nil
+}
+
+// remove stops a watch and reports whether it existed.
+func (s *fsWatches) remove(id string) bool {
+	s.mu.Lock()
+	w, ok := s.watches[id]
+	delete(s.watches, id)
+	s.mu.Unlock()
+	if ok {
+		w.stop()
+	}
+	return ok
+}
+
+// removeWindow stops the watches a closed window started.
+func (s *fsWatches) removeWindow(window int) {
+	s.removeMatching(func(w *fsWatch) bool { return w.window == window })
+}
+
+func (s *fsWatches) removeAll() {
+	s.removeMatching(func(*fsWatch) bool { return true })
+}
+
+func (s *fsWatches) removeMatching(match func(*fsWatch) bool) {
+	s.mu.Lock()
+	var ids []string
+	for id, w := range s.watches {
+		if match(w) {
+			ids = append(ids, id)
+		}
+	}
+	s.mu.Unlock()
+	for _, id := range ids {
+		s.remove(id)
+	}
+}
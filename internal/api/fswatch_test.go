@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	before := map[string]fileState{
+		"/d":        {isDir: true, modTime: 1},
+		"/d/same":   {modTime: 1, size: 1},
+		"/d/write":  {modTime: 1, size: 1},
+		"/d/grow":   {modTime: 1, size: 1},
+		"/d/gone":   {modTime: 1, size: 1},
+		"/d/became": {modTime: 1, size: 1},
+	}
+	after := map[string]fileState{
+		"/d":        {isDir: true, modTime: 2},
+		"/d/same":   {modTime: 1, size: 1},
+		"/d/write":  {modTime: 2, size: 1},
+		"/d/grow":   {modTime: 1, size: 2},
+		"/d/became": {isDir: true, modTime: 1},
+		"/d/new":    {modTime: 1},
+	}
+	want := map[string]string{
+		"/d/write":  "modify",
+		"/d/grow":   "modify",
+		"/d/gone":   "delete",
+		"/d/became": "modify",
+		"/d/new":    "create",
+	}
+	if got := diffSnapshots(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffSnapshots = %v, want %v", got, want)
+	}
+}
+
+func TestApplyChange(t *testing.T) {
+	file := fileState{modTime: 1, size: 1}
+	dir := fileState{isDir: true, modTime: 1}
+	known := map[string]fileState{
+		"/d":       dir,
+		"/d/a":     file,
+		"/d/sub":   dir,
+		"/d/sub/b": file,
+		"/d/sub/c": file,
+		"/d/subx":  file,
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		after  fileState
+		exists bool
+		want   []fsEvent
+	}{
+		{"unchanged file", "/d/a", file, true, nil},
+		{"written file", "/d/a", fileState{modTime: 2, size: 1}, true, []fsEvent{{"/d/a", "modify"}}},
+		{"new file", "/d/n", file, true, []fsEvent{{"/d/n", "create"}}},
+		{"directory entries changed", "/d/sub", fileState{isDir: true, modTime: 2}, true, nil},
+		{"created and deleted in a burst", "/d/brief", fileState{}, false, nil},
+		{"deleted file", "/d/n", fileState{}, false, []fsEvent{{"/d/n", "delete"}}},
+		{"deleted directory", "/d/sub", fileState{}, false, []fsEvent{{"/d/sub/b", "delete"}, {"/d/sub/c", "delete"}, {"/d/sub", "delete"}}},
+		{"file replaced by a directory", "/d/subx", dir, true, []fsEvent{{"/d/subx", "modify"}}},
+	}
+	for _, tt := range tests {
+		if got := applyChange(known, tt.path, tt.after, tt.exists); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: applyChange = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	want := map[string]fileState{"/d": dir, "/d/a": {modTime: 2, size: 1}, "/d/subx": dir}
+	if !reflect.DeepEqual(known, want) {
+		t.Errorf("known = %v, want %v", known, want)
+	}
+}
+
+// fsEvents collects the fs.change events a router sends.
+type fsEvents chan map[string]any
+
+func (c fsEvents) eval(js string) {
+	var evt ipc.Event
+	if json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(js, "__lightshell_receive("), ")")), &evt) != nil {
+		return
+	}
+	if data, ok := evt.Data.(map[string]any); ok && evt.EventName == "fs.change" {
+		select {
+		case c <- data:
+		default: // never block the watch, or stopping it would hang
+		}
+	}
+}
+
+// next returns the next event, or nil if none arrives in time.
+func (c fsEvents) next(wait time.Duration) map[string]any {
+	select {
+	case e := <-c:
+		return e
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+func newFSWatchRouter(t *testing.T, dir string) (*ipc.Router, fsEvents) {
+	t.Helper()
+	policy := security.NewPolicy([]string{"fs"}, dir, "", false)
+	policy.SetFSScope(security.FSScope{Read: []string{dir + "/**"}})
+	events := make(fsEvents, 64)
+	router := ipc.NewRouter()
+	router.SetEvalFunc(events.eval)
+	RegisterFS(router, policy)
+	t.Cleanup(router.RunShutdownHooks)
+	return router, events
+}
+
+func TestFSWatch(t *testing.T) {
+	dir := t.TempDir()
+	router, events := newFSWatchRouter(t, dir)
+	resp := callRouter(t, router, "fs.watch", map[string]any{"path": dir, "debounce": 10})
+	if resp.Error != "" {
+		t.Fatalf("watch failed: %s", resp.Error)
+	}
+	id := resp.Result.(map[string]any)["id"]
+
+	file := filepath.Join(dir, "notes.txt")
+	expect := func(kind string) {
+		t.Helper()
+		e := events.next(5 * time.Second)
+		if e == nil {
+			t.Fatalf("no %s event", kind)
+		}
+		if e["id"] != id || e["path"] != file || e["kind"] != kind {
+			t.Fatalf("event = %v, want %s of %s", e, kind, file)
+		}
+	}
+	os.WriteFile(file, []byte("a"), 0o644)
+	expect("create")
+	os.WriteFile(file, []byte("ab"), 0o644)
+	expect("modify")
+	os.Remove(file)
+	expect("delete")
+
+	// Entries below a directory are only watched when recursive is set
+	os.Mkdir(filepath.Join(dir, "sub"), 0o755)
+	if e := events.next(5 * time.Second); e == nil || e["kind"] != "create" {
+		t.Fatalf("event = %v, want the directory's create", e)
+	}
+	os.WriteFile(filepath.Join(dir, "sub", "deep.txt"), []byte("a"), 0o644)
+	if e := events.next(300 * time.Millisecond); e != nil {
+		t.Errorf("unexpected event below a directory: %v", e)
+	}
+
+	if resp := callRouter(t, router, "fs.unwatch", map[string]any{"id": id}); resp.Result != true {
+		t.Fatalf("unwatch = %v, %q", resp.Result, resp.Error)
+	}
+	os.WriteFile(file, []byte("a"), 0o644)
+	if e := events.next(300 * time.Millisecond); e != nil {
+		t.Errorf("event after unwatch: %v", e)
+	}
+}
+
+func TestFSWatchEndsWithWindow(t *testing.T) {
+	dir := t.TempDir()
+	router, events := newFSWatchRouter(t, dir)
+	for _, window := range []int{0, 2} {
+		if resp := callRouter(t, router, "fs.watch", map[string]any{"path": dir, "debounce": 10, "window": window}); resp.Error != "" {
+			t.Fatalf("watch failed: %s", resp.Error)
+		}
+	}
+
+	// The watch without a window belongs to the main window, so only it
+	// is left once window 2 closes.
+	router.RemoveWindow(2)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("a"), 0o644)
+	if e := events.next(5 * time.Second); e == nil || e["id"] != "watch-1" {
+		t.Fatalf("event = %v, want one from watch-1", e)
+	}
+	if e := events.next(300 * time.Millisecond); e != nil {
+		t.Errorf("event from a closed window's watch: %v", e)
+	}
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/metrics"
+	"github.com/lightshell-dev/lightshell/internal/proxy"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+const (
+	defaultFetchTimeout = 30 * time.Second
+	// maxRedirects matches net/http's default redirect limit.
+	maxRedirects = 10
+	// downloadProgressInterval limits http.download.progress events so a
+	// fast download does not flood the webview.
+	downloadProgressInterval = 100 * time.Millisecond
+)
+
+// HTTPOptions configures the client behind lightshell.http.
+type HTTPOptions struct {
+	// Proxy routes the requests; the zero value connects directly.
+	Proxy proxy.Settings
+
+	// TLS replaces the default certificate checks, for the CA bundles and
+	// pins of security.tls. Nil keeps the system roots.
+	TLS *tls.Config
+}
+
+// RegisterHTTP registers the CORS-free HTTP client. Requests are made from
+// Go, so the webview's CORS rules do not apply, but each URL is checked
+// against the app's http permission and scope.
+func RegisterHTTP(router *ipc.Router, policy *security.Policy, opts HTTPOptions) {
+	// Downloads have no overall timeout, so fetch bounds each request with
+	// a context instead.
+	client := opts.Proxy.Client(0)
+	if opts.TLS != nil {
+		client.Transport.(*http.Transport).TLSClientConfig = opts.TLS
+	}
+	// Every redirect target must pass the same scope check as the original
+	// URL, otherwise an allowed host could bounce a request anywhere.
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return policy.CheckHTTP(req.URL.String())
+	}
+
+	router.Handle("http.fetch", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermHTTP); err != nil {
+			return nil, err
+		}
+		var p struct {
+			URL     string            `json:"url"`
+			Method  string            `json:"method"`
+			Headers map[string]string `json:"headers"`
+			Body    string            `json:"body"`
+			Timeout int               `json:"timeout"` // milliseconds
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if err := policy.CheckHTTP(p.URL); err != nil {
+			return nil, err
+		}
+
+		timeout := defaultFetchTimeout
+		if p.Timeout > 0 {
+			timeout = time.Duration(p.Timeout) * time.Millisecond
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var body io.Reader
+		if p.Body != "" {
+			body = strings.NewReader(p.Body)
+		}
+		resp, err := doRequest(ctx, client, "fetch", p.Method, p.URL, p.Headers, body)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, requestError("fetch", err)
+		}
+		return map[string]any{
+			"status":  resp.StatusCode,
+			"headers": responseHeaders(resp.Header),
+			"body":    string(data),
+		}, nil
+	})
+
+	router.Handle("http.download", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermHTTP); err != nil {
+			return nil, err
+		}
+		var p struct {
+			URL     string            `json:"url"`
+			SaveTo  string            `json:"saveTo"`
+			Headers map[string]string `json:"headers"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if err := policy.CheckHTTP(p.URL); err != nil {
+			return nil, err
+		}
+		dest, err := downloadPath(policy, p.URL, p.SaveTo)
+		if err != nil {
+			return nil, err
+		}
+		if err := policy.CheckFSWrite(dest); err != nil {
+			return nil, err
+		}
+
+		resp, err := doRequest(context.Background(), client, "download", http.MethodGet, p.URL, p.Headers, nil)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, lserrors.HTTPError("download", lserrors.HTTPRequestFailed, fmt.Sprintf("server responded %s", resp.Status), nil)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return nil, err
+		}
+		// Write to a temporary file first so a failed download never leaves
+		// a truncated file at the destination.
+		tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".*.part")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmp.Name())
+
+		progress := &downloadProgress{router: router, url: p.URL, total: resp.ContentLength}
+		size, err := io.Copy(tmp, io.TeeReader(resp.Body, progress))
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, requestError("download", err)
+		}
+		if err := os.Rename(tmp.Name(), dest); err != nil {
+			return nil, err
+		}
+		progress.send()
+		return map[string]any{"path": dest, "size": size}, nil
+	})
+}
+
+// doRequest sends a request and records it in the http metrics.
+func doRequest(ctx context.Context, client *http.Client, method, httpMethod, rawURL string, headers map[string]string, body io.Reader) (*http.Response, error) {
+	if httpMethod == "" {
+		httpMethod = http.MethodGet
+	}
+	httpMethod = strings.ToUpper(httpMethod)
+	req, err := http.NewRequestWithContext(ctx, httpMethod, rawURL, body)
+	if err != nil {
+		return nil, lserrors.HTTPError(method, lserrors.HTTPRequestFailed, "invalid request", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.Add(metrics.HTTPRequests, metrics.Labels{"method": httpMethod, "status": status}, 1)
+	metrics.Observe(metrics.HTTPDuration, metrics.Labels{"method": httpMethod}, time.Since(start))
+	if err != nil {
+		return nil, requestError(method, err)
+	}
+	return resp, nil
+}
+
+// requestError classifies a failed request as a timeout, a TLS failure, or
+// a general request failure. A redirect refused by the http scope is
+// returned as the permission error itself.
+func requestError(method string, err error) error {
+	var permErr *security.PermissionError
+	if errors.As(err, &permErr) {
+		return permErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return lserrors.HTTPError(method, lserrors.HTTPTimeout, "request timed out", err)
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.Timeout() {
+		return lserrors.HTTPError(method, lserrors.HTTPTimeout, "request timed out", err)
+	}
+	if wrapped := security.WrapTLSError("http", method, err); wrapped != err {
+		return wrapped
+	}
+	return lserrors.HTTPError(method, lserrors.HTTPRequestFailed, "request failed", err)
+}
+
+// responseHeaders flattens headers to one value per name, joining repeated
+// headers with ", " as the Fetch API does.
+func responseHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// downloadPath resolves saveTo, expanding path variables. Without saveTo
+// the file goes to $DOWNLOADS under the URL's file name.
+func downloadPath(policy *security.Policy, rawURL, saveTo string) (string, error) {
+	if saveTo == "" {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", lserrors.HTTPError("download", lserrors.HTTPRequestFailed, "invalid URL", err)
+		}
+		name := path.Base(u.Path)
+		if name == "." || name == "/" {
+			name = "download"
+		}
+		saveTo = "$DOWNLOADS/" + name
+	}
+	dest := policy.ExpandPath(saveTo)
+	if !filepath.IsAbs(dest) {
+		return "", fmt.Errorf("http.download: saveTo must be an absolute path or start with a path variable, got %q", saveTo)
+	}
+	return filepath.Clean(dest), nil
+}
+
+// downloadProgress counts bytes written and emits http.download.progress.
+type downloadProgress struct {
+	router   *ipc.Router
+	url      string
+	total    int64 // -1 when the server sent no Content-Length
+	received int64
+	last     time.Time
+}
+
+func (d *downloadProgress) Write(b []byte) (int, error) {
+	d.received += int64(len(b))
+	if time.Since(d.last) >= downloadProgressInterval {
+		d.send()
+	}
+	return len(b), nil
+}
+
+func (d *downloadProgress) send() {
+	d.last = time.Now()
+	total, percent := d.total, 0
+	if total > 0 {
+		percent = int(d.received * 100 / total)
+	} else {
+		total = 0
+	}
+	d.router.SendEvent("http.download.progress", map[string]any{
+		"url":             d.url,
+		"bytesDownloaded": d.received,
+		"totalBytes":      total,
+		"percent":         percent,
+	})
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/proxy"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+func newHTTPRouter(t *testing.T, scope *security.HTTPScope) *ipc.Router {
+	t.Helper()
+	return newHTTPRouterWith(t, scope, HTTPOptions{})
+}
+
+func newHTTPRouterWith(t *testing.T, scope *security.HTTPScope, opts HTTPOptions) *ipc.Router {
+	t.Helper()
+	policy := security.NewPolicy([]string{"http"}, t.TempDir(), "", false)
+	if scope != nil {
+		policy.SetHTTPScope(*scope)
+	}
+	router := ipc.NewRouter()
+	router.SetEvalFunc(func(string) {})
+	RegisterHTTP(router, policy, opts)
+	return router
+}
+
+func callRouter(t *testing.T, router *ipc.Router, method string, params any) ipc.Response {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"id": "1", "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp ipc.Response
+	if err := json.Unmarshal([]byte(router.HandleMessage(string(data))), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	return resp
+}
+
+func TestHTTPFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Add("X-Multi", "a")
+		w.Header().Add("X-Multi", "b")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %s", r.Header.Get("X-Token"), r.URL.Path)
+	}))
+	defer srv.Close()
+
+	router := newHTTPRouter(t, nil)
+	resp := callRouter(t, router, "http.fetch", map[string]any{
+		"url":     srv.URL + "/items",
+		"method":  "post",
+		"headers": map[string]string{"X-Token": "secret"},
+		"body":    "{}",
+	})
+	if resp.Error != "" {
+		t.Fatalf("fetch failed: %s", resp.Error)
+	}
+	result := resp.Result.(map[string]any)
+	if result["status"] != float64(http.StatusCreated) {
+		t.Errorf("status = %v, want 201", result["status"])
+	}
+	if result["body"] != "secret /items" {
+		t.Errorf("body = %q", result["body"])
+	}
+	headers := result["headers"].(map[string]any)
+	if headers["X-Method"] != "POST" || headers["X-Multi"] != "a, b" {
+		t.Errorf("headers = %v", headers)
+	}
+}
+
+func TestHTTPFetchTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "secure")
+	}))
+	defer srv.Close()
+
+	resp := callRouter(t, newHTTPRouter(t, nil), "http.fetch", map[string]any{"url": srv.URL})
+	if !strings.Contains(resp.Error, "not trusted") {
+		t.Errorf("error = %q, want an untrusted certificate error", resp.Error)
+	}
+
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	conf, err := security.NewTLSConfigPEM([]string{ca}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = callRouter(t, newHTTPRouterWith(t, nil, HTTPOptions{TLS: conf}), "http.fetch", map[string]any{"url": srv.URL})
+	if resp.Error != "" {
+		t.Fatalf("fetch with the server's CA failed: %s", resp.Error)
+	}
+	if body := resp.Result.(map[string]any)["body"]; body != "secure" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestHTTPFetchProxy(t *testing.T) {
+	proxied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "via proxy: %s", r.URL)
+	}))
+	defer proxied.Close()
+
+	opts := HTTPOptions{Proxy: proxy.Settings{HTTP: proxied.URL}}
+	resp := callRouter(t, newHTTPRouterWith(t, nil, opts), "http.fetch", map[string]any{"url": "http://api.example.invalid/items"})
+	if resp.Error != "" {
+		t.Fatalf("fetch through the proxy failed: %s", resp.Error)
+	}
+	if body := resp.Result.(map[string]any)["body"]; body != "via proxy: http://api.example.invalid/items" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestHTTPFetchOutOfScope(t *testing.T) {
+	requested := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer srv.Close()
+
+	router := newHTTPRouter(t, &security.HTTPScope{Allow: []string{"api.example.com"}})
+	resp := callRouter(t, router, "http.fetch", map[string]any{"url": srv.URL})
+	if !strings.Contains(resp.Error, "Permission denied") {
+		t.Errorf("error = %q, want a permission error", resp.Error)
+	}
+	if requested {
+		t.Error("the request reached the server")
+	}
+}
+
+func TestHTTPRedirectsAreChecked(t *testing.T) {
+	var hits []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/next":
+			http.Redirect(w, r, "/api/done", http.StatusFound)
+		case "/api/escape":
+			http.Redirect(w, r, "/admin", http.StatusFound)
+		default:
+			fmt.Fprint(w, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	scope := &security.HTTPScope{Allow: []string{srv.URL + "/api/**"}}
+	router := newHTTPRouter(t, scope)
+
+	resp := callRouter(t, router, "http.fetch", map[string]any{"url": srv.URL + "/api/next"})
+	if resp.Error != "" {
+		t.Fatalf("redirect within scope failed: %s", resp.Error)
+	}
+	if body := resp.Result.(map[string]any)["body"]; body != "/api/done" {
+		t.Errorf("body = %q, want /api/done", body)
+	}
+
+	hits = nil
+	resp = callRouter(t, router, "http.fetch", map[string]any{"url": srv.URL + "/api/escape"})
+	if !strings.Contains(resp.Error, "Permission denied") || !strings.Contains(resp.Error, "/admin") {
+		t.Errorf("error = %q, want a permission error for /admin", resp.Error)
+	}
+	if len(hits) != 1 {
+		t.Errorf("server saw %v, want only the first request", hits)
+	}
+
+	dest := filepath.Join(t.TempDir(), "file.txt")
+	resp = callRouter(t, router, "http.download", map[string]any{"url": srv.URL + "/api/escape", "saveTo": dest})
+	if !strings.Contains(resp.Error, "Permission denied") {
+		t.Errorf("download error = %q, want a permission error", resp.Error)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("download wrote the destination after a refused redirect")
+	}
+}
+
+func TestHTTPDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "file contents")
+	}))
+	defer srv.Close()
+
+	router := newHTTPRouter(t, nil)
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "sub", "file.txt")
+	resp := callRouter(t, router, "http.download", map[string]any{"url": srv.URL + "/file.txt", "saveTo": dest})
+	if resp.Error != "" {
+		t.Fatalf("download failed: %s", resp.Error)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != "file contents" {
+		t.Fatalf("destination = %q, %v", data, err)
+	}
+
+	missing := filepath.Join(dir, "missing.txt")
+	resp = callRouter(t, router, "http.download", map[string]any{"url": srv.URL + "/missing", "saveTo": missing})
+	if !strings.Contains(resp.Error, "404") {
+		t.Errorf("error = %q, want the 404 status", resp.Error)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Error("a failed download created the destination")
+	}
+}
+
+func TestHTTPDownloadRemovesPartialFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Promise more than is sent, then drop the connection.
+		w.Header().Set("Content-Length", "1000")
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	router := newHTTPRouter(t, nil)
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "file.bin")
+	os.WriteFile(dest, []byte("previous"), 0o644)
+
+	resp := callRouter(t, router, "http.download", map[string]any{"url": srv.URL + "/file.bin", "saveTo": dest})
+	if resp.Error == "" {
+		t.Fatal("expected the truncated download to fail")
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "previous" {
+		t.Errorf("destination = %q, want it untouched", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".part") {
+			t.Errorf("left a temporary file behind: %s", e.Name())
+		}
+	}
+}
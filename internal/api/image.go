@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+
+	"github.com/lightshell-dev/lightshell/internal/imaging"
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+// imageSource is where an image operation reads from: a file path (requires
+// the fs permission and read scope) or base64-encoded bytes.
+type imageSource struct {
+	Path string `json:"path"`
+	Data string `json:"data"`
+}
+
+// imageOutput is where an image operation writes to. Without Dest the result
+// is returned as base64.
+type imageOutput struct {
+	Dest    string `json:"dest"`
+	Format  string `json:"format"`
+	Quality int    `json:"quality"`
+}
+
+// RegisterImage registers image processing handlers. They run on the worker
+// pool so large images do not block other IPC calls.
+func RegisterImage(router *ipc.Router, policy *security.Policy) {
+	router.HandlePooled("image.decode", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermImage); err != nil {
+			return nil, err
+		}
+		var p struct {
+			imageSource
+			Pixels bool `json:"pixels"` // include raw RGBA pixels for canvas ImageData
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		data, err := readImageSource(policy, p.imageSource)
+		if err != nil {
+			return nil, err
+		}
+		if !p.Pixels {
+			cfg, format, err := imaging.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"width": cfg.Width, "height": cfg.Height, "format": format}, nil
+		}
+		img, format, err := imaging.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		b := img.Bounds()
+		rgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+		return map[string]any{
+			"width":  b.Dx(),
+			"height": b.Dy(),
+			"format": format,
+			"pixels": base64.StdEncoding.EncodeToString(rgba.Pix),
+		}, nil
+	})
+
+	router.HandlePooled("image.resize", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermImage); err != nil {
+			return nil, err
+		}
+		var p struct {
+			imageSource
+			imageOutput
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+			Fit    string `json:"fit"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		img, format, err := decodeImageSource(policy, p.imageSource)
+		if err != nil {
+			return nil, err
+		}
+		out, err := imaging.Resize(img, p.Width, p.Height, p.Fit)
+		if err != nil {
+			return nil, err
+		}
+		return writeImageOutput(policy, out, format, p.imageOutput)
+	})
+
+	router.HandlePooled("image.convert", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermImage); err != nil {
+			return nil, err
+		}
+		var p struct {
+			imageSource
+			imageOutput
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Format == "" && p.Dest == "" {
+			return nil, fmt.Errorf("image.convert: format or dest is required")
+		}
+		img, format, err := decodeImageSource(policy, p.imageSource)
+		if err != nil {
+			return nil, err
+		}
+		return writeImageOutput(policy, img, format, p.imageOutput)
+	})
+
+	router.HandlePooled("image.thumbnail", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermImage); err != nil {
+			return nil, err
+		}
+		var p struct {
+			imageSource
+			imageOutput
+			Size int `json:"size"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Size <= 0 {
+			p.Size = 256
+		}
+		img, format, err := decodeImageSource(policy, p.imageSource)
+		if err != nil {
+			return nil, err
+		}
+		return writeImageOutput(policy, imaging.Thumbnail(img, p.Size), format, p.imageOutput)
+	})
+}
+
+// readImageSource returns the encoded bytes of src after permission checks.
+func readImageSource(policy *security.Policy, src imageSource) ([]byte, error) {
+	switch {
+	case src.Path != "":
+		if err := policy.Check(security.PermFS); err != nil {
+			return nil, err
+		}
+		if err := policy.CheckFSRead(src.Path); err != nil {
+			return nil, err
+		}
+		return os.ReadFile(src.Path)
+	case src.Data != "":
+		data, err := base64.StdEncoding.DecodeString(src.Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 image data: %w", err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("an image path or base64 data is required")
+}
+
+func decodeImageSource(policy *security.Policy, src imageSource) (image.Image, string, error) {
+	data, err := readImageSource(policy, src)
+	if err != nil {
+		return nil, "", err
+	}
+	return imaging.Decode(bytes.NewReader(data))
+}
+
+// writeImageOutput encodes img and writes it to out.Dest, or returns it as
+// base64. The format defaults to the dest extension, then the source format.
+func writeImageOutput(policy *security.Policy, img image.Image, srcFormat string, out imageOutput) (any, error) {
+	format := out.Format
+	if format == "" && out.Dest != "" {
+		format = filepath.Ext(out.Dest)
+	}
+	if format == "" {
+		format = srcFormat
+	}
+	format, err := imaging.NormalizeFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	data, err := imaging.EncodeBytes(img, format, out.Quality)
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	result := map[string]any{"width": b.Dx(), "height": b.Dy(), "format": format}
+	if out.Dest == "" {
+		result["data"] = base64.StdEncoding.EncodeToString(data)
+		return result, nil
+	}
+
+	if err := policy.Check(security.PermFS); err != nil {
+		return nil, err
+	}
+	if err := policy.CheckFSWrite(out.Dest); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(out.Dest), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(out.Dest, data, 0o644); err != nil {
+		return nil, err
+	}
+	result["path"] = out.Dest
+	result["size"] = len(data)
+	return result, nil
+}
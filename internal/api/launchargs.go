@@ -0,0 +1,24 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/launchargs"
+)
+
+// RegisterLaunchArgs registers app.argv and app.launchOptions. argv is the
+// app's own arguments, without the program name; launch is argv parsed
+// against the launchArgs schema in lightshell.json. Both are fixed for the
+// life of the process.
+func RegisterLaunchArgs(router *ipc.Router, argv []string, launch launchargs.Result) {
+	if argv == nil {
+		argv = []string{}
+	}
+	router.Handle("app.argv", func(params json.RawMessage) (any, error) {
+		return argv, nil
+	})
+	router.Handle("app.launchOptions", func(params json.RawMessage) (any, error) {
+		return launch, nil
+	})
+}
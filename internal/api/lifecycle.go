@@ -0,0 +1,26 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/webview"
+)
+
+// RegisterLifecycle applies the app's quitOnLastWindowClosed policy and
+// registers app.hide and app.show. A click on the macOS Dock icon is sent
+// as an app.reopen event, after the main window has been shown again if no
+// window was visible.
+func RegisterLifecycle(router *ipc.Router, wv webview.Webview, quitOnLastWindowClosed bool) {
+	wv.SetQuitOnLastWindowClosed(quitOnLastWindowClosed)
+	wv.OnReopen(func(hasVisibleWindows bool) {
+		router.SendEvent("app.reopen", map[string]any{"hasVisibleWindows": hasVisibleWindows})
+	})
+
+	router.Handle("app.hide", func(params json.RawMessage) (any, error) {
+		return nil, wv.Hide()
+	})
+	router.Handle("app.show", func(params json.RawMessage) (any, error) {
+		return nil, wv.Show()
+	})
+}
@@ -2,13 +2,82 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"sync"
 
+	"github.com/lightshell-dev/lightshell/internal/accel"
 	"github.com/lightshell-dev/lightshell/internal/ipc"
 	"github.com/lightshell-dev/lightshell/internal/security"
 )
 
+// menuItem is one entry of a menu template. Top-level menus list their
+// entries under items; nested menus may use either items or submenu.
+type menuItem struct {
+	ID          string      `json:"id,omitempty"`
+	Label       string      `json:"label,omitempty"`
+	Type        string      `json:"type,omitempty"` // "normal", "separator", or "checkbox"
+	Role        string      `json:"role,omitempty"`
+	Accelerator string      `json:"accelerator,omitempty"`
+	Enabled     *bool       `json:"enabled,omitempty"`
+	Checked     bool        `json:"checked,omitempty"`
+	Separator   bool        `json:"separator,omitempty"`
+	Items       []*menuItem `json:"items,omitempty"`
+	Submenu     []*menuItem `json:"submenu,omitempty"`
+}
+
+func (m *menuItem) children() []*menuItem {
+	if len(m.Items) > 0 {
+		return m.Items
+	}
+	return m.Submenu
+}
+
+func (m *menuItem) isSeparator() bool {
+	return m.Separator || m.Type == "separator"
+}
+
+// nativeMenuItem is a menu item as the platform builds it. Tag identifies
+// the item when it is clicked.
+type nativeMenuItem struct {
+	Tag       int              `json:"tag"`
+	Label     string           `json:"label"`
+	Role      string           `json:"role,omitempty"`
+	Key       string           `json:"key,omitempty"`  // keyEquivalent character
+	Mods      int              `json:"mods,omitempty"` // menuMod* bits
+	Enabled   bool             `json:"enabled"`
+	Checked   bool             `json:"checked"`
+	Checkbox  bool             `json:"checkbox,omitempty"`
+	Separator bool             `json:"separator,omitempty"`
+	Submenu   []nativeMenuItem `json:"submenu,omitempty"`
+}
+
+// Modifier bits of nativeMenuItem.Mods.
+const (
+	menuModCmd = 1 << iota
+	menuModCtrl
+	menuModAlt
+	menuModShift
+)
+
+// menuState is the application menu as last set or updated from JS. It
+// lives in the Go process, so it outlasts page reloads, and it is the source
+// every native rebuild starts from.
+var menuState struct {
+	sync.Mutex
+	bar       []*menuItem
+	barTags   map[int]*menuItem // native tag -> menu bar item
+	popupTags map[int]*menuItem // native tag -> item of the last popup
+	trayTags  map[int]*menuItem // native tag -> item of the tray menu
+	router    *ipc.Router
+}
+
 // RegisterMenu registers application menu API handlers with security checks.
+// Clicks on items with an id are emitted as menu.click events.
 func RegisterMenu(router *ipc.Router, policy *security.Policy) {
+	menuState.Lock()
+	menuState.router = router
+	menuState.Unlock()
+
 	wrap := func(handler ipc.HandlerFunc) ipc.HandlerFunc {
 		return func(params json.RawMessage) (any, error) {
 			if err := policy.Check(security.PermMenu); err != nil {
@@ -18,4 +87,244 @@ func RegisterMenu(router *ipc.Router, policy *security.Policy) {
 		}
 	}
 	router.Handle("menu.set", wrap(handleMenuSet))
+	router.Handle("menu.get", wrap(handleMenuGet))
+	router.Handle("menu.updateItem", wrap(handleMenuUpdateItem))
+	router.Handle("menu.popup", wrap(handleMenuPopup))
+}
+
+func handleMenuSet(params json.RawMessage) (any, error) {
+	var p struct {
+		Template []*menuItem `json:"template"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if err := validateMenu(p.Template, map[string]bool{}); err != nil {
+		return nil, err
+	}
+
+	menuState.Lock()
+	defer menuState.Unlock()
+	prev := menuState.bar
+	menuState.bar = p.Template
+	if err := applyMenuBar(); err != nil {
+		menuState.bar = prev
+		return nil, err
+	}
+	return nil, nil
+}
+
+// handleMenuGet returns the current menu bar template, including updates,
+// so a reloaded page can render state that matches the native menu.
+func handleMenuGet(params json.RawMessage) (any, error) {
+	menuState.Lock()
+	defer menuState.Unlock()
+	if menuState.bar == nil {
+		return []*menuItem{}, nil
+	}
+	// Marshal under the lock; clicks and updates mutate items in place
+	data, err := json.Marshal(menuState.bar)
+	return json.RawMessage(data), err
+}
+
+func handleMenuUpdateItem(params json.RawMessage) (any, error) {
+	var p struct {
+		ID      string  `json:"id"`
+		Enabled *bool   `json:"enabled"`
+		Checked *bool   `json:"checked"`
+		Label   *string `json:"label"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if p.ID == "" {
+		return nil, fmt.Errorf("menu.updateItem: id is required")
+	}
+
+	menuState.Lock()
+	defer menuState.Unlock()
+	item := findMenuItem(menuState.bar, p.ID)
+	if item == nil {
+		return nil, fmt.Errorf("menu.updateItem: no menu item with id %q", p.ID)
+	}
+	if p.Enabled != nil {
+		item.Enabled = p.Enabled
+	}
+	if p.Checked != nil {
+		item.Checked = *p.Checked
+	}
+	if p.Label != nil {
+		item.Label = *p.Label
+	}
+	return nil, applyMenuBar()
+}
+
+func handleMenuPopup(params json.RawMessage) (any, error) {
+	var p struct {
+		Template []*menuItem `json:"template"`
+		X        *int        `json:"x"`
+		Y        *int        `json:"y"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Template) == 0 {
+		return nil, fmt.Errorf("menu.popup: template is empty")
+	}
+	if err := validateMenu(p.Template, map[string]bool{}); err != nil {
+		return nil, err
+	}
+
+	menuState.Lock()
+	defer menuState.Unlock()
+	tags := make(map[int]*menuItem)
+	items, err := nativeMenu(p.Template, tags)
+	if err != nil {
+		return nil, err
+	}
+	// Without a position the menu opens at the mouse pointer
+	x, y, atMouse := 0, 0, p.X == nil || p.Y == nil
+	if !atMouse {
+		x, y = *p.X, *p.Y
+	}
+	if err := showPopupMenu(items, x, y, atMouse); err != nil {
+		return nil, err
+	}
+	menuState.popupTags = tags
+	return nil, nil
+}
+
+// validateMenu checks accelerators and that ids are unique, since ids are how
+// updateItem and click events find an item.
+func validateMenu(items []*menuItem, ids map[string]bool) error {
+	for _, item := range items {
+		if item == nil {
+			return fmt.Errorf("menu: template contains a null item")
+		}
+		if item.ID != "" {
+			if ids[item.ID] {
+				return fmt.Errorf("menu: duplicate item id %q", item.ID)
+			}
+			ids[item.ID] = true
+		}
+		if item.Accelerator != "" {
+			if _, err := accel.Parse(item.Accelerator, "darwin"); err != nil {
+				return fmt.Errorf("menu item %q: %w", item.Label, err)
+			}
+		}
+		if err := validateMenu(item.children(), ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findMenuItem(items []*menuItem, id string) *menuItem {
+	for _, item := range items {
+		if item.ID == id {
+			return item
+		}
+		if found := findMenuItem(item.children(), id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// applyMenuBar rebuilds the native menu bar from menuState.bar. The caller
+// holds menuState.
+func applyMenuBar() error {
+	tags := make(map[int]*menuItem)
+	items, err := nativeMenu(menuState.bar, tags)
+	if err != nil {
+		return err
+	}
+	if err := setMenuBar(items); err != nil {
+		return err
+	}
+	menuState.barTags = tags
+	return nil
+}
+
+var menuTagSeq int
+
+// nativeMenu converts template items, assigning each a fresh tag recorded
+// in tags. The caller holds menuState.
+func nativeMenu(items []*menuItem, tags map[int]*menuItem) ([]nativeMenuItem, error) {
+	out := make([]nativeMenuItem, 0, len(items))
+	for _, item := range items {
+		menuTagSeq++
+		n := nativeMenuItem{
+			Tag:       menuTagSeq,
+			Label:     item.Label,
+			Role:      item.Role,
+			Enabled:   item.Enabled == nil || *item.Enabled,
+			Checked:   item.Checked,
+			Checkbox:  item.Type == "checkbox",
+			Separator: item.isSeparator(),
+		}
+		if item.Accelerator != "" {
+			b, err := accel.Parse(item.Accelerator, "darwin")
+			if err != nil {
+				return nil, err
+			}
+			n.Key = b.KeyEquivalent()
+			if b.Meta {
+				n.Mods |= menuModCmd
+			}
+			if b.Ctrl {
+				n.Mods |= menuModCtrl
+			}
+			if b.Alt {
+				n.Mods |= menuModAlt
+			}
+			if b.Shift {
+				n.Mods |= menuModShift
+			}
+		}
+		sub, err := nativeMenu(item.children(), tags)
+		if err != nil {
+			return nil, err
+		}
+		if len(sub) > 0 {
+			n.Submenu = sub
+		}
+		tags[n.Tag] = item
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// menuClicked handles a click on the native item with tag. Checkbox items
+// flip their checked state before the event is sent. Tray menu clicks are
+// sent as tray.click rather than menu.click.
+func menuClicked(tag int) {
+	menuState.Lock()
+	event := "menu.click"
+	item := menuState.barTags[tag]
+	if item == nil {
+		item = menuState.popupTags[tag]
+	}
+	if item == nil {
+		if item = menuState.trayTags[tag]; item != nil {
+			event = "tray.click"
+		}
+	}
+	router := menuState.router
+	if item == nil || router == nil {
+		menuState.Unlock()
+		return
+	}
+	if item.Type == "checkbox" {
+		item.Checked = !item.Checked
+	}
+	data := map[string]any{"id": item.ID, "checked": item.Checked}
+	if item.Role != "" {
+		data["role"] = item.Role
+	}
+	menuState.Unlock()
+
+	if item.ID != "" || item.Role != "" {
+		router.SendEvent(event, data)
+	}
 }
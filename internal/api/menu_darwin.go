@@ -8,23 +8,45 @@ package api
 
 #include <stdlib.h>
 
-extern void MenuSet(const char* jsonTemplate);
+extern void MenuSet(const char* jsonItems);
+extern int MenuPopup(const char* jsonItems, int x, int y, int atMouse);
 */
 import "C"
 import (
 	"encoding/json"
+	"fmt"
 	"unsafe"
 )
 
-func handleMenuSet(params json.RawMessage) (any, error) {
-	var p struct {
-		Template json.RawMessage `json:"template"`
-	}
-	if err := json.Unmarshal(params, &p); err != nil {
-		return nil, err
+//export goMenuClicked
+func goMenuClicked(tag C.int) {
+	menuClicked(int(tag))
+}
+
+func setMenuBar(items []nativeMenuItem) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
 	}
-	cJSON := C.CString(string(p.Template))
+	cJSON := C.CString(string(data))
 	defer C.free(unsafe.Pointer(cJSON))
 	C.MenuSet(cJSON)
-	return nil, nil
+	return nil
+}
+
+func showPopupMenu(items []nativeMenuItem, x, y int, atMouse bool) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	cJSON := C.CString(string(data))
+	defer C.free(unsafe.Pointer(cJSON))
+	mouse := 0
+	if atMouse {
+		mouse = 1
+	}
+	if C.MenuPopup(cJSON, C.int(x), C.int(y), C.int(mouse)) == 0 {
+		return fmt.Errorf("menu.popup: no window to show the menu in")
+	}
+	return nil
 }
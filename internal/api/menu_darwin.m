@@ -1,33 +1,170 @@
 #import <Cocoa/Cocoa.h>
 
-void MenuSet(const char* jsonTemplate) {
-    // Basic menu setup — creates a standard macOS menu bar
-    dispatch_async(dispatch_get_main_queue(), ^{
-        NSMenu *mainMenu = [[NSMenu alloc] init];
-
-        // App menu
-        NSMenuItem *appMenuItem = [[NSMenuItem alloc] init];
-        NSMenu *appMenu = [[NSMenu alloc] initWithTitle:@""];
-        NSMenuItem *quitItem = [[NSMenuItem alloc] initWithTitle:@"Quit"
-                                                          action:@selector(terminate:)
-                                                   keyEquivalent:@"q"];
-        [appMenu addItem:quitItem];
-        [appMenuItem setSubmenu:appMenu];
-        [mainMenu addItem:appMenuItem];
-
-        // Edit menu (for copy/paste/select all)
-        NSMenuItem *editMenuItem = [[NSMenuItem alloc] init];
-        NSMenu *editMenu = [[NSMenu alloc] initWithTitle:@"Edit"];
-        [editMenu addItemWithTitle:@"Undo" action:@selector(undo:) keyEquivalent:@"z"];
-        [editMenu addItemWithTitle:@"Redo" action:@selector(redo:) keyEquivalent:@"Z"];
-        [editMenu addItem:[NSMenuItem separatorItem]];
-        [editMenu addItemWithTitle:@"Cut" action:@selector(cut:) keyEquivalent:@"x"];
-        [editMenu addItemWithTitle:@"Copy" action:@selector(copy:) keyEquivalent:@"c"];
-        [editMenu addItemWithTitle:@"Paste" action:@selector(paste:) keyEquivalent:@"v"];
-        [editMenu addItemWithTitle:@"Select All" action:@selector(selectAll:) keyEquivalent:@"a"];
-        [editMenuItem setSubmenu:editMenu];
-        [mainMenu addItem:editMenuItem];
+extern void goMenuClicked(int tag);
+
+// Modifier bits, matching menuMod* in menu.go
+#define MENU_MOD_CMD   1
+#define MENU_MOD_CTRL  2
+#define MENU_MOD_ALT   4
+#define MENU_MOD_SHIFT 8
+
+// MenuTarget routes clicks on template items back to Go by tag.
+@interface MenuTarget : NSObject
+- (void)itemClicked:(id)sender;
+@end
+
+@implementation MenuTarget
+- (void)itemClicked:(id)sender {
+    NSMenuItem *item = (NSMenuItem *)sender;
+    if ([item.representedObject isEqual:@"checkbox"]) {
+        item.state = (item.state == NSControlStateValueOn) ? NSControlStateValueOff : NSControlStateValueOn;
+    }
+    goMenuClicked((int)item.tag);
+}
+@end
+
+static MenuTarget *menuTarget = nil;
+
+// roleSelector returns the responder-chain action for a system role, or NULL
+// for roles handled by the page (reload, toggleDevtools).
+static SEL roleSelector(NSString *role) {
+    if ([role isEqualToString:@"undo"]) return @selector(undo:);
+    if ([role isEqualToString:@"redo"]) return @selector(redo:);
+    if ([role isEqualToString:@"cut"]) return @selector(cut:);
+    if ([role isEqualToString:@"copy"]) return @selector(copy:);
+    if ([role isEqualToString:@"paste"]) return @selector(paste:);
+    if ([role isEqualToString:@"selectAll"]) return @selector(selectAll:);
+    if ([role isEqualToString:@"minimize"]) return @selector(performMiniaturize:);
+    if ([role isEqualToString:@"close"]) return @selector(performClose:);
+    if ([role isEqualToString:@"quit"]) return @selector(terminate:);
+    return NULL;
+}
+
+static NSString *roleLabel(NSString *role) {
+    NSDictionary *labels = @{
+        @"undo": @"Undo", @"redo": @"Redo", @"cut": @"Cut", @"copy": @"Copy",
+        @"paste": @"Paste", @"selectAll": @"Select All", @"minimize": @"Minimize",
+        @"close": @"Close Window", @"quit": @"Quit", @"reload": @"Reload",
+        @"toggleDevtools": @"Toggle Developer Tools",
+    };
+    return labels[role] ?: @"";
+}
+
+static NSMenu *buildMenu(NSArray *items, NSString *title) {
+    NSMenu *menu = [[[NSMenu alloc] initWithTitle:title] autorelease];
+    // Items carry their own enabled state from the template
+    menu.autoenablesItems = NO;
+
+    for (NSDictionary *spec in items) {
+        if ([spec[@"separator"] boolValue]) {
+            [menu addItem:[NSMenuItem separatorItem]];
+            continue;
+        }
+
+        NSString *role = spec[@"role"] ?: @"";
+        NSString *label = spec[@"label"] ?: @"";
+        if (label.length == 0) {
+            label = roleLabel(role);
+        }
+
+        NSMenuItem *item = [[[NSMenuItem alloc] initWithTitle:label action:NULL keyEquivalent:@""] autorelease];
+        SEL action = roleSelector(role);
+        if (action) {
+            item.action = action;
+        } else {
+            item.action = @selector(itemClicked:);
+            item.target = menuTarget;
+        }
+        item.tag = [spec[@"tag"] integerValue];
+        item.enabled = [spec[@"enabled"] boolValue];
+        item.state = [spec[@"checked"] boolValue] ? NSControlStateValueOn : NSControlStateValueOff;
+        if ([spec[@"checkbox"] boolValue]) {
+            item.representedObject = @"checkbox";
+        }
 
+        NSString *key = spec[@"key"];
+        if (key.length > 0) {
+            int mods = [spec[@"mods"] intValue];
+            NSEventModifierFlags mask = 0;
+            if (mods & MENU_MOD_CMD) mask |= NSEventModifierFlagCommand;
+            if (mods & MENU_MOD_CTRL) mask |= NSEventModifierFlagControl;
+            if (mods & MENU_MOD_ALT) mask |= NSEventModifierFlagOption;
+            if (mods & MENU_MOD_SHIFT) mask |= NSEventModifierFlagShift;
+            item.keyEquivalent = key;
+            item.keyEquivalentModifierMask = mask;
+        }
+
+        NSArray *submenu = spec[@"submenu"];
+        if (submenu.count > 0) {
+            item.submenu = buildMenu(submenu, label);
+        }
+        [menu addItem:item];
+    }
+    return menu;
+}
+
+// MenuBuildTemplate builds a menu from parsed template items for menus
+// shown outside the menu bar, such as the tray's. The menu is autoreleased;
+// call on the main thread.
+NSMenu *MenuBuildTemplate(NSArray *items) {
+    if (menuTarget == nil) {
+        menuTarget = [[MenuTarget alloc] init];
+    }
+    return buildMenu(items, @"");
+}
+
+static NSArray *parseItems(const char* jsonItems) {
+    NSData *data = [NSData dataWithBytes:jsonItems length:strlen(jsonItems)];
+    id parsed = [NSJSONSerialization JSONObjectWithData:data options:0 error:nil];
+    return [parsed isKindOfClass:[NSArray class]] ? parsed : @[];
+}
+
+// MenuSet replaces the application menu bar. Each top-level item becomes a
+// menu; on macOS the first one is shown under the app name.
+void MenuSet(const char* jsonItems) {
+    NSArray *items = [parseItems(jsonItems) retain];
+    dispatch_async(dispatch_get_main_queue(), ^{
+        if (menuTarget == nil) {
+            menuTarget = [[MenuTarget alloc] init];
+        }
+        NSMenu *mainMenu = [[NSMenu alloc] initWithTitle:@""];
+        for (NSDictionary *spec in items) {
+            NSString *label = spec[@"label"] ?: @"";
+            NSMenuItem *top = [[NSMenuItem alloc] initWithTitle:label action:NULL keyEquivalent:@""];
+            top.submenu = buildMenu(spec[@"submenu"] ?: @[], label);
+            [mainMenu addItem:top];
+            [top release];
+        }
         [NSApp setMainMenu:mainMenu];
+        [mainMenu release];
+        [items release];
+    });
+}
+
+// MenuPopup shows a context menu at (x, y) in the key window's content view,
+// measured from the top-left corner, or at the mouse pointer. Returns 0 if
+// there is no window. Must be called on the main thread; the menu opens once
+// the current IPC call has returned.
+int MenuPopup(const char* jsonItems, int x, int y, int atMouse) {
+    NSWindow *window = [NSApp keyWindow] ?: [NSApp mainWindow];
+    if (window == nil) {
+        return 0;
+    }
+    if (menuTarget == nil) {
+        menuTarget = [[MenuTarget alloc] init];
+    }
+    NSMenu *menu = [buildMenu(parseItems(jsonItems), @"") retain];
+    NSView *view = [window.contentView retain];
+
+    dispatch_async(dispatch_get_main_queue(), ^{
+        if (atMouse) {
+            [menu popUpMenuPositioningItem:nil atLocation:[NSEvent mouseLocation] inView:nil];
+        } else {
+            CGFloat top = view.isFlipped ? y : view.bounds.size.height - y;
+            [menu popUpMenuPositioningItem:nil atLocation:NSMakePoint(x, top) inView:view];
+        }
+        [menu release];
+        [view release];
     });
+    return 1;
 }
@@ -2,11 +2,12 @@
 
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "fmt"
 
-func handleMenuSet(params json.RawMessage) (any, error) {
-	return nil, fmt.Errorf("menu.set not yet implemented on linux")
+func setMenuBar(items []nativeMenuItem) error {
+	return fmt.Errorf("menu.set not yet implemented on linux")
+}
+
+func showPopupMenu(items []nativeMenuItem, x, y int, atMouse bool) error {
+	return fmt.Errorf("menu.popup not yet implemented on linux")
 }
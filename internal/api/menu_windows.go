@@ -0,0 +1,13 @@
+//go:build windows
+
+package api
+
+import "fmt"
+
+func setMenuBar(items []nativeMenuItem) error {
+	return fmt.Errorf("menu.set not yet implemented on windows")
+}
+
+func showPopupMenu(items []nativeMenuItem, x, y int, atMouse bool) error {
+	return fmt.Errorf("menu.popup not yet implemented on windows")
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+)
+
+// networkStatus is the connectivity reported to JS. Online means the system
+// has a usable network path, not that any particular host is reachable.
+type networkStatus struct {
+	Online    bool   `json:"online"`
+	Type      string `json:"type"`      // "wifi", "ethernet", "cellular", "other", or "none"
+	Expensive bool   `json:"expensive"` // metered, e.g. cellular or a personal hotspot
+}
+
+var (
+	networkMu     sync.Mutex
+	networkState  *networkStatus // last status from the platform monitor
+	networkRouter *ipc.Router
+	networkOnce   sync.Once
+)
+
+// RegisterNetwork registers connectivity handlers and starts the platform
+// monitor, which emits system.networkChange whenever the status changes.
+func RegisterNetwork(router *ipc.Router) {
+	networkMu.Lock()
+	networkRouter = router
+	networkMu.Unlock()
+	networkOnce.Do(startNetworkMonitor)
+
+	router.Handle("system.isOnline", func(params json.RawMessage) (any, error) {
+		return currentNetworkStatus().Online, nil
+	})
+
+	router.Handle("system.networkStatus", func(params json.RawMessage) (any, error) {
+		return currentNetworkStatus(), nil
+	})
+}
+
+func currentNetworkStatus() networkStatus {
+	networkMu.Lock()
+	defer networkMu.Unlock()
+	if networkState != nil {
+		return *networkState
+	}
+	return probeNetworkStatus()
+}
+
+// setNetworkStatus records a status from the platform monitor. The first
+// report is the baseline; later ones emit an event only when they differ.
+func setNetworkStatus(s networkStatus) {
+	networkMu.Lock()
+	prev := networkState
+	networkState = &s
+	router := networkRouter
+	networkMu.Unlock()
+
+	if prev == nil || *prev == s || router == nil {
+		return
+	}
+	router.SendEvent("system.networkChange", s)
+}
+
+// probeNetworkStatus checks for an up, non-loopback interface with a
+// global unicast address. It is the fallback before the monitor reports.
+func probeNetworkStatus() networkStatus {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return networkStatus{Type: "none"}
+	}
+	for _, ifc := range ifaces {
+		if ifc.Flags&net.FlagUp == 0 || ifc.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := ifc.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.IsGlobalUnicast() {
+				return networkStatus{Online: true, Type: interfaceType(ifc.Name)}
+			}
+		}
+	}
+	return networkStatus{Type: "none"}
+}
@@ -0,0 +1,32 @@
+//go:build darwin
+
+package api
+
+/*
+#cgo darwin CFLAGS: -x objective-c
+#cgo darwin LDFLAGS: -framework Network
+
+extern void NetworkStartMonitor();
+*/
+import "C"
+
+//export goNetworkChanged
+func goNetworkChanged(online C.int, ifaceType *C.char, expensive C.int) {
+	s := networkStatus{Online: online != 0, Type: C.GoString(ifaceType), Expensive: expensive != 0}
+	if !s.Online {
+		s.Type = "none"
+	}
+	setNetworkStatus(s)
+}
+
+// startNetworkMonitor starts an NWPathMonitor, which reports the current
+// path immediately and again whenever it changes.
+func startNetworkMonitor() {
+	C.NetworkStartMonitor()
+}
+
+// interfaceType is only used before the first monitor report; the path
+// monitor supplies the real type.
+func interfaceType(name string) string {
+	return "other"
+}
@@ -0,0 +1,27 @@
+#import <Foundation/Foundation.h>
+#import <Network/Network.h>
+
+extern void goNetworkChanged(int online, const char* ifaceType, int expensive);
+
+static nw_path_monitor_t monitor = nil;
+
+void NetworkStartMonitor() {
+    if (monitor != nil) {
+        return;
+    }
+    monitor = nw_path_monitor_create();
+    nw_path_monitor_set_queue(monitor, dispatch_get_global_queue(QOS_CLASS_UTILITY, 0));
+    nw_path_monitor_set_update_handler(monitor, ^(nw_path_t path) {
+        int online = nw_path_get_status(path) == nw_path_status_satisfied;
+        const char *type = "other";
+        if (nw_path_uses_interface_type(path, nw_interface_type_wifi)) {
+            type = "wifi";
+        } else if (nw_path_uses_interface_type(path, nw_interface_type_wired)) {
+            type = "ethernet";
+        } else if (nw_path_uses_interface_type(path, nw_interface_type_cellular)) {
+            type = "cellular";
+        }
+        goNetworkChanged(online, type, nw_path_is_expensive(path) ? 1 : 0);
+    });
+    nw_path_monitor_start(monitor);
+}
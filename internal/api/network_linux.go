@@ -0,0 +1,59 @@
+//go:build linux
+
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// rtnetlink multicast groups (linux/rtnetlink.h); the syscall package does
+// not export them.
+const (
+	rtmgrpLink       = 0x1
+	rtmgrpIPv4Ifaddr = 0x10
+	rtmgrpIPv4Route  = 0x40
+	rtmgrpIPv6Ifaddr = 0x100
+)
+
+// startNetworkMonitor listens for link, address, and route changes on a
+// netlink socket and re-probes the interfaces after each one.
+func startNetworkMonitor() {
+	setNetworkStatus(probeNetworkStatus())
+
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return
+	}
+	sa := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: rtmgrpLink | rtmgrpIPv4Ifaddr | rtmgrpIPv6Ifaddr | rtmgrpIPv4Route,
+	}
+	if err := syscall.Bind(fd, sa); err != nil {
+		syscall.Close(fd)
+		return
+	}
+
+	go func() {
+		defer syscall.Close(fd)
+		buf := make([]byte, 8192)
+		for {
+			if _, _, err := syscall.Recvfrom(fd, buf, 0); err != nil {
+				if err == syscall.EINTR || err == syscall.ENOBUFS {
+					continue
+				}
+				return
+			}
+			setNetworkStatus(probeNetworkStatus())
+		}
+	}()
+}
+
+// interfaceType reports "wifi" for wireless interfaces and "ethernet" otherwise.
+func interfaceType(name string) string {
+	if _, err := os.Stat(filepath.Join("/sys/class/net", name, "wireless")); err == nil {
+		return "wifi"
+	}
+	return "ethernet"
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package api
+
+import (
+	"strings"
+	"time"
+)
+
+// networkPollInterval is how often the interfaces are re-probed. Windows
+// reports changes through NotifyIpInterfaceChange, whose callback runs on
+// a system thread pool; polling keeps this free of cgo and callbacks.
+const networkPollInterval = 5 * time.Second
+
+// startNetworkMonitor probes the interfaces now and then periodically.
+func startNetworkMonitor() {
+	setNetworkStatus(probeNetworkStatus())
+	go func() {
+		for range time.Tick(networkPollInterval) {
+			setNetworkStatus(probeNetworkStatus())
+		}
+	}()
+}
+
+// interfaceType guesses from the adapter's friendly name, which is what
+// net.Interfaces reports on Windows ("Wi-Fi", "Ethernet", ...).
+func interfaceType(name string) string {
+	lower := strings.ToLower(name)
+	for _, w := range []string{"wi-fi", "wifi", "wireless", "wlan"} {
+		if strings.Contains(lower, w) {
+			return "wifi"
+		}
+	}
+	return "ethernet"
+}
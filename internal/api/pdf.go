@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+// pdfOptions controls page layout for pdf.fromHTML. Sizes are in points (1/72 inch).
+type pdfOptions struct {
+	Width     float64
+	Height    float64
+	Margin    float64
+	Landscape bool
+	BaseURL   string
+}
+
+// paperSizes maps paper names to portrait width and height in points.
+var paperSizes = map[string][2]float64{
+	"letter": {612, 792},
+	"legal":  {612, 1008},
+	"a4":     {595.28, 841.89},
+	"a3":     {841.89, 1190.55},
+}
+
+// RegisterPDF registers PDF generation handlers. Rendering happens in an
+// offscreen webview, so the visible window is never navigated. The handler
+// runs on the worker pool because it waits for the main thread to render.
+func RegisterPDF(router *ipc.Router, policy *security.Policy) {
+	router.HandlePooled("pdf.fromHTML", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermPDF); err != nil {
+			return nil, err
+		}
+		var p struct {
+			HTML      string   `json:"html"`
+			Path      string   `json:"path"`
+			Paper     string   `json:"paper"`
+			Landscape bool     `json:"landscape"`
+			Margin    *float64 `json:"margin"`
+			BaseURL   string   `json:"baseURL"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Path == "" {
+			return nil, fmt.Errorf("pdf.fromHTML: path is required")
+		}
+		if err := policy.Check(security.PermFS); err != nil {
+			return nil, err
+		}
+		if err := policy.CheckFSWrite(p.Path); err != nil {
+			return nil, err
+		}
+
+		paper := p.Paper
+		if paper == "" {
+			paper = "letter"
+		}
+		size, ok := paperSizes[paper]
+		if !ok {
+			return nil, fmt.Errorf("pdf.fromHTML: unknown paper %q (use letter, legal, a4, or a3)", p.Paper)
+		}
+		opts := pdfOptions{Width: size[0], Height: size[1], Margin: 36, Landscape: p.Landscape, BaseURL: p.BaseURL}
+		if p.Margin != nil {
+			opts.Margin = *p.Margin
+		}
+		if opts.Landscape {
+			opts.Width, opts.Height = opts.Height, opts.Width
+		}
+
+		if err := os.MkdirAll(filepath.Dir(p.Path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := renderPDF(p.HTML, p.Path, opts); err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(p.Path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"path": p.Path, "size": info.Size()}, nil
+	})
+}
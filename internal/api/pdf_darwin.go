@@ -0,0 +1,39 @@
+//go:build darwin
+
+package api
+
+/*
+#cgo darwin CFLAGS: -x objective-c
+#cgo darwin LDFLAGS: -framework Cocoa -framework WebKit
+
+#include <stdlib.h>
+
+extern const char* PDFFromHTML(const char* html, const char* baseURL, const char* dest,
+	double width, double height, double margin, int landscape);
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+func renderPDF(html, dest string, opts pdfOptions) error {
+	cHTML := C.CString(html)
+	defer C.free(unsafe.Pointer(cHTML))
+	cBase := C.CString(opts.BaseURL)
+	defer C.free(unsafe.Pointer(cBase))
+	cDest := C.CString(dest)
+	defer C.free(unsafe.Pointer(cDest))
+
+	landscape := 0
+	if opts.Landscape {
+		landscape = 1
+	}
+
+	errMsg := C.PDFFromHTML(cHTML, cBase, cDest, C.double(opts.Width), C.double(opts.Height), C.double(opts.Margin), C.int(landscape))
+	if errMsg != nil {
+		defer C.free(unsafe.Pointer(errMsg))
+		return fmt.Errorf("pdf.fromHTML: %s", C.GoString(errMsg))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+#import <Cocoa/Cocoa.h>
+#import <WebKit/WebKit.h>
+
+// PDFRenderer loads HTML into an offscreen WKWebView and prints it to a PDF
+// file. It must be driven from the main thread.
+@interface PDFRenderer : NSObject <WKNavigationDelegate>
+@property (retain) NSWindow *window;
+@property (retain) WKWebView *webView;
+@property (retain) NSString *dest;
+@property NSSize paperSize; // portrait; orientation is applied by NSPrintInfo
+@property NSSize viewSize;  // oriented page size used to lay out the page
+@property CGFloat margin;
+@property BOOL landscape;
+@property (copy) void (^completion)(NSString *error);
+@end
+
+@implementation PDFRenderer
+
+- (void)startWithHTML:(NSString *)html baseURL:(NSURL *)baseURL {
+    NSRect frame = NSMakeRect(-10000, -10000, self.viewSize.width, self.viewSize.height);
+    self.window = [[[NSWindow alloc] initWithContentRect:frame
+                                               styleMask:NSWindowStyleMaskBorderless
+                                                 backing:NSBackingStoreBuffered
+                                                   defer:YES] autorelease];
+    [self.window setReleasedWhenClosed:NO];
+
+    WKWebViewConfiguration *config = [[[WKWebViewConfiguration alloc] init] autorelease];
+    self.webView = [[[WKWebView alloc] initWithFrame:NSMakeRect(0, 0, frame.size.width, frame.size.height)
+                                       configuration:config] autorelease];
+    self.webView.navigationDelegate = self;
+    [self.window setContentView:self.webView];
+    [self.webView loadHTMLString:html baseURL:baseURL];
+}
+
+- (void)webView:(WKWebView *)webView didFinishNavigation:(WKNavigation *)navigation {
+    NSPrintInfo *info = [[[NSPrintInfo sharedPrintInfo] copy] autorelease];
+    [info setPaperSize:self.paperSize];
+    [info setOrientation:(self.landscape ? NSPaperOrientationLandscape : NSPaperOrientationPortrait)];
+    [info setTopMargin:self.margin];
+    [info setBottomMargin:self.margin];
+    [info setLeftMargin:self.margin];
+    [info setRightMargin:self.margin];
+    [info setHorizontalPagination:NSPrintingPaginationModeFit];
+    [info setVerticalPagination:NSPrintingPaginationModeAutomatic];
+    [info setJobDisposition:NSPrintSaveJob];
+    [[info dictionary] setObject:[NSURL fileURLWithPath:self.dest] forKey:NSPrintJobSavingURL];
+
+    NSPrintOperation *op = [webView printOperationWithPrintInfo:info];
+    [op setShowsPrintPanel:NO];
+    [op setShowsProgressPanel:NO];
+    [[op view] setFrame:NSMakeRect(0, 0, self.viewSize.width, self.viewSize.height)];
+    [op runOperationModalForWindow:self.window
+                          delegate:self
+                    didRunSelector:@selector(printOperationDidRun:success:contextInfo:)
+                       contextInfo:NULL];
+}
+
+- (void)printOperationDidRun:(NSPrintOperation *)op success:(BOOL)success contextInfo:(void *)info {
+    [self finish:(success ? nil : @"print operation failed")];
+}
+
+- (void)webView:(WKWebView *)webView didFailNavigation:(WKNavigation *)navigation withError:(NSError *)error {
+    [self finish:[error localizedDescription]];
+}
+
+- (void)webView:(WKWebView *)webView didFailProvisionalNavigation:(WKNavigation *)navigation withError:(NSError *)error {
+    [self finish:[error localizedDescription]];
+}
+
+- (void)finish:(NSString *)error {
+    if (self.completion) {
+        void (^completion)(NSString *) = [[self.completion retain] autorelease];
+        self.completion = nil;
+        self.webView.navigationDelegate = nil;
+        [self.window close];
+        completion(error);
+    }
+}
+
+- (void)dealloc {
+    [_window release];
+    [_webView release];
+    [_dest release];
+    [_completion release];
+    [super dealloc];
+}
+
+@end
+
+// PDFFromHTML renders html to a PDF at dest. width and height are the
+// oriented page size in points (already swapped for landscape). It blocks until rendering
+// finishes and must not be called on the main thread. Returns NULL on success
+// or a malloc'd error message.
+const char* PDFFromHTML(const char* html, const char* baseURL, const char* dest,
+                        double width, double height, double margin, int landscape) {
+    if ([NSThread isMainThread]) {
+        return strdup("cannot render PDF on the main thread");
+    }
+
+    __block char *result = NULL;
+    dispatch_semaphore_t done = dispatch_semaphore_create(0);
+
+    NSString *nsHTML = [NSString stringWithUTF8String:html];
+    NSString *nsBase = [NSString stringWithUTF8String:baseURL];
+    NSString *nsDest = [NSString stringWithUTF8String:dest];
+
+    dispatch_async(dispatch_get_main_queue(), ^{
+        PDFRenderer *renderer = [[PDFRenderer alloc] init];
+        renderer.dest = nsDest;
+        // NSPrintInfo expects portrait dimensions plus an orientation
+        renderer.paperSize = landscape ? NSMakeSize(height, width) : NSMakeSize(width, height);
+        renderer.viewSize = NSMakeSize(width, height);
+        renderer.margin = margin;
+        renderer.landscape = landscape != 0;
+        renderer.completion = ^(NSString *error) {
+            if (error) {
+                result = strdup([error UTF8String]);
+            }
+            dispatch_semaphore_signal(done);
+            [renderer autorelease];
+        };
+        NSURL *base = [nsBase length] > 0 ? [NSURL URLWithString:nsBase] : nil;
+        [renderer startWithHTML:nsHTML baseURL:base];
+    });
+
+    if (dispatch_semaphore_wait(done, dispatch_time(DISPATCH_TIME_NOW, 30 * NSEC_PER_SEC)) != 0) {
+        return strdup("timed out after 30s rendering PDF");
+    }
+    return result;
+}
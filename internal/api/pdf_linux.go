@@ -0,0 +1,9 @@
+//go:build linux
+
+package api
+
+import "fmt"
+
+func renderPDF(html, dest string, opts pdfOptions) error {
+	return fmt.Errorf("pdf.fromHTML not yet implemented on linux")
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+// permissionDescriptor is what lightshell.permissions.query and request
+// take: an API with the path, URL, or command to ask about.
+type permissionDescriptor struct {
+	API    string   `json:"api"`
+	Access string   `json:"access"` // fs: read (the default) or write
+	Path   string   `json:"path"`
+	URL    string   `json:"url"`
+	Cmd    string   `json:"cmd"`
+	Args   []string `json:"args"`
+}
+
+func (d permissionDescriptor) request(policy *security.Policy) (security.Request, error) {
+	switch security.Permission(d.API) {
+	case security.PermFS:
+		if d.Path == "" {
+			return security.Request{}, fmt.Errorf("permissions: fs needs a path")
+		}
+		access := d.Access
+		if access == "" {
+			access = "read"
+		}
+		if access != "read" && access != "write" {
+			return security.Request{}, fmt.Errorf("permissions: fs access must be read or write, not %q", access)
+		}
+		return security.Request{API: security.PermFS, Access: access, Target: policy.ExpandPath(d.Path)}, nil
+	case security.PermHTTP:
+		if d.URL == "" {
+			return security.Request{}, fmt.Errorf("permissions: http needs a url")
+		}
+		return security.Request{API: security.PermHTTP, Target: d.URL}, nil
+	case security.PermProcess:
+		if d.Cmd == "" {
+			return security.Request{}, fmt.Errorf("permissions: process needs a cmd")
+		}
+		return security.Request{API: security.PermProcess, Target: d.Cmd, Args: d.Args}, nil
+	}
+	return security.Request{}, fmt.Errorf("permissions: api must be fs, http, or process, not %q", d.API)
+}
+
+// RegisterPermissions registers lightshell.permissions, which reports
+// whether a path, URL, or command is allowed, and asks the user about it
+// when the permissions config turns on ask mode.
+func RegisterPermissions(router *ipc.Router, policy *security.Policy) {
+	handle := func(resolve func(security.Request) security.State) ipc.HandlerFunc {
+		return func(params json.RawMessage) (any, error) {
+			var d permissionDescriptor
+			if err := json.Unmarshal(params, &d); err != nil {
+				return nil, err
+			}
+			req, err := d.request(policy)
+			if err != nil {
+				return nil, err
+			}
+			return resolve(req), nil
+		}
+	}
+	router.Handle("permissions.query", handle(policy.Query))
+	router.Handle("permissions.request", handle(policy.Request))
+}
+
+// PermissionPrompter returns the native prompt ask mode shows for appName,
+// or nil on platforms without one, where denials stand.
+func PermissionPrompter(appName string) security.Prompter {
+	if !PermissionPromptSupported {
+		return nil
+	}
+	return func(req security.Request) security.Decision {
+		return showPermissionPrompt(req.Prompt(appName),
+			"Allow lets it do this until it quits. Always Allow also lets it the next time it runs.")
+	}
+}
@@ -0,0 +1,39 @@
+//go:build darwin
+
+package api
+
+/*
+#cgo darwin CFLAGS: -x objective-c
+#cgo darwin LDFLAGS: -framework Cocoa
+
+#include <stdlib.h>
+
+extern int PermissionPrompt(const char* message, const char* detail);
+*/
+import "C"
+import (
+	"unsafe"
+
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+// PermissionPromptSupported reports whether this platform can show the
+// permission prompt of ask mode.
+const PermissionPromptSupported = true
+
+// showPermissionPrompt shows an alert with Allow, Deny, and Always Allow
+// buttons. Escape denies.
+func showPermissionPrompt(message, detail string) security.Decision {
+	cMsg := C.CString(message)
+	defer C.free(unsafe.Pointer(cMsg))
+	cDetail := C.CString(detail)
+	defer C.free(unsafe.Pointer(cDetail))
+
+	switch C.PermissionPrompt(cMsg, cDetail) {
+	case 1:
+		return security.Allow
+	case 2:
+		return security.AlwaysAllow
+	}
+	return security.Deny
+}
@@ -0,0 +1,33 @@
+#import <Cocoa/Cocoa.h>
+
+// PermissionPrompt returns 1 for Allow, 2 for Always Allow, and 0 for Deny.
+// IPC handlers usually run on the main thread, where the alert runs
+// directly; waiting on the main queue from there would never return.
+int PermissionPrompt(const char* message, const char* detail) {
+    __block int result = 0;
+
+    void (^prompt)(void) = ^{
+        NSAlert *alert = [[NSAlert alloc] init];
+        [alert setMessageText:[NSString stringWithUTF8String:message]];
+        [alert setInformativeText:[NSString stringWithUTF8String:detail]];
+        [alert setAlertStyle:NSAlertStyleWarning];
+        [alert addButtonWithTitle:@"Allow"];
+        NSButton *deny = [alert addButtonWithTitle:@"Deny"];
+        [deny setKeyEquivalent:@"\033"];
+        [alert addButtonWithTitle:@"Always Allow"];
+
+        NSModalResponse response = [alert runModal];
+        if (response == NSAlertFirstButtonReturn) {
+            result = 1;
+        } else if (response == NSAlertThirdButtonReturn) {
+            result = 2;
+        }
+    };
+
+    if ([NSThread isMainThread]) {
+        prompt();
+    } else {
+        dispatch_sync(dispatch_get_main_queue(), prompt);
+    }
+    return result;
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+	"github.com/lightshell-dev/lightshell/internal/worker"
+)
+
+// dispatchWithin dispatches a message and fails the test if no reply
+// arrives in time, as happens when a prompt waits on the thread it runs on.
+func dispatchWithin(t *testing.T, router *ipc.Router, method string, params any) ipc.Response {
+	t.Helper()
+	data, _ := json.Marshal(map[string]any{"id": "1", "method": method, "params": params})
+	replies := make(chan string, 1)
+	go router.Dispatch(string(data), func(response string) { replies <- response })
+	select {
+	case raw := <-replies:
+		var resp ipc.Response
+		if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return resp
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s did not reply", method)
+		return ipc.Response{}
+	}
+}
+
+func TestPermissionPromptFromHandler(t *testing.T) {
+	allowed, outside := t.TempDir(), t.TempDir()
+	file := filepath.Join(outside, "notes.txt")
+	os.WriteFile(file, []byte("hello"), 0o644)
+
+	policy := security.NewPolicy([]string{"fs"}, allowed, "", false)
+	policy.SetFSScope(security.FSScope{Read: []string{allowed + "/**"}})
+	var prompts atomic.Int32
+	policy.SetAsk([]security.Permission{security.PermFS}, func(req security.Request) security.Decision {
+		prompts.Add(1)
+		if req.Target != file {
+			t.Errorf("prompted about %q, want %q", req.Target, file)
+		}
+		return security.Allow
+	}, filepath.Join(t.TempDir(), "permissions.json"))
+
+	router := ipc.NewRouter()
+	RegisterFS(router, policy)
+	RegisterPermissions(router, policy)
+
+	// Handlers run on the thread that dispatches them, as on the main
+	// thread in the app, and on the worker pool.
+	resp := dispatchWithin(t, router, "fs.readFile", map[string]any{"path": file})
+	if resp.Error != "" || resp.Result != "hello" {
+		t.Fatalf("readFile = %v, %q", resp.Result, resp.Error)
+	}
+	router.SetPool(worker.NewPool(worker.Config{Namespaces: map[string]int{"fs": 1}}))
+	resp = dispatchWithin(t, router, "fs.readFile", map[string]any{"path": file})
+	if resp.Error != "" || resp.Result != "hello" {
+		t.Fatalf("pooled readFile = %v, %q", resp.Result, resp.Error)
+	}
+	if n := prompts.Load(); n != 1 {
+		t.Errorf("prompts = %d, want 1 for the session", n)
+	}
+
+	resp = dispatchWithin(t, router, "permissions.query", map[string]any{"api": "fs", "path": file})
+	if resp.Result != string(security.Granted) {
+		t.Errorf("query = %v, want granted", resp.Result)
+	}
+}
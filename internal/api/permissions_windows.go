@@ -0,0 +1,13 @@
+//go:build windows
+
+package api
+
+import "github.com/lightshell-dev/lightshell/internal/security"
+
+// PermissionPromptSupported reports whether this platform can show the
+// permission prompt of ask mode.
+const PermissionPromptSupported = false
+
+func showPermissionPrompt(message, detail string) security.Decision {
+	return security.Deny
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+// powerState tracks the sleep blockers held for the page. Each is a
+// platform handle (an IOPM assertion or a ScreenSaver inhibit cookie) that
+// must be released exactly once.
+var powerState struct {
+	sync.Mutex
+	seq      int
+	blockers map[string]sleepBlocker
+}
+
+// sleepBlocker is a held request to keep the display awake.
+type sleepBlocker struct {
+	Reason  string
+	release func() error
+}
+
+// RegisterPower registers the display sleep handlers with security checks.
+// Blockers are released on shutdown; the OS also drops them when the
+// process exits, so a crash does not leave the display awake.
+func RegisterPower(router *ipc.Router, policy *security.Policy) {
+	router.Handle("power.preventSleep", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermPower); err != nil {
+			return nil, err
+		}
+		var p struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Reason == "" {
+			return nil, fmt.Errorf("power.preventSleep: reason is required")
+		}
+
+		release, err := preventDisplaySleep(p.Reason)
+		if err != nil {
+			return nil, err
+		}
+		powerState.Lock()
+		defer powerState.Unlock()
+		if powerState.blockers == nil {
+			powerState.blockers = make(map[string]sleepBlocker)
+		}
+		powerState.seq++
+		id := fmt.Sprintf("sleep-%d", powerState.seq)
+		powerState.blockers[id] = sleepBlocker{Reason: p.Reason, release: release}
+		return map[string]string{"id": id}, nil
+	})
+
+	router.Handle("power.allowSleep", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermPower); err != nil {
+			return nil, err
+		}
+		var p struct {
+			ID string `json:"id"`
+		}
+		if len(params) > 0 {
+			if err := json.Unmarshal(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		if p.ID == "" {
+			return nil, releaseSleepBlockers()
+		}
+
+		powerState.Lock()
+		defer powerState.Unlock()
+		b, ok := powerState.blockers[p.ID]
+		if !ok {
+			return nil, fmt.Errorf("power.allowSleep: no sleep blocker with id %q", p.ID)
+		}
+		delete(powerState.blockers, p.ID)
+		return nil, b.release()
+	})
+
+	router.Handle("power.isPreventingSleep", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermPower); err != nil {
+			return nil, err
+		}
+		powerState.Lock()
+		defer powerState.Unlock()
+		reasons := make([]string, 0, len(powerState.blockers))
+		for _, b := range powerState.blockers {
+			reasons = append(reasons, b.Reason)
+		}
+		sort.Strings(reasons)
+		return map[string]any{"preventing": len(reasons) > 0, "reasons": reasons}, nil
+	})
+
+	router.OnShutdown(func() {
+		releaseSleepBlockers()
+	})
+}
+
+// releaseSleepBlockers releases every held blocker, returning the first error.
+func releaseSleepBlockers() error {
+	powerState.Lock()
+	defer powerState.Unlock()
+	var first error
+	for id, b := range powerState.blockers {
+		if err := b.release(); err != nil && first == nil {
+			first = err
+		}
+		delete(powerState.blockers, id)
+	}
+	return first
+}
@@ -0,0 +1,35 @@
+//go:build darwin
+
+package api
+
+/*
+#cgo darwin CFLAGS: -x objective-c
+#cgo darwin LDFLAGS: -framework Cocoa -framework IOKit
+
+#include <stdlib.h>
+
+extern unsigned int PowerPreventDisplaySleep(const char* reason);
+extern int PowerReleaseAssertion(unsigned int assertionID);
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// preventDisplaySleep takes an IOPM assertion that keeps the display from
+// idling to sleep. It returns a function that releases the assertion.
+func preventDisplaySleep(reason string) (func() error, error) {
+	cReason := C.CString(reason)
+	defer C.free(unsafe.Pointer(cReason))
+	id := C.PowerPreventDisplaySleep(cReason)
+	if id == 0 {
+		return nil, fmt.Errorf("power: failed to create power assertion")
+	}
+	return func() error {
+		if C.PowerReleaseAssertion(id) == 0 {
+			return fmt.Errorf("power: failed to release power assertion")
+		}
+		return nil
+	}, nil
+}
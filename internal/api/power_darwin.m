@@ -0,0 +1,22 @@
+#import <Cocoa/Cocoa.h>
+#import <IOKit/pwr_mgt/IOPMLib.h>
+
+// PowerPreventDisplaySleep creates an assertion that keeps the display (and
+// so the system) awake while the user is idle. Returns the assertion id, or 0
+// on failure. Assertions are released by the kernel when the process exits.
+unsigned int PowerPreventDisplaySleep(const char* reason) {
+    IOPMAssertionID assertionID = kIOPMNullAssertionID;
+    NSString *name = [NSString stringWithUTF8String:reason];
+    IOReturn result = IOPMAssertionCreateWithName(kIOPMAssertionTypePreventUserIdleDisplaySleep,
+        kIOPMAssertionLevelOn, (CFStringRef)name, &assertionID);
+    if (result != kIOReturnSuccess) {
+        return 0;
+    }
+    return (unsigned int)assertionID;
+}
+
+// PowerReleaseAssertion releases an assertion from PowerPreventDisplaySleep.
+// Returns 1 on success.
+int PowerReleaseAssertion(unsigned int assertionID) {
+    return IOPMAssertionRelease((IOPMAssertionID)assertionID) == kIOReturnSuccess ? 1 : 0;
+}
@@ -0,0 +1,53 @@
+//go:build linux
+
+package api
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lightshell-dev/lightshell/internal/dbus"
+)
+
+const (
+	screenSaverName = "org.freedesktop.ScreenSaver"
+	screenSaverPath = "/org/freedesktop/ScreenSaver"
+)
+
+// preventDisplaySleep asks the desktop's ScreenSaver service to inhibit idle
+// blanking. The inhibit belongs to the bus connection, so the connection is
+// kept open until the returned function releases it; if the process dies the
+// bus closes the connection and the desktop drops the inhibit.
+func preventDisplaySleep(reason string) (func() error, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("power: %w", err)
+	}
+	app := filepath.Base(os.Args[0])
+	reply, err := conn.Call(screenSaverName, screenSaverPath, screenSaverName, "Inhibit", app, reason)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("power: screensaver inhibit failed: %w", err)
+	}
+	cookie, ok := firstUint32(reply)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("power: unexpected Inhibit reply %v", reply)
+	}
+	return func() error {
+		defer conn.Close()
+		if _, err := conn.Call(screenSaverName, screenSaverPath, screenSaverName, "UnInhibit", cookie); err != nil {
+			return fmt.Errorf("power: screensaver uninhibit failed: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+func firstUint32(values []any) (uint32, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+	v, ok := values[0].(uint32)
+	return v, ok
+}
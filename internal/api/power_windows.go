@@ -0,0 +1,49 @@
+//go:build windows
+
+package api
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+var procSetThreadExecutionState = syscall.NewLazyDLL("kernel32.dll").NewProc("SetThreadExecutionState")
+
+const (
+	esContinuous      = 0x80000000
+	esDisplayRequired = 0x00000002
+)
+
+// preventDisplaySleep keeps the display on with SetThreadExecutionState.
+// The request belongs to the calling thread, so each blocker gets its own
+// locked thread that holds the request until it is released. Windows drops
+// the request when the thread or process exits. reason is only shown on
+// macOS; Windows has no per-request label.
+func preventDisplaySleep(reason string) (func() error, error) {
+	errc := make(chan error)
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		if r, _, err := procSetThreadExecutionState.Call(esContinuous | esDisplayRequired); r == 0 {
+			errc <- fmt.Errorf("power: SetThreadExecutionState failed: %w", err)
+			return
+		}
+		errc <- nil
+		<-release
+		if r, _, err := procSetThreadExecutionState.Call(esContinuous); r == 0 {
+			done <- fmt.Errorf("power: SetThreadExecutionState failed: %w", err)
+			return
+		}
+		done <- nil
+	}()
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	return func() error {
+		close(release)
+		return <-done
+	}, nil
+}
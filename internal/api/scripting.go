@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/scripting"
+	"github.com/lightshell-dev/lightshell/internal/webview"
+)
+
+// appleEventHandler receives the Apple Events forwarded by the platform
+// layer. An error is returned to the script that sent the event.
+var appleEventHandler func(kind string, payload []byte) error
+
+// RegisterScripting forwards the Apple Events of a scriptable app
+// (scripting.enabled) into it. Files opened from Finder or with "open" are
+// sent as app.openFiles events and "run action" as app.scriptAction events;
+// quit quits the app like app.quit, so shutdown hooks run.
+func RegisterScripting(router *ipc.Router, wv webview.Webview, actions []string) {
+	appleEventHandler = func(kind string, payload []byte) error {
+		switch kind {
+		case "open":
+			var paths []string
+			if err := json.Unmarshal(payload, &paths); err != nil {
+				return err
+			}
+			router.SendEvent("app.openFiles", map[string]any{"paths": paths})
+		case "runAction":
+			var p struct {
+				Action   string `json:"action"`
+				Argument string `json:"argument"`
+			}
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return err
+			}
+			if !scripting.Allows(actions, p.Action) {
+				return fmt.Errorf("unknown action %q", p.Action)
+			}
+			router.SendEvent("app.scriptAction", map[string]any{"action": p.Action, "argument": p.Argument})
+		case "quit":
+			wv.Quit()
+		}
+		return nil
+	}
+	enableAppleEvents()
+}
@@ -0,0 +1,32 @@
+//go:build darwin
+
+package api
+
+/*
+#cgo darwin CFLAGS: -x objective-c
+#cgo darwin LDFLAGS: -framework Cocoa
+
+#include <stdlib.h>
+
+extern void ScriptingEnable();
+*/
+import "C"
+
+// goAppleEvent is called on the main thread for each handled Apple Event.
+// It returns an error message for the sender, which the caller frees, or
+// NULL on success.
+//
+//export goAppleEvent
+func goAppleEvent(kind, payload *C.char) *C.char {
+	if appleEventHandler == nil {
+		return nil
+	}
+	if err := appleEventHandler(C.GoString(kind), []byte(C.GoString(payload))); err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+func enableAppleEvents() {
+	C.ScriptingEnable()
+}
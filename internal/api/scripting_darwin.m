@@ -0,0 +1,87 @@
+#import <Cocoa/Cocoa.h>
+
+extern char* goAppleEvent(const char* kind, const char* payload);
+
+// "run action" of the LightShell suite, and its optional "with" argument
+#define kLightShellSuite 'LSap'
+#define kRunActionEvent 'rAct'
+#define kArgumentKeyword 'wIth'
+
+// ScriptingHandler forwards open, quit and run action Apple Events to Go
+@interface ScriptingHandler : NSObject
+@end
+
+@implementation ScriptingHandler
+- (void)forward:(const char *)kind payload:(id)payload reply:(NSAppleEventDescriptor *)reply {
+    NSData *data = [NSJSONSerialization dataWithJSONObject:payload options:0 error:nil];
+    NSString *json = data ? [[[NSString alloc] initWithData:data encoding:NSUTF8StringEncoding] autorelease] : @"null";
+    char *err = goAppleEvent(kind, [json UTF8String]);
+    if (err != NULL) {
+        [reply setParamDescriptor:[NSAppleEventDescriptor descriptorWithInt32:errAEEventFailed] forKeyword:keyErrorNumber];
+        [reply setParamDescriptor:[NSAppleEventDescriptor descriptorWithString:[NSString stringWithUTF8String:err]] forKeyword:keyErrorString];
+        free(err);
+    }
+}
+
+- (void)openDocuments:(NSAppleEventDescriptor *)event withReplyEvent:(NSAppleEventDescriptor *)reply {
+    NSAppleEventDescriptor *direct = [event paramDescriptorForKeyword:keyDirectObject];
+    NSMutableArray *paths = [NSMutableArray array];
+    // A single file may arrive as a plain descriptor rather than a list
+    if (direct.descriptorType == typeAEList) {
+        for (NSInteger i = 1; i <= direct.numberOfItems; i++) {
+            NSURL *url = [direct descriptorAtIndex:i].fileURLValue;
+            if (url.path) [paths addObject:url.path];
+        }
+    } else if (direct.fileURLValue.path) {
+        [paths addObject:direct.fileURLValue.path];
+    }
+    [self forward:"open" payload:paths reply:reply];
+}
+
+- (void)quit:(NSAppleEventDescriptor *)event withReplyEvent:(NSAppleEventDescriptor *)reply {
+    [self forward:"quit" payload:@{} reply:reply];
+}
+
+- (void)runAction:(NSAppleEventDescriptor *)event withReplyEvent:(NSAppleEventDescriptor *)reply {
+    NSString *action = [[event paramDescriptorForKeyword:keyDirectObject] stringValue] ?: @"";
+    NSString *argument = [[event paramDescriptorForKeyword:kArgumentKeyword] stringValue] ?: @"";
+    [self forward:"runAction" payload:@{@"action": action, @"argument": argument} reply:reply];
+}
+@end
+
+static ScriptingHandler *scriptingHandler = nil;
+
+static void installAppleEventHandlers(void) {
+    NSAppleEventManager *manager = [NSAppleEventManager sharedAppleEventManager];
+    [manager setEventHandler:scriptingHandler andSelector:@selector(openDocuments:withReplyEvent:)
+        forEventClass:kCoreEventClass andEventID:kAEOpenDocuments];
+    [manager setEventHandler:scriptingHandler andSelector:@selector(quit:withReplyEvent:)
+        forEventClass:kCoreEventClass andEventID:kAEQuitApplication];
+    [manager setEventHandler:scriptingHandler andSelector:@selector(runAction:withReplyEvent:)
+        forEventClass:kLightShellSuite andEventID:kRunActionEvent];
+}
+
+// ScriptingEnable installs the Apple Event handlers. NSApplication installs
+// its own open and quit handlers while it finishes launching, so until then
+// ours wait for the will-finish-launching notification, which is still in
+// time for the files the app was launched to open. It is called before the
+// run loop starts, so it must not wait for the main queue.
+void ScriptingEnable() {
+    void (^enable)(void) = ^{
+        if (scriptingHandler != nil) return;
+        scriptingHandler = [[ScriptingHandler alloc] init];
+        if ([NSApp isRunning]) {
+            installAppleEventHandlers();
+            return;
+        }
+        [[NSNotificationCenter defaultCenter] addObserverForName:NSApplicationWillFinishLaunchingNotification
+            object:nil queue:nil usingBlock:^(NSNotification *note) {
+                installAppleEventHandlers();
+            }];
+    };
+    if ([NSThread isMainThread]) {
+        enable();
+    } else {
+        dispatch_async(dispatch_get_main_queue(), enable);
+    }
+}
@@ -0,0 +1,6 @@
+//go:build linux
+
+package api
+
+// enableAppleEvents is a no-op: Apple Events exist only on macOS.
+func enableAppleEvents() {}
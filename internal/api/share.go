@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+// shareRouter receives share.completed events from the platform picker.
+var shareRouter *ipc.Router
+
+var shareSeq atomic.Int64
+
+// RegisterShare registers the native share sheet handler. share.show returns
+// an id as soon as the picker is shown; the outcome arrives later as a
+// share.completed event carrying the same id.
+func RegisterShare(router *ipc.Router, policy *security.Policy) {
+	shareRouter = router
+	router.Handle("share.show", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermShare); err != nil {
+			return nil, err
+		}
+		var p struct {
+			Text  string   `json:"text"`
+			URL   string   `json:"url"`
+			Files []string `json:"files"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Text == "" && p.URL == "" && len(p.Files) == 0 {
+			return nil, fmt.Errorf("share: text, url, or files is required")
+		}
+
+		files := make([]string, 0, len(p.Files))
+		if len(p.Files) > 0 {
+			// Sharing a file hands its contents to another app, so it needs
+			// the same access as reading it.
+			if err := policy.Check(security.PermFS); err != nil {
+				return nil, err
+			}
+			for _, f := range p.Files {
+				if err := policy.CheckFSRead(f); err != nil {
+					return nil, err
+				}
+				abs, err := filepath.Abs(f)
+				if err != nil {
+					return nil, err
+				}
+				if _, err := os.Stat(abs); err != nil {
+					return nil, err
+				}
+				files = append(files, abs)
+			}
+		}
+
+		id := fmt.Sprintf("share-%d", shareSeq.Add(1))
+		if err := showSharePicker(id, p.Text, p.URL, files); err != nil {
+			return nil, err
+		}
+		return map[string]string{"id": id}, nil
+	})
+}
+
+// emitShareCompleted reports the outcome of a share. service is the title of
+// the chosen service, or empty if the user dismissed the picker.
+func emitShareCompleted(id, service string, completed bool, errMsg string) {
+	if shareRouter == nil {
+		return
+	}
+	data := map[string]any{
+		"id":        id,
+		"service":   service,
+		"completed": completed,
+	}
+	if errMsg != "" {
+		data["error"] = errMsg
+	}
+	shareRouter.SendEvent("share.completed", data)
+}
@@ -0,0 +1,48 @@
+//go:build darwin
+
+package api
+
+/*
+#cgo darwin CFLAGS: -x objective-c
+#cgo darwin LDFLAGS: -framework Cocoa
+
+#include <stdlib.h>
+
+extern int ShareShow(const char* shareId, const char* text, const char* url, const char** files, int fileCount);
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+//export goShareCompleted
+func goShareCompleted(shareID *C.char, service *C.char, completed C.int, errMsg *C.char) {
+	emitShareCompleted(C.GoString(shareID), C.GoString(service), completed != 0, C.GoString(errMsg))
+}
+
+func showSharePicker(id, text, url string, files []string) error {
+	cID := C.CString(id)
+	defer C.free(unsafe.Pointer(cID))
+	cText := C.CString(text)
+	defer C.free(unsafe.Pointer(cText))
+	cURL := C.CString(url)
+	defer C.free(unsafe.Pointer(cURL))
+
+	var cFiles **C.char
+	if len(files) > 0 {
+		arr := C.malloc(C.size_t(len(files)) * C.size_t(unsafe.Sizeof(uintptr(0))))
+		defer C.free(arr)
+		slice := unsafe.Slice((**C.char)(arr), len(files))
+		for i, f := range files {
+			slice[i] = C.CString(f)
+			defer C.free(unsafe.Pointer(slice[i]))
+		}
+		cFiles = (**C.char)(arr)
+	}
+
+	if C.ShareShow(cID, cText, cURL, cFiles, C.int(len(files))) == 0 {
+		return fmt.Errorf("share: no window to show the share picker in")
+	}
+	return nil
+}
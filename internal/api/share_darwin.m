@@ -0,0 +1,82 @@
+#import <Cocoa/Cocoa.h>
+
+extern void goShareCompleted(const char* shareId, const char* service, int completed, const char* errMsg);
+
+// --- SharePickerDelegate: reports the picker and service outcome for one share ---
+@interface SharePickerDelegate : NSObject <NSSharingServicePickerDelegate, NSSharingServiceDelegate>
+@property (nonatomic, copy) NSString *shareId;
+@property (nonatomic, retain) NSSharingServicePicker *picker; // kept alive until the share finishes
+@end
+
+@implementation SharePickerDelegate
+
+- (void)dealloc {
+    [_shareId release];
+    [_picker release];
+    [super dealloc];
+}
+
+- (void)finishWithService:(NSSharingService *)service completed:(BOOL)completed error:(NSError *)error {
+    const char *title = service ? [service.title UTF8String] : "";
+    const char *msg = error ? [error.localizedDescription UTF8String] : "";
+    goShareCompleted([self.shareId UTF8String], title ? title : "", completed ? 1 : 0, msg ? msg : "");
+    [self release];
+}
+
+- (void)sharingServicePicker:(NSSharingServicePicker *)picker didChooseSharingService:(NSSharingService *)service {
+    if (service == nil) {
+        [self finishWithService:nil completed:NO error:nil];
+        return;
+    }
+    service.delegate = self;
+}
+
+- (void)sharingService:(NSSharingService *)service didShareItems:(NSArray *)items {
+    [self finishWithService:service completed:YES error:nil];
+}
+
+- (void)sharingService:(NSSharingService *)service didFailToShareItems:(NSArray *)items error:(NSError *)error {
+    BOOL cancelled = [error.domain isEqualToString:NSCocoaErrorDomain] && error.code == NSUserCancelledError;
+    [self finishWithService:service completed:NO error:cancelled ? nil : error];
+}
+
+@end
+
+// ShareShow presents the share picker anchored to the key window's content
+// view. Returns 0 if there is no window to anchor to. Must be called on the
+// main thread, which is where IPC handlers run.
+int ShareShow(const char* shareId, const char* text, const char* url, const char** files, int fileCount) {
+    NSWindow *window = [NSApp keyWindow] ?: [NSApp mainWindow];
+    if (window == nil) {
+        return 0;
+    }
+
+    NSMutableArray *items = [NSMutableArray array];
+    if (text && strlen(text) > 0) {
+        [items addObject:[NSString stringWithUTF8String:text]];
+    }
+    if (url && strlen(url) > 0) {
+        NSURL *u = [NSURL URLWithString:[NSString stringWithUTF8String:url]];
+        if (u) {
+            [items addObject:u];
+        }
+    }
+    for (int i = 0; i < fileCount; i++) {
+        [items addObject:[NSURL fileURLWithPath:[NSString stringWithUTF8String:files[i]]]];
+    }
+
+    // The delegate releases itself once the share finishes or is dismissed.
+    SharePickerDelegate *delegate = [[SharePickerDelegate alloc] init];
+    delegate.shareId = [NSString stringWithUTF8String:shareId];
+
+    NSSharingServicePicker *picker = [[NSSharingServicePicker alloc] initWithItems:items];
+    picker.delegate = delegate;
+    delegate.picker = picker;
+
+    NSView *view = window.contentView;
+    NSRect bounds = view.bounds;
+    NSRect anchor = NSMakeRect(NSMidX(bounds), NSMidY(bounds), 1, 1);
+    [picker showRelativeToRect:anchor ofView:view preferredEdge:NSMinYEdge];
+    [picker release];
+    return 1;
+}
@@ -0,0 +1,11 @@
+//go:build linux
+
+package api
+
+import "fmt"
+
+// showSharePicker is not implemented on Linux: xdg-desktop-portal has no
+// general share portal yet, only per-service integrations.
+func showSharePicker(id, text, url string, files []string) error {
+	return fmt.Errorf("share not yet implemented on linux")
+}
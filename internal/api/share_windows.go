@@ -0,0 +1,12 @@
+//go:build windows
+
+package api
+
+import "fmt"
+
+// showSharePicker is not implemented on Windows yet: the share sheet is the
+// WinRT DataTransferManager, which needs an interop layer this package
+// does not have.
+func showSharePicker(id, text, url string, files []string) error {
+	return fmt.Errorf("share not yet implemented on windows")
+}
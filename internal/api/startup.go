@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/startup"
+)
+
+// RegisterStartup registers the startup metrics handlers. The client library
+// reports page milestones through app.startupMarks; each report is persisted so
+// lightshell doctor can check the startup budget.
+func RegisterStartup(router *ipc.Router, tracker *startup.Tracker, appName string) {
+	router.Handle("app.getStartupMetrics", func(params json.RawMessage) (any, error) {
+		return tracker.Report(), nil
+	})
+
+	router.Handle("app.startupMarks", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Marks map[string]float64 `json:"marks"` // epoch milliseconds
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		for name, ms := range p.Marks {
+			switch name {
+			case startup.LoadCommitted, startup.Ready, startup.DOMContentLoaded, startup.FirstPaint:
+			default:
+				continue // Go records the other milestones itself
+			}
+			if ms <= 0 {
+				continue // milestone not available in this webview
+			}
+			tracker.MarkAt(name, time.Unix(0, int64(ms*float64(time.Millisecond))))
+		}
+		return nil, startup.Save(startup.ReportPath(appName), tracker.Report())
+	})
+}
@@ -3,10 +3,10 @@ package api
 import (
 	"encoding/json"
 	"os"
-	"path/filepath"
 	goruntime "runtime"
 
 	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/paths"
 	"github.com/lightshell-dev/lightshell/internal/webview"
 )
 
@@ -34,7 +34,7 @@ func RegisterSystem(router *ipc.Router, appVersion string, appName string, wv we
 	})
 
 	router.Handle("app.quit", func(params json.RawMessage) (any, error) {
-		wv.Close()
+		wv.Quit()
 		return nil, nil
 	})
 
@@ -43,20 +43,26 @@ func RegisterSystem(router *ipc.Router, appVersion string, appName string, wv we
 	})
 
 	router.Handle("app.dataDir", func(params json.RawMessage) (any, error) {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, err
-		}
-		var dir string
-		switch goruntime.GOOS {
-		case "darwin":
-			dir = filepath.Join(home, "Library", "Application Support", appName)
-		default:
-			dir = filepath.Join(home, ".local", "share", appName)
-		}
+		dir := paths.DataDir(appName)
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			return nil, err
 		}
 		return dir, nil
 	})
+
+	router.Handle("app.paths", func(params json.RawMessage) (any, error) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dirs := paths.For(appName)
+		return map[string]string{
+			"data":   dirs.Data,
+			"config": dirs.Config,
+			"cache":  dirs.Cache,
+			"log":    dirs.Log,
+			"home":   home,
+			"temp":   os.TempDir(),
+		}, nil
+	})
 }
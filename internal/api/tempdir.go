@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/security"
+	"github.com/lightshell-dev/lightshell/internal/tempspace"
+)
+
+// RegisterTempDirs registers fs.createTempDir. Directories are created in a
+// session under the app's temp root ($APP_TEMP), which is removed on
+// shutdown. Sessions left by a crash are removed here, at startup.
+func RegisterTempDirs(router *ipc.Router, policy *security.Policy, appName string) {
+	root := tempspace.Root(appName)
+	tempspace.Recover(root)
+
+	var (
+		mu      sync.Mutex
+		session *tempspace.Session
+	)
+
+	router.Handle("fs.createTempDir", func(params json.RawMessage) (any, error) {
+		if err := policy.Check(security.PermFS); err != nil {
+			return nil, err
+		}
+		var p struct {
+			Prefix string `json:"prefix"`
+		}
+		if len(params) > 0 {
+			if err := json.Unmarshal(params, &p); err != nil {
+				return nil, err
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if session == nil {
+			s, err := tempspace.Open(root)
+			if err != nil {
+				return nil, err
+			}
+			session = s
+		}
+		// A scoped fs policy has to allow $APP_TEMP, or the page could not
+		// use the directory anyway.
+		if err := policy.CheckFSWrite(filepath.Join(session.Dir, p.Prefix)); err != nil {
+			return nil, err
+		}
+		return session.CreateTempDir(p.Prefix)
+	})
+
+	router.OnShutdown(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if session != nil {
+			session.Close()
+		}
+	})
+}
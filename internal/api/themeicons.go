@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/themeicon"
+	"github.com/lightshell-dev/lightshell/internal/webview"
+)
+
+// appearance is the system appearance last reported by the page.
+var appearance struct {
+	mu       sync.Mutex
+	dark     bool
+	reported bool
+}
+
+func appearanceIsDark() bool {
+	appearance.mu.Lock()
+	defer appearance.mu.Unlock()
+	return appearance.dark
+}
+
+// iconFS holds the icons named by relative paths, when they are not files
+// on disk: a built app's pages and theme icons.
+var iconFS fs.FS
+
+// SetIconFS has icons named by relative paths read from fsys: the
+// themeIcons paths, and tray icons named as pages would, such as
+// "icons/tray.png". Paths that start with a variable like $APP_DATA are
+// still read from disk.
+func SetIconFS(fsys fs.FS) {
+	iconFS = fsys
+}
+
+// readIcon reads the icon at name for the appearance: its @dark variant
+// when dark is set and there is one.
+func readIcon(name string, dark bool) ([]byte, error) {
+	if iconFS == nil || filepath.IsAbs(name) {
+		return os.ReadFile(themeicon.Resolve(name, dark))
+	}
+	name = filepath.ToSlash(name)
+	if dark {
+		if data, err := fs.ReadFile(iconFS, themeicon.DarkName(name)); err == nil {
+			return data, nil
+		}
+	}
+	return fs.ReadFile(iconFS, name)
+}
+
+// RegisterThemeIcons keeps the icons named in themeIcons in step with the
+// system appearance. The client reports the appearance through
+// app.appearanceChanged on load and whenever prefers-color-scheme changes;
+// the window icon and the tray icon then switch to their @dark variants
+// and back. windowIcon and trayDefault are absolute paths, paths in the
+// icon FS, or empty.
+func RegisterThemeIcons(router *ipc.Router, wv webview.Webview, windowIcon, trayDefault string) {
+	trayIcon.mu.Lock()
+	trayIcon.fallback = trayDefault
+	trayIcon.mu.Unlock()
+
+	router.Handle("app.appearanceChanged", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Dark bool `json:"dark"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		appearance.mu.Lock()
+		changed := !appearance.reported || appearance.dark != p.Dark
+		appearance.dark, appearance.reported = p.Dark, true
+		appearance.mu.Unlock()
+		if !changed {
+			return nil, nil
+		}
+
+		updateTrayIcon(p.Dark)
+		if windowIcon == "" {
+			return nil, nil
+		}
+		data, err := readIcon(windowIcon, p.Dark)
+		if err != nil {
+			return nil, fmt.Errorf("themeIcons.window: %w", err)
+		}
+		return nil, wv.SetIcon(data)
+	})
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+)
+
+// toolbarRouter receives toolbar.click and touchBar.click events from the
+// native controls.
+var toolbarRouter *ipc.Router
+
+// barItem is an item of the window toolbar or the Touch Bar.
+type barItem struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"` // "button" (default), "label" (Touch Bar), "space", "flexibleSpace", "separator" (toolbar)
+	Label   string `json:"label"`
+	Icon    string `json:"icon"` // SF Symbol name, e.g. "square.and.arrow.up"
+	Tooltip string `json:"tooltip"`
+	Enabled bool   `json:"enabled"`
+}
+
+var toolbarDisplayModes = map[string]bool{"": true, "default": true, "iconAndLabel": true, "iconOnly": true, "labelOnly": true}
+
+// RegisterToolbar registers the macOS window toolbar and Touch Bar handlers.
+// Both are window chrome, so like the window API they need no permission.
+func RegisterToolbar(router *ipc.Router) {
+	toolbarRouter = router
+
+	router.Handle("toolbar.set", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Items       []json.RawMessage `json:"items"`
+			DisplayMode string            `json:"displayMode"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if !toolbarDisplayModes[p.DisplayMode] {
+			return nil, fmt.Errorf("toolbar.set: invalid displayMode %q: must be one of iconAndLabel, iconOnly, labelOnly", p.DisplayMode)
+		}
+		items, err := parseBarItems("toolbar.set", p.Items, map[string]bool{
+			"button": true, "space": true, "flexibleSpace": true, "separator": true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, setToolbar(items, p.DisplayMode)
+	})
+
+	router.Handle("toolbar.remove", func(params json.RawMessage) (any, error) {
+		return nil, setToolbar(nil, "")
+	})
+
+	router.Handle("touchBar.set", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		items, err := parseBarItems("touchBar.set", p.Items, map[string]bool{
+			"button": true, "label": true, "space": true, "flexibleSpace": true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, setTouchBar(items)
+	})
+
+	router.Handle("touchBar.remove", func(params json.RawMessage) (any, error) {
+		return nil, setTouchBar(nil)
+	})
+}
+
+// parseBarItems decodes and validates items, defaulting type to "button"
+// and enabled to true. Buttons need a unique id and a label or icon.
+func parseBarItems(method string, raw []json.RawMessage, types map[string]bool) ([]barItem, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%s: items is empty", method)
+	}
+	ids := make(map[string]bool, len(raw))
+	items := make([]barItem, 0, len(raw))
+	for i, r := range raw {
+		item := barItem{Enabled: true}
+		if err := json.Unmarshal(r, &item); err != nil {
+			return nil, fmt.Errorf("%s: item %d: %w", method, i, err)
+		}
+		if item.Type == "" {
+			item.Type = "button"
+		}
+		if !types[item.Type] {
+			return nil, fmt.Errorf("%s: item %d: unsupported type %q", method, i, item.Type)
+		}
+		switch item.Type {
+		case "button":
+			if item.ID == "" {
+				return nil, fmt.Errorf("%s: item %d: buttons need an id", method, i)
+			}
+			if item.Label == "" && item.Icon == "" {
+				return nil, fmt.Errorf("%s: item %q: a label or icon is required", method, item.ID)
+			}
+		case "label":
+			if item.Label == "" {
+				return nil, fmt.Errorf("%s: item %d: labels need text", method, i)
+			}
+		}
+		if item.ID != "" {
+			if ids[item.ID] {
+				return nil, fmt.Errorf("%s: duplicate item id %q", method, item.ID)
+			}
+			ids[item.ID] = true
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// emitBarClick sends event ("toolbar.click" or "touchBar.click") for the
+// item with id.
+func emitBarClick(event, id string) {
+	if toolbarRouter == nil {
+		return
+	}
+	toolbarRouter.SendEvent(event, map[string]string{"id": id})
+}
@@ -0,0 +1,68 @@
+//go:build darwin
+
+package api
+
+/*
+#cgo darwin CFLAGS: -x objective-c
+#cgo darwin LDFLAGS: -framework Cocoa
+
+#include <stdlib.h>
+
+extern int ToolbarSet(const char* jsonItems, const char* displayMode);
+extern int TouchBarSet(const char* jsonItems);
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"unsafe"
+)
+
+//export goToolbarClicked
+func goToolbarClicked(id *C.char) {
+	emitBarClick("toolbar.click", C.GoString(id))
+}
+
+//export goTouchBarClicked
+func goTouchBarClicked(id *C.char) {
+	emitBarClick("touchBar.click", C.GoString(id))
+}
+
+// setToolbar shows items as the window toolbar, or removes it when items is nil.
+func setToolbar(items []barItem, displayMode string) error {
+	cJSON, err := barItemsJSON(items)
+	if err != nil {
+		return err
+	}
+	defer C.free(unsafe.Pointer(cJSON))
+	cMode := C.CString(displayMode)
+	defer C.free(unsafe.Pointer(cMode))
+	if C.ToolbarSet(cJSON, cMode) == 0 {
+		return fmt.Errorf("toolbar: no window to attach the toolbar to")
+	}
+	return nil
+}
+
+// setTouchBar shows items in the Touch Bar, or removes them when items is nil.
+func setTouchBar(items []barItem) error {
+	cJSON, err := barItemsJSON(items)
+	if err != nil {
+		return err
+	}
+	defer C.free(unsafe.Pointer(cJSON))
+	if C.TouchBarSet(cJSON) == 0 {
+		return fmt.Errorf("touchBar: no window to attach the Touch Bar to")
+	}
+	return nil
+}
+
+func barItemsJSON(items []barItem) (*C.char, error) {
+	if items == nil {
+		items = []barItem{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	return C.CString(string(data)), nil
+}
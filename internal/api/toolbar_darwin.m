@@ -0,0 +1,239 @@
+#import <Cocoa/Cocoa.h>
+
+extern void goToolbarClicked(const char* itemId);
+extern void goTouchBarClicked(const char* itemId);
+
+static NSArray *parseBarItems(const char* jsonItems) {
+    NSData *data = [NSData dataWithBytes:jsonItems length:strlen(jsonItems)];
+    id parsed = [NSJSONSerialization JSONObjectWithData:data options:0 error:nil];
+    return [parsed isKindOfClass:[NSArray class]] ? parsed : @[];
+}
+
+static NSImage *symbolImage(NSString *name, NSString *description) {
+    if (name.length == 0) {
+        return nil;
+    }
+    if (@available(macOS 11.0, *)) {
+        return [NSImage imageWithSystemSymbolName:name accessibilityDescription:description];
+    }
+    return nil;
+}
+
+// --- Window toolbar ---
+
+// ToolbarController is the NSToolbar delegate. Item identifiers are the
+// indexes of the specs so that items without an id (spaces) stay distinct.
+@interface ToolbarController : NSObject <NSToolbarDelegate>
+@property (nonatomic, retain) NSArray *items;
+@end
+
+@implementation ToolbarController
+
+- (NSToolbarItemIdentifier)identifierAt:(NSUInteger)i {
+    NSString *type = self.items[i][@"type"];
+    if ([type isEqualToString:@"space"]) return NSToolbarSpaceItemIdentifier;
+    if ([type isEqualToString:@"flexibleSpace"]) return NSToolbarFlexibleSpaceItemIdentifier;
+    if ([type isEqualToString:@"separator"]) return NSToolbarSeparatorItemIdentifier;
+    return [NSString stringWithFormat:@"lightshell.%lu", (unsigned long)i];
+}
+
+- (NSArray<NSToolbarItemIdentifier> *)toolbarDefaultItemIdentifiers:(NSToolbar *)toolbar {
+    NSMutableArray *ids = [NSMutableArray array];
+    for (NSUInteger i = 0; i < self.items.count; i++) {
+        [ids addObject:[self identifierAt:i]];
+    }
+    return ids;
+}
+
+- (NSArray<NSToolbarItemIdentifier> *)toolbarAllowedItemIdentifiers:(NSToolbar *)toolbar {
+    return [self toolbarDefaultItemIdentifiers:toolbar];
+}
+
+- (NSToolbarItem *)toolbar:(NSToolbar *)toolbar itemForItemIdentifier:(NSToolbarItemIdentifier)identifier
+ willBeInsertedIntoToolbar:(BOOL)flag {
+    if (![identifier hasPrefix:@"lightshell."]) {
+        return nil;
+    }
+    NSUInteger i = (NSUInteger)[[identifier substringFromIndex:11] integerValue];
+    if (i >= self.items.count) {
+        return nil;
+    }
+    NSDictionary *spec = self.items[i];
+    NSString *label = spec[@"label"];
+
+    NSToolbarItem *item = [[[NSToolbarItem alloc] initWithItemIdentifier:identifier] autorelease];
+    item.label = label;
+    item.paletteLabel = label;
+    item.toolTip = [spec[@"tooltip"] length] > 0 ? spec[@"tooltip"] : nil;
+    item.image = symbolImage(spec[@"icon"], label);
+    item.enabled = [spec[@"enabled"] boolValue];
+    item.tag = (NSInteger)i;
+    item.target = self;
+    item.action = @selector(itemClicked:);
+    // Keep the item enabled as set rather than validating it against the action
+    item.autovalidates = NO;
+    return item;
+}
+
+- (void)itemClicked:(NSToolbarItem *)sender {
+    NSString *itemId = self.items[(NSUInteger)sender.tag][@"id"];
+    goToolbarClicked([itemId UTF8String]);
+}
+
+- (void)dealloc {
+    [_items release];
+    [super dealloc];
+}
+
+@end
+
+static ToolbarController *toolbarController = nil;
+
+// ToolbarSet replaces the key window's toolbar with items, or removes it when
+// items is empty. Returns 0 if there is no window. Must be called on the main
+// thread, which is where IPC handlers run.
+int ToolbarSet(const char* jsonItems, const char* displayMode) {
+    NSWindow *window = [NSApp keyWindow] ?: [NSApp mainWindow];
+    if (window == nil) {
+        return 0;
+    }
+    NSArray *items = parseBarItems(jsonItems);
+    if (items.count == 0) {
+        window.toolbar = nil;
+        [toolbarController release];
+        toolbarController = nil;
+        return 1;
+    }
+
+    ToolbarController *controller = [[ToolbarController alloc] init];
+    controller.items = items;
+
+    // A fresh identifier per call so AppKit does not restore a cached layout
+    static NSUInteger generation = 0;
+    NSString *identifier = [NSString stringWithFormat:@"lightshell.toolbar.%lu", (unsigned long)++generation];
+    NSToolbar *toolbar = [[NSToolbar alloc] initWithIdentifier:identifier];
+    toolbar.delegate = controller;
+    toolbar.allowsUserCustomization = NO;
+
+    NSString *mode = [NSString stringWithUTF8String:displayMode];
+    if ([mode isEqualToString:@"iconAndLabel"]) {
+        toolbar.displayMode = NSToolbarDisplayModeIconAndLabel;
+    } else if ([mode isEqualToString:@"iconOnly"]) {
+        toolbar.displayMode = NSToolbarDisplayModeIconOnly;
+    } else if ([mode isEqualToString:@"labelOnly"]) {
+        toolbar.displayMode = NSToolbarDisplayModeLabelOnly;
+    }
+
+    window.toolbar = toolbar;
+    [toolbar release];
+    // The toolbar does not retain its delegate
+    [toolbarController release];
+    toolbarController = controller;
+    return 1;
+}
+
+// --- Touch Bar ---
+
+@interface TouchBarController : NSObject <NSTouchBarDelegate>
+@property (nonatomic, retain) NSArray *items;
+@end
+
+@implementation TouchBarController
+
+- (NSTouchBarItemIdentifier)identifierAt:(NSUInteger)i {
+    NSString *type = self.items[i][@"type"];
+    if ([type isEqualToString:@"space"]) return NSTouchBarItemIdentifierFixedSpaceSmall;
+    if ([type isEqualToString:@"flexibleSpace"]) return NSTouchBarItemIdentifierFlexibleSpace;
+    return [NSString stringWithFormat:@"lightshell.%lu", (unsigned long)i];
+}
+
+- (NSTouchBar *)makeTouchBar {
+    NSTouchBar *bar = [[[NSTouchBar alloc] init] autorelease];
+    bar.delegate = self;
+    NSMutableArray *ids = [NSMutableArray array];
+    for (NSUInteger i = 0; i < self.items.count; i++) {
+        [ids addObject:[self identifierAt:i]];
+    }
+    bar.defaultItemIdentifiers = ids;
+    return bar;
+}
+
+- (NSTouchBarItem *)touchBar:(NSTouchBar *)touchBar makeItemForIdentifier:(NSTouchBarItemIdentifier)identifier {
+    if (![identifier hasPrefix:@"lightshell."]) {
+        return nil;
+    }
+    NSUInteger i = (NSUInteger)[[identifier substringFromIndex:11] integerValue];
+    if (i >= self.items.count) {
+        return nil;
+    }
+    NSDictionary *spec = self.items[i];
+    NSString *label = spec[@"label"] ?: @"";
+
+    NSCustomTouchBarItem *item = [[[NSCustomTouchBarItem alloc] initWithIdentifier:identifier] autorelease];
+    if ([spec[@"type"] isEqualToString:@"label"]) {
+        item.view = [NSTextField labelWithString:label];
+        return item;
+    }
+
+    NSImage *image = symbolImage(spec[@"icon"], label);
+    NSButton *button;
+    if (image && label.length > 0) {
+        button = [NSButton buttonWithTitle:label image:image target:self action:@selector(buttonClicked:)];
+    } else if (image) {
+        button = [NSButton buttonWithImage:image target:self action:@selector(buttonClicked:)];
+    } else {
+        button = [NSButton buttonWithTitle:label target:self action:@selector(buttonClicked:)];
+    }
+    button.tag = (NSInteger)i;
+    button.enabled = [spec[@"enabled"] boolValue];
+    item.view = button;
+    item.customizationLabel = label;
+    return item;
+}
+
+- (void)buttonClicked:(NSButton *)sender {
+    NSString *itemId = self.items[(NSUInteger)sender.tag][@"id"];
+    goTouchBarClicked([itemId UTF8String]);
+}
+
+- (void)dealloc {
+    [_items release];
+    [super dealloc];
+}
+
+@end
+
+static TouchBarController *touchBarController = nil;
+
+// TouchBarSet replaces the Touch Bar shown for the key window, or restores
+// the default when items is empty. The bar is set on the window and on the
+// web view, which otherwise supplies its own text-editing bar. Returns 0 if
+// there is no window. Must be called on the main thread.
+int TouchBarSet(const char* jsonItems) {
+    NSWindow *window = [NSApp keyWindow] ?: [NSApp mainWindow];
+    if (window == nil) {
+        return 0;
+    }
+
+    NSTouchBar *bar = nil;
+    NSArray *items = parseBarItems(jsonItems);
+    TouchBarController *controller = nil;
+    if (items.count > 0) {
+        controller = [[TouchBarController alloc] init];
+        controller.items = items;
+        bar = [controller makeTouchBar];
+    }
+
+    window.touchBar = bar;
+    Class webViewClass = NSClassFromString(@"WKWebView");
+    for (NSView *view in window.contentView.subviews) {
+        if (webViewClass && [view isKindOfClass:webViewClass]) {
+            view.touchBar = bar;
+        }
+    }
+
+    // The bar does not retain its delegate
+    [touchBarController release];
+    touchBarController = controller;
+    return 1;
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package api
+
+import "fmt"
+
+func setToolbar(items []barItem, displayMode string) error {
+	return fmt.Errorf("toolbar not yet implemented on windows")
+}
+
+func setTouchBar(items []barItem) error {
+	return fmt.Errorf("touchBar is only available on macOS")
+}
@@ -1,14 +1,42 @@
 package api
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
 
 	"github.com/lightshell-dev/lightshell/internal/ipc"
 	"github.com/lightshell-dev/lightshell/internal/security"
 )
 
+// trayIcon remembers the icon the tray was set with, before its dark
+// variant is resolved, so an appearance change can swap it.
+var trayIcon struct {
+	mu       sync.Mutex
+	fallback string // themeIcons.tray from lightshell.json
+	icon     string // icon of the tray currently shown
+	data     []byte // icon given as base64 data instead, which has no dark variant
+	shown    bool
+}
+
+// trayIconParams name the tray icon: a file path, or base64-encoded image
+// bytes, such as a PNG drawn on a canvas.
+type trayIconParams struct {
+	Icon     string `json:"icon"`
+	IconData string `json:"iconData"`
+}
+
 // RegisterTray registers system tray API handlers with security checks.
+// Clicks on tray menu items are emitted as tray.click events, and clicks on
+// the icon itself as tray.iconClick events with the button pressed.
 func RegisterTray(router *ipc.Router, policy *security.Policy) {
+	menuState.Lock()
+	menuState.router = router
+	menuState.Unlock()
+
 	wrap := func(handler ipc.HandlerFunc) ipc.HandlerFunc {
 		return func(params json.RawMessage) (any, error) {
 			if err := policy.Check(security.PermTray); err != nil {
@@ -17,6 +45,203 @@ func RegisterTray(router *ipc.Router, policy *security.Policy) {
 			return handler(params)
 		}
 	}
-	router.Handle("tray.set", wrap(handleTraySet))
-	router.Handle("tray.remove", wrap(handleTrayRemove))
+	router.Handle("tray.set", wrap(func(params json.RawMessage) (any, error) {
+		var p struct {
+			trayIconParams
+			Tooltip string      `json:"tooltip"`
+			Menu    []*menuItem `json:"menu"`
+			MenuOn  string      `json:"menuOn"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if err := validateMenu(p.Menu, map[string]bool{}); err != nil {
+			return nil, err
+		}
+		rightClickOnly, err := trayMenuOn(p.MenuOn)
+		if err != nil {
+			return nil, err
+		}
+		trayIcon.mu.Lock()
+		defer trayIcon.mu.Unlock()
+		if err := setTrayIconSource(policy, p.trayIconParams); err != nil {
+			return nil, err
+		}
+		icon, err := trayIconBytes(appearanceIsDark())
+		if err != nil {
+			return nil, err
+		}
+		if err := traySet(p.Tooltip, icon); err != nil {
+			return nil, err
+		}
+		trayIcon.shown = true
+		if err := applyTrayMenu(p.Menu, rightClickOnly); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}))
+	router.Handle("tray.setIcon", wrap(func(params json.RawMessage) (any, error) {
+		var p trayIconParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		trayIcon.mu.Lock()
+		defer trayIcon.mu.Unlock()
+		if !trayIcon.shown {
+			return nil, errTrayNotShown
+		}
+		if err := setTrayIconSource(policy, p); err != nil {
+			return nil, err
+		}
+		icon, err := trayIconBytes(appearanceIsDark())
+		if err != nil {
+			return nil, err
+		}
+		traySetIcon(icon)
+		return nil, nil
+	}))
+	router.Handle("tray.setTooltip", wrap(func(params json.RawMessage) (any, error) {
+		var p struct {
+			Tooltip string `json:"tooltip"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		trayIcon.mu.Lock()
+		defer trayIcon.mu.Unlock()
+		if !trayIcon.shown {
+			return nil, errTrayNotShown
+		}
+		traySetTooltip(p.Tooltip)
+		return nil, nil
+	}))
+	router.Handle("tray.setMenu", wrap(func(params json.RawMessage) (any, error) {
+		var p struct {
+			Menu   []*menuItem `json:"menu"`
+			MenuOn string      `json:"menuOn"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if err := validateMenu(p.Menu, map[string]bool{}); err != nil {
+			return nil, err
+		}
+		rightClickOnly, err := trayMenuOn(p.MenuOn)
+		if err != nil {
+			return nil, err
+		}
+		trayIcon.mu.Lock()
+		defer trayIcon.mu.Unlock()
+		if !trayIcon.shown {
+			return nil, errTrayNotShown
+		}
+		return nil, applyTrayMenu(p.Menu, rightClickOnly)
+	}))
+	router.Handle("tray.remove", wrap(func(params json.RawMessage) (any, error) {
+		trayIcon.mu.Lock()
+		defer trayIcon.mu.Unlock()
+		if err := trayRemove(); err != nil {
+			return nil, err
+		}
+		trayIcon.shown = false
+		menuState.Lock()
+		menuState.trayTags = nil
+		menuState.Unlock()
+		return nil, nil
+	}))
+}
+
+var errTrayNotShown = fmt.Errorf("tray: no tray icon is shown; call tray.set first")
+
+// trayMenuOn parses tray.set's menuOn: the menu opens on any click of the
+// icon ("click", the default) or only on a right-click ("rightClick"),
+// leaving left-clicks to tray.iconClick listeners.
+func trayMenuOn(menuOn string) (rightClickOnly bool, err error) {
+	switch menuOn {
+	case "", "click":
+		return false, nil
+	case "rightClick":
+		return true, nil
+	}
+	return false, fmt.Errorf("tray: invalid menuOn %q (want \"click\" or \"rightClick\")", menuOn)
+}
+
+// setTrayIconSource records the icon p names, or themeIcons.tray when it
+// names none. The caller holds trayIcon.
+func setTrayIconSource(policy *security.Policy, p trayIconParams) error {
+	if p.IconData != "" {
+		data, err := base64.StdEncoding.DecodeString(p.IconData)
+		if err != nil {
+			return fmt.Errorf("tray: invalid base64 iconData: %w", err)
+		}
+		trayIcon.icon, trayIcon.data = "", data
+		return nil
+	}
+	trayIcon.icon, trayIcon.data = trayIcon.fallback, nil
+	switch {
+	case p.Icon == "":
+	case iconFS != nil && !strings.HasPrefix(p.Icon, "$"):
+		// A page path, as the page itself would use it
+		trayIcon.icon = strings.TrimPrefix(path.Clean("/"+p.Icon), "/")
+	default:
+		trayIcon.icon = policy.ExpandPath(p.Icon)
+	}
+	return nil
+}
+
+// trayIconBytes returns the current tray icon image for the appearance,
+// or nil when there is none. The caller holds trayIcon.
+func trayIconBytes(dark bool) ([]byte, error) {
+	if trayIcon.data != nil {
+		return trayIcon.data, nil
+	}
+	if trayIcon.icon == "" {
+		return nil, nil
+	}
+	data, err := readIcon(trayIcon.icon, dark)
+	if err != nil {
+		return nil, fmt.Errorf("tray: %w", err)
+	}
+	return data, nil
+}
+
+// applyTrayMenu shows items as the tray icon's menu; an empty menu removes
+// it. Items use the menu template format, so roles like "quit" work here too.
+func applyTrayMenu(items []*menuItem, rightClickOnly bool) error {
+	menuState.Lock()
+	defer menuState.Unlock()
+	tags := make(map[int]*menuItem)
+	native, err := nativeMenu(items, tags)
+	if err != nil {
+		return err
+	}
+	if err := traySetMenu(native, rightClickOnly); err != nil {
+		return err
+	}
+	menuState.trayTags = tags
+	return nil
+}
+
+// trayIconClicked emits a click on the tray icon itself, with the button
+// pressed ("left" or "right").
+func trayIconClicked(button string) {
+	menuState.Lock()
+	router := menuState.router
+	menuState.Unlock()
+	if router != nil {
+		router.SendEvent("tray.iconClick", map[string]any{"button": button})
+	}
+}
+
+// updateTrayIcon shows the tray icon's variant for the appearance. Icons
+// given as data have no variants.
+func updateTrayIcon(dark bool) {
+	trayIcon.mu.Lock()
+	defer trayIcon.mu.Unlock()
+	if !trayIcon.shown || trayIcon.data != nil || trayIcon.icon == "" {
+		return
+	}
+	if icon, err := trayIconBytes(dark); err == nil {
+		traySetIcon(icon)
+	}
 }
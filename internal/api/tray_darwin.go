@@ -8,8 +8,11 @@ package api
 
 #include <stdlib.h>
 
-extern void TraySet(const char* tooltip);
+extern void TraySet(const char* tooltip, const void* icon, int iconLen);
+extern void TraySetIcon(const void* icon, int iconLen);
+extern void TraySetTooltip(const char* tooltip);
 extern void TrayRemove();
+extern void TraySetMenu(const char* jsonItems, int rightClickOnly);
 extern void TraySetDevMenu();
 */
 import "C"
@@ -18,6 +21,10 @@ import (
 	"unsafe"
 )
 
+// TraySupported reports whether this platform can show a tray icon, which
+// a tray-only app ("window": false) needs to be reachable at all.
+const TraySupported = true
+
 var trayEvalFunc func(string)
 
 //export goTrayMenuAction
@@ -39,20 +46,57 @@ func SetupDevTray(evalFunc func(string)) {
 	C.TraySetDevMenu()
 }
 
-func handleTraySet(params json.RawMessage) (any, error) {
-	var p struct {
-		Tooltip string `json:"tooltip"`
+//export goTrayIconClicked
+func goTrayIconClicked(right C.int) {
+	if right != 0 {
+		trayIconClicked("right")
+	} else {
+		trayIconClicked("left")
 	}
-	if err := json.Unmarshal(params, &p); err != nil {
-		return nil, err
+}
+
+// iconPointer points at icon's bytes, which TraySet and TraySetIcon copy
+// before returning, or is nil for no icon.
+func iconPointer(icon []byte) unsafe.Pointer {
+	if len(icon) == 0 {
+		return nil
 	}
-	cTooltip := C.CString(p.Tooltip)
+	return unsafe.Pointer(&icon[0])
+}
+
+func traySet(tooltip string, icon []byte) error {
+	cTooltip := C.CString(tooltip)
+	defer C.free(unsafe.Pointer(cTooltip))
+	C.TraySet(cTooltip, iconPointer(icon), C.int(len(icon)))
+	return nil
+}
+
+func traySetIcon(icon []byte) {
+	C.TraySetIcon(iconPointer(icon), C.int(len(icon)))
+}
+
+func traySetTooltip(tooltip string) {
+	cTooltip := C.CString(tooltip)
 	defer C.free(unsafe.Pointer(cTooltip))
-	C.TraySet(cTooltip)
-	return nil, nil
+	C.TraySetTooltip(cTooltip)
+}
+
+func traySetMenu(items []nativeMenuItem, rightClickOnly bool) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	cJSON := C.CString(string(data))
+	defer C.free(unsafe.Pointer(cJSON))
+	right := 0
+	if rightClickOnly {
+		right = 1
+	}
+	C.TraySetMenu(cJSON, C.int(right))
+	return nil
 }
 
-func handleTrayRemove(params json.RawMessage) (any, error) {
+func trayRemove() error {
 	C.TrayRemove()
-	return nil, nil
+	return nil
 }
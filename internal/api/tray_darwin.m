@@ -4,6 +4,8 @@ static NSStatusItem *statusItem = nil;
 static NSStatusItem *devStatusItem = nil;
 
 extern void goTrayMenuAction(const char* itemId);
+extern void goTrayIconClicked(int right);
+extern NSMenu *MenuBuildTemplate(NSArray *items);
 
 // --- TrayMenuTarget: handles dev menu item clicks ---
 @interface TrayMenuTarget : NSObject
@@ -24,15 +26,102 @@ extern void goTrayMenuAction(const char* itemId);
 
 static TrayMenuTarget *menuTarget = nil;
 
-void TraySet(const char* tooltip) {
+static NSMenu *trayMenu = nil;
+static BOOL trayMenuRightClickOnly = NO;
+
+// --- TrayButtonTarget: handles clicks on the tray icon itself ---
+@interface TrayButtonTarget : NSObject
+- (void)buttonClicked:(id)sender;
+@end
+
+@implementation TrayButtonTarget
+- (void)buttonClicked:(id)sender {
+    NSEvent *event = [NSApp currentEvent];
+    BOOL right = event.type == NSEventTypeRightMouseDown ||
+        (event.modifierFlags & NSEventModifierFlagControl) != 0;
+    goTrayIconClicked(right ? 1 : 0);
+    if (trayMenu != nil && (right || !trayMenuRightClickOnly)) {
+        // Attached only while it is open, so the button keeps its action
+        statusItem.menu = trayMenu;
+        [statusItem.button performClick:nil];
+        statusItem.menu = nil;
+    }
+}
+@end
+
+static TrayButtonTarget *buttonTarget = nil;
+
+// Shows the image on the status item, scaled to the menu bar height, or
+// the "LS" title when there is no image.
+static void trayApplyIcon(NSData *data) {
+    NSImage *image = data ? [[[NSImage alloc] initWithData:data] autorelease] : nil;
+    if (image != nil && image.size.height > 0) {
+        CGFloat height = 18;
+        image.size = NSMakeSize(image.size.width * height / image.size.height, height);
+        statusItem.button.image = image;
+        statusItem.button.title = @"";
+    } else {
+        statusItem.button.image = nil;
+        statusItem.button.title = @"LS";
+    }
+}
+
+// TraySet shows the tray icon from encoded image bytes, which are copied
+// before it returns. An empty tooltip keeps the current one.
+void TraySet(const char* tooltip, const void* icon, int iconLen) {
+    NSData *data = iconLen > 0 ? [[NSData alloc] initWithBytes:icon length:iconLen] : nil;
+    NSString *tip = (tooltip && strlen(tooltip) > 0) ? [[NSString alloc] initWithUTF8String:tooltip] : nil;
     dispatch_async(dispatch_get_main_queue(), ^{
+        if (buttonTarget == nil) {
+            buttonTarget = [[TrayButtonTarget alloc] init];
+        }
         if (statusItem == nil) {
-            statusItem = [[NSStatusBar systemStatusBar] statusItemWithLength:NSVariableStatusItemLength];
+            statusItem = [[[NSStatusBar systemStatusBar] statusItemWithLength:NSVariableStatusItemLength] retain];
+            statusItem.button.target = buttonTarget;
+            statusItem.button.action = @selector(buttonClicked:);
+            [statusItem.button sendActionOn:NSEventMaskLeftMouseDown | NSEventMaskRightMouseDown];
         }
-        statusItem.button.title = @"LS";
-        if (tooltip && strlen(tooltip) > 0) {
-            statusItem.button.toolTip = [NSString stringWithUTF8String:tooltip];
+        trayApplyIcon(data);
+        if (tip != nil) {
+            statusItem.button.toolTip = tip;
         }
+        [data release];
+        [tip release];
+    });
+}
+
+void TraySetIcon(const void* icon, int iconLen) {
+    NSData *data = iconLen > 0 ? [[NSData alloc] initWithBytes:icon length:iconLen] : nil;
+    dispatch_async(dispatch_get_main_queue(), ^{
+        if (statusItem != nil) {
+            trayApplyIcon(data);
+        }
+        [data release];
+    });
+}
+
+void TraySetTooltip(const char* tooltip) {
+    NSString *tip = [[NSString alloc] initWithUTF8String:tooltip];
+    dispatch_async(dispatch_get_main_queue(), ^{
+        if (statusItem != nil) {
+            statusItem.button.toolTip = tip.length > 0 ? tip : nil;
+        }
+        [tip release];
+    });
+}
+
+// TraySetMenu sets the menu the tray icon opens from menu template JSON, as
+// MenuSet takes; an empty list removes it. The menu opens on any click, or
+// with rightClickOnly on right- and control-clicks only. Call after TraySet.
+void TraySetMenu(const char* jsonItems, int rightClickOnly) {
+    NSData *data = [NSData dataWithBytes:jsonItems length:strlen(jsonItems)];
+    id parsed = [NSJSONSerialization JSONObjectWithData:data options:0 error:nil];
+    NSArray *items = [([parsed isKindOfClass:[NSArray class]] ? parsed : @[]) retain];
+    dispatch_async(dispatch_get_main_queue(), ^{
+        [trayMenu release];
+        trayMenu = items.count > 0 ? [MenuBuildTemplate(items) retain] : nil;
+        trayMenuRightClickOnly = rightClickOnly != 0;
+        [items release];
     });
 }
 
@@ -40,8 +129,13 @@ void TrayRemove() {
     dispatch_async(dispatch_get_main_queue(), ^{
         if (statusItem != nil) {
             [[NSStatusBar systemStatusBar] removeStatusItem:statusItem];
+            [statusItem release];
             statusItem = nil;
         }
+        if (trayMenu != nil) {
+            [trayMenu release];
+            trayMenu = nil;
+        }
     });
 }
 
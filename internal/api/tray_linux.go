@@ -2,18 +2,24 @@
 
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "fmt"
 
-func handleTraySet(params json.RawMessage) (any, error) {
-	return nil, fmt.Errorf("tray.set not yet implemented on linux")
+// TraySupported reports whether this platform can show a tray icon, which
+// a tray-only app ("window": false) needs to be reachable at all.
+const TraySupported = false
+
+func traySet(tooltip string, icon []byte) error {
+	return fmt.Errorf("tray.set not yet implemented on linux")
 }
 
-func handleTrayRemove(params json.RawMessage) (any, error) {
-	return nil, fmt.Errorf("tray.remove not yet implemented on linux")
+func traySetIcon(icon []byte) {}
+
+func traySetTooltip(tooltip string) {}
+
+func traySetMenu(items []nativeMenuItem, rightClickOnly bool) error { return nil }
+
+func trayRemove() error {
+	return fmt.Errorf("tray.remove not yet implemented on linux")
 }
 
 func SetupDevTray(evalFunc func(string)) {}
-
@@ -0,0 +1,25 @@
+//go:build windows
+
+package api
+
+import "fmt"
+
+// TraySupported reports whether this platform can show a tray icon, which
+// a tray-only app ("window": false) needs to be reachable at all.
+const TraySupported = false
+
+func traySet(tooltip string, icon []byte) error {
+	return fmt.Errorf("tray.set not yet implemented on windows")
+}
+
+func traySetIcon(icon []byte) {}
+
+func traySetTooltip(tooltip string) {}
+
+func traySetMenu(items []nativeMenuItem, rightClickOnly bool) error { return nil }
+
+func trayRemove() error {
+	return fmt.Errorf("tray.remove not yet implemented on windows")
+}
+
+func SetupDevTray(evalFunc func(string)) {}
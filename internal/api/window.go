@@ -68,6 +68,13 @@ func RegisterWindow(router *ipc.Router, wv webview.Webview) {
 	})
 
 	router.Handle("window.close", func(params json.RawMessage) (any, error) {
-		return nil, wv.Close()
+		id, err := windowID(params)
+		if err != nil {
+			return nil, err
+		}
+		if id == webview.MainWindowID {
+			return nil, wv.Close()
+		}
+		return nil, wv.CloseWindow(id)
 	})
 }
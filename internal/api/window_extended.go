@@ -36,6 +36,14 @@ func RegisterWindowExtended(router *ipc.Router, wv webview.Webview) {
 		return nil, wv.SetVibrancy(p.Style)
 	})
 
+	router.Handle("window.setTitlebar", func(params json.RawMessage) (any, error) {
+		var style webview.TitlebarStyle
+		if err := json.Unmarshal(params, &style); err != nil {
+			return nil, err
+		}
+		return nil, wv.SetTitlebar(style)
+	})
+
 	router.Handle("window.setColorScheme", func(params json.RawMessage) (any, error) {
 		var p struct {
 			Scheme string `json:"scheme"`
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/webview"
+)
+
+const (
+	defaultWindowWidth  = 800
+	defaultWindowHeight = 600
+)
+
+// WindowManager tracks the windows opened with window.create. Creating a
+// window and registering it with the router happen under mu, and messages
+// from additional windows wait for it, so a page's first call is never
+// dropped.
+type WindowManager struct {
+	mu        sync.Mutex
+	titles    map[int]string
+	intercept func(id int, title, msg string) bool
+
+	router    *ipc.Router
+	wv        webview.Webview
+	pageURL   string
+	mainTitle string
+	devTools  bool
+}
+
+// Intercept sets a function that sees each message from an additional
+// window, with the window's title, before the router does. Returning true
+// consumes the message. The dev server uses it to collect each window's
+// console output.
+func (m *WindowManager) Intercept(fn func(id int, title, msg string) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.intercept = fn
+}
+
+// WindowInfo describes an open window, as window.list returns it.
+type WindowInfo struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Main  bool   `json:"main"`
+}
+
+// List returns the open windows, the main window first.
+func (m *WindowManager) List() []WindowInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]WindowInfo, 0, len(m.titles))
+	for id, title := range m.titles {
+		list = append(list, WindowInfo{ID: id, Title: title, Main: id == webview.MainWindowID})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// RegisterWindowManager registers the multi-window API. pageURL is the main
+// window's page; window.create resolves relative URLs against it and only
+// opens pages of the same origin, since every window gets the full API.
+// mainTitle is listed for the main window.
+func RegisterWindowManager(router *ipc.Router, wv webview.Webview, pageURL, mainTitle string, devTools bool) *WindowManager {
+	m := &WindowManager{
+		titles:    map[int]string{webview.MainWindowID: mainTitle},
+		router:    router,
+		wv:        wv,
+		pageURL:   pageURL,
+		mainTitle: mainTitle,
+		devTools:  devTools,
+	}
+
+	wv.OnWindowMessage(func(id int, msg string) {
+		// Wait out a window.create still registering this window
+		m.mu.Lock()
+		title, intercept := m.titles[id], m.intercept
+		m.mu.Unlock()
+		if intercept != nil && intercept(id, title, msg) {
+			return
+		}
+		router.DispatchWindow(id, msg)
+	})
+	wv.OnWindowClosed(func(id int) {
+		m.mu.Lock()
+		delete(m.titles, id)
+		m.mu.Unlock()
+		router.RemoveWindow(id)
+		router.SendEvent("window.closed", map[string]int{"id": id})
+	})
+
+	router.Handle("window.create", func(params json.RawMessage) (any, error) {
+		var p struct {
+			URL         string `json:"url"`
+			Title       string `json:"title"`
+			Width       int    `json:"width"`
+			Height      int    `json:"height"`
+			MinWidth    int    `json:"minWidth"`
+			MinHeight   int    `json:"minHeight"`
+			Resizable   *bool  `json:"resizable"`
+			Frameless   bool   `json:"frameless"`
+			AlwaysOnTop bool   `json:"alwaysOnTop"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return m.Open(webview.WindowConfig{
+			Title:       p.Title,
+			Width:       p.Width,
+			Height:      p.Height,
+			MinWidth:    p.MinWidth,
+			MinHeight:   p.MinHeight,
+			Resizable:   p.Resizable == nil || *p.Resizable,
+			Frameless:   p.Frameless,
+			AlwaysOnTop: p.AlwaysOnTop,
+		}, p.URL)
+	})
+
+	router.Handle("window.list", func(params json.RawMessage) (any, error) {
+		return m.List(), nil
+	})
+
+	router.Handle("window.focus", func(params json.RawMessage) (any, error) {
+		id, err := windowID(params)
+		if err != nil {
+			return nil, err
+		}
+		return nil, wv.FocusWindow(id)
+	})
+	return m
+}
+
+// Open opens a window on rawURL, which must be a page of the app and is
+// resolved against the main page, and returns its ID. An empty title or a
+// zero size takes the default window.create uses.
+func (m *WindowManager) Open(cfg webview.WindowConfig, rawURL string) (int, error) {
+	target, err := windowURL(m.pageURL, rawURL)
+	if err != nil {
+		return 0, err
+	}
+	cfg.DevTools = m.devTools
+	if cfg.Title == "" {
+		cfg.Title = m.mainTitle
+	}
+	if cfg.Width <= 0 {
+		cfg.Width = defaultWindowWidth
+	}
+	if cfg.Height <= 0 {
+		cfg.Height = defaultWindowHeight
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id, err := m.wv.CreateWindow(cfg, target)
+	if err != nil {
+		return 0, err
+	}
+	m.router.AddWindow(id, func(js string) { m.wv.EvalWindow(id, js) })
+	m.titles[id] = cfg.Title
+	return id, nil
+}
+
+// windowID reads the optional id parameter, defaulting to the main window.
+func windowID(params json.RawMessage) (int, error) {
+	var p struct {
+		ID int `json:"id"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return 0, err
+		}
+	}
+	if p.ID == 0 {
+		return webview.MainWindowID, nil
+	}
+	return p.ID, nil
+}
+
+// windowURL resolves a window.create URL against the main page and checks
+// that it belongs to the app.
+func windowURL(pageURL, raw string) (string, error) {
+	if raw == "" {
+		return "", fmt.Errorf("window.create: url is required")
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("window.create: invalid url %q: %w", raw, err)
+	}
+	target := base.ResolveReference(ref)
+	if target.Scheme != base.Scheme || target.Host != base.Host {
+		return "", fmt.Errorf("window.create: %q is not a page of this app; open external sites with shell.open", raw)
+	}
+	return target.String(), nil
+}
@@ -0,0 +1,344 @@
+// Package app is the production runtime: it serves an app's pages under
+// their Content-Security-Policy, opens its main window, and registers the
+// APIs the pages call, gated by the app's permissions. Built apps run it
+// from the small main that lightshell build generates, and lightshell run
+// runs a project with it, so the two cannot drift apart.
+package app
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	goruntime "runtime"
+	"syscall"
+
+	"github.com/lightshell-dev/lightshell/internal/accel"
+	"github.com/lightshell-dev/lightshell/internal/api"
+	"github.com/lightshell-dev/lightshell/internal/clientjs"
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/launchargs"
+	"github.com/lightshell-dev/lightshell/internal/paths"
+	"github.com/lightshell-dev/lightshell/internal/proxy"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/security"
+	"github.com/lightshell-dev/lightshell/internal/startup"
+	"github.com/lightshell-dev/lightshell/internal/webview"
+	"github.com/lightshell-dev/lightshell/internal/worker"
+)
+
+// Origins is where the pages are served from on the platforms whose
+// webviews answer requests from Go, so there is no port other processes
+// could reach. macOS loads them through the app:// scheme; WebView2
+// cannot add schemes once it is running, so on Windows requests to an
+// https host that never resolves are answered instead. Elsewhere the
+// pages are served on a loopback port.
+var Origins = map[string]string{"darwin": "app://localhost", "windows": "https://app.localhost"}
+
+// Options configures Run.
+type Options struct {
+	// Config is the app's lightshell.json with its defaults applied.
+	// Its permissions are the ones enforced, so an app that declares none
+	// should be given the build's defaults.
+	Config runtime.Config
+
+	// Pages holds the entry's directory: the entry page and everything
+	// it loads.
+	Pages fs.FS
+
+	// Icons holds the themeIcons files when their paths are relative, as
+	// in a built app; tray icons are also looked up in it before Pages.
+	Icons fs.FS
+
+	// ProjectDir is the project the app runs from, which the fs API may
+	// access. A built app has none; its data directory stands in.
+	ProjectDir string
+
+	// Argv holds the app's own arguments, parsed into Launch against the
+	// config's launchArgs.
+	Argv   []string
+	Launch launchargs.Result
+
+	// Register, if set, adds APIs and handlers once the built-in ones are
+	// registered and before the entry page loads.
+	Register func(a *App)
+
+	// Ready, if set, runs once the entry page is loading, before the
+	// event loop starts.
+	Ready func(a *App)
+
+	// The rest serve programs that embed LightShell through
+	// pkg/lightshell. Router and Webview, when set, are used in place of
+	// new ones, so handlers can be added and windows reached before Run.
+	Router  *ipc.Router
+	Webview webview.Webview
+	// Entry is the entry page's path in Pages. It defaults to the base
+	// name of Config.Entry.
+	Entry string
+	// Window, when set, configures the main window in place of
+	// Config.Window.
+	Window *webview.WindowConfig
+	// DevTools enables the web inspector in every window.
+	DevTools bool
+}
+
+// App is a running app, as Options.Register sees it.
+type App struct {
+	Config  runtime.Config
+	Router  *ipc.Router
+	Policy  *security.Policy
+	Webview webview.Webview
+	Windows *api.WindowManager // the windows opened with window.create
+}
+
+// Run serves the pages, opens the main window on the entry page, and runs
+// the event loop until the app quits. It must be called from the main
+// goroutine.
+func Run(opts Options) error {
+	cfg := opts.Config
+	bindings, err := accel.Compile(cfg.Accelerators, goruntime.GOOS)
+	if err != nil {
+		return fmt.Errorf("invalid accelerators in lightshell.json: %w", err)
+	}
+
+	wv := opts.Webview
+	if wv == nil {
+		wv = webview.New()
+	}
+	pages := PagesHandler(opts.Pages, cfg.Security.PageCSP(false))
+	entry := opts.Entry
+	if entry == "" {
+		entry = filepath.Base(cfg.Entry)
+	}
+	var pageURL string
+	if as, ok := wv.(webview.AssetServer); ok && Origins[goruntime.GOOS] != "" {
+		as.ServeAssets(Origins[goruntime.GOOS], pages)
+		pageURL = Origins[goruntime.GOOS] + "/" + entry
+	} else {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return fmt.Errorf("could not find free port: %w", err)
+		}
+		server := &http.Server{Handler: pages}
+		go server.Serve(listener)
+		defer server.Close()
+		pageURL = fmt.Sprintf("http://%s/%s", listener.Addr(), entry)
+	}
+
+	router := opts.Router
+	if router == nil {
+		router = ipc.NewRouter()
+	}
+	router.SetPool(worker.NewPool(worker.Config{
+		Size:       cfg.Workers.Size,
+		Queue:      cfg.Workers.Queue,
+		Namespaces: cfg.Workers.Namespaces,
+	}))
+
+	wcfg := webview.WindowConfig{
+		Title:     cfg.Window.Title,
+		Width:     cfg.Window.Width,
+		Height:    cfg.Window.Height,
+		MinWidth:  cfg.Window.MinWidth,
+		MinHeight: cfg.Window.MinHeight,
+		Resizable: true,
+		Frameless: cfg.Window.Frameless,
+		Titlebar:  cfg.Window.Titlebar,
+		Hidden:    TrayOnly(cfg),
+	}
+	if cfg.Window.Resizable != nil {
+		wcfg.Resizable = *cfg.Window.Resizable
+	}
+	if opts.Window != nil {
+		wcfg = *opts.Window
+	}
+	wcfg.DevTools = opts.DevTools
+	GuardNavigation(wv, pageURL, cfg)
+	if err := wv.Create(wcfg); err != nil {
+		return fmt.Errorf("failed to create window: %w", err)
+	}
+	tracker := startup.NewTracker("production")
+	tracker.Mark(startup.WindowCreated)
+
+	router.SetEvalFunc(func(js string) {
+		wv.Eval(js)
+	})
+	wv.OnMessage(func(msg string) {
+		router.Dispatch(msg, func(response string) {
+			wv.Eval(fmt.Sprintf("__lightshell_receive(%s)", response))
+		})
+	})
+
+	dir := opts.ProjectDir
+	if dir == "" {
+		dir = paths.For(cfg.Name).Data
+	}
+	a := &App{
+		Config:  cfg,
+		Router:  router,
+		Policy:  cfg.Permissions.Policy(dir, cfg.Name),
+		Webview: wv,
+	}
+	if len(cfg.Permissions.Ask) > 0 {
+		if !api.PermissionPromptSupported {
+			fmt.Println("Warning: permission prompts are not yet supported on this platform; \"ask\" leaves denials as they are")
+		}
+		grants := filepath.Join(paths.For(cfg.Name).Data, "permissions.json")
+		a.Policy.SetAsk(cfg.Permissions.AskAPIs(), api.PermissionPrompter(cfg.Name), grants)
+	}
+	a.register(opts, pageURL, tracker)
+	if opts.Register != nil {
+		opts.Register(a)
+	}
+
+	// The built app's scripts: no debug console
+	wv.AddUserScript(clientjs.Polyfills)
+	wv.AddUserScript(accel.Script(bindings)) // read by the client library, so it goes first
+	wv.AddUserScript(clientjs.Client)
+	wv.AddUserScript(clientjs.DefaultsScript())
+
+	if err := wv.LoadURL(pageURL); err != nil {
+		return fmt.Errorf("failed to load %s: %w", pageURL, err)
+	}
+	if opts.Ready != nil {
+		opts.Ready(a)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		<-sigCh
+		wv.Quit()
+	}()
+
+	err = wv.Run()
+	router.RunShutdownHooks()
+	return err
+}
+
+// register adds the APIs every app has. fs, temporary directories, http,
+// image, pdf, share, and codes are left to Options.Register, so a built
+// app only links them when its permissions allow them.
+func (a *App) register(opts Options, pageURL string, tracker *startup.Tracker) {
+	cfg, router, wv, policy := a.Config, a.Router, a.Webview, a.Policy
+
+	iconFS := overlay{opts.Pages}
+	if opts.Icons != nil {
+		iconFS = overlay{opts.Icons, opts.Pages}
+	}
+	api.SetIconFS(iconFS)
+	windowIcon, trayIcon := cfg.ThemeIcons.Window, cfg.ThemeIcons.Tray
+	if opts.Icons == nil {
+		windowIcon, trayIcon = projectPath(opts.ProjectDir, windowIcon), projectPath(opts.ProjectDir, trayIcon)
+	}
+
+	api.RegisterWindow(router, wv)
+	api.RegisterWindowExtended(router, wv)
+	a.Windows = api.RegisterWindowManager(router, wv, pageURL, cfg.Window.Title, opts.DevTools)
+	api.RegisterLifecycle(router, wv, cfg.QuitOnLastWindowClosed())
+	if cfg.Scripting.Enabled {
+		api.RegisterScripting(router, wv, cfg.Scripting.Actions)
+	}
+	api.RegisterToolbar(router)
+	api.RegisterDialog(router, policy)
+	api.RegisterClipboard(router, policy)
+	api.RegisterShell(router, policy)
+	api.RegisterSystem(router, cfg.Version, cfg.Name, wv)
+	api.RegisterNetwork(router)
+	api.RegisterNotification(router, policy)
+	api.RegisterTray(router, policy)
+	api.RegisterThemeIcons(router, wv, windowIcon, trayIcon)
+	api.RegisterMenu(router, policy)
+	api.RegisterAppExtended(router, cfg.Name)
+	api.RegisterAppState(router, cfg.Name, cfg.Version, cfg.Migrations)
+	api.RegisterLaunchArgs(router, opts.Argv, opts.Launch)
+	api.RegisterCache(router, cfg.Name, int64(cfg.Cache.MaxSizeMB)*1024*1024)
+	api.RegisterStartup(router, tracker, cfg.Name)
+	api.RegisterPower(router, policy)
+	api.RegisterPermissions(router, policy)
+}
+
+// Main runs a built app with the arguments it was started with, and exits
+// on errors: with status 2 for bad arguments, and 0 after printing its
+// usage for --help.
+func Main(opts Options) {
+	cfg := opts.Config
+	opts.Argv = os.Args[1:]
+	launch, err := launchargs.Parse(cfg.LaunchArgs, opts.Argv)
+	if err == launchargs.ErrHelp {
+		fmt.Print(launchargs.Usage(cfg.Name, cfg.LaunchArgs))
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	opts.Launch = launch
+
+	// launchAtLogin in lightshell.json applies until the user makes a choice
+	if cfg.LaunchAtLogin {
+		api.DefaultLaunchAtLogin(cfg.Name)
+	}
+
+	if err := Run(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// HTTPOptions returns the options of the lightshell.http client that cfg
+// calls for. The proxy is cfg's, else the environment's or the OS's, read
+// once. CA files in security.tls resolve against dir; a built app embeds
+// their contents instead.
+func HTTPOptions(cfg runtime.Config, dir string) (api.HTTPOptions, error) {
+	conf, err := cfg.Security.TLS.ClientConfig(dir)
+	if err != nil {
+		return api.HTTPOptions{}, err
+	}
+	return api.HTTPOptions{
+		Proxy: proxy.Resolve(cfg.Proxy.URL, cfg.Proxy.NoProxy),
+		TLS:   conf,
+	}, nil
+}
+
+// TrayOnly reports whether the main window stays hidden ("window": false).
+// Without a tray the app would be unreachable, so where there is none the
+// window is shown after all.
+func TrayOnly(cfg runtime.Config) bool {
+	if cfg.Window.Disabled && !api.TraySupported {
+		fmt.Println("Warning: \"window\": false needs a system tray, which is not yet supported on this platform; showing the window instead")
+		return false
+	}
+	return cfg.Window.Disabled
+}
+
+// GuardNavigation keeps wv's main window on the origin of pageURL and the
+// URLs in security.allowNavigation, where the webview can. It must be
+// called before the window is created. Refused navigations are reported,
+// since to the user the link just does nothing.
+func GuardNavigation(wv webview.Webview, pageURL string, cfg runtime.Config) {
+	guard, ok := wv.(webview.NavigationGuard)
+	if !ok {
+		return
+	}
+	policy := security.NavigationPolicy{PageURL: pageURL, Allow: cfg.Security.AllowNavigation}
+	guard.GuardNavigation(func(url string) bool {
+		if policy.Allows(url) {
+			return true
+		}
+		fmt.Fprintf(os.Stderr, "Blocked navigation to %s: open external links with lightshell.shell.open, or add the URL to security.allowNavigation in lightshell.json\n", url)
+		return false
+	})
+}
+
+// projectPath resolves a path from lightshell.json against dir.
+func projectPath(dir, p string) string {
+	if p == "" || dir == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// PagesHandler serves pages from fsys under csp. Each HTML page also gets
+// the policy in a meta tag, for webviews that do not apply the header to
+// pages they did not fetch over HTTP.
+func PagesHandler(fsys fs.FS, csp string) http.Handler {
+	fileServer := http.FileServer(http.FS(fsys))
+	meta := []byte(cspMeta(csp))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", csp)
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "index.html"
+		}
+		if ext := path.Ext(name); ext == ".html" || ext == ".htm" {
+			if data, err := fs.ReadFile(fsys, name); err == nil {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Write(withMeta(data, meta))
+				return
+			}
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// cspMeta returns the meta tag that applies csp to a page.
+func cspMeta(csp string) string {
+	return fmt.Sprintf(`<meta http-equiv="Content-Security-Policy" content="%s">`, html.EscapeString(csp))
+}
+
+// withMeta adds meta at the start of page's head.
+func withMeta(page, meta []byte) []byte {
+	i := bytes.Index(bytes.ToLower(page), []byte("<head>"))
+	if i < 0 {
+		return append(meta[:len(meta):len(meta)], page...)
+	}
+	i += len("<head>")
+	out := make([]byte, 0, len(page)+len(meta))
+	out = append(out, page[:i]...)
+	out = append(out, meta...)
+	return append(out, page[i:]...)
+}
+
+// overlay reads each file from the first of its file systems that has it.
+type overlay []fs.FS
+
+func (o overlay) Open(name string) (fs.File, error) {
+	for _, fsys := range o[:len(o)-1] {
+		f, err := fsys.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return o[len(o)-1].Open(name)
+}
+
+// Overlay returns a file system that reads each file from the first of
+// fsys that has it, such as bundled scripts over the sources they were
+// built from.
+func Overlay(fsys ...fs.FS) fs.FS {
+	return overlay(fsys)
+}
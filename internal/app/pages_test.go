@@ -0,0 +1,64 @@
+package app
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPagesHandlerCSP(t *testing.T) {
+	pages := fstest.MapFS{
+		"index.html": {Data: []byte("<html><HEAD><title>x</title></HEAD></html>")},
+		"bare.html":  {Data: []byte("<p>hi</p>")},
+		"app.js":     {Data: []byte("console.log(1)")},
+	}
+	csp := `default-src 'self' https://cdn.example.com; script-src "self"`
+	h := PagesHandler(pages, csp)
+	meta := `<meta http-equiv="Content-Security-Policy" content="default-src &#39;self&#39; https://cdn.example.com; script-src &#34;self&#34;">`
+
+	tests := []struct {
+		path, prefix string
+	}{
+		{"/", "<html><HEAD>" + meta + "<title>"},
+		{"/index.html", "<html><HEAD>" + meta + "<title>"},
+		{"/bare.html", meta + "<p>hi</p>"},
+		{"/app.js", "console.log(1)"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if got := rec.Header().Get("Content-Security-Policy"); got != csp {
+			t.Errorf("%s: CSP header = %q", tt.path, got)
+		}
+		body, _ := io.ReadAll(rec.Body)
+		if !strings.HasPrefix(string(body), tt.prefix) {
+			t.Errorf("%s: body = %q, want prefix %q", tt.path, body, tt.prefix)
+		}
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	bundled := fstest.MapFS{"app.js": {Data: []byte("bundled")}}
+	src := fstest.MapFS{
+		"app.js":     {Data: []byte("source")},
+		"index.html": {Data: []byte("page")},
+	}
+	fsys := Overlay(bundled, src)
+
+	for name, want := range map[string]string{"app.js": "bundled", "index.html": "page"} {
+		f, err := fsys.Open(name)
+		if err != nil {
+			t.Fatalf("Open(%s): %v", name, err)
+		}
+		data, _ := io.ReadAll(f)
+		f.Close()
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if _, err := fsys.Open("missing.js"); err == nil {
+		t.Error("expected an error for a file in neither")
+	}
+}
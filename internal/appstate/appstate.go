@@ -0,0 +1,93 @@
+// Package appstate records which app version last ran and which version the
+// app's data was last migrated to, so the page can tell a first run from an
+// upgrade and run its data migrations exactly once.
+package appstate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/fsutil"
+	"github.com/lightshell-dev/lightshell/internal/paths"
+)
+
+// State is what is stored between launches.
+type State struct {
+	Version         string    `json:"version"`         // version of the last launch
+	MigratedVersion string    `json:"migratedVersion"` // version the app's data was last migrated to
+	FirstRunAt      time.Time `json:"firstRunAt"`
+}
+
+// Launch describes the current launch.
+type Launch struct {
+	IsFirstRun      bool   `json:"isFirstRun"`
+	PreviousVersion string `json:"previousVersion"` // version of the last launch, "" on a first run
+	Version         string `json:"version"`
+	MigratedVersion string `json:"migratedVersion"` // migrations newer than this, up to Version, are pending
+}
+
+// Path returns where the state for appName is stored.
+func Path(appName string) string {
+	return filepath.Join(paths.DataDir(appName), "app-state.json")
+}
+
+// Record loads the state at path, records version as the current launch,
+// and returns what changed. Call it once per process, at startup.
+//
+// On a first run there is no data to migrate, so the data is recorded as
+// already at version.
+func Record(path, version string) (Launch, error) {
+	st, err := Load(path)
+	if os.IsNotExist(err) {
+		st = State{Version: version, MigratedVersion: version, FirstRunAt: time.Now().UTC()}
+		return Launch{IsFirstRun: true, Version: version, MigratedVersion: version}, Save(path, st)
+	}
+	if err != nil {
+		return Launch{}, err
+	}
+
+	l := Launch{PreviousVersion: st.Version, Version: version, MigratedVersion: st.MigratedVersion}
+	if l.MigratedVersion == "" {
+		l.MigratedVersion = st.Version
+	}
+	if st.Version == version && st.MigratedVersion != "" {
+		return l, nil
+	}
+	st.Version, st.MigratedVersion = version, l.MigratedVersion
+	return l, Save(path, st)
+}
+
+// SetMigrated records that the app's data has been migrated to version.
+func SetMigrated(path, version string) error {
+	st, err := Load(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	st.MigratedVersion = version
+	return Save(path, st)
+}
+
+// Load reads the state at path.
+func Load(path string) (State, error) {
+	var st State
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st, err
+	}
+	err = json.Unmarshal(data, &st)
+	return st, err
+}
+
+// Save writes st to path atomically, creating the parent directory.
+func Save(path string, st State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,88 @@
+package appstate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordFirstRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "app-state.json")
+	l, err := Record(path, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Launch{IsFirstRun: true, Version: "1.0.0", MigratedVersion: "1.0.0"}
+	if l != want {
+		t.Errorf("Record = %+v, want %+v", l, want)
+	}
+	st, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Version != "1.0.0" || st.MigratedVersion != "1.0.0" || st.FirstRunAt.IsZero() {
+		t.Errorf("stored state = %+v", st)
+	}
+}
+
+func TestRecordUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app-state.json")
+	if _, err := Record(path, "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same version again: not a first run, nothing pending
+	l, err := Record(path, "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Launch{PreviousVersion: "1.0.0", Version: "1.0.0", MigratedVersion: "1.0.0"}); l != want {
+		t.Errorf("relaunch = %+v, want %+v", l, want)
+	}
+
+	// Upgrade: migrations from 1.0.0 are pending until SetMigrated
+	l, err = Record(path, "2.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Launch{PreviousVersion: "1.0.0", Version: "2.0.0", MigratedVersion: "1.0.0"}); l != want {
+		t.Errorf("upgrade = %+v, want %+v", l, want)
+	}
+
+	// The app quit before migrating; the next launch still reports them
+	l, _ = Record(path, "2.0.0")
+	if want := (Launch{PreviousVersion: "2.0.0", Version: "2.0.0", MigratedVersion: "1.0.0"}); l != want {
+		t.Errorf("relaunch before migrating = %+v, want %+v", l, want)
+	}
+
+	if err := SetMigrated(path, "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	l, _ = Record(path, "2.0.0")
+	if l.MigratedVersion != "2.0.0" {
+		t.Errorf("MigratedVersion = %q after SetMigrated, want 2.0.0", l.MigratedVersion)
+	}
+}
+
+func TestRecordWithoutMigratedVersion(t *testing.T) {
+	// A state file with only a version treats the data as at that version
+	path := filepath.Join(t.TempDir(), "app-state.json")
+	if err := os.WriteFile(path, []byte(`{"version":"1.2.0"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Record(path, "1.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.IsFirstRun || l.PreviousVersion != "1.2.0" || l.MigratedVersion != "1.2.0" {
+		t.Errorf("Record = %+v", l)
+	}
+}
+
+func TestRecordCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app-state.json")
+	os.WriteFile(path, []byte("{"), 0o644)
+	if _, err := Record(path, "1.0.0"); err == nil {
+		t.Error("expected error for corrupt state")
+	}
+}
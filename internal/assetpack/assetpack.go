@@ -0,0 +1,277 @@
+// Package assetpack packs an app's web assets into a single blob for
+// embedding in the built binary. Identical files are stored once, and files
+// that compress well are gzipped; already-compressed media is stored as is.
+//
+// Pack layout:
+//
+//	"LSPK1\n"            magic
+//	uint32 big-endian    length of the JSON index
+//	JSON index           {"path": {"o": offset, "n": length, "size": raw size, "z": gzipped}}
+//	data                 file contents, addressed by offset relative to the end of the index
+//
+// Built apps embed the pack and read it with Open.
+package assetpack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Magic identifies a pack.
+const Magic = "LSPK1\n"
+
+// Entry locates one file in the pack's data section.
+type Entry struct {
+	Offset int64 `json:"o"`
+	Length int64 `json:"n"`
+	Size   int64 `json:"size"`
+	Gzip   bool  `json:"z,omitempty"`
+}
+
+// Stats summarizes a Pack call for build output.
+type Stats struct {
+	Files       int   // files in the source tree
+	Duplicates  int   // files whose contents were already stored
+	RawBytes    int64 // total size of all files
+	PackedBytes int64 // size of the pack
+}
+
+// incompressible lists extensions whose contents are already compressed.
+var incompressible = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".avif": true,
+	".mp3": true, ".mp4": true, ".m4a": true, ".ogg": true, ".webm": true, ".mov": true,
+	".woff": true, ".woff2": true, ".zip": true, ".gz": true, ".br": true, ".zst": true,
+}
+
+// Pack reads every regular file under dir and returns the pack. When compress
+// is false, files are only deduplicated.
+func Pack(dir string, compress bool) ([]byte, Stats, error) {
+	var stats Stats
+	index := make(map[string]Entry)
+	stored := make(map[[sha256.Size]byte]Entry)
+	var data bytes.Buffer
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		stats.Files++
+		stats.RawBytes += int64(len(content))
+
+		sum := sha256.Sum256(content)
+		if e, ok := stored[sum]; ok {
+			stats.Duplicates++
+			index[name] = e
+			return nil
+		}
+
+		e := Entry{Offset: int64(data.Len()), Size: int64(len(content))}
+		body := content
+		if compress && !incompressible[strings.ToLower(path.Ext(name))] {
+			if z, err := gzipBytes(content); err == nil && len(z) < len(content) {
+				body = z
+				e.Gzip = true
+			}
+		}
+		e.Length = int64(len(body))
+		data.Write(body)
+		stored[sum] = e
+		index[name] = e
+		return nil
+	})
+	if err != nil {
+		return nil, stats, err
+	}
+
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return nil, stats, err
+	}
+	var out bytes.Buffer
+	out.WriteString(Magic)
+	binary.Write(&out, binary.BigEndian, uint32(len(indexJSON)))
+	out.Write(indexJSON)
+	out.Write(data.Bytes())
+
+	stats.PackedBytes = int64(out.Len())
+	return out.Bytes(), stats, nil
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Open returns a read-only file system over a pack. Files are decompressed
+// on open; directories are synthesized from the file paths.
+func Open(pack []byte) (fs.FS, error) {
+	if !bytes.HasPrefix(pack, []byte(Magic)) || len(pack) < len(Magic)+4 {
+		return nil, errors.New("assetpack: not a pack")
+	}
+	n := int(binary.BigEndian.Uint32(pack[len(Magic):]))
+	start := len(Magic) + 4
+	if start+n > len(pack) {
+		return nil, errors.New("assetpack: truncated index")
+	}
+	var index map[string]Entry
+	if err := json.Unmarshal(pack[start:start+n], &index); err != nil {
+		return nil, err
+	}
+	data := pack[start+n:]
+	for name, e := range index {
+		if e.Offset < 0 || e.Offset+e.Length > int64(len(data)) {
+			return nil, errors.New("assetpack: entry out of range: " + name)
+		}
+	}
+	return &packFS{data: data, index: index}, nil
+}
+
+type packFS struct {
+	data  []byte
+	index map[string]Entry
+}
+
+func (p *packFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if e, ok := p.index[name]; ok {
+		body := p.data[e.Offset : e.Offset+e.Length]
+		if e.Gzip {
+			zr, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+			}
+			raw, err := io.ReadAll(zr)
+			if err != nil {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+			}
+			body = raw
+		}
+		return &packFile{Reader: bytes.NewReader(body), info: fileInfo{name: path.Base(name), size: e.Size}}, nil
+	}
+
+	entries := p.readDir(name)
+	if entries == nil && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &packDir{info: fileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+// readDir lists the direct children of dir, or nil if dir has none.
+func (p *packFS) readDir(dir string) []fs.DirEntry {
+	prefix := ""
+	if dir != "." {
+		prefix = dir + "/"
+	}
+	seen := make(map[string]fs.DirEntry)
+	for name, e := range p.index {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := name[len(prefix):]
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			child := rest[:i]
+			seen[child] = fs.FileInfoToDirEntry(fileInfo{name: child, dir: true})
+		} else {
+			seen[rest] = fs.FileInfoToDirEntry(fileInfo{name: rest, size: e.Size})
+		}
+	}
+	if len(seen) == 0 {
+		return nil
+	}
+	entries := make([]fs.DirEntry, 0, len(seen))
+	for _, e := range seen {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
+
+type fileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return fi.dir }
+func (fi fileInfo) Sys() any           { return nil }
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+type packFile struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *packFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *packFile) Close() error               { return nil }
+
+type packDir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *packDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *packDir) Close() error               { return nil }
+func (d *packDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *packDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,84 @@
+package assetpack
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPackRoundTrip(t *testing.T) {
+	js := strings.Repeat("console.log('hello');\n", 200)
+	files := map[string]string{
+		"index.html":      "<html><body>hi</body></html>",
+		"app.js":          js,
+		"vendor/copy.js":  js,
+		"img/logo.png":    "\x89PNG not really",
+		"img/icons/a.svg": "<svg/>",
+	}
+	dir := writeTree(t, files)
+
+	pack, stats, err := Pack(dir, true)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if stats.Files != 5 || stats.Duplicates != 1 {
+		t.Errorf("expected 5 files with 1 duplicate, got %+v", stats)
+	}
+	if stats.PackedBytes >= stats.RawBytes {
+		t.Errorf("expected pack to be smaller than raw assets, got %+v", stats)
+	}
+
+	fsys, err := Open(pack)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for name, want := range files {
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: content mismatch", name)
+		}
+	}
+	if err := fstest.TestFS(fsys, "index.html", "app.js", "vendor/copy.js", "img/logo.png", "img/icons/a.svg"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPackWithoutCompression(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a.txt": "same", "b.txt": "same"})
+	pack, stats, err := Pack(dir, false)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if stats.Duplicates != 1 {
+		t.Errorf("expected duplicate to be detected, got %+v", stats)
+	}
+	fsys, _ := Open(pack)
+	if got, _ := fs.ReadFile(fsys, "b.txt"); string(got) != "same" {
+		t.Errorf("expected deduplicated content, got %q", got)
+	}
+}
+
+func TestOpenRejectsGarbage(t *testing.T) {
+	if _, err := Open([]byte("not a pack")); err == nil {
+		t.Error("expected error for invalid pack")
+	}
+}
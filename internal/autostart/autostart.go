@@ -0,0 +1,245 @@
+// Package autostart manages launching an app at login. On Linux that is an
+// XDG autostart desktop entry; on macOS the app registers itself with
+// SMAppService, which only a bundled app can do. Either way the app records
+// the outcome in a state file so lightshell doctor can explain problems.
+package autostart
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/fsutil"
+	"github.com/lightshell-dev/lightshell/internal/paths"
+)
+
+// Launch-at-login statuses. The macOS ones mirror SMAppServiceStatus.
+const (
+	StatusEnabled          = "enabled"
+	StatusDisabled         = "disabled"
+	StatusRequiresApproval = "requiresApproval" // registered, but the user has to allow it in System Settings
+	StatusNotFound         = "notFound"         // macOS could not find the app bundle
+	StatusUnsupported      = "unsupported"      // macOS before 13
+)
+
+// State is the last launch-at-login change an app made.
+type State struct {
+	Enabled   bool      `json:"enabled"` // what the app asked for
+	Status    string    `json:"status"`  // what the OS reported afterwards
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// StatePath returns where the launch-at-login state for appName is stored.
+// Its presence also means a choice has been made, so the launchAtLogin
+// config default is not applied again.
+func StatePath(appName string) string {
+	return filepath.Join(paths.DataDir(appName), "launch-at-login.json")
+}
+
+// SaveState writes s to path atomically, creating the parent directory.
+func SaveState(path string, s State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, append(data, '\n'), 0o644)
+}
+
+// LoadState reads a state written by SaveState.
+func LoadState(path string) (State, error) {
+	var s State
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+// Entry describes the command an XDG autostart entry runs.
+type Entry struct {
+	Name string   // display name
+	Exec string   // absolute path of the executable
+	Args []string // arguments passed to Exec
+	Dir  string   // working directory, optional
+}
+
+// DesktopFilePath returns the XDG autostart entry for appName.
+func DesktopFilePath(appName string) string {
+	home, _ := os.UserHomeDir()
+	return desktopFilePath(appName, home, os.Getenv)
+}
+
+func desktopFilePath(appName, home string, getenv func(string) string) string {
+	config := getenv("XDG_CONFIG_HOME")
+	if config == "" || !filepath.IsAbs(config) {
+		config = filepath.Join(home, ".config")
+	}
+	return filepath.Join(config, "autostart", fileName(appName)+".desktop")
+}
+
+// fileName turns an app name into a desktop file id: letters, digits, '-',
+// '_' and '.', with everything else replaced by '-'.
+func fileName(appName string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '-'
+	}, appName)
+}
+
+// WriteDesktopFile writes an autostart entry for e to path.
+func WriteDesktopFile(path string, e Entry) error {
+	if !filepath.IsAbs(e.Exec) {
+		return fmt.Errorf("autostart: executable %q is not an absolute path", e.Exec)
+	}
+	cmd := make([]string, 0, len(e.Args)+1)
+	for _, a := range append([]string{e.Exec}, e.Args...) {
+		cmd = append(cmd, quoteExecArg(a))
+	}
+
+	var b strings.Builder
+	b.WriteString("[Desktop Entry]\n")
+	b.WriteString("Type=Application\n")
+	fmt.Fprintf(&b, "Name=%s\n", escapeValue(e.Name))
+	fmt.Fprintf(&b, "Exec=%s\n", escapeValue(strings.Join(cmd, " ")))
+	if e.Dir != "" {
+		fmt.Fprintf(&b, "Path=%s\n", escapeValue(e.Dir))
+	}
+	b.WriteString("Terminal=false\n")
+	b.WriteString("X-GNOME-Autostart-enabled=true\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, []byte(b.String()), 0o644)
+}
+
+// quoteExecArg quotes an Exec argument when it contains reserved characters,
+// as the Desktop Entry spec requires. '%' is doubled so it is not read as a
+// field code.
+func quoteExecArg(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\><~|&;$*?#()`") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// escapeValue escapes a desktop file string value.
+func escapeValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace(s)
+}
+
+// DesktopFile is the part of an autostart entry that decides whether and
+// what it launches.
+type DesktopFile struct {
+	Exec    string // Exec value, unescaped
+	Hidden  bool   // Hidden=true deletes the entry as far as the session is concerned
+	Enabled bool   // false when X-GNOME-Autostart-enabled=false
+}
+
+// ReadDesktopFile reads the [Desktop Entry] group of the file at path.
+func ReadDesktopFile(path string) (DesktopFile, error) {
+	d := DesktopFile{Enabled: true}
+	f, err := os.Open(path)
+	if err != nil {
+		return d, err
+	}
+	defer f.Close()
+
+	inEntry := false
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inEntry || !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Exec":
+			d.Exec = unescapeValue(value)
+		case "Hidden":
+			d.Hidden = value == "true"
+		case "X-GNOME-Autostart-enabled":
+			d.Enabled = value != "false"
+		}
+	}
+	return d, s.Err()
+}
+
+func unescapeValue(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t", `\r`, "\r", `\s`, " ").Replace(s)
+}
+
+// Program returns the executable an Exec value launches.
+func (d DesktopFile) Program() string {
+	exec := strings.TrimSpace(d.Exec)
+	if strings.HasPrefix(exec, `"`) {
+		var b strings.Builder
+		for i := 1; i < len(exec); i++ {
+			switch exec[i] {
+			case '\\':
+				if i+1 < len(exec) {
+					i++
+					b.WriteByte(exec[i])
+				}
+			case '"':
+				return b.String()
+			default:
+				b.WriteByte(exec[i])
+			}
+		}
+		return b.String()
+	}
+	program, _, _ := strings.Cut(exec, " ")
+	return program
+}
+
+// Problems returns what would stop the autostart entry at path from
+// launching the app, in a form suitable for lightshell doctor. A missing
+// entry is not a problem.
+func Problems(path string) []string {
+	d, err := ReadDesktopFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("cannot read %s: %v", path, err)}
+	}
+
+	var problems []string
+	if d.Hidden {
+		problems = append(problems, fmt.Sprintf("%s sets Hidden=true, so the session ignores it", path))
+	}
+	if !d.Enabled {
+		problems = append(problems, fmt.Sprintf("%s is disabled (X-GNOME-Autostart-enabled=false), usually from the desktop's startup settings", path))
+	}
+	if program := d.Program(); program == "" {
+		problems = append(problems, fmt.Sprintf("%s has no Exec command", path))
+	} else if info, err := os.Stat(program); err != nil {
+		problems = append(problems, fmt.Sprintf("%s launches %s, which no longer exists; re-enable launch at login from the app", path, program))
+	} else if info.Mode()&0o111 == 0 {
+		problems = append(problems, fmt.Sprintf("%s launches %s, which is not executable", path, program))
+	}
+	return problems
+}
@@ -0,0 +1,121 @@
+package autostart
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDesktopFilePath(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(k string) string { return env[k] }
+
+	if got, want := desktopFilePath("My App", "/home/u", getenv), "/home/u/.config/autostart/My-App.desktop"; got != want {
+		t.Errorf("default = %q, want %q", got, want)
+	}
+	env["XDG_CONFIG_HOME"] = "/xdg"
+	if got, want := desktopFilePath("notes", "/home/u", getenv), "/xdg/autostart/notes.desktop"; got != want {
+		t.Errorf("XDG_CONFIG_HOME = %q, want %q", got, want)
+	}
+	env["XDG_CONFIG_HOME"] = "relative"
+	if got, want := desktopFilePath("notes", "/home/u", getenv), "/home/u/.config/autostart/notes.desktop"; got != want {
+		t.Errorf("relative XDG_CONFIG_HOME = %q, want %q", got, want)
+	}
+}
+
+func TestQuoteExecArg(t *testing.T) {
+	tests := map[string]string{
+		"/usr/bin/notes":  "/usr/bin/notes",
+		"--hidden":        "--hidden",
+		"/opt/My App/run": `"/opt/My App/run"`,
+		`say "hi" $HOME`:  `"say \"hi\" \$HOME"`,
+		"100%":            "100%%",
+		"":                `""`,
+	}
+	for in, want := range tests {
+		if got := quoteExecArg(in); got != want {
+			t.Errorf("quoteExecArg(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteAndReadDesktopFile(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "My App", "notes")
+	if err := os.MkdirAll(filepath.Dir(exe), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(exe, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "autostart", "notes.desktop")
+	if err := WriteDesktopFile(path, Entry{Name: "Notes", Exec: exe, Args: []string{"--hidden"}, Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"[Desktop Entry]\n", "Type=Application\n", "Name=Notes\n", "Path=" + dir + "\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("desktop file missing %q:\n%s", want, data)
+		}
+	}
+
+	d, err := ReadDesktopFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Hidden || !d.Enabled {
+		t.Errorf("entry should be active: %+v", d)
+	}
+	if got := d.Program(); got != exe {
+		t.Errorf("Program() = %q, want %q", got, exe)
+	}
+	if p := Problems(path); len(p) != 0 {
+		t.Errorf("Problems() = %v, want none", p)
+	}
+}
+
+func TestWriteDesktopFileRelativeExec(t *testing.T) {
+	if err := WriteDesktopFile(filepath.Join(t.TempDir(), "a.desktop"), Entry{Name: "a", Exec: "notes"}); err == nil {
+		t.Error("expected error for relative Exec")
+	}
+}
+
+func TestProblems(t *testing.T) {
+	dir := t.TempDir()
+	if p := Problems(filepath.Join(dir, "missing.desktop")); p != nil {
+		t.Errorf("missing entry: got %v, want none", p)
+	}
+
+	path := filepath.Join(dir, "notes.desktop")
+	content := "[Desktop Entry]\nType=Application\nExec=/nonexistent/notes --hidden\nHidden=true\nX-GNOME-Autostart-enabled=false\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := Problems(path)
+	if len(p) != 3 {
+		t.Fatalf("Problems() = %v, want 3 problems", p)
+	}
+	for i, want := range []string{"Hidden=true", "disabled", "no longer exists"} {
+		if !strings.Contains(p[i], want) {
+			t.Errorf("problem %d = %q, want it to mention %q", i, p[i], want)
+		}
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "launch-at-login.json")
+	want := State{Enabled: true, Status: StatusRequiresApproval, UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	if err := SaveState(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.UpdatedAt.Equal(want.UpdatedAt) || got.Enabled != want.Enabled || got.Status != want.Status {
+		t.Errorf("LoadState = %+v, want %+v", got, want)
+	}
+}
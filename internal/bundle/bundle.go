@@ -0,0 +1,143 @@
+// Package bundle produces a deterministic byte stream for directory artifacts
+// such as macOS .app bundles, so they can be hashed and signed the same way
+// as single-file artifacts.
+//
+// Stream format: an uncompressed tar archive (as written by Go's archive/tar)
+// containing every entry under the bundle root, excluding the root itself.
+//
+//   - Entries are sorted by their slash-separated path relative to the root.
+//   - Directory names end in "/".
+//   - ModTime is the Unix epoch, Uid/Gid are 0, Uname/Gname are empty.
+//   - Directories and executable files have mode 0755, other files 0644,
+//     and symlinks 0777 with their link target recorded as-is.
+//   - Any other file types (sockets, devices) are rejected.
+//
+// The SHA-256 of this stream is the bundle hash. Because ownership, timestamps
+// and filesystem walk order are normalized away, the same bundle contents always
+// hash to the same value.
+package bundle
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// entry is a single file, directory, or symlink inside a bundle.
+type entry struct {
+	name string // slash-separated path relative to the bundle root
+	path string // absolute path on disk
+	info fs.FileInfo
+}
+
+// WriteTar writes the deterministic tar stream for the bundle at dir to w.
+func WriteTar(w io.Writer, dir string) error {
+	entries, err := collect(dir)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		hdr, err := header(e)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("write header for %s: %w", e.name, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		f, err := os.Open(e.path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("write %s: %w", e.name, err)
+		}
+	}
+	return tw.Close()
+}
+
+// Hash returns the hex-encoded SHA-256 of the bundle's deterministic tar stream.
+func Hash(dir string) (string, error) {
+	h := sha256.New()
+	if err := WriteTar(h, dir); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// collect walks dir and returns its entries sorted by relative path.
+func collect(dir string) ([]entry, error) {
+	var entries []entry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if d.IsDir() {
+			name += "/"
+		}
+		entries = append(entries, entry{name: name, path: path, info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	return entries, nil
+}
+
+// header builds a normalized tar header for an entry.
+func header(e entry) (*tar.Header, error) {
+	hdr := &tar.Header{
+		Name:    e.name,
+		ModTime: time.Unix(0, 0),
+	}
+	mode := e.info.Mode()
+	switch {
+	case mode.IsDir():
+		hdr.Typeflag = tar.TypeDir
+		hdr.Mode = 0o755
+	case mode&fs.ModeSymlink != 0:
+		target, err := os.Readlink(e.path)
+		if err != nil {
+			return nil, err
+		}
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = filepath.ToSlash(target)
+		hdr.Mode = 0o777
+	case mode.IsRegular():
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = e.info.Size()
+		hdr.Mode = 0o644
+		if mode&0o111 != 0 {
+			hdr.Mode = 0o755
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file type in bundle: %s (%s)", e.name, mode.Type())
+	}
+	return hdr, nil
+}
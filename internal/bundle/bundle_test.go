@@ -0,0 +1,125 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// makeBundle creates a small .app-like directory tree and returns its path.
+func makeBundle(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "My.app")
+	files := map[string]struct {
+		data string
+		mode os.FileMode
+	}{
+		"Contents/Info.plist":      {"<plist/>", 0o644},
+		"Contents/MacOS/myapp":     {"binary", 0o755},
+		"Contents/Resources/a.png": {"png", 0o600},
+	}
+	for name, f := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f.data), f.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestHashIgnoresTimesAndModes(t *testing.T) {
+	dir := makeBundle(t)
+	first, err := Hash(dir)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	// Touch every file and loosen a non-executable mode: the hash must not change.
+	old := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		return os.Chtimes(path, old, old)
+	})
+	os.Chmod(filepath.Join(dir, "Contents/Resources/a.png"), 0o664)
+
+	second, err := Hash(dir)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if first != second {
+		t.Errorf("hash changed after metadata-only changes: %s != %s", first, second)
+	}
+}
+
+func TestHashDetectsContentAndExecChanges(t *testing.T) {
+	dir := makeBundle(t)
+	base, _ := Hash(dir)
+
+	os.WriteFile(filepath.Join(dir, "Contents/Info.plist"), []byte("<plist>x</plist>"), 0o644)
+	changed, _ := Hash(dir)
+	if changed == base {
+		t.Error("expected hash to change after content change")
+	}
+
+	os.Chmod(filepath.Join(dir, "Contents/MacOS/myapp"), 0o644)
+	noExec, _ := Hash(dir)
+	if noExec == changed {
+		t.Error("expected hash to change after dropping the executable bit")
+	}
+}
+
+func TestWriteTarIsSortedAndNormalized(t *testing.T) {
+	dir := makeBundle(t)
+	var buf bytes.Buffer
+	if err := WriteTar(&buf, dir); err != nil {
+		t.Fatalf("WriteTar: %v", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		names = append(names, hdr.Name)
+		if !hdr.ModTime.Equal(time.Unix(0, 0)) {
+			t.Errorf("%s: expected epoch mtime, got %v", hdr.Name, hdr.ModTime)
+		}
+		if hdr.Uid != 0 || hdr.Gid != 0 || hdr.Uname != "" || hdr.Gname != "" {
+			t.Errorf("%s: expected normalized ownership", hdr.Name)
+		}
+		if hdr.Name == "Contents/Resources/a.png" && hdr.Mode != 0o644 {
+			t.Errorf("%s: expected mode 0644, got %o", hdr.Name, hdr.Mode)
+		}
+		if hdr.Name == "Contents/MacOS/myapp" && hdr.Mode != 0o755 {
+			t.Errorf("%s: expected mode 0755, got %o", hdr.Name, hdr.Mode)
+		}
+	}
+
+	want := []string{
+		"Contents/",
+		"Contents/Info.plist",
+		"Contents/MacOS/",
+		"Contents/MacOS/myapp",
+		"Contents/Resources/",
+		"Contents/Resources/a.png",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %v", len(want), len(names), names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("entry %d: expected %q, got %q", i, want[i], names[i])
+		}
+	}
+}
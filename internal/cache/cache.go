@@ -0,0 +1,273 @@
+// Package cache implements a size-bounded on-disk cache with per-entry TTLs.
+// It lives in the app's cache directory so apps that download thumbnails,
+// models, or API responses get eviction for free instead of filling the data dir.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBytes is the size limit used when none is configured.
+const DefaultMaxBytes int64 = 256 * 1024 * 1024
+
+// Cache is a directory of cached entries. Each entry is stored as a data file
+// plus a small JSON metadata file, both named by the SHA-256 of the key.
+// When the total size exceeds the limit, least recently used entries are
+// evicted. Expired entries are removed on access and during eviction.
+type Cache struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	now      func() time.Time
+}
+
+// Entry describes a cached item.
+type Entry struct {
+	Key      string    `json:"key"`
+	Size     int64     `json:"size"`
+	Created  time.Time `json:"created"`
+	Accessed time.Time `json:"accessed"`
+	Expires  time.Time `json:"expires,omitempty"` // zero means no expiry
+}
+
+// New opens (creating if needed) a cache rooted at dir. maxBytes <= 0 uses DefaultMaxBytes.
+func New(dir string, maxBytes int64) (*Cache, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+	return &Cache{dir: dir, maxBytes: maxBytes, now: time.Now}, nil
+}
+
+// Dir returns the cache's root directory.
+func (c *Cache) Dir() string { return c.dir }
+
+// Put stores data under key. ttl <= 0 means the entry never expires (it can
+// still be evicted for space). Entries larger than the cache limit are rejected.
+func (c *Cache) Put(key string, data []byte, ttl time.Duration) error {
+	if int64(len(data)) > c.maxBytes {
+		return fmt.Errorf("cache entry %q is %d bytes, larger than the cache limit of %d bytes", key, len(data), c.maxBytes)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	e := Entry{Key: key, Size: int64(len(data)), Created: now, Accessed: now}
+	if ttl > 0 {
+		e.Expires = now.Add(ttl)
+	}
+
+	dataPath, metaPath := c.paths(key)
+	if err := os.WriteFile(dataPath, data, 0o644); err != nil {
+		return err
+	}
+	if err := writeMeta(metaPath, e); err != nil {
+		os.Remove(dataPath)
+		return err
+	}
+	return c.evictLocked()
+}
+
+// Get returns the data for key. ok is false if the key is missing or expired.
+func (c *Cache) Get(key string) (data []byte, ok bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dataPath, metaPath := c.paths(key)
+	e, err := readMeta(metaPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if c.expired(e) {
+		c.removeLocked(key)
+		return nil, false, nil
+	}
+
+	data, err = os.ReadFile(dataPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			os.Remove(metaPath)
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	e.Accessed = c.now()
+	writeMeta(metaPath, e) // best effort: only affects LRU order
+	return data, true, nil
+}
+
+// Delete removes key from the cache. Deleting a missing key is not an error.
+func (c *Cache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.removeLocked(key)
+}
+
+// Clear removes every entry. The cache shares its directory with other
+// files, such as the app's own cache files and startup timings, so only
+// entry files are removed.
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !isEntryFile(f.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, f.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Size returns the total bytes of live cache entries.
+func (c *Cache) Size() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, err := c.entriesLocked()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	return total, nil
+}
+
+// Prune removes expired entries and evicts least recently used entries until
+// the cache is within its size limit.
+func (c *Cache) Prune() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evictLocked()
+}
+
+// evictLocked drops expired entries, then the least recently accessed entries
+// until the total size fits. Must be called with c.mu held.
+func (c *Cache) evictLocked() error {
+	entries, err := c.entriesLocked()
+	if err != nil {
+		return err
+	}
+
+	var live []Entry
+	var total int64
+	for _, e := range entries {
+		if c.expired(e) {
+			c.removeLocked(e.Key)
+			continue
+		}
+		live = append(live, e)
+		total += e.Size
+	}
+	if total <= c.maxBytes {
+		return nil
+	}
+
+	sort.Slice(live, func(i, j int) bool { return live[i].Accessed.Before(live[j].Accessed) })
+	for _, e := range live {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := c.removeLocked(e.Key); err != nil {
+			return err
+		}
+		total -= e.Size
+	}
+	return nil
+}
+
+// entriesLocked reads all entry metadata. Must be called with c.mu held.
+func (c *Cache) entriesLocked() ([]Entry, error) {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".meta") {
+			continue
+		}
+		e, err := readMeta(filepath.Join(c.dir, f.Name()))
+		if err != nil {
+			continue // skip corrupt metadata; it will be overwritten on next Put
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (c *Cache) removeLocked(key string) error {
+	dataPath, metaPath := c.paths(key)
+	if err := os.Remove(dataPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(metaPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// isEntryFile reports whether name is an entry's data or metadata file: a
+// hex SHA-256 with a .bin or .meta extension.
+func isEntryFile(name string) bool {
+	base, ok := strings.CutSuffix(name, ".bin")
+	if !ok {
+		base, ok = strings.CutSuffix(name, ".meta")
+	}
+	if !ok || len(base) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(base)
+	return err == nil
+}
+
+func (c *Cache) expired(e Entry) bool {
+	return !e.Expires.IsZero() && !c.now().Before(e.Expires)
+}
+
+// paths returns the data and metadata file paths for a key.
+func (c *Cache) paths(key string) (dataPath, metaPath string) {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name+".bin"), filepath.Join(c.dir, name+".meta")
+}
+
+func readMeta(path string) (Entry, error) {
+	var e Entry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return e, err
+	}
+	err = json.Unmarshal(data, &e)
+	return e, err
+}
+
+func writeMeta(path string, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestCache returns a cache with a controllable clock.
+func newTestCache(t *testing.T, maxBytes int64) (*Cache, *time.Time) {
+	t.Helper()
+	c, err := New(t.TempDir(), maxBytes)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestPutGet(t *testing.T) {
+	c, _ := newTestCache(t, 0)
+
+	if err := c.Put("thumb:1", []byte("hello"), 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	data, ok, err := c.Get("thumb:1")
+	if err != nil || !ok {
+		t.Fatalf("expected hit, got ok=%v err=%v", ok, err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("expected %q, got %q", "hello", data)
+	}
+
+	if _, ok, _ := c.Get("missing"); ok {
+		t.Error("expected miss for unknown key")
+	}
+}
+
+func TestTTLExpiry(t *testing.T) {
+	c, now := newTestCache(t, 0)
+
+	c.Put("k", []byte("v"), time.Minute)
+	*now = now.Add(30 * time.Second)
+	if _, ok, _ := c.Get("k"); !ok {
+		t.Fatal("expected hit before expiry")
+	}
+
+	*now = now.Add(time.Minute)
+	if _, ok, _ := c.Get("k"); ok {
+		t.Error("expected miss after expiry")
+	}
+	if size, _ := c.Size(); size != 0 {
+		t.Errorf("expected expired entry to be removed, size=%d", size)
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	c, now := newTestCache(t, 10)
+
+	c.Put("a", []byte("aaaa"), 0)
+	*now = now.Add(time.Second)
+	c.Put("b", []byte("bbbb"), 0)
+	*now = now.Add(time.Second)
+
+	// Touch "a" so "b" becomes least recently used
+	c.Get("a")
+	*now = now.Add(time.Second)
+
+	c.Put("c", []byte("cccc"), 0)
+
+	if _, ok, _ := c.Get("b"); ok {
+		t.Error("expected least recently used entry b to be evicted")
+	}
+	if _, ok, _ := c.Get("a"); !ok {
+		t.Error("expected recently used entry a to survive")
+	}
+	if _, ok, _ := c.Get("c"); !ok {
+		t.Error("expected newest entry c to survive")
+	}
+	if size, _ := c.Size(); size > 10 {
+		t.Errorf("expected size <= 10, got %d", size)
+	}
+}
+
+func TestPutTooLarge(t *testing.T) {
+	c, _ := newTestCache(t, 4)
+	if err := c.Put("big", []byte("12345"), 0); err == nil {
+		t.Error("expected error for entry larger than the cache")
+	}
+}
+
+func TestDeleteAndClear(t *testing.T) {
+	c, _ := newTestCache(t, 0)
+	c.Put("a", []byte("1"), 0)
+	c.Put("b", []byte("2"), 0)
+
+	if err := c.Delete("a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := c.Delete("a"); err != nil {
+		t.Errorf("deleting a missing key should not error: %v", err)
+	}
+	if _, ok, _ := c.Get("a"); ok {
+		t.Error("expected a to be deleted")
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if size, _ := c.Size(); size != 0 {
+		t.Errorf("expected empty cache, size=%d", size)
+	}
+}
+
+func TestClearKeepsOtherFiles(t *testing.T) {
+	c, _ := newTestCache(t, 0)
+	c.Put("a", []byte("1"), 0)
+	others := []string{"startup.json", "notes.bin", "thumbs/x.bin"}
+	for _, name := range others {
+		path := filepath.Join(c.Dir(), filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte("keep"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, ok, _ := c.Get("a"); ok {
+		t.Error("expected a to be cleared")
+	}
+	for _, name := range others {
+		if _, err := os.Stat(filepath.Join(c.Dir(), filepath.FromSlash(name))); err != nil {
+			t.Errorf("Clear removed %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+
+	"github.com/lightshell-dev/lightshell/internal/iconset"
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/winres"
+)
+
+// macIconName is the app icon's name in Contents/Resources, which
+// CFBundleIconFile gives without the extension.
+const macIconName = "icon"
+
+// appIcon decodes build.icon, the one image every platform's app icon is
+// made from, or returns nil when it is not set.
+func appIcon(dir string, cfg lsruntime.Config) (image.Image, error) {
+	if cfg.Build.Icon == "" {
+		return nil, nil
+	}
+	img, err := loadIcon(dir, cfg.Build.Icon)
+	if err != nil {
+		return nil, fmt.Errorf("could not read build.icon: %w", err)
+	}
+	return img, nil
+}
+
+// writeIconResource writes the app icon into staging as a Windows resource
+// the go tool links into the executable, where Explorer and the app's
+// windows find it.
+func writeIconResource(staging, goarch string, icon image.Image) error {
+	ico, err := iconset.ICO(icon)
+	if err != nil {
+		return err
+	}
+	syso, err := winres.IconSyso(ico, goarch)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(staging, winres.SysoName(goarch)), syso, 0o644)
+}
+
+// linuxIcons returns the app icon at each size Linux desktops use, keyed
+// by its path under the hicolor theme.
+func linuxIcons(icon image.Image, name string) (map[string][]byte, error) {
+	sizes := iconset.Sizes["linux"]
+	pngs, err := iconset.PNGs(icon, sizes)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, len(sizes))
+	for _, size := range sizes {
+		files[fmt.Sprintf("usr/share/icons/hicolor/%dx%d/apps/%s.png", size, size, name)] = pngs[size]
+	}
+	return files, nil
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/clientjs"
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/webview"
+)
+
+// A bug report is a zip of what a dev app's page looked like, to attach to
+// an issue: page.html, the DOM with its styles inlined; screenshot.png;
+// console.log, the console tail; and report.json, describing the page and
+// the app. Reports are kept in .lightshell/reports.
+
+// pageSnapshot is what clientjs.Snapshot returns.
+type pageSnapshot struct {
+	HTML      string `json:"html"`
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	UserAgent string `json:"userAgent"`
+}
+
+// bugReportInfo is report.json.
+type bugReportInfo struct {
+	CreatedAt  time.Time `json:"createdAt"`
+	App        string    `json:"app,omitempty"`
+	AppVersion string    `json:"appVersion,omitempty"`
+	LightShell string    `json:"lightshellVersion"`
+	Platform   string    `json:"platform"`
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	UserAgent  string    `json:"userAgent"`
+	Console    int       `json:"consoleEntries"`
+	Files      []string  `json:"files"`
+}
+
+// bugReport is the result of saving a bug report.
+type bugReport struct {
+	Path    string   `json:"path"`
+	Files   []string `json:"files"`
+	Console int      `json:"consoleEntries"`
+}
+
+// bugReportConsoleLines is how many console entries a report keeps.
+const bugReportConsoleLines = 200
+
+func reportsDir(dir string) string {
+	return filepath.Join(dir, ".lightshell", "reports")
+}
+
+// writeBugReport saves a bug report of page to the reports of the project
+// dir. A report without a screenshot is still saved.
+func writeBugReport(dir string, page pageSnapshot, screenshot []byte, console []mcpConsoleEntry) (bugReport, error) {
+	if len(console) > bugReportConsoleLines {
+		console = console[len(console)-bugReportConsoleLines:]
+	}
+	if err := os.MkdirAll(reportsDir(dir), 0o755); err != nil {
+		return bugReport{}, fmt.Errorf("could not create reports directory: %w", err)
+	}
+	now := time.Now()
+	f, path, err := createReportFile(reportsDir(dir), "report-"+now.Format("2006-01-02T15-04-05"))
+	if err != nil {
+		return bugReport{}, err
+	}
+	defer f.Close()
+
+	info := bugReportInfo{
+		CreatedAt:  now.UTC(),
+		LightShell: runtime.Version,
+		Platform:   goruntime.GOOS + "/" + goruntime.GOARCH,
+		Title:      page.Title,
+		URL:        page.URL,
+		Width:      page.Width,
+		Height:     page.Height,
+		UserAgent:  page.UserAgent,
+		Console:    len(console),
+	}
+	if cfg, err := runtime.LoadConfig(dir); err == nil {
+		info.App, info.AppVersion = cfg.Name, cfg.Version
+	}
+
+	var log strings.Builder
+	for _, e := range console {
+		if e.Window != "" {
+			fmt.Fprintf(&log, "%s [%s] [%s] %s\n", e.Timestamp, e.Window, e.Level, e.Message)
+		} else {
+			fmt.Fprintf(&log, "%s [%s] %s\n", e.Timestamp, e.Level, e.Message)
+		}
+	}
+	type reportFile struct {
+		name string
+		data []byte
+	}
+	files := []reportFile{{"page.html", []byte(page.HTML)}}
+	if len(screenshot) > 0 {
+		files = append(files, reportFile{"screenshot.png", screenshot})
+	}
+	files = append(files, reportFile{"console.log", []byte(log.String())})
+	for _, file := range files {
+		info.Files = append(info.Files, file.name)
+	}
+	info.Files = append(info.Files, "report.json")
+	infoJSON, _ := json.MarshalIndent(info, "", "  ")
+	files = append(files, reportFile{"report.json", append(infoJSON, '\n')})
+
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: now})
+		if err == nil {
+			_, err = w.Write(file.data)
+		}
+		if err != nil {
+			os.Remove(path)
+			return bugReport{}, fmt.Errorf("could not write bug report: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		os.Remove(path)
+		return bugReport{}, fmt.Errorf("could not write bug report: %w", err)
+	}
+	return bugReport{Path: path, Files: info.Files, Console: len(console)}, nil
+}
+
+// createReportFile creates name.zip in dir, or name-2.zip and so on when
+// a report was already saved that second.
+func createReportFile(dir, name string) (*os.File, string, error) {
+	for i := 1; ; i++ {
+		path := filepath.Join(dir, name+".zip")
+		if i > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d.zip", name, i))
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("could not create bug report: %w", err)
+		}
+		return f, path, nil
+	}
+}
+
+// registerExportPage registers dev.exportPage, which the debug console's
+// Report button calls with a snapshot of the page and its console tail.
+// The report is saved in the background, since the screenshot needs the
+// main thread the call arrives on; the debug console is told where it went
+// with window.__lightshell_debug.reportSaved.
+func registerExportPage(router *ipc.Router, wv webview.Webview, dir string) {
+	router.Handle("dev.exportPage", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Page    pageSnapshot      `json:"page"`
+			Console []mcpConsoleEntry `json:"console"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		go func() {
+			screenshot, _ := wv.Screenshot()
+			result := map[string]string{}
+			if report, err := writeBugReport(dir, p.Page, screenshot, p.Console); err != nil {
+				result["error"] = err.Error()
+			} else {
+				result["path"] = report.Path
+				fmt.Printf("Bug report saved to %s\n", report.Path)
+			}
+			resultJSON, _ := json.Marshal(result)
+			wv.Eval(fmt.Sprintf("window.__lightshell_debug && window.__lightshell_debug.reportSaved(%s)", resultJSON))
+		}()
+		return map[string]string{"status": "saving"}, nil
+	})
+}
+
+// snapshotScript returns JS that posts clientjs.Snapshot's result to
+// handler as the result of callbackJSON.
+func snapshotScript(handler, callbackJSON string) string {
+	return fmt.Sprintf(`(function(){
+		try {
+			var page = (%[3]s)();
+			window.webkit.messageHandlers.%[1]s.postMessage(JSON.stringify({
+				__mcp_eval: %[2]s,
+				result: JSON.stringify(page)
+			}));
+		} catch(e) {
+			window.webkit.messageHandlers.%[1]s.postMessage(JSON.stringify({
+				__mcp_eval: %[2]s,
+				error: e.message || String(e)
+			}));
+		}
+	})()`, handler, callbackJSON, clientjs.Snapshot)
+}
@@ -1,77 +1,389 @@
 package cli
 
 import (
-	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
 
+	lightshell "github.com/lightshell-dev/lightshell"
+	"github.com/lightshell-dev/lightshell/internal/accel"
+	"github.com/lightshell-dev/lightshell/internal/assetpack"
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+	"github.com/lightshell-dev/lightshell/internal/iconset"
+	"github.com/lightshell-dev/lightshell/internal/ignore"
 	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/scripting"
+	"github.com/lightshell-dev/lightshell/internal/security"
+	"github.com/lightshell-dev/lightshell/internal/semver"
+	"github.com/lightshell-dev/lightshell/internal/themeicon"
 )
 
-//go:embed buildfiles/webview_darwin.m
-var webviewDarwinM string
+// BuildFlags holds flags for the build command.
+type BuildFlags struct {
+	Target    string // package format, "default" for the OS's usual one, or "all"
+	Platforms string // comma-separated platforms to build for instead of this one
+	Sign      bool   // code-sign with build.mac.identity
+	Notarize  bool   // notarize and staple the signed outputs
+	JSON      bool   // print the built artifacts as JSON
+	// ReportSize reports the size API gating saved, which takes a second
+	// compile
+	ReportSize bool
+}
+
+// buildTargets lists the package formats each OS builds, its default first.
+var buildTargets = map[string][]string{
+	"darwin":  {"app", "dmg"},
+	"windows": {"nsis"},
+	"linux":   {"appimage", "deb", "rpm"},
+}
+
+var osNames = map[string]string{"darwin": "macOS", "windows": "Windows", "linux": "Linux"}
+
+func parseBuildFlags(args []string) (BuildFlags, error) {
+	flags := BuildFlags{Target: "default"}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--target":
+			if i+1 >= len(args) {
+				return flags, fmt.Errorf("--target requires a value")
+			}
+			i++
+			flags.Target = args[i]
+		case "--platform":
+			if i+1 >= len(args) {
+				return flags, fmt.Errorf("--platform requires a value")
+			}
+			i++
+			flags.Platforms = args[i]
+		case "--sign":
+			flags.Sign = true
+		case "--notarize":
+			flags.Notarize = true
+		case "--json":
+			flags.JSON = true
+		case "--report-size":
+			flags.ReportSize = true
+		default:
+			return flags, fmt.Errorf("unknown flag: %s", args[i])
+		}
+	}
+	if flags.Notarize && !flags.Sign {
+		return flags, fmt.Errorf("--notarize requires --sign")
+	}
+	return flags, nil
+}
+
+// resolveTargets returns the package formats target stands for on goos.
+func resolveTargets(target, goos string) ([]string, error) {
+	formats := buildTargets[goos]
+	if formats == nil {
+		return nil, fmt.Errorf("build not yet supported on %s", goos)
+	}
+	switch target {
+	case "default":
+		return formats[:1], nil
+	case "all":
+		return formats, nil
+	}
+	for _, f := range formats {
+		if f == target {
+			return []string{f}, nil
+		}
+	}
+	for other, fs := range buildTargets {
+		for _, f := range fs {
+			if f == target {
+				return nil, fmt.Errorf("--target %s packages for %s; build it on %s", target, osNames[other], osNames[other])
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown --target %q (expected default, %s or all)", target, strings.Join(formats, ", "))
+}
+
+// checkPackagers makes sure the tools the formats need are installed. For
+// --target all, formats whose tool is missing are left out.
+func checkPackagers(w io.Writer, formats []string, all bool) ([]string, error) {
+	var ok []string
+	for _, f := range formats {
+		if f == "dmg" && runtime.GOOS != "darwin" {
+			if !all {
+				return nil, fmt.Errorf("DMGs can only be built on macOS, which has hdiutil")
+			}
+			fmt.Fprintln(w, "Not on macOS; skipping the .dmg")
+			continue
+		}
+		if f == "rpm" {
+			if _, err := exec.LookPath("rpmbuild"); err != nil {
+				if !all {
+					return nil, fmt.Errorf("rpmbuild not found; install rpm-build (Fedora, RHEL) or rpm (Debian, Ubuntu) to build .rpm packages")
+				}
+				fmt.Fprintln(w, "rpmbuild not found; skipping the .rpm")
+				continue
+			}
+		}
+		ok = append(ok, f)
+	}
+	return ok, nil
+}
+
+// buildPlan is what Build makes for one platform.
+type buildPlan struct {
+	platform buildPlatform
+	targets  []string
+	cc       string // C compiler for cgo, "" for the default
+	distDir  string
+}
+
+// builtArtifact is a packaged output, the target it was packaged as, and
+// the platform it runs on.
+type builtArtifact struct {
+	path     string
+	target   string
+	platform buildPlatform
+}
+
+// buildResult is what build --json prints.
+type buildResult struct {
+	Name       string          `json:"name"`
+	Version    string          `json:"version"`
+	DurationMs int64           `json:"durationMs"`
+	Artifacts  []buildArtifact `json:"artifacts"`
+}
+
+// buildArtifact describes one packaged output.
+type buildArtifact struct {
+	Path     string `json:"path"`
+	Target   string `json:"target"`
+	Platform string `json:"platform"`
+	Size     int64  `json:"size"` // in bytes, all files of a bundle included
+}
+
+// Build handles the `lightshell build` command.
+func Build(args []string) error {
+	flags, err := parseBuildFlags(args)
+	if err != nil {
+		return usageError(err)
+	}
+	if flags.JSON {
+		return runJSON(func(w io.Writer) (any, error) { return build(w, flags) })
+	}
+	_, err = build(os.Stdout, flags)
+	return err
+}
 
-// Build compiles the app for the current platform.
-func Build() error {
+// build compiles the app for the current platform, or those --platform
+// lists, and packages it in the formats --target names.
+func build(w io.Writer, flags BuildFlags) (buildResult, error) {
 	start := time.Now()
 
+	platforms := []buildPlatform{hostPlatform()}
+	if flags.Platforms != "" {
+		var err error
+		if platforms, err = parsePlatforms(flags.Platforms); err != nil {
+			return buildResult{}, err
+		}
+	}
+	if flags.Sign && runtime.GOOS != "darwin" {
+		return buildResult{}, fmt.Errorf("--sign and --notarize are only supported on macOS")
+	}
+
 	dir, err := os.Getwd()
 	if err != nil {
-		return err
+		return buildResult{}, err
 	}
 
-	cfg, err := lsruntime.LoadConfig(dir)
+	cfg, err := loadConfig(dir)
 	if err != nil {
-		return err
+		return buildResult{}, err
 	}
 
-	// If a build command is configured (e.g. Vite), run it first
-	if cfg.BuildCommand != "" {
-		if _, err := os.Stat(filepath.Join(dir, "node_modules")); os.IsNotExist(err) {
-			return fmt.Errorf("node_modules not found. Run 'npm install' first")
+	// Find every platform's targets and toolchain before building any, so
+	// a missing cross-compiler is reported up front
+	var plans []buildPlan
+	var problems []string
+	windows := 0
+	for _, p := range platforms {
+		targets, err := resolveTargets(flags.Target, p.GOOS)
+		if err != nil {
+			return buildResult{}, err
+		}
+		if targets, err = checkPackagers(w, targets, flags.Target == "all"); err != nil {
+			return buildResult{}, err
+		}
+		plan := buildPlan{platform: p, targets: targets, distDir: filepath.Join(dir, "dist")}
+		if flags.Platforms != "" {
+			plan.distDir = filepath.Join(plan.distDir, p.String())
+		}
+		switch p.GOOS {
+		case "linux":
+			// The packages are ready for it, but there is no WebKitGTK
+			// bridge for the built app to link against yet
+			problems = append(problems, fmt.Sprintf("%s: the Linux webview is not implemented yet, so apps cannot be built for Linux", p))
+			continue
+		case "windows":
+			if windows++; windows == 2 {
+				problems = append(problems, fmt.Sprintf("%s: build one Windows architecture at a time; WebView2Loader.dll in the project directory is for one architecture", p))
+				continue
+			}
 		}
-		fmt.Printf("Running: %s\n", cfg.BuildCommand)
-		parts := strings.Fields(cfg.BuildCommand)
-		buildCmd := exec.Command(parts[0], parts[1:]...)
-		buildCmd.Dir = dir
-		buildCmd.Stdout = os.Stdout
-		buildCmd.Stderr = os.Stderr
-		if err := buildCmd.Run(); err != nil {
-			return fmt.Errorf("build command failed: %w", err)
+		if plan.cc, err = crossCompiler(p, cfg.Build.CrossCompilers); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", p, err))
+			continue
 		}
+		plans = append(plans, plan)
+	}
+	if len(problems) == 1 && flags.Platforms == "" {
+		_, problem, _ := strings.Cut(problems[0], ": ")
+		return buildResult{}, errors.New(problem)
+	}
+	if len(problems) > 0 {
+		return buildResult{}, fmt.Errorf("cannot build for every platform:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	for _, plan := range plans {
+		if _, err := acceleratorScriptFor(cfg, plan.platform.GOOS); err != nil {
+			return buildResult{}, err
+		}
+	}
+	if err := cfg.Window.Titlebar.Validate(); err != nil {
+		return buildResult{}, withCode(lserrors.ConfigInvalid, fmt.Errorf("invalid window.titlebar in lightshell.json: %w", err))
+	}
+	if err := cfg.LaunchArgs.Validate(); err != nil {
+		return buildResult{}, invalidConfig(err)
+	}
+	if err := validateMigrations(cfg.Migrations); err != nil {
+		return buildResult{}, invalidConfig(err)
+	}
+	for _, plan := range plans {
+		if slices.Contains(plan.targets, "dmg") {
+			if err := validateDMG(dir, cfg.Build.Mac.DMG); err != nil {
+				return buildResult{}, invalidConfig(err)
+			}
+			break
+		}
+	}
+	if flags.Sign {
+		if err := validateSigning(cfg.Build.Mac, flags.Notarize); err != nil {
+			return buildResult{}, withCode(lserrors.SigningFailed, err)
+		}
+	}
+	icon, err := appIcon(dir, cfg)
+	if err != nil {
+		return buildResult{}, err
+	}
+	if err := recordLightShellVersion(dir, cfg.LightShellVersion); err != nil {
+		return buildResult{}, err
+	}
+
+	names := make([]string, len(plans))
+	for i, plan := range plans {
+		names[i] = plan.platform.String()
+	}
+	if err := runHook(w, "preBuild", cfg.Hooks.PreBuild, dir, hookEnv{
+		OutputPath: filepath.Join(dir, "dist"),
+		Version:    cfg.Version,
+		Platform:   strings.Join(names, ","),
+	}); err != nil {
+		return buildResult{}, err
+	}
+
+	// If a build command is configured (e.g. Vite), run it first
+	if err := runBuildCommand(w, dir, cfg); err != nil {
+		return buildResult{}, withCode(lserrors.BuildFailed, err)
 	}
 
 	// Create staging directory
 	staging, err := os.MkdirTemp("", "lightshell-build-*")
 	if err != nil {
-		return fmt.Errorf("failed to create staging dir: %w", err)
+		return buildResult{}, fmt.Errorf("failed to create staging dir: %w", err)
 	}
 	defer os.RemoveAll(staging)
 
-	// Copy user source into staging
+	// Copy user source into staging, without what .lightshellignore lists
+	ign, err := ignore.Load(dir)
+	if err != nil {
+		return buildResult{}, fmt.Errorf("could not read %s: %w", ignore.FileName, err)
+	}
+	if ign.Match(cfg.Entry, false) {
+		return buildResult{}, fmt.Errorf("entry %s is listed in %s, so the app would have no page", cfg.Entry, ignore.FileName)
+	}
 	srcDir := filepath.Join(dir, filepath.Dir(cfg.Entry))
 	stagingSrc := filepath.Join(staging, "src")
-	if err := copyDir(srcDir, stagingSrc); err != nil {
-		return fmt.Errorf("failed to stage source files: %w", err)
+	bundle := cfg.Build.Bundle
+	if bundle != nil {
+		if err := validateBundle(dir, srcDir, bundle); err != nil {
+			return buildResult{}, err
+		}
+	}
+	skip := func(path string, info os.FileInfo) bool {
+		rel, _ := filepath.Rel(dir, path)
+		if bundle != nil && !info.IsDir() && compiledOnly(path) {
+			return true
+		}
+		return ign.Match(rel, info.IsDir())
+	}
+	if err := copyDir(srcDir, stagingSrc, skip); err != nil {
+		return buildResult{}, fmt.Errorf("failed to stage source files: %w", err)
 	}
 
-	// Copy scripts (polyfills + lightshell client + defaults CSS)
-	stageScripts := filepath.Join(staging, "scripts")
-	os.MkdirAll(stageScripts, 0o755)
-	os.WriteFile(filepath.Join(stageScripts, "polyfills.js"), []byte(polyfillsJS), 0o644)
-	os.WriteFile(filepath.Join(stageScripts, "lightshell.js"), []byte(clientJS), 0o644)
-	os.WriteFile(filepath.Join(stageScripts, "defaults.css"), []byte(defaultsCSS), 0o644)
+	// Bundled scripts replace their sources in the staged tree
+	if bundle != nil {
+		fmt.Fprintf(w, "Bundling %s\n", strings.Join(bundle.EntryPoints, ", "))
+		if err := runBundle(w, dir, srcDir, stagingSrc, bundle, false); err != nil {
+			return buildResult{}, err
+		}
+	}
 
-	// Generate the embed-based main.go for the built app
-	buildMain := filepath.Join(staging, "main.go")
-	if err := generateBuildMain(buildMain, cfg); err != nil {
-		return fmt.Errorf("failed to generate build source: %w", err)
+	sources, err := stagedSourcesDigest(stagingSrc)
+	if err != nil {
+		return buildResult{}, fmt.Errorf("failed to hash source files: %w", err)
+	}
+
+	// Optionally replace the embedded source tree with a deduplicated,
+	// compressed asset pack
+	if cfg.Build.CompressAssets {
+		pack, stats, err := assetpack.Pack(stagingSrc, true)
+		if err != nil {
+			return buildResult{}, fmt.Errorf("failed to pack assets: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(staging, "assets.pack"), pack, 0o644); err != nil {
+			return buildResult{}, fmt.Errorf("failed to write asset pack: %w", err)
+		}
+		fmt.Fprintf(w, "Packed %d assets (%d duplicates): %.1fKB -> %.1fKB\n",
+			stats.Files, stats.Duplicates, float64(stats.RawBytes)/1024, float64(stats.PackedBytes)/1024)
+	}
+
+	// The app's main package is compiled within the lightshell module, so
+	// it runs on the same packages as lightshell run
+	if err := stageModule(staging); err != nil {
+		return buildResult{}, fmt.Errorf("failed to stage the runtime: %w", err)
+	}
+	icons, err := stageThemeIcons(staging, dir, cfg.ThemeIcons)
+	if err != nil {
+		return buildResult{}, err
+	}
+	caPEM, err := security.ReadCAFiles(dir, cfg.Security.TLS.CAFiles)
+	if err != nil {
+		return buildResult{}, err
+	}
+	appCfg, err := json.MarshalIndent(appConfig(cfg, icons, caPEM), "", "  ")
+	if err != nil {
+		return buildResult{}, err
+	}
+	if err := os.WriteFile(filepath.Join(staging, "config.json"), appCfg, 0o644); err != nil {
+		return buildResult{}, fmt.Errorf("failed to stage config: %w", err)
 	}
 
 	// Copy user's handlers.go if it exists, otherwise generate a default stub
@@ -80,631 +392,527 @@ func Build() error {
 	if data, err := os.ReadFile(userHandlers); err == nil {
 		os.WriteFile(stageHandlers, data, 0o644)
 	} else {
-		defaultHandlers := "package main\n\n// customHandlers registers your Go handlers.\n// Edit handlers.go in your project root to add custom handlers.\n//\n// Example:\n//   func customHandlers() {\n//       Handle(\"ai.status\", func(payload json.RawMessage) (any, error) {\n//           return map[string]any{\"ready\": true}, nil\n//       })\n//       OnShutdown(func() { fmt.Println(\"Goodbye!\") })\n//   }\nfunc customHandlers() {}\n"
+		defaultHandlers := "package main\n\n// customHandlers registers your Go handlers.\n// Edit handlers.go in your project root to add custom handlers.\n//\n// Example:\n//   func customHandlers() {\n//       Handle(\"ai.status\", func(payload json.RawMessage) (any, error) {\n//           return map[string]any{\"ready\": true}, nil\n//       })\n//       OnShutdown(func() { fmt.Fprintln(w, \"Goodbye!\") })\n//   }\nfunc customHandlers() {}\n"
 		os.WriteFile(stageHandlers, []byte(defaultHandlers), 0o644)
 	}
 
-	// Copy the Objective-C webview bridge
-	if runtime.GOOS == "darwin" {
-		os.WriteFile(filepath.Join(staging, "webview_darwin.m"), []byte(webviewDarwinM), 0o644)
-	}
-
-	// Generate go.mod for the staging dir
-	buildGoMod := filepath.Join(staging, "go.mod")
-	os.WriteFile(buildGoMod, []byte("module lightshell-app\n\ngo 1.23\n"), 0o644)
-
-	// Compile the Go binary
-	distDir := filepath.Join(dir, "dist")
-	os.MkdirAll(distDir, 0o755)
-
-	binaryName := cfg.Name
-	if binaryName == "" {
-		binaryName = "app"
+	for _, plan := range plans {
+		if cfg.Window.Disabled && plan.platform.GOOS != "darwin" {
+			fmt.Fprintf(w, "Warning: \"window\": false needs the tray, which built apps support on macOS only; the window will be shown on %s\n", osNames[plan.platform.GOOS])
+		}
+		if plan.platform.GOOS == "windows" {
+			if _, err := os.Stat(filepath.Join(dir, "WebView2Loader.dll")); err != nil {
+				return buildResult{}, fmt.Errorf("WebView2Loader.dll not found in the project directory: copy it from the Microsoft.Web.WebView2 NuGet package (build/native/%s/WebView2Loader.dll)", strings.TrimPrefix(plan.platform.String(), "windows-"))
+			}
+		}
 	}
-	binaryPath := filepath.Join(staging, binaryName)
-
-	cmd := exec.Command("go", "build", "-ldflags=-s -w", "-o", binaryPath, ".")
-	cmd.Dir = staging
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("build failed: %w", err)
+	entitlements := filepath.Join(staging, "entitlements.plist")
+	if flags.Sign {
+		if err := writeEntitlements(entitlements, cfg.Build.Mac, buildPermissions(cfg)); err != nil {
+			return buildResult{}, err
+		}
 	}
 
-	// Package for platform
-	var outputPath string
-	switch runtime.GOOS {
-	case "darwin":
-		outputPath, err = packageDarwin(binaryPath, distDir, cfg)
-	default:
-		return fmt.Errorf("build not yet supported on %s", runtime.GOOS)
-	}
-	if err != nil {
-		return fmt.Errorf("packaging failed: %w", err)
+	var outputs []builtArtifact
+	for i, plan := range plans {
+		built, err := buildForPlatform(w, plan, dir, staging, entitlements, icon, cfg, flags, flags.ReportSize && i == 0)
+		if err != nil {
+			if len(plans) > 1 {
+				return buildResult{}, fmt.Errorf("%s: %w", plan.platform, err)
+			}
+			return buildResult{}, err
+		}
+		outputs = append(outputs, built...)
 	}
 
 	// Print result
-	sizeMB := float64(dirSize(outputPath)) / 1024 / 1024
-	sizeStr := fmt.Sprintf("%.1fMB", sizeMB)
+	elapsed := time.Since(start)
+	result := buildResult{Name: cfg.Name, Version: cfg.Version, DurationMs: elapsed.Milliseconds(), Artifacts: []buildArtifact{}}
+	fmt.Fprintf(w, "Built %s in %.1fs\n", cfg.Name, elapsed.Seconds())
+	for _, output := range outputs {
+		size := dirSize(output.path)
+		fmt.Fprintf(w, "Output: %s (%.1fMB)\n", output.path, float64(size)/1024/1024)
+		result.Artifacts = append(result.Artifacts, buildArtifact{Path: output.path, Target: output.target, Platform: output.platform.String(), Size: size})
+	}
 
-	elapsed := time.Since(start).Seconds()
-	fmt.Printf("Built %s in %.1fs -> %s\n", cfg.Name, elapsed, sizeStr)
-	fmt.Printf("Output: %s\n", outputPath)
+	for _, output := range outputs {
+		if err := runHook(w, "postBuild", cfg.Hooks.PostBuild, dir, hookEnv{
+			OutputPath: output.path,
+			Version:    cfg.Version,
+			Platform:   output.platform.String(),
+		}); err != nil {
+			return buildResult{}, err
+		}
 
-	return nil
+		// Written last, so it describes the artifact as the hook left it
+		if err := writeProvenance(w, dir, output.path, cfg, provenanceRecord{started: start, platform: output.platform.String(), sources: sources}); err != nil {
+			return buildResult{}, err
+		}
+	}
+	return result, nil
 }
 
-func generateBuildMain(path string, cfg lsruntime.Config) error {
-	tmpl := `package main
-
-/*
-#cgo darwin CFLAGS: -x objective-c
-#cgo darwin LDFLAGS: -framework Cocoa -framework WebKit
-
-#include <stdlib.h>
-
-extern void WebviewCreate(const char* title, int width, int height, int minWidth, int minHeight,
-	int resizable, int frameless, int alwaysOnTop, int transparent, int devTools);
-extern void WebviewLoadURL(const char* url);
-extern void WebviewLoadHTML(const char* html);
-extern void WebviewEval(const char* js);
-extern void WebviewAddUserScript(const char* js);
-extern void WebviewSetTitle(const char* title);
-extern void WebviewSetSize(int width, int height);
-extern void WebviewSetMinSize(int width, int height);
-extern void WebviewSetMaxSize(int width, int height);
-extern void WebviewSetPosition(int x, int y);
-extern void WebviewFullscreen();
-extern void WebviewMinimize();
-extern void WebviewMaximize();
-extern void WebviewRestore();
-extern void WebviewClose();
-extern void WebviewRun();
-extern void WebviewDestroy();
-extern void WebviewSetContentProtection(int enabled);
-extern void WebviewSetVibrancy(const char* style);
-extern void WebviewSetColorScheme(const char* scheme);
-extern void WebviewEnableFileDrop(void);
-extern int WebviewGetWidth(void);
-extern int WebviewGetHeight(void);
-extern int WebviewGetX(void);
-extern int WebviewGetY(void);
-extern void AppSetBadgeCount(int count);
-*/
-import "C"
-
-import (
-	"embed"
-	"encoding/base64"
-	"encoding/json"
-	"fmt"
-	"io"
-	"io/fs"
-	"net"
-	"net/http"
-	"os"
-	"os/signal"
-	"path/filepath"
-	"runtime"
-	"strings"
-	"syscall"
-	"unsafe"
-)
+// buildForPlatform compiles the staged app for plan's platform and
+// packages it in each of its targets, with icon as the app icon when it is
+// set, signing and notarizing macOS outputs when flags ask for it. measure
+// reports what API gating saved, which takes a second compile.
+func buildForPlatform(w io.Writer, plan buildPlan, dir, staging, entitlements string, icon image.Image, cfg lsruntime.Config, flags BuildFlags, measure bool) ([]builtArtifact, error) {
+	p := plan.platform
+	if plan.cc != "" {
+		fmt.Fprintf(w, "Building for %s with %s\n", p, plan.cc)
+	}
 
-//go:embed src
-var srcFS embed.FS
+	// Generate the embed-based main.go for the built app
+	// Only APIs covered by the declared permissions are compiled in
+	perms := buildPermissions(cfg)
+	buildMain := filepath.Join(staging, "main.go")
+	if err := generateBuildMain(buildMain, cfg, perms); err != nil {
+		return nil, fmt.Errorf("failed to generate build source: %w", err)
+	}
 
-//go:embed scripts/polyfills.js
-var polyfillsJS string
+	// Compile the Go binary
+	distDir := plan.distDir
+	os.MkdirAll(distDir, 0o755)
 
-//go:embed scripts/lightshell.js
-var clientJS string
+	binaryName := cfg.Name
+	if binaryName == "" {
+		binaryName = "app"
+	}
+	if p.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+	binaryPath := filepath.Join(staging, "bin", p.String(), binaryName)
 
-//go:embed scripts/defaults.css
-var defaultsCSS string
+	if p.GOOS == "windows" && icon != nil {
+		if err := writeIconResource(staging, p.GOARCH, icon); err != nil {
+			return nil, fmt.Errorf("failed to embed the app icon: %w", err)
+		}
+	}
+	if err := goBuild(w, staging, binaryPath, plan, true); err != nil {
+		return nil, withCode(lserrors.BuildFailed, fmt.Errorf("build failed: %w", err))
+	}
 
-var msgHandler func(string)
-var ipcHandlers = map[string]func(json.RawMessage)(any, error){}
-var invokeHandlers = map[string]func(json.RawMessage)(any, error){}
-var shutdownHooks []func()
+	if omitted := omittedAPIs(perms); len(omitted) > 0 {
+		msg := fmt.Sprintf("Omitted APIs not covered by permissions: %s", strings.Join(omitted, ", "))
+		if measure {
+			if saved, ok := measureGatingSavings(w, staging, binaryPath, cfg, plan); ok {
+				msg += fmt.Sprintf(" (saved %.1fKB)", float64(saved)/1024)
+			}
+		}
+		fmt.Fprintln(w, msg)
+	}
 
-// Handle registers a custom handler invokable from JS via lightshell.invoke(name, payload).
-func Handle(name string, handler func(json.RawMessage)(any, error)) {
-	invokeHandlers[name] = handler
-}
+	identity := cfg.Build.Mac.Identity
+	bundleApp := func() (string, error) {
+		app, err := packageDarwin(binaryPath, distDir, icon, cfg)
+		if err == nil && flags.Sign {
+			err = withCode(lserrors.SigningFailed, codesignApp(w, app, identity, entitlements))
+		}
+		return app, err
+	}
 
-// OnShutdown registers a function to be called when the app is shutting down.
-func OnShutdown(fn func()) {
-	shutdownHooks = append(shutdownHooks, fn)
+	// Package in each target format
+	var outputs []builtArtifact
+	var appPath string // the .app bundle, which a DMG packages in turn
+	webview2Loader := filepath.Join(dir, "WebView2Loader.dll")
+	for _, target := range plan.targets {
+		var outputPath string
+		var err error
+		switch target {
+		case "app":
+			outputPath, err = bundleApp()
+			appPath = outputPath
+		case "dmg":
+			if appPath == "" {
+				appPath, err = bundleApp()
+			}
+			if err == nil {
+				outputPath, err = packageDMG(w, appPath, dir, distDir, cfg)
+			}
+			if err == nil && flags.Sign {
+				err = withCode(lserrors.SigningFailed, codesignFile(w, outputPath, identity))
+			}
+		case "nsis":
+			outputPath, err = packageWindows(w, binaryPath, webview2Loader, distDir, icon, cfg)
+		case "appimage":
+			outputPath, err = packageAppImage(w, binaryPath, distDir, p.GOARCH, icon, cfg)
+		case "deb":
+			outputPath, err = packageDeb(binaryPath, distDir, p.GOARCH, icon, cfg)
+		case "rpm":
+			outputPath, err = packageRPM(w, binaryPath, distDir, p.GOARCH, icon, cfg)
+		}
+		if err != nil {
+			return nil, withCode(lserrors.BuildFailed, fmt.Errorf("packaging %s failed: %w", target, err))
+		}
+		if flags.Notarize && p.GOOS == "darwin" {
+			if err := notarize(w, outputPath, cfg.Build.Mac); err != nil {
+				return nil, withCode(lserrors.SigningFailed, err)
+			}
+		}
+		if err := writeSBOM(w, dir, binaryPath, outputPath, cfg); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, builtArtifact{path: outputPath, target: target, platform: p})
+	}
+	return outputs, nil
 }
 
-func runShutdownHooks() {
-	for _, fn := range shutdownHooks {
-		fn()
+// runBuildCommand runs the project's buildCommand, if any, which bundles
+// the pages into the entry's directory.
+func runBuildCommand(w io.Writer, dir string, cfg lsruntime.Config) error {
+	if cfg.BuildCommand == "" {
+		return nil
 	}
+	if _, err := os.Stat(filepath.Join(dir, "node_modules")); os.IsNotExist(err) {
+		return fmt.Errorf("node_modules not found. Run 'npm install' first")
+	}
+	fmt.Fprintf(w, "Running: %s\n", cfg.BuildCommand)
+	parts := strings.Fields(cfg.BuildCommand)
+	buildCmd := exec.Command(parts[0], parts[1:]...)
+	buildCmd.Dir = dir
+	buildCmd.Stdout = w
+	buildCmd.Stderr = os.Stderr
+	if err := buildCmd.Run(); err != nil {
+		return fmt.Errorf("build command failed: %w", err)
+	}
+	return nil
 }
 
-// Security: declared permissions and allowed paths
-var permissions = map[string]bool{
-{{- range .Permissions}}
-	"{{.}}": true,
-{{- end}}
+// defaultPermissions are granted to apps that declare no permissions.
+var defaultPermissions = []string{"fs", "http", "dialog", "clipboard", "shell", "notification", "tray", "menu", "image", "pdf", "share", "codes"}
+
+// gatedAPIs maps a permission to the API namespaces that are only compiled
+// into the built app when that permission is declared.
+var gatedAPIs = map[string][]string{
+	"fs":    {"fs"},
+	"http":  {"http"},
+	"image": {"image"},
+	"pdf":   {"pdf"},
+	"share": {"share"},
+	"codes": {"codes"},
 }
 
-func checkPerm(perm string) error {
-	if !permissions[perm] {
-		return fmt.Errorf("permission denied: %%q not declared in lightshell.json permissions", perm)
+// buildPermissions returns the permissions compiled into the built app.
+func buildPermissions(cfg lsruntime.Config) []string {
+	if len(cfg.Permissions.Names) == 0 {
+		return defaultPermissions
 	}
-	return nil
+	return cfg.Permissions.Names
 }
 
-var allowedPaths []string
-
-func initSecurity() {
-	// Allow temp dir
-	allowedPaths = append(allowedPaths, os.TempDir())
-	// Allow app data dir
-	if home, err := os.UserHomeDir(); err == nil {
-		allowedPaths = append(allowedPaths, home+"/Library/Application Support/{{.Name}}")
-		allowedPaths = append(allowedPaths, home+"/.local/share/{{.Name}}")
+// omittedAPIs returns the gated namespaces left out of a build with perms, sorted.
+func omittedAPIs(perms []string) []string {
+	declared := make(map[string]bool, len(perms))
+	for _, p := range perms {
+		declared[p] = true
 	}
+	var omitted []string
+	for perm, namespaces := range gatedAPIs {
+		if !declared[perm] {
+			omitted = append(omitted, namespaces...)
+		}
+	}
+	sort.Strings(omitted)
+	return omitted
 }
 
-func checkPath(path string) error {
-	abs, err := filepath.Abs(path)
+// measureGatingSavings builds the app again with every gated permission
+// declared and returns how many bytes API gating saved. It is best effort:
+// any failure returns ok=false and the build continues.
+func measureGatingSavings(w io.Writer, staging, binaryPath string, cfg lsruntime.Config, plan buildPlan) (saved int64, ok bool) {
+	gated, err := os.Stat(binaryPath)
 	if err != nil {
-		return fmt.Errorf("invalid path: %%w", err)
+		return 0, false
 	}
-	// Resolve symlinks to prevent traversal attacks
-	resolved, err := filepath.EvalSymlinks(filepath.Dir(abs))
-	if err != nil {
-		resolved = filepath.Dir(abs)
+
+	all := make([]string, 0, len(gatedAPIs))
+	for perm := range gatedAPIs {
+		all = append(all, perm)
 	}
-	abs = filepath.Join(resolved, filepath.Base(abs))
-	for _, dir := range allowedPaths {
-		if strings.HasPrefix(abs, dir+string(os.PathSeparator)) || abs == dir {
-			return nil
-		}
+	sort.Strings(all)
+	mainPath := filepath.Join(staging, "main.go")
+	if err := generateBuildMain(mainPath, cfg, all); err != nil {
+		return 0, false
 	}
-	return fmt.Errorf("access denied: path %%q is outside allowed directories", path)
+	fullPath := binaryPath + "-full"
+	defer os.Remove(fullPath)
+
+	if err := goBuild(w, staging, fullPath, plan, false); err != nil {
+		return 0, false
+	}
+	full, err := os.Stat(fullPath)
+	if err != nil {
+		return 0, false
+	}
+	return full.Size() - gated.Size(), true
 }
 
-//export goMessageHandler
-func goMessageHandler(msg *C.char) {
-	if msgHandler != nil {
-		msgHandler(C.GoString(msg))
+// buildLDFlags strips the binary and, on Windows, marks it as a GUI app so
+// it opens without a console window.
+func buildLDFlags(goos string) string {
+	if goos == "windows" {
+		return "-ldflags=-s -w -H windowsgui"
 	}
+	return "-ldflags=-s -w"
 }
 
-func evalJS(js string) {
-	cJS := C.CString(js)
-	C.WebviewEval(cJS)
-	C.free(unsafe.Pointer(cJS))
+// stageModule writes the lightshell module's runtime packages into the
+// staging dir, whose main package then imports them like any package of
+// the module. The CLI, the MCP server, and tests are left out.
+func stageModule(staging string) error {
+	return fs.WalkDir(lightshell.Source, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name == "internal/cli" || name == "internal/mcp" || d.Name() == "testdata" {
+				return fs.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(staging, filepath.FromSlash(name)), 0o755)
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+		data, err := fs.ReadFile(lightshell.Source, name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(staging, filepath.FromSlash(name)), data, 0o644)
+	})
 }
 
-func addUserScript(js string) {
-	cJS := C.CString(js)
-	C.WebviewAddUserScript(cJS)
-	C.free(unsafe.Pointer(cJS))
+// appConfig is the config a built app embeds: cfg without what only the
+// CLI reads, with the permissions it is built with, and with its theme
+// icons at their staged paths. Of security, the app keeps its CSP,
+// navigation allowlist, and TLS settings, with the contents of the CA
+// files in caPEM standing in for their paths.
+func appConfig(cfg lsruntime.Config, icons lsruntime.ThemeIconsConfig, caPEM []string) lsruntime.Config {
+	cfg.Permissions.Names = buildPermissions(cfg)
+	cfg.ThemeIcons = icons
+	cfg.Build = lsruntime.BuildConfig{}
+	cfg.Hooks = lsruntime.HooksConfig{}
+	cfg.Dev = lsruntime.DevConfig{}
+	cfg.DevCommand, cfg.BuildCommand = "", ""
+	cfg.Security = lsruntime.SecurityConfig{
+		CSP:             cfg.Security.CSP,
+		AllowNavigation: cfg.Security.AllowNavigation,
+		TLS: lsruntime.TLSConfig{
+			Pins:  cfg.Security.TLS.Pins,
+			CAPEM: append(caPEM, cfg.Security.TLS.CAPEM...),
+		},
+	}
+	cfg.Startup = lsruntime.StartupConfig{}
+	cfg.LightShellVersion = ""
+	return cfg
 }
 
-func injectCSS(css string) {
-	js := fmt.Sprintf("(function(){var s=document.createElement('style');s.id='lightshell-defaults';s.textContent=%s;document.head.insertBefore(s,document.head.firstChild)})()", fmt.Sprintf("%q", css))
-	evalJS(js)
+// stageThemeIcons copies the themeIcons and their @dark variants into the
+// staging dir's themeicons directory for the built app to embed, and
+// returns their paths there.
+func stageThemeIcons(staging, dir string, icons lsruntime.ThemeIconsConfig) (lsruntime.ThemeIconsConfig, error) {
+	stage := func(field, src string) (string, error) {
+		if src == "" {
+			return "", nil
+		}
+		src = projectPath(dir, src)
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return "", fmt.Errorf("themeIcons.%s in lightshell.json: %w", field, err)
+		}
+		staged := "themeicons/" + field + filepath.Ext(src)
+		if err := os.MkdirAll(filepath.Join(staging, "themeicons"), 0o755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(staging, filepath.FromSlash(staged)), data, 0o644); err != nil {
+			return "", err
+		}
+		if dark, err := os.ReadFile(themeicon.DarkName(src)); err == nil {
+			if err := os.WriteFile(filepath.Join(staging, filepath.FromSlash(themeicon.DarkName(staged))), dark, 0o644); err != nil {
+				return "", err
+			}
+		}
+		return staged, nil
+	}
+	var staged lsruntime.ThemeIconsConfig
+	var err error
+	if staged.Window, err = stage("window", icons.Window); err != nil {
+		return staged, err
+	}
+	staged.Tray, err = stage("tray", icons.Tray)
+	return staged, err
 }
 
-func registerHandler(method string, fn func(json.RawMessage)(any, error)) {
-	ipcHandlers[method] = fn
+// acceleratorScript validates the configured accelerators for this platform
+// and returns the script that hands them to the client library.
+func acceleratorScript(cfg lsruntime.Config) (string, error) {
+	return acceleratorScriptFor(cfg, runtime.GOOS)
 }
 
-func handleMessage(rawMsg string) string {
-	var req struct {
-		ID     string          {{.BTick}}json:"id"{{.BTick}}
-		Method string          {{.BTick}}json:"method"{{.BTick}}
-		Params json.RawMessage {{.BTick}}json:"params"{{.BTick}}
-	}
-	if err := json.Unmarshal([]byte(rawMsg), &req); err != nil {
-		resp, _ := json.Marshal(map[string]any{"id": "", "error": err.Error()})
-		return string(resp)
-	}
-	handler, ok := ipcHandlers[req.Method]
-	if !ok {
-		resp, _ := json.Marshal(map[string]any{"id": req.ID, "error": "unknown method: " + req.Method})
-		return string(resp)
-	}
-	result, err := handler(req.Params)
+// acceleratorScriptFor is acceleratorScript for an app built for goos.
+func acceleratorScriptFor(cfg lsruntime.Config, goos string) (string, error) {
+	bindings, err := accel.Compile(cfg.Accelerators, goos)
 	if err != nil {
-		resp, _ := json.Marshal(map[string]any{"id": req.ID, "error": err.Error()})
-		return string(resp)
+		return "", fmt.Errorf("invalid accelerators in lightshell.json: %w", err)
 	}
-	resp, _ := json.Marshal(map[string]any{"id": req.ID, "result": result})
-	return string(resp)
+	return accel.Script(bindings), nil
 }
 
-func registerAPIs() {
-	registerHandler("invoke", func(p json.RawMessage) (any, error) {
-		var req struct {
-			Handler string          {{.BTick}}json:"handler"{{.BTick}}
-			Payload json.RawMessage {{.BTick}}json:"payload"{{.BTick}}
-		}
-		if err := json.Unmarshal(p, &req); err != nil {
-			return nil, fmt.Errorf("invalid invoke params: %%v", err)
+// validateMigrations checks that each migration is keyed by a version and
+// names a module inside the app's assets, where the page can import it.
+func validateMigrations(migrations map[string]string) error {
+	for version, module := range migrations {
+		if _, err := semver.Parse(version); err != nil {
+			return fmt.Errorf("migrations: %w", err)
 		}
-		handler, ok := invokeHandlers[req.Handler]
-		if !ok {
-			return nil, fmt.Errorf("unknown handler: %%s", req.Handler)
+		clean := filepath.ToSlash(filepath.Clean(module))
+		if module == "" || filepath.IsAbs(module) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("migrations: %q for %s must be a path relative to the entry's directory", module, version)
 		}
-		return handler(req.Payload)
-	})
+	}
+	return nil
+}
 
-	registerHandler("system.platform", func(p json.RawMessage) (any, error) {
-		return runtime.GOOS, nil
-	})
-	registerHandler("system.arch", func(p json.RawMessage) (any, error) {
-		return runtime.GOARCH, nil
-	})
-	registerHandler("system.homeDir", func(p json.RawMessage) (any, error) {
-		return os.UserHomeDir()
-	})
-	registerHandler("system.tempDir", func(p json.RawMessage) (any, error) {
-		return os.TempDir(), nil
-	})
-	registerHandler("system.hostname", func(p json.RawMessage) (any, error) {
-		return os.Hostname()
-	})
-	registerHandler("app.version", func(p json.RawMessage) (any, error) {
-		return "{{.Version}}", nil
-	})
-	registerHandler("app.quit", func(p json.RawMessage) (any, error) {
-		C.WebviewClose()
-		return nil, nil
-	})
-	registerHandler("app.dataDir", func(p json.RawMessage) (any, error) {
-		home, err := os.UserHomeDir()
-		if err != nil { return nil, err }
-		dir := home + "/Library/Application Support/{{.Name}}"
-		os.MkdirAll(dir, 0755)
-		return dir, nil
-	})
+// buildMainTemplate is the built app's main package: the pages, config,
+// and theme icons it embeds, handed to internal/app, which is the runtime
+// lightshell run uses. The gated APIs are registered, and so linked in,
+// only when their permissions are declared.
+var buildMainTemplate = template.Must(template.New("main").Parse(`// Code generated by lightshell build. DO NOT EDIT.
 
-	registerHandler("fs.readFile", func(p json.RawMessage) (any, error) {
-		if err := checkPerm("fs"); err != nil { return nil, err }
-		var params struct {
-			Path     string {{.BTick}}json:"path"{{.BTick}}
-			Encoding string {{.BTick}}json:"encoding"{{.BTick}}
-		}
-		json.Unmarshal(p, &params)
-		if err := checkPath(params.Path); err != nil { return nil, err }
-		data, err := os.ReadFile(params.Path)
-		if err != nil { return nil, err }
-		switch params.Encoding {
-		case "base64", "binary":
-			return base64.StdEncoding.EncodeToString(data), nil
-		default:
-			return string(data), nil
-		}
-	})
-	registerHandler("fs.writeFile", func(p json.RawMessage) (any, error) {
-		if err := checkPerm("fs"); err != nil { return nil, err }
-		var params struct {
-			Path string {{.BTick}}json:"path"{{.BTick}}
-			Data string {{.BTick}}json:"data"{{.BTick}}
-		}
-		json.Unmarshal(p, &params)
-		if err := checkPath(params.Path); err != nil { return nil, err }
-		os.MkdirAll(filepath.Dir(params.Path), 0755)
-		return nil, os.WriteFile(params.Path, []byte(params.Data), 0644)
-	})
-	registerHandler("fs.exists", func(p json.RawMessage) (any, error) {
-		if err := checkPerm("fs"); err != nil { return nil, err }
-		var params struct { Path string {{.BTick}}json:"path"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		if err := checkPath(params.Path); err != nil { return nil, err }
-		_, err := os.Stat(params.Path)
-		return err == nil, nil
-	})
-	registerHandler("fs.readDir", func(p json.RawMessage) (any, error) {
-		if err := checkPerm("fs"); err != nil { return nil, err }
-		var params struct { Path string {{.BTick}}json:"path"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		if err := checkPath(params.Path); err != nil { return nil, err }
-		entries, err := os.ReadDir(params.Path)
-		if err != nil { return nil, err }
-		result := []map[string]any{}
-		for _, e := range entries {
-			info, _ := e.Info()
-			size := int64(0)
-			if info != nil { size = info.Size() }
-			result = append(result, map[string]any{"name": e.Name(), "isDir": e.IsDir(), "size": size})
-		}
-		return result, nil
-	})
-	registerHandler("fs.stat", func(p json.RawMessage) (any, error) {
-		if err := checkPerm("fs"); err != nil { return nil, err }
-		var params struct { Path string {{.BTick}}json:"path"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		if err := checkPath(params.Path); err != nil { return nil, err }
-		info, err := os.Stat(params.Path)
-		if err != nil { return nil, err }
-		return map[string]any{
-			"name": info.Name(), "size": info.Size(), "isDir": info.IsDir(),
-			"modTime": info.ModTime().Format("2006-01-02T15:04:05Z07:00"),
-			"mode": info.Mode().String(),
-		}, nil
-	})
-	registerHandler("fs.mkdir", func(p json.RawMessage) (any, error) {
-		if err := checkPerm("fs"); err != nil { return nil, err }
-		var params struct { Path string {{.BTick}}json:"path"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		if err := checkPath(params.Path); err != nil { return nil, err }
-		return nil, os.MkdirAll(params.Path, 0755)
-	})
-	registerHandler("fs.remove", func(p json.RawMessage) (any, error) {
-		if err := checkPerm("fs"); err != nil { return nil, err }
-		var params struct { Path string {{.BTick}}json:"path"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		if err := checkPath(params.Path); err != nil { return nil, err }
-		return nil, os.RemoveAll(params.Path)
-	})
+package main
 
-	registerHandler("window.setTitle", func(p json.RawMessage) (any, error) {
-		var params struct { Title string {{.BTick}}json:"title"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		cTitle := C.CString(params.Title)
-		defer C.free(unsafe.Pointer(cTitle))
-		C.WebviewSetTitle(cTitle)
-		return nil, nil
-	})
-	registerHandler("window.setSize", func(p json.RawMessage) (any, error) {
-		var params struct { Width int {{.BTick}}json:"width"{{.BTick}}; Height int {{.BTick}}json:"height"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		C.WebviewSetSize(C.int(params.Width), C.int(params.Height))
-		return nil, nil
-	})
-	registerHandler("window.getSize", func(p json.RawMessage) (any, error) {
-		return map[string]int{"width": int(C.WebviewGetWidth()), "height": int(C.WebviewGetHeight())}, nil
-	})
-	registerHandler("window.setPosition", func(p json.RawMessage) (any, error) {
-		var params struct { X int {{.BTick}}json:"x"{{.BTick}}; Y int {{.BTick}}json:"y"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		C.WebviewSetPosition(C.int(params.X), C.int(params.Y))
-		return nil, nil
-	})
-	registerHandler("window.getPosition", func(p json.RawMessage) (any, error) {
-		return map[string]int{"x": int(C.WebviewGetX()), "y": int(C.WebviewGetY())}, nil
-	})
-	registerHandler("window.minimize", func(p json.RawMessage) (any, error) {
-		C.WebviewMinimize()
-		return nil, nil
-	})
-	registerHandler("window.maximize", func(p json.RawMessage) (any, error) {
-		C.WebviewMaximize()
-		return nil, nil
-	})
-	registerHandler("window.fullscreen", func(p json.RawMessage) (any, error) {
-		C.WebviewFullscreen()
-		return nil, nil
-	})
-	registerHandler("window.restore", func(p json.RawMessage) (any, error) {
-		C.WebviewRestore()
-		return nil, nil
-	})
-	registerHandler("window.close", func(p json.RawMessage) (any, error) {
-		C.WebviewClose()
-		return nil, nil
-	})
+import (
+{{- if or .ThemeIcons (not .CompressAssets)}}
+	"embed"
+{{- else}}
+	_ "embed"
+{{- end}}
+	"encoding/json"
+	"fmt"
+{{- if not .CompressAssets}}
+	"io/fs"
+{{- end}}
+	"os"
 
-	// Extended window APIs
-	registerHandler("window.setContentProtection", func(p json.RawMessage) (any, error) {
-		var params struct { Enabled bool {{.BTick}}json:"enabled"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		e := 0
-		if params.Enabled { e = 1 }
-		C.WebviewSetContentProtection(C.int(e))
-		return nil, nil
-	})
-	registerHandler("window.setVibrancy", func(p json.RawMessage) (any, error) {
-		var params struct { Style string {{.BTick}}json:"style"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		valid := map[string]bool{"sidebar": true, "header": true, "content": true, "sheet": true}
-		if !valid[params.Style] {
-			return nil, fmt.Errorf("invalid vibrancy style %q", params.Style)
-		}
-		cStyle := C.CString(params.Style)
-		defer C.free(unsafe.Pointer(cStyle))
-		C.WebviewSetVibrancy(cStyle)
-		return nil, nil
-	})
-	registerHandler("window.setColorScheme", func(p json.RawMessage) (any, error) {
-		var params struct { Scheme string {{.BTick}}json:"scheme"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		valid := map[string]bool{"light": true, "dark": true, "system": true}
-		if !valid[params.Scheme] {
-			return nil, fmt.Errorf("invalid color scheme %q", params.Scheme)
-		}
-		cScheme := C.CString(params.Scheme)
-		defer C.free(unsafe.Pointer(cScheme))
-		C.WebviewSetColorScheme(cScheme)
-		return nil, nil
-	})
-	registerHandler("window.enableFileDrop", func(p json.RawMessage) (any, error) {
-		C.WebviewEnableFileDrop()
-		return nil, nil
-	})
+{{if .Gated}}	"github.com/lightshell-dev/lightshell/internal/api"
+{{end}}	"github.com/lightshell-dev/lightshell/internal/app"
+{{- if .CompressAssets}}
+	"github.com/lightshell-dev/lightshell/internal/assetpack"
+{{- end}}
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
+)
 
-	// Extended app APIs
-	registerHandler("app.setBadgeCount", func(p json.RawMessage) (any, error) {
-		var params struct { Count int {{.BTick}}json:"count"{{.BTick}} }
-		json.Unmarshal(p, &params)
-		C.AppSetBadgeCount(C.int(params.Count))
-		return nil, nil
-	})
-	registerHandler("app.enableSingleInstance", func(p json.RawMessage) (any, error) {
-		sockPath := filepath.Join(os.TempDir(), fmt.Sprintf("lightshell-{{.Name}}.sock"))
-		conn, err := net.Dial("unix", sockPath)
-		if err == nil {
-			args := strings.Join(os.Args[1:], "\n")
-			conn.Write([]byte(args))
-			conn.Close()
-			return map[string]bool{"isSecondInstance": true}, nil
-		}
-		os.Remove(sockPath)
-		listener, err := net.Listen("unix", sockPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create single instance lock: %w", err)
-		}
-		go func() {
-			defer listener.Close()
-			for {
-				c, err := listener.Accept()
-				if err != nil { return }
-				buf := make([]byte, 4096)
-				n, _ := c.Read(buf)
-				c.Close()
-				if n > 0 {
-					args := strings.Split(string(buf[:n]), "\n")
-					evt, _ := json.Marshal(map[string]any{"event": "app.secondInstance", "data": map[string]any{"args": args}})
-					evalJS(fmt.Sprintf("__lightshell_receive(%s)", string(evt)))
-				}
-			}
-		}()
-		return map[string]bool{"isSecondInstance": false}, nil
-	})
+//go:embed config.json
+var configJSON []byte
+{{if .CompressAssets}}
+//go:embed assets.pack
+var assetPack []byte
+{{- else}}
+//go:embed src
+var srcFS embed.FS
+{{- end}}
+{{- if .ThemeIcons}}
+
+//go:embed themeicons
+var themeIcons embed.FS
+{{- end}}
+
+var router *ipc.Router
+
+// Handle registers a custom handler invokable from JS via lightshell.invoke(name, payload).
+func Handle(name string, handler func(json.RawMessage) (any, error)) {
+	router.HandleCustom(name, handler)
 }
 
-func init() {
-	runtime.LockOSThread()
+// OnShutdown registers a function to be called when the app is shutting down.
+func OnShutdown(fn func()) {
+	router.OnShutdown(fn)
 }
 
 func main() {
-	subFS, err := fs.Sub(srcFS, "src")
+	cfg, err := runtime.DecodeConfig(configJSON)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fail(err)
 	}
-
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	port := listener.Addr().(*net.TCPAddr).Port
-
-	const productionCSP = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data: blob:; font-src 'self' data:; connect-src 'self'; object-src 'none'; frame-ancestors 'none'"
-	cspMeta := fmt.Sprintf("<meta http-equiv=\"Content-Security-Policy\" content=\"%s\">", productionCSP)
-	fileServer := http.FileServer(http.FS(subFS))
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		p := r.URL.Path
-		if p == "/" || strings.HasSuffix(p, ".html") || strings.HasSuffix(p, ".htm") {
-			// Read and inject CSP into HTML files
-			fname := strings.TrimPrefix(p, "/")
-			if fname == "" { fname = "index.html" }
-			f, err := subFS.Open(fname)
-			if err == nil {
-				defer f.Close()
-				data, err := io.ReadAll(f)
-				if err == nil {
-					html := string(data)
-					idx := strings.Index(strings.ToLower(html), "<head>")
-					if idx >= 0 {
-						html = html[:idx+6] + cspMeta + html[idx+6:]
-					} else {
-						html = cspMeta + html
-					}
-					w.Header().Set("Content-Type", "text/html; charset=utf-8")
-					w.Write([]byte(html))
-					return
-				}
-			}
-		}
-		fileServer.ServeHTTP(w, r)
-	})
-	go http.Serve(listener, mux)
-
-	initSecurity()
-	registerAPIs()
-
-	cTitle := C.CString("{{.Title}}")
-	defer C.free(unsafe.Pointer(cTitle))
-	C.WebviewCreate(cTitle, {{.Width}}, {{.Height}}, {{.MinWidth}}, {{.MinHeight}}, {{.ResizableInt}}, 0, 0, 0, 0)
-
-	msgHandler = func(msg string) {
-		response := handleMessage(msg)
-		js := fmt.Sprintf("__lightshell_receive(%s)", response)
-		evalJS(js)
-	}
-
-	// Use addUserScript so scripts persist across page navigations (including initial LoadURL)
-	addUserScript(polyfillsJS)
-	addUserScript(clientJS)
-	// Inject defaults CSS as a user script
-	cssJS := fmt.Sprintf("(function(){var s=document.createElement('style');s.id='lightshell-defaults';s.textContent=%s;document.head.insertBefore(s,document.head.firstChild)})()", fmt.Sprintf("%q", defaultsCSS))
-	addUserScript(cssJS)
-
-	url := fmt.Sprintf("http://127.0.0.1:%d/{{.EntryFile}}", port)
-	cURL := C.CString(url)
-	defer C.free(unsafe.Pointer(cURL))
-	C.WebviewLoadURL(cURL)
-
-	// Register custom handlers (user code in handlers.go)
-	customHandlers()
-
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigCh
-		runShutdownHooks()
-		C.WebviewDestroy()
-		os.Exit(0)
-	}()
-
-	C.WebviewRun()
-	runShutdownHooks()
-}
-`
-	t, err := template.New("main").Parse(tmpl)
+{{- if .CompressAssets}}
+	pages, err := assetpack.Open(assetPack)
+{{- else}}
+	pages, err := fs.Sub(srcFS, "src")
+{{- end}}
 	if err != nil {
-		return err
+		fail(err)
 	}
-
-	resizable := 1
-	if cfg.Window.Resizable != nil && !*cfg.Window.Resizable {
-		resizable = 0
+{{- if .Perms.http}}
+	httpOpts, err := app.HTTPOptions(cfg, "")
+	if err != nil {
+		fail(err)
 	}
+{{- end}}
+	app.Main(app.Options{
+		Config: cfg,
+		Pages:  pages,
+{{- if .ThemeIcons}}
+		Icons:  themeIcons,
+{{- end}}
+		Register: func(a *app.App) {
+			router = a.Router
+{{- if .Perms.fs}}
+			api.RegisterFS(a.Router, a.Policy)
+			api.RegisterTempDirs(a.Router, a.Policy, cfg.Name)
+{{- end}}
+{{- if .Perms.http}}
+			api.RegisterHTTP(a.Router, a.Policy, httpOpts)
+{{- end}}
+{{- if .Perms.image}}
+			api.RegisterImage(a.Router, a.Policy)
+{{- end}}
+{{- if .Perms.pdf}}
+			api.RegisterPDF(a.Router, a.Policy)
+{{- end}}
+{{- if .Perms.share}}
+			api.RegisterShare(a.Router, a.Policy)
+{{- end}}
+{{- if .Perms.codes}}
+			api.RegisterCodes(a.Router, a.Policy)
+{{- end}}
+			customHandlers()
+		},
+	})
+}
 
-	// Default to all permissions if none declared
-	perms := cfg.Permissions
-	if len(perms) == 0 {
-		perms = []string{"fs", "dialog", "clipboard", "shell", "notification", "tray", "menu"}
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+`))
+
+// generateBuildMain writes the built app's main.go, which registers the
+// gated APIs perms covers.
+func generateBuildMain(path string, cfg lsruntime.Config, perms []string) error {
+	permSet := make(map[string]bool, len(perms))
+	for _, p := range perms {
+		permSet[p] = true
 	}
-
-	data := map[string]any{
-		"Title":        cfg.Window.Title,
-		"Width":        cfg.Window.Width,
-		"Height":       cfg.Window.Height,
-		"MinWidth":     cfg.Window.MinWidth,
-		"MinHeight":    cfg.Window.MinHeight,
-		"ResizableInt": resizable,
-		"Version":      cfg.Version,
-		"Name":         cfg.Name,
-		"EntryFile":    filepath.Base(cfg.Entry),
-		"BTick":        "`",
-		"Permissions":  perms,
+	// api is only imported when a gated API uses it
+	gated := false
+	for perm := range gatedAPIs {
+		gated = gated || permSet[perm]
 	}
-
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-
-	return t.Execute(f, data)
+	return buildMainTemplate.Execute(f, map[string]any{
+		"CompressAssets": cfg.Build.CompressAssets,
+		"ThemeIcons":     cfg.ThemeIcons.Window != "" || cfg.ThemeIcons.Tray != "",
+		"Perms":          permSet,
+		"Gated":          gated,
+	})
 }
 
-func packageDarwin(binaryPath, distDir string, cfg lsruntime.Config) (string, error) {
+func packageDarwin(binaryPath, distDir string, icon image.Image, cfg lsruntime.Config) (string, error) {
 	title := cfg.Window.Title
 	if title == "" {
 		title = cfg.Name
@@ -731,9 +939,28 @@ func packageDarwin(binaryPath, distDir string, cfg lsruntime.Config) (string, er
 		return "", err
 	}
 
+	// App icon, named by CFBundleIconFile in Info.plist
+	if icon != nil {
+		icns, err := iconset.ICNS(icon)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(resDir, macIconName+".icns"), icns, 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	// Scripting dictionary, named by OSAScriptingDefinition in Info.plist
+	if cfg.Scripting.Enabled {
+		sdef := scripting.Dictionary(title, cfg.Scripting.Actions)
+		if err := os.WriteFile(filepath.Join(resDir, scripting.DictionaryName(cfg.Name)), sdef, 0o644); err != nil {
+			return "", err
+		}
+	}
+
 	// Generate Info.plist
 	plistPath := filepath.Join(appPath, "Contents", "Info.plist")
-	plist := generatePlist(cfg)
+	plist := generatePlist(cfg, icon != nil)
 	if err := os.WriteFile(plistPath, []byte(plist), 0o644); err != nil {
 		return "", err
 	}
@@ -741,7 +968,7 @@ func packageDarwin(binaryPath, distDir string, cfg lsruntime.Config) (string, er
 	return appPath, nil
 }
 
-func generatePlist(cfg lsruntime.Config) string {
+func generatePlist(cfg lsruntime.Config, hasIcon bool) string {
 	appID := cfg.Build.AppID
 	if appID == "" {
 		appID = "com.lightshell." + cfg.Name
@@ -751,6 +978,20 @@ func generatePlist(cfg lsruntime.Config) string {
 		title = cfg.Name
 	}
 
+	var extraKeys string
+	if hasIcon {
+		extraKeys = fmt.Sprintf(`
+	<key>CFBundleIconFile</key>
+	<string>%s</string>`, macIconName)
+	}
+	if cfg.Scripting.Enabled {
+		extraKeys += fmt.Sprintf(`
+	<key>NSAppleScriptEnabled</key>
+	<true/>
+	<key>OSAScriptingDefinition</key>
+	<string>%s</string>`, scripting.DictionaryName(cfg.Name))
+	}
+
 	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
 <plist version="1.0">
@@ -771,15 +1012,147 @@ func generatePlist(cfg lsruntime.Config) string {
 	<string>11.0</string>
 	<key>NSHighResolutionCapable</key>
 	<true/>
+	<key>LightShellVersion</key>
+	<string>%s</string>%s
 </dict>
-</plist>`, cfg.Name, appID, title, cfg.Version, cfg.Version)
+</plist>`, cfg.Name, appID, title, cfg.Version, cfg.Version, lsruntime.Version, extraKeys)
 }
 
-func copyDir(src, dst string) error {
+// packageWindows puts the app and WebView2Loader.dll in a folder, which runs
+// as is, and builds a per-user NSIS installer from it when makensis is on
+// PATH. The output is the installer, or the folder without NSIS. The app
+// icon is in the executable already; the installer gets it too.
+func packageWindows(w io.Writer, binaryPath, webview2Loader, distDir string, icon image.Image, cfg lsruntime.Config) (string, error) {
+	title := cfg.Window.Title
+	if title == "" {
+		title = cfg.Name
+	}
+	appDir := filepath.Join(distDir, strings.ReplaceAll(title, " ", ""))
+	os.RemoveAll(appDir)
+	if err := os.MkdirAll(appDir, 0o755); err != nil {
+		return "", err
+	}
+
+	exeName := filepath.Base(binaryPath)
+	for src, name := range map[string]string{binaryPath: exeName, webview2Loader: "WebView2Loader.dll"} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(appDir, name), data, 0o755); err != nil {
+			return "", err
+		}
+	}
+
+	makensis, err := exec.LookPath("makensis")
+	if err != nil {
+		fmt.Fprintln(w, "makensis not found; skipping the installer (install NSIS to build one)")
+		return appDir, nil
+	}
+	setupName := fmt.Sprintf("%s-%s-setup.exe", strings.ReplaceAll(title, " ", ""), cfg.Version)
+	scriptPath := filepath.Join(distDir, "installer.nsi")
+	var iconPath string
+	if icon != nil {
+		ico, err := iconset.ICO(icon)
+		if err != nil {
+			return "", err
+		}
+		iconPath = filepath.Join(distDir, "installer.ico")
+		if err := os.WriteFile(iconPath, ico, 0o644); err != nil {
+			return "", err
+		}
+		defer os.Remove(iconPath)
+	}
+	if err := os.WriteFile(scriptPath, []byte(generateNSIS(cfg, title, exeName, appDir, setupName, iconPath)), 0o644); err != nil {
+		return "", err
+	}
+	defer os.Remove(scriptPath)
+
+	cmd := exec.Command(makensis, "/V2", scriptPath)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("makensis failed: %w", err)
+	}
+	return filepath.Join(distDir, setupName), nil
+}
+
+// generateNSIS returns an installer script for the files in appDir. The
+// app installs per user under %LOCALAPPDATA%\Programs, so no elevation is
+// needed, with a Start menu shortcut and an Apps & features entry. The
+// uninstaller also removes the launch-at-login entry. iconPath, when set,
+// is the installer's and uninstaller's icon.
+func generateNSIS(cfg lsruntime.Config, title, exeName, appDir, setupName, iconPath string) string {
+	appID := cfg.Build.AppID
+	if appID == "" {
+		appID = "com.lightshell." + cfg.Name
+	}
+	uninstKey := `Software\Microsoft\Windows\CurrentVersion\Uninstall\` + appID
+	q := nsisString
+	var icon string
+	if iconPath != "" {
+		icon = fmt.Sprintf("Icon %s\nUninstallIcon %s\n", q(iconPath), q(iconPath))
+	}
+
+	return fmt.Sprintf(`Unicode true
+SetCompressor /SOLID lzma
+Name %[1]s
+OutFile %[2]s
+InstallDir "$LOCALAPPDATA\Programs\%[3]s"
+RequestExecutionLevel user
+%[9]s
+Page directory
+Page instfiles
+UninstPage uninstConfirm
+UninstPage instfiles
+
+Section "Install"
+  SetOutPath "$INSTDIR"
+  File /r %[4]s
+  WriteUninstaller "$INSTDIR\Uninstall.exe"
+  CreateShortcut "$SMPROGRAMS\%[3]s.lnk" "$INSTDIR\%[5]s"
+  WriteRegStr HKCU %[6]s "DisplayName" %[1]s
+  WriteRegStr HKCU %[6]s "DisplayVersion" %[7]s
+  WriteRegStr HKCU %[6]s "DisplayIcon" "$INSTDIR\%[5]s"
+  WriteRegStr HKCU %[6]s "InstallLocation" "$INSTDIR"
+  WriteRegStr HKCU %[6]s "UninstallString" '"$INSTDIR\Uninstall.exe"'
+  WriteRegDWORD HKCU %[6]s "NoModify" 1
+  WriteRegDWORD HKCU %[6]s "NoRepair" 1
+SectionEnd
+
+Section "Uninstall"
+  Delete "$SMPROGRAMS\%[3]s.lnk"
+  DeleteRegKey HKCU %[6]s
+  DeleteRegValue HKCU "Software\Microsoft\Windows\CurrentVersion\Run" %[8]s
+  RMDir /r "$INSTDIR"
+SectionEnd
+`, q(title), q(setupName), nsisEscape(title), q(filepath.Join(appDir, "*")), nsisEscape(exeName),
+		q(uninstKey), q(cfg.Version), q(strings.TrimSuffix(exeName, ".exe")), icon)
+}
+
+// nsisString quotes s for an NSIS script.
+func nsisString(s string) string {
+	return `"` + nsisEscape(s) + `"`
+}
+
+// nsisEscape escapes the characters NSIS interprets inside a quoted string.
+func nsisEscape(s string) string {
+	return strings.NewReplacer("$", "$$", `"`, `$\"`).Replace(s)
+}
+
+// copyDir copies the tree at src to dst, leaving out the files and
+// directories skip reports.
+func copyDir(src, dst string, skip func(path string, info os.FileInfo) bool) error {
 	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if path != src && skip(path, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		relPath, _ := filepath.Rel(src, path)
 		destPath := filepath.Join(dst, relPath)
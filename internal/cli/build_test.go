@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// generatedMain returns the built app's main.go for cfg.
+func generatedMain(t *testing.T, cfg lsruntime.Config) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := generateBuildMain(path, cfg, buildPermissions(cfg)); err != nil {
+		t.Fatalf("generateBuildMain failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBuildMainWithoutPermissions(t *testing.T) {
+	// An app that declares no permissions gets every gated API
+	main := generatedMain(t, lsruntime.Config{Name: "app"})
+	for _, call := range []string{"api.RegisterFS(", "api.RegisterHTTP(", "app.HTTPOptions(",
+		"api.RegisterImage(", "api.RegisterPDF(", "api.RegisterShare(", "api.RegisterCodes("} {
+		if !strings.Contains(main, call) {
+			t.Errorf("main.go without permissions lacks %s", call)
+		}
+	}
+	if omitted := omittedAPIs(buildPermissions(lsruntime.Config{})); len(omitted) != 0 {
+		t.Errorf("omitted = %v, want none", omitted)
+	}
+}
+
+func TestBuildMainGatesAPIs(t *testing.T) {
+	cfg := lsruntime.Config{Name: "app", Permissions: lsruntime.Permissions{Names: []string{"fs", "codes"}}}
+	main := generatedMain(t, cfg)
+	for _, call := range []string{"api.RegisterFS(", "api.RegisterCodes("} {
+		if !strings.Contains(main, call) {
+			t.Errorf("main.go lacks the declared %s", call)
+		}
+	}
+	for _, call := range []string{"api.RegisterHTTP(", "api.RegisterImage(", "api.RegisterPDF(", "api.RegisterShare("} {
+		if strings.Contains(main, call) {
+			t.Errorf("main.go calls %s without its permission", call)
+		}
+	}
+	want := []string{"http", "image", "pdf", "share"}
+	if omitted := omittedAPIs(cfg.Permissions.Names); !reflect.DeepEqual(omitted, want) {
+		t.Errorf("omitted = %v, want %v", omitted, want)
+	}
+}
+
+func TestBuildMainWithoutGatedAPIs(t *testing.T) {
+	// With no gated API to register, main.go must not import api unused
+	cfg := lsruntime.Config{Name: "app", Permissions: lsruntime.Permissions{Names: []string{"dialog"}}}
+	if main := generatedMain(t, cfg); strings.Contains(main, "/internal/api\"") {
+		t.Error("main.go imports internal/api without a gated API")
+	}
+}
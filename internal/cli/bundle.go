@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// bundleSources are the script types build.bundle compiles. The ones the
+// webview cannot load are left out of built apps.
+var bundleSources = map[string]bool{
+	".js": true, ".mjs": true, ".cjs": true, ".jsx": true,
+	".ts": true, ".mts": true, ".cts": true, ".tsx": true,
+}
+
+// compiledOnly reports whether path is a script only the bundler reads.
+func compiledOnly(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return bundleSources[ext] && ext != ".js" && ext != ".mjs" && ext != ".cjs"
+}
+
+// validateBundle checks build.bundle's entry points: each must be a script
+// inside srcDir, the directory the pages are served from.
+func validateBundle(dir, srcDir string, b *lsruntime.BundleConfig) error {
+	if len(b.EntryPoints) == 0 {
+		return fmt.Errorf("build.bundle needs at least one entry point in entryPoints")
+	}
+	for _, entry := range b.EntryPoints {
+		if !bundleSources[strings.ToLower(filepath.Ext(entry))] {
+			return fmt.Errorf("build.bundle entry point %s is not a JavaScript or TypeScript file", entry)
+		}
+		rel, err := filepath.Rel(srcDir, filepath.Join(dir, entry))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			served, _ := filepath.Rel(dir, srcDir)
+			return fmt.Errorf("build.bundle entry point %s is outside %s, where the pages are served from", entry, served)
+		}
+		if _, err := os.Stat(filepath.Join(dir, entry)); err != nil {
+			return fmt.Errorf("build.bundle entry point %s not found", entry)
+		}
+	}
+	return nil
+}
+
+// esbuildPath finds the project's esbuild, or one on the PATH.
+func esbuildPath(dir string) (string, error) {
+	name := "esbuild"
+	if runtime.GOOS == "windows" {
+		name = "esbuild.cmd"
+	}
+	local := filepath.Join(dir, "node_modules", ".bin", name)
+	if _, err := os.Stat(local); err == nil {
+		return local, nil
+	}
+	if global, err := exec.LookPath("esbuild"); err == nil {
+		return global, nil
+	}
+	return "", fmt.Errorf("build.bundle needs esbuild, which was not found in node_modules or on the PATH\n\nInstall it with:\n  npm install --save-dev esbuild")
+}
+
+// runBundle bundles the entry points into outDir with the project's
+// esbuild. It runs the esbuild command rather than linking esbuild's Go
+// API, which keeps the CLI free of third-party modules and lets each
+// project pick its esbuild version.
+func runBundle(w io.Writer, dir, srcDir, outDir string, b *lsruntime.BundleConfig, dev bool) error {
+	esbuild, err := esbuildPath(dir)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(esbuild, bundleArgs(dir, srcDir, outDir, b, dev)...)
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("bundling failed: %w", err)
+	}
+	return nil
+}
+
+// bundleArgs returns esbuild's arguments. Each entry point lands in outDir
+// at its path relative to srcDir with a .js extension. Dev bundles carry
+// inline source maps and are never minified.
+func bundleArgs(dir, srcDir, outDir string, b *lsruntime.BundleConfig, dev bool) []string {
+	args := []string{"--bundle", "--format=esm", "--log-level=warning",
+		"--outbase=" + srcDir, "--outdir=" + outDir}
+	switch {
+	case dev:
+		args = append(args, "--sourcemap=inline")
+	case b.Sourcemap:
+		args = append(args, "--sourcemap")
+	}
+	if b.Minify && !dev {
+		args = append(args, "--minify")
+	}
+	keys := make([]string, 0, len(b.Define))
+	for k := range b.Define {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--define:"+k+"="+b.Define[k])
+	}
+	for _, entry := range b.EntryPoints {
+		args = append(args, filepath.Join(dir, entry))
+	}
+	return args
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+func TestValidateBundle(t *testing.T) {
+	dir := t.TempDir()
+	srcDir := filepath.Join(dir, "src")
+	for _, name := range []string{"src/main.ts", "src/pages/about.tsx", "scripts/tool.ts", "src/style.css"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := validateBundle(dir, srcDir, &lsruntime.BundleConfig{EntryPoints: []string{"src/main.ts", "src/pages/about.tsx"}}); err != nil {
+		t.Errorf("valid entry points: %v", err)
+	}
+	tests := []struct {
+		entries []string
+		want    string
+	}{
+		{nil, "at least one entry point"},
+		{[]string{"src/style.css"}, "not a JavaScript or TypeScript file"},
+		{[]string{"scripts/tool.ts"}, "outside src"},
+		{[]string{"src/../scripts/tool.ts"}, "outside src"},
+		{[]string{"src/missing.js"}, "not found"},
+	}
+	for _, tt := range tests {
+		err := validateBundle(dir, srcDir, &lsruntime.BundleConfig{EntryPoints: tt.entries})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("entry points %q: error = %v, want %q", tt.entries, err, tt.want)
+		}
+	}
+}
+
+func TestBundleArgs(t *testing.T) {
+	b := &lsruntime.BundleConfig{
+		EntryPoints: []string{"src/main.ts", "src/worker.js"},
+		Minify:      true,
+		Sourcemap:   true,
+		Define:      map[string]string{"DEBUG": "false", "API_URL": `"https://example.com"`},
+	}
+	common := []string{"--bundle", "--format=esm", "--log-level=warning", "--outbase=/p/src", "--outdir=/p/out"}
+	tail := []string{`--define:API_URL="https://example.com"`, "--define:DEBUG=false",
+		filepath.Join("/p", "src/main.ts"), filepath.Join("/p", "src/worker.js")}
+
+	build := append(append(append([]string{}, common...), "--sourcemap", "--minify"), tail...)
+	if got := bundleArgs("/p", "/p/src", "/p/out", b, false); !reflect.DeepEqual(got, build) {
+		t.Errorf("build args = %q, want %q", got, build)
+	}
+	// Dev bundles inline their source maps and are never minified
+	dev := append(append(append([]string{}, common...), "--sourcemap=inline"), tail...)
+	if got := bundleArgs("/p", "/p/src", "/p/out", b, true); !reflect.DeepEqual(got, dev) {
+		t.Errorf("dev args = %q, want %q", got, dev)
+	}
+
+	plain := append(append([]string{}, common...), filepath.Join("/p", "src/main.ts"))
+	if got := bundleArgs("/p", "/p/src", "/p/out", &lsruntime.BundleConfig{EntryPoints: []string{"src/main.ts"}}, false); !reflect.DeepEqual(got, plain) {
+		t.Errorf("args = %q, want %q", got, plain)
+	}
+}
+
+func TestCompiledOnly(t *testing.T) {
+	for path, want := range map[string]bool{"a.ts": true, "a.TSX": true, "a.jsx": true, "a.js": false, "a.mjs": false, "a.css": false} {
+		if got := compiledOnly(path); got != want {
+			t.Errorf("compiledOnly(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// validateSigning checks that build.mac has what --sign, and --notarize,
+// need before the build starts.
+func validateSigning(mac lsruntime.MacConfig, notarize bool) error {
+	if mac.Identity == "" {
+		return fmt.Errorf("--sign needs build.mac.identity in lightshell.json, such as \"Developer ID Application: Your Name (TEAMID)\"; list your identities with `security find-identity -v -p codesigning`")
+	}
+	if !notarize {
+		return nil
+	}
+	if mac.Identity == "-" {
+		return fmt.Errorf("--notarize needs a Developer ID identity; an ad-hoc signature (build.mac.identity \"-\") cannot be notarized")
+	}
+	_, err := notaryCredentials(mac)
+	return err
+}
+
+// macEntitlements returns the entitlements the app is signed with:
+// those its permissions call for, then build.mac.entitlements on top.
+// Outside the App Sandbox most of them have no effect, but they document
+// what the app does and are ready for build.mac.sandbox.
+func macEntitlements(mac lsruntime.MacConfig, perms []string) map[string]any {
+	ent := map[string]any{}
+	if mac.Sandbox {
+		ent["com.apple.security.app-sandbox"] = true
+		// Pages are served from a local HTTP server
+		ent["com.apple.security.network.server"] = true
+		ent["com.apple.security.network.client"] = true
+	}
+	for _, p := range perms {
+		switch p {
+		case "http", "updater":
+			ent["com.apple.security.network.client"] = true
+		case "fs", "dialog":
+			ent["com.apple.security.files.user-selected.read-write"] = true
+		case "process":
+			// Under the hardened runtime, commands the app runs need it
+			// to script other apps, as osascript does
+			ent["com.apple.security.automation.apple-events"] = true
+		}
+	}
+	for key, value := range mac.Entitlements {
+		ent[key] = value
+	}
+	return ent
+}
+
+// entitlementsPlist renders entitlements as a property list. Values are
+// booleans, strings, numbers, or arrays of those.
+func entitlementsPlist(ent map[string]any) (string, error) {
+	keys := make([]string, 0, len(ent))
+	for key := range ent {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\t<key>%s</key>\n", html.EscapeString(key))
+		if list, ok := ent[key].([]any); ok {
+			b.WriteString("\t<array>\n")
+			for _, item := range list {
+				value, err := plistValue(key, item)
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintf(&b, "\t\t%s\n", value)
+			}
+			b.WriteString("\t</array>\n")
+			continue
+		}
+		value, err := plistValue(key, ent[key])
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "\t%s\n", value)
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String(), nil
+}
+
+func plistValue(key string, v any) (string, error) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return "<true/>", nil
+		}
+		return "<false/>", nil
+	case string:
+		return "<string>" + html.EscapeString(v) + "</string>", nil
+	case float64:
+		if v == float64(int64(v)) {
+			return "<integer>" + strconv.FormatInt(int64(v), 10) + "</integer>", nil
+		}
+		return "<real>" + strconv.FormatFloat(v, 'g', -1, 64) + "</real>", nil
+	}
+	return "", fmt.Errorf("build.mac.entitlements.%s: unsupported value %v", key, v)
+}
+
+// writeEntitlements writes the app's entitlements to path.
+func writeEntitlements(path string, mac lsruntime.MacConfig, perms []string) error {
+	plist, err := entitlementsPlist(macEntitlements(mac, perms))
+	if err != nil {
+		return invalidConfig(err)
+	}
+	return os.WriteFile(path, []byte(plist), 0o644)
+}
+
+// codesignApp signs an app bundle with the hardened runtime, which
+// notarization requires, and verifies the signature.
+func codesignApp(w io.Writer, appPath, identity, entitlements string) error {
+	args := []string{"--force", "--deep", "--options", "runtime", "--entitlements", entitlements}
+	args = append(args, timestampFlag(identity), "--sign", identity, appPath)
+	if err := runTool("codesign", args...); err != nil {
+		return err
+	}
+	if err := runTool("codesign", "--verify", "--deep", "--strict", appPath); err != nil {
+		return fmt.Errorf("signature of %s does not verify: %w", appPath, err)
+	}
+	fmt.Fprintf(w, "Signed %s with %q\n", appPath, identity)
+	return nil
+}
+
+// codesignFile signs a disk image, which carries no entitlements.
+func codesignFile(w io.Writer, path, identity string) error {
+	if err := runTool("codesign", "--force", timestampFlag(identity), "--sign", identity, path); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Signed %s with %q\n", path, identity)
+	return nil
+}
+
+// timestampFlag asks for a secure timestamp, which notarization requires,
+// except for ad-hoc signatures, which Apple's timestamp server rejects.
+func timestampFlag(identity string) string {
+	if identity == "-" {
+		return "--timestamp=none"
+	}
+	return "--timestamp"
+}
+
+var identityTeam = regexp.MustCompile(`\(([A-Z0-9]{10})\)\s*$`)
+
+// teamFromIdentity returns the team ID at the end of a Developer ID
+// identity, or "".
+func teamFromIdentity(identity string) string {
+	if m := identityTeam.FindStringSubmatch(identity); m != nil {
+		return m[1]
+	}
+	return ""
+}
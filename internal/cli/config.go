@@ -5,27 +5,39 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lightshell-dev/lightshell/internal/api"
+	"github.com/lightshell-dev/lightshell/internal/app"
+	"github.com/lightshell-dev/lightshell/internal/fsutil"
+	"github.com/lightshell-dev/lightshell/internal/proxy"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
 )
 
 // Config handles the `lightshell config` command.
 func Config(args []string) error {
+	args, asJSON := jsonFlag(args)
 	if len(args) < 1 {
-		return fmt.Errorf("usage: lightshell config <get|set> <key> [value]\n\nKeys:\n  releaseServer    URL of the release server\n  releaseToken     Auth token for the release server")
+		return fmt.Errorf("usage: lightshell config <get|set> <key> [value] [--json]\n       lightshell config show [--resolved] [--json]\n\nKeys:\n  releaseServer    URL of the release server\n  releaseToken     Auth token for the release server\n  proxy            Proxy URL for network requests, or \"direct\" to ignore system proxies\n  noProxy          Comma-separated hosts to reach without the proxy\n  updateChannel    Release channel self-update follows: stable or beta")
 	}
 
 	switch args[0] {
 	case "get":
 		if len(args) < 2 {
-			return fmt.Errorf("usage: lightshell config get <key>")
+			return usageError(fmt.Errorf("usage: lightshell config get <key>"))
 		}
-		return configGet(args[1])
+		return configGet(args[1], asJSON)
 	case "set":
 		if len(args) < 3 {
-			return fmt.Errorf("usage: lightshell config set <key> <value>")
+			return usageError(fmt.Errorf("usage: lightshell config set <key> <value>"))
 		}
-		return configSet(args[1], args[2])
+		return configSet(args[1], args[2], asJSON)
+	case "show":
+		return configShow(args[1:], asJSON)
 	default:
-		return fmt.Errorf("unknown config subcommand: %s\n\nUsage: lightshell config <get|set> <key> [value]", args[0])
+		return usageError(fmt.Errorf("unknown config subcommand: %s\n\nUsage: lightshell config <get|set> <key> [value]\n       lightshell config show [--resolved] [--json]", args[0]))
 	}
 }
 
@@ -33,14 +45,20 @@ func Config(args []string) error {
 var validConfigKeys = map[string]bool{
 	"releaseServer": true,
 	"releaseToken":  true,
+	"proxy":         true,
+	"noProxy":       true,
+	"updateChannel": true,
 }
 
-func configGet(key string) error {
+func configGet(key string, asJSON bool) error {
 	if !validConfigKeys[key] {
-		return fmt.Errorf("unknown config key: %q\n\nValid keys: releaseServer, releaseToken", key)
+		return fmt.Errorf("unknown config key: %q\n\nValid keys: releaseServer, releaseToken, proxy, noProxy, updateChannel", key)
 	}
 
 	value := loadConfigValue(key)
+	if asJSON {
+		return printJSON(map[string]any{"key": key, "value": value, "set": value != ""})
+	}
 	if value == "" {
 		fmt.Printf("%s: (not set)\n", key)
 	} else {
@@ -49,10 +67,21 @@ func configGet(key string) error {
 	return nil
 }
 
-func configSet(key, value string) error {
+func configSet(key, value string, asJSON bool) error {
 	if !validConfigKeys[key] {
-		return fmt.Errorf("unknown config key: %q\n\nValid keys: releaseServer, releaseToken", key)
+		return fmt.Errorf("unknown config key: %q\n\nValid keys: releaseServer, releaseToken, proxy, noProxy, updateChannel", key)
+	}
+	if key == "updateChannel" && !selfUpdateChannels[value] {
+		return fmt.Errorf("unknown channel %q: must be stable or beta", value)
+	}
+
+	// Hold the lock across read-modify-write so concurrent `config set`
+	// invocations don't drop each other's keys.
+	unlock, err := lockGlobalConfig()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
 	cfg, err := loadGlobalConfig()
 	if err != nil {
@@ -65,10 +94,112 @@ func configSet(key, value string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
+	if asJSON {
+		return printJSON(map[string]any{"key": key, "path": globalConfigPath()})
+	}
 	fmt.Printf("Set %s\n", key)
 	return nil
 }
 
+// configShow prints the project's lightshell.json. With --resolved it
+// prints the config the app runs with, everything it extends merged in and
+// defaults applied, and which file each setting comes from.
+func configShow(args []string, asJSON bool) error {
+	var resolve bool
+	for _, arg := range args {
+		switch arg {
+		case "--resolved":
+			resolve = true
+		default:
+			return usageError(fmt.Errorf("unknown flag: %s\n\nUsage: lightshell config show [--resolved] [--json]", arg))
+		}
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if !resolve {
+		data, err := os.ReadFile(filepath.Join(dir, "lightshell.json"))
+		if err != nil {
+			return fmt.Errorf("could not read lightshell.json: %w", err)
+		}
+		if asJSON {
+			if !json.Valid(data) {
+				return fmt.Errorf("lightshell.json is not valid JSON")
+			}
+			return printJSON(map[string]any{"config": json.RawMessage(data), "path": filepath.Join(dir, "lightshell.json")})
+		}
+		os.Stdout.Write(data)
+		return nil
+	}
+
+	resolved, err := runtime.EffectiveConfig(dir)
+	if err != nil {
+		return err
+	}
+	files := make([]string, len(resolved.Files))
+	for i, f := range resolved.Files {
+		files[i] = displayPath(dir, f)
+	}
+	sources := make(map[string]string, len(resolved.Sources))
+	for k, f := range resolved.Sources {
+		if f != runtime.DefaultSource {
+			f = displayPath(dir, f)
+		}
+		sources[k] = f
+	}
+
+	if asJSON {
+		return printJSON(map[string]any{
+			"config":  resolved.Values,
+			"files":   files,
+			"sources": sources,
+		})
+	}
+
+	fmt.Println("Config files, later ones overriding earlier ones:")
+	for i, f := range files {
+		fmt.Printf("  %d. %s\n", i+1, f)
+	}
+	fmt.Printf("Settings no file sets show their default, marked %s.\n", runtime.DefaultSource)
+	fmt.Println()
+
+	settings := map[string]any{}
+	flattenConfig(resolved.Values, "", settings)
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, k := range keys {
+		value, _ := json.Marshal(settings[k])
+		fmt.Fprintf(w, "%s\t%s\t%s\n", k, value, sources[k])
+	}
+	return w.Flush()
+}
+
+// flattenConfig collects the settings in values by dotted key path, as
+// runtime.ResolvedConfig.Sources names them.
+func flattenConfig(values map[string]any, prefix string, out map[string]any) {
+	for k, v := range values {
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			flattenConfig(m, prefix+k+".", out)
+			continue
+		}
+		out[prefix+k] = v
+	}
+}
+
+// displayPath shows path relative to dir when it is inside it or nearby.
+func displayPath(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, filepath.Join("..", "..")) {
+		return rel
+	}
+	return path
+}
+
 func globalConfigPath() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -114,7 +245,38 @@ func saveGlobalConfig(cfg map[string]string) error {
 		return err
 	}
 
-	return os.WriteFile(path, append(data, '\n'), 0o600)
+	return fsutil.WriteFileAtomic(path, append(data, '\n'), 0o600)
+}
+
+// lockGlobalConfig takes an exclusive lock on ~/.lightshell, serializing
+// writers of config.json and the signing key files across processes.
+func lockGlobalConfig() (func(), error) {
+	path := globalConfigPath()
+	if path == "" {
+		return nil, fmt.Errorf("could not determine home directory")
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return fsutil.Lock(filepath.Join(dir, ".lock"))
+}
+
+// proxySettings resolves the proxy for CLI network requests from the proxy
+// and noProxy config keys, falling back to the environment and the OS.
+func proxySettings() proxy.Settings {
+	return proxy.Resolve(loadConfigValue("proxy"), proxy.SplitList(loadConfigValue("noProxy")))
+}
+
+// httpOptions is app.HTTPOptions for lightshell dev and run, where the
+// proxy set with lightshell config applies when lightshell.json sets none
+// and both noProxy lists are used. Built apps do not read it.
+func httpOptions(cfg runtime.Config, dir string) (api.HTTPOptions, error) {
+	if cfg.Proxy.URL == "" {
+		cfg.Proxy.URL = loadConfigValue("proxy")
+	}
+	cfg.Proxy.NoProxy = append(proxy.SplitList(loadConfigValue("noProxy")), cfg.Proxy.NoProxy...)
+	return app.HTTPOptions(cfg, dir)
 }
 
 // loadConfigValue reads a single value from the global config.
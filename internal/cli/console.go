@@ -0,0 +1,376 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// consoleHistoryLimit is how many lines ~/.lightshell/console_history keeps.
+const consoleHistoryLimit = 1000
+
+const consoleHelp = `Enter JavaScript to evaluate it in the page. Lines with open brackets,
+template literals, or comments continue on the next line.
+
+  .window [id]  Evaluate in another window, or list them without an id
+  .break        Discard the lines entered so far
+  .history      Print the input history
+  .help         Print this help
+  .exit         Leave the console (or press Ctrl+D)
+
+Top-level let and const only last for their entry; use var or globalThis
+to keep a value.`
+
+// Console runs lightshell console: a JavaScript prompt in the page of a
+// running dev app, attached over the control socket of a detached dev
+// process (lightshell dev --daemon), or the socket given with --socket.
+func Console(args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	var socket string
+	var depth, windowID int
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--socket", "--depth", "--window":
+			if i+1 >= len(args) {
+				return usageError(fmt.Errorf("%s needs a value", args[i]))
+			}
+			value := args[i+1]
+			i++
+			if args[i-1] == "--socket" {
+				socket = value
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return usageError(fmt.Errorf("invalid %s value %q", args[i-1], value))
+			}
+			if args[i-1] == "--depth" {
+				depth = n
+			} else {
+				windowID = n
+			}
+		default:
+			return usageError(fmt.Errorf("unknown console option %q", args[i]))
+		}
+	}
+
+	if socket == "" {
+		st, err := readDevState(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no detached dev process is running; start one with 'lightshell dev --daemon'")
+		}
+		if err != nil {
+			return fmt.Errorf("could not read dev state: %w", err)
+		}
+		if !processAlive(st.PID) {
+			removeDevState(dir, st)
+			return fmt.Errorf("the detached dev process (pid %d) has exited; its output is in %s", st.PID, st.Log)
+		}
+		socket = st.Socket
+	}
+
+	conn, err := dialSocket(socket)
+	if err != nil {
+		return fmt.Errorf("could not attach to the dev app: %w", err)
+	}
+	defer conn.Close()
+
+	var page struct {
+		URL string `json:"url"`
+	}
+	if resp, err := conn.send(mcpSocketCommand{Cmd: "status"}); err == nil {
+		json.Unmarshal(resp.Result, &page)
+	}
+	fmt.Printf("Attached to %s\n", page.URL)
+	fmt.Println("Type .help for commands, .exit or Ctrl+D to leave")
+
+	editor := newLineEditor(os.Stdin, os.Stdout)
+	historyPath := consoleHistoryPath()
+	editor.history = loadConsoleHistory(historyPath)
+
+	var entry []string
+	for {
+		prompt := "> "
+		if len(entry) > 0 {
+			prompt = "... "
+		}
+		line, err := editor.readLine(prompt)
+		if err == errInterrupt {
+			if len(entry) == 0 {
+				fmt.Println("(To exit, press Ctrl+D or type .exit)")
+			}
+			entry = nil
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) != "" && editor.addHistory(line) {
+			appendConsoleHistory(historyPath, line)
+		}
+
+		if len(entry) == 0 && strings.HasPrefix(strings.TrimSpace(line), ".") {
+			fields := strings.Fields(line)
+			switch fields[0] {
+			case ".exit":
+				return nil
+			case ".help":
+				fmt.Println(consoleHelp)
+			case ".break":
+			case ".history":
+				for i, h := range editor.history {
+					fmt.Printf("%5d  %s\n", i+1, h)
+				}
+			case ".window":
+				windowID, err = consoleWindow(conn, fields[1:], windowID)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+				}
+			default:
+				fmt.Fprintf(os.Stderr, "Unknown command %s; type .help for the list\n", fields[0])
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == ".break" {
+			entry = nil
+			continue
+		}
+
+		entry = append(entry, line)
+		code := strings.Join(entry, "\n")
+		if !inputComplete(code) {
+			continue
+		}
+		entry = nil
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+
+		resp, err := conn.send(mcpSocketCommand{Cmd: "eval", Code: code, Depth: depth, WindowID: windowID})
+		switch {
+		case lostConnection(err):
+			return fmt.Errorf("lost the connection to the dev app: %w", err)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Uncaught %v\n", strings.TrimPrefix(err.Error(), "JS error: "))
+		default:
+			fmt.Println(formatResult(resp.Result))
+			if resp.Truncated {
+				fmt.Println("(output truncated)")
+			}
+		}
+	}
+}
+
+// consoleWindow handles .window: with an id it targets that window, and
+// without one it lists the open windows.
+func consoleWindow(conn *socketClient, args []string, current int) (int, error) {
+	if len(args) > 0 {
+		id, err := strconv.Atoi(args[0])
+		if err != nil || id <= 0 {
+			return current, fmt.Errorf("invalid window id %q", args[0])
+		}
+		resp, err := conn.send(mcpSocketCommand{Cmd: "windows"})
+		if err != nil {
+			return current, err
+		}
+		for _, w := range resp.Windows {
+			if w.ID == id {
+				fmt.Printf("Evaluating in %q\n", w.Title)
+				if w.Main {
+					return 0, nil
+				}
+				return id, nil
+			}
+		}
+		return current, fmt.Errorf("no window with id %d is open", id)
+	}
+
+	resp, err := conn.send(mcpSocketCommand{Cmd: "windows"})
+	if err != nil {
+		return current, err
+	}
+	for _, w := range resp.Windows {
+		mark := " "
+		if (current == 0 && w.Main) || w.ID == current {
+			mark = "*"
+		}
+		fmt.Printf("%s %d  %s (%dx%d)\n", mark, w.ID, w.Title, w.Width, w.Height)
+	}
+	return current, nil
+}
+
+// formatResult renders an eval result for the terminal. The page sends
+// values as JSON text, which is indented; undefined and values cut short
+// by truncation are printed as they are.
+func formatResult(result json.RawMessage) string {
+	var value string
+	if err := json.Unmarshal(result, &value); err != nil {
+		return string(result)
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(value), "", "  "); err != nil {
+		return value
+	}
+	return buf.String()
+}
+
+// inputComplete reports whether src is a whole entry, or whether the
+// console should keep reading lines because a bracket, template literal,
+// or block comment is still open. A closing bracket that does not match
+// ends the entry, so that eval reports the syntax error.
+func inputComplete(src string) bool {
+	var open []byte // ( [ { and `, with $ for a template's ${
+	var prev byte   // the last character of code, to tell a regex from division
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if len(open) > 0 && open[len(open)-1] == '`' {
+			switch {
+			case c == '\\':
+				i++
+			case c == '`':
+				open = open[:len(open)-1]
+				prev = c
+			case c == '$' && i+1 < len(src) && src[i+1] == '{':
+				open = append(open, '$')
+				prev = '{'
+				i++
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"':
+			i = skipString(src, i)
+		case '`', '(', '[', '{':
+			open = append(open, c)
+		case ')', ']', '}':
+			if len(open) == 0 {
+				return true
+			}
+			top := open[len(open)-1]
+			if top != "([{"[strings.IndexByte(")]}", c)] && !(top == '$' && c == '}') {
+				return true
+			}
+			open = open[:len(open)-1]
+		case '/':
+			switch {
+			case strings.HasPrefix(src[i:], "//"):
+				end := strings.IndexByte(src[i:], '\n')
+				if end < 0 {
+					return len(open) == 0
+				}
+				i += end
+				continue
+			case strings.HasPrefix(src[i:], "/*"):
+				end := strings.Index(src[i+2:], "*/")
+				if end < 0 {
+					return false
+				}
+				i += end + 3
+				continue
+			case regexCanStart(prev):
+				i = skipRegex(src, i)
+			}
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			prev = c
+		}
+	}
+	return len(open) == 0
+}
+
+// skipString returns the index of the quote closing the string literal
+// opening at src[i], or of the end of its line when it is unterminated.
+func skipString(src string, i int) int {
+	quote := src[i]
+	for i++; i < len(src) && src[i] != quote && src[i] != '\n'; i++ {
+		if src[i] == '\\' {
+			i++
+		}
+	}
+	return i
+}
+
+// skipRegex returns the index of the slash closing the regex literal
+// opening at src[i]. Slashes in a character class do not close it.
+func skipRegex(src string, i int) int {
+	class := false
+	for i++; i < len(src) && src[i] != '\n'; i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '[':
+			class = true
+		case ']':
+			class = false
+		case '/':
+			if !class {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// regexCanStart reports whether a slash after prev starts a regex literal
+// rather than dividing.
+func regexCanStart(prev byte) bool {
+	return prev == 0 || strings.IndexByte("(,=:[!&|?{};+-*%<>~^", prev) >= 0
+}
+
+func consoleHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".lightshell", "console_history")
+}
+
+// loadConsoleHistory reads the saved history, trimming the file to the
+// newest consoleHistoryLimit lines.
+func loadConsoleHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+	if len(lines) > consoleHistoryLimit {
+		lines = lines[len(lines)-consoleHistoryLimit:]
+		os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600)
+	}
+	return lines
+}
+
+func appendConsoleHistory(path, line string) {
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
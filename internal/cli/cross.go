@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// buildPlatform is an OS and architecture the app is built for.
+type buildPlatform struct {
+	GOOS, GOARCH string
+}
+
+// String names the platform as release manifests do, like darwin-arm64 or
+// linux-x64.
+func (p buildPlatform) String() string {
+	return normalizePlatform(p.GOOS + "-" + p.GOARCH)
+}
+
+func hostPlatform() buildPlatform {
+	return buildPlatform{runtime.GOOS, runtime.GOARCH}
+}
+
+var platformArchs = map[string]string{"x64": "amd64", "amd64": "amd64", "x86_64": "amd64", "arm64": "arm64", "aarch64": "arm64"}
+
+// parsePlatforms parses the comma-separated list --platform takes.
+func parsePlatforms(list string) ([]buildPlatform, error) {
+	var platforms []buildPlatform
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		goos, arch, _ := strings.Cut(name, "-")
+		p := buildPlatform{goos, platformArchs[arch]}
+		if buildTargets[goos] == nil || p.GOARCH == "" {
+			return nil, fmt.Errorf("unknown --platform %q (expected darwin, windows or linux and x64 or arm64, like darwin-arm64)", name)
+		}
+		if !slices.Contains(platforms, p) {
+			platforms = append(platforms, p)
+		}
+	}
+	return platforms, nil
+}
+
+// crossCompiler returns the C compiler cgo needs to build the webview
+// bridge for p: none for the host, p's entry in build.crossCompilers, or
+// one found on PATH. On a Mac, clang builds for the other Mac
+// architecture; elsewhere osxcross builds for macOS and zig for Windows
+// and Linux.
+func crossCompiler(p buildPlatform, configured map[string]string) (string, error) {
+	if p == hostPlatform() {
+		return "", nil
+	}
+	if cc := strings.TrimSpace(configured[p.String()]); cc != "" {
+		if _, err := exec.LookPath(strings.Fields(cc)[0]); err != nil {
+			return "", fmt.Errorf("build.crossCompilers[%q]: %s not found", p, strings.Fields(cc)[0])
+		}
+		return cc, nil
+	}
+	if p.GOOS == "darwin" {
+		if runtime.GOOS == "darwin" {
+			return "clang -arch " + map[string]string{"amd64": "x86_64", "arm64": "arm64"}[p.GOARCH], nil
+		}
+		// osxcross names its clang wrappers o64-clang and oa64-clang
+		name := map[string]string{"amd64": "o64-clang", "arm64": "oa64-clang"}[p.GOARCH]
+		if _, err := exec.LookPath(name); err == nil {
+			return name, nil
+		}
+		return "", fmt.Errorf("no macOS cross-compiler: install osxcross (https://github.com/tpoechtrager/osxcross) with the macOS SDK so %s is on PATH, or set build.crossCompilers[%q]", name, p)
+	}
+	if _, err := exec.LookPath("zig"); err == nil {
+		arch := map[string]string{"amd64": "x86_64", "arm64": "aarch64"}[p.GOARCH]
+		return fmt.Sprintf("zig cc -target %s-%s-gnu", arch, p.GOOS), nil
+	}
+	return "", fmt.Errorf("no C cross-compiler: install zig (https://ziglang.org) or set build.crossCompilers[%q]", p)
+}
+
+// goBuild compiles the staged app for plan's platform into out.
+func goBuild(w io.Writer, staging, out string, plan buildPlan, verbose bool) error {
+	cmd := exec.Command("go", "build", buildLDFlags(plan.platform.GOOS), "-o", out, ".")
+	cmd.Dir = staging
+	if verbose {
+		cmd.Stdout = w
+		cmd.Stderr = os.Stderr
+	}
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=1", "GOOS="+plan.platform.GOOS, "GOARCH="+plan.platform.GOARCH)
+	if plan.cc != "" {
+		cmd.Env = append(cmd.Env, "CC="+plan.cc)
+	}
+	return cmd.Run()
+}
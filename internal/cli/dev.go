@@ -1,24 +1,33 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path"
 	"path/filepath"
-	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
 	"github.com/lightshell-dev/lightshell/internal/api"
+	"github.com/lightshell-dev/lightshell/internal/app"
+	"github.com/lightshell-dev/lightshell/internal/clientjs"
+	"github.com/lightshell-dev/lightshell/internal/ignore"
 	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/launchargs"
+	"github.com/lightshell-dev/lightshell/internal/metrics"
 	"github.com/lightshell-dev/lightshell/internal/runtime"
 	"github.com/lightshell-dev/lightshell/internal/security"
+	"github.com/lightshell-dev/lightshell/internal/startup"
+	"github.com/lightshell-dev/lightshell/internal/watch"
 	"github.com/lightshell-dev/lightshell/internal/webview"
+	"github.com/lightshell-dev/lightshell/internal/worker"
 )
 
 // Dev runs the app in development mode with hot reload.
@@ -28,24 +37,53 @@ func Dev() error {
 		return err
 	}
 
-	cfg, err := runtime.LoadConfig(dir)
+	// lightshell dev stop|status|logs manage a detached dev process
+	if len(os.Args) > 2 {
+		switch os.Args[2] {
+		case "stop", "status", "logs":
+			return devControl(dir, os.Args[2], os.Args[3:])
+		}
+	}
+
+	cfg, err := loadConfig(dir)
 	if err != nil {
 		return err
 	}
+	if warning := runtime.CheckVersion(cfg.LightShellVersion); warning != "" {
+		fmt.Printf("Warning: %s\n", warning)
+	}
 
-	// If a dev command is configured, delegate to bundler-aware dev mode
-	if cfg.DevCommand != "" {
-		return devWithBundler(dir, cfg)
+	accelJS, err := acceleratorScript(cfg)
+	if err != nil {
+		return err
 	}
 
-	// Check for --mcp-socket flag (used when launched by the MCP server)
-	var mcpSocketPath string
-	for i, arg := range os.Args {
-		if arg == "--mcp-socket" && i+1 < len(os.Args) {
-			mcpSocketPath = os.Args[i+1]
-		}
+	argv, launch, err := devLaunchArgs(cfg)
+	if err == launchargs.ErrHelp {
+		fmt.Print(launchargs.Usage(cfg.Name, cfg.LaunchArgs))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := validateMigrations(cfg.Migrations); err != nil {
+		return invalidConfig(err)
 	}
 
+	if devFlag("--record") && !devFlag("--daemon") && mcpSocketFlag() == "" {
+		return usageError(fmt.Errorf("--record records the commands of the dev process's control socket; use it with --daemon"))
+	}
+	if devFlag("--daemon") {
+		return devDaemon(dir, argv)
+	}
+
+	// If a dev command is configured, delegate to bundler-aware dev mode
+	if command, devURL, ok := cfg.DevServer(); ok {
+		return devWithBundler(dir, cfg, command, devURL, accelJS, argv, launch)
+	}
+
+	mcpSocketPath := mcpSocketFlag()
+
 	// Find a free port
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -59,7 +97,30 @@ func Dev() error {
 
 	// Start HTTP server for serving source files
 	mux := http.NewServeMux()
-	mux.Handle("/", http.FileServer(http.Dir(srcDir)))
+	csp := cfg.Security.PageCSP(true)
+	mux.Handle("/", app.PagesHandler(os.DirFS(srcDir), csp))
+	var rebundle func() error
+	if bundle := cfg.Build.Bundle; bundle != nil {
+		if err := validateBundle(dir, srcDir, bundle); err != nil {
+			return err
+		}
+		outDir, err := os.MkdirTemp("", "lightshell-bundle-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(outDir)
+		rebundle = func() error { return runBundle(os.Stdout, dir, srcDir, outDir, bundle, true) }
+		if err := rebundle(); err != nil {
+			return err
+		}
+		mux.Handle("/", app.PagesHandler(app.Overlay(os.DirFS(outDir), os.DirFS(srcDir)), csp))
+	}
+	mux.HandleFunc("/metrics", serveMetrics)
+	var hmr *hmrServer
+	if cfg.Dev.HMR {
+		hmr = newHMRServer(fmt.Sprintf("http://127.0.0.1:%d", port))
+		mux.Handle(hmrPath, hmr)
+	}
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf("127.0.0.1:%d", port),
@@ -76,9 +137,11 @@ func Dev() error {
 	devURL := fmt.Sprintf("http://127.0.0.1:%d/%s", port, entryFile)
 
 	fmt.Printf("Dev server running at http://127.0.0.1:%d\n", port)
+	fmt.Printf("Metrics available at http://127.0.0.1:%d/metrics\n", port)
 
 	// Set up IPC router and register APIs
 	router := ipc.NewRouter()
+	router.SetPool(newWorkerPool(cfg))
 
 	// Create the webview
 	wv := webview.New()
@@ -91,20 +154,31 @@ func Dev() error {
 		Resizable: true,
 		Frameless: cfg.Window.Frameless,
 		DevTools:  true,
+		Titlebar:  cfg.Window.Titlebar,
+		Hidden:    app.TrayOnly(cfg),
 	}
 	if cfg.Window.Resizable != nil {
 		wcfg.Resizable = *cfg.Window.Resizable
 	}
 
+	app.GuardNavigation(wv, devURL, cfg)
 	if err := wv.Create(wcfg); err != nil {
 		return fmt.Errorf("failed to create window: %w", err)
 	}
+	tracker := startup.NewTracker("dev")
+	tracker.Mark(startup.WindowCreated)
 
 	// Set up the MCP socket server if --mcp-socket was specified.
 	// This must be done before wiring OnMessage so we can intercept MCP messages.
 	var mcpSrv *mcpSocketServer
 	if mcpSocketPath != "" {
-		mcpSrv = newMCPSocketServer(mcpSocketPath, wv)
+		mcpSrv = newMCPSocketServer(mcpSocketPath, wv, router)
+		mcpSrv.pageURL = devURL
+		mcpSrv.dir = dir
+		wv.OnIsolatedMessage(mcpSrv.handleIsolatedMessage)
+		if err := recordSession(dir, mcpSrv); err != nil {
+			return err
+		}
 	}
 
 	// Wire IPC: webview messages go to router, router can eval JS back.
@@ -119,37 +193,72 @@ func Dev() error {
 			return // was an MCP message, don't route to IPC
 		}
 
-		response := router.HandleMessage(msg)
-		js := fmt.Sprintf("__lightshell_receive(%s)", response)
-		wv.Eval(js)
+		router.Dispatch(msg, func(response string) {
+			wv.Eval(fmt.Sprintf("__lightshell_receive(%s)", response))
+		})
 	})
 
 	// Dev mode: all permissions granted
 	policy := security.DevPolicy()
+	httpOpts, err := httpOptions(cfg, dir)
+	if err != nil {
+		return err
+	}
 
 	// Register all APIs with security policy
 	api.RegisterWindow(router, wv)
 	api.RegisterWindowExtended(router, wv)
+	windows := api.RegisterWindowManager(router, wv, devURL, cfg.Window.Title, true)
+	if mcpSrv != nil {
+		mcpSrv.windows = windows
+		windows.Intercept(func(id int, title, msg string) bool {
+			return mcpSrv.handleWindowMessage(title, msg)
+		})
+	}
+	api.RegisterLifecycle(router, wv, cfg.QuitOnLastWindowClosed())
+	if cfg.Scripting.Enabled {
+		api.RegisterScripting(router, wv, cfg.Scripting.Actions)
+	}
+	api.RegisterToolbar(router)
 	api.RegisterFS(router, policy)
+	api.RegisterTempDirs(router, policy, cfg.Name)
 	api.RegisterDialog(router, policy)
 	api.RegisterClipboard(router, policy)
 	api.RegisterShell(router, policy)
+	api.RegisterHTTP(router, policy, httpOpts)
 	api.RegisterSystem(router, cfg.Version, cfg.Name, wv)
+	api.RegisterNetwork(router)
 	api.RegisterNotification(router, policy)
 	api.RegisterTray(router, policy)
+	api.RegisterThemeIcons(router, wv, projectPath(dir, cfg.ThemeIcons.Window), projectPath(dir, cfg.ThemeIcons.Tray))
 	api.RegisterMenu(router, policy)
 	api.RegisterAppExtended(router, cfg.Name)
+	api.RegisterAppState(router, cfg.Name, cfg.Version, cfg.Migrations)
+	api.RegisterLaunchArgs(router, argv, launch)
+	api.RegisterCache(router, cfg.Name, int64(cfg.Cache.MaxSizeMB)*1024*1024)
+	api.RegisterStartup(router, tracker, cfg.Name)
+	api.RegisterImage(router, policy)
+	api.RegisterPDF(router, policy)
+	api.RegisterShare(router, policy)
+	api.RegisterCodes(router, policy)
+	api.RegisterPower(router, policy)
+	api.RegisterPermissions(router, policy)
+	registerExportPage(router, wv, dir)
 
 	// Set up dev tray with Debug Console menu
 	api.SetupDevTray(func(js string) { wv.Eval(js) })
 
 	// Inject polyfills + client library + debug console
-	injectScripts(wv)
+	injectScripts(wv, accelJS)
+	if hmr != nil {
+		wv.AddUserScript(hmr.clientScript())
+	}
 
 	// If MCP mode, inject the console forwarding script that wraps
 	// console.log/warn/error to forward entries to Go via postMessage
 	if mcpSrv != nil {
-		wv.AddUserScript(mcpConsoleForwardScript)
+		wv.AddUserScript(mcpSrv.consoleForwardScript())
+		wv.AddUserScript(mcpEvalScript)
 	}
 
 	// Load the dev URL
@@ -168,9 +277,37 @@ func Dev() error {
 	}
 
 	// Start file watcher for hot reload
-	go watchFiles(srcDir, func() {
+	go watchFiles(dir, srcDir, func(paths []string) {
+		if rebundle != nil && bundledChange(paths) {
+			// Modules are inside the bundle now, so the page reloads
+			if err := rebundle(); err != nil {
+				fmt.Fprintf(os.Stderr, "Not reloading: %v\n", err)
+				return
+			}
+			fmt.Println("Bundled scripts changed, reloading...")
+			router.EvalAll("location.reload()")
+			return
+		}
+		if !cfg.Dev.FullReload {
+			if js, swapped := hotSwapScript(srcDir, paths); swapped != nil {
+				fmt.Printf("Updating %s in place\n", strings.Join(swapped, ", "))
+				router.EvalAll(js)
+				return
+			}
+			if hmr != nil {
+				if modules := hmr.update(srcDir, paths); modules != nil {
+					fmt.Printf("Hot-replacing %s\n", strings.Join(modules, ", "))
+					return
+				}
+			}
+		}
+		if js, pages := pageReloadScript(srcDir, paths); pages != nil {
+			fmt.Printf("%s changed, reloading the windows showing it\n", strings.Join(pages, ", "))
+			router.EvalAll(js)
+			return
+		}
 		fmt.Println("File changed, reloading...")
-		wv.Eval("location.reload()")
+		router.EvalAll("location.reload()")
 	})
 
 	// Handle graceful shutdown
@@ -194,44 +331,126 @@ func Dev() error {
 	return err
 }
 
-func injectScripts(wv webview.Webview) {
+func injectScripts(wv webview.Webview, accelJS string) {
 	// Use AddUserScript so scripts persist across page navigations (including initial LoadURL)
 	wv.AddUserScript(polyfillsJS)
+	wv.AddUserScript(accelJS) // read by the client library, so it goes first
 	wv.AddUserScript(clientJS)
 	wv.AddUserScript(debugConsoleJS)
 	// Inject defaults CSS as a <style> tag
-	cssInjection := fmt.Sprintf(`(function(){var s=document.createElement('style');s.id='lightshell-defaults';s.textContent=%q;document.head.insertBefore(s,document.head.firstChild)})()`, defaultsCSS)
-	wv.AddUserScript(cssInjection)
+	wv.AddUserScript(clientjs.DefaultsScript())
 }
 
-// devWithBundler runs in dev mode using an external dev server (e.g. Vite).
-func devWithBundler(dir string, cfg runtime.Config) error {
-	// Check node_modules exists
-	if _, err := os.Stat(filepath.Join(dir, "node_modules")); os.IsNotExist(err) {
-		return fmt.Errorf("node_modules not found. Run 'npm install' first")
+// projectPath resolves a path from lightshell.json against the project
+// directory. An empty path stays empty.
+func projectPath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
 	}
+	return filepath.Join(dir, path)
+}
 
-	// Parse port from devCommand
-	port := parsePort(cfg.DevCommand)
-	if port == 0 {
-		port = 5173 // Vite default
+// devLaunchArgs returns the app's own arguments, given after "--" as in
+// "lightshell dev -- --file notes.txt", and parses them against launchArgs.
+func devLaunchArgs(cfg runtime.Config) ([]string, launchargs.Result, error) {
+	if err := cfg.LaunchArgs.Validate(); err != nil {
+		return nil, launchargs.Result{}, invalidConfig(err)
+	}
+	var argv []string
+	for i, arg := range os.Args {
+		if arg == "--" {
+			argv = os.Args[i+1:]
+			break
+		}
 	}
+	launch, err := launchargs.Parse(cfg.LaunchArgs, argv)
+	return argv, launch, err
+}
 
-	// Start the dev command as a child process
-	parts := strings.Fields(cfg.DevCommand)
-	cmd := exec.Command(parts[0], parts[1:]...)
-	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// devFlag reports whether the dev command was given flag before "--".
+func devFlag(flag string) bool {
+	for _, arg := range os.Args[2:] {
+		if arg == "--" {
+			return false
+		}
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start dev command %q: %w", cfg.DevCommand, err)
+// recordSession starts recording srv's commands to a session when dev was
+// given --record.
+func recordSession(dir string, srv *mcpSocketServer) error {
+	if !devFlag("--record") {
+		return nil
+	}
+	rec, err := newSessionRecorder(dir)
+	if err != nil {
+		return err
+	}
+	srv.recorder = rec
+	fmt.Printf("Recording the session to %s\n", rec.dir)
+	return nil
+}
+
+// mcpSocketFlag returns the --mcp-socket path, given when the dev process is
+// controlled by the MCP server or runs detached.
+func mcpSocketFlag() string {
+	for i, arg := range os.Args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--mcp-socket" && i+1 < len(os.Args) {
+			return os.Args[i+1]
+		}
+	}
+	return ""
+}
+
+// devWithBundler runs in dev mode using an external dev server (e.g. Vite)
+// at devURL, started with command unless that is empty.
+func devWithBundler(dir string, cfg runtime.Config, command, devURL, accelJS string, argv []string, launch launchargs.Result) error {
+	// The page gets the IPC bridge with every permission, so it must be
+	// one the developer serves locally
+	if err := checkDevURL(devURL); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	exited := make(chan error, 1)
+	if command != "" {
+		// Check node_modules exists
+		if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+			if _, err := os.Stat(filepath.Join(dir, "node_modules")); os.IsNotExist(err) {
+				return fmt.Errorf("node_modules not found. Run 'npm install' first")
+			}
+		}
+
+		// Start the dev command as a child process
+		parts := strings.Fields(command)
+		cmd = exec.Command(parts[0], parts[1:]...)
+		cmd.Dir = dir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("failed to start dev command %q: %w", command, err)
+		}
+		go func() { exited <- cmd.Wait() }()
+	} else {
+		fmt.Printf("Waiting for the dev server at %s...\n", devURL)
+	}
+	stop := func() {
+		if cmd != nil {
+			cmd.Process.Kill()
+		}
 	}
 
 	// Wait for dev server to be ready
-	devURL := fmt.Sprintf("http://127.0.0.1:%d", port)
-	if err := waitForServer(devURL, 30*time.Second); err != nil {
-		cmd.Process.Kill()
+	if err := waitForDevServer(devURL, 30*time.Second, exited); err != nil {
+		stop()
 		return fmt.Errorf("dev server did not start within 30s: %w", err)
 	}
 
@@ -239,6 +458,7 @@ func devWithBundler(dir string, cfg runtime.Config) error {
 
 	// Set up IPC router and register APIs
 	router := ipc.NewRouter()
+	router.SetPool(newWorkerPool(cfg))
 
 	// Create the webview
 	wv := webview.New()
@@ -251,54 +471,118 @@ func devWithBundler(dir string, cfg runtime.Config) error {
 		Resizable: true,
 		Frameless: cfg.Window.Frameless,
 		DevTools:  true,
+		Titlebar:  cfg.Window.Titlebar,
+		Hidden:    app.TrayOnly(cfg),
 	}
 	if cfg.Window.Resizable != nil {
 		wcfg.Resizable = *cfg.Window.Resizable
 	}
 
+	app.GuardNavigation(wv, devURL, cfg)
 	if err := wv.Create(wcfg); err != nil {
-		cmd.Process.Kill()
+		stop()
 		return fmt.Errorf("failed to create window: %w", err)
 	}
+	tracker := startup.NewTracker("dev")
+	tracker.Mark(startup.WindowCreated)
+
+	var mcpSrv *mcpSocketServer
+	if mcpSocketPath := mcpSocketFlag(); mcpSocketPath != "" {
+		mcpSrv = newMCPSocketServer(mcpSocketPath, wv, router)
+		mcpSrv.pageURL = devURL
+		mcpSrv.dir = dir
+		wv.OnIsolatedMessage(mcpSrv.handleIsolatedMessage)
+		if err := recordSession(dir, mcpSrv); err != nil {
+			stop()
+			return err
+		}
+	}
 
 	// Wire IPC
 	router.SetEvalFunc(func(js string) {
 		wv.Eval(js)
 	})
 	wv.OnMessage(func(msg string) {
-		response := router.HandleMessage(msg)
-		js := fmt.Sprintf("__lightshell_receive(%s)", response)
-		wv.Eval(js)
+		if mcpSrv != nil && mcpSrv.handleMCPMessage(msg) {
+			return
+		}
+		router.Dispatch(msg, func(response string) {
+			wv.Eval(fmt.Sprintf("__lightshell_receive(%s)", response))
+		})
 	})
 
 	// Dev mode: all permissions granted
 	policy := security.DevPolicy()
+	httpOpts, err := httpOptions(cfg, dir)
+	if err != nil {
+		return err
+	}
 
 	// Register all APIs
 	api.RegisterWindow(router, wv)
 	api.RegisterWindowExtended(router, wv)
+	windows := api.RegisterWindowManager(router, wv, devURL, cfg.Window.Title, true)
+	if mcpSrv != nil {
+		mcpSrv.windows = windows
+		windows.Intercept(func(id int, title, msg string) bool {
+			return mcpSrv.handleWindowMessage(title, msg)
+		})
+	}
+	api.RegisterLifecycle(router, wv, cfg.QuitOnLastWindowClosed())
+	if cfg.Scripting.Enabled {
+		api.RegisterScripting(router, wv, cfg.Scripting.Actions)
+	}
+	api.RegisterToolbar(router)
 	api.RegisterFS(router, policy)
+	api.RegisterTempDirs(router, policy, cfg.Name)
 	api.RegisterDialog(router, policy)
 	api.RegisterClipboard(router, policy)
 	api.RegisterShell(router, policy)
+	api.RegisterHTTP(router, policy, httpOpts)
 	api.RegisterSystem(router, cfg.Version, cfg.Name, wv)
+	api.RegisterNetwork(router)
 	api.RegisterNotification(router, policy)
 	api.RegisterTray(router, policy)
+	api.RegisterThemeIcons(router, wv, projectPath(dir, cfg.ThemeIcons.Window), projectPath(dir, cfg.ThemeIcons.Tray))
 	api.RegisterMenu(router, policy)
 	api.RegisterAppExtended(router, cfg.Name)
+	api.RegisterAppState(router, cfg.Name, cfg.Version, cfg.Migrations)
+	api.RegisterLaunchArgs(router, argv, launch)
+	api.RegisterCache(router, cfg.Name, int64(cfg.Cache.MaxSizeMB)*1024*1024)
+	api.RegisterStartup(router, tracker, cfg.Name)
+	api.RegisterImage(router, policy)
+	api.RegisterPDF(router, policy)
+	api.RegisterShare(router, policy)
+	api.RegisterCodes(router, policy)
+	api.RegisterPower(router, policy)
+	api.RegisterPermissions(router, policy)
+	registerExportPage(router, wv, dir)
 
 	// Set up dev tray
 	api.SetupDevTray(func(js string) { wv.Eval(js) })
 
 	// Inject polyfills + client library + debug console
-	injectScripts(wv)
+	injectScripts(wv, accelJS)
+	if mcpSrv != nil {
+		wv.AddUserScript(mcpSrv.consoleForwardScript())
+		wv.AddUserScript(mcpEvalScript)
+	}
 
-	// Load the Vite dev URL
+	// Load the dev server's page
 	if err := wv.LoadURL(devURL); err != nil {
-		cmd.Process.Kill()
+		stop()
 		return fmt.Errorf("failed to load dev URL: %w", err)
 	}
 
+	if mcpSrv != nil {
+		go func() {
+			if err := mcpSrv.serve(); err != nil {
+				fmt.Fprintf(os.Stderr, "MCP socket server error: %v\n", err)
+			}
+		}()
+		defer mcpSrv.close()
+	}
+
 	// No file watcher needed — Vite handles HMR natively
 
 	// Handle graceful shutdown
@@ -308,76 +592,241 @@ func devWithBundler(dir string, cfg runtime.Config) error {
 		<-sigCh
 		fmt.Println("\nShutting down...")
 		router.RunShutdownHooks()
-		cmd.Process.Kill()
+		if mcpSrv != nil {
+			mcpSrv.close()
+		}
+		stop()
 		wv.Destroy()
 		os.Exit(0)
 	}()
 
 	// Run the event loop (blocking)
-	err := wv.Run()
+	err = wv.Run()
 	router.RunShutdownHooks()
-	cmd.Process.Kill()
+	stop()
 	return err
 }
 
-// parsePort extracts the port number from a command string (looks for --port NNNN).
-func parsePort(cmd string) int {
-	parts := strings.Fields(cmd)
-	for i, p := range parts {
-		if p == "--port" && i+1 < len(parts) {
-			port, err := strconv.Atoi(parts[i+1])
-			if err == nil {
-				return port
-			}
-		}
+// newWorkerPool creates the pool for CPU-heavy handlers from lightshell.json.
+func newWorkerPool(cfg runtime.Config) *worker.Pool {
+	return worker.NewPool(worker.Config{
+		Size:       cfg.Workers.Size,
+		Queue:      cfg.Workers.Queue,
+		Namespaces: cfg.Workers.Namespaces,
+	})
+}
+
+// serveMetrics exposes the runtime metrics in the Prometheus text format.
+// It is only mounted on the dev server; built apps do not serve HTTP.
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	metrics.Default.WritePrometheus(w)
+}
+
+// checkDevURL accepts http and https URLs on the loopback interface.
+func checkDevURL(devURL string) error {
+	u, err := url.Parse(devURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid dev.url %q: must be an http:// or https:// URL", devURL)
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("dev.url %q must be on localhost: the dev page can call every lightshell API", devURL)
 	}
-	return 0
+	return nil
 }
 
-// waitForServer polls a URL until it responds or the timeout expires.
-func waitForServer(url string, timeout time.Duration) error {
+// waitForDevServer polls a URL until it responds, the timeout expires, or
+// the dev command exits.
+func waitForDevServer(devURL string, timeout time.Duration, exited <-chan error) error {
 	deadline := time.Now().Add(timeout)
 	client := &http.Client{Timeout: 2 * time.Second}
 	for time.Now().Before(deadline) {
-		resp, err := client.Get(url)
+		resp, err := client.Get(devURL)
 		if err == nil {
 			resp.Body.Close()
 			return nil
 		}
-		time.Sleep(200 * time.Millisecond)
+		select {
+		case err := <-exited:
+			if err == nil {
+				return fmt.Errorf("the dev command exited before %s responded", devURL)
+			}
+			return fmt.Errorf("the dev command exited before %s responded: %w", devURL, err)
+		case <-time.After(200 * time.Millisecond):
+		}
 	}
-	return fmt.Errorf("timeout waiting for %s", url)
+	return fmt.Errorf("timeout waiting for %s", devURL)
 }
 
-func watchFiles(dir string, onchange func()) {
-	lastMod := map[string]int64{}
-	var mu sync.Mutex
-
-	scan := func() bool {
-		changed := false
-		mu.Lock()
-		defer mu.Unlock()
-		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
-				return nil
+// watchFiles watches dir, inside the project at projectDir, and calls
+// onchange with the paths that changed once a burst of changes settles.
+// Dotfiles, node_modules, dist, and what the project's .lightshellignore
+// lists are left out. When the ignore file changes, watching starts over
+// with its new rules.
+func watchFiles(projectDir, dir string, onchange func(paths []string)) {
+	ignorePath := filepath.Join(projectDir, ignore.FileName)
+	for {
+		ign, _ := ignore.Load(projectDir)
+		ignMod := modTime(ignorePath)
+		skip := func(rel string, isDir bool) bool {
+			name := path.Base(rel)
+			if strings.HasPrefix(name, ".") || (isDir && (name == "node_modules" || name == "dist")) {
+				return true
 			}
-			mod := info.ModTime().UnixNano()
-			if prev, ok := lastMod[path]; ok && prev != mod {
-				changed = true
+			// rel is relative to dir, and ignore rules to the project
+			projRel, _ := filepath.Rel(projectDir, filepath.Join(dir, filepath.FromSlash(rel)))
+			return ign.Match(projRel, isDir)
+		}
+
+		w, err := watch.New(dir, watch.Options{Skip: skip})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not watching %s for changes: %v\n", dir, err)
+			return
+		}
+		ticker := time.NewTicker(time.Second)
+		for modTime(ignorePath).Equal(ignMod) {
+			select {
+			case paths := <-w.Changes:
+				onchange(paths)
+			case <-ticker.C:
 			}
-			lastMod[path] = mod
-			return nil
-		})
-		return changed
+		}
+		ticker.Stop()
+		w.Close()
 	}
+}
 
-	// Initial scan to populate timestamps
-	scan()
+// bundledChange reports whether paths include scripts that build.bundle
+// may have compiled.
+func bundledChange(paths []string) bool {
+	for _, p := range paths {
+		if bundleSources[strings.ToLower(filepath.Ext(p))] {
+			return true
+		}
+	}
+	return false
+}
 
-	for {
-		time.Sleep(500 * time.Millisecond)
-		if scan() {
-			onchange()
+// hotSwapImages are the image types the dev page can update in place.
+var hotSwapImages = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
+	".webp": true, ".avif": true, ".svg": true, ".ico": true,
+}
+
+// hotSwapScript returns JavaScript that updates the dev page, served from
+// srcDir, for changed paths without reloading it, along with the paths it
+// swaps relative to srcDir. That works when only stylesheets and images
+// changed: their <link> and <img> elements are pointed at a fresh URL.
+// For any other change swapped is nil, and the page must be reloaded. The
+// script reloads the page itself if it finds nothing that uses a file.
+func hotSwapScript(srcDir string, paths []string) (js string, swapped []string) {
+	var css, images []string
+	for _, p := range paths {
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", nil
+		}
+		// Removed files and new directories need a reload
+		if info, err := os.Stat(p); err != nil || info.IsDir() {
+			return "", nil
+		}
+		urlPath := "/" + filepath.ToSlash(rel)
+		switch ext := strings.ToLower(filepath.Ext(p)); {
+		case ext == ".css":
+			css = append(css, urlPath)
+		case hotSwapImages[ext]:
+			images = append(images, urlPath)
+		default:
+			return "", nil
+		}
+		swapped = append(swapped, filepath.ToSlash(rel))
+	}
+	if len(swapped) == 0 {
+		return "", nil
+	}
+	cssJSON, _ := json.Marshal(css)
+	imagesJSON, _ := json.Marshal(images)
+	return fmt.Sprintf(hotSwapJS, cssJSON, imagesJSON), swapped
+}
+
+// pageReloadScript returns JS that reloads a window only if it shows one of
+// the changed pages, when every changed path is an HTML page in srcDir.
+// Other windows keep their state. pages lists the changed pages relative to
+// srcDir; it is nil when some other file changed, which reloads every
+// window.
+func pageReloadScript(srcDir string, paths []string) (js string, pages []string) {
+	var urlPaths []string
+	for _, p := range paths {
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", nil
+		}
+		if ext := strings.ToLower(filepath.Ext(p)); ext != ".html" && ext != ".htm" {
+			return "", nil
 		}
+		urlPaths = append(urlPaths, "/"+filepath.ToSlash(rel))
+		pages = append(pages, filepath.ToSlash(rel))
+	}
+	if len(pages) == 0 {
+		return "", nil
+	}
+	pagesJSON, _ := json.Marshal(urlPaths)
+	return fmt.Sprintf(pageReloadJS, pagesJSON), pages
+}
+
+// pageReloadJS reloads the page if its path is listed. A directory path
+// shows its index.html.
+const pageReloadJS = `(function(pages){
+	var path = decodeURI(location.pathname);
+	if (path.charAt(path.length - 1) === '/') path += 'index.html';
+	if (pages.indexOf(path) >= 0) location.reload();
+})(%s)`
+
+// hotSwapJS swaps the stylesheets and images whose URL paths are listed.
+// A new <link> replaces the old one once it loads, so the page never shows
+// unstyled; a link already being replaced is left to its successor.
+const hotSwapJS = `(function(css, images){
+	var stamp = String(Date.now());
+	function local(url) {
+		try {
+			var u = new URL(url, location.href);
+			return u.origin === location.origin ? u : null;
+		} catch (e) { return null; }
+	}
+	function fresh(u) {
+		u.searchParams.set('lightshellReload', stamp);
+		return u.href;
+	}
+	var found = {};
+	Array.prototype.forEach.call(document.querySelectorAll('link[rel~="stylesheet"][href]'), function(link){
+		var u = local(link.href);
+		if (!u || link.dataset.lightshellReplaced || css.indexOf(decodeURI(u.pathname)) < 0) return;
+		found[decodeURI(u.pathname)] = true;
+		link.dataset.lightshellReplaced = 'true';
+		var next = link.cloneNode();
+		delete next.dataset.lightshellReplaced;
+		next.href = fresh(u);
+		next.onload = next.onerror = function(){ link.remove(); };
+		link.after(next);
+	});
+	Array.prototype.forEach.call(document.images, function(img){
+		var u = local(img.src);
+		if (!u || images.indexOf(decodeURI(u.pathname)) < 0) return;
+		found[decodeURI(u.pathname)] = true;
+		img.src = fresh(u);
+	});
+	if (css.concat(images).some(function(p){ return !found[p]; })) {
+		location.reload();
+	}
+})(%s, %s)`
+
+// modTime returns path's modification time, or the zero time if it cannot
+// be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
 	}
+	return info.ModTime()
 }
@@ -0,0 +1,367 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/paths"
+)
+
+// devState records a detached dev process (lightshell dev --daemon) so that
+// dev stop, status, and logs can find it. It is kept with the process's
+// output log in .lightshell/dev of the project.
+type devState struct {
+	PID       int       `json:"pid"`
+	Socket    string    `json:"socket"` // control socket, speaking the MCP socket protocol
+	Log       string    `json:"log"`    // stdout and stderr of the process
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// devDaemonTimeout bounds how long dev --daemon waits for the app to come
+// up, which includes a devCommand's server starting.
+const devDaemonTimeout = 45 * time.Second
+
+func devStateDir(dir string) string {
+	return filepath.Join(dir, ".lightshell", "dev")
+}
+
+func readDevState(dir string) (devState, error) {
+	var st devState
+	data, err := os.ReadFile(filepath.Join(devStateDir(dir), "state.json"))
+	if err != nil {
+		return st, err
+	}
+	return st, json.Unmarshal(data, &st)
+}
+
+func removeDevState(dir string, st devState) {
+	os.Remove(filepath.Join(devStateDir(dir), "state.json"))
+	os.Remove(st.Socket)
+}
+
+// devDaemon starts the dev process for dir in the background, controlled
+// through a socket, and returns once its window is up.
+func devDaemon(dir string, argv []string) error {
+	if st, err := readDevState(dir); err == nil {
+		if processAlive(st.PID) {
+			return fmt.Errorf("a detached dev process is already running (pid %d); stop it with 'lightshell dev stop'", st.PID)
+		}
+		removeDevState(dir, st)
+	}
+
+	stateDir := devStateDir(dir)
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		return err
+	}
+	logPath := filepath.Join(stateDir, "dev.log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return fmt.Errorf("could not create dev log: %w", err)
+	}
+	defer logFile.Close()
+
+	// Clean up sockets left by detached dev processes that crashed
+	paths.RemoveStaleSockets("dev-*.sock")
+
+	socket, err := devSocketPath("dev")
+	if err != nil {
+		return err
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find lightshell binary: %w", err)
+	}
+	args := []string{"dev", "--mcp-socket", socket}
+	if devFlag("--record") {
+		args = append(args, "--record")
+	}
+	if len(argv) > 0 {
+		args = append(append(args, "--"), argv...)
+	}
+	cmd := exec.Command(self, args...)
+	cmd.Dir = dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start dev process: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	if err := waitForDevSocket(cmd, socket, exited, logPath); err != nil {
+		return err
+	}
+
+	st := devState{PID: cmd.Process.Pid, Socket: socket, Log: logPath, StartedAt: time.Now()}
+	data, _ := json.MarshalIndent(st, "", "  ")
+	if err := os.WriteFile(filepath.Join(stateDir, "state.json"), data, 0o644); err != nil {
+		cmd.Process.Kill()
+		return err
+	}
+
+	fmt.Printf("Dev app running in the background (pid %d)\n", st.PID)
+	fmt.Printf("Output: %s\n", logPath)
+	fmt.Println("Manage it with: lightshell dev status | logs | stop")
+	return nil
+}
+
+// devSocketPath returns a control socket path with prefix and a random
+// name, in a directory of this user's.
+func devSocketPath(prefix string) (string, error) {
+	var token [8]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", fmt.Errorf("failed to generate socket token: %w", err)
+	}
+	return paths.SocketPath(prefix + "-" + hex.EncodeToString(token[:]) + ".sock")
+}
+
+// waitForDevSocket waits for the dev process cmd, whose output goes to
+// logPath, to answer on socket, which it serves once the page is loading.
+func waitForDevSocket(cmd *exec.Cmd, socket string, exited <-chan error, logPath string) error {
+	deadline := time.Now().Add(devDaemonTimeout)
+	for {
+		if _, err := devSocketCommand(socket, mcpSocketCommand{Cmd: "status"}); err == nil {
+			return nil
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("dev process exited during startup; see %s", logPath)
+		case <-time.After(200 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			cmd.Process.Kill()
+			return fmt.Errorf("dev process did not start within %s; see %s", devDaemonTimeout, logPath)
+		}
+	}
+}
+
+// devControl runs lightshell dev stop, status, or logs against the detached
+// dev process of dir.
+func devControl(dir, sub string, args []string) error {
+	st, err := readDevState(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		if sub == "stop" || sub == "status" {
+			fmt.Println("No detached dev process is running")
+			return nil
+		}
+		return fmt.Errorf("no detached dev process is running; start one with 'lightshell dev --daemon'")
+	}
+	if err != nil {
+		return fmt.Errorf("could not read dev state: %w", err)
+	}
+	if !processAlive(st.PID) {
+		removeDevState(dir, st)
+		if sub == "logs" {
+			return fmt.Errorf("the detached dev process (pid %d) has exited; its output is in %s", st.PID, st.Log)
+		}
+		fmt.Printf("The detached dev process (pid %d) has exited; its output is in %s\n", st.PID, st.Log)
+		return nil
+	}
+
+	switch sub {
+	case "stop":
+		return devStop(dir, st)
+	case "status":
+		return devStatus(st)
+	default:
+		return devLogs(st, args)
+	}
+}
+
+// devStop asks the dev process to shut down, so shutdown hooks run, and
+// kills it if it has not exited after a few seconds.
+func devStop(dir string, st devState) error {
+	proc, err := os.FindProcess(st.PID)
+	if err != nil {
+		return err
+	}
+	if err := terminate(proc); err != nil {
+		return fmt.Errorf("could not stop dev process %d: %w", st.PID, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for processAlive(st.PID) && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if processAlive(st.PID) {
+		proc.Kill()
+	}
+	removeDevState(dir, st)
+	fmt.Printf("Stopped dev process %d\n", st.PID)
+	return nil
+}
+
+func devStatus(st devState) error {
+	resp, err := devSocketCommand(st.Socket, mcpSocketCommand{Cmd: "status"})
+	if err != nil {
+		return fmt.Errorf("dev process %d is running but not responding: %w", st.PID, err)
+	}
+	var info struct {
+		URL     string `json:"url"`
+		Session string `json:"session"`
+	}
+	json.Unmarshal(resp.Result, &info)
+	fmt.Printf("Running (pid %d, up %s)\n", st.PID, time.Since(st.StartedAt).Round(time.Second))
+	fmt.Printf("Page:   %s\n", info.URL)
+	fmt.Printf("Output: %s\n", st.Log)
+	if info.Session != "" {
+		fmt.Printf("Recording: %s\n", info.Session)
+	}
+	return nil
+}
+
+// devLogs prints the page's console. --lines N limits the backlog (default
+// 50), --level filters it, and --follow keeps printing new entries.
+func devLogs(st devState, args []string) error {
+	cmd := mcpSocketCommand{Cmd: "console", Lines: 50, Level: "all"}
+	follow := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--follow", "-f":
+			follow = true
+		case "--lines", "-n":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s needs a number", args[i])
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid %s value %q", args[i], args[i+1])
+			}
+			cmd.Lines = n
+			i++
+		case "--level":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--level needs one of log, warn, error, info, all")
+			}
+			cmd.Level = args[i+1]
+			i++
+		default:
+			return fmt.Errorf("unknown logs option %q", args[i])
+		}
+	}
+
+	// Following clears what was read, so each entry prints once
+	cmd.Clear = follow
+	for {
+		resp, err := devSocketCommand(st.Socket, cmd)
+		if err != nil {
+			if follow && !processAlive(st.PID) {
+				return nil
+			}
+			return err
+		}
+		for _, e := range resp.Entries {
+			if e.Window != "" {
+				fmt.Printf("%s [%s] [%s] %s\n", e.Timestamp, e.Window, e.Level, e.Message)
+				continue
+			}
+			fmt.Printf("%s [%s] %s\n", e.Timestamp, e.Level, e.Message)
+		}
+		if !follow {
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// devSocketCommand sends one command over the control socket and returns
+// the response.
+func devSocketCommand(socket string, cmd mcpSocketCommand) (mcpSocketResponse, error) {
+	var resp mcpSocketResponse
+	conn, err := net.DialTimeout("unix", socket, 2*time.Second)
+	if err != nil {
+		return resp, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	cmd.ID = 1
+	data, _ := json.Marshal(cmd)
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return resp, err
+	}
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return resp, err
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return resp, err
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// socketClient is a connection to a dev process's control socket that
+// stays open for a series of commands, as the console and replay send.
+type socketClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int
+}
+
+func dialSocket(socket string) (*socketClient, error) {
+	conn, err := net.DialTimeout("unix", socket, 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &socketClient{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// send sends cmd and waits for its response, skipping events pushed to the
+// connection in between.
+func (c *socketClient) send(cmd mcpSocketCommand) (mcpSocketResponse, error) {
+	var resp mcpSocketResponse
+	c.nextID++
+	cmd.ID = c.nextID
+	// An export takes a page snapshot, then a screenshot
+	c.conn.SetDeadline(time.Now().Add(20 * time.Second))
+	defer c.conn.SetDeadline(time.Time{})
+
+	data, _ := json.Marshal(cmd)
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return resp, err
+	}
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			return resp, err
+		}
+		resp = mcpSocketResponse{}
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return resp, err
+		}
+		if resp.ID != cmd.ID {
+			continue
+		}
+		if resp.Error != "" {
+			return resp, errors.New(resp.Error)
+		}
+		return resp, nil
+	}
+}
+
+func (c *socketClient) Close() error {
+	return c.conn.Close()
+}
+
+// lostConnection reports whether err from send means the connection is
+// gone, rather than that the command failed.
+func lostConnection(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF)
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const detachedProcess = 0x00000008 // DETACHED_PROCESS
+
+// detach starts cmd without a console, so closing the terminal that started
+// it does not end it.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// processAlive reports whether pid names a running process; FindProcess
+// opens a handle to it, which fails once it has exited.
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	proc.Release()
+	return true
+}
+
+// terminate ends proc. Windows cannot deliver SIGTERM, so the app's
+// shutdown hooks do not run.
+func terminate(proc *os.Process) error {
+	return proc.Kill()
+}
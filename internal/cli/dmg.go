@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// validateDMG checks the files build.mac.dmg names before the build
+// starts.
+func validateDMG(dir string, dmg lsruntime.DMGConfig) error {
+	if dmg.Icon != "" && !strings.EqualFold(filepath.Ext(dmg.Icon), ".icns") {
+		return fmt.Errorf("build.mac.dmg.icon must be an .icns file, not %s", dmg.Icon)
+	}
+	for key, path := range map[string]string{"background": dmg.Background, "icon": dmg.Icon} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			return fmt.Errorf("build.mac.dmg.%s: %s not found", key, path)
+		}
+	}
+	if dmg.Window.Width < 0 || dmg.Window.Height < 0 || dmg.IconSize < 0 {
+		return fmt.Errorf("build.mac.dmg sizes must be positive")
+	}
+	return nil
+}
+
+// packageDMG puts the app bundle in a compressed disk image next to a link
+// to /Applications, to install by dragging one onto the other. The image
+// is made writable first, so Finder can lay out its window, and then
+// converted.
+func packageDMG(w io.Writer, appPath, dir, distDir string, cfg lsruntime.Config) (string, error) {
+	title := cfg.Window.Title
+	if title == "" {
+		title = cfg.Name
+	}
+	dmg := cfg.Build.Mac.DMG
+	out := filepath.Join(distDir, fmt.Sprintf("%s-%s.dmg", strings.ReplaceAll(title, " ", ""), cfg.Version))
+	os.Remove(out)
+
+	tmp, err := os.MkdirTemp("", "lightshell-dmg-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	// ditto keeps the bundle's symlinks, attributes and signature intact
+	src := filepath.Join(tmp, "src")
+	appName := filepath.Base(appPath)
+	if err := runTool("ditto", appPath, filepath.Join(src, appName)); err != nil {
+		return "", err
+	}
+	if err := os.Symlink("/Applications", filepath.Join(src, "Applications")); err != nil {
+		return "", err
+	}
+	background := ""
+	if dmg.Background != "" {
+		background = filepath.Base(dmg.Background)
+		if err := copyFile(filepath.Join(dir, dmg.Background), filepath.Join(src, ".background", background)); err != nil {
+			return "", err
+		}
+	}
+	if dmg.Icon != "" {
+		if err := copyFile(filepath.Join(dir, dmg.Icon), filepath.Join(src, ".VolumeIcon.icns")); err != nil {
+			return "", err
+		}
+	}
+
+	rw := filepath.Join(tmp, "rw.dmg")
+	if err := runTool("hdiutil", "create", "-volname", title, "-srcfolder", src, "-fs", "HFS+", "-format", "UDRW", "-ov", rw); err != nil {
+		return "", err
+	}
+	mount := filepath.Join(tmp, "mnt")
+	if err := runTool("hdiutil", "attach", rw, "-readwrite", "-noverify", "-noautoopen", "-mountpoint", mount); err != nil {
+		return "", err
+	}
+	attached := true
+	defer func() {
+		if attached {
+			exec.Command("hdiutil", "detach", mount, "-force").Run()
+		}
+	}()
+
+	if dmg.Icon != "" {
+		// kHasCustomIcon in the volume's Finder flags makes Finder show
+		// .VolumeIcon.icns
+		if err := runTool("xattr", "-wx", "com.apple.FinderInfo", "0000000000000000040000000000000000000000000000000000000000000000", mount); err != nil {
+			return "", err
+		}
+	}
+	layout := exec.Command("osascript")
+	layout.Stdin = strings.NewReader(dmgLayoutScript(title, appName, background, dmg))
+	if output, err := layout.CombinedOutput(); err != nil {
+		// Finder may not be scriptable, on a CI runner without a session
+		fmt.Fprintf(w, "Warning: could not lay out the DMG window: %v %s\n", err, strings.TrimSpace(string(output)))
+	}
+
+	runTool("sync")
+	if err := runTool("hdiutil", "detach", mount); err != nil {
+		return "", err
+	}
+	attached = false
+	if err := runTool("hdiutil", "convert", rw, "-format", "UDZO", "-imagekey", "zlib-level=9", "-o", out); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// dmgLayoutScript has Finder size the volume's window, set its background,
+// and place the app on the left and Applications on the right.
+func dmgLayoutScript(volume, appName, background string, dmg lsruntime.DMGConfig) string {
+	width, height, iconSize := dmg.Window.Width, dmg.Window.Height, dmg.IconSize
+	if width == 0 {
+		width = 540
+	}
+	if height == 0 {
+		height = 380
+	}
+	if iconSize == 0 {
+		iconSize = 128
+	}
+	q := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "tell application \"Finder\"\n  tell disk %s\n    open\n", q(volume))
+	b.WriteString("    set current view of container window to icon view\n")
+	b.WriteString("    set toolbar visible of container window to false\n")
+	b.WriteString("    set statusbar visible of container window to false\n")
+	fmt.Fprintf(&b, "    set the bounds of container window to {100, 100, %d, %d}\n", 100+width, 100+height)
+	b.WriteString("    set opts to the icon view options of container window\n")
+	b.WriteString("    set arrangement of opts to not arranged\n")
+	fmt.Fprintf(&b, "    set icon size of opts to %d\n", iconSize)
+	if background != "" {
+		fmt.Fprintf(&b, "    set background picture of opts to file %s\n", q(".background:"+background))
+	}
+	fmt.Fprintf(&b, "    set position of item %s of container window to {%d, %d}\n", q(appName), width/4, height/2)
+	fmt.Fprintf(&b, "    set position of item \"Applications\" of container window to {%d, %d}\n", width*3/4, height/2)
+	b.WriteString("    close\n    open\n    update without registering applications\n    delay 1\n    close\n  end tell\nend tell\n")
+	return b.String()
+}
+
+// runTool runs a packaging tool, showing its output only when it fails.
+func runTool(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w\n%s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// copyFile copies src to dst, creating dst's directory.
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
@@ -2,13 +2,42 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/lightshell-dev/lightshell/internal/autostart"
 	"github.com/lightshell-dev/lightshell/internal/compat"
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+	"github.com/lightshell-dev/lightshell/internal/proxy"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/startup"
 )
 
-// Doctor runs compatibility checks on the project.
-func Doctor() error {
+// DoctorFlags holds the parsed flags for the doctor command.
+type DoctorFlags struct {
+	Baseline       bool          // snapshot current issues as the baseline
+	UpdateBaseline bool          // drop fixed issues from the baseline and renew it
+	NoBaseline     bool          // report every issue, ignoring the baseline
+	BaselineTTL    time.Duration // how long a new or renewed baseline applies
+	JSON           bool          // print the issues as JSON instead of a report
+	FailOn         string        // the least severe issue that fails doctor: "error" or "warning"
+	Fix            bool          // apply the polyfills and rewrites doctor can make
+	DryRun         bool          // with Fix, print the changes as a diff instead
+}
+
+// Doctor runs compatibility checks on the project. It fails when the
+// report has errors, or with --fail-on warning any issue, so CI can gate
+// on it.
+func Doctor(args []string) error {
+	flags, err := parseDoctorFlags(args)
+	if err != nil {
+		return usageError(err)
+	}
+
 	dir, err := os.Getwd()
 	if err != nil {
 		return err
@@ -19,8 +48,38 @@ func Doctor() error {
 		return fmt.Errorf("scan failed: %w", err)
 	}
 
+	// With --json, stdout carries only the JSON document
+	status := io.Writer(os.Stdout)
+	if flags.JSON {
+		status = os.Stderr
+	}
+	if flags.Fix {
+		if issues, err = fixProject(status, dir, issues, flags.DryRun); err != nil || flags.DryRun {
+			return err
+		}
+	}
+	issues, suppressed, err := applyBaseline(status, dir, issues, flags)
+	if err != nil {
+		return err
+	}
+
+	if flags.JSON {
+		if err := printDoctorJSON(issues, suppressed); err != nil {
+			return err
+		}
+		return compatFailure(issues, flags.FailOn)
+	}
+
 	if len(issues) == 0 {
-		fmt.Println("No compatibility issues found.")
+		if suppressed > 0 {
+			fmt.Println("No new compatibility issues found.")
+		} else {
+			fmt.Println("No compatibility issues found.")
+		}
+		checkStartupBudget(dir)
+		checkProxy()
+		checkLaunchAtLogin(dir)
+		checkLightShellVersion(dir)
 		return nil
 	}
 
@@ -51,7 +110,14 @@ func Doctor() error {
 			autoFixed++
 		}
 
-		fmt.Printf("  %s  line %d: %s\n", severityIcon(issue.Severity), issue.Line, issue.Title)
+		position := strconv.Itoa(issue.Line)
+		if issue.Column > 0 {
+			position += ":" + strconv.Itoa(issue.Column)
+		}
+		fmt.Printf("  %s  line %s: %s\n", severityIcon(issue.Severity), position, issue.Title)
+		if issue.Selector != "" {
+			fmt.Printf("     in %s\n", issue.Selector)
+		}
 		if issue.Fix != "" {
 			if issue.AutoFix {
 				fmt.Printf("     -> Auto-polyfill: %s\n", issue.Fix)
@@ -59,6 +125,12 @@ func Doctor() error {
 				fmt.Printf("     -> %s\n", issue.Fix)
 			}
 		}
+		if req := issue.Rule.Requirements(); req != "" {
+			fmt.Printf("     Requires: %s\n", req)
+		}
+		if issue.Rule.DocsURL != "" {
+			fmt.Printf("     Docs: %s\n", issue.Rule.DocsURL)
+		}
 	}
 
 	fmt.Println()
@@ -66,9 +138,359 @@ func Doctor() error {
 	if autoFixed > 0 {
 		fmt.Printf(" (%d auto-polyfilled)", autoFixed)
 	}
+	if suppressed > 0 {
+		fmt.Printf(", %d known issue(s) in the baseline", suppressed)
+	}
 	fmt.Println()
 
-	return nil
+	checkStartupBudget(dir)
+	checkProxy()
+	checkLaunchAtLogin(dir)
+	checkLightShellVersion(dir)
+	return compatFailure(issues, flags.FailOn)
+}
+
+// fixProject applies the fixes doctor can make for issues, reporting them
+// to w, and returns the issues a new scan finds. With dryRun it prints the
+// fixes as a diff instead and writes nothing.
+func fixProject(w io.Writer, dir string, issues []compat.Issue, dryRun bool) ([]compat.Issue, error) {
+	cfg, err := loadConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	fixes, err := compat.PlanFixes(dir, cfg.Entry, issues)
+	if err != nil {
+		return nil, fmt.Errorf("could not plan fixes: %w", err)
+	}
+	if len(fixes) == 0 {
+		fmt.Fprintln(w, "Nothing for --fix to change.")
+		if !dryRun {
+			fmt.Fprintln(w)
+		}
+		return issues, nil
+	}
+
+	if dryRun {
+		for _, f := range fixes {
+			fmt.Fprint(w, f.Diff())
+		}
+		fmt.Fprintf(w, "\n%d file(s) would change; run 'lightshell doctor --fix' to apply\n", len(fixes))
+		return issues, nil
+	}
+
+	for _, f := range fixes {
+		if err := f.Apply(dir); err != nil {
+			return nil, err
+		}
+		if f.Created {
+			fmt.Fprintf(w, "Created %s\n", f.File)
+		} else {
+			fmt.Fprintf(w, "Fixed %s\n", f.File)
+		}
+		for _, change := range f.Changes {
+			fmt.Fprintf(w, "  %s\n", change)
+		}
+	}
+	fmt.Fprintf(w, "%d file(s) changed\n\n", len(fixes))
+
+	issues, err = compat.ScanProject(dir)
+	if err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+	return issues, nil
+}
+
+// compatFailure fails doctor, with the compat exit status, when issues
+// include errors, or any issue when failOn is "warning". The report has
+// already listed them.
+func compatFailure(issues []compat.Issue, failOn string) error {
+	n := 0
+	for _, issue := range issues {
+		if issue.Severity == "error" || failOn == "warning" {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	if failOn == "warning" {
+		return reported(lserrors.CompatErrors, fmt.Errorf("%d compatibility issue(s) found", n))
+	}
+	return reported(lserrors.CompatErrors, fmt.Errorf("%d compatibility error(s) found", n))
+}
+
+// applyBaseline creates, updates, or applies the project's baseline and
+// returns the issues left to report.
+func applyBaseline(w io.Writer, dir string, issues []compat.Issue, flags DoctorFlags) ([]compat.Issue, int, error) {
+	path := compat.BaselinePath(dir)
+	now := time.Now()
+
+	if flags.Baseline {
+		b := compat.NewBaseline(issues, now, flags.BaselineTTL)
+		if err := b.Save(path); err != nil {
+			return nil, 0, fmt.Errorf("could not write baseline: %w", err)
+		}
+		fmt.Fprintf(w, "Baseline: recorded %d issue(s) in .lightshell/doctor-baseline.json, expires %s\n\n", len(b.Issues), b.ExpiresAt.Format("2006-01-02"))
+		return nil, len(issues), nil
+	}
+	if flags.NoBaseline {
+		return issues, 0, nil
+	}
+
+	b, err := compat.LoadBaseline(path)
+	if os.IsNotExist(err) {
+		if flags.UpdateBaseline {
+			return nil, 0, fmt.Errorf("no baseline to update\n\nCreate one with:\n  lightshell doctor --baseline")
+		}
+		return issues, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not read .lightshell/doctor-baseline.json: %w\n\nRecreate it with:\n  lightshell doctor --baseline", err)
+	}
+
+	if flags.UpdateBaseline {
+		removed := b.Prune(issues, now, flags.BaselineTTL)
+		if err := b.Save(path); err != nil {
+			return nil, 0, fmt.Errorf("could not write baseline: %w", err)
+		}
+		fmt.Fprintf(w, "Baseline: removed %d fixed issue(s), %d remain, expires %s\n\n", removed, len(b.Issues), b.ExpiresAt.Format("2006-01-02"))
+	} else if b.Expired(now) {
+		fmt.Fprintf(w, "%s  The doctor baseline expired on %s, so all issues are reported\n", severityIcon("warning"), b.ExpiresAt.Format("2006-01-02"))
+		fmt.Fprintln(w, "     -> Fix them, or renew the baseline with: lightshell doctor --update-baseline")
+		fmt.Fprintln(w)
+		return issues, 0, nil
+	}
+
+	fresh, suppressed := b.Filter(issues)
+	return fresh, suppressed, nil
+}
+
+const doctorUsage = "Usage: lightshell doctor [--format text|json] [--fail-on error|warning] [--fix [--dry-run]] [--baseline | --update-baseline | --no-baseline] [--baseline-ttl 90d]"
+
+func parseDoctorFlags(args []string) (DoctorFlags, error) {
+	flags := DoctorFlags{BaselineTTL: compat.DefaultBaselineTTL, FailOn: "error"}
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--baseline":
+			flags.Baseline = true
+		case "--update-baseline":
+			flags.UpdateBaseline = true
+		case "--no-baseline":
+			flags.NoBaseline = true
+		case "--json":
+			flags.JSON = true
+		case "--fix":
+			flags.Fix = true
+		case "--dry-run":
+			flags.DryRun = true
+		case "--format":
+			if i+1 >= len(args) {
+				return flags, fmt.Errorf("--format requires text or json")
+			}
+			i++
+			if args[i] != "text" && args[i] != "json" {
+				return flags, fmt.Errorf("invalid --format %q: use text or json", args[i])
+			}
+			flags.JSON = args[i] == "json"
+		case "--fail-on":
+			if i+1 >= len(args) {
+				return flags, fmt.Errorf("--fail-on requires error or warning")
+			}
+			i++
+			if args[i] != "error" && args[i] != "warning" {
+				return flags, fmt.Errorf("invalid --fail-on %q: use error or warning", args[i])
+			}
+			flags.FailOn = args[i]
+		case "--baseline-ttl":
+			if i+1 >= len(args) {
+				return flags, fmt.Errorf("--baseline-ttl requires a duration (e.g. 30d)")
+			}
+			i++
+			ttl, err := parseDays(args[i])
+			if err != nil || ttl <= 0 {
+				return flags, fmt.Errorf("invalid --baseline-ttl %q: use days (30d) or a duration (720h)", args[i])
+			}
+			flags.BaselineTTL = ttl
+		default:
+			return flags, fmt.Errorf("unknown flag: %s\n\n%s", args[i], doctorUsage)
+		}
+	}
+
+	n := 0
+	for _, set := range []bool{flags.Baseline, flags.UpdateBaseline, flags.NoBaseline} {
+		if set {
+			n++
+		}
+	}
+	if n > 1 {
+		return flags, fmt.Errorf("--baseline, --update-baseline, and --no-baseline cannot be combined")
+	}
+	if flags.DryRun && !flags.Fix {
+		return flags, fmt.Errorf("--dry-run only applies to --fix")
+	}
+	if flags.DryRun && flags.JSON {
+		return flags, fmt.Errorf("--dry-run prints a diff and cannot be combined with --format json")
+	}
+	return flags, nil
+}
+
+// printDoctorJSON prints the compatibility issues as a JSON document. The
+// environment checks are left out; they only apply to the text report.
+func printDoctorJSON(issues []compat.Issue, suppressed int) error {
+	return printJSON(compat.NewReport(issues, suppressed))
+}
+
+// parseDays parses a duration that may also be written in days, as in 30d.
+func parseDays(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// checkStartupBudget compares the most recent recorded startup against the
+// budget in lightshell.json. It only reports; a slow start is not an error.
+func checkStartupBudget(dir string) {
+	cfg, err := runtime.LoadConfig(dir)
+	if err != nil || cfg.Startup.BudgetMs <= 0 {
+		return
+	}
+	milestone := cfg.Startup.Milestone
+	if milestone == "" {
+		milestone = startup.DefaultBudgetMilestone
+	}
+
+	fmt.Println()
+	fmt.Println("Startup Budget")
+	fmt.Println("==============")
+
+	report, err := startup.Load(startup.ReportPath(cfg.Name))
+	if err != nil {
+		fmt.Printf("  %s  No startup report yet. Run the app once (lightshell dev or the built app) to record one.\n", severityIcon("info"))
+		return
+	}
+	ms, ok := report.Elapsed(milestone)
+	if !ok {
+		fmt.Printf("  %s  The last %s run did not record %q (valid milestones: %v)\n", severityIcon("warning"), report.Mode, milestone, startup.Milestones)
+		return
+	}
+	if ms <= float64(cfg.Startup.BudgetMs) {
+		fmt.Printf("  %s  %s at %.0fms in the last %s run, within the %dms budget\n", severityIcon("info"), milestone, ms, report.Mode, cfg.Startup.BudgetMs)
+		return
+	}
+
+	fmt.Printf("  %s  %s at %.0fms in the last %s run, over the %dms budget\n", severityIcon("warning"), milestone, ms, report.Mode, cfg.Startup.BudgetMs)
+	for _, name := range startup.Milestones {
+		if v, ok := report.Elapsed(name); ok {
+			fmt.Printf("     %-17s %6.0fms\n", name, v)
+		}
+	}
+	fmt.Println("     -> Defer non-critical work until after first paint, and avoid blocking IPC calls during load.")
+}
+
+// checkProxy reports the proxy settings that release uploads and version
+// checks will use, so users behind a corporate proxy can confirm detection.
+func checkProxy() {
+	s := proxySettings()
+
+	fmt.Println()
+	fmt.Println("Network Proxy")
+	fmt.Println("=============")
+
+	if s.HTTP == "" && s.HTTPS == "" {
+		if s.Source == proxy.SourceConfig {
+			fmt.Printf("  %s  Proxying disabled by `lightshell config set proxy direct`\n", severityIcon("info"))
+		} else {
+			fmt.Printf("  %s  No proxy detected; connecting directly\n", severityIcon("info"))
+		}
+		return
+	}
+
+	source := map[string]string{
+		proxy.SourceConfig:      "lightshell config",
+		proxy.SourceEnvironment: "HTTP_PROXY/HTTPS_PROXY environment",
+		proxy.SourceSystem:      "system settings",
+	}[s.Source]
+	fmt.Printf("  %s  Using proxy from %s\n", severityIcon("info"), source)
+	if s.HTTP != "" {
+		fmt.Printf("     http:     %s\n", proxy.Redacted(s.HTTP))
+	}
+	if s.HTTPS != "" {
+		fmt.Printf("     https:    %s\n", proxy.Redacted(s.HTTPS))
+	} else {
+		fmt.Printf("  %s  No HTTPS proxy is set, so release uploads connect directly\n", severityIcon("warning"))
+	}
+	if len(s.NoProxy) > 0 {
+		fmt.Printf("     no proxy: %s\n", strings.Join(s.NoProxy, ", "))
+	}
+}
+
+// checkLaunchAtLogin reports launch-at-login problems from the state the app
+// last recorded and, on Linux, from its autostart entry. It is silent for apps
+// that have never used launch at login.
+func checkLaunchAtLogin(dir string) {
+	cfg, err := runtime.LoadConfig(dir)
+	if err != nil {
+		return
+	}
+	state, stateErr := autostart.LoadState(autostart.StatePath(cfg.Name))
+	var problems []string
+	if goruntime.GOOS == "linux" {
+		problems = autostart.Problems(autostart.DesktopFilePath(cfg.Name))
+	}
+	if stateErr != nil && !cfg.LaunchAtLogin && len(problems) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Launch at Login")
+	fmt.Println("===============")
+
+	if stateErr != nil {
+		fmt.Printf("  %s  launchAtLogin is set but has not been applied yet. Run the built app once to register it.\n", severityIcon("info"))
+	} else {
+		switch {
+		case state.Error != "":
+			fmt.Printf("  %s  The last change failed: %s\n", severityIcon("warning"), state.Error)
+		case state.Status == autostart.StatusRequiresApproval:
+			fmt.Printf("  %s  The login item is waiting for approval\n", severityIcon("warning"))
+			fmt.Println("     -> Allow it in System Settings > General > Login Items.")
+		case state.Status == autostart.StatusNotFound:
+			fmt.Printf("  %s  macOS could not find the app bundle for the login item\n", severityIcon("warning"))
+			fmt.Println("     -> Move the app to /Applications and enable launch at login again.")
+		case state.Status == autostart.StatusUnsupported:
+			fmt.Printf("  %s  Launch at login requires macOS 13 or later\n", severityIcon("warning"))
+		default:
+			fmt.Printf("  %s  Launch at login %s (changed %s)\n", severityIcon("info"), state.Status, state.UpdatedAt.Format("2006-01-02 15:04"))
+		}
+	}
+	for _, p := range problems {
+		fmt.Printf("  %s  %s\n", severityIcon("warning"), p)
+	}
+}
+
+// checkLightShellVersion reports when the project records a LightShell
+// version other than this CLI's: a newer one, or an older one from before
+// breaking changes.
+func checkLightShellVersion(dir string) {
+	cfg, err := runtime.LoadConfig(dir)
+	if err != nil {
+		return
+	}
+	warning := runtime.CheckVersion(cfg.LightShellVersion)
+	if warning == "" {
+		return
+	}
+	fmt.Println()
+	fmt.Println("LightShell Version")
+	fmt.Println("==================")
+	fmt.Printf("  %s  Project: %s, CLI: %s\n", severityIcon("warning"), cfg.LightShellVersion, runtime.Version)
+	fmt.Printf("     -> %s\n", strings.ToUpper(warning[:1])+warning[1:])
 }
 
 func severityIcon(severity string) string {
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// codedError gives a command's error an error code, and so an exit
+// status, without changing its message.
+type codedError struct {
+	code     string
+	err      error
+	reported bool // the command's own output already said what went wrong
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// withCode gives err code, unless it already has a more specific one, as
+// when a signing failure surfaces as a failed package.
+func withCode(code string, err error) error {
+	if err == nil || errorCode(err) != lserrors.CommandFailed {
+		return err
+	}
+	return &codedError{code: code, err: err}
+}
+
+// reported returns err with code for a failure the command has already
+// printed, such as the issues of a doctor report, so only the exit status
+// is left to give.
+func reported(code string, err error) error {
+	return &codedError{code: code, err: err, reported: true}
+}
+
+// errorCode returns the code of the failure err describes: the one it was
+// given by withCode, the code of a LightShellError, CONFIG_INVALID for a
+// lightshell.json that cannot be decoded, PERMISSION_DENIED for a file it
+// may not access, or COMMAND_FAILED.
+func errorCode(err error) string {
+	var coded *codedError
+	var lsErr *lserrors.LightShellError
+	var fieldErr *lsruntime.FieldError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.As(err, &lsErr):
+		return lsErr.Code
+	case errors.As(err, &fieldErr), errors.As(err, &syntaxErr):
+		return lserrors.ConfigInvalid
+	case errors.Is(err, fs.ErrPermission):
+		return lserrors.PermissionDenied
+	}
+	return lserrors.CommandFailed
+}
+
+// ExitCode returns the exit status of a command that failed with err.
+func ExitCode(err error) int {
+	return lserrors.ExitStatus(errorCode(err))
+}
+
+// Reported reports whether the command that failed with err has already
+// printed why, so the error needs no message.
+func Reported(err error) bool {
+	var coded *codedError
+	return errors.As(err, &coded) && coded.reported
+}
+
+// loadConfig loads dir's lightshell.json, with the config exit status for
+// a file that is missing or invalid.
+func loadConfig(dir string) (lsruntime.Config, error) {
+	cfg, err := lsruntime.LoadConfig(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, withCode(lserrors.ConfigNotFound, err)
+	}
+	return cfg, withCode(lserrors.ConfigInvalid, err)
+}
+
+// usageError gives err, about a command's flags or arguments, the usage
+// exit status.
+func usageError(err error) error {
+	return withCode(lserrors.InvalidUsage, err)
+}
+
+// invalidConfig describes err, about a value in lightshell.json, with the
+// config exit status.
+func invalidConfig(err error) error {
+	return withCode(lserrors.ConfigInvalid, fmt.Errorf("invalid lightshell.json: %w", err))
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// writePackageMetadata writes what package managers need to install the
+// released artifact into outDir: a Homebrew cask for macOS artifacts, or
+// apt repository indexes for a .deb. It returns the files written.
+func writePackageMetadata(outDir, server, appName, version, artifact string, release PlatformArtifact) ([]string, error) {
+	if release.URL == "" {
+		return nil, fmt.Errorf("--formula needs the artifact's download URL; configure a release server or pass --server")
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(artifact)
+	switch {
+	case strings.HasSuffix(name, ".app"), strings.HasSuffix(name, ".dmg"), strings.HasSuffix(name, ".zip"):
+		bundle := appName + ".app"
+		if strings.HasSuffix(name, ".app") {
+			bundle = name
+		}
+		token := caskToken(appName)
+		path := filepath.Join(outDir, token+".rb")
+		if err := os.WriteFile(path, []byte(homebrewCask(token, appName, bundle, version, release)), 0o644); err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	case strings.HasSuffix(name, ".deb"):
+		return writeAptIndexes(filepath.Join(outDir, "apt"), server, artifact, release)
+	default:
+		return nil, fmt.Errorf("--formula supports macOS artifacts (.app, .dmg, .zip) for Homebrew and .deb packages for apt, not %s", name)
+	}
+}
+
+// caskToken turns an app name into a Homebrew cask token: lowercase, with
+// runs of other characters replaced by hyphens.
+func caskToken(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	token := strings.TrimSuffix(b.String(), "-")
+	if token == "" {
+		return "app"
+	}
+	return token
+}
+
+// homebrewCask renders a cask installing the app bundle from the artifact.
+// Homebrew unpacks .app artifacts' tar streams, disk images and zips
+// alike, and moves the app into /Applications.
+func homebrewCask(token, appName, bundle, version string, release PlatformArtifact) string {
+	url := strings.ReplaceAll(release.URL, version, "#{version}")
+	return fmt.Sprintf(`cask %q do
+  version %q
+  sha256 %q
+
+  url %q
+  name %q
+
+  app %q
+end
+`, token, version, release.SHA256, url, appName, bundle)
+}
+
+// writeAptIndexes writes a flat apt repository's Packages, Packages.gz,
+// and unsigned Release for one .deb. Filename is the artifact's path on
+// the release server, so the server's root is the repository.
+func writeAptIndexes(outDir, server, artifact string, release PlatformArtifact) ([]string, error) {
+	control, err := debControl(artifact)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", filepath.Base(artifact), err)
+	}
+	info, err := os.Stat(artifact)
+	if err != nil {
+		return nil, err
+	}
+	filename, err := repoPath(server, release.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var stanza bytes.Buffer
+	stanza.WriteString(strings.TrimRight(control, "\n"))
+	fmt.Fprintf(&stanza, "\nFilename: %s\nSize: %d\nSHA256: %s\n", filename, info.Size(), release.SHA256)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(stanza.Bytes())
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	indexes := []struct {
+		name string
+		data []byte
+	}{{"Packages", stanza.Bytes()}, {"Packages.gz", gz.Bytes()}}
+	var rel bytes.Buffer
+	fmt.Fprintf(&rel, "Date: %s\nSHA256:\n", time.Now().UTC().Format(time.RFC1123Z))
+	for _, f := range indexes {
+		sum := sha256.Sum256(f.data)
+		fmt.Fprintf(&rel, " %s %d %s\n", hex.EncodeToString(sum[:]), len(f.data), f.name)
+	}
+	indexes = append(indexes, struct {
+		name string
+		data []byte
+	}{"Release", rel.Bytes()})
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, f := range indexes {
+		path := filepath.Join(outDir, f.name)
+		if err := os.WriteFile(path, f.data, 0o644); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// repoPath returns artifactURL's path relative to server.
+func repoPath(server, artifactURL string) (string, error) {
+	base, err := url.Parse(strings.TrimSuffix(server, "/") + "/")
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(artifactURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host != base.Host || !strings.HasPrefix(u.Path, base.Path) {
+		return "", fmt.Errorf("artifact URL %s is not on the release server %s", artifactURL, server)
+	}
+	return strings.TrimPrefix(u.Path, base.Path), nil
+}
+
+// debControl returns a .deb's control file. It reads gzipped control
+// archives itself and leaves other compressions to dpkg-deb.
+func debControl(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	member, err := arMember(bufio.NewReader(f), "control.tar.gz")
+	if err != nil {
+		out, dpkgErr := exec.Command("dpkg-deb", "--field", path).Output()
+		if dpkgErr != nil {
+			return "", fmt.Errorf("%w (and dpkg-deb could not read it either)", err)
+		}
+		return string(out), nil
+	}
+	zr, err := gzip.NewReader(member)
+	if err != nil {
+		return "", err
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return "", fmt.Errorf("package has no control file")
+		}
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimPrefix(hdr.Name, "./") == "control" {
+			data, err := io.ReadAll(io.LimitReader(tr, 1<<20))
+			return string(data), err
+		}
+	}
+}
+
+// arMember returns the contents of the named member of an ar archive, the
+// container .deb packages use.
+func arMember(r *bufio.Reader, name string) (io.Reader, error) {
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != "!<arch>\n" {
+		return nil, fmt.Errorf("not a Debian package")
+	}
+	header := make([]byte, 60)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("package has no %s", name)
+		}
+		var size int64
+		if _, err := fmt.Sscan(string(header[48:58]), &size); err != nil {
+			return nil, fmt.Errorf("invalid package member header")
+		}
+		if strings.TrimRight(strings.TrimSpace(string(header[:16])), "/") == name {
+			return io.LimitReader(r, size), nil
+		}
+		// Members are padded to an even length
+		if _, err := r.Discard(int(size + size%2)); err != nil {
+			return nil, fmt.Errorf("package has no %s", name)
+		}
+	}
+}
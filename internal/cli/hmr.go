@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/websocket"
+)
+
+// hmrPath is where dev pages connect for hot module replacement.
+const hmrPath = "/__lightshell/hmr"
+
+// hmrServer delivers changed JS modules to the dev pages connected to it,
+// which then run their lightshell.hmr.accept handlers instead of reloading.
+type hmrServer struct {
+	origin string // the dev server's, the only one allowed to connect
+
+	mu      sync.Mutex
+	clients map[*websocket.Conn]bool
+}
+
+func newHMRServer(origin string) *hmrServer {
+	return &hmrServer{origin: origin, clients: map[*websocket.Conn]bool{}}
+}
+
+// ServeHTTP accepts a page's connection and holds it until the page goes
+// away.
+func (h *hmrServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Origin") != h.origin {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	h.clients[conn] = true
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, conn)
+		h.mu.Unlock()
+		conn.Close()
+	}()
+	for {
+		if _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// update sends the changed paths under srcDir to the connected pages when
+// they are all JS modules, returning the modules' URL paths. It returns
+// nil when some other file changed or no page is connected, and the page
+// must be reloaded.
+func (h *hmrServer) update(srcDir string, paths []string) []string {
+	var modules []string
+	for _, p := range paths {
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(p)); ext != ".js" && ext != ".mjs" {
+			return nil
+		}
+		if info, err := os.Stat(p); err != nil || info.IsDir() {
+			return nil
+		}
+		modules = append(modules, "/"+filepath.ToSlash(rel))
+	}
+	if len(modules) == 0 {
+		return nil
+	}
+	msg, _ := json.Marshal(map[string]any{"type": "update", "modules": modules})
+
+	h.mu.Lock()
+	clients := make([]*websocket.Conn, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+	sent := false
+	for _, c := range clients {
+		if c.WriteText(msg) == nil {
+			sent = true
+		}
+	}
+	if !sent {
+		return nil
+	}
+	return modules
+}
+
+// clientScript is injected into dev pages. It replaces the inert
+// lightshell.hmr of the client library with one that connects to the dev
+// server.
+func (h *hmrServer) clientScript() string {
+	return fmt.Sprintf(hmrClientJS, h.origin, hmrPath)
+}
+
+// hmrClientJS applies updates: for each changed module, its dispose
+// handlers run, the new version is imported, and its accept handlers get
+// the new module. A module without accept handlers reloads the page.
+// Handlers registered while the new version loads, as a module accepting
+// itself does, replace the ones registered before.
+const hmrClientJS = `(function(origin, path){
+	if (location.origin !== origin || !window.lightshell) return;
+	var handlers = {};
+	function entry(mod) {
+		var key = decodeURI(new URL(String(mod), location.href).pathname);
+		return handlers[key] || (handlers[key] = { accept: [], dispose: [] });
+	}
+	window.lightshell.hmr = {
+		enabled: true,
+		accept: function(mod, cb) { entry(mod).accept.push(cb); },
+		dispose: function(mod, cb) { entry(mod).dispose.push(cb); },
+	};
+
+	function apply(key, stamp) {
+		var h = handlers[key];
+		var accept = h.accept, dispose = h.dispose;
+		h.accept = [];
+		h.dispose = [];
+		dispose.forEach(function(cb){
+			try { cb(); } catch (e) { console.error('[hmr] dispose handler for ' + key + ' failed:', e); }
+		});
+		return import(key + '?lightshellHMR=' + stamp).then(function(mod){
+			accept.forEach(function(cb){
+				try { cb(mod); } catch (e) { console.error('[hmr] accept handler for ' + key + ' failed:', e); }
+			});
+			if (h.accept.length === 0) h.accept = accept;
+			console.log('[hmr] updated ' + key);
+		}, function(e){
+			h.accept = accept;
+			console.error('[hmr] could not load ' + key + ':', e);
+		});
+	}
+
+	var queue = Promise.resolve();
+	function update(modules) {
+		if (modules.some(function(key){ return !handlers[key] || handlers[key].accept.length === 0; })) {
+			location.reload();
+			return;
+		}
+		var stamp = Date.now();
+		modules.forEach(function(key){
+			queue = queue.then(function(){ return apply(key, stamp); });
+		});
+	}
+
+	function connect() {
+		var ws = new WebSocket(origin.replace(/^http/, 'ws') + path);
+		ws.onmessage = function(e){
+			var msg;
+			try { msg = JSON.parse(e.data); } catch (err) { return; }
+			if (msg.type === 'update') update(msg.modules);
+		};
+		// The dev server restarted or the connection dropped: try again
+		ws.onclose = function(){ setTimeout(connect, 1000); };
+	}
+	connect();
+})(%q, %q)`
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// hookEnv holds the values exposed to lifecycle hook commands.
+type hookEnv struct {
+	OutputPath string
+	Version    string
+	Platform   string
+}
+
+// runHook runs a lifecycle hook command from lightshell.json in the project
+// directory, with sh -c, or cmd /C on Windows. An empty command is a no-op.
+// A non-zero exit aborts the caller.
+func runHook(w io.Writer, name, command, dir string, env hookEnv) error {
+	if command == "" {
+		return nil
+	}
+
+	fmt.Fprintf(w, "Running %s hook: %s\n", name, command)
+	cmd := exec.Command("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	}
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"LIGHTSHELL_HOOK="+name,
+		"OUTPUT_PATH="+env.OutputPath,
+		"VERSION="+env.Version,
+		"PLATFORM="+env.Platform,
+	)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lightshell-dev/lightshell/internal/iconset"
+	"github.com/lightshell-dev/lightshell/internal/imaging"
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/themeicon"
+)
+
+// IconsFlags holds flags for the icons command.
+type IconsFlags struct {
+	Source string // app icon to check instead of build.icon
+	Out    string // preview directory; dist/icons by default
+	JSON   bool
+}
+
+// iconReport is what the icons command found about one icon.
+type iconReport struct {
+	Role      string          `json:"role"` // "app", "window" or "tray"
+	Path      string          `json:"path"`
+	Dark      string          `json:"dark,omitempty"`
+	Width     int             `json:"width"`
+	Height    int             `json:"height"`
+	Issues    []iconset.Issue `json:"issues"`
+	Generated []string        `json:"generated,omitempty"`
+
+	preview, previewDark string // copies next to the preview page
+}
+
+var iconRoles = map[string]string{"app": "App icon", "window": "Window icon", "tray": "Tray icon"}
+
+// Icons checks the project's icons and writes the sizes a build would
+// generate, with a page previewing them on light and dark backgrounds,
+// without building the app.
+func Icons(args []string) error {
+	flags, err := parseIconsFlags(args)
+	if err != nil {
+		return usageError(err)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(dir)
+	if err != nil {
+		return err
+	}
+	app := cfg.Build.Icon
+	if flags.Source != "" {
+		app = flags.Source
+	}
+	if app == "" && cfg.ThemeIcons.Window == "" && cfg.ThemeIcons.Tray == "" {
+		return fmt.Errorf("no icons to check: set build.icon, themeIcons.window or themeIcons.tray in lightshell.json, or pass an image")
+	}
+	out := flags.Out
+	if out == "" {
+		out = filepath.Join(dir, "dist", "icons")
+	}
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+
+	var reports []iconReport
+	if app != "" {
+		r, err := appIconReport(dir, app, out)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, r)
+	}
+	for _, icon := range []struct{ role, path string }{{"window", cfg.ThemeIcons.Window}, {"tray", cfg.ThemeIcons.Tray}} {
+		if icon.path == "" {
+			continue
+		}
+		r, err := themeIconReport(dir, icon.role, icon.path, out)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, r)
+	}
+
+	preview := filepath.Join(out, "index.html")
+	if err := os.WriteFile(preview, []byte(iconsPreview(cfg, reports)), 0o644); err != nil {
+		return fmt.Errorf("failed to write preview: %w", err)
+	}
+
+	if flags.JSON {
+		return printJSON(map[string]any{"icons": reports, "preview": preview})
+	}
+	for i, r := range reports {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s: %s (%dx%d)", iconRoles[r.Role], r.Path, r.Width, r.Height)
+		if r.Dark != "" {
+			fmt.Printf(", dark: %s", r.Dark)
+		}
+		fmt.Println()
+		if len(r.Issues) == 0 {
+			fmt.Println("  No issues found")
+		}
+		for _, issue := range r.Issues {
+			fmt.Printf("  %s  %s\n", severityIcon(issue.Severity), issue.Message)
+		}
+		if len(r.Generated) > 0 {
+			fmt.Printf("  Generated %d sizes in %s\n", len(r.Generated), filepath.Dir(r.Generated[0]))
+		}
+	}
+	fmt.Printf("\nPreview: %s\n", preview)
+	return nil
+}
+
+func parseIconsFlags(args []string) (IconsFlags, error) {
+	var flags IconsFlags
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--out":
+			if i+1 >= len(args) {
+				return flags, fmt.Errorf("--out requires a directory")
+			}
+			i++
+			flags.Out = args[i]
+		case arg == "--json":
+			flags.JSON = true
+		case strings.HasPrefix(arg, "-"):
+			return flags, fmt.Errorf("unknown flag: %s", arg)
+		case flags.Source == "":
+			flags.Source = arg
+		default:
+			return flags, fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	return flags, nil
+}
+
+// appIconReport checks the app icon and writes every size platforms use
+// into out/app.
+func appIconReport(dir, path, out string) (iconReport, error) {
+	img, err := loadIcon(dir, path)
+	if err != nil {
+		return iconReport{}, err
+	}
+	b := img.Bounds()
+	r := iconReport{Role: "app", Path: path, Width: b.Dx(), Height: b.Dy(), Issues: iconset.CheckApp(img)}
+
+	sizes := iconset.AllSizes()
+	scaled, err := iconset.Generate(img, sizes)
+	if err != nil {
+		return r, err
+	}
+	appDir := filepath.Join(out, "app")
+	if err := os.MkdirAll(appDir, 0o755); err != nil {
+		return r, err
+	}
+	for _, size := range sizes {
+		data, err := imaging.EncodeBytes(scaled[size], "png", 0)
+		if err != nil {
+			return r, err
+		}
+		name := filepath.Join(appDir, fmt.Sprintf("icon_%dx%d.png", size, size))
+		if err := os.WriteFile(name, data, 0o644); err != nil {
+			return r, err
+		}
+		r.Generated = append(r.Generated, name)
+	}
+	return r, nil
+}
+
+// themeIconReport checks a window or tray icon and its @dark variant, and
+// copies them next to the preview page.
+func themeIconReport(dir, role, path, out string) (iconReport, error) {
+	light, err := loadIcon(dir, path)
+	if err != nil {
+		return iconReport{}, err
+	}
+	b := light.Bounds()
+	r := iconReport{Role: role, Path: path, Width: b.Dx(), Height: b.Dy()}
+
+	var dark image.Image
+	darkPath := themeicon.DarkName(path)
+	if _, err := os.Stat(projectPath(dir, darkPath)); err == nil {
+		if dark, err = loadIcon(dir, darkPath); err != nil {
+			return r, err
+		}
+		r.Dark = darkPath
+	}
+	if role == "tray" {
+		r.Issues = iconset.CheckTray(light, dark)
+	} else {
+		r.Issues = iconset.CheckWindow(light, dark)
+	}
+
+	r.preview = role + filepath.Ext(path)
+	if err := copyFile(projectPath(dir, path), filepath.Join(out, r.preview)); err != nil {
+		return r, err
+	}
+	r.previewDark = r.preview
+	if r.Dark != "" {
+		r.previewDark = themeicon.DarkName(r.preview)
+		if err := copyFile(projectPath(dir, darkPath), filepath.Join(out, r.previewDark)); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+func loadIcon(dir, path string) (image.Image, error) {
+	f, err := os.Open(projectPath(dir, path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := imaging.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+// iconsPreview renders a page showing each icon as it appears: app icon
+// sizes at their real size, and theme icons in light and dark mode.
+func iconsPreview(cfg lsruntime.Config, reports []iconReport) string {
+	e := html.EscapeString
+	var b strings.Builder
+	fmt.Fprintf(&b, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s icons</title>
+<style>
+body { font: 13px -apple-system, system-ui, sans-serif; margin: 24px; color: #222; }
+.row { display: flex; gap: 0; margin: 8px 0 24px; }
+.pane { flex: 1; padding: 16px; display: flex; align-items: flex-end; gap: 16px; flex-wrap: wrap; }
+.light { background: #fff; border: 1px solid #ddd; }
+.dark { background: #1e1e1e; color: #ccc; }
+.menubar.light { background: #f2f2f2; }
+.menubar.dark { background: #2b2b2b; }
+.menubar img { height: 22px; }
+figure { margin: 0; text-align: center; }
+figcaption { font-size: 11px; opacity: .7; margin-top: 4px; }
+li.warning { color: #b35c00; }
+</style>
+</head>
+<body>
+<h1>%s icons</h1>
+`, e(cfg.Name), e(cfg.Name))
+
+	for _, r := range reports {
+		fmt.Fprintf(&b, "<h2>%s <small>%s</small></h2>\n", iconRoles[r.Role], e(r.Path))
+		if len(r.Issues) > 0 {
+			b.WriteString("<ul>\n")
+			for _, issue := range r.Issues {
+				fmt.Fprintf(&b, "<li class=\"%s\">%s</li>\n", issue.Severity, e(issue.Message))
+			}
+			b.WriteString("</ul>\n")
+		}
+		b.WriteString("<div class=\"row\">\n")
+		for _, mode := range []string{"light", "dark"} {
+			class := mode
+			if r.Role == "tray" {
+				class = "menubar " + mode
+			}
+			fmt.Fprintf(&b, "<div class=\"pane %s\">\n", class)
+			switch {
+			case r.Role == "app":
+				for _, name := range r.Generated {
+					rel := "app/" + filepath.Base(name)
+					size := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), "icon_"), ".png")
+					fmt.Fprintf(&b, "<figure><img src=\"%s\"><figcaption>%s</figcaption></figure>\n", e(rel), size)
+				}
+			case mode == "dark":
+				fmt.Fprintf(&b, "<figure><img src=\"%s\"></figure>\n", e(r.previewDark))
+			default:
+				fmt.Fprintf(&b, "<figure><img src=\"%s\"></figure>\n", e(r.preview))
+			}
+			b.WriteString("</div>\n")
+		}
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
@@ -3,10 +3,13 @@ package cli
 import (
 	"embed"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/lightshell-dev/lightshell/internal/runtime"
 )
 
 //go:embed all:templates/default
@@ -18,8 +21,65 @@ var reactTemplate embed.FS
 //go:embed all:templates/svelte
 var svelteTemplate embed.FS
 
-// Init creates a new LightShell project.
-func Init(name string, templateName string) error {
+// InitFlags holds the parsed flags for the init command.
+type InitFlags struct {
+	Name     string // the project's name and directory
+	Template string // default, react, or svelte
+	JSON     bool   // print the created project as JSON
+}
+
+// initResult is what init --json prints.
+type initResult struct {
+	Name     string   `json:"name"`
+	Dir      string   `json:"dir"`
+	Template string   `json:"template"`
+	Files    []string `json:"files"`
+	Next     []string `json:"next"` // commands to run next
+}
+
+// Init handles the `lightshell init` command.
+func Init(args []string) error {
+	flags := parseInitFlags(args)
+	if flags.JSON {
+		return runJSON(func(io.Writer) (any, error) {
+			return initProject(flags.Name, flags.Template)
+		})
+	}
+	result, err := initProject(flags.Name, flags.Template)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %s", result.Name)
+	if result.Template != "default" {
+		fmt.Printf(" (template: %s)", result.Template)
+	}
+	fmt.Println()
+	fmt.Println()
+	for _, cmd := range result.Next {
+		fmt.Printf("  %s\n", cmd)
+	}
+	fmt.Println()
+	return nil
+}
+
+func parseInitFlags(args []string) InitFlags {
+	var flags InitFlags
+	args, flags.JSON = jsonFlag(args)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--template" && i+1 < len(args) {
+			flags.Template = args[i+1]
+			i++
+		} else if !strings.HasPrefix(arg, "-") && flags.Name == "" {
+			flags.Name = arg
+		}
+	}
+	return flags
+}
+
+// initProject creates a project from a template.
+func initProject(name string, templateName string) (initResult, error) {
 	if name == "" {
 		name = "my-lightshell-app"
 	}
@@ -30,7 +90,7 @@ func Init(name string, templateName string) error {
 
 	// Validate name
 	if strings.ContainsAny(name, " /\\") {
-		return fmt.Errorf("project name cannot contain spaces or slashes: %q", name)
+		return initResult{}, fmt.Errorf("project name cannot contain spaces or slashes: %q", name)
 	}
 
 	// Select template
@@ -47,23 +107,24 @@ func Init(name string, templateName string) error {
 		tmplFS = svelteTemplate
 		tmplRoot = "templates/svelte"
 	default:
-		return fmt.Errorf("unknown template %q. Available templates: default, react, svelte", templateName)
+		return initResult{}, fmt.Errorf("unknown template %q. Available templates: default, react, svelte", templateName)
 	}
 
 	dir, err := filepath.Abs(name)
 	if err != nil {
-		return err
+		return initResult{}, err
 	}
 
 	if _, err := os.Stat(dir); err == nil {
-		return fmt.Errorf("directory already exists: %s", dir)
+		return initResult{}, fmt.Errorf("directory already exists: %s", dir)
 	}
 
 	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("could not create directory: %w", err)
+		return initResult{}, fmt.Errorf("could not create directory: %w", err)
 	}
 
 	// Copy template files
+	var files []string
 	err = fs.WalkDir(tmplFS, tmplRoot, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -86,28 +147,22 @@ func Init(name string, templateName string) error {
 		content := string(data)
 		content = strings.ReplaceAll(content, "{{NAME}}", name)
 		content = strings.ReplaceAll(content, "{{TITLE}}", formatTitle(name))
+		content = strings.ReplaceAll(content, "{{LIGHTSHELL_VERSION}}", runtime.Version)
 
+		files = append(files, filepath.ToSlash(relPath))
 		return os.WriteFile(destPath, []byte(content), 0o644)
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to create project: %w", err)
+		return initResult{}, fmt.Errorf("failed to create project: %w", err)
 	}
 
-	fmt.Printf("Created %s", name)
+	next := []string{"cd " + name}
 	if templateName != "default" {
-		fmt.Printf(" (template: %s)", templateName)
+		next = append(next, "npm install")
 	}
-	fmt.Println()
-	fmt.Println()
-	fmt.Printf("  cd %s\n", name)
-	if templateName != "default" {
-		fmt.Println("  npm install")
-	}
-	fmt.Println("  lightshell dev")
-	fmt.Println()
-
-	return nil
+	next = append(next, "lightshell dev")
+	return initResult{Name: name, Dir: dir, Template: templateName, Files: files, Next: next}, nil
 }
 
 func formatTitle(name string) string {
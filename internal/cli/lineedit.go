@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// errInterrupt is returned by readLine when Ctrl+C is pressed.
+var errInterrupt = errors.New("interrupted")
+
+// lineEditor reads the console's input. On a terminal it edits the line
+// itself in raw mode, with the arrow keys moving through the line and the
+// history; elsewhere, and on Windows, where the console keeps a history of
+// its own, it reads whole lines.
+type lineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	fd      int
+	history []string
+}
+
+func newLineEditor(in *os.File, out io.Writer) *lineEditor {
+	return &lineEditor{in: bufio.NewReader(in), out: out, fd: int(in.Fd())}
+}
+
+// addHistory records line, unless it repeats the previous one, and
+// reports whether it did.
+func (e *lineEditor) addHistory(line string) bool {
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return false
+	}
+	e.history = append(e.history, line)
+	return true
+}
+
+// readLine prints prompt and returns the line entered, without its line
+// ending. It returns io.EOF once the input ends, or Ctrl+D is pressed on an
+// empty line, and errInterrupt for Ctrl+C.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(e.fd)
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	defer restore()
+	return e.edit(prompt)
+}
+
+func (e *lineEditor) readPlain(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (e *lineEditor) edit(prompt string) (string, error) {
+	var line []rune
+	pos := 0
+	current := len(e.history) // len(history) is the line being typed
+	var draft []rune
+
+	recall := func(i int) {
+		if current == len(e.history) {
+			draft = line
+		}
+		current = i
+		if i == len(e.history) {
+			line = draft
+		} else {
+			line = []rune(e.history[i])
+		}
+		pos = len(line)
+	}
+
+	fmt.Fprint(e.out, prompt)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(line), nil
+		case 3: // Ctrl+C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupt
+		case 4: // Ctrl+D
+			if len(line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(line) {
+				line = slices.Delete(line, pos, pos+1)
+			}
+		case 127, 8: // Backspace
+			if pos > 0 {
+				line = slices.Delete(line, pos-1, pos)
+				pos--
+			}
+		case 1: // Ctrl+A
+			pos = 0
+		case 5: // Ctrl+E
+			pos = len(line)
+		case 2: // Ctrl+B
+			pos = max(pos-1, 0)
+		case 6: // Ctrl+F
+			pos = min(pos+1, len(line))
+		case 11: // Ctrl+K
+			line = line[:pos]
+		case 21: // Ctrl+U
+			line = slices.Clone(line[pos:])
+			pos = 0
+		case 16: // Ctrl+P
+			if current > 0 {
+				recall(current - 1)
+			}
+		case 14: // Ctrl+N
+			if current < len(e.history) {
+				recall(current + 1)
+			}
+		case 27: // an escape sequence, such as an arrow key
+			switch e.escape() {
+			case "A":
+				if current > 0 {
+					recall(current - 1)
+				}
+			case "B":
+				if current < len(e.history) {
+					recall(current + 1)
+				}
+			case "C":
+				pos = min(pos+1, len(line))
+			case "D":
+				pos = max(pos-1, 0)
+			case "H", "1~", "7~":
+				pos = 0
+			case "F", "4~", "8~":
+				pos = len(line)
+			case "3~":
+				if pos < len(line) {
+					line = slices.Delete(line, pos, pos+1)
+				}
+			}
+		default:
+			if r >= ' ' {
+				line = slices.Insert(line, pos, r)
+				pos++
+			}
+		}
+
+		// Redraw the line and put the cursor back
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(line))
+		if n := len(line) - pos; n > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", n)
+		}
+	}
+}
+
+// escape reads the rest of an escape sequence, returning its parameters
+// and final byte, as "A" for ESC [ A or "3~" for ESC [ 3 ~.
+func (e *lineEditor) escape() string {
+	b, err := e.in.ReadByte()
+	if err != nil || (b != '[' && b != 'O') {
+		return ""
+	}
+	var seq []byte
+	for {
+		b, err := e.in.ReadByte()
+		if err != nil {
+			return ""
+		}
+		seq = append(seq, b)
+		if b >= 0x40 && b <= 0x7e {
+			return string(seq)
+		}
+	}
+}
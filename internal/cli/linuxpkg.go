@@ -0,0 +1,414 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// linuxPackageName is the name a Linux package, its executable and its
+// desktop entry share. Cask tokens follow rules Debian and RPM accept.
+func linuxPackageName(cfg lsruntime.Config) string {
+	return caskToken(cfg.Name)
+}
+
+// debArch and rpmArch name a Go architecture the way each format does.
+// AppImages use the RPM names.
+func debArch(goarch string) string {
+	switch goarch {
+	case "386":
+		return "i386"
+	case "arm":
+		return "armhf"
+	}
+	return goarch
+}
+
+func rpmArch(goarch string) string {
+	switch goarch {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "i686"
+	case "arm":
+		return "armv7hl"
+	}
+	return goarch
+}
+
+// linuxVersion turns a semver version into one Debian and RPM sort
+// correctly: a pre-release's hyphen becomes a tilde, which sorts before
+// the release (1.2.0~beta.1 < 1.2.0).
+func linuxVersion(version string) string {
+	return strings.Replace(version, "-", "~", 1)
+}
+
+// linuxSummary is the one-line description packages and the desktop entry
+// show.
+func linuxSummary(cfg lsruntime.Config) string {
+	summary := cfg.Description
+	if summary == "" {
+		summary = cfg.Window.Title
+	}
+	if summary == "" {
+		summary = cfg.Name
+	}
+	return strings.Join(strings.Fields(summary), " ")
+}
+
+// Runtime dependencies of the WebKitGTK webview, as each distribution
+// names them.
+const (
+	debDepends  = "libwebkit2gtk-4.1-0 (>= 2.38), libgtk-3-0"
+	rpmRequires = "webkit2gtk4.1 >= 2.38, gtk3"
+)
+
+// stageLinuxRoot lays out under root the files a Linux package installs:
+// the executable, a desktop entry, and the app icon at each hicolor size
+// when there is one. It returns the installed paths, relative to root.
+func stageLinuxRoot(root, binaryPath string, icon image.Image, cfg lsruntime.Config) ([]string, error) {
+	name := linuxPackageName(cfg)
+	files := map[string][]byte{}
+
+	bin, err := os.ReadFile(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	files["usr/bin/"+name] = bin
+	files["usr/share/applications/"+name+".desktop"] = []byte(desktopEntry(cfg, name))
+
+	if icon != nil {
+		icons, err := linuxIcons(icon, name)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(files, icons)
+	}
+
+	var paths []string
+	for path, data := range files {
+		mode := os.FileMode(0o644)
+		if strings.HasPrefix(path, "usr/bin/") {
+			mode = 0o755
+		}
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(full, data, mode); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// desktopEntry registers the app with Linux application launchers.
+func desktopEntry(cfg lsruntime.Config, name string) string {
+	title := cfg.Window.Title
+	if title == "" {
+		title = cfg.Name
+	}
+	entry := fmt.Sprintf("[Desktop Entry]\nType=Application\nName=%s\nExec=%s %%U\nIcon=%s\nCategories=Utility;\nTerminal=false\n",
+		strings.Join(strings.Fields(title), " "), name, name)
+	if cfg.Description != "" {
+		entry += "Comment=" + linuxSummary(cfg) + "\n"
+	}
+	return entry
+}
+
+// packageDeb builds a Debian package from the staged files, without
+// needing dpkg: a .deb is an ar archive of its format version, a control
+// tarball and a data tarball.
+func packageDeb(binaryPath, distDir, goarch string, icon image.Image, cfg lsruntime.Config) (string, error) {
+	root, err := os.MkdirTemp("", "lightshell-deb-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(root)
+	paths, err := stageLinuxRoot(root, binaryPath, icon, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	data, md5sums, size, err := debData(root, paths)
+	if err != nil {
+		return "", err
+	}
+
+	name := linuxPackageName(cfg)
+	maintainer := cfg.Author
+	if maintainer == "" {
+		maintainer = cfg.Name
+	}
+	control := fmt.Sprintf("Package: %s\nVersion: %s\nSection: utils\nPriority: optional\nArchitecture: %s\nDepends: %s\nInstalled-Size: %d\nMaintainer: %s\n",
+		name, linuxVersion(cfg.Version), debArch(goarch), debDepends, (size+1023)/1024, maintainer)
+	if cfg.Homepage != "" {
+		control += "Homepage: " + cfg.Homepage + "\n"
+	}
+	control += "Description: " + linuxSummary(cfg) + "\n"
+
+	controlTar, err := tarGz(map[string][]byte{"control": []byte(control), "md5sums": []byte(md5sums)})
+	if err != nil {
+		return "", err
+	}
+
+	var deb bytes.Buffer
+	deb.WriteString("!<arch>\n")
+	now := time.Now()
+	for _, m := range []struct {
+		name string
+		data []byte
+	}{
+		{"debian-binary", []byte("2.0\n")},
+		{"control.tar.gz", controlTar},
+		{"data.tar.gz", data},
+	} {
+		fmt.Fprintf(&deb, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", m.name, now.Unix(), 0, 0, "100644", len(m.data))
+		deb.Write(m.data)
+		if len(m.data)%2 == 1 {
+			deb.WriteByte('\n')
+		}
+	}
+
+	out := filepath.Join(distDir, fmt.Sprintf("%s_%s_%s.deb", name, linuxVersion(cfg.Version), debArch(goarch)))
+	if err := os.WriteFile(out, deb.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// debData archives the staged files, owned by root, and returns the
+// archive, their md5sums listing and their total size.
+func debData(root string, paths []string) (data []byte, md5sums string, size int64, err error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "./", Mode: 0o755, Uname: "root", Gname: "root", ModTime: now}); err != nil {
+		return nil, "", 0, err
+	}
+	dirs := map[string]bool{}
+	for _, path := range paths {
+		// Parent directories come first, each once
+		for i := range path {
+			if path[i] != '/' || dirs[path[:i]] {
+				continue
+			}
+			dirs[path[:i]] = true
+			if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "./" + path[:i+1], Mode: 0o755, Uname: "root", Gname: "root", ModTime: now}); err != nil {
+				return nil, "", 0, err
+			}
+		}
+
+		full := filepath.Join(root, filepath.FromSlash(path))
+		info, err := os.Stat(full)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		content, err := os.ReadFile(full)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: "./" + path, Mode: int64(info.Mode().Perm()), Size: int64(len(content)), Uname: "root", Gname: "root", ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, "", 0, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, "", 0, err
+		}
+		sum := md5.Sum(content)
+		md5sums += hex.EncodeToString(sum[:]) + "  " + path + "\n"
+		size += int64(len(content))
+	}
+	if err := tw.Close(); err != nil {
+		return nil, "", 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, "", 0, err
+	}
+	return buf.Bytes(), md5sums, size, nil
+}
+
+// tarGz archives files, by name, as a gzipped tarball of ./-relative paths.
+func tarGz(files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: "./" + name, Mode: 0o644, Size: int64(len(files[name])), Uname: "root", Gname: "root", ModTime: time.Now()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// packageRPM builds an RPM with rpmbuild from a generated spec file that
+// copies the staged files into place.
+func packageRPM(w io.Writer, binaryPath, distDir, goarch string, icon image.Image, cfg lsruntime.Config) (string, error) {
+	rpmbuild, err := exec.LookPath("rpmbuild")
+	if err != nil {
+		return "", fmt.Errorf("rpmbuild not found; install rpm-build (Fedora, RHEL) or rpm (Debian, Ubuntu) to build .rpm packages")
+	}
+	top, err := os.MkdirTemp("", "lightshell-rpm-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(top)
+	root := filepath.Join(top, "root")
+	paths, err := stageLinuxRoot(root, binaryPath, icon, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	arch := rpmArch(goarch)
+	specPath := filepath.Join(top, "app.spec")
+	if err := os.WriteFile(specPath, []byte(rpmSpec(cfg, root, paths)), 0o644); err != nil {
+		return "", err
+	}
+	cmd := exec.Command(rpmbuild, "-bb", "--target", arch, "--define", "_topdir "+top, specPath)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("rpmbuild failed: %w", err)
+	}
+
+	built, _ := filepath.Glob(filepath.Join(top, "RPMS", "*", "*.rpm"))
+	if len(built) != 1 {
+		return "", fmt.Errorf("rpmbuild did not produce a package")
+	}
+	out := filepath.Join(distDir, fmt.Sprintf("%s-%s-1.%s.rpm", linuxPackageName(cfg), linuxVersion(cfg.Version), arch))
+	data, err := os.ReadFile(built[0])
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(out, data, 0o644); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// rpmSpec returns a spec file packaging the files staged under root. The
+// executable is a Go binary, so there is no debug package to split off
+// and nothing for rpmbuild to strip or scan for library dependencies.
+func rpmSpec(cfg lsruntime.Config, root string, paths []string) string {
+	var b strings.Builder
+	b.WriteString("%global debug_package %{nil}\n%global __os_install_post %{nil}\n\n")
+	fmt.Fprintf(&b, "Name: %s\nVersion: %s\nRelease: 1\nSummary: %s\nLicense: Unspecified\n",
+		linuxPackageName(cfg), linuxVersion(cfg.Version), linuxSummary(cfg))
+	if cfg.Homepage != "" {
+		fmt.Fprintf(&b, "URL: %s\n", cfg.Homepage)
+	}
+	if cfg.Author != "" {
+		fmt.Fprintf(&b, "Packager: %s\n", cfg.Author)
+	}
+	fmt.Fprintf(&b, "Requires: %s\nAutoReqProv: no\n\n", rpmRequires)
+	fmt.Fprintf(&b, "%%description\n%s\n\n", linuxSummary(cfg))
+	fmt.Fprintf(&b, "%%install\nmkdir -p %%{buildroot}\ncp -a '%s'/. %%{buildroot}/\n\n", strings.ReplaceAll(root, "'", `'\''`))
+	b.WriteString("%files\n")
+	for _, path := range paths {
+		fmt.Fprintf(&b, "\"/%s\"\n", path)
+	}
+	return b.String()
+}
+
+// packageAppImage lays out an AppDir and turns it into an AppImage with
+// appimagetool when it is on PATH. Without it the output is the AppDir,
+// which runs as is through its AppRun.
+func packageAppImage(w io.Writer, binaryPath, distDir, goarch string, icon image.Image, cfg lsruntime.Config) (string, error) {
+	name := linuxPackageName(cfg)
+	appDir := filepath.Join(distDir, name+".AppDir")
+	os.RemoveAll(appDir)
+	if _, err := stageLinuxRoot(appDir, binaryPath, icon, cfg); err != nil {
+		return "", err
+	}
+
+	// The AppImage runtime runs AppRun, and finds the desktop entry and
+	// icon at the top of the AppDir
+	appRun := fmt.Sprintf("#!/bin/sh\nHERE=\"$(dirname \"$(readlink -f \"$0\")\")\"\nexec \"$HERE/usr/bin/%s\" \"$@\"\n", name)
+	if err := os.WriteFile(filepath.Join(appDir, "AppRun"), []byte(appRun), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(appDir, name+".desktop"), []byte(desktopEntry(cfg, name)), 0o644); err != nil {
+		return "", err
+	}
+	dirIcon, err := os.ReadFile(filepath.Join(appDir, "usr/share/icons/hicolor/256x256/apps", name+".png"))
+	if err != nil {
+		fmt.Fprintln(w, "build.icon is not set; the AppImage gets a blank icon")
+		if dirIcon, err = placeholderIcon(); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(appDir, name+".png"), dirIcon, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Symlink(name+".png", filepath.Join(appDir, ".DirIcon")); err != nil {
+		return "", err
+	}
+
+	appimagetool, err := exec.LookPath("appimagetool")
+	if err != nil {
+		fmt.Fprintln(w, "appimagetool not found; skipping the AppImage (install appimagetool to build one)")
+		return appDir, nil
+	}
+	arch := rpmArch(goarch)
+	out := filepath.Join(distDir, fmt.Sprintf("%s-%s-%s.AppImage", name, cfg.Version, arch))
+	cmd := exec.Command(appimagetool, "--no-appstream", appDir, out)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "ARCH="+arch)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("appimagetool failed: %w", err)
+	}
+	os.RemoveAll(appDir)
+	return out, nil
+}
+
+// placeholderIcon is a plain square for AppImages without an icon, which
+// appimagetool requires.
+func placeholderIcon() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{0x8e, 0x8e, 0x93, 0xff}}, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
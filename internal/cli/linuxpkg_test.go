@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, or with -update writes it
+// there.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs:\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func linuxTestConfig() lsruntime.Config {
+	return lsruntime.Config{
+		Name:        "Note Taker",
+		Version:     "1.2.0-beta.1",
+		Description: "Take notes,\n  quickly",
+		Author:      "Ada Lovelace <ada@example.com>",
+		Homepage:    "https://example.com/notes",
+		Window:      lsruntime.WindowConfig{Title: "Notes"},
+	}
+}
+
+// linuxTestBinary writes a stand-in executable with fixed contents, so
+// sizes and checksums in the golden files don't change.
+func linuxTestBinary(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho notes\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDebControlGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		cfg    lsruntime.Config
+		goarch string
+	}{
+		{"linuxpkg/control", linuxTestConfig(), "amd64"},
+		// Without a description, author or homepage the window title and
+		// app name stand in
+		{"linuxpkg/control-minimal", lsruntime.Config{Name: "Notes", Version: "0.1.0", Window: lsruntime.WindowConfig{Title: "My  Notes"}}, "arm"},
+	}
+	for _, tt := range tests {
+		deb, err := packageDeb(linuxTestBinary(t), t.TempDir(), tt.goarch, nil, tt.cfg)
+		if err != nil {
+			t.Fatalf("packageDeb failed: %v", err)
+		}
+		control, err := debControl(deb)
+		if err != nil {
+			t.Fatalf("reading the control file: %v", err)
+		}
+		checkGolden(t, tt.golden, control)
+	}
+}
+
+func TestRPMSpecGolden(t *testing.T) {
+	paths := []string{"usr/bin/note-taker", "usr/share/applications/note-taker.desktop"}
+	checkGolden(t, "linuxpkg/app.spec", rpmSpec(linuxTestConfig(), "/tmp/it's here", paths))
+}
+
+func TestAppImageGolden(t *testing.T) {
+	// Without appimagetool the AppDir is the output
+	t.Setenv("PATH", t.TempDir())
+	appDir, err := packageAppImage(io.Discard, linuxTestBinary(t), t.TempDir(), "amd64", nil, linuxTestConfig())
+	if err != nil {
+		t.Fatalf("packageAppImage failed: %v", err)
+	}
+	for _, name := range []string{"AppRun", "note-taker.desktop"} {
+		data, err := os.ReadFile(filepath.Join(appDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, "linuxpkg/"+name, string(data))
+	}
+
+	// The packaged desktop entry is the same one
+	installed, err := os.ReadFile(filepath.Join(appDir, "usr/share/applications/note-taker.desktop"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "linuxpkg/note-taker.desktop", string(installed))
+	if info, err := os.Stat(filepath.Join(appDir, "AppRun")); err != nil || info.Mode().Perm()&0o111 == 0 {
+		t.Errorf("AppRun is not executable: %v", err)
+	}
+}
@@ -2,14 +2,27 @@ package cli
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/lightshell-dev/lightshell/internal/api"
+	"github.com/lightshell-dev/lightshell/internal/framing"
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/metrics"
 	"github.com/lightshell-dev/lightshell/internal/webview"
 )
 
@@ -19,18 +32,43 @@ type mcpSocketServer struct {
 	socketPath  string
 	listener    net.Listener
 	wv          webview.Webview
+	router      *ipc.Router
 	console     *mcpConsoleBuffer
+	network     *mcpNetworkBuffer
 	mu          sync.Mutex
 	evalResults map[string]chan evalResult
 	closed      bool
+	pageURL     string // page the window shows, reported by status
+	dir         string // the project, where export saves bug reports
+	windows     *api.WindowManager
+	recorder    *sessionRecorder // set with --record
+
+	subscribers map[chan []byte]bool // event streams opened with subscribe
+
+	// secret signs DOM and export callback IDs and consoleToken marks
+	// console and network entries, so page scripts posting to the
+	// "lightshell" handler themselves cannot forge either
+	secret       []byte
+	consoleToken string
 }
 
 // evalResult holds the result (or error) from a JS evaluation.
 type evalResult struct {
-	Value string
-	Error string
+	Value     string
+	Error     string
+	Truncated bool // the page stopped serializing at the size limit
+	Total     int  // for dom, the selected element's child count
 }
 
+// Eval and DOM output is serialized in the page within a size budget and
+// depth, so a huge value is never stringified whole, and cut again here.
+const (
+	defaultOutputBytes = 100 * 1024
+	maxOutputBytes     = 1024 * 1024
+	defaultEvalDepth   = 10
+	maxOutputDepth     = 50
+)
+
 // mcpConsoleBuffer is a thread-safe ring buffer for console log entries
 // within the dev process.
 type mcpConsoleBuffer struct {
@@ -44,6 +82,28 @@ type mcpConsoleEntry struct {
 	Timestamp string `json:"timestamp"`
 	Level     string `json:"level"`
 	Message   string `json:"message"`
+	Window    string `json:"window,omitempty"` // title of the window it came from, unless the main one
+}
+
+// mcpNetworkBuffer is a thread-safe ring buffer for the page's fetch and
+// XMLHttpRequest activity within the dev process.
+type mcpNetworkBuffer struct {
+	mu      sync.Mutex
+	entries []mcpNetworkEntry
+	max     int
+}
+
+// mcpNetworkEntry is one completed or failed request.
+type mcpNetworkEntry struct {
+	Timestamp string  `json:"timestamp"`
+	Type      string  `json:"type"` // "fetch" or "xhr"
+	Method    string  `json:"method"`
+	URL       string  `json:"url"`
+	Status    int     `json:"status"`          // 0 when the request failed
+	Duration  float64 `json:"duration"`        // ms until the response headers (fetch) or the end (xhr)
+	Size      int64   `json:"size,omitempty"`  // response bytes, when known
+	Error     string  `json:"error,omitempty"` // why the request failed
+	Window    string  `json:"window,omitempty"`
 }
 
 // mcpSocketCommand is the JSON command received from the MCP server.
@@ -57,6 +117,22 @@ type mcpSocketCommand struct {
 	Selector string `json:"selector,omitempty"`
 	Depth    int    `json:"depth,omitempty"`
 	Code     string `json:"code,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+	MaxBytes int    `json:"maxBytes,omitempty"`
+	Filter   string `json:"filter,omitempty"`
+	WindowID int    `json:"windowId,omitempty"` // screenshot, eval, dom and export: an additional window
+
+	Encodings []string `json:"encodings,omitempty"` // hello: what the client reads
+}
+
+// mcpWindow is an open window, as the windows command lists it.
+type mcpWindow struct {
+	api.WindowInfo
+	Width  int `json:"width"`
+	Height int `json:"height"`
 }
 
 // mcpSocketResponse is the JSON response sent back to the MCP server.
@@ -70,23 +146,66 @@ type mcpSocketResponse struct {
 	Status  string            `json:"status,omitempty"`
 	HTML    string            `json:"html,omitempty"`
 	Entries []mcpConsoleEntry `json:"entries,omitempty"`
+
+	Requests []mcpNetworkEntry `json:"requests,omitempty"`
+	Windows  []mcpWindow       `json:"windows,omitempty"`
+
+	Encodings []string `json:"encodings,omitempty"` // hello: what responses may be compressed with
+
+	Truncated bool `json:"truncated,omitempty"` // eval or dom output was cut at maxBytes
+	Total     int  `json:"total,omitempty"`     // dom: children of the selected element
 }
 
 // newMCPSocketServer creates a new MCP socket server.
-func newMCPSocketServer(socketPath string, wv webview.Webview) *mcpSocketServer {
+func newMCPSocketServer(socketPath string, wv webview.Webview, router *ipc.Router) *mcpSocketServer {
+	secret := make([]byte, 32)
+	token := make([]byte, 16)
+	rand.Read(secret)
+	rand.Read(token)
 	return &mcpSocketServer{
 		socketPath: socketPath,
 		wv:         wv,
+		router:     router,
 		console: &mcpConsoleBuffer{
 			entries: make([]mcpConsoleEntry, 0, 1000),
 			max:     1000,
 		},
-		evalResults: make(map[string]chan evalResult),
+		network: &mcpNetworkBuffer{
+			entries: make([]mcpNetworkEntry, 0, 500),
+			max:     500,
+		},
+		evalResults:  make(map[string]chan evalResult),
+		subscribers:  make(map[chan []byte]bool),
+		secret:       secret,
+		consoleToken: hex.EncodeToString(token),
+	}
+}
+
+// callbackID returns a new ID for a DOM or export result, signed so that only
+// this server can have made it.
+func (s *mcpSocketServer) callbackID(kind string, cmdID int) string {
+	id := fmt.Sprintf("mcp_%s_%d_%d", kind, cmdID, time.Now().UnixNano())
+	return id + "." + s.signature(id)
+}
+
+func (s *mcpSocketServer) signature(id string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validCallbackID reports whether id was made by callbackID.
+func (s *mcpSocketServer) validCallbackID(id string) bool {
+	i := strings.LastIndexByte(id, '.')
+	if i < 0 {
+		return false
 	}
+	return hmac.Equal([]byte(id[i+1:]), []byte(s.signature(id[:i])))
 }
 
-// serve starts the Unix domain socket server. It accepts one connection at a
-// time (the MCP server is the only client) and processes commands sequentially.
+// serve starts the Unix domain socket server. The MCP server is the only
+// client: it sends commands over one connection, processed sequentially,
+// and may open a second to subscribe to events.
 func (s *mcpSocketServer) serve() error {
 	// Remove any stale socket file
 	os.Remove(s.socketPath)
@@ -113,13 +232,16 @@ func (s *mcpSocketServer) serve() error {
 			continue
 		}
 
-		s.handleConnection(conn)
+		go s.handleConnection(conn)
 	}
 }
 
 // handleConnection processes commands from a single MCP server connection.
+// A client that opens with hello, listing the encodings it reads, gets
+// large responses compressed.
 func (s *mcpSocketServer) handleConnection(conn net.Conn) {
 	defer conn.Close()
+	var encodings []string
 
 	scanner := bufio.NewScanner(conn)
 	// Allow up to 10MB per line for large responses (screenshots)
@@ -134,23 +256,97 @@ func (s *mcpSocketServer) handleConnection(conn net.Conn) {
 		var cmd mcpSocketCommand
 		if err := json.Unmarshal(line, &cmd); err != nil {
 			resp := mcpSocketResponse{Error: fmt.Sprintf("invalid command: %v", err)}
-			s.writeResponse(conn, resp)
+			s.writeResponse(conn, resp, encodings)
+			continue
+		}
+		switch cmd.Cmd {
+		case "subscribe":
+			s.streamEvents(conn)
+			return
+		case "hello":
+			encodings = framing.Negotiate(cmd.Encodings)
+			s.writeResponse(conn, mcpSocketResponse{ID: cmd.ID, Status: "ok", Encodings: encodings}, nil)
 			continue
 		}
 
 		resp := s.handleCommand(cmd)
-		s.writeResponse(conn, resp)
+		if s.recorder != nil && cmd.Cmd != "status" {
+			s.recorder.record(cmd, resp)
+		}
+		s.writeResponse(conn, resp, encodings)
 	}
 }
 
-// writeResponse writes a JSON response followed by a newline to the connection.
-func (s *mcpSocketServer) writeResponse(conn net.Conn, resp mcpSocketResponse) {
+// mcpEventQueue is how many events a subscriber may fall behind by before
+// further ones are dropped, so a stalled reader cannot block the page.
+const mcpEventQueue = 256
+
+// mcpEvent is a line sent to subscribers as something happens.
+type mcpEvent struct {
+	Event string           `json:"event"` // "console"
+	Entry *mcpConsoleEntry `json:"entry,omitempty"`
+}
+
+// streamEvents sends events to conn, one JSON object per line, until the
+// MCP server closes it.
+func (s *mcpSocketServer) streamEvents(conn net.Conn) {
+	ch := make(chan []byte, mcpEventQueue)
+	s.mu.Lock()
+	s.subscribers[ch] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	// Nothing more is read; this only notices the connection closing
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(done)
+	}()
+
+	for {
+		select {
+		case line := <-ch:
+			conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			if _, err := conn.Write(line); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// publish sends an event to every subscriber that has room for it.
+func (s *mcpSocketServer) publish(event mcpEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// writeResponse writes a JSON response followed by a newline to the
+// connection, compressed with encodings when it is large.
+func (s *mcpSocketServer) writeResponse(conn net.Conn, resp mcpSocketResponse, encodings []string) {
 	data, err := json.Marshal(resp)
 	if err != nil {
 		// Last resort: write a plain error
 		data = []byte(`{"error":"failed to marshal response"}`)
 	}
-	data = append(data, '\n')
+	data = append(framing.Encode(data, encodings), '\n')
 	conn.Write(data)
 }
 
@@ -161,12 +357,24 @@ func (s *mcpSocketServer) handleCommand(cmd mcpSocketCommand) mcpSocketResponse
 		return s.handleScreenshot(cmd)
 	case "console":
 		return s.handleConsole(cmd)
+	case "network":
+		return s.handleNetwork(cmd)
 	case "eval":
 		return s.handleEval(cmd)
 	case "dom":
 		return s.handleDOM(cmd)
 	case "reload":
 		return s.handleReload(cmd)
+	case "metrics":
+		return s.handleMetrics(cmd)
+	case "store":
+		return s.handleStore(cmd)
+	case "status":
+		return s.handleStatus(cmd)
+	case "windows":
+		return s.handleWindows(cmd)
+	case "export":
+		return s.handleExport(cmd)
 	default:
 		return mcpSocketResponse{
 			ID:    cmd.ID,
@@ -186,7 +394,13 @@ func (s *mcpSocketServer) handleScreenshot(cmd mcpSocketCommand) mcpSocketRespon
 		time.Sleep(time.Duration(delay) * time.Millisecond)
 	}
 
-	data, err := s.wv.Screenshot()
+	var data []byte
+	var err error
+	if mainWindow(cmd) {
+		data, err = s.wv.Screenshot()
+	} else {
+		data, err = s.wv.ScreenshotWindow(cmd.WindowID)
+	}
 	if err != nil {
 		return mcpSocketResponse{
 			ID:    cmd.ID,
@@ -205,6 +419,9 @@ func (s *mcpSocketServer) handleScreenshot(cmd mcpSocketCommand) mcpSocketRespon
 
 	// Get window dimensions for the response
 	width, height := s.wv.GetSize()
+	if !mainWindow(cmd) {
+		width, height, _ = s.wv.WindowSize(cmd.WindowID)
+	}
 
 	encoded := base64.StdEncoding.EncodeToString(data)
 	return mcpSocketResponse{
@@ -238,7 +455,28 @@ func (s *mcpSocketServer) handleConsole(cmd mcpSocketCommand) mcpSocketResponse
 	}
 }
 
+// handleNetwork returns recent requests from the buffer, newest last,
+// optionally only those whose URL contains cmd.Filter.
+func (s *mcpSocketServer) handleNetwork(cmd mcpSocketCommand) mcpSocketResponse {
+	limit := cmd.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	requests := s.network.get(limit, cmd.Filter)
+	if cmd.Clear {
+		s.network.clear()
+	}
+	return mcpSocketResponse{
+		ID:       cmd.ID,
+		Requests: requests,
+	}
+}
+
 // handleEval evaluates JavaScript code in the webview and returns the result.
+// The code runs in the page's world, through window.__lightshell_mcp_eval
+// (see mcpEvalScript), and the result comes back from the webview's own
+// completion handler rather than a message, so page scripts cannot post a
+// result in its place.
 func (s *mcpSocketServer) handleEval(cmd mcpSocketCommand) mcpSocketResponse {
 	if cmd.Code == "" {
 		return mcpSocketResponse{
@@ -247,47 +485,31 @@ func (s *mcpSocketServer) handleEval(cmd mcpSocketCommand) mcpSocketResponse {
 		}
 	}
 
-	// Generate a unique callback ID
-	callbackID := fmt.Sprintf("mcp_eval_%d_%d", cmd.ID, time.Now().UnixNano())
-
-	// Create a channel for the result
-	resultCh := make(chan evalResult, 1)
-	s.mu.Lock()
-	s.evalResults[callbackID] = resultCh
-	s.mu.Unlock()
-
-	// Clean up the channel when done
-	defer func() {
-		s.mu.Lock()
-		delete(s.evalResults, callbackID)
-		s.mu.Unlock()
-	}()
+	maxBytes := outputBytes(cmd.MaxBytes)
+	depth := cmd.Depth
+	if depth <= 0 {
+		depth = defaultEvalDepth
+	}
+	depth = min(depth, maxOutputDepth)
 
-	// Build JS that evaluates the code and sends the result back via postMessage.
-	// We JSON-encode both the user code and the callback ID to prevent injection.
+	// The code is JSON-encoded to prevent injection
 	codeJSON, _ := json.Marshal(cmd.Code)
-	callbackJSON, _ := json.Marshal(callbackID)
-	js := fmt.Sprintf(`(function(){
-		try {
-			var __r = eval(%s);
-			var __v;
-			if (__r === undefined) { __v = "undefined"; }
-			else if (__r === null) { __v = "null"; }
-			else { try { __v = JSON.stringify(__r); } catch(e) { __v = String(__r); } }
-			window.webkit.messageHandlers.lightshell.postMessage(JSON.stringify({
-				__mcp_eval: %s,
-				result: __v
-			}));
-		} catch(e) {
-			window.webkit.messageHandlers.lightshell.postMessage(JSON.stringify({
-				__mcp_eval: %s,
-				error: e.message || String(e)
-			}));
-		}
-	})()`, string(codeJSON), string(callbackJSON), string(callbackJSON))
+	js := fmt.Sprintf(`typeof window.__lightshell_mcp_eval === 'function' ? window.__lightshell_mcp_eval(%s, %d, %d) : 'eThe page has not loaded the LightShell scripts'`,
+		codeJSON, depth, maxBytes)
 
-	// Evaluate the JS in the webview
-	if err := s.wv.Eval(js); err != nil {
+	windowID := cmd.WindowID
+	if windowID == 0 {
+		windowID = webview.MainWindowID
+	}
+	resultCh := make(chan evalResult, 1)
+	err := s.wv.EvalResult(windowID, js, func(result string, err error) {
+		if err != nil {
+			resultCh <- evalResult{Error: err.Error()}
+			return
+		}
+		resultCh <- evalResult{Value: result}
+	})
+	if err != nil {
 		return mcpSocketResponse{
 			ID:    cmd.ID,
 			Error: fmt.Sprintf("eval failed: %v", err),
@@ -297,19 +519,28 @@ func (s *mcpSocketServer) handleEval(cmd mcpSocketCommand) mcpSocketResponse {
 	// Wait for the result with a timeout
 	select {
 	case result := <-resultCh:
+		// The value is "r" or "t" (truncated) and the serialized result,
+		// or "e" and the error message
+		kind, value := "e", "the script returned nothing"
 		if result.Error != "" {
+			value = result.Error
+		} else if result.Value != "" {
+			kind, value = result.Value[:1], result.Value[1:]
+		}
+		if kind == "e" {
 			return mcpSocketResponse{
 				ID:    cmd.ID,
-				Error: fmt.Sprintf("JS error: %s", result.Error),
+				Error: fmt.Sprintf("JS error: %s", value),
 			}
 		}
-		// result.Value is already a string from JS (e.g., JSON.stringify output
-		// for objects, or a plain string like "undefined"/"null").
-		// Wrap it as a proper JSON string value.
-		valueJSON, _ := json.Marshal(result.Value)
+		// value is a string from JS (JSON output for objects, or a plain
+		// string like "undefined"/"null"); wrap it as a JSON string value.
+		value, truncated := truncateOutput(value, maxBytes, kind == "t")
+		valueJSON, _ := json.Marshal(value)
 		return mcpSocketResponse{
-			ID:     cmd.ID,
-			Result: json.RawMessage(valueJSON),
+			ID:        cmd.ID,
+			Result:    json.RawMessage(valueJSON),
+			Truncated: truncated,
 		}
 	case <-time.After(5 * time.Second):
 		return mcpSocketResponse{
@@ -330,57 +561,100 @@ func (s *mcpSocketServer) handleDOM(cmd mcpSocketCommand) mcpSocketResponse {
 	if depth <= 0 {
 		depth = 3 // default depth
 	}
+	depth = min(depth, maxOutputDepth)
+	maxBytes := outputBytes(cmd.MaxBytes)
+
+	// Pagination over the selected element's children
+	offset := max(cmd.Offset, 0)
+	limit := cmd.Limit
+	if limit <= 0 {
+		limit = -1
+	}
 
-	// Generate a unique callback ID for this DOM request
-	callbackID := fmt.Sprintf("mcp_dom_%d_%d", cmd.ID, time.Now().UnixNano())
+	// Generate a unique, signed callback ID for this DOM request
+	callbackID := s.callbackID("dom", cmd.ID)
 
-	// Build JS to serialize the DOM at the given selector and depth
+	// Build JS to serialize the DOM at the given selector and depth. It
+	// runs in an isolated world where there is one, which page scripts can
+	// neither tamper with nor post into, so handler names the world's own.
 	selectorJSON, _ := json.Marshal(selector)
 	callbackJSON, _ := json.Marshal(callbackID)
-	js := fmt.Sprintf(`(function(){
-		function serializeDOM(el, depth) {
-			if (!el || depth <= 0) return '';
-			var tag = el.tagName ? el.tagName.toLowerCase() : '';
-			if (!tag) return el.textContent || '';
-			var attrs = '';
-			if (el.attributes) {
-				for (var i = 0; i < el.attributes.length; i++) {
-					var a = el.attributes[i];
-					attrs += ' ' + a.name + '="' + a.value.replace(/"/g, '&quot;') + '"';
-				}
+	domScript := func(handler string) string {
+		return fmt.Sprintf(`(function(){
+		// Output stops at the budget; long attribute values and text are
+		// cut before they are copied
+		var budget = %[5]d, size = 0, truncated = false, parts = [];
+		function emit(s) {
+			if (truncated) return;
+			if (size + s.length > budget) {
+				parts.push(s.slice(0, budget - size));
+				size = budget;
+				truncated = true;
+				return;
 			}
-			var children = '';
-			if (depth > 1 && el.childNodes) {
-				for (var j = 0; j < el.childNodes.length; j++) {
-					var child = el.childNodes[j];
-					if (child.nodeType === 1) {
-						children += serializeDOM(child, depth - 1);
-					} else if (child.nodeType === 3) {
-						var text = child.textContent.trim();
-						if (text) children += text;
-					}
+			parts.push(s);
+			size += s.length;
+		}
+		function clip(s) {
+			return s.length > budget - size ? s.slice(0, budget - size + 1) : s;
+		}
+		var selfClosing = ['br','hr','img','input','meta','link','area','base','col','embed','source','track','wbr'];
+		function serializeNode(node, depth) {
+			if (truncated) return;
+			if (node.nodeType === 3) {
+				var text = clip(node.textContent).trim();
+				if (text) emit(text);
+				return;
+			}
+			if (node.nodeType === 1) serializeElement(node, depth, 0, -1);
+		}
+		// Serializes el with its children from offset, limit of them (-1: all)
+		function serializeElement(el, depth, offset, limit) {
+			var tag = el.tagName.toLowerCase();
+			emit('<' + tag);
+			for (var i = 0; i < el.attributes.length && !truncated; i++) {
+				var a = el.attributes[i];
+				emit(' ' + a.name + '="' + clip(a.value).replace(/"/g, '&quot;') + '"');
+			}
+			if (selfClosing.indexOf(tag) >= 0) { emit('/>'); return; }
+			emit('>');
+			var kids = children(el);
+			if (depth > 1) {
+				var end = limit < 0 ? kids.length : Math.min(kids.length, offset + limit);
+				for (var j = offset; j < end && !truncated; j++) {
+					serializeNode(kids[j], depth - 1);
 				}
-			} else if (el.childNodes && el.childNodes.length > 0) {
-				children = '...';
+			} else if (kids.length > 0) {
+				emit('...');
+			}
+			emit('</' + tag + '>');
+		}
+		// Element and non-blank text children, which pagination counts
+		function children(el) {
+			var out = [];
+			for (var i = 0; i < el.childNodes.length; i++) {
+				var c = el.childNodes[i];
+				if (c.nodeType === 1 || (c.nodeType === 3 && /\S/.test(c.textContent))) out.push(c);
 			}
-			var selfClosing = ['br','hr','img','input','meta','link','area','base','col','embed','source','track','wbr'];
-			if (selfClosing.indexOf(tag) >= 0) return '<' + tag + attrs + '/>';
-			return '<' + tag + attrs + '>' + children + '</' + tag + '>';
+			return out;
 		}
-		var el = document.querySelector(%s);
+		var el = document.querySelector(%[2]s);
 		if (!el) {
-			window.webkit.messageHandlers.lightshell.postMessage(JSON.stringify({
-				__mcp_eval: %s,
-				error: "Element not found: " + %s
+			window.webkit.messageHandlers.%[1]s.postMessage(JSON.stringify({
+				__mcp_eval: %[3]s,
+				error: "Element not found: " + %[2]s
 			}));
 			return;
 		}
-		var html = serializeDOM(el, %d);
-		window.webkit.messageHandlers.lightshell.postMessage(JSON.stringify({
-			__mcp_eval: %s,
-			result: html
+		serializeElement(el, %[4]d, %[6]d, %[7]d);
+		window.webkit.messageHandlers.%[1]s.postMessage(JSON.stringify({
+			__mcp_eval: %[3]s,
+			result: parts.join(''),
+			truncated: truncated,
+			total: children(el).length
 		}));
-	})()`, string(selectorJSON), string(callbackJSON), string(selectorJSON), depth, string(callbackJSON))
+	})()`, handler, string(selectorJSON), string(callbackJSON), depth, maxBytes, offset, limit)
+	}
 
 	// Create a channel for the DOM result
 	resultCh := make(chan evalResult, 1)
@@ -394,10 +668,15 @@ func (s *mcpSocketServer) handleDOM(cmd mcpSocketCommand) mcpSocketResponse {
 		s.mu.Unlock()
 	}()
 
-	if err := s.wv.Eval(js); err != nil {
-		return mcpSocketResponse{
-			ID:    cmd.ID,
-			Error: fmt.Sprintf("DOM inspection failed: %v", err),
+	// Additional windows have no isolated world
+	if !mainWindow(cmd) || s.wv.EvalIsolated(domScript("lightshellIsolated")) != nil {
+		// No isolated world on this platform: the signed ID still keeps
+		// page scripts from forging a result blindly
+		if err := s.evalIn(cmd, domScript("lightshell")); err != nil {
+			return mcpSocketResponse{
+				ID:    cmd.ID,
+				Error: fmt.Sprintf("DOM inspection failed: %v", err),
+			}
 		}
 	}
 
@@ -409,9 +688,12 @@ func (s *mcpSocketServer) handleDOM(cmd mcpSocketCommand) mcpSocketResponse {
 				Error: result.Error,
 			}
 		}
+		html, truncated := truncateOutput(result.Value, maxBytes, result.Truncated)
 		return mcpSocketResponse{
-			ID:   cmd.ID,
-			HTML: result.Value,
+			ID:        cmd.ID,
+			HTML:      html,
+			Truncated: truncated,
+			Total:     result.Total,
 		}
 	case <-time.After(5 * time.Second):
 		return mcpSocketResponse{
@@ -421,6 +703,176 @@ func (s *mcpSocketServer) handleDOM(cmd mcpSocketCommand) mcpSocketResponse {
 	}
 }
 
+// handleExport saves a bug report of the page: its DOM with the styles
+// inlined, a screenshot, and the last cmd.Lines console entries.
+func (s *mcpSocketServer) handleExport(cmd mcpSocketCommand) mcpSocketResponse {
+	lines := cmd.Lines
+	if lines <= 0 || lines > bugReportConsoleLines {
+		lines = bugReportConsoleLines
+	}
+
+	callbackID := s.callbackID("export", cmd.ID)
+	callbackJSON, _ := json.Marshal(callbackID)
+	resultCh := make(chan evalResult, 1)
+	s.mu.Lock()
+	s.evalResults[callbackID] = resultCh
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.evalResults, callbackID)
+		s.mu.Unlock()
+	}()
+
+	// Like dom, the snapshot runs in the isolated world where there is one
+	if !mainWindow(cmd) || s.wv.EvalIsolated(snapshotScript("lightshellIsolated", string(callbackJSON))) != nil {
+		if err := s.evalIn(cmd, snapshotScript("lightshell", string(callbackJSON))); err != nil {
+			return mcpSocketResponse{ID: cmd.ID, Error: fmt.Sprintf("export failed: %v", err)}
+		}
+	}
+
+	var page pageSnapshot
+	select {
+	case result := <-resultCh:
+		if result.Error != "" {
+			return mcpSocketResponse{ID: cmd.ID, Error: fmt.Sprintf("export failed: %s", result.Error)}
+		}
+		if err := json.Unmarshal([]byte(result.Value), &page); err != nil {
+			return mcpSocketResponse{ID: cmd.ID, Error: fmt.Sprintf("export failed: invalid snapshot: %v", err)}
+		}
+	case <-time.After(10 * time.Second):
+		return mcpSocketResponse{ID: cmd.ID, Error: "export timed out after 10s"}
+	}
+
+	var screenshot []byte
+	if mainWindow(cmd) {
+		screenshot, _ = s.wv.Screenshot()
+	} else {
+		screenshot, _ = s.wv.ScreenshotWindow(cmd.WindowID)
+	}
+	report, err := writeBugReport(s.dir, page, screenshot, s.console.get(lines, "all"))
+	if err != nil {
+		return mcpSocketResponse{ID: cmd.ID, Error: err.Error()}
+	}
+	result, _ := json.Marshal(report)
+	return mcpSocketResponse{ID: cmd.ID, Result: result}
+}
+
+// outputBytes returns the size limit for a command's maxBytes.
+func outputBytes(maxBytes int) int {
+	if maxBytes <= 0 {
+		return defaultOutputBytes
+	}
+	return min(maxBytes, maxOutputBytes)
+}
+
+// truncateOutput cuts out to limit bytes on a character boundary and marks
+// where, also when the page already stopped at the limit (truncated).
+func truncateOutput(out string, limit int, truncated bool) (string, bool) {
+	if len(out) > limit {
+		for limit > 0 && !utf8.RuneStart(out[limit]) {
+			limit--
+		}
+		out, truncated = out[:limit], true
+	}
+	if truncated {
+		out += fmt.Sprintf("... [truncated at %d bytes]", len(out))
+	}
+	return out, truncated
+}
+
+// mcpEvalScript defines window.__lightshell_mcp_eval(code, maxDepth,
+// maxLength), which handleEval calls. It evaluates code in the page's
+// global scope and returns a string: "r", or "t" when it was truncated,
+// then the value serialized like JSON.stringify but giving up at maxLength
+// characters, with "[Object]"/"[Array]" past maxDepth and "[Circular]" for
+// cycles; or "e" then the error message. The function is defined before
+// page scripts run, on a property they cannot replace, and only uses the
+// built-ins it took then, so a page that later replaces eval,
+// JSON.stringify, or Array methods cannot change what it returns.
+const mcpEvalScript = `(function(){
+	var evaluate = eval, stringify = JSON.stringify, isArray = Array.isArray,
+		apply = Reflect.apply, hasOwn = Object.prototype.hasOwnProperty,
+		slice = String.prototype.slice;
+	function serialize(value, maxDepth, maxLength) {
+		var out = '', truncated = false, stack = [];
+		function emit(s) {
+			if (truncated) return;
+			if (out.length + s.length > maxLength) {
+				out += apply(slice, s, [0, maxLength - out.length]);
+				truncated = true;
+				return;
+			}
+			out += s;
+		}
+		function walk(v, depth) {
+			if (truncated) return;
+			if (v && typeof v.toJSON === 'function') v = v.toJSON();
+			switch (typeof v) {
+			case 'string':
+				emit(stringify(v.length > maxLength - out.length ? apply(slice, v, [0, maxLength - out.length + 1]) : v));
+				return;
+			case 'number':
+				emit(v === v && v !== Infinity && v !== -Infinity ? '' + v : 'null');
+				return;
+			case 'boolean':
+				emit(v ? 'true' : 'false');
+				return;
+			case 'bigint':
+				emit(stringify('' + v));
+				return;
+			case 'object':
+				if (v === null) { emit('null'); return; }
+				break;
+			default:
+				emit('null');
+				return;
+			}
+			for (var i = 0; i < stack.length; i++) {
+				if (stack[i] === v) { emit('"[Circular]"'); return; }
+			}
+			var array = isArray(v);
+			if (depth >= maxDepth) { emit(array ? '"[Array]"' : '"[Object]"'); return; }
+			stack[stack.length] = v;
+			if (array) {
+				emit('[');
+				for (var j = 0; j < v.length && !truncated; j++) {
+					if (j > 0) emit(',');
+					walk(v[j], depth + 1);
+				}
+				emit(']');
+			} else {
+				emit('{');
+				var first = true;
+				for (var k in v) {
+					if (truncated) break;
+					if (!apply(hasOwn, v, [k])) continue;
+					var t = typeof v[k];
+					if (t === 'undefined' || t === 'function' || t === 'symbol') continue;
+					emit((first ? '' : ',') + stringify(k) + ':');
+					first = false;
+					walk(v[k], depth + 1);
+				}
+				emit('}');
+			}
+			stack.length--;
+		}
+		walk(value, 0);
+		return (truncated ? 't' : 'r') + out;
+	}
+	Object.defineProperty(window, '__lightshell_mcp_eval', {
+		value: function(code, maxDepth, maxLength) {
+			try {
+				var r = evaluate(code);
+				if (r === undefined) return 'rundefined';
+				if (r === null) return 'rnull';
+				return serialize(r, maxDepth, maxLength);
+			} catch (e) {
+				try { return 'e' + ((e && e.message) || ('' + e)); } catch (_) { return 'eUncaught exception'; }
+			}
+		}
+	});
+})()`
+
 // handleReload triggers a page reload in the webview.
 func (s *mcpSocketServer) handleReload(cmd mcpSocketCommand) mcpSocketResponse {
 	if err := s.wv.Eval("location.reload()"); err != nil {
@@ -435,10 +887,61 @@ func (s *mcpSocketServer) handleReload(cmd mcpSocketCommand) mcpSocketResponse {
 	}
 }
 
+// handleWindows lists the open windows with their sizes.
+func (s *mcpSocketServer) handleWindows(cmd mcpSocketCommand) mcpSocketResponse {
+	var list []mcpWindow
+	for _, w := range s.windows.List() {
+		width, height, err := s.wv.WindowSize(w.ID)
+		if err != nil {
+			continue // closed since List
+		}
+		list = append(list, mcpWindow{w, width, height})
+	}
+	return mcpSocketResponse{ID: cmd.ID, Windows: list}
+}
+
+// mainWindow reports whether cmd targets the main window, which it does
+// without a windowId.
+func mainWindow(cmd mcpSocketCommand) bool {
+	return cmd.WindowID == 0 || cmd.WindowID == webview.MainWindowID
+}
+
+// evalIn evaluates js in the window cmd targets.
+func (s *mcpSocketServer) evalIn(cmd mcpSocketCommand, js string) error {
+	if mainWindow(cmd) {
+		return s.wv.Eval(js)
+	}
+	return s.wv.EvalWindow(cmd.WindowID, js)
+}
+
+// handleStatus reports the dev process, the page it shows, and the session
+// being recorded, for lightshell dev status.
+func (s *mcpSocketServer) handleStatus(cmd mcpSocketCommand) mcpSocketResponse {
+	status := map[string]any{"pid": os.Getpid(), "url": s.pageURL}
+	if s.recorder != nil {
+		status["session"] = s.recorder.dir
+	}
+	result, _ := json.Marshal(status)
+	return mcpSocketResponse{
+		ID:     cmd.ID,
+		Status: "running",
+		Result: result,
+	}
+}
+
 // handleMCPMessage checks if a message from the webview is an MCP-specific
 // message (console forwarding or eval result). Returns true if the message
-// was handled and should not be routed to the normal IPC handler.
+// was handled and should not be routed to the normal IPC handler. Console
+// entries without the console token and results without a signed callback
+// ID come from page scripts and are dropped.
 func (s *mcpSocketServer) handleMCPMessage(msg string) bool {
+	return s.handleWindowMessage("", msg)
+}
+
+// handleWindowMessage is handleMCPMessage for a message from an additional
+// window; its console and network entries are tagged with the window's
+// title.
+func (s *mcpSocketServer) handleWindowMessage(window, msg string) bool {
 	// Try to parse as JSON to check for MCP-specific fields
 	var obj map[string]json.RawMessage
 	if err := json.Unmarshal([]byte(msg), &obj); err != nil {
@@ -448,56 +951,100 @@ func (s *mcpSocketServer) handleMCPMessage(msg string) bool {
 	// Check for console forwarding messages
 	if _, ok := obj["__mcp_console"]; ok {
 		var entry struct {
+			Token   string `json:"__mcp_console"`
 			Level   string `json:"level"`
 			Message string `json:"message"`
 		}
 		if err := json.Unmarshal([]byte(msg), &entry); err != nil {
 			return true // still an MCP message, just malformed
 		}
+		if !hmac.Equal([]byte(entry.Token), []byte(s.consoleToken)) {
+			return true
+		}
 		msg := entry.Message
 		// Cap console message size to 10KB to prevent memory abuse
 		const maxMsgSize = 10 * 1024
 		if len(msg) > maxMsgSize {
 			msg = msg[:maxMsgSize] + "... (truncated)"
 		}
-		s.console.add(mcpConsoleEntry{
+		e := mcpConsoleEntry{
 			Timestamp: time.Now().Format(time.RFC3339),
 			Level:     entry.Level,
 			Message:   msg,
-		})
+			Window:    window,
+		}
+		s.console.add(e)
+		s.publish(mcpEvent{Event: "console", Entry: &e})
 		return true
 	}
 
-	// Check for eval/DOM result messages
-	if evalIDRaw, ok := obj["__mcp_eval"]; ok {
-		var evalID string
-		if err := json.Unmarshal(evalIDRaw, &evalID); err != nil {
+	// Check for network activity messages
+	if _, ok := obj["__mcp_network"]; ok {
+		var report struct {
+			Token string          `json:"__mcp_network"`
+			Entry mcpNetworkEntry `json:"entry"`
+		}
+		if err := json.Unmarshal([]byte(msg), &report); err != nil {
+			return true // still an MCP message, just malformed
+		}
+		if !hmac.Equal([]byte(report.Token), []byte(s.consoleToken)) {
 			return true
 		}
-
-		s.mu.Lock()
-		ch, found := s.evalResults[evalID]
-		s.mu.Unlock()
-
-		if found {
-			var resp struct {
-				Result string `json:"result"`
-				Error  string `json:"error"`
-			}
-			json.Unmarshal([]byte(msg), &resp)
-
-			// Non-blocking send (channel is buffered with capacity 1)
-			select {
-			case ch <- evalResult{Value: resp.Result, Error: resp.Error}:
-			default:
-			}
+		e := report.Entry
+		// Data URLs and error messages can be arbitrarily long
+		if len(e.URL) > 2048 {
+			e.URL = e.URL[:2048] + "... (truncated)"
 		}
+		if len(e.Error) > 1024 {
+			e.Error = e.Error[:1024] + "... (truncated)"
+		}
+		e.Timestamp = time.Now().Format(time.RFC3339)
+		e.Window = window
+		s.network.add(e)
+		return true
+	}
+
+	// Check for DOM and export result messages
+	if _, ok := obj["__mcp_eval"]; ok {
+		s.deliverResult(msg)
 		return true
 	}
 
 	return false
 }
 
+// handleIsolatedMessage receives DOM results posted from the isolated
+// world, which only this server's scripts run in.
+func (s *mcpSocketServer) handleIsolatedMessage(msg string) {
+	s.deliverResult(msg)
+}
+
+// deliverResult hands a DOM or export result to the command waiting for it.
+func (s *mcpSocketServer) deliverResult(msg string) {
+	var resp struct {
+		ID        string `json:"__mcp_eval"`
+		Result    string `json:"result"`
+		Error     string `json:"error"`
+		Truncated bool   `json:"truncated"`
+		Total     int    `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(msg), &resp); err != nil || !s.validCallbackID(resp.ID) {
+		return
+	}
+
+	s.mu.Lock()
+	ch, found := s.evalResults[resp.ID]
+	s.mu.Unlock()
+	if !found {
+		return
+	}
+	// Non-blocking send (channel is buffered with capacity 1)
+	select {
+	case ch <- evalResult{Value: resp.Result, Error: resp.Error, Truncated: resp.Truncated, Total: resp.Total}:
+	default:
+	}
+}
+
 // close shuts down the MCP socket server and cleans up resources.
 func (s *mcpSocketServer) close() {
 	s.mu.Lock()
@@ -508,6 +1055,9 @@ func (s *mcpSocketServer) close() {
 		s.listener.Close()
 	}
 	os.Remove(s.socketPath)
+	if s.recorder != nil {
+		s.recorder.close()
+	}
 }
 
 // mcpConsoleBuffer methods
@@ -560,10 +1110,61 @@ func (b *mcpConsoleBuffer) clear() {
 	b.entries = b.entries[:0]
 }
 
-// mcpConsoleForwardScript is injected into the webview when running in MCP mode.
-// It wraps console.log/warn/error/info/debug to forward entries to Go via
-// postMessage, and captures unhandled errors and promise rejections.
-const mcpConsoleForwardScript = `(function(){
+// mcpNetworkBuffer methods
+
+func (b *mcpNetworkBuffer) add(entry mcpNetworkEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.entries) >= b.max {
+		copy(b.entries, b.entries[1:])
+		b.entries = b.entries[:len(b.entries)-1]
+	}
+	b.entries = append(b.entries, entry)
+}
+
+func (b *mcpNetworkBuffer) get(n int, filter string) []mcpNetworkEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var matched []mcpNetworkEntry
+	for _, e := range b.entries {
+		if filter == "" || strings.Contains(e.URL, filter) {
+			matched = append(matched, e)
+		}
+	}
+	if n > 0 && n < len(matched) {
+		matched = matched[len(matched)-n:]
+	}
+	return matched
+}
+
+func (b *mcpNetworkBuffer) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = b.entries[:0]
+}
+
+// consoleForwardScript is injected into the webview when running in MCP
+// mode. It wraps console.log/warn/error/info/debug to forward entries to Go
+// via postMessage, and captures unhandled errors and promise rejections. It
+// also wraps fetch and XMLHttpRequest to report each request's outcome.
+// Entries carry the console token, which stays in the script's closure;
+// postMessage and JSON.stringify are taken before page scripts run, so
+// replacing them later does not reveal it.
+func (s *mcpSocketServer) consoleForwardScript() string {
+	return fmt.Sprintf(mcpConsoleForwardScript, s.consoleToken)
+}
+
+const mcpConsoleForwardScript = `(function(token){
+	var target = window.chrome && window.chrome.webview ? window.chrome.webview : window.webkit.messageHandlers.lightshell;
+	var post = target.postMessage.bind(target);
+	var stringify = JSON.stringify;
+	function send(level, message) {
+		try {
+			post('{"__mcp_console":"' + token + '","level":' + stringify(level) + ',"message":' + stringify(message) + '}');
+		} catch(e) {}
+	}
 	var orig = {
 		log: console.log,
 		warn: console.warn,
@@ -578,35 +1179,162 @@ const mcpConsoleForwardScript = `(function(){
 				if (a === null) return 'null';
 				if (a === undefined) return 'undefined';
 				if (typeof a === 'object') {
-					try { return JSON.stringify(a); } catch(e) { return String(a); }
+					try { return stringify(a); } catch(e) { return String(a); }
 				}
 				return String(a);
 			});
-			try {
-				window.webkit.messageHandlers.lightshell.postMessage(JSON.stringify({
-					__mcp_console: true,
-					level: level,
-					message: args.join(' ')
-				}));
-			} catch(e) {}
+			send(level, args.join(' '));
 		};
 	});
 	window.addEventListener('error', function(e) {
-		try {
-			window.webkit.messageHandlers.lightshell.postMessage(JSON.stringify({
-				__mcp_console: true,
-				level: 'error',
-				message: e.message + (e.filename ? ' at ' + e.filename + ':' + e.lineno : '')
-			}));
-		} catch(ex) {}
+		send('error', e.message + (e.filename ? ' at ' + e.filename + ':' + e.lineno : ''));
 	});
 	window.addEventListener('unhandledrejection', function(e) {
-		try {
-			window.webkit.messageHandlers.lightshell.postMessage(JSON.stringify({
-				__mcp_console: true,
-				level: 'error',
-				message: 'Unhandled rejection: ' + (e.reason instanceof Error ? e.reason.message : String(e.reason))
-			}));
-		} catch(ex) {}
+		send('error', 'Unhandled rejection: ' + (e.reason instanceof Error ? e.reason.message : String(e.reason)));
 	});
-})();`
+
+	// Network activity. Sizes come from Content-Length, or for XHR from
+	// the loaded response.
+	var now = performance.now.bind(performance);
+	function absolute(url) {
+		try { return new URL(url, location.href).href; } catch(e) { return String(url); }
+	}
+	function record(entry) {
+		try {
+			post('{"__mcp_network":"' + token + '","entry":' + stringify(entry) + '}');
+		} catch(e) {}
+	}
+	var origFetch = window.fetch;
+	if (origFetch) {
+		window.fetch = function(input, init) {
+			var method = String((init && init.method) || (input && input.method) || 'GET').toUpperCase();
+			var url = absolute(typeof input === 'string' || input instanceof URL ? input : input && input.url);
+			var start = now();
+			return origFetch.apply(this, arguments).then(function(res) {
+				var size = parseInt(res.headers.get('content-length'), 10);
+				record({type: 'fetch', method: method, url: res.url || url, status: res.status,
+					duration: now() - start, size: isNaN(size) ? 0 : size});
+				return res;
+			}, function(err) {
+				record({type: 'fetch', method: method, url: url, status: 0,
+					duration: now() - start, error: String(err && err.message || err)});
+				throw err;
+			});
+		};
+	}
+	var XHR = window.XMLHttpRequest;
+	if (XHR) {
+		var requests = new WeakMap();
+		var open = XHR.prototype.open;
+		var sendXHR = XHR.prototype.send;
+		XHR.prototype.open = function(method, url) {
+			requests.set(this, {method: String(method).toUpperCase(), url: absolute(url)});
+			return open.apply(this, arguments);
+		};
+		XHR.prototype.send = function() {
+			var xhr = this, info = requests.get(this);
+			if (info) {
+				var start = now();
+				xhr.addEventListener('loadend', function() {
+					var size = parseInt(xhr.getResponseHeader('content-length'), 10);
+					if (isNaN(size)) {
+						var body = xhr.response;
+						size = !body ? 0 : typeof body === 'string' ? body.length : body.byteLength || body.size || 0;
+					}
+					var entry = {type: 'xhr', method: info.method, url: xhr.responseURL || info.url,
+						status: xhr.status, duration: now() - start, size: size};
+					if (xhr.status === 0) entry.error = 'request failed or was aborted';
+					record(entry);
+				});
+			}
+			return sendXHR.apply(this, arguments);
+		};
+	}
+})(%q);`
+
+// handleMetrics returns a snapshot of the runtime metrics, either as a list of
+// samples or as Prometheus text wrapped in a JSON string.
+func (s *mcpSocketServer) handleMetrics(cmd mcpSocketCommand) mcpSocketResponse {
+	var result any
+	if cmd.Format == "prometheus" {
+		var buf bytes.Buffer
+		metrics.Default.WritePrometheus(&buf)
+		result = buf.String()
+	} else {
+		result = metrics.Default.Snapshot()
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return mcpSocketResponse{
+			ID:    cmd.ID,
+			Error: fmt.Sprintf("metrics: %v", err),
+		}
+	}
+	return mcpSocketResponse{
+		ID:     cmd.ID,
+		Result: json.RawMessage(data),
+	}
+}
+
+// handleStore reads persisted store entries whose keys start with
+// cmd.Prefix through the app's own store.keys and store.get handlers, so
+// the agent sees exactly what lightshell.store returns to the page.
+func (s *mcpSocketServer) handleStore(cmd mcpSocketCommand) mcpSocketResponse {
+	fail := func(err error) mcpSocketResponse {
+		return mcpSocketResponse{ID: cmd.ID, Error: fmt.Sprintf("store: %v", err)}
+	}
+
+	var keys []string
+	if err := s.callIPC("store.keys", map[string]any{"prefix": cmd.Prefix}, &keys); err != nil {
+		return fail(err)
+	}
+	sort.Strings(keys)
+	total := len(keys)
+	if cmd.Limit > 0 && len(keys) > cmd.Limit {
+		keys = keys[:cmd.Limit]
+	}
+
+	entries := make([]map[string]any, 0, len(keys))
+	for _, key := range keys {
+		var value json.RawMessage
+		if err := s.callIPC("store.get", map[string]any{"key": key}, &value); err != nil {
+			return fail(err)
+		}
+		entries = append(entries, map[string]any{"key": key, "value": value})
+	}
+
+	data, err := json.Marshal(map[string]any{"entries": entries, "total": total})
+	if err != nil {
+		return fail(err)
+	}
+	return mcpSocketResponse{ID: cmd.ID, Result: json.RawMessage(data)}
+}
+
+// callIPC invokes an IPC handler as the page would and decodes its result.
+func (s *mcpSocketServer) callIPC(method string, params any, result any) error {
+	if s.router == nil {
+		return fmt.Errorf("IPC router not available")
+	}
+	p, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := json.Marshal(ipc.Request{ID: "mcp", Method: method, Params: p})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(s.router.HandleMessage(string(req))), &resp); err != nil {
+		return err
+	}
+	if resp.Error == "unknown method: "+method {
+		return fmt.Errorf("this app has no lightshell.store backend (%s is not registered)", method)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	return json.Unmarshal(resp.Result, result)
+}
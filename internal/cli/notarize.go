@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// NotarizationInfo records the notarization state of a macOS artifact.
+// It is included in the release manifest so clients can sanity-check that
+// the artifact they download was notarized and signed by the expected team.
+type NotarizationInfo struct {
+	Stapled   bool   `json:"stapled"`
+	TeamID    string `json:"teamId,omitempty"`
+	CDHash    string `json:"cdhash,omitempty"`
+	CheckedAt string `json:"checkedAt"`
+}
+
+// isStapleable reports whether stapler can attach a ticket to this artifact type.
+func isStapleable(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".app", ".dmg", ".pkg":
+		return true
+	}
+	return false
+}
+
+// verifyNotarization checks that a macOS artifact has a valid stapled
+// notarization ticket. If wait is non-zero and the ticket is not yet stapled,
+// it polls Apple by retrying `stapler staple` until the ticket is available
+// or wait expires (notarization typically completes within a few minutes).
+func verifyNotarization(w io.Writer, path string, wait time.Duration) (*NotarizationInfo, error) {
+	if _, err := exec.LookPath("xcrun"); err != nil {
+		return nil, fmt.Errorf("xcrun not found: notarization can only be verified on macOS")
+	}
+
+	if err := staplerValidate(path); err != nil {
+		if wait <= 0 {
+			return nil, err
+		}
+		if err := pollStaple(w, path, wait); err != nil {
+			return nil, err
+		}
+	}
+
+	info := &NotarizationInfo{
+		Stapled:   true,
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	info.TeamID, info.CDHash = codesignIdentity(path)
+	return info, nil
+}
+
+// staplerValidate runs `xcrun stapler validate` on the artifact.
+func staplerValidate(path string) error {
+	out, err := exec.Command("xcrun", "stapler", "validate", path).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("no valid notarization ticket stapled to %s:\n%s", filepath.Base(path), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// pollStaple retries stapling until Apple's notary service has a ticket for
+// the artifact, backing off between attempts.
+func pollStaple(w io.Writer, path string, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	delay := 15 * time.Second
+	var lastOut string
+	for {
+		fmt.Fprintf(w, "Waiting for notarization ticket for %s...\n", filepath.Base(path))
+		out, err := exec.Command("xcrun", "stapler", "staple", path).CombinedOutput()
+		if err == nil {
+			return staplerValidate(path)
+		}
+		lastOut = strings.TrimSpace(string(out))
+
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("notarization ticket not available for %s after %s:\n%s", filepath.Base(path), wait, lastOut)
+		}
+		time.Sleep(delay)
+		if delay < time.Minute {
+			delay *= 2
+		}
+	}
+}
+
+// codesignIdentity extracts the team identifier and code directory hash from
+// the artifact's code signature. Missing values are returned as empty strings.
+func codesignIdentity(path string) (teamID, cdhash string) {
+	// codesign writes its details to stderr
+	out, _ := exec.Command("codesign", "-dv", "--verbose=4", path).CombinedOutput()
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "TeamIdentifier":
+			if value != "not set" {
+				teamID = value
+			}
+		case "CDHash":
+			cdhash = value
+		}
+	}
+	return teamID, cdhash
+}
+
+// notarizeTimeout bounds how long build --notarize waits for Apple's
+// verdict. Most submissions finish in a few minutes.
+const notarizeTimeout = 30 * time.Minute
+
+// notaryCredentials returns the notarytool flags that authenticate the
+// submission: build.mac.notaryProfile, a profile saved with `xcrun notarytool
+// store-credentials`, or else an Apple ID with an app-specific password.
+func notaryCredentials(mac lsruntime.MacConfig) ([]string, error) {
+	if mac.NotaryProfile != "" {
+		return []string{"--keychain-profile", mac.NotaryProfile}, nil
+	}
+	appleID := firstNonEmpty(mac.AppleID, os.Getenv("APPLE_ID"))
+	team := firstNonEmpty(mac.TeamID, os.Getenv("APPLE_TEAM_ID"), teamFromIdentity(mac.Identity))
+	password := os.Getenv("APPLE_APP_SPECIFIC_PASSWORD")
+	switch {
+	case appleID == "":
+		return nil, fmt.Errorf("--notarize needs credentials: set build.mac.notaryProfile to a profile saved with `xcrun notarytool store-credentials`, or build.mac.appleId (or APPLE_ID) with APPLE_APP_SPECIFIC_PASSWORD")
+	case password == "":
+		return nil, fmt.Errorf("--notarize needs the app-specific password for %s in APPLE_APP_SPECIFIC_PASSWORD", appleID)
+	case team == "":
+		return nil, fmt.Errorf("--notarize needs a team ID: set build.mac.teamId or APPLE_TEAM_ID")
+	}
+	return []string{"--apple-id", appleID, "--team-id", team, "--password", password}, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// notarize submits a signed .app or .dmg to Apple's notary service, waits
+// for the verdict, and staples the ticket to it, so it opens without a
+// network check. An app bundle is submitted as a zip archive.
+func notarize(w io.Writer, path string, mac lsruntime.MacConfig) error {
+	creds, err := notaryCredentials(mac)
+	if err != nil {
+		return err
+	}
+	upload := path
+	if strings.EqualFold(filepath.Ext(path), ".app") {
+		tmp, err := os.MkdirTemp("", "lightshell-notarize-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		upload = filepath.Join(tmp, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))+".zip")
+		if err := runTool("ditto", "-c", "-k", "--keepParent", path, upload); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "Submitting %s for notarization...\n", filepath.Base(path))
+	var submission struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	}
+	if err := notarytool(&submission, append([]string{"submit", upload}, creds...)...); err != nil {
+		return err
+	}
+	if submission.ID == "" {
+		return fmt.Errorf("notarytool returned no submission ID: %s", submission.Message)
+	}
+
+	status, err := waitForNotary(w, submission.ID, creds)
+	if err != nil {
+		return err
+	}
+	if status != "Accepted" {
+		return fmt.Errorf("notarization of %s was %s (submission %s):\n%s", filepath.Base(path), strings.ToLower(status), submission.ID, notaryIssues(submission.ID, creds))
+	}
+	fmt.Fprintf(w, "Notarized %s (submission %s)\n", filepath.Base(path), submission.ID)
+
+	if err := runTool("xcrun", "stapler", "staple", path); err != nil {
+		return err
+	}
+	if err := staplerValidate(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Stapled the notarization ticket to %s\n", filepath.Base(path))
+	return nil
+}
+
+// waitForNotary polls a submission until it leaves "In Progress" and
+// returns its final status: Accepted, Invalid or Rejected.
+func waitForNotary(w io.Writer, id string, creds []string) (string, error) {
+	deadline := time.Now().Add(notarizeTimeout)
+	delay := 10 * time.Second
+	for {
+		var info struct {
+			Status string `json:"status"`
+		}
+		if err := notarytool(&info, append([]string{"info", id}, creds...)...); err != nil {
+			return "", err
+		}
+		if info.Status != "In Progress" {
+			return info.Status, nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return "", fmt.Errorf("notarization still in progress after %s; check it with `xcrun notarytool info %s`, then staple with `xcrun stapler staple`", notarizeTimeout, id)
+		}
+		fmt.Fprintln(w, "Waiting for Apple to finish notarizing...")
+		time.Sleep(delay)
+		if delay < time.Minute {
+			delay *= 2
+		}
+	}
+}
+
+// notaryIssues summarizes why a submission was rejected, from its log.
+func notaryIssues(id string, creds []string) string {
+	var log struct {
+		StatusSummary string `json:"statusSummary"`
+		Issues        []struct {
+			Path    string `json:"path"`
+			Message string `json:"message"`
+		} `json:"issues"`
+	}
+	if err := notarytool(&log, append([]string{"log", id}, creds...)...); err != nil {
+		return fmt.Sprintf("could not fetch the log: %v", err)
+	}
+	lines := []string{log.StatusSummary}
+	for _, issue := range log.Issues {
+		lines = append(lines, fmt.Sprintf("  %s: %s", issue.Path, issue.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// notarytool runs an `xcrun notarytool` subcommand and decodes its JSON
+// output into v.
+func notarytool(v any, args ...string) error {
+	args = append([]string{"notarytool"}, args...)
+	out, err := exec.Command("xcrun", append(args, "--output-format", "json")...).Output()
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return fmt.Errorf("notarytool %s failed: %w\n%s", args[1], err, strings.TrimSpace(string(exit.Stderr)+"\n"+string(out)))
+		}
+		return fmt.Errorf("notarytool %s failed: %w", args[1], err)
+	}
+	if err := json.Unmarshal(out, v); err != nil {
+		return fmt.Errorf("unexpected notarytool output: %w", err)
+	}
+	return nil
+}
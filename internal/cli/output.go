@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+)
+
+// jsonFlag reports whether args ask for --json, and returns them without
+// it. Arguments after -- belong to the app, not the command.
+func jsonFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	found := false
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if arg == "--json" {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// JSONRequested reports whether a command's arguments ask for --json, or
+// --format json, in which case its errors are printed with PrintErrorJSON.
+func JSONRequested(args []string) bool {
+	if _, ok := jsonFlag(args); ok {
+		return true
+	}
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--format" && i+1 < len(args) && args[i+1] == "json" {
+			return true
+		}
+	}
+	return false
+}
+
+// runJSON runs a command for --json. The command writes its progress to
+// the writer it is given, stderr, so stdout carries only the JSON document
+// of its result.
+func runJSON(run func(w io.Writer) (any, error)) error {
+	result, err := run(os.Stderr)
+	if err != nil {
+		return err
+	}
+	return printJSON(result)
+}
+
+// printJSON writes v to stdout, indented. Results hold paths, code, and
+// commands, so & and < are left readable.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// jsonError is the document a command run with --json prints when it fails.
+type jsonError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Fix     string `json:"fix,omitempty"`
+		Docs    string `json:"docs,omitempty"`
+	} `json:"error"`
+}
+
+// PrintErrorJSON prints err to stdout as a JSON document with its error
+// code, which also decides the exit status.
+func PrintErrorJSON(err error) {
+	var doc jsonError
+	doc.Error.Code = errorCode(err)
+	doc.Error.Message = err.Error()
+	var lsErr *lserrors.LightShellError
+	if errors.As(err, &lsErr) {
+		doc.Error.Message = lsErr.Message
+		if lsErr.Cause != nil {
+			doc.Error.Message += ": " + lsErr.Cause.Error()
+		}
+		doc.Error.Fix = lsErr.Fix
+		doc.Error.Docs = lsErr.DocsURL
+	}
+	printJSON(doc)
+}
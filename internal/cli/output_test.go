@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestRunJSONWritesProgressToStderr(t *testing.T) {
+	r, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = pw
+	defer func() { os.Stdout = stdout }()
+
+	var progress io.Writer
+	err = runJSON(func(w io.Writer) (any, error) {
+		progress = w
+		return map[string]string{"name": "app"}, nil
+	})
+	pw.Close()
+	out, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress != os.Stderr {
+		t.Errorf("progress writer = %v, want stderr", progress)
+	}
+	var doc map[string]string
+	if err := json.Unmarshal(out, &doc); err != nil || doc["name"] != "app" {
+		t.Errorf("stdout = %q, want only the JSON result", out)
+	}
+}
+
+func TestRunHookWritesToWriter(t *testing.T) {
+	command, newline := "echo $VERSION", "\n"
+	if runtime.GOOS == "windows" {
+		command, newline = "echo %VERSION%", "\r\n"
+	}
+	var out bytes.Buffer
+	if err := runHook(&out, "preBuild", command, t.TempDir(), hookEnv{Version: "1.2.3"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Running preBuild hook: " + command + "\n1.2.3" + newline; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
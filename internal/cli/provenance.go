@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/bundle"
+	"github.com/lightshell-dev/lightshell/internal/fsutil"
+	"github.com/lightshell-dev/lightshell/internal/provenance"
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// provenancePath is where the provenance of an artifact is written: next
+// to it, with the extension SLSA tooling looks for.
+func provenancePath(artifact string) string {
+	return artifact + ".intoto.jsonl"
+}
+
+// provenanceRecord holds what Build learns about a build as it runs.
+type provenanceRecord struct {
+	started  time.Time
+	platform string
+	sources  string // SHA-256 of the staged pages, as embedded
+}
+
+// writeProvenance records how artifact was built and signs the record with
+// the release signing key. Without a key it is written unsigned, and
+// lightshell release will not accept it.
+func writeProvenance(w io.Writer, dir, artifact string, cfg lsruntime.Config, rec provenanceRecord) error {
+	digest, err := computeSHA256(artifact)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s for its provenance: %w", filepath.Base(artifact), err)
+	}
+
+	deps := []provenance.ResourceDescriptor{{Name: "src", Digest: provenance.Digest{"sha256": rec.sources}}}
+	if data, err := os.ReadFile(filepath.Join(dir, "lightshell.json")); err == nil {
+		sum := sha256.Sum256(data)
+		deps = append(deps, provenance.ResourceDescriptor{Name: "lightshell.json", Digest: provenance.Digest{"sha256": hex.EncodeToString(sum[:])}})
+	}
+	internal := map[string]any{
+		"lightshell": lsruntime.Version,
+		"go":         goVersion(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+	}
+	if commit, remote, dirty, ok := gitState(dir); ok {
+		deps = append(deps, provenance.ResourceDescriptor{URI: remote, Digest: provenance.Digest{"gitCommit": commit}})
+		if dirty {
+			internal["gitDirty"] = true
+		}
+	}
+
+	builder, invocation := builderIdentity()
+	stmt := provenance.Statement{
+		Type:          provenance.StatementType,
+		Subject:       []provenance.ResourceDescriptor{{Name: artifactFileName(artifact), Digest: provenance.Digest{"sha256": digest}}},
+		PredicateType: provenance.PredicateType,
+		Predicate: provenance.Predicate{
+			BuildDefinition: provenance.BuildDefinition{
+				BuildType: provenance.BuildType,
+				ExternalParameters: map[string]any{
+					"name":     cfg.Name,
+					"version":  cfg.Version,
+					"appId":    cfg.Build.AppID,
+					"platform": rec.platform,
+				},
+				InternalParameters:   internal,
+				ResolvedDependencies: deps,
+			},
+			RunDetails: provenance.RunDetails{
+				Builder: provenance.Builder{ID: builder},
+				Metadata: provenance.BuildMetadata{
+					InvocationID: invocation,
+					StartedOn:    rec.started.UTC().Truncate(time.Second),
+					FinishedOn:   time.Now().UTC().Truncate(time.Second),
+				},
+			},
+		},
+	}
+
+	privKey, keyErr := loadPrivateKey() // nil without a key
+	env, err := provenance.Seal(stmt, privKey)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	path := provenancePath(artifact)
+	if err := fsutil.WriteFileAtomic(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write provenance: %w", err)
+	}
+	if keyErr != nil {
+		fmt.Fprintf(w, "Provenance: %s (unsigned: no signing key; run `lightshell keys generate`)\n", path)
+	} else {
+		fmt.Fprintf(w, "Provenance: %s\n", path)
+	}
+	return nil
+}
+
+// verifyProvenance checks that artifact's provenance is signed with
+// pubKey and records digest, the artifact's hash now. It returns the
+// verified statement.
+func verifyProvenance(artifact, digest string, pubKey ed25519.PublicKey) (*provenance.Statement, error) {
+	path := provenancePath(artifact)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no provenance found at %s\n\nRebuild with `lightshell build`, which writes it, or pass --skip-provenance", path)
+	}
+	var env provenance.Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid provenance at %s: %w", path, err)
+	}
+	stmt, err := provenance.Verify(env, pubKey)
+	if errors.Is(err, provenance.ErrUnsigned) {
+		return nil, fmt.Errorf("provenance at %s is unsigned because the build had no signing key\n\nRebuild now that the key exists, or pass --skip-provenance", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid provenance at %s: %w", path, err)
+	}
+	recorded, ok := stmt.SubjectDigest(artifactFileName(artifact))
+	if !ok {
+		return nil, fmt.Errorf("provenance at %s does not describe %s", path, artifactFileName(artifact))
+	}
+	if recorded != digest {
+		return nil, fmt.Errorf("%s changed after it was built (SHA-256 %s, provenance records %s)\n\nChanges such as code signing or stapling belong in the postBuild hook, which runs before the provenance is written. Rebuild, or pass --skip-provenance", filepath.Base(artifact), digest, recorded)
+	}
+	return stmt, nil
+}
+
+// stagedSourcesDigest hashes the pages a build embeds.
+func stagedSourcesDigest(stagingSrc string) (string, error) {
+	return bundle.Hash(stagingSrc)
+}
+
+// builderIdentity returns an ID for what is running the build, a CI run
+// when there is one, and the CI's ID for this invocation.
+func builderIdentity() (id, invocation string) {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		server := os.Getenv("GITHUB_SERVER_URL")
+		if server == "" {
+			server = "https://github.com"
+		}
+		run := os.Getenv("GITHUB_RUN_ID")
+		if attempt := os.Getenv("GITHUB_RUN_ATTEMPT"); attempt != "" {
+			run += "/attempts/" + attempt
+		}
+		return fmt.Sprintf("%s/%s/actions/runs/%s", server, os.Getenv("GITHUB_REPOSITORY"), run), os.Getenv("GITHUB_RUN_ID")
+	}
+	if job := os.Getenv("CI_JOB_URL"); job != "" { // GitLab
+		return job, os.Getenv("CI_JOB_ID")
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return "https://lightshell.dev/provenance/builder/local#" + host, ""
+}
+
+// goVersion returns the version of the go command that compiles apps.
+func goVersion() string {
+	out, err := exec.Command("go", "env", "GOVERSION").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// gitState returns the commit dir is checked out at, its origin as a
+// git+ URI, and whether there are uncommitted changes. ok is false outside
+// a git repository.
+func gitState(dir string) (commit, remote string, dirty, ok bool) {
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit = git("rev-parse", "HEAD")
+	if commit == "" {
+		return "", "", false, false
+	}
+	remote = git("remote", "get-url", "origin")
+	if u, err := url.Parse(remote); err == nil && u.User != nil {
+		u.User = nil // a token in an HTTPS remote
+		remote = u.String()
+	}
+	if remote == "" {
+		remote = "file://" + filepath.ToSlash(dir)
+	}
+	return commit, "git+" + remote, git("status", "--porcelain") != "", true
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package cli
+
+import "errors"
+
+// makeRaw is only implemented for Linux and macOS terminals; elsewhere the
+// console reads whole lines, which the Windows console edits itself.
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
@@ -17,7 +17,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lightshell-dev/lightshell/internal/bundle"
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
 	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/security"
+	"github.com/lightshell-dev/lightshell/internal/semver"
 )
 
 // ReleaseFlags holds the parsed flags for the release command.
@@ -30,23 +34,56 @@ type ReleaseFlags struct {
 	NoBuild   bool   // skip the build step, use existing dist/
 	Server    string // release server URL (overrides config)
 	Token     string // auth token (overrides config)
+
+	SkipVersionCheck bool // publish even if the channel already has this version or newer
+
+	AllowUnnotarized bool          // publish macOS artifacts without a stapled notarization ticket
+	NotarizationWait time.Duration // how long to poll for a pending notarization ticket
+
+	Formula        bool // also write a Homebrew cask or apt indexes for the artifact
+	SkipProvenance bool // publish without verifying the build's provenance
+	SBOM           bool // upload the build's SBOM and reference it in the manifest
+	JSON           bool // print the release as JSON
+}
+
+// releaseResult is what release --json prints.
+type releaseResult struct {
+	Name               string          `json:"name"`
+	Version            string          `json:"version"`
+	Platform           string          `json:"platform"`
+	Artifact           string          `json:"artifact"`
+	SHA256             string          `json:"sha256"`
+	ProvenanceVerified bool            `json:"provenanceVerified"`
+	Manifest           ReleaseManifest `json:"manifest"`
+	ManifestPath       string          `json:"manifestPath,omitempty"` // where a dry run wrote the manifest
+	PackageMetadata    []string        `json:"packageMetadata,omitempty"`
+	Published          bool            `json:"published"`
+	Server             string          `json:"server,omitempty"`
 }
 
 // Release handles the `lightshell release` command.
 func Release(args []string) error {
 	flags, err := parseReleaseFlags(args)
 	if err != nil {
-		return err
+		return usageError(err)
 	}
+	if flags.JSON {
+		return runJSON(func(w io.Writer) (any, error) { return release(w, flags) })
+	}
+	_, err = release(os.Stdout, flags)
+	return err
+}
 
+// release builds, signs, and publishes the app's release for one platform.
+func release(w io.Writer, flags ReleaseFlags) (releaseResult, error) {
 	dir, err := os.Getwd()
 	if err != nil {
-		return err
+		return releaseResult{}, err
 	}
 
-	cfg, err := lsruntime.LoadConfig(dir)
+	cfg, err := loadConfig(dir)
 	if err != nil {
-		return err
+		return releaseResult{}, err
 	}
 
 	// Resolve server and token from flags or config
@@ -60,8 +97,11 @@ func Release(args []string) error {
 		token = loadConfigValue("releaseToken")
 	}
 
+	if server == "" && flags.Formula {
+		return releaseResult{}, fmt.Errorf("--formula needs a release server to point the package metadata at\n\nSet one with:\n  lightshell config set releaseServer https://releases.example.com\n\nOr pass --server on the command line")
+	}
 	if server == "" && !flags.DryRun {
-		return fmt.Errorf("no release server configured\n\nSet one with:\n  lightshell config set releaseServer https://releases.example.com\n\nOr pass --server on the command line")
+		return releaseResult{}, withCode(lserrors.ReleaseFailed, fmt.Errorf("no release server configured\n\nSet one with:\n  lightshell config set releaseServer https://releases.example.com\n\nOr pass --server on the command line"))
 	}
 
 	// Determine platform
@@ -73,36 +113,80 @@ func Release(args []string) error {
 	// Normalize platform names
 	platform = normalizePlatform(platform)
 
+	client, err := releaseClient(dir, cfg.Security.TLS)
+	if err != nil {
+		return releaseResult{}, err
+	}
+
+	// Refuse to publish a version that isn't newer than the channel's latest
+	if server != "" && !flags.SkipVersionCheck {
+		if err := checkVersionIsNewer(w, client, server, cfg.Version); err != nil {
+			return releaseResult{}, withCode(lserrors.ReleaseFailed, err)
+		}
+	}
+
+	// Another platform's build goes to dist/<platform>, where build
+	// --platform puts it
+	distDir := filepath.Join(dir, "dist")
+	buildFlags := BuildFlags{Target: "default"}
+	if platform != hostPlatform().String() {
+		buildFlags.Platforms = platform
+		if info, err := os.Stat(filepath.Join(distDir, platform)); !flags.NoBuild || err == nil && info.IsDir() {
+			distDir = filepath.Join(distDir, platform)
+		}
+	}
+
 	// Build if needed
 	if !flags.NoBuild {
-		fmt.Println("Building...")
-		if err := Build(); err != nil {
-			return fmt.Errorf("build failed: %w", err)
+		fmt.Fprintln(w, "Building...")
+		if _, err := build(w, buildFlags); err != nil {
+			return releaseResult{}, fmt.Errorf("build failed: %w", err)
 		}
 	}
 
 	// Find the built artifact in dist/
-	distDir := filepath.Join(dir, "dist")
 	artifact, err := findArtifact(distDir, cfg.Name)
 	if err != nil {
-		return fmt.Errorf("could not find built artifact in dist/: %w\n\nRun `lightshell build` first, or use --no-build if you have a pre-built artifact", err)
+		return releaseResult{}, fmt.Errorf("could not find built artifact in dist/: %w\n\nRun `lightshell build` first, or use --no-build if you have a pre-built artifact", err)
+	}
+
+	fmt.Fprintf(w, "Artifact: %s\n", artifact)
+	result := releaseResult{Name: cfg.Name, Version: cfg.Version, Platform: platform, Artifact: artifact, Server: server}
+
+	hookVars := hookEnv{OutputPath: artifact, Version: cfg.Version, Platform: platform}
+	if err := runHook(w, "preRelease", cfg.Hooks.PreRelease, dir, hookVars); err != nil {
+		return releaseResult{}, err
 	}
 
-	fmt.Printf("Artifact: %s\n", artifact)
+	// macOS artifacts must be notarized and stapled before they are published.
+	// This runs before hashing because stapling modifies the artifact.
+	var notarization *NotarizationInfo
+	if strings.HasPrefix(platform, "darwin") && !flags.AllowUnnotarized {
+		if isStapleable(artifact) {
+			notarization, err = verifyNotarization(w, artifact, flags.NotarizationWait)
+			if err != nil {
+				return releaseResult{}, withCode(lserrors.SigningFailed, fmt.Errorf("refusing to publish an unnotarized macOS artifact: %w\n\nNotarize with `lightshell build --sign --notarize`, wait for a pending ticket with --notarization-wait 10m, or pass --allow-unnotarized to publish anyway", err))
+			}
+			fmt.Fprintf(w, "Notarization: stapled ticket verified (team %s)\n", notarization.TeamID)
+		} else {
+			fmt.Fprintf(w, "Warning: cannot verify notarization for %s; only .app, .dmg and .pkg artifacts can carry a stapled ticket\n", filepath.Base(artifact))
+		}
+	}
 
 	// Compute SHA256
 	hash, err := computeSHA256(artifact)
 	if err != nil {
-		return fmt.Errorf("failed to compute hash: %w", err)
+		return releaseResult{}, fmt.Errorf("failed to compute hash: %w", err)
 	}
-	fmt.Printf("SHA256: %s\n", hash)
+	fmt.Fprintf(w, "SHA256: %s\n", hash)
+	result.SHA256 = hash
 
 	// Resolve release notes
 	notes := flags.Notes
 	if notes == "" && flags.NotesFile != "" {
 		data, err := os.ReadFile(flags.NotesFile)
 		if err != nil {
-			return fmt.Errorf("could not read notes file: %w", err)
+			return releaseResult{}, fmt.Errorf("could not read notes file: %w", err)
 		}
 		notes = string(data)
 	}
@@ -113,13 +197,36 @@ func Release(args []string) error {
 	// Load signing key
 	privKey, err := loadPrivateKey()
 	if err != nil {
-		return err
+		return releaseResult{}, err
+	}
+
+	// The artifact must be the one the build attested to
+	var attachments []releaseAttachment
+	if !flags.SkipProvenance {
+		stmt, err := verifyProvenance(artifact, hash, privKey.Public().(ed25519.PublicKey))
+		if err != nil {
+			return releaseResult{}, withCode(lserrors.ReleaseFailed, fmt.Errorf("refusing to publish: %w", err))
+		}
+		attachments = append(attachments, releaseAttachment{field: "provenance", path: provenancePath(artifact)})
+		fmt.Fprintf(w, "Provenance: verified, built by %s\n", stmt.Predicate.RunDetails.Builder.ID)
+		result.ProvenanceVerified = true
 	}
 
 	// Create release manifest — set URL before signing
-	platformArtifact := PlatformArtifact{SHA256: hash}
+	platformArtifact := PlatformArtifact{SHA256: hash, Notarization: notarization}
 	if server != "" {
-		platformArtifact.URL = fmt.Sprintf("%s/releases/v%s/%s", strings.TrimSuffix(server, "/"), cfg.Version, filepath.Base(artifact))
+		platformArtifact.URL = fmt.Sprintf("%s/releases/v%s/%s", strings.TrimSuffix(server, "/"), cfg.Version, artifactFileName(artifact))
+	}
+	if flags.SBOM {
+		sbomHash, err := computeSHA256(sbomPath(artifact))
+		if err != nil {
+			return releaseResult{}, fmt.Errorf("no SBOM found at %s: %w\n\nRebuild with `lightshell build`, which writes it", sbomPath(artifact), err)
+		}
+		attachments = append(attachments, releaseAttachment{field: "sbom", path: sbomPath(artifact)})
+		platformArtifact.SBOM = &SBOMInfo{SHA256: sbomHash}
+		if server != "" {
+			platformArtifact.SBOM.URL = fmt.Sprintf("%s/releases/v%s/%s", strings.TrimSuffix(server, "/"), cfg.Version, artifactFileName(artifact)+".cdx.json")
+		}
 	}
 
 	manifest := ReleaseManifest{
@@ -135,36 +242,50 @@ func Release(args []string) error {
 	// Sign the manifest (after all fields are set, excluding Signature itself)
 	manifestJSON, err := json.Marshal(manifest)
 	if err != nil {
-		return fmt.Errorf("failed to serialize manifest: %w", err)
+		return releaseResult{}, fmt.Errorf("failed to serialize manifest: %w", err)
 	}
 
 	signature := ed25519.Sign(privKey, manifestJSON)
 	manifest.Signature = base64.StdEncoding.EncodeToString(signature)
+	result.Manifest = manifest
 
 	// Print manifest
 	manifestOut, _ := json.MarshalIndent(manifest, "", "  ")
-	fmt.Printf("\nRelease manifest:\n%s\n\n", string(manifestOut))
+	fmt.Fprintf(w, "\nRelease manifest:\n%s\n\n", string(manifestOut))
+
+	if flags.Formula {
+		files, err := writePackageMetadata(filepath.Join(distDir, "packages"), server, cfg.Name, cfg.Version, artifact, platformArtifact)
+		if err != nil {
+			return releaseResult{}, err
+		}
+		for _, f := range files {
+			fmt.Fprintf(w, "Package metadata written to: %s\n", f)
+		}
+		result.PackageMetadata = files
+	}
 
 	if flags.DryRun {
-		fmt.Println("Dry run — skipping upload")
+		fmt.Fprintln(w, "Dry run — skipping upload")
 
 		// Write manifest to dist/ for inspection
 		manifestPath := filepath.Join(distDir, "latest.json")
 		if err := os.WriteFile(manifestPath, append(manifestOut, '\n'), 0o644); err != nil {
-			return fmt.Errorf("failed to write manifest: %w", err)
+			return releaseResult{}, fmt.Errorf("failed to write manifest: %w", err)
 		}
-		fmt.Printf("Manifest written to: %s\n", manifestPath)
-		return nil
+		fmt.Fprintf(w, "Manifest written to: %s\n", manifestPath)
+		result.ManifestPath = manifestPath
+		return result, nil
 	}
 
 	// Upload to server
-	fmt.Printf("Uploading to %s...\n", server)
-	if err := uploadRelease(server, token, artifact, manifest); err != nil {
-		return fmt.Errorf("upload failed: %w", err)
+	fmt.Fprintf(w, "Uploading to %s...\n", server)
+	if err := uploadRelease(client, server, token, artifact, attachments, manifest); err != nil {
+		return releaseResult{}, withCode(lserrors.UploadFailed, fmt.Errorf("upload failed: %w", err))
 	}
 
-	fmt.Printf("Released %s v%s for %s\n", cfg.Name, cfg.Version, platform)
-	return nil
+	fmt.Fprintf(w, "Released %s v%s for %s\n", cfg.Name, cfg.Version, platform)
+	result.Published = true
+	return result, runHook(w, "postRelease", cfg.Hooks.PostRelease, dir, hookVars)
 }
 
 // ReleaseManifest is the JSON manifest published for the auto-updater.
@@ -179,6 +300,14 @@ type ReleaseManifest struct {
 
 // PlatformArtifact describes a platform-specific release artifact.
 type PlatformArtifact struct {
+	URL          string            `json:"url"`
+	SHA256       string            `json:"sha256"`
+	Notarization *NotarizationInfo `json:"notarization,omitempty"`
+	SBOM         *SBOMInfo         `json:"sbom,omitempty"`
+}
+
+// SBOMInfo locates the CycloneDX SBOM published with an artifact.
+type SBOMInfo struct {
 	URL    string `json:"url"`
 	SHA256 string `json:"sha256"`
 }
@@ -212,6 +341,28 @@ func parseReleaseFlags(args []string) (ReleaseFlags, error) {
 			flags.DryRun = true
 		case "--no-build":
 			flags.NoBuild = true
+		case "--skip-version-check":
+			flags.SkipVersionCheck = true
+		case "--allow-unnotarized":
+			flags.AllowUnnotarized = true
+		case "--notarization-wait":
+			if i+1 >= len(args) {
+				return flags, fmt.Errorf("--notarization-wait requires a duration (e.g. 10m)")
+			}
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil {
+				return flags, fmt.Errorf("invalid --notarization-wait %q: %w", args[i], err)
+			}
+			flags.NotarizationWait = d
+		case "--formula":
+			flags.Formula = true
+		case "--skip-provenance":
+			flags.SkipProvenance = true
+		case "--sbom":
+			flags.SBOM = true
+		case "--json":
+			flags.JSON = true
 		case "--server":
 			if i+1 >= len(args) {
 				return flags, fmt.Errorf("--server requires a value")
@@ -225,13 +376,50 @@ func parseReleaseFlags(args []string) (ReleaseFlags, error) {
 			i++
 			flags.Token = args[i]
 		default:
-			return flags, fmt.Errorf("unknown flag: %s\n\nUsage: lightshell release [--platform darwin-arm64] [--notes \"...\"] [--notes-file NOTES.md] [--draft] [--dry-run] [--no-build] [--server URL] [--token TOKEN]", args[i])
+			return flags, fmt.Errorf("unknown flag: %s\n\nUsage: lightshell release [--platform darwin-arm64] [--notes \"...\"] [--notes-file NOTES.md] [--draft] [--dry-run] [--no-build] [--server URL] [--token TOKEN] [--skip-version-check] [--allow-unnotarized] [--notarization-wait 10m] [--formula] [--skip-provenance] [--sbom] [--json]", args[i])
 		}
 	}
 
 	return flags, nil
 }
 
+// releaseClient returns the HTTP client for release server requests. It
+// honors the configured proxy and the security.tls CA bundles and pins in
+// lightshell.json, since the release server is also the updater endpoint.
+func releaseClient(dir string, tlsCfg lsruntime.TLSConfig) (*http.Client, error) {
+	conf, err := security.NewTLSConfig(dir, tlsCfg.CAFiles, tlsCfg.Pins)
+	if err != nil {
+		return nil, err
+	}
+	client := proxySettings().Client(5 * time.Minute)
+	client.Transport.(*http.Transport).TLSClientConfig = conf
+	return client, nil
+}
+
+// checkVersionIsNewer verifies that version is newer than the latest release
+// published on the server.
+func checkVersionIsNewer(w io.Writer, client *http.Client, server, version string) error {
+	if _, err := semver.Parse(version); err != nil {
+		return fmt.Errorf("lightshell.json %w", err)
+	}
+	latest, err := fetchLatestVersion(client, server)
+	if err != nil {
+		return fmt.Errorf("could not check the latest published version: %w\n\nPass --skip-version-check to publish anyway", err)
+	}
+	if latest == "" {
+		return nil
+	}
+	cmp, err := semver.Compare(version, latest)
+	if err != nil {
+		return fmt.Errorf("could not compare against published version %q: %w", latest, err)
+	}
+	if cmp <= 0 {
+		return fmt.Errorf("version %s is not newer than the published version %s\n\nBump it with:\n  lightshell version bump patch\n\nOr pass --skip-version-check to publish anyway", version, latest)
+	}
+	fmt.Fprintf(w, "Version check: %s > %s (published)\n", version, latest)
+	return nil
+}
+
 func normalizePlatform(platform string) string {
 	// Normalize common aliases
 	replacer := strings.NewReplacer(
@@ -248,10 +436,10 @@ func findArtifact(distDir, appName string) (string, error) {
 		filepath.Join(distDir, appName+".tar.gz"),
 		filepath.Join(distDir, appName+".zip"),
 		filepath.Join(distDir, "*.app"),
-		filepath.Join(distDir, appName+"*.dmg"),
-		filepath.Join(distDir, appName+"*.deb"),
-		filepath.Join(distDir, appName+"*.rpm"),
-		filepath.Join(distDir, appName+"*.AppImage"),
+		filepath.Join(distDir, "*.dmg"),
+		filepath.Join(distDir, caskToken(appName)+"_*.deb"),
+		filepath.Join(distDir, caskToken(appName)+"-*.rpm"),
+		filepath.Join(distDir, caskToken(appName)+"-*.AppImage"),
 	}
 
 	for _, pattern := range patterns {
@@ -278,6 +466,15 @@ func findArtifact(distDir, appName string) (string, error) {
 	return "", fmt.Errorf("no artifact found")
 }
 
+// artifactFileName returns the uploaded file name for an artifact. Directory
+// artifacts are uploaded as their deterministic tar stream (see internal/bundle).
+func artifactFileName(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Base(path) + ".tar"
+	}
+	return filepath.Base(path)
+}
+
 func computeSHA256(path string) (string, error) {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -285,8 +482,9 @@ func computeSHA256(path string) (string, error) {
 	}
 
 	if info.IsDir() {
-		// For directories (like .app bundles), hash a tar of the contents
-		return computeDirSHA256(path)
+		// For directories (like .app bundles), hash the deterministic tar stream
+		// that is also what gets uploaded, so the signed hash covers the bundle.
+		return bundle.Hash(path)
 	}
 
 	f, err := os.Open(path)
@@ -302,34 +500,14 @@ func computeSHA256(path string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func computeDirSHA256(dir string) (string, error) {
-	h := sha256.New()
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		rel, _ := filepath.Rel(dir, path)
-		// Include path name and size in hash for determinism
-		fmt.Fprintf(h, "%s:%d:%d\n", rel, info.Size(), info.Mode())
-		if !info.IsDir() {
-			f, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-			if _, err := io.Copy(h, f); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-	if err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(h.Sum(nil)), nil
+// releaseAttachment is a file uploaded along with an artifact, named after
+// it: Notes.app.tar's provenance is Notes.app.tar.intoto.jsonl.
+type releaseAttachment struct {
+	field string // form field
+	path  string
 }
 
-func uploadRelease(server, token, artifactPath string, manifest ReleaseManifest) error {
+func uploadRelease(client *http.Client, server, token, artifactPath string, attachments []releaseAttachment, manifest ReleaseManifest) error {
 	// Create multipart request with manifest + artifact
 	var body bytes.Buffer
 	writer := multipart.NewWriter(&body)
@@ -350,10 +528,15 @@ func uploadRelease(server, token, artifactPath string, manifest ReleaseManifest)
 	}
 
 	if info.IsDir() {
-		// For directory artifacts (.app), skip file upload — server handles it differently
-		if err := writer.WriteField("artifact_name", filepath.Base(artifactPath)); err != nil {
+		// For directory artifacts (.app), upload the deterministic tar stream.
+		// Its SHA256 is the hash recorded in the signed manifest.
+		part, err := writer.CreateFormFile("artifact", artifactFileName(artifactPath))
+		if err != nil {
 			return err
 		}
+		if err := bundle.WriteTar(part, artifactPath); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", artifactPath, err)
+		}
 	} else {
 		part, err := writer.CreateFormFile("artifact", filepath.Base(artifactPath))
 		if err != nil {
@@ -369,6 +552,22 @@ func uploadRelease(server, token, artifactPath string, manifest ReleaseManifest)
 		}
 	}
 
+	// Add the build's provenance and SBOM, for users to audit the artifact
+	for _, a := range attachments {
+		data, err := os.ReadFile(a.path)
+		if err != nil {
+			return err
+		}
+		name := artifactFileName(artifactPath) + strings.TrimPrefix(filepath.Base(a.path), filepath.Base(artifactPath))
+		part, err := writer.CreateFormFile(a.field, name)
+		if err != nil {
+			return err
+		}
+		if _, err := part.Write(data); err != nil {
+			return err
+		}
+	}
+
 	if err := writer.Close(); err != nil {
 		return err
 	}
@@ -384,10 +583,9 @@ func uploadRelease(server, token, artifactPath string, manifest ReleaseManifest)
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
-	client := &http.Client{Timeout: 5 * time.Minute}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return fmt.Errorf("request failed: %w", security.WrapTLSError("release", "upload", err))
 	}
 	defer resp.Body.Close()
 
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+	"github.com/lightshell-dev/lightshell/internal/paths"
+)
+
+// replayMaxGap caps the pause between replayed commands, unless --realtime
+// keeps the recorded timing.
+const replayMaxGap = time.Second
+
+// Replay runs lightshell replay: it starts a fresh dev process of the
+// project and sends it the commands of a recorded session, in order,
+// reporting the responses that differ from the recording.
+func Replay(args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	var session string
+	realtime := false
+	for _, arg := range args {
+		switch {
+		case arg == "--realtime":
+			realtime = true
+		case strings.HasPrefix(arg, "-"):
+			return usageError(fmt.Errorf("unknown replay option %q", arg))
+		case session == "":
+			session = arg
+		default:
+			return usageError(fmt.Errorf("unexpected argument %q", arg))
+		}
+	}
+	if session == "" {
+		if names := listSessions(dir); len(names) > 0 {
+			return usageError(fmt.Errorf("usage: lightshell replay <session>; recorded sessions are %s", strings.Join(names, ", ")))
+		}
+		return usageError(fmt.Errorf("usage: lightshell replay <session>"))
+	}
+
+	if _, err := loadConfig(dir); err != nil {
+		return err
+	}
+	sessionDir, err := findSession(dir, session)
+	if err != nil {
+		return err
+	}
+	entries, err := readSession(sessionDir)
+	if err != nil {
+		return fmt.Errorf("could not read session: %w", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("session %s recorded no commands", sessionDir)
+	}
+
+	outDir := filepath.Join(sessionDir, "replay-"+time.Now().Format("2006-01-02T15-04-05"))
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("could not create replay directory: %w", err)
+	}
+	logPath := filepath.Join(outDir, "dev.log")
+
+	fmt.Printf("Replaying %d commands from %s\n", len(entries), sessionDir)
+	socket, stop, err := startReplayDev(dir, logPath)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	conn, err := dialSocket(socket)
+	if err != nil {
+		return fmt.Errorf("could not connect to the dev app: %w", err)
+	}
+	defer conn.Close()
+
+	differ, screenshots := 0, 0
+	var last int64
+	for i, entry := range entries {
+		gap := time.Duration(entry.At-last) * time.Millisecond
+		if !realtime {
+			gap = min(gap, replayMaxGap)
+		}
+		time.Sleep(gap)
+		last = entry.At
+
+		cmd := entry.Command
+		cmd.ID = 0
+		resp, err := conn.send(cmd)
+		if lostConnection(err) {
+			return fmt.Errorf("lost the connection to the dev app at command %d: %w; see %s", i+1, err, logPath)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if resp.Image != "" {
+			if data, err := base64.StdEncoding.DecodeString(resp.Image); err == nil {
+				if os.WriteFile(filepath.Join(outDir, fmt.Sprintf("%04d.png", i+1)), data, 0o644) == nil {
+					screenshots++
+				}
+			}
+		}
+
+		prefix := fmt.Sprintf("[%d/%d] %s", i+1, len(entries), describeCommand(cmd))
+		if diff := compareResponses(cmd, entry.Response, resp); diff != "" {
+			differ++
+			fmt.Printf("%s differs: %s\n", prefix, diff)
+		} else {
+			fmt.Printf("%s ok\n", prefix)
+		}
+	}
+
+	if screenshots > 0 {
+		fmt.Printf("Screenshots: recorded in %s, replayed in %s\n", sessionDir, outDir)
+	}
+	if differ > 0 {
+		fmt.Printf("%d of %d responses differ from the recording\n", differ, len(entries))
+		return reported(lserrors.CommandFailed, fmt.Errorf("%d of %d responses differ from the recording", differ, len(entries)))
+	}
+	fmt.Printf("All %d responses match the recording\n", len(entries))
+	return nil
+}
+
+// startReplayDev starts a dev process of dir with a control socket, its
+// output going to logPath, and returns the socket and a function that
+// stops the process.
+func startReplayDev(dir, logPath string) (string, func(), error) {
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not create dev log: %w", err)
+	}
+
+	// Clean up sockets left by replays that crashed
+	paths.RemoveStaleSockets("replay-*.sock")
+
+	socket, err := devSocketPath("replay")
+	if err != nil {
+		logFile.Close()
+		return "", nil, err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		logFile.Close()
+		return "", nil, fmt.Errorf("could not find lightshell binary: %w", err)
+	}
+	cmd := exec.Command(self, "dev", "--mcp-socket", socket)
+	cmd.Dir = dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		return "", nil, fmt.Errorf("failed to start dev process: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	stop := func() {
+		terminate(cmd.Process)
+		select {
+		case <-exited:
+		case <-time.After(5 * time.Second):
+			cmd.Process.Kill()
+			<-exited
+		}
+		logFile.Close()
+	}
+	if err := waitForDevSocket(cmd, socket, exited, logPath); err != nil {
+		logFile.Close()
+		return "", nil, err
+	}
+	return socket, stop, nil
+}
+
+// describeCommand returns a one-line summary of cmd for the replay output.
+func describeCommand(cmd mcpSocketCommand) string {
+	switch cmd.Cmd {
+	case "eval":
+		return "eval " + clip(strings.Join(strings.Fields(cmd.Code), " "), 60)
+	case "dom":
+		if cmd.Selector != "" {
+			return "dom " + cmd.Selector
+		}
+	}
+	return cmd.Cmd
+}
+
+// compareResponses returns how the response now differs from the one
+// recorded, was, in what the command cmd is about, or "" when they agree.
+// Timestamps, durations, and pixels are never compared; a screenshot is
+// compared by its size only.
+func compareResponses(cmd mcpSocketCommand, was, now mcpSocketResponse) string {
+	if was.Error != now.Error {
+		return fmt.Sprintf("error was %q, now %q", clip(was.Error, 80), clip(now.Error, 80))
+	}
+	if was.Error != "" {
+		return ""
+	}
+
+	switch cmd.Cmd {
+	case "eval", "store", "dom":
+		if a, b := compactJSON(was.Result), compactJSON(now.Result); a != b {
+			return fmt.Sprintf("result was %s, now %s", clip(a, 80), clip(b, 80))
+		}
+		if was.HTML != now.HTML {
+			return fmt.Sprintf("html was %s, now %s", clip(was.HTML, 80), clip(now.HTML, 80))
+		}
+	case "console":
+		a, b := consoleLines(was.Entries), consoleLines(now.Entries)
+		if diff := compareLists("console entry", "console entries", a, b); diff != "" {
+			return diff
+		}
+	case "network":
+		a, b := networkLines(was.Requests), networkLines(now.Requests)
+		if diff := compareLists("request", "requests", a, b); diff != "" {
+			return diff
+		}
+	case "windows":
+		a, b := windowTitles(was.Windows), windowTitles(now.Windows)
+		if diff := compareLists("window", "windows", a, b); diff != "" {
+			return diff
+		}
+	case "screenshot":
+		if was.Width != now.Width || was.Height != now.Height {
+			return fmt.Sprintf("size was %dx%d, now %dx%d", was.Width, was.Height, now.Width, now.Height)
+		}
+	}
+	return ""
+}
+
+// compareLists describes the first difference between the lists a and b
+// of what, whose plural is whats.
+func compareLists(what, whats string, a, b []string) string {
+	for i := 0; i < min(len(a), len(b)); i++ {
+		if a[i] != b[i] {
+			return fmt.Sprintf("%s %d was %q, now %q", what, i+1, clip(a[i], 80), clip(b[i], 80))
+		}
+	}
+	if len(a) != len(b) {
+		return fmt.Sprintf("%d %s were recorded, now %d", len(a), whats, len(b))
+	}
+	return ""
+}
+
+func consoleLines(entries []mcpConsoleEntry) []string {
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = e.Level + ": " + e.Message
+	}
+	return lines
+}
+
+func networkLines(requests []mcpNetworkEntry) []string {
+	lines := make([]string, len(requests))
+	for i, r := range requests {
+		lines[i] = fmt.Sprintf("%s %s %d", r.Method, r.URL, r.Status)
+	}
+	return lines
+}
+
+func windowTitles(windows []mcpWindow) []string {
+	titles := make([]string, len(windows))
+	for i, w := range windows {
+		titles[i] = w.Title
+	}
+	return titles
+}
+
+// compactJSON returns raw without insignificant whitespace, so results
+// that differ only in spacing compare equal.
+func compactJSON(raw json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+// clip shortens s to n runes for display.
+func clip(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lightshell-dev/lightshell/internal/api"
+	"github.com/lightshell-dev/lightshell/internal/app"
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+	"github.com/lightshell-dev/lightshell/internal/launchargs"
+)
+
+// Run runs a project on the runtime its built app uses, without compiling
+// it: declared permissions are enforced (the build's defaults when none
+// are declared), pages get the production CSP, and there is no hot
+// reload, debug console, dev tray, or devtools. A buildCommand runs once
+// first. args are "[path] [-- app args]"; path defaults to the current
+// directory.
+func Run(args []string) error {
+	dir := "."
+	var argv []string
+	for i, arg := range args {
+		if arg == "--" {
+			argv = args[i+1:]
+			break
+		}
+		dir = arg
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(dir)
+	if err != nil {
+		return err
+	}
+	if _, err := acceleratorScript(cfg); err != nil {
+		return err
+	}
+	if err := cfg.Window.Titlebar.Validate(); err != nil {
+		return withCode(lserrors.ConfigInvalid, fmt.Errorf("invalid window.titlebar in lightshell.json: %w", err))
+	}
+	if err := cfg.LaunchArgs.Validate(); err != nil {
+		return invalidConfig(err)
+	}
+	if err := validateMigrations(cfg.Migrations); err != nil {
+		return invalidConfig(err)
+	}
+	launch, err := launchargs.Parse(cfg.LaunchArgs, argv)
+	if err == launchargs.ErrHelp {
+		fmt.Print(launchargs.Usage(cfg.Name, cfg.LaunchArgs))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := runBuildCommand(os.Stdout, dir, cfg); err != nil {
+		return err
+	}
+
+	// Serve the pages as the built app does
+	srcDir := filepath.Join(dir, filepath.Dir(cfg.Entry))
+	pages := os.DirFS(srcDir)
+	if bundle := cfg.Build.Bundle; bundle != nil {
+		if err := validateBundle(dir, srcDir, bundle); err != nil {
+			return err
+		}
+		outDir, err := os.MkdirTemp("", "lightshell-bundle-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(outDir)
+		if err := runBundle(os.Stdout, dir, srcDir, outDir, bundle, false); err != nil {
+			return err
+		}
+		pages = app.Overlay(os.DirFS(outDir), pages)
+	}
+
+	cfg.Permissions.Names = buildPermissions(cfg)
+	httpOpts, err := httpOptions(cfg, dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Running %s with permissions: %v\n", cfg.Name, cfg.Permissions.Names)
+
+	return app.Run(app.Options{
+		Config:     cfg,
+		Pages:      pages,
+		ProjectDir: dir,
+		Argv:       argv,
+		Launch:     launch,
+		Register: func(a *app.App) {
+			api.RegisterFS(a.Router, a.Policy)
+			api.RegisterTempDirs(a.Router, a.Policy, cfg.Name)
+			api.RegisterHTTP(a.Router, a.Policy, httpOpts)
+			api.RegisterImage(a.Router, a.Policy)
+			api.RegisterPDF(a.Router, a.Policy)
+			api.RegisterShare(a.Router, a.Policy)
+			api.RegisterCodes(a.Router, a.Policy)
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"debug/buildinfo"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/sbom"
+)
+
+// sbomPath is where the SBOM of an artifact is written: next to it.
+func sbomPath(artifact string) string {
+	return artifact + ".cdx.json"
+}
+
+// npmLockfiles are the lockfiles the SBOM reads, in order of preference.
+var npmLockfiles = []string{"npm-shrinkwrap.json", "package-lock.json"}
+
+// otherLockfiles are lockfiles the SBOM cannot read yet.
+var otherLockfiles = []string{"pnpm-lock.yaml", "yarn.lock", "bun.lockb"}
+
+// writeSBOM writes a CycloneDX SBOM for artifact, listing the Go modules
+// compiled into binary and the packages the project's npm lockfile pins.
+func writeSBOM(w io.Writer, dir, binary, artifact string, cfg lsruntime.Config) error {
+	info, err := buildinfo.ReadFile(binary)
+	if err != nil {
+		return fmt.Errorf("failed to read build info for the SBOM: %w", err)
+	}
+	components := sbom.GoComponents(info)
+
+	lockfile := ""
+	for _, name := range npmLockfiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		npm, err := sbom.NPMComponents(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		components = append(components, npm...)
+		lockfile = name
+		break
+	}
+	if lockfile == "" {
+		for _, name := range otherLockfiles {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				fmt.Fprintf(w, "Warning: the SBOM leaves out packages from %s; only npm's package-lock.json is read\n", name)
+				break
+			}
+		}
+	}
+
+	app := sbom.Component{Type: "application", BOMRef: "app", Name: cfg.Name, Version: cfg.Version}
+	data, err := json.MarshalIndent(sbom.New(app, "lightshell", lsruntime.Version, components), "", "  ")
+	if err != nil {
+		return err
+	}
+	path := sbomPath(artifact)
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write SBOM: %w", err)
+	}
+	fmt.Fprintf(w, "SBOM: %s (%d components)\n", path, len(components))
+	return nil
+}
@@ -1,17 +1,9 @@
 package cli
 
-import (
-	_ "embed"
-)
-
-//go:embed scripts/polyfills.js
-var polyfillsJS string
-
-//go:embed scripts/lightshell.js
-var clientJS string
+import "github.com/lightshell-dev/lightshell/internal/clientjs"
 
-//go:embed scripts/defaults.css
-var defaultsCSS string
-
-//go:embed scripts/debug-console.js
-var debugConsoleJS string
+var (
+	polyfillsJS    = clientjs.Polyfills
+	clientJS       = clientjs.Client
+	debugConsoleJS = clientjs.DebugConsole
+)
@@ -0,0 +1,361 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/codesig"
+	lsruntime "github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/security"
+	"github.com/lightshell-dev/lightshell/internal/semver"
+)
+
+// selfUpdatePublicKey is the base64 ed25519 key LightShell's own release
+// manifests are signed with. Release builds set it with
+// -ldflags "-X github.com/lightshell-dev/lightshell/internal/cli.selfUpdatePublicKey=...";
+// builds without it cannot update themselves.
+var selfUpdatePublicKey = ""
+
+// selfUpdateURL serves a signed manifest per channel, <channel>.json.
+// LIGHTSHELL_UPDATE_URL replaces it, for a mirror; the manifests must still
+// be signed with the pinned key.
+const selfUpdateURL = "https://lightshell.dev/cli"
+
+// selfUpdateChannels are the release channels self-update can follow.
+var selfUpdateChannels = map[string]bool{"stable": true, "beta": true}
+
+// maxExecutableSize bounds the downloaded executable.
+const maxExecutableSize = 512 << 20
+
+// SelfUpdateFlags holds the parsed flags for the self-update command.
+type SelfUpdateFlags struct {
+	Channel  string // stable or beta; empty keeps the configured channel
+	Check    bool   // only report whether an update is available
+	Rollback bool   // go back to the version the last update replaced
+	Force    bool   // reinstall or downgrade, and update npm-managed installs
+}
+
+// SelfUpdate handles the `lightshell self-update` command.
+func SelfUpdate(args []string) error {
+	flags, err := parseSelfUpdateFlags(args)
+	if err != nil {
+		return usageError(err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+
+	if flags.Rollback {
+		return rollbackExecutable(exe)
+	}
+	if strings.Contains(exe, string(filepath.Separator)+"node_modules"+string(filepath.Separator)) && !flags.Force {
+		return fmt.Errorf("lightshell at %s was installed with npm, which would undo the update\n\nUpdate it with:\n  npm install -g @lightshell/cli\n\nOr pass --force to replace it anyway", exe)
+	}
+
+	channel, err := selfUpdateChannel(flags.Channel)
+	if err != nil {
+		return err
+	}
+	pubKey, err := selfUpdateKey()
+	if err != nil {
+		return err
+	}
+	base := selfUpdateURL
+	if env := os.Getenv("LIGHTSHELL_UPDATE_URL"); env != "" {
+		base = env
+	}
+	client := proxySettings().Client(5 * time.Minute)
+	return selfUpdate(client, base, channel, pubKey, exe, flags)
+}
+
+func parseSelfUpdateFlags(args []string) (SelfUpdateFlags, error) {
+	usage := "usage: lightshell self-update [--channel stable|beta] [--check] [--force]\n       lightshell self-update --rollback"
+	var flags SelfUpdateFlags
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--channel":
+			if i+1 >= len(args) {
+				return flags, fmt.Errorf("--channel requires a value\n\n%s", usage)
+			}
+			i++
+			flags.Channel = args[i]
+		case strings.HasPrefix(arg, "--channel="):
+			flags.Channel = strings.TrimPrefix(arg, "--channel=")
+		case arg == "--check":
+			flags.Check = true
+		case arg == "--rollback":
+			flags.Rollback = true
+		case arg == "--force":
+			flags.Force = true
+		default:
+			return flags, fmt.Errorf("unknown flag: %s\n\n%s", arg, usage)
+		}
+	}
+	if flags.Channel != "" && !selfUpdateChannels[flags.Channel] {
+		return flags, fmt.Errorf("unknown channel %q: must be stable or beta", flags.Channel)
+	}
+	return flags, nil
+}
+
+// selfUpdateChannel returns the channel to update from. A channel given on
+// the command line is saved as updateChannel in the global config, so
+// later updates follow it.
+func selfUpdateChannel(requested string) (string, error) {
+	if requested == "" {
+		if channel := loadConfigValue("updateChannel"); selfUpdateChannels[channel] {
+			return channel, nil
+		}
+		return "stable", nil
+	}
+	if loadConfigValue("updateChannel") != requested {
+		if err := configSet("updateChannel", requested, false); err != nil {
+			return "", err
+		}
+	}
+	return requested, nil
+}
+
+// selfUpdateKey decodes the pinned release key.
+func selfUpdateKey() (ed25519.PublicKey, error) {
+	if selfUpdatePublicKey == "" {
+		return nil, fmt.Errorf("this build of lightshell has no release key to verify updates with\n\nUpdate it the way you installed it, for example:\n  npm install -g @lightshell/cli")
+	}
+	key, err := base64.StdEncoding.DecodeString(selfUpdatePublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("this build of lightshell has an invalid release key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// selfUpdate replaces exe with the newer version the channel's manifest
+// under base offers.
+func selfUpdate(client *http.Client, base, channel string, pubKey ed25519.PublicKey, exe string, flags SelfUpdateFlags) error {
+	manifestURL := strings.TrimSuffix(base, "/") + "/" + channel + ".json"
+	manifest, err := fetchSelfUpdateManifest(client, manifestURL, pubKey)
+	if err != nil {
+		return err
+	}
+
+	cmp, err := semver.Compare(manifest.Version, lsruntime.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version in %s: %w", manifestURL, err)
+	}
+	if cmp <= 0 && !flags.Force {
+		fmt.Printf("lightshell %s is up to date (%s channel, latest %s)\n", lsruntime.Version, channel, manifest.Version)
+		return nil
+	}
+	if flags.Check {
+		fmt.Printf("lightshell %s is available on the %s channel (installed: %s)\n", manifest.Version, channel, lsruntime.Version)
+		fmt.Println("Run `lightshell self-update` to install it")
+		return nil
+	}
+
+	platform := normalizePlatform(runtime.GOOS + "-" + runtime.GOARCH)
+	artifact, ok := manifest.Platforms[platform]
+	if !ok || artifact.URL == "" {
+		return fmt.Errorf("lightshell %s has no build for %s", manifest.Version, platform)
+	}
+
+	fmt.Printf("Downloading lightshell %s for %s...\n", manifest.Version, platform)
+	next, err := downloadExecutable(client, artifact, filepath.Dir(exe))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(next) // gone already once it is swapped in
+
+	// A validly hashed build from a compromised server is still not ours
+	if err := codesig.VerifyUpdate(next); err != nil {
+		return fmt.Errorf("%w, so the installed version was kept", err)
+	}
+
+	// A binary that cannot run here must not replace the working one
+	out, err := exec.Command(next, "version").Output()
+	if err != nil || !strings.Contains(string(out), manifest.Version) {
+		return fmt.Errorf("the downloaded lightshell %s did not run correctly, so the installed version was kept", manifest.Version)
+	}
+
+	if err := swapExecutable(exe, next, exe+".old"); err != nil {
+		return err
+	}
+	fmt.Printf("Updated lightshell %s -> %s\n", lsruntime.Version, manifest.Version)
+	fmt.Println("Run `lightshell self-update --rollback` to go back")
+	return nil
+}
+
+// fetchSelfUpdateManifest downloads a release manifest and checks its
+// signature, made as lightshell release signs app manifests: over the
+// manifest's JSON without the signature.
+func fetchSelfUpdateManifest(client *http.Client, url string, pubKey ed25519.PublicKey) (ReleaseManifest, error) {
+	var manifest ReleaseManifest
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return manifest, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return manifest, fmt.Errorf("could not check for updates: %w", security.WrapTLSError("self-update", "check", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return manifest, fmt.Errorf("could not check for updates: %s returned %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("invalid update manifest at %s: %w", url, err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(manifest.Signature)
+	if err != nil || manifest.Signature == "" {
+		return manifest, fmt.Errorf("update manifest at %s is not signed", url)
+	}
+	unsigned := manifest
+	unsigned.Signature = ""
+	signed, err := json.Marshal(unsigned)
+	if err != nil {
+		return manifest, err
+	}
+	if !ed25519.Verify(pubKey, signed, sig) {
+		return manifest, fmt.Errorf("update manifest at %s has an invalid signature; refusing to update", url)
+	}
+	return manifest, nil
+}
+
+// downloadExecutable downloads an artifact into dir, checks its SHA-256,
+// and returns the path of the executable in it: the file itself, or the
+// one inside a .tar.gz.
+func downloadExecutable(client *http.Client, artifact PlatformArtifact, dir string) (string, error) {
+	resp, err := client.Get(artifact.URL)
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", security.WrapTLSError("self-update", "download", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed: %s returned %d", artifact.URL, resp.StatusCode)
+	}
+
+	// Next to the executable, so the swap is a rename on one file system
+	tmp, err := os.CreateTemp(dir, ".lightshell-download-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot write to %s, where lightshell is installed: %w", dir, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(resp.Body, maxExecutableSize)); err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, artifact.SHA256) {
+		return "", fmt.Errorf("downloaded file's SHA-256 %s does not match the manifest's %s", got, artifact.SHA256)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	next, err := os.CreateTemp(dir, ".lightshell-new-*")
+	if err != nil {
+		return "", err
+	}
+	ok := false
+	defer func() {
+		next.Close()
+		if !ok {
+			os.Remove(next.Name())
+		}
+	}()
+	var src io.Reader = tmp
+	if strings.HasSuffix(artifact.URL, ".tar.gz") || strings.HasSuffix(artifact.URL, ".tgz") {
+		if src, err = executableInArchive(tmp); err != nil {
+			return "", err
+		}
+	}
+	if _, err := io.Copy(next, io.LimitReader(src, maxExecutableSize)); err != nil {
+		return "", err
+	}
+	if err := next.Chmod(0o755); err != nil {
+		return "", err
+	}
+	if err := next.Close(); err != nil {
+		return "", err
+	}
+	ok = true
+	return next.Name(), nil
+}
+
+// executableInArchive returns the contents of the lightshell executable in
+// a release .tar.gz.
+func executableInArchive(r io.Reader) (io.Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("release archive has no lightshell executable")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid release archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeReg && strings.HasPrefix(filepath.Base(hdr.Name), "lightshell") {
+			return tr, nil
+		}
+	}
+}
+
+// swapExecutable moves exe to keep and next to exe. Renaming works while
+// exe is running, on Windows too. If next cannot be moved in, exe is put
+// back.
+func swapExecutable(exe, next, keep string) error {
+	os.Remove(keep)
+	if err := os.Rename(exe, keep); err != nil {
+		return fmt.Errorf("cannot replace %s: %w", exe, err)
+	}
+	if err := os.Rename(next, exe); err != nil {
+		if restoreErr := os.Rename(keep, exe); restoreErr != nil {
+			return fmt.Errorf("cannot replace %s: %w; the previous version is at %s", exe, err, keep)
+		}
+		return fmt.Errorf("cannot replace %s: %w; kept the installed version", exe, err)
+	}
+	return nil
+}
+
+// rollbackExecutable swaps exe with the version the last update replaced,
+// so a second rollback returns to the update.
+func rollbackExecutable(exe string) error {
+	previous := exe + ".old"
+	if _, err := os.Stat(previous); err != nil {
+		return fmt.Errorf("no previous version to roll back to (%s not found)", previous)
+	}
+	tmp := exe + ".rollback"
+	if err := os.Rename(previous, tmp); err != nil {
+		return fmt.Errorf("cannot roll back: %w", err)
+	}
+	if err := swapExecutable(exe, tmp, previous); err != nil {
+		os.Rename(tmp, previous)
+		return err
+	}
+	out, _ := exec.Command(exe, "version").Output()
+	fmt.Printf("Rolled back to %s", out)
+	return nil
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A session is a recording of the commands a dev process's control socket
+// handled (lightshell dev --record, or lightshell_dev_start with record),
+// kept in a directory of .lightshell/sessions so lightshell replay can send
+// them to a fresh dev process. session.jsonl holds one sessionEntry per
+// command, in order; the screenshots they returned are files beside it.
+type sessionEntry struct {
+	At         int64             `json:"at"` // ms since the recording started
+	Command    mcpSocketCommand  `json:"command"`
+	Response   mcpSocketResponse `json:"response"`             // without a screenshot's image
+	Screenshot string            `json:"screenshot,omitempty"` // the image, relative to the session directory
+}
+
+const sessionLog = "session.jsonl"
+
+func sessionsDir(dir string) string {
+	return filepath.Join(dir, ".lightshell", "sessions")
+}
+
+// sessionRecorder appends the commands a control socket handles to a
+// session directory.
+type sessionRecorder struct {
+	mu      sync.Mutex
+	dir     string
+	log     *os.File
+	started time.Time
+	count   int
+}
+
+// newSessionRecorder starts a session in a new directory of the project
+// dir's sessions, named for the time.
+func newSessionRecorder(dir string) (*sessionRecorder, error) {
+	started := time.Now()
+	sessionDir := filepath.Join(sessionsDir(dir), started.Format("2006-01-02T15-04-05"))
+	if err := os.MkdirAll(sessionDir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create session directory: %w", err)
+	}
+	log, err := os.OpenFile(filepath.Join(sessionDir, sessionLog), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not start session recording: %w", err)
+	}
+	return &sessionRecorder{dir: sessionDir, log: log, started: started}, nil
+}
+
+// record appends cmd and its response. A screenshot is written to its own
+// file, so the log stays readable.
+func (r *sessionRecorder) record(cmd mcpSocketCommand, resp mcpSocketResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.count++
+	entry := sessionEntry{At: time.Since(r.started).Milliseconds(), Command: cmd, Response: resp}
+	if resp.Image != "" {
+		if data, err := base64.StdEncoding.DecodeString(resp.Image); err == nil {
+			name := fmt.Sprintf("%04d.png", r.count)
+			if os.WriteFile(filepath.Join(r.dir, name), data, 0o644) == nil {
+				entry.Screenshot = name
+			}
+		}
+		entry.Response.Image = ""
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	r.log.Write(append(line, '\n'))
+}
+
+func (r *sessionRecorder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log.Close()
+}
+
+// readSession reads the entries of the session directory dir.
+func readSession(dir string) ([]sessionEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sessionLog))
+	if err != nil {
+		return nil, err
+	}
+	var entries []sessionEntry
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var entry sessionEntry
+		if err := dec.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("%s: entry %d: %w", sessionLog, len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// findSession returns the directory of session, which is a path or the
+// name of one of dir's recorded sessions.
+func findSession(dir, session string) (string, error) {
+	if _, err := os.Stat(filepath.Join(session, sessionLog)); err == nil {
+		return session, nil
+	}
+	named := filepath.Join(sessionsDir(dir), session)
+	if _, err := os.Stat(filepath.Join(named, sessionLog)); err == nil {
+		return named, nil
+	}
+	names := listSessions(dir)
+	if len(names) == 0 {
+		return "", fmt.Errorf("no session %q; record one with 'lightshell dev --daemon --record'", session)
+	}
+	return "", fmt.Errorf("no session %q; recorded sessions are %s", session, strings.Join(names, ", "))
+}
+
+// listSessions returns the names of dir's recorded sessions, oldest first.
+func listSessions(dir string) []string {
+	entries, err := os.ReadDir(sessionsDir(dir))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(sessionsDir(dir), e.Name(), sessionLog)); err == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
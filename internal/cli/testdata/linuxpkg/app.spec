@@ -0,0 +1,23 @@
+%global debug_package %{nil}
+%global __os_install_post %{nil}
+
+Name: note-taker
+Version: 1.2.0~beta.1
+Release: 1
+Summary: Take notes, quickly
+License: Unspecified
+URL: https://example.com/notes
+Packager: Ada Lovelace <ada@example.com>
+Requires: webkit2gtk4.1 >= 2.38, gtk3
+AutoReqProv: no
+
+%description
+Take notes, quickly
+
+%install
+mkdir -p %{buildroot}
+cp -a '/tmp/it'\''s here'/. %{buildroot}/
+
+%files
+"/usr/bin/note-taker"
+"/usr/share/applications/note-taker.desktop"
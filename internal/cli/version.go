@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/fsutil"
+	"github.com/lightshell-dev/lightshell/internal/jsonedit"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/security"
+	"github.com/lightshell-dev/lightshell/internal/semver"
+)
+
+// Version handles the `lightshell version set|bump` subcommands.
+// The bare `lightshell version` command (print CLI version) is handled in main.
+func Version(args []string) error {
+	usage := "usage: lightshell version <set <x.y.z>|bump <patch|minor|major>> [--tag]"
+	if len(args) < 2 {
+		return usageError(fmt.Errorf("%s", usage))
+	}
+
+	tag := false
+	var rest []string
+	for _, arg := range args[1:] {
+		switch arg {
+		case "--tag":
+			tag = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return usageError(fmt.Errorf("unknown flag: %s\n\n%s", arg, usage))
+			}
+			rest = append(rest, arg)
+		}
+	}
+	if len(rest) != 1 {
+		return usageError(fmt.Errorf("%s", usage))
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	configPath := filepath.Join(dir, "lightshell.json")
+
+	current, err := readConfigVersion(configPath)
+	if err != nil {
+		return err
+	}
+
+	var next string
+	switch args[0] {
+	case "set":
+		if _, err := semver.Parse(rest[0]); err != nil {
+			return err
+		}
+		next = strings.TrimPrefix(rest[0], "v")
+	case "bump":
+		next, err = bumpVersion(current, rest[0])
+		if err != nil {
+			return err
+		}
+	default:
+		return usageError(fmt.Errorf("unknown version subcommand: %s\n\n%s", args[0], usage))
+	}
+
+	if err := writeConfigVersion(configPath, next); err != nil {
+		return fmt.Errorf("failed to update lightshell.json: %w", err)
+	}
+	fmt.Printf("Version: %s -> %s\n", current, next)
+
+	if tag {
+		tagName := "v" + next
+		cmd := exec.Command("git", "tag", "-a", tagName, "-m", "Release "+tagName)
+		cmd.Dir = dir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to create git tag %s: %w", tagName, err)
+		}
+		fmt.Printf("Created git tag %s\n", tagName)
+	}
+
+	return nil
+}
+
+// bumpVersion increments the given part of a version. Bumping drops any
+// pre-release suffix, except that a patch bump of a pre-release finalizes it
+// (1.2.3-beta.1 -> 1.2.3), matching semver precedence.
+func bumpVersion(current, part string) (string, error) {
+	v, err := semver.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	switch part {
+	case "major":
+		v = semver.Version{Major: v.Major + 1}
+	case "minor":
+		v = semver.Version{Major: v.Major, Minor: v.Minor + 1}
+	case "patch":
+		if v.Pre != "" {
+			v.Pre = ""
+		} else {
+			v.Patch++
+		}
+	default:
+		return "", fmt.Errorf("unknown version part %q: must be one of patch, minor, major", part)
+	}
+	return v.String(), nil
+}
+
+// readConfigVersion returns the version field from lightshell.json.
+func readConfigVersion(configPath string) (string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", fmt.Errorf("could not read lightshell.json: %w\n\nMake sure you're in a LightShell project directory.", err)
+	}
+	var cfg struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", invalidConfig(err)
+	}
+	if cfg.Version == "" {
+		return "0.0.0", nil
+	}
+	return cfg.Version, nil
+}
+
+// writeConfigVersion sets the version field in lightshell.json and writes it back.
+func writeConfigVersion(configPath, version string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	out, err := jsonedit.Set(data, []string{"version"}, version)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(configPath, out, 0o644)
+}
+
+// recordLightShellVersion warns when the LightShell version the project in
+// dir records does not match this one, then records this one in its
+// lightshell.json, unless the project's is newer.
+func recordLightShellVersion(dir, recorded string) error {
+	if warning := runtime.CheckVersion(recorded); warning != "" {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	if cmp, err := semver.Compare(recorded, runtime.Version); err == nil && cmp >= 0 {
+		return nil
+	}
+
+	configPath := filepath.Join(dir, "lightshell.json")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	out, err := jsonedit.Set(data, []string{"lightshellVersion"}, runtime.Version)
+	if err != nil {
+		return fmt.Errorf("could not record lightshellVersion in lightshell.json: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(configPath, out, 0o644); err != nil {
+		return fmt.Errorf("could not record lightshellVersion in lightshell.json: %w", err)
+	}
+	fmt.Printf("Recorded lightshellVersion %s in lightshell.json\n", runtime.Version)
+	return nil
+}
+
+// fetchLatestVersion returns the version currently published on the release
+// server's channel, or "" if nothing has been published yet.
+func fetchLatestVersion(client *http.Client, server string) (string, error) {
+	latestURL := strings.TrimSuffix(server, "/") + "/latest.json"
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", latestURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", security.WrapTLSError("release", "checkVersion", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var manifest ReleaseManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return "", fmt.Errorf("invalid manifest at %s: %w", latestURL, err)
+	}
+	return manifest.Version, nil
+}
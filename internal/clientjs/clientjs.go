@@ -0,0 +1,35 @@
+// Package clientjs holds what LightShell adds to every page: the
+// polyfills, the lightshell client library, the default stylesheet, and
+// the debug console of dev mode, with the page snapshot its bug reports
+// use.
+package clientjs
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed polyfills.js
+var Polyfills string
+
+//go:embed lightshell.js
+var Client string
+
+//go:embed defaults.css
+var DefaultsCSS string
+
+//go:embed snapshot.js
+var Snapshot string // a function expression returning {html, title, url, width, height, userAgent}
+
+//go:embed debug-console.js
+var debugConsole string
+
+// DebugConsole is the debug console, with Snapshot for its Report button.
+var DebugConsole = strings.Replace(debugConsole, "__LIGHTSHELL_SNAPSHOT__", "("+Snapshot+")", 1)
+
+// DefaultsScript returns a user script that inserts DefaultsCSS as the
+// page's first stylesheet, so the page's own styles win.
+func DefaultsScript() string {
+	return fmt.Sprintf(`(function(){var s=document.createElement('style');s.id='lightshell-defaults';s.textContent=%q;document.head.insertBefore(s,document.head.firstChild)})()`, DefaultsCSS)
+}
@@ -10,6 +10,10 @@
   let panelVisible = false
   let panelHeight = 300
 
+  // Filled in with clientjs.Snapshot when the script is built
+  const snapshot = __LIGHTSHELL_SNAPSHOT__
+  const pendingCalls = new Map()
+
   // --- Console interception ---
   const origConsole = {}
   ;['log', 'warn', 'error', 'info', 'debug'].forEach(level => {
@@ -82,6 +86,11 @@
   if (origReceive) {
     window.__lightshell_receive = function(json) {
       let msg = typeof json === 'string' ? JSON.parse(json) : json
+      if (msg.id && pendingCalls.has(msg.id)) {
+        const { resolve, reject } = pendingCalls.get(msg.id)
+        pendingCalls.delete(msg.id)
+        msg.error ? reject(new Error(msg.error)) : resolve(msg.result)
+      }
       if (msg.id) {
         const entry = ipcCalls.find(e => e.id === msg.id)
         if (entry) {
@@ -127,6 +136,58 @@
     return s.slice(0, max) + '...'
   }
 
+  // Calls a dev-only IPC method, which the client library does not expose
+  function call(method, params) {
+    return new Promise((resolve, reject) => {
+      if (!origPostMessage || !origReceive) {
+        reject(new Error('LightShell runtime not available'))
+        return
+      }
+      const id = crypto.randomUUID()
+      pendingCalls.set(id, { resolve, reject })
+      window.webkit.messageHandlers.lightshell.postMessage(JSON.stringify({ id, method, params }))
+    })
+  }
+
+  // Saves a bug report: the page with its styles, a screenshot of it, and
+  // the console tail. The panel is hidden while the screenshot is taken.
+  function saveReport() {
+    const wasVisible = panelVisible
+    hide()
+    const entries = logs.slice(-200).map(e => ({ time: e.time, level: e.level, message: e.message }))
+      .concat(errors.map(e => ({
+        time: e.time,
+        level: 'error',
+        message: 'Uncaught ' + e.message + (e.source ? ' (' + e.source + (e.line ? ':' + e.line : '') + ')' : '') + (e.stack ? '\n' + e.stack : '')
+      })))
+      .sort((a, b) => a.time - b.time)
+      .map(e => ({ timestamp: new Date(e.time).toISOString(), level: e.level, message: e.message }))
+    // Wait for the page to be drawn without the panel
+    requestAnimationFrame(() => requestAnimationFrame(() => {
+      let page
+      try {
+        page = snapshot()
+      } catch (err) {
+        if (wasVisible) show()
+        addLog('error', ['Could not capture the page: ' + err.message])
+        return
+      }
+      reopen = wasVisible
+      call('dev.exportPage', { page, console: entries })
+        .catch(err => reportSaved({ error: err.message }))
+    }))
+  }
+
+  // Called by the dev process once the report is saved, after it has
+  // taken the screenshot
+  let reopen = false
+  function reportSaved(result) {
+    if (reopen) show()
+    reopen = false
+    if (result.error) addLog('error', ['Could not save the bug report: ' + result.error])
+    else addLog('info', ['Bug report saved to ' + result.path])
+  }
+
   // --- UI ---
   function createPanel() {
     const el = document.createElement('div')
@@ -139,6 +200,7 @@
         <button class="__ls-tab" data-tab="ipc">IPC <span class="__ls-badge" id="__ls-ipc-badge">0</span></button>
         <button class="__ls-tab" data-tab="info">Info</button>
         <div class="__ls-spacer"></div>
+        <button class="__ls-tab __ls-clear-btn" id="__ls-report" title="Save the page, a screenshot, and the console to .lightshell/reports">Report</button>
         <button class="__ls-tab __ls-clear-btn" id="__ls-clear">Clear</button>
         <button class="__ls-tab __ls-close-btn" id="__ls-close">&times;</button>
       </div>
@@ -168,6 +230,9 @@
     // Close
     el.querySelector('#__ls-close').addEventListener('click', hide)
 
+    // Bug report
+    el.querySelector('#__ls-report').addEventListener('click', saveReport)
+
     // Clear
     el.querySelector('#__ls-clear').addEventListener('click', () => {
       if (activeTab === 'console') { logs.length = 0 }
@@ -308,6 +373,7 @@
 
   // --- Styles ---
   const style = document.createElement('style')
+  style.id = '__ls-debug-style'
   style.textContent = `
     #__ls-debug {
       all: initial;
@@ -423,5 +489,5 @@
   }
   init()
 
-  window.__lightshell_debug = { toggle, show, hide }
+  window.__lightshell_debug = { toggle, show, hide, saveReport, reportSaved }
 })()
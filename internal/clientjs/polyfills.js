@@ -1,6 +1,6 @@
 (()=>{
 const L=navigator.platform.includes('Linux')
-document.documentElement.classList.add(L?'platform-linux':'platform-darwin')
+document.documentElement.classList.add(L?'platform-linux':navigator.platform.startsWith('Win')?'platform-windows':'platform-darwin')
 if(typeof structuredClone==='undefined'){
 window.structuredClone=(o,t)=>{
 if(t?.transfer?.length)throw new DOMException('Transfer not supported','DataCloneError')
@@ -0,0 +1,86 @@
+// Captures the page for a bug report. Returns the DOM as a standalone HTML
+// document, with the page's stylesheets inlined, form state kept, canvases
+// drawn as images, and scripts and event handlers left out, so it renders
+// like the page when opened in a browser. Stylesheets from another origin
+// cannot be read and are linked instead. The debug console is left out.
+function () {
+  const root = document.documentElement
+  const copy = root.cloneNode(true)
+
+  // Form state lives in properties, which cloning does not copy
+  const fields = root.querySelectorAll('input, textarea, select')
+  const copies = copy.querySelectorAll('input, textarea, select')
+  fields.forEach((field, i) => {
+    const c = copies[i]
+    if (!c) return
+    if (field.tagName === 'TEXTAREA') {
+      c.textContent = field.value
+    } else if (field.tagName === 'SELECT') {
+      Array.from(field.options).forEach((o, j) => {
+        if (o.selected) c.options[j].setAttribute('selected', '')
+        else c.options[j].removeAttribute('selected')
+      })
+    } else if (field.type === 'checkbox' || field.type === 'radio') {
+      if (field.checked) c.setAttribute('checked', '')
+      else c.removeAttribute('checked')
+    } else if (field.type !== 'password' && field.type !== 'file') {
+      c.setAttribute('value', field.value)
+    }
+  })
+
+  const canvases = root.querySelectorAll('canvas')
+  copy.querySelectorAll('canvas').forEach((c, i) => {
+    try {
+      const img = document.createElement('img')
+      img.src = canvases[i].toDataURL()
+      img.width = canvases[i].width
+      img.height = canvases[i].height
+      if (c.getAttribute('style')) img.setAttribute('style', c.getAttribute('style'))
+      if (c.className) img.className = c.className
+      c.replaceWith(img)
+    } catch (e) {} // tainted by an image from another origin
+  })
+
+  // The stylesheets in cascade order, as one <style>
+  const css = []
+  const sheets = Array.from(document.styleSheets).concat(Array.from(document.adoptedStyleSheets || []))
+  sheets.forEach(sheet => {
+    if (sheet.ownerNode && sheet.ownerNode.id === '__ls-debug-style') return
+    if (sheet.disabled) return
+    try {
+      const rules = Array.from(sheet.cssRules).map(r => r.cssText).join('\n')
+      css.push(sheet.media && sheet.media.mediaText ? '@media ' + sheet.media.mediaText + ' {\n' + rules + '\n}' : rules)
+    } catch (e) {
+      if (sheet.href) css.push('@import url(' + JSON.stringify(sheet.href) + ');')
+    }
+  })
+
+  copy.querySelectorAll('script, style, link[rel~="stylesheet"], #__ls-debug').forEach(el => el.remove())
+  copy.querySelectorAll('*').forEach(el => {
+    Array.from(el.attributes).forEach(a => {
+      if (/^on/i.test(a.name) || /^\s*javascript:/i.test(a.value)) el.removeAttribute(a.name)
+    })
+  })
+
+  let head = copy.querySelector('head')
+  if (!head) {
+    head = document.createElement('head')
+    copy.insertBefore(head, copy.firstChild)
+  }
+  const style = document.createElement('style')
+  // @import rules must come first
+  style.textContent = css.filter(c => c.startsWith('@import')).concat(css.filter(c => !c.startsWith('@import'))).join('\n')
+  head.insertBefore(style, head.firstChild)
+  const base = document.createElement('base')
+  base.href = location.href
+  head.insertBefore(base, head.firstChild)
+
+  return {
+    html: '<!DOCTYPE html>\n' + copy.outerHTML,
+    title: document.title,
+    url: location.href,
+    width: window.innerWidth,
+    height: window.innerHeight,
+    userAgent: navigator.userAgent
+  }
+}
@@ -0,0 +1,63 @@
+package codes
+
+import "testing"
+
+func TestCode128Patterns(t *testing.T) {
+	seen := make(map[string]int)
+	for v, p := range code128Patterns {
+		sum := 0
+		for _, w := range p {
+			sum += int(w - '0')
+		}
+		if sum != 11 {
+			t.Errorf("pattern %d (%s) is %d modules wide, want 11", v, p, sum)
+		}
+		if prev, ok := seen[p]; ok {
+			t.Errorf("patterns %d and %d are both %s", prev, v, p)
+		}
+		seen[p] = v
+	}
+}
+
+func TestEncodeCode128(t *testing.T) {
+	for _, tt := range []struct {
+		text    string
+		symbols int // data symbols between start and checksum
+		start   int
+	}{
+		{"PJJ123C", 7, code128StartB},
+		{"12345678", 4, code128StartC},
+		{"1234567", 7, code128StartB},
+	} {
+		modules, err := EncodeCode128(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := 11*(tt.symbols+2) + 13; len(modules) != want {
+			t.Errorf("%q: %d modules, want %d", tt.text, len(modules), want)
+		}
+		start := appendWidths(nil, code128Patterns[tt.start])
+		for i := range start {
+			if modules[i] != start[i] {
+				t.Errorf("%q: does not begin with start code %d", tt.text, tt.start)
+				break
+			}
+		}
+	}
+
+	if _, err := EncodeCode128("café"); err == nil {
+		t.Error("expected an error for non-ASCII text")
+	}
+}
+
+func TestCode128Checksum(t *testing.T) {
+	// Start B (104) + P·1 + J·2 + J·3 + 1·4 + 2·5 + 3·6 + C·7 = 879, 879 mod 103 = 55
+	modules, _ := EncodeCode128("PJJ123C")
+	checksum := appendWidths(nil, code128Patterns[55])
+	got := modules[len(modules)-13-11 : len(modules)-13]
+	for i := range checksum {
+		if got[i] != checksum[i] {
+			t.Fatalf("checksum symbol does not match value 55")
+		}
+	}
+}
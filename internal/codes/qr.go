@@ -0,0 +1,624 @@
+// Package codes generates QR codes and Code 128 barcodes for lightshell.codes.
+// Both are implemented here so apps need no JS libraries to show pairing or
+// sharing codes offline.
+package codes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is a QR error correction level. Higher levels survive more damage
+// at the cost of a denser code.
+type Level int
+
+const (
+	LevelL Level = iota // ~7% of codewords can be restored
+	LevelM              // ~15%
+	LevelQ              // ~25%
+	LevelH              // ~30%
+)
+
+// ParseLevel parses "L", "M", "Q", or "H" (any case). Empty means LevelM.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return LevelL, nil
+	case "", "M":
+		return LevelM, nil
+	case "Q":
+		return LevelQ, nil
+	case "H":
+		return LevelH, nil
+	}
+	return LevelM, fmt.Errorf("unknown error correction level %q (use L, M, Q, or H)", s)
+}
+
+// formatBits is the level's two-bit value in the format information.
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// Error correction codewords per block and number of blocks, indexed by
+// level then version (index 0 unused). From ISO/IEC 18004 table 9.
+var (
+	eccCodewordsPerBlock = [4][41]int{
+		{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	eccBlocks = [4][41]int{
+		{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+)
+
+// Segment modes, smallest encoding first.
+type mode int
+
+const (
+	modeNumeric mode = iota
+	modeAlphanumeric
+	modeByte
+)
+
+const alphanumericChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// indicator is the mode's four-bit header.
+func (m mode) indicator() int {
+	return [...]int{1, 2, 4}[m]
+}
+
+// countBits is the width of the character count field for version.
+func (m mode) countBits(version int) int {
+	i := 0
+	switch {
+	case version >= 27:
+		i = 2
+	case version >= 10:
+		i = 1
+	}
+	return [...][3]int{{10, 12, 14}, {9, 11, 13}, {8, 16, 16}}[m][i]
+}
+
+// QR is an encoded QR code symbol.
+type QR struct {
+	Version int // 1-40
+	Level   Level
+	Mask    int // 0-7
+
+	size       int
+	modules    [][]bool // [y][x], true is dark
+	isFunction [][]bool // finder, timing, alignment, format, and version modules
+}
+
+// EncodeQR encodes text as the smallest QR code that holds it at level.
+// Text made only of digits, or of uppercase letters, digits, and " $%*+-./:",
+// uses the compact numeric or alphanumeric modes; anything else is UTF-8 bytes.
+func EncodeQR(text string, level Level) (*QR, error) {
+	version, data, err := encodeData(text, level)
+	if err != nil {
+		return nil, err
+	}
+	q := newQR(version, level)
+	q.drawFunctionPatterns()
+	q.drawCodewords(q.addECCAndInterleave(data))
+	q.chooseMask()
+	return q, nil
+}
+
+// encodeData picks the smallest version that fits text at level and returns
+// it with the padded data codewords.
+func encodeData(text string, level Level) (int, []byte, error) {
+	m := pickMode(text)
+	count := len(text)
+
+	var data bitBuffer
+	switch m {
+	case modeNumeric:
+		for i := 0; i < len(text); i += 3 {
+			chunk := text[i:min(i+3, len(text))]
+			n := 0
+			for _, c := range chunk {
+				n = n*10 + int(c-'0')
+			}
+			data.append(n, len(chunk)*3+1)
+		}
+	case modeAlphanumeric:
+		for i := 0; i+1 < len(text); i += 2 {
+			a := strings.IndexByte(alphanumericChars, text[i])
+			b := strings.IndexByte(alphanumericChars, text[i+1])
+			data.append(a*45+b, 11)
+		}
+		if len(text)%2 == 1 {
+			data.append(strings.IndexByte(alphanumericChars, text[len(text)-1]), 6)
+		}
+	default:
+		for i := 0; i < len(text); i++ {
+			data.append(int(text[i]), 8)
+		}
+	}
+
+	version := 0
+	for v := 1; v <= 40; v++ {
+		cb := m.countBits(v)
+		if count < 1<<cb && 4+cb+data.len() <= dataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return 0, nil, fmt.Errorf("text is too long for a QR code at level %s (%d bytes)", "LMQH"[level:level+1], len(text))
+	}
+
+	var bb bitBuffer
+	bb.append(m.indicator(), 4)
+	bb.append(count, m.countBits(version))
+	bb.bits = append(bb.bits, data.bits...)
+
+	// Terminator, byte alignment, then alternating pad bytes
+	capacity := dataCodewords(version, level) * 8
+	bb.append(0, min(4, capacity-bb.len()))
+	bb.append(0, (8-bb.len()%8)%8)
+	for pad := 0xEC; bb.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	return version, bb.bytes(), nil
+}
+
+func pickMode(text string) mode {
+	numeric, alnum := true, true
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c < '0' || c > '9' {
+			numeric = false
+		}
+		if strings.IndexByte(alphanumericChars, c) < 0 {
+			alnum = false
+		}
+	}
+	switch {
+	case numeric:
+		return modeNumeric
+	case alnum:
+		return modeAlphanumeric
+	}
+	return modeByte
+}
+
+// Size is the width and height of the symbol in modules, without quiet zone.
+func (q *QR) Size() int {
+	return q.size
+}
+
+// Dark reports whether the module at (x, y) is dark. Coordinates outside the
+// symbol are light.
+func (q *QR) Dark(x, y int) bool {
+	return x >= 0 && y >= 0 && x < q.size && y < q.size && q.modules[y][x]
+}
+
+func newQR(version int, level Level) *QR {
+	size := version*4 + 17
+	q := &QR{Version: version, Level: level, size: size}
+	q.modules = make([][]bool, size)
+	q.isFunction = make([][]bool, size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.isFunction[i] = make([]bool, size)
+	}
+	return q
+}
+
+// numRawDataModules is the number of modules available for data and error
+// correction codewords in version, including remainder bits.
+func numRawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// dataCodewords is the number of 8-bit data codewords in version at level.
+func dataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*eccBlocks[level][version]
+}
+
+func (q *QR) setFunction(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+func (q *QR) drawFunctionPatterns() {
+	for i := 0; i < q.size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+
+	q.drawFinder(3, 3)
+	q.drawFinder(q.size-4, 3)
+	q.drawFinder(3, q.size-4)
+
+	pos := alignmentPositions(q.Version)
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			// Skip the three corners occupied by finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			q.drawAlignment(pos[i], pos[j])
+		}
+	}
+
+	// Reserve the format areas now; the real bits are drawn once the mask is chosen
+	q.drawFormatBits(0)
+	q.drawVersion()
+}
+
+// drawFinder draws a finder pattern and its separator centered on (cx, cy).
+func (q *QR) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= q.size || y >= q.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			q.setFunction(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+func (q *QR) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPositions returns the row/column centers of the alignment patterns.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, version*4+17-7; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// formatInfo returns the 15-bit BCH-protected format information.
+func formatInfo(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionInfo returns the 18-bit BCH-protected version information.
+func versionInfo(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem
+}
+
+func (q *QR) drawFormatBits(mask int) {
+	bits := formatInfo(q.Level, mask)
+	bit := func(i int) bool { return bits>>i&1 != 0 }
+
+	// Around the top-left finder
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+
+	// Split between the other two finders
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.size-15+i, bit(i))
+	}
+	q.setFunction(8, q.size-8, true) // always-dark module
+}
+
+func (q *QR) drawVersion() {
+	if q.Version < 7 {
+		return
+	}
+	bits := versionInfo(q.Version)
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 != 0
+		a, b := q.size-11+i%3, i/3
+		q.setFunction(a, b, dark)
+		q.setFunction(b, a, dark)
+	}
+}
+
+// addECCAndInterleave splits data into blocks, appends Reed-Solomon error
+// correction to each, and interleaves the result.
+func (q *QR) addECCAndInterleave(data []byte) []byte {
+	numBlocks := eccBlocks[q.Level][q.Version]
+	eccLen := eccCodewordsPerBlock[q.Level][q.Version]
+	raw := numRawDataModules(q.Version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		ecc := rsRemainder(block, divisor)
+		if i < numShort {
+			block = append(block, 0) // placeholder so all blocks line up; skipped below
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	out := make([]byte, 0, raw)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// drawCodewords places data in the zigzag pattern, skipping function modules.
+func (q *QR) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if upward {
+					y = q.size - 1 - vert
+				}
+				if !q.isFunction[y][x] && i < len(data)*8 {
+					q.modules[y][x] = data[i>>3]>>(7-i&7)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask XORs the data modules with mask pattern m; applying it twice undoes it.
+func (q *QR) applyMask(m int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch m {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// chooseMask applies the mask with the lowest penalty score.
+func (q *QR) chooseMask() {
+	best, bestScore := 0, -1
+	for m := 0; m < 8; m++ {
+		q.applyMask(m)
+		q.drawFormatBits(m)
+		if s := q.penalty(); bestScore < 0 || s < bestScore {
+			best, bestScore = m, s
+		}
+		q.applyMask(m)
+	}
+	q.Mask = best
+	q.applyMask(best)
+	q.drawFormatBits(best)
+}
+
+// penalty scores the symbol per ISO/IEC 18004 section 7.8.3; lower is
+// easier for scanners to read.
+func (q *QR) penalty() int {
+	score := 0
+	line := make([]bool, q.size)
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < q.size; a++ {
+			for b := 0; b < q.size; b++ {
+				if vertical {
+					line[b] = q.modules[b][a]
+				} else {
+					line[b] = q.modules[a][b]
+				}
+			}
+			score += runPenalty(line) + finderPenalty(line)
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			c := q.modules[y][x]
+			if c {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size && c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+				score += 3
+			}
+		}
+	}
+	total := q.size * q.size
+	score += abs(dark*100/total-50) / 5 * 10
+	return score
+}
+
+// runPenalty scores runs of five or more same-colored modules.
+func runPenalty(line []bool) int {
+	score, run := 0, 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			score += run - 2
+		}
+		run = 1
+	}
+	return score
+}
+
+// finderPenalty scores 1:1:3:1:1 dark-light patterns with four light modules
+// on either side, which scanners can mistake for finder patterns.
+func finderPenalty(line []bool) int {
+	pattern := []bool{true, false, true, true, true, false, true}
+	score := 0
+	for i := 0; i+len(pattern) <= len(line); i++ {
+		match := true
+		for j, p := range pattern {
+			if line[i+j] != p {
+				match = false
+				break
+			}
+		}
+		if match && (lightRun(line, i-4, i) || lightRun(line, i+len(pattern), i+len(pattern)+4)) {
+			score += 40
+		}
+	}
+	return score
+}
+
+// lightRun reports whether line[from:to] is all light, treating modules past
+// either end as the light quiet zone.
+func lightRun(line []bool, from, to int) bool {
+	for i := from; i < to; i++ {
+		if i >= 0 && i < len(line) && line[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of degree, without
+// its leading 1 coefficient.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the error correction codewords for data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) len() int {
+	return len(b.bits)
+}
+
+// append adds the low n bits of v, most significant first.
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		b.bits = append(b.bits, v>>i&1 != 0)
+	}
+}
+
+func (b *bitBuffer) bytes() []byte {
+	out := make([]byte, (len(b.bits)+7)/8)
+	for i, bit := range b.bits {
+		if bit {
+			out[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+	return out
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,187 @@
+package codes
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDataHelloWorld(t *testing.T) {
+	// Worked example from the QR specification literature: "HELLO WORLD" at 1-Q
+	version, data, err := encodeData("HELLO WORLD", LevelQ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Fatalf("version = %d, want 1", version)
+	}
+	want := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236}
+	if !bytes.Equal(data, want) {
+		t.Errorf("data codewords = %v, want %v", data, want)
+	}
+
+	ecc := rsRemainder(data, rsDivisor(eccCodewordsPerBlock[LevelQ][1]))
+	wantECC := []byte{168, 72, 22, 82, 217, 54, 156, 0, 46, 15, 180, 122, 16}
+	if !bytes.Equal(ecc, wantECC) {
+		t.Errorf("ecc codewords = %v, want %v", ecc, wantECC)
+	}
+}
+
+func TestFormatAndVersionInfo(t *testing.T) {
+	for _, tt := range []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{LevelL, 0, 0b111011111000100},
+		{LevelM, 0, 0b101010000010010},
+		{LevelQ, 0, 0b011010101011111},
+		{LevelH, 0, 0b001011010001001},
+	} {
+		if got := formatInfo(tt.level, tt.mask); got != tt.want {
+			t.Errorf("formatInfo(%d, %d) = %015b, want %015b", tt.level, tt.mask, got, tt.want)
+		}
+	}
+	if got := versionInfo(7); got != 0b000111110010010100 {
+		t.Errorf("versionInfo(7) = %018b", got)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	for _, tt := range []struct {
+		version int
+		level   Level
+		want    int
+	}{
+		{1, LevelL, 19}, {1, LevelH, 9}, {10, LevelM, 216}, {40, LevelL, 2956}, {40, LevelH, 1276},
+	} {
+		if got := dataCodewords(tt.version, tt.level); got != tt.want {
+			t.Errorf("dataCodewords(%d, %d) = %d, want %d", tt.version, tt.level, got, tt.want)
+		}
+	}
+
+	if _, err := EncodeQR(strings.Repeat("x", 2953), LevelL); err != nil {
+		t.Errorf("max byte capacity should fit: %v", err)
+	}
+	if _, err := EncodeQR(strings.Repeat("x", 2954), LevelL); err == nil {
+		t.Error("expected an error past max capacity")
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	for version, want := range map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	} {
+		got := alignmentPositions(version)
+		if len(got) != len(want) {
+			t.Errorf("version %d: got %v, want %v", version, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("version %d: got %v, want %v", version, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestPickMode(t *testing.T) {
+	for text, want := range map[string]mode{
+		"0123456789":           modeNumeric,
+		"HTTPS://EXAMPLE.COM":  modeAlphanumeric,
+		"WIFI:S:home;T:WPA;;":  modeByte,
+		"https://example.com/": modeByte,
+	} {
+		if got := pickMode(text); got != want {
+			t.Errorf("pickMode(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+// TestRoundTrip reads codewords back out of encoded symbols using the format
+// information in the symbol, and checks them against the encoder's input.
+func TestRoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		text  string
+		level Level
+	}{
+		{"HELLO WORLD", LevelQ},
+		{"WIFI:T:WPA;S:My Network;P:correct horse battery staple;;", LevelM},
+		{strings.Repeat("lightshell ", 60), LevelH},
+		{strings.Repeat("31415926535", 40), LevelL},
+	} {
+		q, err := EncodeQR(tt.text, tt.level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, data, _ := encodeData(tt.text, tt.level)
+
+		// Format information around the top-left finder
+		bits := 0
+		for i := 0; i <= 5; i++ {
+			bits |= b2i(q.modules[i][8]) << i
+		}
+		bits |= b2i(q.modules[7][8])<<6 | b2i(q.modules[8][8])<<7 | b2i(q.modules[8][7])<<8
+		for i := 9; i < 15; i++ {
+			bits |= b2i(q.modules[8][14-i]) << i
+		}
+		if bits != formatInfo(tt.level, q.Mask) {
+			t.Fatalf("%q: format bits %015b do not match level %d mask %d", tt.text, bits, tt.level, q.Mask)
+		}
+
+		q.applyMask(q.Mask)
+		var read bitBuffer
+		for right := q.size - 1; right >= 1; right -= 2 {
+			if right == 6 {
+				right = 5
+			}
+			upward := (right+1)&2 == 0
+			for vert := 0; vert < q.size; vert++ {
+				for j := 0; j < 2; j++ {
+					x, y := right-j, vert
+					if upward {
+						y = q.size - 1 - vert
+					}
+					if !q.isFunction[y][x] {
+						read.bits = append(read.bits, q.modules[y][x])
+					}
+				}
+			}
+		}
+		raw := read.bytes()[:numRawDataModules(q.Version)/8]
+		if want := q.addECCAndInterleave(data); !bytes.Equal(raw, want) {
+			t.Errorf("%q: codewords read back do not match those written", tt.text)
+		}
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestQRImage(t *testing.T) {
+	q, err := EncodeQR("https://lightshell.dev", LevelM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale := QRScale(q, 256, 4)
+	img := QRImage(q, scale, 4, DefaultColors)
+	if got, want := img.Bounds().Dx(), (q.Size()+8)*scale; got != want {
+		t.Errorf("image width = %d, want %d", got, want)
+	}
+	if img.Bounds().Dx() > 256 {
+		t.Errorf("image width %d exceeds requested size", img.Bounds().Dx())
+	}
+	// Quiet zone is light; the finder's top-left corner is dark
+	if img.ColorIndexAt(0, 0) != 0 || img.ColorIndexAt(4*scale, 4*scale) != 1 {
+		t.Error("unexpected quiet zone or finder pixels")
+	}
+}
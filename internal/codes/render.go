@@ -0,0 +1,58 @@
+package codes
+
+import (
+	"image"
+	"image/color"
+)
+
+// Colors are the dark and light colors of a rendered code.
+type Colors struct {
+	Foreground color.Color
+	Background color.Color
+}
+
+// DefaultColors renders black on white, which every scanner reads.
+var DefaultColors = Colors{Foreground: color.Black, Background: color.White}
+
+// QRImage renders q with scale pixels per module and a quiet zone of margin
+// modules on each side.
+func QRImage(q *QR, scale, margin int, colors Colors) *image.Paletted {
+	scale = max(1, scale)
+	margin = max(0, margin)
+	n := (q.Size() + 2*margin) * scale
+	img := image.NewPaletted(image.Rect(0, 0, n, n), color.Palette{colors.Background, colors.Foreground})
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if q.Dark(x/scale-margin, y/scale-margin) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// QRScale returns the largest whole-pixel module size that fits q, with its
+// quiet zone, within size pixels.
+func QRScale(q *QR, size, margin int) int {
+	return max(1, size/(q.Size()+2*max(0, margin)))
+}
+
+// BarcodeImage renders barcode modules with scale pixels per module, height
+// pixels tall, and a quiet zone of margin modules on each side.
+func BarcodeImage(modules []bool, scale, height, margin int, colors Colors) *image.Paletted {
+	scale = max(1, scale)
+	height = max(1, height)
+	margin = max(0, margin)
+	w := (len(modules) + 2*margin) * scale
+	img := image.NewPaletted(image.Rect(0, 0, w, height), color.Palette{colors.Background, colors.Foreground})
+	for x := 0; x < w; x++ {
+		m := x/scale - margin
+		if m < 0 || m >= len(modules) || !modules[m] {
+			continue
+		}
+		for y := 0; y < height; y++ {
+			img.SetColorIndex(x, y, 1)
+		}
+	}
+	return img
+}
@@ -0,0 +1,74 @@
+// Package codesig checks macOS code signatures, so that an update is only
+// swapped in when it was signed by the same team as the code it replaces.
+// A release server that is compromised can serve a build whose SHA-256
+// matches its own manifest; it cannot sign that build with the team's
+// Developer ID.
+package codesig
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrUnsupported is returned on platforms without code signatures to check.
+var ErrUnsupported = errors.New("code signatures can only be checked on macOS")
+
+// Info describes a valid code signature.
+type Info struct {
+	Identifier string // the signing identifier, such as dev.lightshell.cli
+	TeamID     string // the Developer ID team; empty for ad-hoc signatures
+}
+
+// teamIDPattern matches Apple team identifiers, which are also spliced
+// into the requirement Check verifies.
+var teamIDPattern = regexp.MustCompile(`^[A-Z0-9]{10}$`)
+
+// Self returns the signature of the running executable.
+func Self() (Info, error) {
+	return self()
+}
+
+// Check verifies the signature of the executable, app bundle, or disk image
+// at path, including any code nested in it, and returns it. When teamID is
+// set the signature must also chain to Apple's CA and be issued to that
+// team, as Developer ID signatures are.
+func Check(path, teamID string) (Info, error) {
+	if teamID != "" && !teamIDPattern.MatchString(teamID) {
+		return Info{}, fmt.Errorf("invalid team identifier %q", teamID)
+	}
+	return check(path, teamID)
+}
+
+// VerifyUpdate checks that the code at path is signed by the team that
+// signed the running executable. There is nothing to compare against when
+// the running executable has no team, as in development builds, or on
+// platforms other than macOS, so those updates pass.
+func VerifyUpdate(path string) error {
+	running, err := Self()
+	if errors.Is(err, ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read the running app's signature: %w", err)
+	}
+	return sameSigner(running, path, Check)
+}
+
+// sameSigner checks path against the running signature with check.
+func sameSigner(running Info, path string, check func(path, teamID string) (Info, error)) error {
+	if running.TeamID == "" {
+		return nil
+	}
+	got, err := check(path, running.TeamID)
+	if err != nil {
+		return fmt.Errorf("the update is not signed by team %s: %w", running.TeamID, err)
+	}
+	if got.TeamID != running.TeamID {
+		return fmt.Errorf("the update is signed by team %q, not %s", got.TeamID, running.TeamID)
+	}
+	if got.Identifier != running.Identifier {
+		return fmt.Errorf("the update is signed as %q, not %q", got.Identifier, running.Identifier)
+	}
+	return nil
+}
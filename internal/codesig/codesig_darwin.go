@@ -0,0 +1,135 @@
+//go:build darwin
+
+package codesig
+
+/*
+#cgo darwin LDFLAGS: -framework Security -framework CoreFoundation
+
+#include <Security/Security.h>
+#include <stdlib.h>
+#include <string.h>
+
+static char *codesigCString(CFStringRef s) {
+	if (s == NULL) return NULL;
+	CFIndex size = CFStringGetMaximumSizeForEncoding(CFStringGetLength(s), kCFStringEncodingUTF8) + 1;
+	char *buf = malloc(size);
+	if (!CFStringGetCString(s, buf, size, kCFStringEncodingUTF8)) {
+		free(buf);
+		return NULL;
+	}
+	return buf;
+}
+
+static char *codesigErrorMessage(OSStatus status) {
+	CFStringRef msg = SecCopyErrorMessageString(status, NULL);
+	if (msg == NULL) return NULL;
+	char *out = codesigCString(msg);
+	CFRelease(msg);
+	return out;
+}
+
+static OSStatus codesigInfo(SecStaticCodeRef code, char **ident, char **team) {
+	CFDictionaryRef info = NULL;
+	OSStatus status = SecCodeCopySigningInformation(code, kSecCSSigningInformation, &info);
+	if (status != errSecSuccess) return status;
+	*ident = codesigCString(CFDictionaryGetValue(info, kSecCodeInfoIdentifier));
+	*team = codesigCString(CFDictionaryGetValue(info, kSecCodeInfoTeamIdentifier));
+	CFRelease(info);
+	return errSecSuccess;
+}
+
+static OSStatus codesigSelf(char **ident, char **team) {
+	SecCodeRef code = NULL;
+	OSStatus status = SecCodeCopySelf(kSecCSDefaultFlags, &code);
+	if (status != errSecSuccess) return status;
+	SecStaticCodeRef staticCode = NULL;
+	status = SecCodeCopyStaticCode(code, kSecCSDefaultFlags, &staticCode);
+	CFRelease(code);
+	if (status != errSecSuccess) return status;
+	status = codesigInfo(staticCode, ident, team);
+	CFRelease(staticCode);
+	return status;
+}
+
+static OSStatus codesigCheck(const char *path, const char *requirement, char **ident, char **team) {
+	CFURLRef url = CFURLCreateFromFileSystemRepresentation(NULL, (const UInt8 *)path, strlen(path), false);
+	SecStaticCodeRef code = NULL;
+	OSStatus status = SecStaticCodeCreateWithPath(url, kSecCSDefaultFlags, &code);
+	CFRelease(url);
+	if (status != errSecSuccess) return status;
+
+	SecRequirementRef req = NULL;
+	if (requirement != NULL) {
+		CFStringRef text = CFStringCreateWithCString(NULL, requirement, kCFStringEncodingUTF8);
+		status = SecRequirementCreateWithString(text, kSecCSDefaultFlags, &req);
+		CFRelease(text);
+		if (status != errSecSuccess) {
+			CFRelease(code);
+			return status;
+		}
+	}
+
+	status = SecStaticCodeCheckValidity(code, kSecCSCheckAllArchitectures | kSecCSCheckNestedCode | kSecCSStrictValidate, req);
+	if (status == errSecSuccess) {
+		status = codesigInfo(code, ident, team);
+	}
+	if (req != NULL) CFRelease(req);
+	CFRelease(code);
+	return status;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+func self() (Info, error) {
+	var ident, team *C.char
+	if status := C.codesigSelf(&ident, &team); status != 0 {
+		return Info{}, statusError(status)
+	}
+	return takeInfo(ident, team), nil
+}
+
+func check(path, teamID string) (Info, error) {
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+	var cReq *C.char
+	if teamID != "" {
+		// Developer ID certificates carry the team in their subject's OU
+		cReq = C.CString(fmt.Sprintf(`anchor apple generic and certificate leaf[subject.OU] = "%s"`, teamID))
+		defer C.free(unsafe.Pointer(cReq))
+	}
+
+	var ident, team *C.char
+	if status := C.codesigCheck(cPath, cReq, &ident, &team); status != 0 {
+		return Info{}, statusError(status)
+	}
+	return takeInfo(ident, team), nil
+}
+
+// takeInfo converts and frees the strings codesigInfo returned.
+func takeInfo(ident, team *C.char) Info {
+	var info Info
+	if ident != nil {
+		info.Identifier = C.GoString(ident)
+		C.free(unsafe.Pointer(ident))
+	}
+	if team != nil {
+		info.TeamID = C.GoString(team)
+		C.free(unsafe.Pointer(team))
+	}
+	return info
+}
+
+// statusError describes a Security framework status.
+func statusError(status C.OSStatus) error {
+	msg := C.codesigErrorMessage(status)
+	if msg == nil {
+		return fmt.Errorf("code signature check failed (OSStatus %d)", int(status))
+	}
+	defer C.free(unsafe.Pointer(msg))
+	return fmt.Errorf("%s (OSStatus %d)", C.GoString(msg), int(status))
+}
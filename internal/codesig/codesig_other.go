@@ -0,0 +1,11 @@
+//go:build !darwin
+
+package codesig
+
+func self() (Info, error) {
+	return Info{}, ErrUnsupported
+}
+
+func check(path, teamID string) (Info, error) {
+	return Info{}, ErrUnsupported
+}
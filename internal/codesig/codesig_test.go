@@ -0,0 +1,53 @@
+package codesig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSameSigner(t *testing.T) {
+	running := Info{Identifier: "dev.lightshell.cli", TeamID: "ABCDE12345"}
+	fails := errors.New("a sealed resource is missing or invalid")
+
+	tests := []struct {
+		name    string
+		running Info
+		got     Info
+		err     error
+		want    string // substring of the error; empty for none
+	}{
+		{"same team", running, running, nil, ""},
+		{"unsigned running app", Info{Identifier: "a.out"}, Info{}, fails, ""},
+		{"invalid signature", running, Info{}, fails, "not signed by team ABCDE12345"},
+		{"other team", running, Info{Identifier: "dev.lightshell.cli", TeamID: "ZZZZZ99999"}, nil, `team "ZZZZZ99999"`},
+		{"other identifier", running, Info{Identifier: "com.evil.cli", TeamID: "ABCDE12345"}, nil, `"com.evil.cli"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTeam string
+			err := sameSigner(tt.running, "/tmp/update", func(path, teamID string) (Info, error) {
+				gotTeam = teamID
+				return tt.got, tt.err
+			})
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+			if gotTeam != tt.running.TeamID {
+				t.Errorf("checked against team %q, want %q", gotTeam, tt.running.TeamID)
+			}
+		})
+	}
+}
+
+func TestCheckRejectsInvalidTeamID(t *testing.T) {
+	if _, err := Check("/tmp/update", `X" or anchor trusted or "`); err == nil || !strings.Contains(err.Error(), "invalid team identifier") {
+		t.Errorf("expected an invalid team identifier error, got %v", err)
+	}
+}
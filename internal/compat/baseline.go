@@ -0,0 +1,131 @@
+package compat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultBaselineTTL is how long a baseline suppresses known issues before
+// doctor reports everything again.
+const DefaultBaselineTTL = 90 * 24 * time.Hour
+
+// Baseline is a snapshot of accepted issues. Issues in the baseline are
+// not reported, so a project adopting LightShell sees only new problems.
+type Baseline struct {
+	CreatedAt time.Time       `json:"createdAt"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+	Issues    []BaselineEntry `json:"issues"`
+}
+
+// BaselineEntry identifies one accepted issue. The fingerprint covers the
+// line's content rather than its number, so edits elsewhere in the file do
+// not resurface it.
+type BaselineEntry struct {
+	Rule        string `json:"rule"`
+	File        string `json:"file"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// BaselinePath returns where the project's baseline is stored.
+func BaselinePath(dir string) string {
+	return filepath.Join(dir, ".lightshell", "doctor-baseline.json")
+}
+
+// NewBaseline snapshots issues, expiring ttl after now.
+func NewBaseline(issues []Issue, now time.Time, ttl time.Duration) *Baseline {
+	b := &Baseline{CreatedAt: now.UTC(), ExpiresAt: now.UTC().Add(ttl), Issues: []BaselineEntry{}}
+	for _, issue := range issues {
+		b.Issues = append(b.Issues, baselineEntry(issue))
+	}
+	sort.Slice(b.Issues, func(i, j int) bool {
+		a, c := b.Issues[i], b.Issues[j]
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		if a.Rule != c.Rule {
+			return a.Rule < c.Rule
+		}
+		return a.Fingerprint < c.Fingerprint
+	})
+	return b
+}
+
+// LoadBaseline reads a baseline file.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Save writes the baseline, creating its directory.
+func (b *Baseline) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Expired reports whether the baseline no longer applies at now.
+func (b *Baseline) Expired(now time.Time) bool {
+	return !b.ExpiresAt.IsZero() && now.After(b.ExpiresAt)
+}
+
+// Filter returns the issues not covered by the baseline and how many were
+// suppressed. Each entry covers one occurrence, so a second copy of an
+// accepted line is still reported.
+func (b *Baseline) Filter(issues []Issue) (fresh []Issue, suppressed int) {
+	remaining := make(map[BaselineEntry]int, len(b.Issues))
+	for _, e := range b.Issues {
+		remaining[e]++
+	}
+	for _, issue := range issues {
+		e := baselineEntry(issue)
+		if remaining[e] > 0 {
+			remaining[e]--
+			suppressed++
+			continue
+		}
+		fresh = append(fresh, issue)
+	}
+	return fresh, suppressed
+}
+
+// Prune drops entries for issues that are no longer found and renews the
+// expiry. It never adds issues, so updating a baseline cannot hide new ones.
+func (b *Baseline) Prune(issues []Issue, now time.Time, ttl time.Duration) (removed int) {
+	found := make(map[BaselineEntry]int, len(issues))
+	for _, issue := range issues {
+		found[baselineEntry(issue)]++
+	}
+	kept := []BaselineEntry{}
+	for _, e := range b.Issues {
+		if found[e] > 0 {
+			found[e]--
+			kept = append(kept, e)
+		}
+	}
+	removed = len(b.Issues) - len(kept)
+	b.Issues = kept
+	b.ExpiresAt = now.UTC().Add(ttl)
+	return removed
+}
+
+func baselineEntry(issue Issue) BaselineEntry {
+	sum := sha256.Sum256([]byte(issue.Snippet))
+	return BaselineEntry{Rule: issue.Rule.ID, File: filepath.ToSlash(issue.File), Fingerprint: hex.EncodeToString(sum[:8])}
+}
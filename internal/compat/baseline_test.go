@@ -0,0 +1,72 @@
+package compat
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func baselineIssue(file, rule, snippet string, line int) Issue {
+	return Issue{File: file, Line: line, Snippet: snippet, Rule: CompatRule{ID: rule}}
+}
+
+func TestBaselineFilter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	known := []Issue{
+		baselineIssue("src/a.css", "CSS-001", ".x { backdrop-filter: blur(4px); }", 3),
+		baselineIssue("src/b.js", "JS-002", "new Intl.Segmenter()", 10),
+	}
+	b := NewBaseline(known, now, DefaultBaselineTTL)
+
+	path := BaselinePath(t.TempDir())
+	if err := b.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline: %v", err)
+	}
+	if filepath.Base(filepath.Dir(path)) != ".lightshell" {
+		t.Errorf("baseline path %s is not under .lightshell", path)
+	}
+
+	current := []Issue{
+		baselineIssue("src/a.css", "CSS-001", ".x { backdrop-filter: blur(4px); }", 7), // moved down
+		baselineIssue("src/a.css", "CSS-001", ".x { backdrop-filter: blur(4px); }", 9), // a second copy
+		baselineIssue("src/c.js", "JS-002", "new Intl.Segmenter()", 1),                 // new file
+	}
+	fresh, suppressed := loaded.Filter(current)
+	if suppressed != 1 {
+		t.Errorf("suppressed = %d, want 1", suppressed)
+	}
+	if len(fresh) != 2 || fresh[0].Line != 9 || fresh[1].File != "src/c.js" {
+		t.Errorf("fresh = %+v, want the second copy and the new file", fresh)
+	}
+}
+
+func TestBaselinePruneAndExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBaseline([]Issue{
+		baselineIssue("src/a.css", "CSS-001", "backdrop-filter: blur(4px);", 1),
+		baselineIssue("src/b.js", "JS-002", "new Intl.Segmenter()", 1),
+	}, now, 24*time.Hour)
+
+	if b.Expired(now.Add(time.Hour)) {
+		t.Error("baseline expired before its TTL")
+	}
+	if !b.Expired(now.Add(25 * time.Hour)) {
+		t.Error("baseline did not expire after its TTL")
+	}
+
+	later := now.Add(48 * time.Hour)
+	removed := b.Prune([]Issue{
+		baselineIssue("src/b.js", "JS-002", "new Intl.Segmenter()", 4),
+		baselineIssue("src/d.js", "JS-008", "require('fs')", 1),
+	}, later, 24*time.Hour)
+	if removed != 1 || len(b.Issues) != 1 || b.Issues[0].File != "src/b.js" {
+		t.Errorf("Prune removed %d, kept %+v; want only src/b.js kept", removed, b.Issues)
+	}
+	if b.Expired(later) {
+		t.Error("Prune did not renew the expiry")
+	}
+}
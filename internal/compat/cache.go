@@ -0,0 +1,155 @@
+package compat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// scanCacheVersion changes whenever the cache layout does.
+const scanCacheVersion = 2
+
+// ScanCachePath returns where ScanProject caches per-file results.
+func ScanCachePath(dir string) string {
+	return filepath.Join(dir, ".lightshell", "cache", "compat-scan.json")
+}
+
+// scanCache maps a file's path, relative to the project with forward
+// slashes, to the issues found at a given content hash. The rules
+// fingerprint invalidates every entry when a rule's patterns change.
+type scanCache struct {
+	Version int                       `json:"version"`
+	Rules   string                    `json:"rules"`
+	Files   map[string]scanCacheEntry `json:"files"`
+
+	path  string
+	dirty bool
+}
+
+type scanCacheEntry struct {
+	Hash   string        `json:"hash"`
+	Issues []cachedIssue `json:"issues,omitempty"`
+}
+
+// cachedIssue stores the rule by ID; the rest of the Issue is rebuilt from
+// the current rule database.
+type cachedIssue struct {
+	Rule     string `json:"rule"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Selector string `json:"selector,omitempty"`
+	Snippet  string `json:"snippet"`
+}
+
+// loadScanCache reads the project's cache. A missing, unreadable, or stale
+// cache yields an empty one.
+func loadScanCache(dir string) *scanCache {
+	path := ScanCachePath(dir)
+	fresh := &scanCache{Version: scanCacheVersion, Rules: rulesFingerprint(), Files: map[string]scanCacheEntry{}, path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fresh
+	}
+	var c scanCache
+	if err := json.Unmarshal(data, &c); err != nil || c.Version != fresh.Version || c.Rules != fresh.Rules || c.Files == nil {
+		fresh.dirty = true
+		return fresh
+	}
+	c.path = path
+	return &c
+}
+
+// lookup returns the cached issues for file if its content is unchanged.
+func (c *scanCache) lookup(file, hash string) ([]Issue, bool) {
+	entry, ok := c.Files[file]
+	if !ok || entry.Hash != hash {
+		return nil, false
+	}
+	relPath := filepath.FromSlash(file)
+	issues := make([]Issue, 0, len(entry.Issues))
+	for _, ci := range entry.Issues {
+		rule, ok := ruleByID(ci.Rule)
+		if !ok {
+			return nil, false
+		}
+		issue := newIssue(rule, relPath, ci.Line, ci.Snippet)
+		issue.Column = ci.Column
+		issue.Selector = ci.Selector
+		issues = append(issues, issue)
+	}
+	return issues, true
+}
+
+func (c *scanCache) store(file, hash string, issues []Issue) {
+	entry := scanCacheEntry{Hash: hash}
+	for _, issue := range issues {
+		entry.Issues = append(entry.Issues, cachedIssue{Rule: issue.Rule.ID, Line: issue.Line, Column: issue.Column, Selector: issue.Selector, Snippet: issue.Snippet})
+	}
+	c.Files[file] = entry
+	c.dirty = true
+}
+
+// prune drops entries for files that no longer exist.
+func (c *scanCache) prune(seen map[string]bool) {
+	for file := range c.Files {
+		if !seen[file] {
+			delete(c.Files, file)
+			c.dirty = true
+		}
+	}
+}
+
+// save writes the cache if it changed. Errors are ignored: the cache only
+// saves work, and a project directory may be read-only.
+func (c *scanCache) save() {
+	if !c.dirty {
+		return
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	c.dirty = false
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// rulesFingerprint hashes what decides whether a rule matches a line, so
+// adding a rule or changing a pattern invalidates cached results.
+func rulesFingerprint() string {
+	h := sha256.New()
+	for _, rule := range Rules {
+		h.Write([]byte(rule.ID + "\x00" + strings.Join(rule.FileTypes, ",") + "\x00" + strings.Join(rule.Patterns, "\x00") + "\x01"))
+		if rule.CSS != nil {
+			fmt.Fprintf(h, "%+v\x01", *rule.CSS)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func ruleByID(id string) (CompatRule, bool) {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return CompatRule{}, false
+}
@@ -0,0 +1,76 @@
+package compat
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceFilesNoDuplicates(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"app.js":          "",
+		"style.css":       "",
+		"pages/about.htm": "",
+		"lib/util.js":     "",
+		"readme.md":       "",
+	})
+	files := sourceFiles(dir, []string{"*.js", "*.css", "*.html", "*.htm"})
+	if len(files) != 4 {
+		t.Fatalf("sourceFiles = %v, want 4 files", files)
+	}
+	seen := map[string]bool{}
+	for _, f := range files {
+		if seen[f] {
+			t.Errorf("%s listed twice", f)
+		}
+		seen[f] = true
+	}
+}
+
+func TestScanCache(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"app.js":    "const x = structuredClone(data);",
+		"style.css": "body { margin: 0; }",
+	})
+	issues, err := ScanProject(dir)
+	if err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1", len(issues))
+	}
+
+	// An unchanged file is served from the cache: edit the cached snippet
+	// and check the scan returns it.
+	cachePath := ScanCachePath(dir)
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+	var c scanCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	entry := c.Files["src/app.js"]
+	entry.Issues[0].Snippet = "from cache"
+	c.Files["src/app.js"] = entry
+	data, _ = json.Marshal(c)
+	os.WriteFile(cachePath, data, 0o644)
+
+	issues, _ = ScanProject(dir)
+	if len(issues) != 1 || issues[0].Snippet != "from cache" || issues[0].Title != issues[0].Rule.Title {
+		t.Fatalf("second scan = %+v, want the cached issue", issues)
+	}
+
+	// A changed file is re-scanned, and a deleted one leaves the cache.
+	os.WriteFile(filepath.Join(dir, "src", "style.css"), []byte(".a { backdrop-filter: blur(2px); }"), 0o644)
+	os.Remove(filepath.Join(dir, "src", "app.js"))
+	issues, _ = ScanProject(dir)
+	if len(issues) != 1 || issues[0].Rule.ID != "CSS-001" {
+		t.Fatalf("third scan = %+v, want only CSS-001", issues)
+	}
+	if got := loadScanCache(dir).Files; len(got) != 1 {
+		t.Errorf("cache files = %v, want only style.css", got)
+	}
+}
@@ -0,0 +1,512 @@
+package compat
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// CSSMatch describes what a rule looks for in stylesheets, matched against
+// tokenized CSS rather than raw lines. Strings, comments, url() contents,
+// and @supports conditions never match, and minified CSS is matched the
+// same as formatted CSS. An issue is reported if any field matches.
+type CSSMatch struct {
+	Properties []string // declarations of these properties; vendor prefixes are ignored
+	Values     []string // with Properties: only when the value has one of these keywords
+	Functions  []string // function calls in a declaration value, e.g. "color-mix"
+	Selectors  []string // pseudo-classes or pseudo-elements, e.g. ":has" or "::view-transition"
+	AtRules    []string // at-rules, without the @
+	Nesting    bool     // a rule nested directly inside a style rule
+}
+
+// String describes the match for the compat-rules catalog.
+func (m *CSSMatch) String() string {
+	var parts []string
+	if len(m.Properties) > 0 {
+		s := "property " + strings.Join(m.Properties, " | ")
+		if len(m.Values) > 0 {
+			s += " with value " + strings.Join(m.Values, " | ")
+		}
+		parts = append(parts, s)
+	}
+	if len(m.Functions) > 0 {
+		parts = append(parts, "function "+strings.Join(m.Functions, "() | ")+"()")
+	}
+	if len(m.Selectors) > 0 {
+		parts = append(parts, "selector "+strings.Join(m.Selectors, " | "))
+	}
+	if len(m.AtRules) > 0 {
+		parts = append(parts, "@"+strings.Join(m.AtRules, " | @"))
+	}
+	if m.Nesting {
+		parts = append(parts, "nested style rules")
+	}
+	return strings.Join(parts, "; ")
+}
+
+type cssTokenKind int
+
+const (
+	cssWhitespace cssTokenKind = iota
+	cssIdent
+	cssFunction // name( — value is the name
+	cssAtKeyword
+	cssHash
+	cssString
+	cssURL
+	cssNumber
+	cssColon
+	cssSemicolon
+	cssLeftBrace
+	cssRightBrace
+	cssLeftParen
+	cssRightParen
+	cssDelim
+)
+
+type cssToken struct {
+	kind  cssTokenKind
+	value string // lowercased name for idents, functions, and at-keywords
+	text  string // the source text
+	line  int
+	col   int
+}
+
+// tokenizeCSS splits src into tokens, following the CSS Syntax tokenizer
+// closely enough to tell names apart from strings, comments, and url()
+// contents. Positions start at line and col.
+func tokenizeCSS(src string, line, col int) []cssToken {
+	var tokens []cssToken
+	i := 0
+	advance := func(to int) {
+		for _, r := range src[i:to] {
+			if r == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+		}
+		i = to
+	}
+	emit := func(kind cssTokenKind, value string, end int) {
+		tokens = append(tokens, cssToken{kind: kind, value: value, text: src[i:end], line: line, col: col})
+		advance(end)
+	}
+
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 4
+			}
+			// A comment separates tokens like whitespace does
+			emit(cssWhitespace, " ", end)
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			end := i
+			for end < len(src) && strings.IndexByte(" \t\n\r\f", src[end]) >= 0 {
+				end++
+			}
+			emit(cssWhitespace, " ", end)
+		case c == '"' || c == '\'':
+			emit(cssString, "", cssStringEnd(src, i))
+		case c == '@' && cssStartsName(src, i+1):
+			end := cssNameEnd(src, i+1)
+			emit(cssAtKeyword, strings.ToLower(src[i+1:end]), end)
+		case c == '#' && i+1 < len(src) && cssIsNameByte(src[i+1]):
+			emit(cssHash, "", cssNameEnd(src, i+1))
+		case cssStartsNumber(src, i):
+			end := i + 1
+			for end < len(src) && (src[end] >= '0' && src[end] <= '9' || src[end] == '.') {
+				end++
+			}
+			if end < len(src) && src[end] == '%' {
+				end++
+			} else if cssStartsName(src, end) {
+				end = cssNameEnd(src, end)
+			}
+			emit(cssNumber, "", end)
+		case cssStartsName(src, i):
+			end := cssNameEnd(src, i)
+			name := strings.ToLower(src[i:end])
+			if end < len(src) && src[end] == '(' {
+				if name == "url" {
+					if urlEnd, ok := cssURLEnd(src, end+1); ok {
+						emit(cssURL, "", urlEnd)
+						continue
+					}
+				}
+				emit(cssFunction, name, end+1)
+				continue
+			}
+			emit(cssIdent, name, end)
+		case c == ':':
+			emit(cssColon, "", i+1)
+		case c == ';':
+			emit(cssSemicolon, "", i+1)
+		case c == '{':
+			emit(cssLeftBrace, "", i+1)
+		case c == '}':
+			emit(cssRightBrace, "", i+1)
+		case c == '(':
+			emit(cssLeftParen, "", i+1)
+		case c == ')':
+			emit(cssRightParen, "", i+1)
+		default:
+			_, size := utf8.DecodeRuneInString(src[i:])
+			emit(cssDelim, src[i:i+size], i+size)
+		}
+	}
+	return tokens
+}
+
+func cssIsNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '\\' || c >= 0x80
+}
+
+// cssStartsName reports whether an identifier starts at i.
+func cssStartsName(src string, i int) bool {
+	if i >= len(src) {
+		return false
+	}
+	c := src[i]
+	if c == '-' {
+		return i+1 < len(src) && (src[i+1] == '-' || cssIsNameByte(src[i+1]) && !(src[i+1] >= '0' && src[i+1] <= '9'))
+	}
+	return cssIsNameByte(c) && !(c >= '0' && c <= '9')
+}
+
+func cssStartsNumber(src string, i int) bool {
+	isDigit := func(j int) bool { return j < len(src) && src[j] >= '0' && src[j] <= '9' }
+	switch src[i] {
+	case '+', '-':
+		return isDigit(i+1) || src[i+1:] != "" && src[i+1] == '.' && isDigit(i+2)
+	case '.':
+		return isDigit(i + 1)
+	}
+	return isDigit(i)
+}
+
+func cssNameEnd(src string, i int) int {
+	for i < len(src) && cssIsNameByte(src[i]) {
+		if src[i] == '\\' && i+1 < len(src) {
+			i++
+		}
+		i++
+	}
+	return i
+}
+
+func cssStringEnd(src string, i int) int {
+	quote := src[i]
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		case '\n':
+			return j // an unterminated string ends at the line
+		}
+	}
+	return len(src)
+}
+
+// cssURLEnd finds the end of an unquoted url(...), whose contents may hold
+// characters like ; that would otherwise end a declaration. A quoted url()
+// is tokenized as a function.
+func cssURLEnd(src string, i int) (int, bool) {
+	j := i
+	for j < len(src) && strings.IndexByte(" \t\n\r\f", src[j]) >= 0 {
+		j++
+	}
+	if j < len(src) && (src[j] == '"' || src[j] == '\'') {
+		return 0, false
+	}
+	end := strings.IndexByte(src[j:], ')')
+	if end < 0 {
+		return len(src), true
+	}
+	return j + end + 1, true
+}
+
+// cssMatch is an issue found by matchCSS, before it is tied to a file.
+type cssMatch struct {
+	rule     CompatRule
+	line     int
+	col      int
+	selector string
+	snippet  string
+}
+
+type cssBlockKind int
+
+const (
+	cssTopLevel cssBlockKind = iota
+	cssStyleRule
+	cssGroupRule        // an at-rule holding rules, like @media
+	cssDeclarationBlock // an at-rule holding declarations, like @font-face
+)
+
+type cssBlock struct {
+	kind     cssBlockKind
+	selector string // for a style rule
+}
+
+// cssDeclarationAtRules hold declarations rather than rules.
+var cssDeclarationAtRules = map[string]bool{
+	"font-face": true, "page": true, "property": true, "counter-style": true,
+	"font-palette-values": true, "view-transition": true, "viewport": true,
+}
+
+// matchCSS checks tokenized CSS against the rules with a CSSMatch. inline
+// is for a style attribute, whose contents are declarations.
+func matchCSS(tokens []cssToken, rules []CompatRule, inline bool) []cssMatch {
+	var matches []cssMatch
+	stack := []cssBlock{{kind: cssTopLevel}}
+	if inline {
+		stack[0] = cssBlock{kind: cssStyleRule}
+	}
+
+	var prelude []cssToken
+	// Parentheses and brackets in a prelude, as in :is(a, b) or a value's
+	// function arguments, do not end it.
+	depth := 0
+	flush := func(end cssTokenKind) {
+		parts := trimCSS(prelude)
+		prelude = prelude[:0]
+		parent := stack[len(stack)-1]
+		if len(parts) == 0 {
+			if end == cssLeftBrace {
+				stack = append(stack, cssBlock{kind: cssStyleRule})
+			}
+			return
+		}
+
+		if parts[0].kind == cssAtKeyword {
+			name := parts[0].value
+			for _, rule := range rules {
+				if containsName(rule.CSS.AtRules, name) || end == cssLeftBrace && rule.CSS.Nesting && parent.kind == cssStyleRule {
+					matches = append(matches, cssMatch{rule: rule, line: parts[0].line, col: parts[0].col, selector: parent.selector, snippet: cssText(parts)})
+				}
+			}
+			if end == cssLeftBrace {
+				kind := cssGroupRule
+				if cssDeclarationAtRules[name] {
+					kind = cssDeclarationBlock
+				}
+				stack = append(stack, cssBlock{kind: kind, selector: parent.selector})
+			}
+			return
+		}
+
+		if end == cssLeftBrace {
+			selector := cssText(parts)
+			for _, rule := range rules {
+				if rule.CSS.Nesting && parent.kind == cssStyleRule {
+					matches = append(matches, cssMatch{rule: rule, line: parts[0].line, col: parts[0].col, selector: selector, snippet: selector})
+					continue
+				}
+				if tok, ok := findPseudo(parts, rule.CSS.Selectors); ok {
+					matches = append(matches, cssMatch{rule: rule, line: tok.line, col: tok.col, selector: selector, snippet: selector})
+				}
+			}
+			stack = append(stack, cssBlock{kind: cssStyleRule, selector: selector})
+			return
+		}
+
+		if parent.kind != cssStyleRule && parent.kind != cssDeclarationBlock {
+			return
+		}
+		// A declaration: name, optional whitespace, colon, value
+		if parts[0].kind != cssIdent {
+			return
+		}
+		colon := 1
+		for colon < len(parts) && parts[colon].kind == cssWhitespace {
+			colon++
+		}
+		if colon >= len(parts) || parts[colon].kind != cssColon {
+			return
+		}
+		property := unprefixed(parts[0].value)
+		value := parts[colon+1:]
+		for _, rule := range rules {
+			m := rule.CSS
+			at := parts[0]
+			matched := false
+			if containsName(m.Properties, property) {
+				if len(m.Values) == 0 {
+					matched = true
+				} else if tok, ok := findToken(value, cssIdent, m.Values); ok {
+					matched, at = true, tok
+				}
+			}
+			if !matched {
+				if tok, ok := findToken(value, cssFunction, m.Functions); ok {
+					matched, at = true, tok
+				}
+			}
+			if matched {
+				matches = append(matches, cssMatch{rule: rule, line: at.line, col: at.col, selector: parent.selector, snippet: cssText(parts)})
+			}
+		}
+	}
+
+	for _, tok := range tokens {
+		switch tok.kind {
+		case cssLeftParen, cssFunction:
+			depth++
+		case cssRightParen:
+			if depth > 0 {
+				depth--
+			}
+		case cssDelim:
+			if tok.text == "[" {
+				depth++
+			} else if tok.text == "]" && depth > 0 {
+				depth--
+			}
+		}
+		if depth > 0 {
+			prelude = append(prelude, tok)
+			continue
+		}
+		switch tok.kind {
+		case cssLeftBrace:
+			flush(cssLeftBrace)
+		case cssSemicolon:
+			flush(cssSemicolon)
+		case cssRightBrace:
+			flush(cssRightBrace)
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			prelude = append(prelude, tok)
+		}
+	}
+	flush(cssSemicolon)
+	return matches
+}
+
+// trimCSS drops leading and trailing whitespace tokens.
+func trimCSS(tokens []cssToken) []cssToken {
+	for len(tokens) > 0 && tokens[0].kind == cssWhitespace {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].kind == cssWhitespace {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+// maxCSSSnippet bounds a snippet, since a minified value can be very long.
+const maxCSSSnippet = 200
+
+// cssText joins tokens back into source text, collapsing whitespace.
+func cssText(tokens []cssToken) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		if tok.kind == cssWhitespace {
+			b.WriteByte(' ')
+		} else {
+			b.WriteString(tok.text)
+		}
+	}
+	s := b.String()
+	if len(s) > maxCSSSnippet {
+		cut := maxCSSSnippet
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut] + "…"
+	}
+	return s
+}
+
+// findPseudo finds a pseudo-class (":has") or pseudo-element
+// ("::view-transition") in a selector.
+func findPseudo(tokens []cssToken, names []string) (cssToken, bool) {
+	for i, tok := range tokens {
+		if tok.kind != cssColon || i+1 >= len(tokens) {
+			continue
+		}
+		prefix, next := ":", tokens[i+1]
+		if next.kind == cssColon && i+2 < len(tokens) {
+			prefix, next = "::", tokens[i+2]
+		}
+		if next.kind != cssIdent && next.kind != cssFunction {
+			continue
+		}
+		if containsName(names, prefix+next.value) {
+			return tok, true
+		}
+	}
+	return cssToken{}, false
+}
+
+func findToken(tokens []cssToken, kind cssTokenKind, names []string) (cssToken, bool) {
+	for _, tok := range tokens {
+		if tok.kind == kind && containsName(names, unprefixed(tok.value)) {
+			return tok, true
+		}
+	}
+	return cssToken{}, false
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unprefixed strips a vendor prefix, so -webkit-backdrop-filter matches
+// backdrop-filter.
+func unprefixed(name string) string {
+	for _, prefix := range []string{"-webkit-", "-moz-", "-ms-", "-o-"} {
+		if strings.HasPrefix(name, prefix) {
+			return name[len(prefix):]
+		}
+	}
+	return name
+}
+
+var (
+	styleElementPattern   = regexp.MustCompile(`(?is)<style\b[^>]*>(.*?)</style\s*>`)
+	styleAttributePattern = regexp.MustCompile(`(?is)\sstyle\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// matchHTMLCSS checks the <style> elements and style attributes of an HTML
+// document.
+func matchHTMLCSS(src string, rules []CompatRule) []cssMatch {
+	var matches []cssMatch
+	for _, loc := range styleElementPattern.FindAllStringSubmatchIndex(src, -1) {
+		line, col := position(src, loc[2])
+		matches = append(matches, matchCSS(tokenizeCSS(src[loc[2]:loc[3]], line, col), rules, false)...)
+	}
+	for _, loc := range styleAttributePattern.FindAllStringSubmatchIndex(src, -1) {
+		start, end := loc[2], loc[3]
+		if start < 0 {
+			start, end = loc[4], loc[5]
+		}
+		line, col := position(src, start)
+		matches = append(matches, matchCSS(tokenizeCSS(src[start:end], line, col), rules, true)...)
+	}
+	return matches
+}
+
+// position returns the 1-based line and column of offset in src.
+func position(src string, offset int) (line, col int) {
+	before := src[:offset]
+	line = strings.Count(before, "\n") + 1
+	col = utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return line, col
+}
@@ -0,0 +1,84 @@
+package compat
+
+import "testing"
+
+func TestCSSScannerStructural(t *testing.T) {
+	tests := []struct {
+		name   string
+		css    string
+		ruleID string
+		want   int
+	}{
+		{"ampersand in string", `.a::after { content: "Tom & .Jerry"; }`, "CSS-005", 0},
+		{"ampersand in comment", "/* & .b */ .a { color: red; }", "CSS-005", 0},
+		{"minified nesting", ".a{color:red;&:hover{color:blue}.b{color:green}}", "CSS-005", 2},
+		{"nested media", ".a { @media (min-width: 1px) { color: red; } }", "CSS-005", 1},
+		{"keyframes are not nesting", "@keyframes spin { from { opacity: 0; } to { opacity: 1; } }", "CSS-005", 0},
+		{"prefixed property", ".a { -webkit-backdrop-filter: blur(2px); }", "CSS-001", 1},
+		{"property name in a value", ".a { transition: backdrop-filter 1s; }", "CSS-001", 0},
+		{"class named like a property", ".backdrop-filter { color: red; }", "CSS-001", 0},
+		{"font shorthand", ".a { font: 14px/1.4 system-ui, sans-serif; }", "CSS-002", 1},
+		{"system-ui elsewhere", ".a { content: 'system-ui'; font-family: Inter; }", "CSS-002", 0},
+		{"has in is", ".a:is(.b:has(img)) { color: red; }", "CSS-003", 1},
+		{"has in supports", "@supports selector(:has(a)) { .a { color: red; } }", "CSS-003", 0},
+		{"color-mix in supports", "@supports (color: color-mix(in srgb, red, blue)) { .a { color: red; } }", "CSS-004", 0},
+		{"color-mix in value", ".a{color:color-mix(in srgb,red,blue)}", "CSS-004", 1},
+		{"url with semicolon", ".a{background:url(data:image/svg+xml;utf8,<svg/>);color:color-mix(in srgb,red,blue)}", "CSS-004", 1},
+		{"container query", "@container sidebar (min-width: 400px) { .a { display: flex; } }", "CSS-006", 1},
+		{"view transition pseudo", "::view-transition-new(root) { animation: none; }", "CSS-007", 1},
+	}
+	for _, tt := range tests {
+		rule, _ := ruleByID(tt.ruleID)
+		got := matchCSS(tokenizeCSS(tt.css, 1, 1), []CompatRule{rule}, false)
+		if len(got) != tt.want {
+			t.Errorf("%s: %d match(es) of %s in %q, want %d", tt.name, len(got), tt.ruleID, tt.css, tt.want)
+		}
+	}
+}
+
+func TestCSSScannerPosition(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"style.css": ".card {\n  padding: 0;\n  .title { color: gray; }\n}\n.x{a:b}.y:has(.z){backdrop-filter:blur(1px)}",
+	})
+	issues, err := ScanProject(dir)
+	if err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	want := []struct {
+		rule, selector, snippet string
+		line, col               int
+	}{
+		{"CSS-005", ".title", ".title", 3, 3},
+		{"CSS-003", ".y:has(.z)", ".y:has(.z)", 5, 10},
+		{"CSS-001", ".y:has(.z)", "backdrop-filter:blur(1px)", 5, 19},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d: %+v", len(issues), len(want), issues)
+	}
+	for i, w := range want {
+		got := issues[i]
+		if got.Rule.ID != w.rule || got.Line != w.line || got.Column != w.col || got.Selector != w.selector || got.Snippet != w.snippet {
+			t.Errorf("issue %d = %s %d:%d %q %q, want %s %d:%d %q %q", i, got.Rule.ID, got.Line, got.Column, got.Selector, got.Snippet, w.rule, w.line, w.col, w.selector, w.snippet)
+		}
+	}
+}
+
+func TestCSSScannerHTML(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"index.html": "<html>\n<head><style>\n  .a { color: color-mix(in srgb, red, blue); }\n</style></head>\n" +
+			"<body><p class=\"backdrop-filter\">x</p>\n<div style=\"backdrop-filter: blur(4px)\"></div></body></html>",
+	})
+	issues, err := ScanProject(dir)
+	if err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("got %d issues, want 2: %+v", len(issues), issues)
+	}
+	if issues[0].Rule.ID != "CSS-004" || issues[0].Line != 3 || issues[0].Column != 15 {
+		t.Errorf("first issue = %s %d:%d, want CSS-004 3:15", issues[0].Rule.ID, issues[0].Line, issues[0].Column)
+	}
+	if issues[1].Rule.ID != "CSS-001" || issues[1].Line != 6 || issues[1].Column != 13 {
+		t.Errorf("second issue = %s %d:%d, want CSS-001 6:13", issues[1].Rule.ID, issues[1].Line, issues[1].Column)
+	}
+}
@@ -0,0 +1,382 @@
+package compat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lightshell-dev/lightshell/internal/clientjs"
+)
+
+// PolyfillFile is the name of the polyfill bundle lightshell doctor --fix
+// writes beside the entry HTML, for the rules whose issues LightShell
+// polyfills (AutoFix). The runtime injects the same bundle; loading it from
+// the page also covers the page opened in a browser. Scans skip it.
+const PolyfillFile = "lightshell-polyfills.js"
+
+// A Rewrite replaces a call that has a LightShell equivalent. Only the
+// lines a scan reported for Rule are rewritten.
+type Rewrite struct {
+	Rule    string
+	From    string // the call replaced, for people
+	To      string // what replaces it, for people
+	Note    string // what to check in the rewritten code
+	pattern *regexp.Regexp
+	replace string
+}
+
+// Rewrites are the simple patterns lightshell doctor --fix rewrites. Calls
+// with arguments other than these are left for people to port, since their
+// options differ.
+var Rewrites = []Rewrite{
+	newRewrite("JS-004", `showOpenFilePicker\(\)`, "showOpenFilePicker()", "lightshell.dialog.open()", "it returns a path, not file handles"),
+	newRewrite("JS-004", `showOpenFilePicker\(\{\s*multiple:\s*true\s*\}\)`, "showOpenFilePicker({ multiple: true })", "lightshell.dialog.open({ multiple: true })", "it returns paths, not file handles"),
+	newRewrite("JS-004", `showSaveFilePicker\(\)`, "showSaveFilePicker()", "lightshell.dialog.save()", "it returns a path, not a file handle"),
+	newRewrite("JS-004", `showDirectoryPicker\(\)`, "showDirectoryPicker()", "lightshell.dialog.open({ directory: true })", "it returns a path, not a directory handle"),
+	newRewrite("JS-003", `navigation\.back\(\)`, "navigation.back()", "history.back()", ""),
+}
+
+// newRewrite rewrites the calls matching pattern, on window or not, to to.
+func newRewrite(rule, pattern, from, to, note string) Rewrite {
+	return Rewrite{
+		Rule:    rule,
+		From:    from,
+		To:      to,
+		Note:    note,
+		pattern: regexp.MustCompile(`(^|[^\w$.])(?:window\.)?` + pattern),
+		replace: "${1}" + to,
+	}
+}
+
+// A FileFix is the change lightshell doctor --fix makes to one file.
+type FileFix struct {
+	File    string   // relative to the project
+	Created bool     // the file is new
+	Changes []string // one line per change, for the report
+
+	old   []string // the lines of the file, with their line endings
+	edits []lineEdit
+}
+
+// lineEdit replaces del lines of a file from line at (0-based) with ins.
+type lineEdit struct {
+	at  int
+	del int
+	ins []string
+}
+
+// PlanFixes works out the fixes for issues in the project dir, whose entry
+// HTML is entry, without writing them: the simple calls Rewrites covers
+// are rewritten, and when any issue is polyfilled the polyfill bundle is
+// written beside the entry and loaded from it. Fixes are in file order.
+func PlanFixes(dir, entry string, issues []Issue) ([]*FileFix, error) {
+	var fixes []*FileFix
+	byFile := map[string]*FileFix{}
+	fixFor := func(file string, create bool) (*FileFix, error) {
+		if f, ok := byFile[file]; ok {
+			return f, nil
+		}
+		f := &FileFix{File: file}
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		switch {
+		case err == nil:
+			f.old = splitLines(string(data))
+		case create && os.IsNotExist(err):
+			f.Created = true
+		default:
+			return nil, err
+		}
+		byFile[file] = f
+		fixes = append(fixes, f)
+		return f, nil
+	}
+
+	polyfilled := false
+	done := map[string]bool{}
+	for _, issue := range issues {
+		polyfilled = polyfilled || issue.AutoFix
+		key := fmt.Sprintf("%s:%d:%s", issue.File, issue.Line, issue.Rule.ID)
+		if done[key] || !hasRewrite(issue.Rule.ID) {
+			continue
+		}
+		done[key] = true
+
+		f, err := fixFor(issue.File, false)
+		if err != nil {
+			return nil, err
+		}
+		if issue.Line < 1 || issue.Line > len(f.old) {
+			continue
+		}
+		line := f.rewritten(issue.Line - 1)
+		for _, r := range Rewrites {
+			if r.Rule != issue.Rule.ID || !r.pattern.MatchString(line) {
+				continue
+			}
+			line = r.pattern.ReplaceAllString(line, r.replace)
+			change := fmt.Sprintf("line %d: %s -> %s", issue.Line, r.From, r.To)
+			if r.Note != "" {
+				change += "; " + r.Note
+			}
+			f.Changes = append(f.Changes, change)
+		}
+		if line != f.rewritten(issue.Line-1) {
+			f.replace(issue.Line-1, line)
+		}
+	}
+
+	if polyfilled {
+		if err := planPolyfills(dir, entry, fixFor); err != nil {
+			return nil, err
+		}
+	}
+
+	var planned []*FileFix
+	for _, f := range fixes {
+		if len(f.edits) > 0 {
+			sort.SliceStable(f.edits, func(i, j int) bool {
+				if f.edits[i].at != f.edits[j].at {
+					return f.edits[i].at < f.edits[j].at
+				}
+				return f.edits[i].del < f.edits[j].del // insertions before a line come first
+			})
+			planned = append(planned, f)
+		}
+	}
+	return planned, nil
+}
+
+var (
+	headTag   = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	headEnd   = regexp.MustCompile(`(?i)</head>`)
+	scriptTag = regexp.MustCompile(`(?i)<script[\s>]`)
+	bodyEnd   = regexp.MustCompile(`(?i)</body>`)
+	metaChar  = regexp.MustCompile(`(?i)<meta\s+charset`)
+)
+
+// planPolyfills writes the polyfill bundle beside the entry HTML, or
+// updates it, and loads it first thing in the entry unless it already does.
+func planPolyfills(dir, entry string, fixFor func(string, bool) (*FileFix, error)) error {
+	if ext := strings.ToLower(filepath.Ext(entry)); ext != ".html" && ext != ".htm" {
+		return nil
+	}
+	page, err := fixFor(filepath.Clean(entry), false)
+	if err != nil {
+		return fmt.Errorf("could not read the entry HTML: %w", err)
+	}
+
+	bundlePath := filepath.Join(filepath.Dir(filepath.Clean(entry)), PolyfillFile)
+	bundle, err := fixFor(bundlePath, true)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", bundlePath, err)
+	}
+	if want := splitLines(clientjs.Polyfills); strings.Join(bundle.old, "") != clientjs.Polyfills {
+		bundle.edits = append(bundle.edits, lineEdit{at: 0, del: len(bundle.old), ins: want})
+		if bundle.Created {
+			bundle.Changes = append(bundle.Changes, "the LightShell polyfill bundle")
+		} else {
+			bundle.Changes = append(bundle.Changes, "updated to this version's polyfill bundle")
+		}
+	}
+
+	for _, line := range page.old {
+		if strings.Contains(line, PolyfillFile) {
+			return nil
+		}
+	}
+	at, indent := polyfillScriptPosition(page.old)
+	eol := "\n"
+	if at > 0 && strings.HasSuffix(page.old[at-1], "\r\n") {
+		eol = "\r\n"
+	}
+	script := indent + `<script src="` + PolyfillFile + `"></script>` + eol
+	if at == len(page.old) && at > 0 && !strings.HasSuffix(page.old[at-1], "\n") {
+		// The last line gets the line ending it lacked
+		page.replace(at-1, page.rewritten(at-1)+eol)
+	}
+	page.edits = append(page.edits, lineEdit{at: at, ins: []string{script}})
+	page.Changes = append(page.Changes, fmt.Sprintf("loads %s before the page's scripts", PolyfillFile))
+	return nil
+}
+
+// polyfillScriptPosition returns the line to insert the polyfill script
+// before, and its indentation: the start of <head>, after its charset, or
+// else the first script, or else the end of <body>.
+func polyfillScriptPosition(lines []string) (int, string) {
+	for i, line := range lines {
+		if headTag.MatchString(line) && !headEnd.MatchString(line) {
+			at := i + 1
+			for at < len(lines) && metaChar.MatchString(lines[at]) {
+				at++
+			}
+			if at < len(lines) && strings.TrimSpace(lines[at]) != "" {
+				return at, indentation(lines[at])
+			}
+			return at, indentation(line) + "  "
+		}
+	}
+	for i, line := range lines {
+		if scriptTag.MatchString(line) {
+			return i, indentation(line)
+		}
+	}
+	for i, line := range lines {
+		if bodyEnd.MatchString(line) {
+			return i, indentation(line)
+		}
+	}
+	return len(lines), ""
+}
+
+func indentation(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func hasRewrite(rule string) bool {
+	for _, r := range Rewrites {
+		if r.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// rewritten returns line i as the edits so far leave it.
+func (f *FileFix) rewritten(i int) string {
+	for _, e := range f.edits {
+		if e.at == i && e.del == 1 {
+			return e.ins[0]
+		}
+	}
+	return f.old[i]
+}
+
+// replace sets line i to line.
+func (f *FileFix) replace(i int, line string) {
+	for j, e := range f.edits {
+		if e.at == i && e.del == 1 {
+			f.edits[j].ins = []string{line}
+			return
+		}
+	}
+	f.edits = append(f.edits, lineEdit{at: i, del: 1, ins: []string{line}})
+}
+
+// Content returns the file as the fix leaves it.
+func (f *FileFix) Content() []byte {
+	var b strings.Builder
+	pos := 0
+	for _, e := range f.edits {
+		for ; pos < e.at; pos++ {
+			b.WriteString(f.old[pos])
+		}
+		for _, line := range e.ins {
+			b.WriteString(line)
+		}
+		pos = e.at + e.del
+	}
+	for ; pos < len(f.old); pos++ {
+		b.WriteString(f.old[pos])
+	}
+	return []byte(b.String())
+}
+
+// Apply writes the fixed file in the project dir.
+func (f *FileFix) Apply(dir string) error {
+	path := filepath.Join(dir, f.File)
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, f.Content(), mode); err != nil {
+		return fmt.Errorf("could not write %s: %w", f.File, err)
+	}
+	return nil
+}
+
+// diffContext is how many unchanged lines a diff shows around a change.
+const diffContext = 3
+
+// Diff returns the fix as a unified diff.
+func (f *FileFix) Diff() string {
+	var b strings.Builder
+	name := filepath.ToSlash(f.File)
+	if f.Created {
+		b.WriteString("--- /dev/null\n")
+	} else {
+		b.WriteString("--- a/" + name + "\n")
+	}
+	b.WriteString("+++ b/" + name + "\n")
+
+	offset := 0 // how many lines the edits before the hunk added
+	for i := 0; i < len(f.edits); {
+		// Edits whose context touches are one hunk
+		end := i + 1
+		for end < len(f.edits) && f.edits[end].at-(f.edits[end-1].at+f.edits[end-1].del) <= 2*diffContext {
+			end++
+		}
+		start := max(f.edits[i].at-diffContext, 0)
+		last := f.edits[end-1]
+		stop := min(last.at+last.del+diffContext, len(f.old))
+
+		var body strings.Builder
+		oldN, newN := 0, 0
+		pos := start
+		newStart := start + offset
+		for _, e := range f.edits[i:end] {
+			for ; pos < e.at; pos++ {
+				writeDiffLine(&body, " ", f.old[pos])
+				oldN++
+				newN++
+			}
+			for _, line := range f.old[e.at : e.at+e.del] {
+				writeDiffLine(&body, "-", line)
+				oldN++
+			}
+			for _, line := range e.ins {
+				writeDiffLine(&body, "+", line)
+				newN++
+			}
+			pos = e.at + e.del
+			offset += len(e.ins) - e.del
+		}
+		for ; pos < stop; pos++ {
+			writeDiffLine(&body, " ", f.old[pos])
+			oldN++
+			newN++
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(start, oldN), hunkRange(newStart, newN))
+		b.WriteString(body.String())
+		i = end
+	}
+	return b.String()
+}
+
+// hunkRange formats the lines of a hunk from the 0-based start; an empty
+// range names the line before it.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+func writeDiffLine(b *strings.Builder, prefix, line string) {
+	b.WriteString(prefix + line)
+	if !strings.HasSuffix(line, "\n") {
+		b.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// splitLines splits s into lines that keep their line endings.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,176 @@
+package compat
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lightshell-dev/lightshell/internal/clientjs"
+)
+
+const fixTestPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>App</title>
+  <script src="app.js"></script>
+</head>
+</html>
+`
+
+func planTestFixes(t *testing.T, dir string) []*FileFix {
+	t.Helper()
+	issues, err := ScanProject(dir)
+	if err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	fixes, err := PlanFixes(dir, "src/index.html", issues)
+	if err != nil {
+		t.Fatalf("PlanFixes failed: %v", err)
+	}
+	return fixes
+}
+
+func findFix(fixes []*FileFix, file string) *FileFix {
+	for _, f := range fixes {
+		if filepath.ToSlash(f.File) == file {
+			return f
+		}
+	}
+	return nil
+}
+
+func TestPlanFixesRewritesSimpleCalls(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"index.html": fixTestPage,
+		"app.js": "const [h] = await window.showOpenFilePicker()\n" +
+			"const all = await showOpenFilePicker({ multiple: true })\n" +
+			"const out = await showSaveFilePicker()\n" +
+			"const typed = await showOpenFilePicker({ types: [] })\n" +
+			"navigation.back()\n" +
+			"my.navigation.back()\n",
+	})
+
+	f := findFix(planTestFixes(t, dir), "src/app.js")
+	if f == nil {
+		t.Fatal("expected a fix for src/app.js")
+	}
+	want := "const [h] = await lightshell.dialog.open()\n" +
+		"const all = await lightshell.dialog.open({ multiple: true })\n" +
+		"const out = await lightshell.dialog.save()\n" +
+		"const typed = await showOpenFilePicker({ types: [] })\n" +
+		"history.back()\n" +
+		"my.navigation.back()\n"
+	if got := string(f.Content()); got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+	if len(f.Changes) != 4 {
+		t.Errorf("changes = %q, want 4", f.Changes)
+	}
+	if !strings.HasPrefix(f.Changes[0], "line 1: showOpenFilePicker() -> lightshell.dialog.open()") {
+		t.Errorf("change = %q", f.Changes[0])
+	}
+}
+
+func TestPlanFixesInjectsPolyfills(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"index.html": fixTestPage,
+		"app.js":     "const copy = structuredClone(state)\n",
+	})
+
+	fixes := planTestFixes(t, dir)
+	if len(fixes) != 2 {
+		t.Fatalf("fixes = %d, want 2", len(fixes))
+	}
+	page := findFix(fixes, "src/index.html")
+	if page == nil {
+		t.Fatal("expected a fix for the entry HTML")
+	}
+	want := strings.Replace(fixTestPage, "<title>", "<script src=\"lightshell-polyfills.js\"></script>\n  <title>", 1)
+	if got := string(page.Content()); got != want {
+		t.Errorf("entry = %q, want %q", got, want)
+	}
+	bundle := findFix(fixes, "src/"+PolyfillFile)
+	if bundle == nil || !bundle.Created {
+		t.Fatal("expected the polyfill bundle to be created")
+	}
+	if string(bundle.Content()) != clientjs.Polyfills {
+		t.Error("bundle differs from the runtime's polyfills")
+	}
+
+	for _, f := range fixes {
+		if err := f.Apply(dir); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+	if fixes := planTestFixes(t, dir); len(fixes) != 0 {
+		t.Errorf("a second plan has %d fixes, want none", len(fixes))
+	}
+	issues, _ := ScanProject(dir)
+	for _, issue := range issues {
+		if strings.HasSuffix(issue.File, PolyfillFile) {
+			t.Errorf("scan reported the polyfill bundle: %s", issue.Rule.ID)
+		}
+	}
+}
+
+func TestPlanFixesUpdatesOldBundle(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"index.html": strings.Replace(fixTestPage, "<head>\n", "<head>\n<script src=\"lightshell-polyfills.js\"></script>\n", 1),
+		"app.js":     "const copy = structuredClone(state)\n",
+		PolyfillFile: "// old\n",
+	})
+
+	fixes := planTestFixes(t, dir)
+	if len(fixes) != 1 {
+		t.Fatalf("fixes = %d, want only the bundle", len(fixes))
+	}
+	if fixes[0].Created || string(fixes[0].Content()) != clientjs.Polyfills {
+		t.Error("expected the bundle to be replaced")
+	}
+}
+
+func TestPlanFixesWithoutHead(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"index.html": "<div id=\"app\"></div>\n<script type=\"module\" src=\"app.js\"></script>",
+		"app.js":     "const copy = structuredClone(state)\n",
+	})
+
+	page := findFix(planTestFixes(t, dir), "src/index.html")
+	if page == nil {
+		t.Fatal("expected a fix for the entry HTML")
+	}
+	want := "<div id=\"app\"></div>\n<script src=\"lightshell-polyfills.js\"></script>\n<script type=\"module\" src=\"app.js\"></script>"
+	if got := string(page.Content()); got != want {
+		t.Errorf("entry = %q, want %q", got, want)
+	}
+}
+
+func TestFileFixDiff(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"index.html": fixTestPage,
+		"app.js":     "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nshowSaveFilePicker()\nl",
+	})
+	issues, err := ScanProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixes, err := PlanFixes(dir, "src/index.html", issues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := findFix(fixes, "src/app.js")
+	if f == nil {
+		t.Fatal("expected a fix for src/app.js")
+	}
+	want := "--- a/src/app.js\n+++ b/src/app.js\n" +
+		"@@ -9,5 +9,5 @@\n i\n j\n k\n-showSaveFilePicker()\n+lightshell.dialog.save()\n l\n\\ No newline at end of file\n"
+	if got := f.Diff(); got != want {
+		t.Errorf("diff =\n%s\nwant\n%s", got, want)
+	}
+
+	created := &FileFix{File: "src/new.js", Created: true, edits: []lineEdit{{at: 0, ins: []string{"x\n", "y\n"}}}}
+	if got, want := created.Diff(), "--- /dev/null\n+++ b/src/new.js\n@@ -0,0 +1,2 @@\n+x\n+y\n"; got != want {
+		t.Errorf("diff =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -3,7 +3,7 @@
 
   // Platform class for targeted CSS
   document.documentElement.classList.add(
-    isLinux ? 'platform-linux' : 'platform-darwin'
+    isLinux ? 'platform-linux' : navigator.platform.startsWith('Win') ? 'platform-windows' : 'platform-darwin'
   )
 
   // structuredClone polyfill (missing in older WebKitGTK)
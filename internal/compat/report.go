@@ -0,0 +1,110 @@
+package compat
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// Report is the machine-readable form of a scan, printed by lightshell
+// doctor --json and returned by the MCP doctor tool.
+type Report struct {
+	Issues  []ReportIssue `json:"issues"`
+	Rules   []ReportRule  `json:"rules"` // the rules the issues broke, by ID
+	Summary ReportSummary `json:"summary"`
+}
+
+// ReportIssue is one issue in a Report.
+type ReportIssue struct {
+	File       string            `json:"file"` // slash-separated, relative to the project
+	Line       int               `json:"line"`
+	Column     int               `json:"column,omitempty"`
+	Selector   string            `json:"selector,omitempty"`
+	Rule       string            `json:"rule"`
+	Severity   string            `json:"severity"`
+	Title      string            `json:"title"`
+	Fix        string            `json:"fix,omitempty"`
+	AutoFix    bool              `json:"autoFix"`
+	Snippet    string            `json:"snippet"`
+	Platforms  []string          `json:"platforms"`
+	DocsURL    string            `json:"docsUrl,omitempty"`
+	MinVersion map[string]string `json:"minVersion"`
+}
+
+// ReportRule counts the issues of one rule in a Report, for CI that
+// tracks or gates on particular rules.
+type ReportRule struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Count    int    `json:"count"`
+	Files    int    `json:"files"` // how many files have its issues
+}
+
+// ReportSummary counts a Report's issues. Baselined issues are not in
+// Issues.
+type ReportSummary struct {
+	Files          int `json:"files"` // how many files have issues
+	Errors         int `json:"errors"`
+	Warnings       int `json:"warnings"`
+	AutoPolyfilled int `json:"autoPolyfilled"`
+	Baselined      int `json:"baselined"`
+}
+
+// NewReport builds the report for issues, with baselined issues left out
+// of the scan counted.
+func NewReport(issues []Issue, baselined int) Report {
+	r := Report{Issues: []ReportIssue{}, Rules: []ReportRule{}}
+	files := map[string]bool{}
+	rules := map[string]*ReportRule{}
+	ruleFiles := map[[2]string]bool{}
+	for _, issue := range issues {
+		minVersion := issue.Rule.MinVersion
+		if minVersion == nil {
+			minVersion = map[string]string{}
+		}
+		r.Issues = append(r.Issues, ReportIssue{
+			File:       filepath.ToSlash(issue.File),
+			Line:       issue.Line,
+			Column:     issue.Column,
+			Selector:   issue.Selector,
+			Rule:       issue.Rule.ID,
+			Severity:   issue.Severity,
+			Title:      issue.Title,
+			Fix:        issue.Fix,
+			AutoFix:    issue.AutoFix,
+			Snippet:    issue.Snippet,
+			Platforms:  issue.Rule.Platforms,
+			DocsURL:    issue.Rule.DocsURL,
+			MinVersion: minVersion,
+		})
+		if issue.Severity == "error" {
+			r.Summary.Errors++
+		} else {
+			r.Summary.Warnings++
+		}
+		if issue.AutoFix {
+			r.Summary.AutoPolyfilled++
+		}
+
+		files[issue.File] = true
+		rule := rules[issue.Rule.ID]
+		if rule == nil {
+			rule = &ReportRule{Rule: issue.Rule.ID, Severity: issue.Severity}
+			rules[issue.Rule.ID] = rule
+		}
+		rule.Count++
+		if key := [2]string{issue.Rule.ID, issue.File}; !ruleFiles[key] {
+			ruleFiles[key] = true
+			rule.Files++
+		}
+		if issue.Severity == "error" {
+			rule.Severity = "error"
+		}
+	}
+	for _, rule := range rules {
+		r.Rules = append(r.Rules, *rule)
+	}
+	sort.Slice(r.Rules, func(i, j int) bool { return r.Rules[i].Rule < r.Rules[j].Rule })
+	r.Summary.Files = len(files)
+	r.Summary.Baselined = baselined
+	return r
+}
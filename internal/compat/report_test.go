@@ -0,0 +1,47 @@
+package compat
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewReport(t *testing.T) {
+	rule := CompatRule{ID: "JS-002", Platforms: []string{"linux"}, DocsURL: "https://example.com/js-002"}
+	issues := []Issue{
+		{File: filepath.Join("src", "app.js"), Line: 4, Rule: rule, Severity: "error", Title: "t", Snippet: "x"},
+		{File: "style.css", Line: 1, Column: 3, Rule: CompatRule{ID: "CSS-001"}, Severity: "warning", AutoFix: true},
+		{File: "style.css", Line: 9, Rule: CompatRule{ID: "CSS-001"}, Severity: "warning", AutoFix: true},
+	}
+
+	r := NewReport(issues, 2)
+	if len(r.Issues) != 3 {
+		t.Fatalf("got %d issues, want 3", len(r.Issues))
+	}
+	got := r.Issues[0]
+	if got.File != "src/app.js" || got.Rule != "JS-002" || got.Line != 4 || got.DocsURL != rule.DocsURL {
+		t.Errorf("first issue = %+v", got)
+	}
+	if r.Issues[1].MinVersion == nil {
+		t.Error("MinVersion is nil; want an empty map so the JSON has an object")
+	}
+	want := ReportSummary{Files: 2, Errors: 1, Warnings: 2, AutoPolyfilled: 2, Baselined: 2}
+	if r.Summary != want {
+		t.Errorf("summary = %+v, want %+v", r.Summary, want)
+	}
+	wantRules := []ReportRule{
+		{Rule: "CSS-001", Severity: "warning", Count: 2, Files: 1},
+		{Rule: "JS-002", Severity: "error", Count: 1, Files: 1},
+	}
+	if len(r.Rules) != len(wantRules) {
+		t.Fatalf("rules = %+v, want %+v", r.Rules, wantRules)
+	}
+	for i := range wantRules {
+		if r.Rules[i] != wantRules[i] {
+			t.Errorf("rules[%d] = %+v, want %+v", i, r.Rules[i], wantRules[i])
+		}
+	}
+
+	if empty := NewReport(nil, 0); empty.Issues == nil || empty.Rules == nil {
+		t.Error("Issues or Rules is nil for a clean scan; want empty lists")
+	}
+}
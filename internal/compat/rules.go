@@ -1,5 +1,7 @@
 package compat
 
+import "strings"
+
 // CompatRule defines a known cross-platform compatibility issue.
 type CompatRule struct {
 	ID          string
@@ -7,16 +9,27 @@ type CompatRule struct {
 	Title       string
 	Description string
 	Platforms   []string
-	Patterns    []string // regex patterns to match in user code
-	FileTypes   []string // "css", "js", "html"
+	Patterns    []string  // regex patterns to match in user code
+	CSS         *CSSMatch // structural match in stylesheets; replaces Patterns there
+	FileTypes   []string  // "css", "js", "html"
 	Fix         string
 	AutoFix     bool
+	Before      string // short example the rule flags
+	After       string // the same example with Fix applied
+	DocsURL     string // reference page for the feature
+	// MinVersion maps an engine ("webkitgtk", "safari") to the first
+	// release that supports the feature. An engine is left out when no
+	// release supports it reliably.
+	MinVersion map[string]string
 }
 
 // Issue represents a detected compatibility problem in user code.
 type Issue struct {
 	File     string
 	Line     int
+	Column   int    // 1-based; 0 for issues matched by Patterns, which cover the whole line
+	Selector string // the style rule the issue is in, for stylesheet issues
+	Snippet  string // the matched line, trimmed, or for stylesheet issues the declaration, selector, or at-rule
 	Rule     CompatRule
 	Severity string
 	Title    string
@@ -27,14 +40,19 @@ type Issue struct {
 // Rules is the database of known cross-platform issues.
 var Rules = []CompatRule{
 	{
-		ID:        "CSS-001",
-		Severity:  "warning",
-		Title:     "backdrop-filter — limited on Linux (WebKitGTK)",
-		Platforms: []string{"linux"},
-		Patterns:  []string{`backdrop-filter`},
-		FileTypes: []string{"css", "html"},
-		Fix:       "fallback background injected at runtime",
-		AutoFix:   true,
+		ID:         "CSS-001",
+		Severity:   "warning",
+		Title:      "backdrop-filter — limited on Linux (WebKitGTK)",
+		Platforms:  []string{"linux"},
+		Patterns:   []string{`backdrop-filter`},
+		CSS:        &CSSMatch{Properties: []string{"backdrop-filter"}},
+		FileTypes:  []string{"css", "html"},
+		Fix:        "fallback background injected at runtime",
+		AutoFix:    true,
+		Before:     ".panel { backdrop-filter: blur(12px); }",
+		After:      ".panel {\n  background: rgba(255, 255, 255, 0.85); /* shown where blur is unsupported */\n  backdrop-filter: blur(12px);\n}",
+		DocsURL:    "https://developer.mozilla.org/en-US/docs/Web/CSS/backdrop-filter",
+		MinVersion: map[string]string{"safari": "9.0"},
 	},
 	{
 		ID:        "CSS-002",
@@ -42,79 +60,116 @@ var Rules = []CompatRule{
 		Title:     "system-ui font — renders differently across platforms",
 		Platforms: []string{"linux"},
 		Patterns:  []string{`font-family:.*system-ui`},
+		CSS:       &CSSMatch{Properties: []string{"font-family", "font"}, Values: []string{"system-ui"}},
 		FileTypes: []string{"css", "html"},
 		Fix:       "Use explicit font stack or bundle a web font",
 		AutoFix:   false,
+		Before:    "body { font-family: system-ui; }",
+		After:     "body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Cantarell, 'Noto Sans', sans-serif; }",
+		DocsURL:   "https://developer.mozilla.org/en-US/docs/Web/CSS/font-family",
 	},
 	{
-		ID:        "CSS-003",
-		Severity:  "warning",
-		Title:     ":has() selector — limited support on older WebKitGTK",
-		Platforms: []string{"linux"},
-		Patterns:  []string{`:has\(`},
-		FileTypes: []string{"css", "html"},
-		Fix:       "Use JavaScript or alternative CSS selectors for broader support",
-		AutoFix:   false,
+		ID:         "CSS-003",
+		Severity:   "warning",
+		Title:      ":has() selector — limited support on older WebKitGTK",
+		Platforms:  []string{"linux"},
+		Patterns:   []string{`:has\(`},
+		CSS:        &CSSMatch{Selectors: []string{":has"}},
+		FileTypes:  []string{"css", "html"},
+		Fix:        "Use JavaScript or alternative CSS selectors for broader support",
+		AutoFix:    false,
+		Before:     ".card:has(img) { padding: 0; }",
+		After:      ".card.has-image { padding: 0; }\n/* JS: card.classList.toggle('has-image', !!card.querySelector('img')) */",
+		DocsURL:    "https://developer.mozilla.org/en-US/docs/Web/CSS/:has",
+		MinVersion: map[string]string{"webkitgtk": "2.42", "safari": "15.4"},
 	},
 	{
-		ID:        "CSS-004",
-		Severity:  "warning",
-		Title:     "color-mix() — not supported on older WebKitGTK",
-		Platforms: []string{"linux"},
-		Patterns:  []string{`color-mix\(`},
-		FileTypes: []string{"css", "html"},
-		Fix:       "Use pre-computed color values instead",
-		AutoFix:   false,
+		ID:         "CSS-004",
+		Severity:   "warning",
+		Title:      "color-mix() — not supported on older WebKitGTK",
+		Platforms:  []string{"linux"},
+		Patterns:   []string{`color-mix\(`},
+		CSS:        &CSSMatch{Functions: []string{"color-mix"}},
+		FileTypes:  []string{"css", "html"},
+		Fix:        "Use pre-computed color values instead",
+		AutoFix:    false,
+		Before:     ".btn:hover { background: color-mix(in srgb, #3b82f6 80%, black); }",
+		After:      ".btn:hover { background: #2f68c5; }",
+		DocsURL:    "https://developer.mozilla.org/en-US/docs/Web/CSS/color_value/color-mix",
+		MinVersion: map[string]string{"webkitgtk": "2.40", "safari": "16.2"},
 	},
 	{
-		ID:        "CSS-005",
-		Severity:  "warning",
-		Title:     "CSS nesting — requires WebKitGTK 2.42+",
-		Platforms: []string{"linux"},
-		Patterns:  []string{`&\s*[.#\[]`},
-		FileTypes: []string{"css"},
-		Fix:       "Use flat CSS selectors for broader WebKitGTK support",
-		AutoFix:   false,
+		ID:         "CSS-005",
+		Severity:   "warning",
+		Title:      "CSS nesting — requires WebKitGTK 2.42+",
+		Platforms:  []string{"linux"},
+		Patterns:   []string{`&\s*[.#\[]`},
+		CSS:        &CSSMatch{Nesting: true},
+		FileTypes:  []string{"css"},
+		Fix:        "Use flat CSS selectors for broader WebKitGTK support",
+		AutoFix:    false,
+		Before:     ".nav {\n  & .item { color: gray; }\n}",
+		After:      ".nav .item { color: gray; }",
+		DocsURL:    "https://developer.mozilla.org/en-US/docs/Web/CSS/CSS_nesting",
+		MinVersion: map[string]string{"webkitgtk": "2.42", "safari": "16.5"},
 	},
 	{
-		ID:        "CSS-006",
-		Severity:  "warning",
-		Title:     "Container Queries — version-dependent WebKitGTK support",
-		Platforms: []string{"linux"},
-		Patterns:  []string{`@container`},
-		FileTypes: []string{"css", "html"},
-		Fix:       "Use media queries or resize observers as fallback",
-		AutoFix:   false,
+		ID:         "CSS-006",
+		Severity:   "warning",
+		Title:      "Container Queries — version-dependent WebKitGTK support",
+		Platforms:  []string{"linux"},
+		Patterns:   []string{`@container`},
+		CSS:        &CSSMatch{AtRules: []string{"container"}},
+		FileTypes:  []string{"css", "html"},
+		Fix:        "Use media queries or resize observers as fallback",
+		AutoFix:    false,
+		Before:     "@container (min-width: 400px) { .card { display: flex; } }",
+		After:      "@media (min-width: 400px) { .card { display: flex; } }",
+		DocsURL:    "https://developer.mozilla.org/en-US/docs/Web/CSS/CSS_containment/Container_queries",
+		MinVersion: map[string]string{"webkitgtk": "2.40", "safari": "16.0"},
 	},
 	{
-		ID:        "CSS-007",
-		Severity:  "warning",
-		Title:     "View Transitions API — not available in WebKitGTK",
-		Platforms: []string{"linux"},
-		Patterns:  []string{`view-transition`},
-		FileTypes: []string{"css", "html"},
-		Fix:       "Use CSS transitions/animations instead",
-		AutoFix:   false,
+		ID:         "CSS-007",
+		Severity:   "warning",
+		Title:      "View Transitions API — not available in WebKitGTK",
+		Platforms:  []string{"linux"},
+		Patterns:   []string{`view-transition`},
+		CSS:        &CSSMatch{Properties: []string{"view-transition-name", "view-transition-class"}, Selectors: []string{"::view-transition", "::view-transition-group", "::view-transition-image-pair", "::view-transition-old", "::view-transition-new"}, AtRules: []string{"view-transition"}},
+		FileTypes:  []string{"css", "html"},
+		Fix:        "Use CSS transitions/animations instead",
+		AutoFix:    false,
+		Before:     "::view-transition-old(root) { animation: fade-out 0.2s; }",
+		After:      ".page.leaving { animation: fade-out 0.2s; }",
+		DocsURL:    "https://developer.mozilla.org/en-US/docs/Web/API/View_Transition_API",
+		MinVersion: map[string]string{"safari": "18.0"},
 	},
 	{
-		ID:        "JS-001",
-		Severity:  "warning",
-		Title:     "structuredClone() — missing on WebKitGTK < 2.40",
-		Platforms: []string{"linux"},
-		Patterns:  []string{`structuredClone\(`},
-		FileTypes: []string{"js", "html"},
-		Fix:       "JSON-based clone injected at runtime",
-		AutoFix:   true,
+		ID:         "JS-001",
+		Severity:   "warning",
+		Title:      "structuredClone() — missing on WebKitGTK < 2.40",
+		Platforms:  []string{"linux"},
+		Patterns:   []string{`structuredClone\(`},
+		FileTypes:  []string{"js", "html"},
+		Fix:        "JSON-based clone injected at runtime",
+		AutoFix:    true,
+		Before:     "const copy = structuredClone(state)",
+		After:      "const copy = structuredClone(state) // polyfilled; Maps, Sets and Dates do not survive the JSON clone",
+		DocsURL:    "https://developer.mozilla.org/en-US/docs/Web/API/Window/structuredClone",
+		MinVersion: map[string]string{"webkitgtk": "2.40", "safari": "15.4"},
 	},
 	{
-		ID:        "JS-002",
-		Severity:  "warning",
-		Title:     "Intl.Segmenter — not available in WebKitGTK",
-		Platforms: []string{"linux"},
-		Patterns:  []string{`Intl\.Segmenter`},
-		FileTypes: []string{"js", "html"},
-		Fix:       "Use a polyfill library or alternative text segmentation",
-		AutoFix:   false,
+		ID:         "JS-002",
+		Severity:   "warning",
+		Title:      "Intl.Segmenter — not available in WebKitGTK",
+		Platforms:  []string{"linux"},
+		Patterns:   []string{`Intl\.Segmenter`},
+		FileTypes:  []string{"js", "html"},
+		Fix:        "Use a polyfill library or alternative text segmentation",
+		AutoFix:    false,
+		Before:     "const seg = new Intl.Segmenter('en', { granularity: 'word' })",
+		After:      "const words = text.split(/\\s+/)",
+		DocsURL:    "https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Global_Objects/Intl/Segmenter",
+		MinVersion: map[string]string{"safari": "14.1"},
 	},
 	{
 		ID:        "JS-003",
@@ -125,6 +180,9 @@ var Rules = []CompatRule{
 		FileTypes: []string{"js", "html"},
 		Fix:       "Use standard History API or lightshell.window instead",
 		AutoFix:   false,
+		Before:    "navigation.navigate('/settings')",
+		After:     "history.pushState({}, '', '/settings')\nrender('/settings')",
+		DocsURL:   "https://developer.mozilla.org/en-US/docs/Web/API/Navigation_API",
 	},
 	{
 		ID:        "JS-004",
@@ -135,6 +193,9 @@ var Rules = []CompatRule{
 		FileTypes: []string{"js", "html"},
 		Fix:       "Use lightshell.dialog.open() / lightshell.dialog.save() instead",
 		AutoFix:   false,
+		Before:    "const [handle] = await window.showOpenFilePicker()",
+		After:     "const path = await lightshell.dialog.open({ filters: [{ name: 'Text', extensions: ['txt'] }] })\nconst text = await lightshell.fs.readFile(path)",
+		DocsURL:   "https://developer.mozilla.org/en-US/docs/Web/API/Window/showOpenFilePicker",
 	},
 	{
 		ID:        "JS-005",
@@ -145,6 +206,9 @@ var Rules = []CompatRule{
 		FileTypes: []string{"js", "html"},
 		Fix:       "Web USB is not supported in system webviews",
 		AutoFix:   false,
+		Before:    "const device = await navigator.usb.requestDevice({ filters: [] })",
+		After:     "// Talk to the device from a helper CLI\nconst out = await lightshell.process.exec('usb-helper', ['list'])",
+		DocsURL:   "https://developer.mozilla.org/en-US/docs/Web/API/WebUSB_API",
 	},
 	{
 		ID:        "JS-006",
@@ -155,6 +219,9 @@ var Rules = []CompatRule{
 		FileTypes: []string{"js", "html"},
 		Fix:       "Web Bluetooth is not supported in system webviews",
 		AutoFix:   false,
+		Before:    "const device = await navigator.bluetooth.requestDevice({ acceptAllDevices: true })",
+		After:     "// Talk to the device from a helper CLI\nconst out = await lightshell.process.exec('ble-helper', ['scan'])",
+		DocsURL:   "https://developer.mozilla.org/en-US/docs/Web/API/Web_Bluetooth_API",
 	},
 	{
 		ID:        "JS-007",
@@ -165,6 +232,9 @@ var Rules = []CompatRule{
 		FileTypes: []string{"js", "html"},
 		Fix:       "Web Serial is not supported in system webviews",
 		AutoFix:   false,
+		Before:    "const port = await navigator.serial.requestPort()",
+		After:     "// Talk to the port from a helper CLI\nconst out = await lightshell.process.exec('serial-helper', ['/dev/ttyUSB0'])",
+		DocsURL:   "https://developer.mozilla.org/en-US/docs/Web/API/Web_Serial_API",
 	},
 	{
 		ID:        "JS-008",
@@ -175,6 +245,9 @@ var Rules = []CompatRule{
 		FileTypes: []string{"js"},
 		Fix:       "Use lightshell.* APIs instead of Node.js modules",
 		AutoFix:   false,
+		Before:    "const fs = require('fs')\nconst text = fs.readFileSync(path, 'utf8')",
+		After:     "const text = await lightshell.fs.readFile(path)",
+		DocsURL:   "https://lightshell.dev/docs/guides/migration-from-electron/",
 	},
 	{
 		ID:        "JS-009",
@@ -185,5 +258,27 @@ var Rules = []CompatRule{
 		FileTypes: []string{"js"},
 		Fix:       "Use lightshell.system.* APIs for environment info",
 		AutoFix:   false,
+		Before:    "const home = process.env.HOME",
+		After:     "const home = await lightshell.system.homeDir()",
+		DocsURL:   "https://lightshell.dev/docs/guides/migration-from-electron/",
 	},
 }
+
+// engineNames are the display names of the MinVersion engines, in the
+// order they are listed.
+var engineNames = []struct{ key, name string }{
+	{"webkitgtk", "WebKitGTK"},
+	{"safari", "Safari"},
+}
+
+// Requirements describes MinVersion for people, as in "WebKitGTK 2.42+,
+// Safari 16.5+". It is empty when no release supports the feature.
+func (r CompatRule) Requirements() string {
+	var parts []string
+	for _, e := range engineNames {
+		if v, ok := r.MinVersion[e.key]; ok {
+			parts = append(parts, e.name+" "+v+"+")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
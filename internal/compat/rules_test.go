@@ -0,0 +1,64 @@
+package compat
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestRuleExamples checks that each rule's Before example is flagged and,
+// unless the rule is fixed at runtime, its After example is not.
+func TestRuleExamples(t *testing.T) {
+	matches := func(rule CompatRule, code string) bool {
+		if rule.CSS != nil {
+			return len(matchCSS(tokenizeCSS(code, 1, 1), []CompatRule{rule}, false)) > 0
+		}
+		for _, pattern := range rule.Patterns {
+			if regexp.MustCompile(pattern).MatchString(code) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rule := range Rules {
+		if rule.Before == "" || rule.After == "" {
+			t.Errorf("%s: missing Before or After example", rule.ID)
+			continue
+		}
+		if !matches(rule, rule.Before) {
+			t.Errorf("%s: Before example %q is not flagged", rule.ID, rule.Before)
+		}
+		if !rule.AutoFix && matches(rule, rule.After) {
+			t.Errorf("%s: After example %q is still flagged", rule.ID, rule.After)
+		}
+	}
+}
+
+// TestRuleMetadata checks that every rule links to a reference page and
+// that MinVersion uses known engines and plain version numbers.
+func TestRuleMetadata(t *testing.T) {
+	version := regexp.MustCompile(`^\d+\.\d+$`)
+	for _, rule := range Rules {
+		if !strings.HasPrefix(rule.DocsURL, "https://") {
+			t.Errorf("%s: DocsURL %q is not an https URL", rule.ID, rule.DocsURL)
+		}
+		for engine, v := range rule.MinVersion {
+			if engine != "webkitgtk" && engine != "safari" {
+				t.Errorf("%s: unknown engine %q", rule.ID, engine)
+			}
+			if !version.MatchString(v) {
+				t.Errorf("%s: %s version %q is not major.minor", rule.ID, engine, v)
+			}
+		}
+	}
+}
+
+func TestRuleRequirements(t *testing.T) {
+	r := CompatRule{MinVersion: map[string]string{"safari": "16.5", "webkitgtk": "2.42"}}
+	if got, want := r.Requirements(), "WebKitGTK 2.42+, Safari 16.5+"; got != want {
+		t.Errorf("Requirements() = %q, want %q", got, want)
+	}
+	if got := (CompatRule{}).Requirements(); got != "" {
+		t.Errorf("Requirements() with no versions = %q, want empty", got)
+	}
+}
@@ -2,106 +2,185 @@ package compat
 
 import (
 	"bufio"
+	"bytes"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/lightshell-dev/lightshell/internal/ignore"
 )
 
-// ScanProject scans all user source files for compatibility issues.
+// ScanProject scans all user source files for compatibility issues. Results
+// are cached per file under .lightshell/cache, keyed by content hash, so a
+// repeat scan only re-scans files that changed.
 func ScanProject(dir string) ([]Issue, error) {
 	var issues []Issue
 
-	// Find source files
-	patterns := []string{"*.js", "*.css", "*.html", "*.htm"}
-	var files []string
+	files := sourceFiles(dir, []string{"*.js", "*.css", "*.html", "*.htm"})
 
-	for _, pattern := range patterns {
-		matches, _ := filepath.Glob(filepath.Join(dir, "src", pattern))
-		files = append(files, matches...)
-		// Also check subdirectories
-		filepath.Walk(filepath.Join(dir, "src"), func(path string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
-				return nil
-			}
-			ext := strings.ToLower(filepath.Ext(path))
-			for _, p := range patterns {
-				if matched, _ := filepath.Match(p, filepath.Base(path)); matched {
-					// Avoid duplicates
-					for _, f := range files {
-						if f == path {
-							return nil
-						}
-					}
-					files = append(files, path)
-					break
-				}
-			}
-			_ = ext
-			return nil
-		})
-	}
+	cache := loadScanCache(dir)
+	seen := make(map[string]bool, len(files))
+	var rules []compiledRule
 
 	for _, file := range files {
-		fileIssues, err := scanFile(file, dir)
+		// The bundle doctor --fix writes is LightShell's code, not the app's
+		if filepath.Base(file) == PolyfillFile {
+			continue
+		}
+		data, err := os.ReadFile(file)
 		if err != nil {
 			continue
 		}
+		relPath, _ := filepath.Rel(dir, file)
+		key := filepath.ToSlash(relPath)
+		seen[key] = true
+
+		hash := contentHash(data)
+		if fileIssues, ok := cache.lookup(key, hash); ok {
+			issues = append(issues, fileIssues...)
+			continue
+		}
+		if rules == nil {
+			rules = compileRules()
+		}
+		fileIssues := scanContent(data, relPath, rules)
+		cache.store(key, hash, fileIssues)
 		issues = append(issues, fileIssues...)
 	}
+	cache.prune(seen)
+	// The cache is an optimization; a read-only project still scans
+	cache.save()
 
 	return issues, nil
 }
 
-func scanFile(path, projectDir string) ([]Issue, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
+// sourceFiles returns the files under dir/src whose names match patterns,
+// in lexical order, leaving out those the project's .lightshellignore lists.
+func sourceFiles(dir string, patterns []string) []string {
+	ign, _ := ignore.Load(dir)
+	var files []string
+	filepath.Walk(filepath.Join(dir, "src"), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if rel, _ := filepath.Rel(dir, path); ign.Match(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		for _, p := range patterns {
+			if matched, _ := filepath.Match(p, filepath.Base(path)); matched {
+				files = append(files, path)
+				break
+			}
+		}
+		return nil
+	})
+	return files
+}
+
+// compiledRule pairs a rule with its compiled patterns. Patterns that do
+// not compile are skipped.
+type compiledRule struct {
+	rule     CompatRule
+	patterns []*regexp.Regexp
+}
+
+func compileRules() []compiledRule {
+	compiled := make([]compiledRule, 0, len(Rules))
+	for _, rule := range Rules {
+		c := compiledRule{rule: rule}
+		for _, pattern := range rule.Patterns {
+			if re, err := regexp.Compile(pattern); err == nil {
+				c.patterns = append(c.patterns, re)
+			}
+		}
+		compiled = append(compiled, c)
 	}
-	defer f.Close()
+	return compiled
+}
 
-	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+func scanContent(data []byte, relPath string, rules []compiledRule) []Issue {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(relPath)), ".")
 	if ext == "htm" {
 		ext = "html"
 	}
 
-	relPath, _ := filepath.Rel(projectDir, path)
+	// Stylesheets and the CSS in HTML are matched structurally by the
+	// rules that support it, and line by line by the rest.
+	var cssRules []CompatRule
+	if ext == "css" || ext == "html" {
+		for _, c := range rules {
+			if c.rule.CSS != nil && matchesFileType(c.rule.FileTypes, ext) {
+				cssRules = append(cssRules, c.rule)
+			}
+		}
+	}
 
 	var issues []Issue
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1) // minified files can be one long line
 	lineNum := 0
 
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
 
-		for _, rule := range Rules {
-			if !matchesFileType(rule.FileTypes, ext) {
+		for _, c := range rules {
+			if !matchesFileType(c.rule.FileTypes, ext) || c.rule.CSS != nil && len(cssRules) > 0 {
 				continue
 			}
 
-			for _, pattern := range rule.Patterns {
-				re, err := regexp.Compile(pattern)
-				if err != nil {
-					continue
-				}
+			for _, re := range c.patterns {
 				if re.MatchString(line) {
-					issues = append(issues, Issue{
-						File:     relPath,
-						Line:     lineNum,
-						Rule:     rule,
-						Severity: rule.Severity,
-						Title:    rule.Title,
-						Fix:      rule.Fix,
-						AutoFix:  rule.AutoFix,
-					})
+					issues = append(issues, newIssue(c.rule, relPath, lineNum, strings.TrimSpace(line)))
 					break // Only report once per rule per line
 				}
 			}
 		}
 	}
 
-	return issues, nil
+	if len(cssRules) > 0 {
+		var matches []cssMatch
+		if ext == "css" {
+			matches = matchCSS(tokenizeCSS(string(data), 1, 1), cssRules, false)
+		} else {
+			matches = matchHTMLCSS(string(data), cssRules)
+		}
+		for _, m := range matches {
+			issue := newIssue(m.rule, relPath, m.line, m.snippet)
+			issue.Column = m.col
+			issue.Selector = m.selector
+			issues = append(issues, issue)
+		}
+		sort.SliceStable(issues, func(i, j int) bool {
+			if issues[i].Line != issues[j].Line {
+				return issues[i].Line < issues[j].Line
+			}
+			return issues[i].Column < issues[j].Column
+		})
+	}
+
+	return issues
+}
+
+func newIssue(rule CompatRule, relPath string, line int, snippet string) Issue {
+	return Issue{
+		File:     relPath,
+		Line:     line,
+		Snippet:  snippet,
+		Rule:     rule,
+		Severity: rule.Severity,
+		Title:    rule.Title,
+		Fix:      rule.Fix,
+		AutoFix:  rule.AutoFix,
+	}
 }
 
 func matchesFileType(types []string, ext string) bool {
@@ -1,6 +1,7 @@
 package compat
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -60,6 +61,23 @@ func TestScannerFindsStructuredClone(t *testing.T) {
 	}
 }
 
+func TestScannerHonorsIgnoreFile(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"app.css":           ".overlay { backdrop-filter: blur(10px); }",
+		"vendor/lib.css":    ".x { backdrop-filter: blur(2px); }",
+		"generated/out.css": ".y { backdrop-filter: blur(2px); }",
+	})
+	os.WriteFile(filepath.Join(dir, ".lightshellignore"), []byte("src/vendor/\ngenerated\n"), 0644)
+
+	issues, err := ScanProject(dir)
+	if err != nil {
+		t.Fatalf("ScanProject failed: %v", err)
+	}
+	if len(issues) != 1 || issues[0].File != filepath.Join("src", "app.css") {
+		t.Fatalf("expected one issue in src/app.css, got %+v", issues)
+	}
+}
+
 func TestScannerCleanFile(t *testing.T) {
 	dir := createTestProject(t, map[string]string{
 		"app.js":    "console.log('hello');",
@@ -248,3 +266,44 @@ func TestScannerWebAPIs(t *testing.T) {
 		}
 	}
 }
+
+func TestScanAPIUsage(t *testing.T) {
+	dir := createTestProject(t, map[string]string{
+		"app.js": "await lightshell.fs.readFile('$APP_DATA/notes.json')\n" +
+			"window.lightshell.http.fetch(`https://api.example.com/v1`, { method: 'GET' })\n" +
+			"lightshell.fs.writeFile(path, data); lightshell.share({ text })\n" +
+			"lightshell.fs.readFile(`${dir}/x`)\n",
+		"index.html": `<button onclick="lightshell.process.exec(&quot;git&quot;)">x</button><script>lightshell.clipboard.write("hi")</script>`,
+	})
+	calls, err := ScanAPIUsage(dir)
+	if err != nil {
+		t.Fatalf("ScanAPIUsage failed: %v", err)
+	}
+
+	got := map[string]APICall{}
+	for _, c := range calls {
+		got[fmt.Sprintf("%s:%d %s.%s", c.File, c.Line, c.Namespace, c.Method)] = c
+	}
+	want := map[string]APICall{
+		"src/app.js:1 fs.readFile":         {Arg: "$APP_DATA/notes.json", Literal: true},
+		"src/app.js:2 http.fetch":          {Arg: "https://api.example.com/v1", Literal: true},
+		"src/app.js:3 fs.writeFile":        {},
+		"src/app.js:3 share.":              {},
+		"src/app.js:4 fs.readFile":         {},
+		"src/index.html:1 process.exec":    {},
+		"src/index.html:1 clipboard.write": {Arg: "hi", Literal: true},
+	}
+	if len(got) != len(want) {
+		t.Errorf("found %d calls, want %d: %v", len(got), len(want), got)
+	}
+	for key, w := range want {
+		c, ok := got[key]
+		if !ok {
+			t.Errorf("missing call %s", key)
+			continue
+		}
+		if c.Arg != w.Arg || c.Literal != w.Literal {
+			t.Errorf("%s: arg = %q (literal %v), want %q (literal %v)", key, c.Arg, c.Literal, w.Arg, w.Literal)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package compat
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// APICall is a call to the lightshell client API found in user code.
+type APICall struct {
+	File      string // relative to the project directory
+	Line      int
+	Namespace string // e.g. "fs"; for lightshell.share(...) this is "share"
+	Method    string // e.g. "readFile"; "" for lightshell.share(...)
+	Arg       string // first argument when it is a string literal
+	Literal   bool   // whether Arg was a string literal
+}
+
+// apiCallPattern matches lightshell.ns.method( or lightshell.ns( and, when
+// the first argument is a string literal, captures it. RE2 has no
+// backreferences, so each quote style is its own alternative.
+var apiCallPattern = regexp.MustCompile(`\blightshell\.([A-Za-z]+)(?:\.([A-Za-z]+))?\s*\(\s*(?:'([^'\\]*)'|"([^"\\]*)"|` + "`([^`\\\\]*)`" + `)?`)
+
+// ScanAPIUsage finds the lightshell API calls in the project's scripts and
+// HTML. Only direct calls are found: an API reached through a variable, as
+// in const { fs } = lightshell, is not.
+func ScanAPIUsage(dir string) ([]APICall, error) {
+	var calls []APICall
+	for _, file := range sourceFiles(dir, []string{"*.js", "*.mjs", "*.jsx", "*.ts", "*.tsx", "*.html", "*.htm"}) {
+		fileCalls, err := scanAPICalls(file, dir)
+		if err != nil {
+			continue
+		}
+		calls = append(calls, fileCalls...)
+	}
+	return calls, nil
+}
+
+func scanAPICalls(path, projectDir string) ([]APICall, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	relPath, _ := filepath.Rel(projectDir, path)
+
+	var calls []APICall
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		for _, m := range apiCallPattern.FindAllStringSubmatch(scanner.Text(), -1) {
+			call := APICall{File: relPath, Line: lineNum, Namespace: m[1], Method: m[2]}
+			for _, arg := range m[3:6] {
+				if arg != "" {
+					call.Arg, call.Literal = arg, true
+				}
+			}
+			// An interpolated template literal is not a constant
+			if strings.Contains(call.Arg, "${") {
+				call.Arg, call.Literal = "", false
+			}
+			calls = append(calls, call)
+		}
+	}
+	return calls, scanner.Err()
+}
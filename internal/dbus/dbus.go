@@ -0,0 +1,442 @@
+// Package dbus is a minimal D-Bus client: enough to connect to the session
+// bus and make method calls whose arguments and results are basic types.
+// Services such as org.freedesktop.ScreenSaver tie state to the caller's
+// connection, so a Conn is meant to be kept open for as long as it matters.
+package dbus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Message types.
+const (
+	typeMethodCall   = 1
+	typeMethodReturn = 2
+	typeError        = 3
+)
+
+// Header field codes.
+const (
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSignature   = 8
+)
+
+// ObjectPath is a D-Bus object path argument.
+type ObjectPath string
+
+// Error is an error reply from a D-Bus service.
+type Error struct {
+	Name    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// Conn is a connection to a message bus. Calls are serialized.
+type Conn struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	r      *bufio.Reader
+	serial uint32
+}
+
+// SessionBus connects to the session bus named by DBUS_SESSION_BUS_ADDRESS.
+func SessionBus() (*Conn, error) {
+	addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if addr == "" {
+		return nil, fmt.Errorf("dbus: DBUS_SESSION_BUS_ADDRESS is not set")
+	}
+	var lastErr error
+	for _, a := range strings.Split(addr, ";") {
+		nc, err := dialAddress(a)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		c, err := newConn(nc, os.Getuid())
+		if err != nil {
+			nc.Close()
+			lastErr = err
+			continue
+		}
+		return c, nil
+	}
+	return nil, lastErr
+}
+
+// dialAddress dials one unix: transport address.
+func dialAddress(addr string) (net.Conn, error) {
+	transport, params, ok := strings.Cut(addr, ":")
+	if !ok || transport != "unix" {
+		return nil, fmt.Errorf("dbus: unsupported address %q", addr)
+	}
+	for _, kv := range strings.Split(params, ",") {
+		k, v, _ := strings.Cut(kv, "=")
+		switch k {
+		case "path":
+			return net.Dial("unix", unescape(v))
+		case "abstract":
+			return net.Dial("unix", "@"+unescape(v))
+		}
+	}
+	return nil, fmt.Errorf("dbus: no path in address %q", addr)
+}
+
+// unescape decodes %XX escapes in address values.
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// newConn authenticates as uid over nc and registers with the bus.
+func newConn(nc net.Conn, uid int) (*Conn, error) {
+	c := &Conn{conn: nc, r: bufio.NewReader(nc)}
+	hexUID := hex.EncodeToString([]byte(strconv.Itoa(uid)))
+	if _, err := io.WriteString(nc, "\x00AUTH EXTERNAL "+hexUID+"\r\n"); err != nil {
+		return nil, err
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("dbus: auth: %w", err)
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return nil, fmt.Errorf("dbus: auth rejected: %s", strings.TrimSpace(line))
+	}
+	if _, err := io.WriteString(nc, "BEGIN\r\n"); err != nil {
+		return nil, err
+	}
+	if _, err := c.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close closes the connection. Services release anything held for it.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Call invokes a method and waits for its reply. Arguments may be string,
+// ObjectPath, uint32, int32, or bool; the reply body is decoded the same way.
+func (c *Conn) Call(dest, path, iface, member string, args ...any) ([]any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.serial++
+	msg, err := encodeCall(c.serial, dest, path, iface, member, args)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.conn.Write(msg); err != nil {
+		return nil, err
+	}
+	for {
+		m, err := readMessage(c.r)
+		if err != nil {
+			return nil, err
+		}
+		if m.replySerial != c.serial || (m.typ != typeMethodReturn && m.typ != typeError) {
+			continue // signals and unrelated traffic
+		}
+		if m.typ == typeError {
+			e := &Error{Name: m.errorName}
+			if len(m.body) > 0 {
+				if s, ok := m.body[0].(string); ok {
+					e.Message = s
+				}
+			}
+			return nil, e
+		}
+		return m.body, nil
+	}
+}
+
+// --- encoding ---
+
+// encoder writes little-endian messages.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) byte(b byte) { e.buf = append(e.buf, b) }
+
+func (e *encoder) uint32(v uint32) {
+	e.align(4)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+}
+
+func (e *encoder) signature(s string) {
+	e.buf = append(e.buf, byte(len(s)))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+}
+
+// value encodes v and returns its type code.
+func (e *encoder) value(v any) (byte, error) {
+	switch v := v.(type) {
+	case string:
+		e.string(v)
+		return 's', nil
+	case ObjectPath:
+		e.string(string(v))
+		return 'o', nil
+	case uint32:
+		e.uint32(v)
+		return 'u', nil
+	case int32:
+		e.uint32(uint32(v))
+		return 'i', nil
+	case bool:
+		b := uint32(0)
+		if v {
+			b = 1
+		}
+		e.uint32(b)
+		return 'b', nil
+	}
+	return 0, fmt.Errorf("dbus: unsupported argument type %T", v)
+}
+
+func encodeCall(serial uint32, dest, path, iface, member string, args []any) ([]byte, error) {
+	body := &encoder{}
+	var sig []byte
+	for _, a := range args {
+		t, err := body.value(a)
+		if err != nil {
+			return nil, err
+		}
+		sig = append(sig, t)
+	}
+
+	// Fields are encoded starting at offset 16, so a leading pad of 16 keeps
+	// alignment relative to the start of the message.
+	fields := &encoder{buf: make([]byte, 16)}
+	field := func(code byte, typ byte, write func()) {
+		fields.align(8)
+		fields.byte(code)
+		fields.signature(string(typ))
+		write()
+	}
+	field(fieldPath, 'o', func() { fields.string(path) })
+	if iface != "" {
+		field(fieldInterface, 's', func() { fields.string(iface) })
+	}
+	field(fieldMember, 's', func() { fields.string(member) })
+	if dest != "" {
+		field(fieldDestination, 's', func() { fields.string(dest) })
+	}
+	if len(sig) > 0 {
+		field(fieldSignature, 'g', func() { fields.signature(string(sig)) })
+	}
+	fieldBytes := fields.buf[16:]
+
+	msg := &encoder{}
+	msg.buf = append(msg.buf, 'l', typeMethodCall, 0, 1)
+	msg.uint32(uint32(len(body.buf)))
+	msg.uint32(serial)
+	msg.uint32(uint32(len(fieldBytes)))
+	msg.buf = append(msg.buf, fieldBytes...)
+	msg.align(8)
+	msg.buf = append(msg.buf, body.buf...)
+	return msg.buf, nil
+}
+
+// --- decoding ---
+
+type message struct {
+	typ         byte
+	serial      uint32
+	replySerial uint32
+	errorName   string
+	body        []any
+}
+
+type decoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+var errShort = errors.New("dbus: malformed message")
+
+func (d *decoder) align(n int) {
+	d.pos = (d.pos + n - 1) / n * n
+}
+
+func (d *decoder) byte() (byte, error) {
+	if d.pos >= len(d.buf) {
+		return 0, errShort
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	d.align(4)
+	if d.pos+4 > len(d.buf) {
+		return 0, errShort
+	}
+	v := d.order.Uint32(d.buf[d.pos:])
+	d.pos += 4
+	return v, nil
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return "", err
+	}
+	if d.pos+int(n)+1 > len(d.buf) {
+		return "", errShort
+	}
+	s := string(d.buf[d.pos : d.pos+int(n)])
+	d.pos += int(n) + 1
+	return s, nil
+}
+
+func (d *decoder) signature() (string, error) {
+	n, err := d.byte()
+	if err != nil {
+		return "", err
+	}
+	if d.pos+int(n)+1 > len(d.buf) {
+		return "", errShort
+	}
+	s := string(d.buf[d.pos : d.pos+int(n)])
+	d.pos += int(n) + 1
+	return s, nil
+}
+
+// value decodes one value of the basic type t.
+func (d *decoder) value(t byte) (any, error) {
+	switch t {
+	case 's':
+		return d.string()
+	case 'o':
+		s, err := d.string()
+		return ObjectPath(s), err
+	case 'g':
+		return d.signature()
+	case 'y':
+		return d.byte()
+	case 'u':
+		return d.uint32()
+	case 'i':
+		v, err := d.uint32()
+		return int32(v), err
+	case 'b':
+		v, err := d.uint32()
+		return v != 0, err
+	}
+	return nil, fmt.Errorf("dbus: unsupported type %q", t)
+}
+
+func readMessage(r io.Reader) (*message, error) {
+	head := make([]byte, 16)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if head[0] == 'B' {
+		order = binary.BigEndian
+	}
+	bodyLen := order.Uint32(head[4:])
+	fieldsLen := order.Uint32(head[12:])
+	total := 16 + int(fieldsLen)
+	total = (total+7)/8*8 + int(bodyLen)
+	if total > 1<<27 {
+		return nil, errShort
+	}
+	buf := make([]byte, total)
+	copy(buf, head)
+	if _, err := io.ReadFull(r, buf[16:]); err != nil {
+		return nil, err
+	}
+
+	m := &message{typ: head[1], serial: order.Uint32(head[8:])}
+	d := &decoder{buf: buf, pos: 16, order: order}
+	var sig string
+	end := 16 + int(fieldsLen)
+	for d.pos < end {
+		d.align(8)
+		code, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		vsig, err := d.signature()
+		if err != nil {
+			return nil, err
+		}
+		if len(vsig) != 1 {
+			return nil, errShort
+		}
+		v, err := d.value(vsig[0])
+		if err != nil {
+			return nil, err
+		}
+		switch code {
+		case fieldReplySerial:
+			m.replySerial, _ = v.(uint32)
+		case fieldErrorName:
+			m.errorName, _ = v.(string)
+		case fieldSignature:
+			sig, _ = v.(string)
+		}
+	}
+
+	d.pos = (end + 7) / 8 * 8
+	for i := 0; i < len(sig); i++ {
+		v, err := d.value(sig[i])
+		if err != nil {
+			// Bodies with containers are not decoded; callers only need
+			// basic results.
+			m.body = nil
+			break
+		}
+		m.body = append(m.body, v)
+	}
+	return m, nil
+}
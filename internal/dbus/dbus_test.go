@@ -0,0 +1,146 @@
+package dbus
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	msg, err := encodeCall(7, "org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver",
+		"org.freedesktop.ScreenSaver", "Inhibit", []any{"My App", "Playing video"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg[0] != 'l' {
+		t.Fatalf("unexpected message header % x", msg[:4])
+	}
+	m, err := readMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.typ != typeMethodCall || m.serial != 7 {
+		t.Errorf("type=%d serial=%d, want 1 and 7", m.typ, m.serial)
+	}
+	if len(m.body) != 2 || m.body[0] != "My App" || m.body[1] != "Playing video" {
+		t.Errorf("body = %#v", m.body)
+	}
+}
+
+func TestEncodeUnsupportedArgument(t *testing.T) {
+	if _, err := encodeCall(1, "", "/", "", "M", []any{1.5}); err == nil {
+		t.Error("expected error for float argument")
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	if got := unescape("/run/user/1000/bus%2dx"); got != "/run/user/1000/bus-x" {
+		t.Errorf("unescape = %q", got)
+	}
+}
+
+func TestDialAddressUnsupported(t *testing.T) {
+	if _, err := dialAddress("tcp:host=localhost,port=1"); err == nil {
+		t.Error("expected error for tcp transport")
+	}
+}
+
+// reply builds a message of type typ answering serial, with the given
+// header fields and body values.
+func reply(typ byte, serial uint32, errName string, body ...any) []byte {
+	b := &encoder{}
+	var sig []byte
+	for _, v := range body {
+		t, _ := b.value(v)
+		sig = append(sig, t)
+	}
+	f := &encoder{buf: make([]byte, 16)}
+	f.align(8)
+	f.byte(fieldReplySerial)
+	f.signature("u")
+	f.uint32(serial)
+	if errName != "" {
+		f.align(8)
+		f.byte(fieldErrorName)
+		f.signature("s")
+		f.string(errName)
+	}
+	if len(sig) > 0 {
+		f.align(8)
+		f.byte(fieldSignature)
+		f.signature("g")
+		f.signature(string(sig))
+	}
+	fields := f.buf[16:]
+	m := &encoder{}
+	m.buf = append(m.buf, 'l', typ, 0, 1)
+	m.uint32(uint32(len(b.buf)))
+	m.uint32(100 + serial)
+	m.uint32(uint32(len(fields)))
+	m.buf = append(m.buf, fields...)
+	m.align(8)
+	m.buf = append(m.buf, b.buf...)
+	return m.buf
+}
+
+// fakeBus accepts the auth handshake on conn and answers each call with
+// the next of replies, rewritten to the call's serial.
+func fakeBus(t *testing.T, conn net.Conn, replies []func(serial uint32) []byte) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "\x00AUTH EXTERNAL ") {
+		t.Errorf("auth line = %q, %v", line, err)
+		return
+	}
+	conn.Write([]byte("OK 0123456789abcdef\r\n"))
+	if line, _ := r.ReadString('\n'); line != "BEGIN\r\n" {
+		t.Errorf("expected BEGIN, got %q", line)
+		return
+	}
+	for _, next := range replies {
+		m, err := readMessage(r)
+		if err != nil {
+			return
+		}
+		conn.Write(next(m.serial))
+	}
+}
+
+func TestCall(t *testing.T) {
+	client, server := net.Pipe()
+	go fakeBus(t, server, []func(uint32) []byte{
+		func(s uint32) []byte { return reply(typeMethodReturn, s, "", ":1.42") },
+		func(s uint32) []byte {
+			// An unrelated signal arrives before the reply
+			return append(reply(4, 0, ""), reply(typeMethodReturn, s, "", uint32(9))...)
+		},
+		func(s uint32) []byte {
+			return reply(typeError, s, "org.freedesktop.DBus.Error.UnknownMethod", "no such method")
+		},
+	})
+
+	c, err := newConn(client, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	got, err := c.Call("org.freedesktop.ScreenSaver", "/org/freedesktop/ScreenSaver",
+		"org.freedesktop.ScreenSaver", "Inhibit", "app", "reason")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != uint32(9) {
+		t.Errorf("Inhibit = %#v, want [9]", got)
+	}
+
+	_, err = c.Call("org.example", "/", "org.example", "Missing")
+	var dErr *Error
+	if !errors.As(err, &dErr) || dErr.Name != "org.freedesktop.DBus.Error.UnknownMethod" || dErr.Message != "no such method" {
+		t.Errorf("error = %v", err)
+	}
+}
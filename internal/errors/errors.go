@@ -19,6 +19,10 @@ const (
 	HTTPRequestFailed = "HTTP_REQUEST_FAILED"
 	HTTPDomainDenied  = "HTTP_DOMAIN_DENIED"
 
+	// TLS errors
+	TLSFailed      = "TLS_FAILED"
+	TLSPinMismatch = "TLS_PIN_MISMATCH"
+
 	// Process errors
 	ProcessDenied   = "PROCESS_DENIED"
 	ProcessTimeout  = "PROCESS_TIMEOUT"
@@ -48,6 +52,12 @@ const (
 	// Release errors
 	ReleaseFailed = "RELEASE_FAILED"
 	UploadFailed  = "UPLOAD_FAILED"
+
+	// CLI errors
+	CommandFailed = "COMMAND_FAILED"
+	InvalidUsage  = "INVALID_USAGE"
+	BuildFailed   = "BUILD_FAILED"
+	CompatErrors  = "COMPAT_ERRORS"
 )
 
 // LightShellError is a structured error with code, context, and remediation info.
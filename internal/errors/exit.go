@@ -0,0 +1,64 @@
+package errors
+
+// Exit statuses of the lightshell command, one per category of failure,
+// so CI scripts and the MCP server can tell a bad config from a failed
+// build without reading the message. A status keeps its meaning across
+// releases; new categories get new numbers.
+const (
+	ExitOK         = 0
+	ExitFailure    = 1 // any failure outside the categories below
+	ExitUsage      = 2 // unknown command, flag, or argument
+	ExitConfig     = 3 // lightshell.json is missing or invalid
+	ExitBuild      = 4 // compiling, bundling, or packaging failed
+	ExitSigning    = 5 // code signing, notarization, or a signing key failed
+	ExitCompat     = 6 // lightshell doctor found compatibility errors
+	ExitPermission = 7 // a permission or file access was denied
+	ExitRelease    = 8 // publishing a release failed
+)
+
+var exitStatuses = map[string]int{
+	InvalidUsage:       ExitUsage,
+	ConfigInvalid:      ExitConfig,
+	ConfigNotFound:     ExitConfig,
+	BuildFailed:        ExitBuild,
+	SigningFailed:      ExitSigning,
+	SigningKeyNotFound: ExitSigning,
+	CompatErrors:       ExitCompat,
+	PermissionDenied:   ExitPermission,
+	FSPermissionDenied: ExitPermission,
+	FSPathTraversal:    ExitPermission,
+	HTTPDomainDenied:   ExitPermission,
+	ProcessDenied:      ExitPermission,
+	ReleaseFailed:      ExitRelease,
+	UploadFailed:       ExitRelease,
+}
+
+var exitCategories = map[int]string{
+	ExitOK:         "ok",
+	ExitFailure:    "failure",
+	ExitUsage:      "usage",
+	ExitConfig:     "config",
+	ExitBuild:      "build",
+	ExitSigning:    "signing",
+	ExitCompat:     "compat",
+	ExitPermission: "permission",
+	ExitRelease:    "release",
+}
+
+// ExitStatus returns the exit status of a command that failed with an
+// error of code.
+func ExitStatus(code string) int {
+	if status, ok := exitStatuses[code]; ok {
+		return status
+	}
+	return ExitFailure
+}
+
+// ExitCategory names the category of an exit status, such as "config";
+// statuses outside the scheme are "failure".
+func ExitCategory(status int) string {
+	if category, ok := exitCategories[status]; ok {
+		return category
+	}
+	return exitCategories[ExitFailure]
+}
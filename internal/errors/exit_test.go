@@ -0,0 +1,34 @@
+package errors
+
+import "testing"
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		code     string
+		status   int
+		category string
+	}{
+		{ConfigInvalid, ExitConfig, "config"},
+		{ConfigNotFound, ExitConfig, "config"},
+		{InvalidUsage, ExitUsage, "usage"},
+		{BuildFailed, ExitBuild, "build"},
+		{SigningKeyNotFound, ExitSigning, "signing"},
+		{CompatErrors, ExitCompat, "compat"},
+		{FSPathTraversal, ExitPermission, "permission"},
+		{UploadFailed, ExitRelease, "release"},
+		{CommandFailed, ExitFailure, "failure"},
+		{"SOMETHING_NEW", ExitFailure, "failure"},
+	}
+	for _, tt := range tests {
+		status := ExitStatus(tt.code)
+		if status != tt.status {
+			t.Errorf("ExitStatus(%s) = %d, want %d", tt.code, status, tt.status)
+		}
+		if got := ExitCategory(status); got != tt.category {
+			t.Errorf("ExitCategory(%d) = %q, want %q", status, got, tt.category)
+		}
+	}
+	if got := ExitCategory(42); got != "failure" {
+		t.Errorf("ExitCategory(42) = %q, want failure", got)
+	}
+}
@@ -0,0 +1,103 @@
+// Package framing compresses large messages on LightShell's line-delimited
+// JSON protocols, the dev process socket and the MCP server's stdio. A
+// message above Threshold is sent as a frame holding it gzipped and
+// base64-encoded, on one line like any other message, but only to a peer
+// that said during the handshake that it reads frames.
+package framing
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Gzip is the only encoding so far.
+const Gzip = "gzip"
+
+// Threshold is the size in bytes from which messages are compressed.
+// Smaller ones gain little and cost a round of gzip on each side.
+const Threshold = 16 * 1024
+
+// maxDecoded bounds what a frame may decompress to, like the line limits
+// of the readers it replaces.
+const maxDecoded = 64 * 1024 * 1024
+
+// Supported lists the encodings this side reads and writes, most preferred
+// first.
+var Supported = []string{Gzip}
+
+// frame is how a compressed message goes over the wire.
+type frame struct {
+	Encoding string `json:"encoding"`
+	Data     string `json:"data"`
+}
+
+// Negotiate returns the encodings in offered that are supported, which a
+// peer may then use.
+func Negotiate(offered []string) []string {
+	var out []string
+	for _, e := range Supported {
+		if slices.Contains(offered, e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Encode returns msg, a JSON message without its trailing newline, as it
+// should be sent to a peer reading encodings: as a frame when msg is over
+// Threshold and compressing shrinks it, and as is otherwise.
+func Encode(msg []byte, encodings []string) []byte {
+	if len(msg) <= Threshold || !slices.Contains(encodings, Gzip) {
+		return msg
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(msg)
+	zw.Close()
+	// Screenshots are already compressed, as are some payloads
+	if base64.StdEncoding.EncodedLen(buf.Len())+32 >= len(msg) {
+		return msg
+	}
+	out, err := json.Marshal(frame{Encoding: Gzip, Data: base64.StdEncoding.EncodeToString(buf.Bytes())})
+	if err != nil {
+		return msg
+	}
+	return out
+}
+
+// Decode returns the message a line holds: what its frame decompresses
+// to, or the line itself when it is not a frame.
+func Decode(line []byte) ([]byte, error) {
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, []byte(`{"encoding":`)) {
+		return line, nil
+	}
+	var f frame
+	if err := json.Unmarshal(line, &f); err != nil || f.Data == "" {
+		return line, nil
+	}
+	if f.Encoding != Gzip {
+		return nil, fmt.Errorf("unsupported message encoding %q", f.Encoding)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(f.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s frame: %w", f.Encoding, err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s frame: %w", f.Encoding, err)
+	}
+	msg, err := io.ReadAll(io.LimitReader(zr, maxDecoded+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s frame: %w", f.Encoding, err)
+	}
+	if len(msg) > maxDecoded {
+		return nil, fmt.Errorf("%s frame decompresses to more than %d bytes", f.Encoding, maxDecoded)
+	}
+	return msg, nil
+}
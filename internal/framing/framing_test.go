@@ -0,0 +1,73 @@
+package framing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	large, _ := json.Marshal(map[string]string{"html": strings.Repeat("<div class=\"row\">cell</div>", 2000)})
+	framed := Encode(large, []string{Gzip})
+	if bytes.Equal(framed, large) || len(framed) >= len(large)/4 {
+		t.Fatalf("%d-byte message framed to %d bytes", len(large), len(framed))
+	}
+	if bytes.ContainsRune(framed, '\n') {
+		t.Error("frame spans lines")
+	}
+	got, err := Decode(framed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, large) {
+		t.Error("decoded message differs")
+	}
+}
+
+func TestEncodeLeavesAsIs(t *testing.T) {
+	small := []byte(`{"id":1,"status":"ok"}`)
+	large := []byte(`{"html":"` + strings.Repeat("x", 2*Threshold) + `"}`)
+	random := make([]byte, 2*Threshold)
+	rand.Read(random)
+	incompressible := []byte(`{"image":"` + base64.StdEncoding.EncodeToString(random) + `"}`)
+
+	tests := []struct {
+		name      string
+		msg       []byte
+		encodings []string
+	}{
+		{"small", small, []string{Gzip}},
+		{"not negotiated", large, nil},
+		{"incompressible", incompressible, []string{Gzip}},
+	}
+	for _, tt := range tests {
+		if got := Encode(tt.msg, tt.encodings); !bytes.Equal(got, tt.msg) {
+			t.Errorf("%s: message was framed", tt.name)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	plain := []byte(`{"id":3,"result":"x"}` + "\n")
+	if got, err := Decode(plain); err != nil || string(got) != `{"id":3,"result":"x"}` {
+		t.Errorf("plain line: %q, %v", got, err)
+	}
+	if _, err := Decode([]byte(`{"encoding":"br","data":"AAAA"}`)); err == nil {
+		t.Error("accepted an unknown encoding")
+	}
+	if _, err := Decode([]byte(`{"encoding":"gzip","data":"bm90IGd6aXA="}`)); err == nil {
+		t.Error("accepted data that is not gzip")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	if got := Negotiate([]string{"br", "gzip"}); len(got) != 1 || got[0] != Gzip {
+		t.Errorf("Negotiate = %v", got)
+	}
+	if got := Negotiate(nil); got != nil {
+		t.Errorf("Negotiate(nil) = %v", got)
+	}
+}
@@ -0,0 +1,84 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := WriteFileAtomic(path, []byte("one"), 0o600); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	if err := WriteFileAtomic(path, []byte("two"), 0o600); err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "two" {
+		t.Errorf("expected %q, got %q", "two", string(data))
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+
+	// No temp files should be left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the target file, got %d entries", len(entries))
+	}
+}
+
+func TestLockSerializesReadModifyWrite(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, ".lock")
+	counter := filepath.Join(dir, "counter")
+	os.WriteFile(counter, []byte{}, 0o600)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := Lock(lockPath)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+			data, _ := os.ReadFile(counter)
+			WriteFileAtomic(counter, append(data, 'x'), 0o600)
+		}()
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(counter)
+	if len(data) != 20 {
+		t.Errorf("expected 20 increments, got %d (lost updates)", len(data))
+	}
+}
+
+func TestTryLock(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), ".lock")
+	unlock, ok, err := TryLock(lockPath)
+	if err != nil || !ok {
+		t.Fatalf("first TryLock: ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := TryLock(lockPath); err != nil || ok {
+		t.Errorf("TryLock while held: ok=%v err=%v, want false, nil", ok, err)
+	}
+	unlock()
+	unlock2, ok, err := TryLock(lockPath)
+	if err != nil || !ok {
+		t.Fatalf("TryLock after release: ok=%v err=%v", ok, err)
+	}
+	unlock2()
+}
@@ -0,0 +1,87 @@
+package iconset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+
+	"github.com/lightshell-dev/lightshell/internal/imaging"
+)
+
+// icnsTypes names the PNG entries of an .icns file by pixel size. Sizes
+// shared by a 1x and a 2x slot, like 32 for 32pt and 16pt@2x, fill both.
+var icnsTypes = []struct {
+	size int
+	kind string
+}{
+	{16, "icp4"}, {32, "icp5"}, {32, "ic11"}, {64, "icp6"}, {64, "ic12"},
+	{128, "ic07"}, {256, "ic08"}, {256, "ic13"}, {512, "ic09"}, {512, "ic14"},
+	{1024, "ic10"},
+}
+
+// ICNS encodes img as a macOS .icns file with every size in
+// Sizes["macos"], each stored as PNG.
+func ICNS(img image.Image) ([]byte, error) {
+	pngs, err := PNGs(img, Sizes["macos"])
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	for _, t := range icnsTypes {
+		data := pngs[t.size]
+		body.WriteString(t.kind)
+		binary.Write(&body, binary.BigEndian, uint32(8+len(data)))
+		body.Write(data)
+	}
+	var out bytes.Buffer
+	out.WriteString("icns")
+	binary.Write(&out, binary.BigEndian, uint32(8+body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// ICO encodes img as a Windows .ico file with every size in
+// Sizes["windows"], each stored as PNG, which Windows reads from Vista on.
+func ICO(img image.Image) ([]byte, error) {
+	sizes := Sizes["windows"]
+	pngs, err := PNGs(img, sizes)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	// ICONDIR: reserved, type 1 (icon), image count
+	binary.Write(&out, binary.LittleEndian, [3]uint16{0, 1, uint16(len(sizes))})
+	offset := 6 + 16*len(sizes)
+	for _, size := range sizes {
+		// ICONDIRENTRY; a width and height of 0 mean 256
+		dim := byte(size)
+		if size >= 256 {
+			dim = 0
+		}
+		out.Write([]byte{dim, dim, 0, 0})
+		binary.Write(&out, binary.LittleEndian, [2]uint16{1, 32}) // planes, bits per pixel
+		binary.Write(&out, binary.LittleEndian, [2]uint32{uint32(len(pngs[size])), uint32(offset)})
+		offset += len(pngs[size])
+	}
+	for _, size := range sizes {
+		out.Write(pngs[size])
+	}
+	return out.Bytes(), nil
+}
+
+// PNGs scales img to each size and encodes the results as PNG.
+func PNGs(img image.Image, sizes []int) (map[int][]byte, error) {
+	scaled, err := Generate(img, sizes)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int][]byte, len(sizes))
+	for _, size := range sizes {
+		data, err := imaging.EncodeBytes(scaled[size], "png", 0)
+		if err != nil {
+			return nil, err
+		}
+		out[size] = data
+	}
+	return out, nil
+}
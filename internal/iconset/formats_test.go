@@ -0,0 +1,59 @@
+package iconset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestICNS(t *testing.T) {
+	data, err := ICNS(artwork(1024, image.Rect(100, 100, 924, 924), blue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[:4]) != "icns" || int(binary.BigEndian.Uint32(data[4:8])) != len(data) {
+		t.Fatalf("bad header % x", data[:8])
+	}
+	sizes := map[string]int{}
+	for rest := data[8:]; len(rest) > 0; {
+		kind, n := string(rest[:4]), int(binary.BigEndian.Uint32(rest[4:8]))
+		cfg, err := png.DecodeConfig(bytes.NewReader(rest[8:n]))
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		sizes[kind] = cfg.Width
+		rest = rest[n:]
+	}
+	for _, want := range icnsTypes {
+		if sizes[want.kind] != want.size {
+			t.Errorf("%s is %dpx, want %d", want.kind, sizes[want.kind], want.size)
+		}
+	}
+}
+
+func TestICO(t *testing.T) {
+	data, err := ICO(artwork(512, image.Rect(50, 50, 462, 462), blue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind, count := binary.LittleEndian.Uint16(data[2:]), int(binary.LittleEndian.Uint16(data[4:])); kind != 1 || count != len(Sizes["windows"]) {
+		t.Fatalf("type %d with %d images", kind, count)
+	}
+	for i, size := range Sizes["windows"] {
+		entry := data[6+16*i:]
+		n, offset := binary.LittleEndian.Uint32(entry[8:]), binary.LittleEndian.Uint32(entry[12:])
+		cfg, err := png.DecodeConfig(bytes.NewReader(data[offset : offset+n]))
+		if err != nil {
+			t.Fatalf("image %d: %v", i, err)
+		}
+		dim := int(entry[0])
+		if dim == 0 {
+			dim = 256
+		}
+		if cfg.Width != size || dim != size {
+			t.Errorf("image %d: %dpx, listed as %d, want %d", i, cfg.Width, dim, size)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package iconset turns one source image into the icon sizes each platform
+// asks for, and checks icons for problems that only show once they are on
+// screen: artwork without padding, opaque backgrounds, faint stray pixels,
+// and tray icons that vanish against one menu bar or the other.
+package iconset
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+
+	"github.com/lightshell-dev/lightshell/internal/imaging"
+)
+
+// Sizes lists the square pixel sizes each platform's app icon comes in.
+var Sizes = map[string][]int{
+	"macos":   {16, 32, 64, 128, 256, 512, 1024}, // 16-512pt at 1x and 2x
+	"windows": {16, 24, 32, 48, 64, 256},
+	"linux":   {16, 24, 32, 48, 64, 128, 256, 512},
+}
+
+// AllSizes returns every size in Sizes, once, smallest first.
+func AllSizes() []int {
+	seen := map[int]bool{}
+	var sizes []int
+	for _, list := range Sizes {
+		for _, s := range list {
+			if !seen[s] {
+				seen[s] = true
+				sizes = append(sizes, s)
+			}
+		}
+	}
+	sort.Ints(sizes)
+	return sizes
+}
+
+// Issue is a problem found in an icon.
+type Issue struct {
+	Severity string `json:"severity"` // "warning" or "info"
+	Message  string `json:"message"`
+}
+
+func warn(format string, args ...any) Issue {
+	return Issue{Severity: "warning", Message: fmt.Sprintf(format, args...)}
+}
+
+func info(format string, args ...any) Issue {
+	return Issue{Severity: "info", Message: fmt.Sprintf(format, args...)}
+}
+
+// visibleAlpha is the opacity from which a pixel counts as part of the
+// artwork. Fainter pixels are invisible at full size but widen the
+// artwork's bounds and show as a halo once scaled down.
+const visibleAlpha = 16
+
+// Generate scales img to each size. A source that is not square is
+// centered on a transparent square first.
+func Generate(img image.Image, sizes []int) (map[int]image.Image, error) {
+	src := square(img)
+	out := make(map[int]image.Image, len(sizes))
+	for _, size := range sizes {
+		scaled, err := imaging.Resize(src, size, size, imaging.FitFill)
+		if err != nil {
+			return nil, err
+		}
+		out[size] = scaled
+	}
+	return out, nil
+}
+
+func square(img image.Image) image.Image {
+	b := img.Bounds()
+	if b.Dx() == b.Dy() {
+		return img
+	}
+	side := max(b.Dx(), b.Dy())
+	canvas := image.NewNRGBA(image.Rect(0, 0, side, side))
+	offset := image.Pt((side-b.Dx())/2, (side-b.Dy())/2)
+	draw.Draw(canvas, b.Sub(b.Min).Add(offset), img, b.Min, draw.Src)
+	return canvas
+}
+
+// CheckApp checks an app icon source.
+func CheckApp(img image.Image) []Issue {
+	var issues []Issue
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w != h {
+		issues = append(issues, warn("Icon is %dx%d, not square; it is centered on a transparent square", w, h))
+	}
+	if side := max(w, h); side < 1024 {
+		issues = append(issues, warn("Icon is %dpx; macOS shows icons up to 1024x1024, so larger sizes are scaled up and blurry", side))
+	}
+
+	if opaque(img) {
+		return append(issues, warn("Icon has no transparency; macOS shows it as a full square instead of the shape around its artwork"))
+	}
+	content := bounds(img, visibleAlpha)
+	if content.Empty() {
+		return append(issues, warn("Icon is fully transparent"))
+	}
+
+	side := float64(max(w, h))
+	left, right := content.Min.X-b.Min.X, b.Max.X-content.Max.X
+	top, bottom := content.Min.Y-b.Min.Y, b.Max.Y-content.Max.Y
+	margin := float64(min(left, right, top, bottom)) / side
+	fill := float64(max(content.Dx(), content.Dy())) / side
+	switch {
+	case margin < 0.03:
+		issues = append(issues, warn("Artwork reaches the edge; macOS icons keep about 10%% padding (824x824 artwork on a 1024x1024 canvas)"))
+	case fill < 0.6:
+		issues = append(issues, warn("Artwork fills only %.0f%% of the canvas; it looks small next to other icons", fill*100))
+	}
+	if dx := abs(left - right); float64(dx)/side > 0.05 {
+		issues = append(issues, warn("Artwork is off-center by %dpx horizontally", dx/2))
+	}
+	if dy := abs(top - bottom); float64(dy)/side > 0.05 {
+		issues = append(issues, info("Artwork is off-center by %dpx vertically; fine if it leaves room for a shadow", dy/2))
+	}
+
+	if faint := bounds(img, 1); faint != content {
+		spread := max(content.Min.X-faint.Min.X, faint.Max.X-content.Max.X, content.Min.Y-faint.Min.Y, faint.Max.Y-content.Max.Y)
+		if float64(spread)/side > 0.01 {
+			issues = append(issues, warn("Nearly transparent pixels (alpha below %d) reach %dpx past the artwork; they show as a faint halo at small sizes", visibleAlpha, spread))
+		}
+	}
+	return issues
+}
+
+// CheckTray checks a tray icon and its dark variant, which may be nil.
+func CheckTray(light, dark image.Image) []Issue {
+	var issues []Issue
+	b := light.Bounds()
+	if h := b.Dy(); h < 16 || h > 64 {
+		issues = append(issues, warn("Icon is %dpx tall; menu bar icons are drawn about 22pt tall, so 22px, or 44px for Retina screens", h))
+	}
+	if opaque(light) {
+		issues = append(issues, warn("Icon has no transparency; it shows as a solid block in the menu bar"))
+	}
+	issues = append(issues, checkVariant(light, dark)...)
+	if dark != nil {
+		return issues
+	}
+	switch l := luminance(light); {
+	case l < 0.25:
+		issues = append(issues, warn("Icon is dark and has no @dark variant; it is hard to see on a dark menu bar"))
+	case l > 0.75:
+		issues = append(issues, warn("Icon is light and has no @dark variant for dark mode; it is hard to see on a light menu bar"))
+	default:
+		issues = append(issues, info("Icon has no @dark variant, so it shows the same in light and dark mode"))
+	}
+	return issues
+}
+
+// CheckWindow checks a window icon and its dark variant, which may be nil.
+func CheckWindow(light, dark image.Image) []Issue {
+	issues := CheckApp(light)
+	return append(issues, checkVariant(light, dark)...)
+}
+
+func checkVariant(light, dark image.Image) []Issue {
+	if dark == nil {
+		return nil
+	}
+	lb, db := light.Bounds(), dark.Bounds()
+	if lb.Dx() != db.Dx() || lb.Dy() != db.Dy() {
+		return []Issue{warn("The @dark variant is %dx%d, not %dx%d like the icon; the icon changes size when the appearance switches", db.Dx(), db.Dy(), lb.Dx(), lb.Dy())}
+	}
+	return nil
+}
+
+// opaque reports whether no pixel of img is transparent.
+func opaque(img image.Image) bool {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if alpha(img.At(x, y)) < 255 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// bounds returns the smallest rectangle holding every pixel of img with at
+// least minAlpha opacity.
+func bounds(img image.Image, minAlpha uint8) image.Rectangle {
+	b := img.Bounds()
+	r := image.Rectangle{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if alpha(img.At(x, y)) >= minAlpha {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+// luminance is the average relative luminance of img's visible pixels,
+// weighted by their opacity, from 0 (black) to 1 (white).
+func luminance(img image.Image) float64 {
+	b := img.Bounds()
+	var sum, weight float64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			a := float64(c.A) / 255
+			sum += a * (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) / 255
+			weight += a
+		}
+	}
+	if weight == 0 {
+		return 0.5
+	}
+	return sum / weight
+}
+
+func alpha(c color.Color) uint8 {
+	_, _, _, a := c.RGBA()
+	return uint8(a >> 8)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,113 @@
+package iconset
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// artwork returns a size x size transparent canvas with an opaque c square
+// covering r.
+func artwork(size int, r image.Rectangle, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func hasIssue(issues []Issue, substr string) bool {
+	for _, i := range issues {
+		if strings.Contains(i.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+var blue = color.NRGBA{0, 90, 200, 255}
+
+func TestCheckApp(t *testing.T) {
+	good := artwork(1024, image.Rect(100, 100, 924, 924), blue)
+	if issues := CheckApp(good); len(issues) != 0 {
+		t.Errorf("well-padded icon: %v", issues)
+	}
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want string
+	}{
+		{"small", artwork(256, image.Rect(25, 25, 231, 231), blue), "scaled up"},
+		{"opaque", artwork(1024, image.Rect(0, 0, 1024, 1024), blue), "no transparency"},
+		{"edge", artwork(1024, image.Rect(0, 100, 1024, 924), blue), "reaches the edge"},
+		{"tiny", artwork(1024, image.Rect(312, 312, 712, 712), blue), "fills only 39%"},
+		{"off-center", artwork(1024, image.Rect(200, 100, 1000, 924), blue), "off-center by 88px horizontally"},
+		{"not square", image.NewNRGBA(image.Rect(0, 0, 1024, 512)), "not square"},
+	}
+	for _, tt := range tests {
+		if issues := CheckApp(tt.img); !hasIssue(issues, tt.want) {
+			t.Errorf("%s: want %q in %v", tt.name, tt.want, issues)
+		}
+	}
+
+	halo := artwork(1024, image.Rect(100, 100, 924, 924), blue)
+	halo.SetNRGBA(10, 500, color.NRGBA{0, 0, 0, 3})
+	if issues := CheckApp(halo); !hasIssue(issues, "transparent pixels") {
+		t.Errorf("halo: %v", issues)
+	}
+}
+
+func TestCheckTray(t *testing.T) {
+	black := color.NRGBA{0, 0, 0, 255}
+	white := color.NRGBA{255, 255, 255, 255}
+	light := artwork(44, image.Rect(4, 4, 40, 40), black)
+
+	if issues := CheckTray(light, nil); !hasIssue(issues, "hard to see on a dark menu bar") {
+		t.Errorf("dark icon without variant: %v", issues)
+	}
+	if issues := CheckTray(light, artwork(44, image.Rect(4, 4, 40, 40), white)); len(issues) != 0 {
+		t.Errorf("icon with variant: %v", issues)
+	}
+	if issues := CheckTray(light, artwork(22, image.Rect(2, 2, 20, 20), white)); !hasIssue(issues, "changes size") {
+		t.Errorf("mismatched variant: %v", issues)
+	}
+	if issues := CheckTray(artwork(128, image.Rect(0, 0, 128, 128), white), nil); !hasIssue(issues, "128px tall") || !hasIssue(issues, "solid block") {
+		t.Errorf("large opaque icon: %v", issues)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	src := artwork(600, image.Rect(0, 0, 600, 300), blue).SubImage(image.Rect(0, 0, 600, 300))
+	out, err := Generate(src, []int{16, 256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{16, 256} {
+		if b := out[size].Bounds(); b.Dx() != size || b.Dy() != size {
+			t.Errorf("size %d: got %v", size, b)
+		}
+	}
+	// The wide source is centered: the top row is transparent
+	if a := alpha(out[256].At(128, 0)); a != 0 {
+		t.Errorf("top row alpha = %d, want 0", a)
+	}
+	if a := alpha(out[256].At(128, 128)); a != 255 {
+		t.Errorf("center alpha = %d, want 255", a)
+	}
+}
+
+func TestAllSizes(t *testing.T) {
+	got := AllSizes()
+	if got[0] != 16 || got[len(got)-1] != 1024 {
+		t.Errorf("AllSizes = %v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Errorf("AllSizes not sorted and unique: %v", got)
+		}
+	}
+}
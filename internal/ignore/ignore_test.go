@@ -0,0 +1,95 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	m := Parse(`# assets too large to watch
+*.psd
+raw/
+/build
+src/vendor/**
+docs/**/*.pdf
+!keep.psd
+\#literal
+trailing   
+tmp?.txt
+[ab].log
+`)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"art.psd", false, true},
+		{"src/images/art.psd", false, true},
+		{"src/keep.psd", false, false},
+		{"raw", true, true},
+		{"raw", false, false}, // dir-only pattern
+		{"src/raw/photo.png", false, true},
+		{"build", true, true},
+		{"src/build", true, false}, // anchored to the root
+		{"build/out.js", false, true},
+		{"src/vendor/lib/a.js", false, true},
+		{"src/vendor", true, false},
+		{"docs/manual.pdf", false, true},
+		{"docs/a/b/manual.pdf", false, true},
+		{"other/manual.pdf", false, false},
+		{"#literal", false, true},
+		{"# assets too large to watch", false, false},
+		{"trailing", false, true},
+		{"tmp1.txt", false, true},
+		{"tmp12.txt", false, false},
+		{"a.log", false, true},
+		{"c.log", false, false},
+		{"src/app.js", false, false},
+		{".", true, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestMatchNegationOrder(t *testing.T) {
+	m := Parse("!a.txt\n*.txt\n")
+	if !m.Match("a.txt", false) {
+		t.Error("a later pattern should override an earlier negation")
+	}
+	m = Parse("*.txt\n!a.txt\n")
+	if m.Match("a.txt", false) {
+		t.Error("a later negation should re-include the file")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Match("anything.psd", false) {
+		t.Error("a project without an ignore file should ignore nothing")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("*.psd\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Match(filepath.Join("src", "art.psd"), false) {
+		t.Error("pattern from the ignore file not applied")
+	}
+
+	var none *Matcher
+	if none.Match("a", false) {
+		t.Error("nil Matcher should ignore nothing")
+	}
+}
@@ -0,0 +1,264 @@
+// Package imaging implements the image operations behind lightshell.image
+// using only the standard library codecs (PNG, JPEG, GIF).
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// Formats lists the supported encode formats.
+var Formats = []string{"png", "jpeg", "gif"}
+
+// DefaultQuality is the JPEG quality used when none is given.
+const DefaultQuality = 85
+
+// Limits on image size. A small file can declare enormous dimensions, so
+// Decode reads the header first and refuses images larger than MaxPixels
+// before allocating them; Resize refuses outputs beyond either limit.
+const (
+	MaxPixels    = 64 << 20 // about 67 megapixels, 256 MB as RGBA
+	MaxDimension = 1 << 15  // longest side of a resized image
+)
+
+// Fit modes for Resize.
+const (
+	FitContain = "contain" // scale to fit inside the box, preserving aspect ratio
+	FitCover   = "cover"   // scale to fill the box, preserving aspect ratio, and crop the overflow
+	FitFill    = "fill"    // stretch to exactly the box
+)
+
+// Decode reads an image and returns it with its format name ("png", "jpeg", "gif").
+// Images of more than MaxPixels are rejected without decoding their pixels.
+func Decode(r io.Reader) (image.Image, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image: %w", err)
+	}
+	cfg, _, err := DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if err := checkPixels("decode image", cfg.Width, cfg.Height); err != nil {
+		return nil, "", err
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// checkPixels rejects a width x height image of more than MaxPixels.
+func checkPixels(op string, width, height int) error {
+	if int64(width)*int64(height) > MaxPixels {
+		return fmt.Errorf("%s: %dx%d is larger than the limit of %d pixels", op, width, height, MaxPixels)
+	}
+	return nil
+}
+
+// DecodeConfig reads only the dimensions and format of an image.
+func DecodeConfig(r io.Reader) (image.Config, string, error) {
+	cfg, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return cfg, "", fmt.Errorf("decode image: %w", err)
+	}
+	return cfg, format, nil
+}
+
+// NormalizeFormat maps format names and file extensions ("jpg", ".PNG") to a
+// supported format, or returns an error.
+func NormalizeFormat(format string) (string, error) {
+	f := strings.ToLower(strings.TrimPrefix(format, "."))
+	switch f {
+	case "jpg", "jpeg":
+		return "jpeg", nil
+	case "png", "gif":
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported image format %q (supported: png, jpeg, gif)", format)
+}
+
+// Encode writes img in format. quality applies to JPEG only; <= 0 uses DefaultQuality.
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	format, err := NormalizeFormat(format)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "jpeg":
+		if quality <= 0 || quality > 100 {
+			quality = DefaultQuality
+		}
+		return jpeg.Encode(w, flatten(img), &jpeg.Options{Quality: quality})
+	case "gif":
+		return gif.Encode(w, img, nil)
+	default:
+		return png.Encode(w, img)
+	}
+}
+
+// EncodeBytes is Encode into a byte slice.
+func EncodeBytes(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, format, quality); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Resize scales img to width x height using fit. A zero width or height is
+// derived from the other to preserve the aspect ratio. Sides longer than
+// MaxDimension and outputs of more than MaxPixels are rejected.
+func Resize(img image.Image, width, height int, fit string) (image.Image, error) {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 {
+		return nil, fmt.Errorf("resize: empty image")
+	}
+	if width < 0 || height < 0 || (width == 0 && height == 0) {
+		return nil, fmt.Errorf("resize: width or height is required")
+	}
+	if width > MaxDimension || height > MaxDimension {
+		return nil, fmt.Errorf("resize: %dx%d is larger than the limit of %d pixels per side", width, height, MaxDimension)
+	}
+	if width == 0 {
+		width = max(1, int(math.Round(float64(sw)*float64(height)/float64(sh))))
+	}
+	if height == 0 {
+		height = max(1, int(math.Round(float64(sh)*float64(width)/float64(sw))))
+	}
+	if width > MaxDimension || height > MaxDimension {
+		return nil, fmt.Errorf("resize: %dx%d is larger than the limit of %d pixels per side", width, height, MaxDimension)
+	}
+	if err := checkPixels("resize", width, height); err != nil {
+		return nil, err
+	}
+
+	switch fit {
+	case "", FitContain:
+		w, h := FitSize(sw, sh, width, height)
+		return scale(img, b, w, h), nil
+	case FitFill:
+		return scale(img, b, width, height), nil
+	case FitCover:
+		// Crop the source to the target aspect ratio, centered, then scale.
+		// The products are computed in int64 so large sizes cannot overflow.
+		crop := b
+		if int64(sw)*int64(height) > int64(sh)*int64(width) {
+			cw := max(1, int(int64(sh)*int64(width)/int64(height)))
+			crop.Min.X = b.Min.X + (sw-cw)/2
+			crop.Max.X = crop.Min.X + cw
+		} else {
+			ch := max(1, int(int64(sw)*int64(height)/int64(width)))
+			crop.Min.Y = b.Min.Y + (sh-ch)/2
+			crop.Max.Y = crop.Min.Y + ch
+		}
+		return scale(img, crop, width, height), nil
+	}
+	return nil, fmt.Errorf("resize: unknown fit %q (use contain, cover, or fill)", fit)
+}
+
+// Thumbnail scales img to fit within size x size, never enlarging it.
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= size && b.Dy() <= size {
+		return img
+	}
+	w, h := FitSize(b.Dx(), b.Dy(), size, size)
+	return scale(img, b, w, h)
+}
+
+// FitSize returns the largest dimensions with the aspect ratio of sw x sh
+// that fit inside maxW x maxH.
+func FitSize(sw, sh, maxW, maxH int) (int, int) {
+	ratio := math.Min(float64(maxW)/float64(sw), float64(maxH)/float64(sh))
+	w := max(1, int(math.Round(float64(sw)*ratio)))
+	h := max(1, int(math.Round(float64(sh)*ratio)))
+	return w, h
+}
+
+// scale resamples the src rectangle of img to w x h. Each destination pixel
+// averages the source pixels it covers (area averaging), which gives clean
+// downscales and degrades to bilinear-like sampling when enlarging.
+func scale(img image.Image, src image.Rectangle, w, h int) *image.NRGBA {
+	in := toNRGBA(img)
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	sx := float64(src.Dx()) / float64(w)
+	sy := float64(src.Dy()) / float64(h)
+
+	for y := 0; y < h; y++ {
+		y0 := float64(src.Min.Y) + float64(y)*sy
+		y1 := y0 + sy
+		for x := 0; x < w; x++ {
+			x0 := float64(src.Min.X) + float64(x)*sx
+			x1 := x0 + sx
+
+			var r, g, b, a, total float64
+			for py := int(y0); float64(py) < y1 && py < src.Max.Y; py++ {
+				wy := math.Min(y1, float64(py+1)) - math.Max(y0, float64(py))
+				for px := int(x0); float64(px) < x1 && px < src.Max.X; px++ {
+					wx := math.Min(x1, float64(px+1)) - math.Max(x0, float64(px))
+					wgt := wx * wy
+					i := in.PixOffset(px, py)
+					pa := float64(in.Pix[i+3])
+					// Weight color by alpha so transparent pixels don't darken edges
+					r += float64(in.Pix[i]) * pa * wgt
+					g += float64(in.Pix[i+1]) * pa * wgt
+					b += float64(in.Pix[i+2]) * pa * wgt
+					a += pa * wgt
+					total += wgt
+				}
+			}
+			o := out.PixOffset(x, y)
+			if a > 0 {
+				out.Pix[o] = clamp(r / a)
+				out.Pix[o+1] = clamp(g / a)
+				out.Pix[o+2] = clamp(b / a)
+			}
+			if total > 0 {
+				out.Pix[o+3] = clamp(a / total)
+			}
+		}
+	}
+	return out
+}
+
+// toNRGBA converts img to non-premultiplied RGBA, reusing it when possible.
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok {
+		return n
+	}
+	b := img.Bounds()
+	n := image.NewNRGBA(b)
+	draw.Draw(n, b, img, b.Min, draw.Src)
+	return n
+}
+
+// flatten composites img onto white, since JPEG has no alpha channel.
+func flatten(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	draw.Draw(out, b, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(out, b, img, b.Min, draw.Over)
+	return out
+}
+
+func clamp(v float64) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
@@ -0,0 +1,156 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func solid(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestResizeFit(t *testing.T) {
+	src := solid(400, 200, color.NRGBA{255, 0, 0, 255})
+
+	tests := []struct {
+		name          string
+		width, height int
+		fit           string
+		wantW, wantH  int
+	}{
+		{"contain", 100, 100, FitContain, 100, 50},
+		{"cover", 100, 100, FitCover, 100, 100},
+		{"fill", 100, 100, FitFill, 100, 100},
+		{"width only", 200, 0, "", 200, 100},
+		{"height only", 0, 50, "", 100, 50},
+		{"enlarge", 800, 0, "", 800, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Resize(src, tt.width, tt.height, tt.fit)
+			if err != nil {
+				t.Fatalf("Resize: %v", err)
+			}
+			if b := out.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("got %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+			if c := color.NRGBAModel.Convert(out.At(out.Bounds().Dx()/2, out.Bounds().Dy()/2)).(color.NRGBA); c != (color.NRGBA{255, 0, 0, 255}) {
+				t.Errorf("expected solid red to stay red, got %v", c)
+			}
+		})
+	}
+
+	if _, err := Resize(src, 0, 0, ""); err == nil {
+		t.Error("expected error without width or height")
+	}
+	if _, err := Resize(src, 10, 10, "stretch"); err == nil {
+		t.Error("expected error for unknown fit")
+	}
+}
+
+func TestResizeAveragesAndKeepsTransparency(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{0, 0, 255, 255})
+	src.SetNRGBA(1, 0, color.NRGBA{255, 255, 255, 0}) // transparent white
+
+	out, _ := Resize(src, 1, 1, FitFill)
+	c := out.(*image.NRGBA).NRGBAAt(0, 0)
+	if c.B != 255 || c.R != 0 {
+		t.Errorf("transparent pixel should not bleed color, got %v", c)
+	}
+	if c.A < 127 || c.A > 128 {
+		t.Errorf("expected half alpha, got %d", c.A)
+	}
+}
+
+func TestThumbnailDoesNotEnlarge(t *testing.T) {
+	small := solid(50, 30, color.NRGBA{0, 255, 0, 255})
+	if out := Thumbnail(small, 256); out.Bounds().Dx() != 50 {
+		t.Errorf("expected small image unchanged, got %v", out.Bounds())
+	}
+	big := solid(1000, 500, color.NRGBA{0, 255, 0, 255})
+	if out := Thumbnail(big, 256); out.Bounds().Dx() != 256 || out.Bounds().Dy() != 128 {
+		t.Errorf("expected 256x128 thumbnail, got %v", out.Bounds())
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	src := solid(8, 4, color.NRGBA{10, 20, 30, 255})
+	for _, format := range []string{"png", "jpg", "gif"} {
+		data, err := EncodeBytes(src, format, 90)
+		if err != nil {
+			t.Fatalf("%s: Encode: %v", format, err)
+		}
+		img, got, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: Decode: %v", format, err)
+		}
+		want, _ := NormalizeFormat(format)
+		if got != want {
+			t.Errorf("expected format %s, got %s", want, got)
+		}
+		if img.Bounds().Dx() != 8 || img.Bounds().Dy() != 4 {
+			t.Errorf("%s: unexpected bounds %v", format, img.Bounds())
+		}
+	}
+
+	if _, err := NormalizeFormat("webp"); err == nil {
+		t.Error("expected webp to be unsupported")
+	}
+}
+
+// pngHeader returns a PNG whose header declares w x h but holds no pixels.
+func pngHeader(w, h uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], w)
+	binary.BigEndian.PutUint32(ihdr[8:], h)
+	ihdr[12], ihdr[13] = 8, 6 // 8-bit RGBA
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(13))
+	buf.Write(ihdr)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	return buf.Bytes()
+}
+
+func TestDecodeRejectsHugeImages(t *testing.T) {
+	data := pngHeader(100000, 100000)
+	if cfg, _, err := DecodeConfig(bytes.NewReader(data)); err != nil || cfg.Width != 100000 {
+		t.Fatalf("DecodeConfig = %v, %v", cfg, err)
+	}
+	_, _, err := Decode(bytes.NewReader(data))
+	if err == nil || !strings.Contains(err.Error(), "larger than the limit") {
+		t.Errorf("Decode error = %v, want the pixel limit", err)
+	}
+}
+
+func TestResizeLimits(t *testing.T) {
+	src := solid(4, 2, color.NRGBA{255, 0, 0, 255})
+	for _, size := range [][2]int{{MaxDimension + 1, 0}, {0, MaxDimension}, {MaxDimension, MaxDimension}} {
+		if _, err := Resize(src, size[0], size[1], FitFill); err == nil {
+			t.Errorf("Resize to %dx%d succeeded, want a limit error", size[0], size[1])
+		}
+	}
+
+	// A wide, short cover crop must not overflow or collapse to nothing.
+	wide := solid(4000, 1, color.NRGBA{0, 0, 255, 255})
+	out, err := Resize(wide, 1, 2000, FitCover)
+	if err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	if b := out.Bounds(); b.Dx() != 1 || b.Dy() != 2000 {
+		t.Errorf("bounds = %v, want 1x2000", b)
+	}
+}
@@ -3,7 +3,12 @@ package ipc
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/metrics"
+	"github.com/lightshell-dev/lightshell/internal/worker"
 )
 
 // HandlerFunc processes an IPC request and returns a result or error.
@@ -15,7 +20,11 @@ type Router struct {
 	handlers        map[string]HandlerFunc
 	customHandlers  map[string]HandlerFunc
 	evalFunc        func(js string) // function to evaluate JS in the webview
+	windows         map[int]func(js string) // eval functions of additional windows, by ID
 	shutdownHooks   []func()
+	windowHooks     []func(id int) // called when a window is removed
+	pool            *worker.Pool
+	pooled          map[string]bool // methods that always run on the pool
 }
 
 // NewRouter creates a new IPC router.
@@ -23,6 +32,8 @@ func NewRouter() *Router {
 	r := &Router{
 		handlers:       make(map[string]HandlerFunc),
 		customHandlers: make(map[string]HandlerFunc),
+		pooled:         make(map[string]bool),
+		windows:        make(map[int]func(js string)),
 	}
 	// Register the invoke dispatcher that routes to custom handlers
 	r.handlers["invoke"] = r.handleInvoke
@@ -34,6 +45,34 @@ func (r *Router) SetEvalFunc(fn func(js string)) {
 	r.evalFunc = fn
 }
 
+// AddWindow registers an additional window. Events are sent to it as well
+// as to the main window, and DispatchWindow replies to it with eval.
+func (r *Router) AddWindow(id int, eval func(js string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.windows[id] = eval
+}
+
+// RemoveWindow forgets a closed window and runs the OnWindowRemoved hooks.
+func (r *Router) RemoveWindow(id int) {
+	r.mu.Lock()
+	delete(r.windows, id)
+	hooks := make([]func(id int), len(r.windowHooks))
+	copy(hooks, r.windowHooks)
+	r.mu.Unlock()
+	for _, fn := range hooks {
+		fn(id)
+	}
+}
+
+// OnWindowRemoved registers a function to be called with the ID of each
+// window that closes, so what it started can be stopped.
+func (r *Router) OnWindowRemoved(fn func(id int)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.windowHooks = append(r.windowHooks, fn)
+}
+
 // Handle registers a handler for a method name.
 func (r *Router) Handle(method string, handler HandlerFunc) {
 	r.mu.Lock()
@@ -41,6 +80,23 @@ func (r *Router) Handle(method string, handler HandlerFunc) {
 	r.handlers[method] = handler
 }
 
+// HandlePooled registers a CPU-heavy handler. When a worker pool is set, calls
+// to it run on the pool under its namespace's limit instead of blocking dispatch.
+func (r *Router) HandlePooled(method string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[method] = handler
+	r.pooled[method] = true
+}
+
+// SetPool sets the worker pool used for pooled handlers and for every method
+// in a namespace the pool is configured for.
+func (r *Router) SetPool(p *worker.Pool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pool = p
+}
+
 // HandleCustom registers a custom handler invokable from JS via lightshell.invoke(name, payload).
 func (r *Router) HandleCustom(name string, handler HandlerFunc) {
 	r.mu.Lock()
@@ -102,7 +158,15 @@ func (r *Router) HandleMessage(rawMsg string) string {
 		return errorResponse(req.ID, fmt.Sprintf("unknown method: %s", req.Method))
 	}
 
+	start := time.Now()
 	result, err := handler(req.Params)
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	metrics.Add(metrics.IPCCalls, metrics.Labels{"method": req.Method, "status": status}, 1)
+	metrics.Observe(metrics.IPCDuration, metrics.Labels{"method": req.Method}, time.Since(start))
+
 	if err != nil {
 		return errorResponse(req.ID, err.Error())
 	}
@@ -110,18 +174,69 @@ func (r *Router) HandleMessage(rawMsg string) string {
 	return successResponse(req.ID, result)
 }
 
-// SendEvent sends an event to the webview via JS eval.
-func (r *Router) SendEvent(eventName string, data any) {
-	if r.evalFunc == nil {
+// Dispatch processes a raw message like HandleMessage and passes the response
+// to reply. Pooled methods run on the worker pool and reply from a pool
+// goroutine; all other methods reply before Dispatch returns.
+func (r *Router) Dispatch(rawMsg string, reply func(response string)) {
+	var req Request
+	if err := json.Unmarshal([]byte(rawMsg), &req); err == nil {
+		ns, _, _ := strings.Cut(req.Method, ".")
+		r.mu.RLock()
+		pool := r.pool
+		pooled := r.pooled[req.Method]
+		r.mu.RUnlock()
+
+		if pool != nil && (pooled || pool.Configured(ns)) {
+			err := pool.Submit(ns, func() { reply(r.HandleMessage(rawMsg)) })
+			if err != nil {
+				reply(errorResponse(req.ID, fmt.Sprintf("%s: %v", req.Method, err)))
+			}
+			return
+		}
+	}
+	reply(r.HandleMessage(rawMsg))
+}
+
+// DispatchWindow dispatches a message from an additional window and
+// replies to that window. Messages from a window that is not registered
+// are dropped.
+func (r *Router) DispatchWindow(id int, rawMsg string) {
+	r.mu.RLock()
+	eval, ok := r.windows[id]
+	r.mu.RUnlock()
+	if !ok {
 		return
 	}
+	r.Dispatch(rawMsg, func(response string) {
+		eval(fmt.Sprintf("__lightshell_receive(%s)", response))
+	})
+}
+
+// SendEvent sends an event to the main window and every additional window
+// via JS eval.
+func (r *Router) SendEvent(eventName string, data any) {
 	evt := Event{EventName: eventName, Data: data}
 	jsonBytes, err := json.Marshal(evt)
 	if err != nil {
 		return
 	}
-	js := fmt.Sprintf("__lightshell_receive(%s)", string(jsonBytes))
-	r.evalFunc(js)
+	r.EvalAll(fmt.Sprintf("__lightshell_receive(%s)", string(jsonBytes)))
+}
+
+// EvalAll evaluates js in the main window and every additional window.
+func (r *Router) EvalAll(js string) {
+	r.mu.RLock()
+	evals := make([]func(string), 0, len(r.windows)+1)
+	if r.evalFunc != nil {
+		evals = append(evals, r.evalFunc)
+	}
+	for _, eval := range r.windows {
+		evals = append(evals, eval)
+	}
+	r.mu.RUnlock()
+	for _, eval := range evals {
+		eval(js)
+	}
 }
 
 func successResponse(id string, result any) string {
@@ -3,9 +3,12 @@ package ipc
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+
+	"github.com/lightshell-dev/lightshell/internal/worker"
 )
 
 func parseResponse(t *testing.T, raw string) Response {
@@ -265,6 +268,69 @@ func TestIPCSendEventNoEvalFunc(t *testing.T) {
 	router.SendEvent("test.event", map[string]string{"key": "value"})
 }
 
+func TestSendEventToWindows(t *testing.T) {
+	router := NewRouter()
+	got := map[string]int{}
+	router.SetEvalFunc(func(js string) { got["main"]++ })
+	router.AddWindow(2, func(js string) { got["2"]++ })
+	router.AddWindow(3, func(js string) { got["3"]++ })
+
+	router.SendEvent("window.closed", map[string]int{"id": 4})
+	if got["main"] != 1 || got["2"] != 1 || got["3"] != 1 {
+		t.Fatalf("expected the event in every window, got %v", got)
+	}
+
+	router.RemoveWindow(3)
+	router.SendEvent("window.closed", map[string]int{"id": 3})
+	if got["main"] != 2 || got["2"] != 2 || got["3"] != 1 {
+		t.Errorf("expected removed window to get no events, got %v", got)
+	}
+}
+
+func TestDispatchWindow(t *testing.T) {
+	router := NewRouter()
+	router.Handle("app.version", func(params json.RawMessage) (any, error) {
+		return "1.0.0", nil
+	})
+	var mainJS, windowJS string
+	router.SetEvalFunc(func(js string) { mainJS = js })
+	router.AddWindow(2, func(js string) { windowJS = js })
+
+	router.DispatchWindow(2, `{"id":"1","method":"app.version","params":{}}`)
+	if mainJS != "" {
+		t.Errorf("reply went to the main window: %q", mainJS)
+	}
+	const prefix = "__lightshell_receive("
+	if !strings.HasPrefix(windowJS, prefix) {
+		t.Fatalf("expected a reply in window 2, got %q", windowJS)
+	}
+	if resp := parseResponse(t, strings.TrimSuffix(strings.TrimPrefix(windowJS, prefix), ")")); resp.ID != "1" || resp.Result != "1.0.0" {
+		t.Errorf("unexpected reply: %+v", resp)
+	}
+
+	// A closed window's messages are dropped
+	windowJS = ""
+	router.RemoveWindow(2)
+	router.DispatchWindow(2, `{"id":"2","method":"app.version","params":{}}`)
+	if windowJS != "" || mainJS != "" {
+		t.Errorf("expected no reply for a removed window, got %q / %q", windowJS, mainJS)
+	}
+}
+
+func TestEvalAll(t *testing.T) {
+	router := NewRouter()
+	var got []string
+	router.SetEvalFunc(func(js string) { got = append(got, "main:"+js) })
+	router.AddWindow(2, func(js string) { got = append(got, "2:"+js) })
+	router.AddWindow(3, func(js string) { got = append(got, "3:"+js) })
+	router.RemoveWindow(3)
+
+	router.EvalAll("location.reload()")
+	if len(got) != 2 || got[0] != "main:location.reload()" || got[1] != "2:location.reload()" {
+		t.Errorf("EvalAll reached %v", got)
+	}
+}
+
 // --- Tests for new invoke/custom handler/shutdown functionality ---
 
 func TestHandleCustomAndInvoke(t *testing.T) {
@@ -503,3 +569,62 @@ func TestHandleCustomConcurrency(t *testing.T) {
 		}
 	}
 }
+
+func TestDispatchPooled(t *testing.T) {
+	router := NewRouter()
+	router.SetPool(worker.NewPool(worker.Config{Size: 1}))
+
+	release := make(chan struct{})
+	router.HandlePooled("image.resize", func(params json.RawMessage) (any, error) {
+		<-release
+		return "done", nil
+	})
+	router.Handle("app.version", func(params json.RawMessage) (any, error) {
+		return "1.0.0", nil
+	})
+
+	replies := make(chan string, 2)
+	reply := func(resp string) { replies <- resp }
+
+	router.Dispatch(`{"id":"1","method":"image.resize","params":{}}`, reply)
+	router.Dispatch(`{"id":"2","method":"app.version","params":{}}`, reply)
+
+	// The unpooled call must not wait behind the pooled one
+	if resp := parseResponse(t, <-replies); resp.ID != "2" {
+		t.Fatalf("expected unpooled reply first, got id %q", resp.ID)
+	}
+
+	close(release)
+	resp := parseResponse(t, <-replies)
+	if resp.ID != "1" || resp.Result != "done" {
+		t.Errorf("unexpected pooled reply: %+v", resp)
+	}
+}
+
+func TestDispatchWithoutPool(t *testing.T) {
+	router := NewRouter()
+	router.HandlePooled("image.resize", func(params json.RawMessage) (any, error) {
+		return "done", nil
+	})
+
+	var got string
+	router.Dispatch(`{"id":"1","method":"image.resize","params":{}}`, func(resp string) { got = resp })
+	if got == "" {
+		t.Fatal("expected synchronous reply when no pool is set")
+	}
+	if resp := parseResponse(t, got); resp.Result != "done" {
+		t.Errorf("unexpected reply: %+v", resp)
+	}
+}
+
+func TestOnWindowRemoved(t *testing.T) {
+	router := NewRouter()
+	router.AddWindow(2, func(string) {})
+	var removed []int
+	router.OnWindowRemoved(func(id int) { removed = append(removed, id) })
+
+	router.RemoveWindow(2)
+	if len(removed) != 1 || removed[0] != 2 {
+		t.Errorf("removed = %v, want [2]", removed)
+	}
+}
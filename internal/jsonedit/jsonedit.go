@@ -0,0 +1,361 @@
+// Package jsonedit changes values in a JSON document in place, so that
+// rewriting a hand-edited file such as lightshell.json keeps its key order,
+// indentation, and any comments outside the changed values.
+package jsonedit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// member is one "key": value pair of an object, by byte offsets.
+type member struct {
+	key        string
+	keyStart   int
+	valueStart int
+	valueEnd   int
+}
+
+// object is a parsed object: its braces and members.
+type object struct {
+	start, end int // offsets of '{' and '}'
+	members    []member
+}
+
+// Set sets the value at path, a list of object keys from the root, and
+// returns the new document. Missing objects along the path are created, and
+// a new key is added after its object's last member.
+func Set(src []byte, path []string, value any) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("jsonedit: empty path")
+	}
+	obj, err := rootObject(src)
+	if err != nil {
+		return nil, err
+	}
+	for depth, key := range path {
+		last := depth == len(path)-1
+		m, ok := obj.find(key)
+		if !ok {
+			// Build what is left of the path as nested objects
+			var v any = value
+			for i := len(path) - 1; i > depth; i-- {
+				v = map[string]any{path[i]: v}
+			}
+			return insert(src, obj, key, v)
+		}
+		if !last && src[m.valueStart] == '{' {
+			if obj, _, err = parseObject(src, m.valueStart); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var v any = value
+		for i := len(path) - 1; i > depth; i-- {
+			v = map[string]any{path[i]: v}
+		}
+		text, err := marshal(v, lineIndent(src, m.keyStart), indentUnit(src))
+		if err != nil {
+			return nil, err
+		}
+		return splice(src, m.valueStart, m.valueEnd, text), nil
+	}
+	return src, nil
+}
+
+// Delete removes the key at path, with its value and separating comma. A
+// missing key is not an error.
+func Delete(src []byte, path []string) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("jsonedit: empty path")
+	}
+	obj, err := rootObject(src)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range path[:len(path)-1] {
+		m, ok := obj.find(key)
+		if !ok || src[m.valueStart] != '{' {
+			return src, nil
+		}
+		if obj, _, err = parseObject(src, m.valueStart); err != nil {
+			return nil, err
+		}
+	}
+
+	key := path[len(path)-1]
+	for i, m := range obj.members {
+		if m.key != key {
+			continue
+		}
+		switch {
+		case len(obj.members) == 1:
+			return splice(src, obj.start+1, obj.end, nil), nil
+		case i == 0:
+			// Up to the next key, taking the comma along
+			return splice(src, m.keyStart, obj.members[1].keyStart, nil), nil
+		default:
+			// From the end of the previous value, taking its comma along
+			return splice(src, obj.members[i-1].valueEnd, m.valueEnd, nil), nil
+		}
+	}
+	return src, nil
+}
+
+// KeyOffset returns the offset of the key at path, or of the deepest of
+// its parents that is present, for pointing at it in messages. It reports
+// false when not even the first key is present.
+func KeyOffset(src []byte, path []string) (int, bool) {
+	obj, err := rootObject(src)
+	if err != nil {
+		return 0, false
+	}
+	offset, found := 0, false
+	for _, key := range path {
+		m, ok := obj.find(key)
+		if !ok {
+			break
+		}
+		offset, found = m.keyStart, true
+		if src[m.valueStart] != '{' {
+			break
+		}
+		if obj, _, err = parseObject(src, m.valueStart); err != nil {
+			break
+		}
+	}
+	return offset, found
+}
+
+func (o object) find(key string) (member, bool) {
+	// The last duplicate wins, as in encoding/json
+	for i := len(o.members) - 1; i >= 0; i-- {
+		if o.members[i].key == key {
+			return o.members[i], true
+		}
+	}
+	return member{}, false
+}
+
+// insert adds "key": value as the last member of obj.
+func insert(src []byte, obj object, key string, value any) ([]byte, error) {
+	unit := indentUnit(src)
+	k, _ := json.Marshal(key)
+
+	if len(obj.members) == 0 {
+		outer := lineIndent(src, obj.start)
+		inner := outer + unit
+		text, err := marshal(value, inner, unit)
+		if err != nil {
+			return nil, err
+		}
+		entry := "\n" + inner + string(k) + ": " + string(text) + "\n" + outer
+		return splice(src, obj.start+1, obj.end, []byte(entry)), nil
+	}
+
+	last := obj.members[len(obj.members)-1]
+	indent := lineIndent(src, last.keyStart)
+	sep := ", "
+	// A multi-line object gets the new key on its own line
+	if bytes.IndexByte(src[obj.start:last.keyStart], '\n') >= 0 {
+		sep = ",\n" + indent
+	}
+	text, err := marshal(value, indent, unit)
+	if err != nil {
+		return nil, err
+	}
+	return splice(src, last.valueEnd, last.valueEnd, []byte(sep+string(k)+": "+string(text))), nil
+}
+
+// marshal encodes v as it should appear at a position indented by prefix,
+// leaving &, <, and > readable.
+func marshal(v any, prefix, unit string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, unit)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func splice(src []byte, start, end int, text []byte) []byte {
+	out := make([]byte, 0, len(src)-(end-start)+len(text))
+	out = append(out, src[:start]...)
+	out = append(out, text...)
+	return append(out, src[end:]...)
+}
+
+// lineIndent returns the whitespace that starts the line containing pos.
+func lineIndent(src []byte, pos int) string {
+	start := bytes.LastIndexByte(src[:pos], '\n') + 1
+	end := start
+	for end < pos && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
+
+// indentUnit guesses the document's indentation step from its first
+// indented line, defaulting to two spaces.
+func indentUnit(src []byte) string {
+	for _, line := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+func rootObject(src []byte) (object, error) {
+	i := skipSpace(src, 0)
+	if i >= len(src) || src[i] != '{' {
+		return object{}, fmt.Errorf("jsonedit: document is not a JSON object")
+	}
+	obj, _, err := parseObject(src, i)
+	return obj, err
+}
+
+// skipSpace skips whitespace and // and /* */ comments.
+func skipSpace(src []byte, i int) int {
+	for i < len(src) {
+		switch {
+		case src[i] == ' ' || src[i] == '\t' || src[i] == '\n' || src[i] == '\r':
+			i++
+		case bytes.HasPrefix(src[i:], []byte("//")):
+			end := bytes.IndexByte(src[i:], '\n')
+			if end < 0 {
+				return len(src)
+			}
+			i += end + 1
+		case bytes.HasPrefix(src[i:], []byte("/*")):
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return len(src)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func syntaxError(i int) error {
+	return fmt.Errorf("jsonedit: invalid JSON at offset %d", i)
+}
+
+// parseObject parses the object starting at src[i] == '{' and returns it
+// and the offset just past it.
+func parseObject(src []byte, i int) (object, int, error) {
+	obj := object{start: i}
+	i = skipSpace(src, i+1)
+	if i < len(src) && src[i] == '}' {
+		obj.end = i
+		return obj, i + 1, nil
+	}
+	for {
+		if i >= len(src) || src[i] != '"' {
+			return obj, 0, syntaxError(i)
+		}
+		keyStart := i
+		keyEnd, err := parseString(src, i)
+		if err != nil {
+			return obj, 0, err
+		}
+		var key string
+		if err := json.Unmarshal(src[keyStart:keyEnd], &key); err != nil {
+			return obj, 0, syntaxError(keyStart)
+		}
+		i = skipSpace(src, keyEnd)
+		if i >= len(src) || src[i] != ':' {
+			return obj, 0, syntaxError(i)
+		}
+		valueStart := skipSpace(src, i+1)
+		valueEnd, err := parseValue(src, valueStart)
+		if err != nil {
+			return obj, 0, err
+		}
+		obj.members = append(obj.members, member{key: key, keyStart: keyStart, valueStart: valueStart, valueEnd: valueEnd})
+
+		i = skipSpace(src, valueEnd)
+		if i >= len(src) {
+			return obj, 0, syntaxError(i)
+		}
+		switch src[i] {
+		case ',':
+			i = skipSpace(src, i+1)
+		case '}':
+			obj.end = i
+			return obj, i + 1, nil
+		default:
+			return obj, 0, syntaxError(i)
+		}
+	}
+}
+
+// parseValue returns the offset just past the value starting at src[i].
+func parseValue(src []byte, i int) (int, error) {
+	if i >= len(src) {
+		return 0, syntaxError(i)
+	}
+	switch src[i] {
+	case '{':
+		_, end, err := parseObject(src, i)
+		return end, err
+	case '[':
+		i = skipSpace(src, i+1)
+		if i < len(src) && src[i] == ']' {
+			return i + 1, nil
+		}
+		for {
+			end, err := parseValue(src, i)
+			if err != nil {
+				return 0, err
+			}
+			i = skipSpace(src, end)
+			if i >= len(src) {
+				return 0, syntaxError(i)
+			}
+			switch src[i] {
+			case ',':
+				i = skipSpace(src, i+1)
+			case ']':
+				return i + 1, nil
+			default:
+				return 0, syntaxError(i)
+			}
+		}
+	case '"':
+		return parseString(src, i)
+	default:
+		// Numbers, true, false, and null run until a delimiter
+		end := i
+		for end < len(src) && !bytes.ContainsRune([]byte(",}] \t\r\n/"), rune(src[end])) {
+			end++
+		}
+		if end == i || !json.Valid(src[i:end]) {
+			return 0, syntaxError(i)
+		}
+		return end, nil
+	}
+}
+
+// parseString returns the offset just past the string starting at src[i].
+func parseString(src []byte, i int) (int, error) {
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		case '\n':
+			return 0, syntaxError(j)
+		}
+	}
+	return 0, syntaxError(i)
+}
@@ -0,0 +1,231 @@
+package jsonedit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const config = `{
+  // hand-edited
+  "name": "Notes",
+  "version": "1.0.0",
+  "window": {
+    "width": 800,
+    "height": 600
+  },
+  "permissions": ["fs", "dialog"],
+  "empty": {}
+}
+`
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  []string
+		value any
+		want  string
+	}{
+		{
+			name:  "replace top-level value",
+			path:  []string{"version"},
+			value: "1.1.0",
+			want: `{
+  // hand-edited
+  "name": "Notes",
+  "version": "1.1.0",
+  "window": {
+    "width": 800,
+    "height": 600
+  },
+  "permissions": ["fs", "dialog"],
+  "empty": {}
+}
+`,
+		},
+		{
+			name:  "replace nested value",
+			path:  []string{"window", "height"},
+			value: 700,
+			want: `{
+  // hand-edited
+  "name": "Notes",
+  "version": "1.0.0",
+  "window": {
+    "width": 800,
+    "height": 700
+  },
+  "permissions": ["fs", "dialog"],
+  "empty": {}
+}
+`,
+		},
+		{
+			name:  "add key to nested object",
+			path:  []string{"window", "title"},
+			value: "Notes & <Tasks>",
+			want: `{
+  // hand-edited
+  "name": "Notes",
+  "version": "1.0.0",
+  "window": {
+    "width": 800,
+    "height": 600,
+    "title": "Notes & <Tasks>"
+  },
+  "permissions": ["fs", "dialog"],
+  "empty": {}
+}
+`,
+		},
+		{
+			name:  "create missing objects",
+			path:  []string{"updater", "publicKey"},
+			value: "abc",
+			want: `{
+  // hand-edited
+  "name": "Notes",
+  "version": "1.0.0",
+  "window": {
+    "width": 800,
+    "height": 600
+  },
+  "permissions": ["fs", "dialog"],
+  "empty": {},
+  "updater": {
+    "publicKey": "abc"
+  }
+}
+`,
+		},
+		{
+			name:  "add key to empty object",
+			path:  []string{"empty", "a"},
+			value: []string{"x"},
+			want: `{
+  // hand-edited
+  "name": "Notes",
+  "version": "1.0.0",
+  "window": {
+    "width": 800,
+    "height": 600
+  },
+  "permissions": ["fs", "dialog"],
+  "empty": {
+    "a": [
+      "x"
+    ]
+  }
+}
+`,
+		},
+		{
+			name:  "replace non-object along the path",
+			path:  []string{"name", "short"},
+			value: "N",
+			want: `{
+  // hand-edited
+  "name": {
+    "short": "N"
+  },
+  "version": "1.0.0",
+  "window": {
+    "width": 800,
+    "height": 600
+  },
+  "permissions": ["fs", "dialog"],
+  "empty": {}
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Set([]byte(config), tt.path, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Set() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetSingleLine(t *testing.T) {
+	got, err := Set([]byte(`{"a": 1}`), []string{"b"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"a": 1, "b": true}` {
+		t.Errorf("Set() = %s", got)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	src := []byte(`{
+  "a": 1,
+  "b": {"c": 2},
+  "d": [1, 2]
+}`)
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{[]string{"a"}, "{\n  \"b\": {\"c\": 2},\n  \"d\": [1, 2]\n}"},
+		{[]string{"d"}, "{\n  \"a\": 1,\n  \"b\": {\"c\": 2}\n}"},
+		{[]string{"b", "c"}, "{\n  \"a\": 1,\n  \"b\": {},\n  \"d\": [1, 2]\n}"},
+		{[]string{"missing"}, string(src)},
+		{[]string{"a", "x"}, string(src)},
+	}
+	for _, tt := range tests {
+		got, err := Delete(src, tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Delete(%v) =\n%s\nwant\n%s", tt.path, got, tt.want)
+		}
+		if !json.Valid(got) {
+			t.Errorf("Delete(%v) produced invalid JSON", tt.path)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, src := range []string{``, `[1]`, `{"a": }`, `{"a": 1`, `{"a" 1}`, `{"a": tru}`} {
+		if _, err := Set([]byte(src), []string{"a"}, 1); err == nil {
+			t.Errorf("Set(%q) succeeded", src)
+		}
+	}
+}
+
+func TestKeyOffset(t *testing.T) {
+	src := []byte(`{
+  "name": "app",
+  "permissions": {
+    "fs": {"read": ["$HOME/**"]}
+  }
+}`)
+	tests := []struct {
+		path []string
+		want string // the text at the offset
+		ok   bool
+	}{
+		{[]string{"name"}, `"name"`, true},
+		{[]string{"permissions", "fs", "read"}, `"read"`, true},
+		{[]string{"permissions", "fs", "write"}, `"fs"`, true},
+		{[]string{"name", "first"}, `"name"`, true},
+		{[]string{"window"}, "", false},
+	}
+	for _, tt := range tests {
+		offset, ok := KeyOffset(src, tt.path)
+		if ok != tt.ok {
+			t.Errorf("KeyOffset(%v) ok = %v, want %v", tt.path, ok, tt.ok)
+			continue
+		}
+		if ok && !strings.HasPrefix(string(src[offset:]), tt.want) {
+			t.Errorf("KeyOffset(%v) points at %.10q, want %q", tt.path, src[offset:], tt.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Package launchargs parses an app's command-line flags against the
+// launchArgs schema in lightshell.json, so apps launched from scripts can
+// receive structured options instead of parsing argv themselves.
+package launchargs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flag types.
+const (
+	TypeString  = "string"
+	TypeBoolean = "boolean"
+	TypeNumber  = "number"
+)
+
+// Flag declares one --name flag.
+type Flag struct {
+	Type        string `json:"type"`            // "string" (default), "boolean", or "number"
+	Short       string `json:"short,omitempty"` // single-letter alias, used as -x
+	Default     any    `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema maps flag names to their declarations.
+type Schema map[string]Flag
+
+// Result is what the app receives: options keyed by flag name, with
+// defaults applied, plus everything that was not a declared flag.
+type Result struct {
+	Options map[string]any `json:"options"`
+	Args    []string       `json:"args"`    // positional arguments
+	Unknown []string       `json:"unknown"` // undeclared flags, e.g. ones added by the OS
+}
+
+// ErrHelp is returned by Parse when the app was launched with --help or -h
+// and the schema does not declare its own help flag.
+var ErrHelp = errors.New("help requested")
+
+// Validate checks names, types, short aliases, and defaults.
+func (s Schema) Validate() error {
+	shorts := make(map[string]string)
+	for _, name := range s.names() {
+		f := s[name]
+		if !validName(name) {
+			return fmt.Errorf("launchArgs: invalid flag name %q: use letters, digits, and '-'", name)
+		}
+		switch f.typ() {
+		case TypeString, TypeBoolean, TypeNumber:
+		default:
+			return fmt.Errorf("launchArgs: flag %q: unknown type %q (use string, boolean, or number)", name, f.Type)
+		}
+		if f.Short != "" {
+			if len(f.Short) != 1 || !validName(f.Short) {
+				return fmt.Errorf("launchArgs: flag %q: short must be a single letter or digit, got %q", name, f.Short)
+			}
+			if other, ok := shorts[f.Short]; ok {
+				return fmt.Errorf("launchArgs: flags %q and %q both use -%s", other, name, f.Short)
+			}
+			shorts[f.Short] = name
+		}
+		if f.Default != nil {
+			if _, err := f.convert(f.Default); err != nil {
+				return fmt.Errorf("launchArgs: flag %q: default %v is not a %s", name, f.Default, f.typ())
+			}
+		}
+	}
+	return nil
+}
+
+func validName(name string) bool {
+	if name == "" || name[0] == '-' {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Flag) typ() string {
+	if f.Type == "" {
+		return TypeString
+	}
+	return f.Type
+}
+
+// convert normalizes a default from JSON to the flag's type.
+func (f Flag) convert(v any) (any, error) {
+	switch f.typ() {
+	case TypeBoolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case TypeNumber:
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		}
+	default:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("wrong type")
+}
+
+// parse converts a command-line value to the flag's type.
+func (f Flag) parse(name, value string) (any, error) {
+	switch f.typ() {
+	case TypeBoolean:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("--%s expects true or false, got %q", name, value)
+		}
+		return b, nil
+	case TypeNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("--%s expects a number, got %q", name, value)
+		}
+		return n, nil
+	}
+	return value, nil
+}
+
+func (s Schema) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse parses argv (without the program name). Flags may be written as
+// --name value, --name=value, -x value, or -x=value; boolean flags also
+// accept --name and --no-name. Everything after "--" is positional.
+func Parse(s Schema, argv []string) (Result, error) {
+	r := Result{Options: make(map[string]any), Args: []string{}, Unknown: []string{}}
+	for name, f := range s {
+		if f.Default != nil {
+			v, _ := f.convert(f.Default)
+			r.Options[name] = v
+		} else if f.typ() == TypeBoolean {
+			r.Options[name] = false
+		}
+	}
+	_, helpDeclared := s["help"]
+	shorts := make(map[string]string)
+	for name, f := range s {
+		if f.Short != "" {
+			shorts[f.Short] = name
+		}
+	}
+
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+		if arg == "--" {
+			r.Args = append(r.Args, argv[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			r.Args = append(r.Args, arg)
+			continue
+		}
+		if !helpDeclared && len(s) > 0 && (arg == "--help" || (arg == "-h" && shorts["h"] == "")) {
+			return r, ErrHelp
+		}
+
+		key, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		name := key
+		if !strings.HasPrefix(arg, "--") {
+			name = shorts[key]
+		}
+		f, ok := s[name]
+		negated := false
+		if !ok && strings.HasPrefix(arg, "--no-") && !hasValue {
+			if nf, nok := s[strings.TrimPrefix(key, "no-")]; nok && nf.typ() == TypeBoolean {
+				name, f, ok, negated = strings.TrimPrefix(key, "no-"), nf, true, true
+			}
+		}
+		if !ok {
+			r.Unknown = append(r.Unknown, arg)
+			continue
+		}
+
+		switch {
+		case negated:
+			r.Options[name] = false
+			continue
+		case f.typ() == TypeBoolean && !hasValue:
+			r.Options[name] = true
+			continue
+		case !hasValue:
+			if i+1 >= len(argv) {
+				return r, fmt.Errorf("%s needs a value", arg)
+			}
+			i++
+			value = argv[i]
+		}
+		v, err := f.parse(name, value)
+		if err != nil {
+			return r, err
+		}
+		r.Options[name] = v
+	}
+	return r, nil
+}
+
+// Usage returns help text listing the declared flags.
+func Usage(program string, s Schema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: %s [options] [args...]\n\nOptions:\n", program)
+	for _, name := range s.names() {
+		f := s[name]
+		flag := "    --" + name
+		if f.Short != "" {
+			flag = "-" + f.Short + ", --" + name
+		}
+		if f.typ() != TypeBoolean {
+			flag += " <" + f.typ() + ">"
+		}
+		line := "  " + flag
+		desc := f.Description
+		if f.Default != nil {
+			desc = strings.TrimSpace(fmt.Sprintf("%s (default %v)", desc, f.Default))
+		}
+		if desc != "" {
+			line = fmt.Sprintf("  %-28s %s", flag, desc)
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return b.String()
+}
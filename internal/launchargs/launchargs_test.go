@@ -0,0 +1,116 @@
+package launchargs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testSchema = Schema{
+	"file":    {Type: "string", Short: "f", Description: "File to open"},
+	"verbose": {Type: "boolean", Short: "v"},
+	"count":   {Type: "number", Default: float64(1)},
+	"mode":    {Default: "fast"},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		argv    []string
+		options map[string]any
+		args    []string
+		unknown []string
+	}{
+		{
+			argv:    nil,
+			options: map[string]any{"verbose": false, "count": 1.0, "mode": "fast"},
+		},
+		{
+			argv:    []string{"--file", "a.txt", "-v", "--count=3", "b.txt"},
+			options: map[string]any{"file": "a.txt", "verbose": true, "count": 3.0, "mode": "fast"},
+			args:    []string{"b.txt"},
+		},
+		{
+			argv:    []string{"-f=x", "--verbose=false", "--mode", "slow", "--", "--file", "y"},
+			options: map[string]any{"file": "x", "verbose": false, "count": 1.0, "mode": "slow"},
+			args:    []string{"--file", "y"},
+		},
+		{
+			argv:    []string{"-v", "--no-verbose", "-psn_0_12345", "--other=1"},
+			options: map[string]any{"verbose": false, "count": 1.0, "mode": "fast"},
+			unknown: []string{"-psn_0_12345", "--other=1"},
+		},
+	}
+	for _, tt := range tests {
+		r, err := Parse(testSchema, tt.argv)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.argv, err)
+			continue
+		}
+		if !reflect.DeepEqual(r.Options, tt.options) {
+			t.Errorf("Parse(%q).Options = %v, want %v", tt.argv, r.Options, tt.options)
+		}
+		if tt.args == nil {
+			tt.args = []string{}
+		}
+		if tt.unknown == nil {
+			tt.unknown = []string{}
+		}
+		if !reflect.DeepEqual(r.Args, tt.args) || !reflect.DeepEqual(r.Unknown, tt.unknown) {
+			t.Errorf("Parse(%q) args=%q unknown=%q, want %q and %q", tt.argv, r.Args, r.Unknown, tt.args, tt.unknown)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, argv := range [][]string{
+		{"--file"},
+		{"--count", "many"},
+		{"--verbose=maybe"},
+	} {
+		if _, err := Parse(testSchema, argv); err == nil {
+			t.Errorf("Parse(%q): expected error", argv)
+		}
+	}
+}
+
+func TestParseHelp(t *testing.T) {
+	if _, err := Parse(testSchema, []string{"--help"}); err != ErrHelp {
+		t.Errorf("--help: got %v, want ErrHelp", err)
+	}
+	// Without a schema the app handles its own flags
+	if r, err := Parse(nil, []string{"--help"}); err != nil || len(r.Unknown) != 1 {
+		t.Errorf("--help with no schema: %+v, %v", r, err)
+	}
+	// A declared help flag is an ordinary option
+	s := Schema{"help": {Type: "boolean"}}
+	if r, err := Parse(s, []string{"--help"}); err != nil || r.Options["help"] != true {
+		t.Errorf("declared --help: %+v, %v", r, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := testSchema.Validate(); err != nil {
+		t.Errorf("valid schema: %v", err)
+	}
+	bad := []Schema{
+		{"bad name": {}},
+		{"x": {Type: "array"}},
+		{"x": {Short: "xy"}},
+		{"a": {Short: "a"}, "b": {Short: "a"}},
+		{"n": {Type: "number", Default: "ten"}},
+	}
+	for _, s := range bad {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%v): expected error", s)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	u := Usage("notes", testSchema)
+	for _, want := range []string{"Usage: notes [options]", "-f, --file <string>", "File to open", "--count <number>", "(default 1)", "-v, --verbose"} {
+		if !strings.Contains(u, want) {
+			t.Errorf("usage missing %q:\n%s", want, u)
+		}
+	}
+}
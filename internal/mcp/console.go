@@ -7,6 +7,20 @@ type ConsoleEntry struct {
 	Timestamp string `json:"timestamp"`
 	Level     string `json:"level"`
 	Message   string `json:"message"`
+	Window    string `json:"window,omitempty"` // title of the window it came from, unless the main one
+}
+
+// NetworkEntry is one fetch or XMLHttpRequest request made by the webview.
+type NetworkEntry struct {
+	Timestamp string  `json:"timestamp"`
+	Type      string  `json:"type"` // "fetch" or "xhr"
+	Method    string  `json:"method"`
+	URL       string  `json:"url"`
+	Status    int     `json:"status"`
+	Duration  float64 `json:"duration"`
+	Size      int64   `json:"size,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	Window    string  `json:"window,omitempty"`
 }
 
 // ConsoleBuffer is a thread-safe ring buffer for console log entries.
@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Project-specific tools are named namespace_name. The lightshell namespace
+// is the built-in tools'.
+var (
+	validToolNamespace = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+	validToolName      = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// AddTool registers a project-specific tool under namespace, exposed to
+// clients as namespace_name. Tools must be added before Run.
+func (s *Server) AddTool(namespace string, t Tool) error {
+	if !validToolNamespace.MatchString(namespace) {
+		return fmt.Errorf("invalid tool namespace %q: use lowercase letters and digits, starting with a letter", namespace)
+	}
+	if namespace == "lightshell" {
+		return fmt.Errorf("the lightshell namespace is reserved for built-in tools")
+	}
+	if !validToolName.MatchString(t.Name) {
+		return fmt.Errorf("invalid tool name %q: use lowercase letters, digits, and underscores, starting with a letter", t.Name)
+	}
+	if t.Handler == nil {
+		return fmt.Errorf("tool %s_%s has no handler", namespace, t.Name)
+	}
+	if t.InputSchema == nil {
+		t.InputSchema = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	t.Name = namespace + "_" + t.Name
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tools[t.Name]; ok {
+		return fmt.Errorf("tool %s is already registered", t.Name)
+	}
+	s.tools[t.Name] = t
+	return nil
+}
+
+// ProjectDir returns the active project's directory, which changes when a
+// client opens another project.
+func (s *Server) ProjectDir() string {
+	return s.getProjectDir()
+}
+
+// SafePath resolves relPath within the active project, refusing paths that
+// lead outside it, including through symlinks.
+func (s *Server) SafePath(relPath string) (string, error) {
+	return s.safePath(relPath)
+}
+
+// DevRunning reports whether the active project's dev process is running.
+func (s *Server) DevRunning() bool {
+	return s.devProcess.IsRunning()
+}
+
+// Eval runs code in a window of the active project's running app, the main
+// window for ID 0, and returns its result as JSON, as lightshell_execute_js
+// does. The result is cut at maxBytes, or the default when it is 0.
+func (s *Server) Eval(code string, windowID, maxBytes int) (json.RawMessage, bool, error) {
+	if err := s.requireDevRunning(); err != nil {
+		return nil, false, err
+	}
+	resp, err := s.devProcess.SendCommand(MCPCommand{
+		Cmd:      "eval",
+		Code:     code,
+		MaxBytes: maxBytes,
+		WindowID: windowID,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("JS execution failed: %w", err)
+	}
+	return resp.Result, resp.Truncated, nil
+}
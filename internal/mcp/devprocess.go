@@ -9,10 +9,14 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/framing"
+	"github.com/lightshell-dev/lightshell/internal/paths"
 )
 
 // MCPCommand is the JSON command sent from the MCP server to the dev process
@@ -21,12 +25,21 @@ type MCPCommand struct {
 	ID       int    `json:"id"`
 	Cmd      string `json:"cmd"`
 	Delay    int    `json:"delay,omitempty"`    // for screenshot (ms to wait before capture)
-	Lines    int    `json:"lines,omitempty"`    // for console (number of entries)
+	Lines    int    `json:"lines,omitempty"`    // for console and export (number of entries)
 	Level    string `json:"level,omitempty"`    // for console (filter level)
-	Clear    bool   `json:"clear,omitempty"`    // for console (clear after read)
+	Clear    bool   `json:"clear,omitempty"`    // for console and network (clear after read)
 	Selector string `json:"selector,omitempty"` // for dom (CSS selector)
-	Depth    int    `json:"depth,omitempty"`    // for dom (traversal depth)
+	Depth    int    `json:"depth,omitempty"`    // for dom (traversal depth) and eval (object depth)
 	Code     string `json:"code,omitempty"`     // for eval (JS code)
+	Format   string `json:"format,omitempty"`   // for metrics ("json" or "prometheus")
+	Prefix   string `json:"prefix,omitempty"`   // for store (key prefix)
+	Limit    int    `json:"limit,omitempty"`    // for store and network (max entries) and dom (max children)
+	Offset   int    `json:"offset,omitempty"`   // for dom (first child)
+	MaxBytes int    `json:"maxBytes,omitempty"` // for dom and eval (output size limit)
+	Filter   string `json:"filter,omitempty"`   // for network (URL substring)
+	WindowID int    `json:"windowId,omitempty"` // for screenshot, eval, dom and export (additional window; 0 for the main one)
+
+	Encodings []string `json:"encodings,omitempty"` // for hello (encodings this side reads)
 }
 
 // MCPResponse is the JSON response from the dev process back to the MCP server.
@@ -40,6 +53,23 @@ type MCPResponse struct {
 	Status  string           `json:"status,omitempty"`
 	HTML    string           `json:"html,omitempty"`
 	Entries []ConsoleEntry   `json:"entries,omitempty"`
+
+	Requests []NetworkEntry `json:"requests,omitempty"`
+	Windows  []WindowInfo   `json:"windows,omitempty"`
+
+	Encodings []string `json:"encodings,omitempty"` // hello: encodings large responses may use
+
+	Truncated bool `json:"truncated,omitempty"` // eval or dom output was cut at maxBytes
+	Total     int  `json:"total,omitempty"`     // dom: children of the selected element
+}
+
+// WindowInfo is an open window of the app, as the windows command lists it.
+type WindowInfo struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Main   bool   `json:"main"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
 }
 
 // DevProcessManager manages the lightshell dev child process and communicates
@@ -53,50 +83,150 @@ type DevProcessManager struct {
 	running    bool
 	projectDir string
 	nextID     atomic.Int64
-	stderr     *limitedBuffer
+	stderr     *tailBuffer
 	exitCh     chan error // signals when the child process exits
+	events     net.Conn   // the event stream subscribed to at start
+	watching   *exec.Cmd  // the started process, until Stop is called
+
+	// Record, when set before Start, has the dev process record the
+	// commands it is sent as a session that lightshell replay can run.
+	Record bool
+
+	// OnEvent, when set, receives console output as the page logs it, and
+	// a report if the process dies without Stop being called. It is called
+	// from other goroutines.
+	OnEvent func(DevEvent)
+}
+
+// DevEvent is something the dev process reports as it happens.
+type DevEvent struct {
+	Kind    string        // "console", or "exit" when the process died on its own
+	Console *ConsoleEntry // for console
+	Error   string        // for exit: how the process ended
+	Output  string        // for exit: the tail of its stderr
 }
 
-// limitedBuffer captures a limited amount of stderr output for error reporting.
-type limitedBuffer struct {
-	mu   sync.Mutex
-	data []byte
-	max  int
+// tailBuffer keeps the last max bytes of stderr for error reporting: a
+// failing process usually prints its error last, after any startup logs.
+type tailBuffer struct {
+	mu      sync.Mutex
+	data    []byte
+	max     int
+	dropped bool
 }
 
-func newLimitedBuffer(max int) *limitedBuffer {
-	return &limitedBuffer{data: make([]byte, 0, max), max: max}
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{data: make([]byte, 0, max), max: max}
 }
 
-func (b *limitedBuffer) Write(p []byte) (int, error) {
+func (b *tailBuffer) Write(p []byte) (int, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	remaining := b.max - len(b.data)
-	if remaining > 0 {
-		if len(p) > remaining {
-			p = p[:remaining]
-		}
-		b.data = append(b.data, p...)
+	n := len(p)
+	if len(p) > b.max {
+		p = p[len(p)-b.max:]
+		b.dropped = true
+	}
+	if over := len(b.data) + len(p) - b.max; over > 0 {
+		copy(b.data, b.data[over:])
+		b.data = b.data[:len(b.data)-over]
+		b.dropped = true
 	}
-	return len(p), nil
+	b.data = append(b.data, p...)
+	return n, nil
 }
 
-func (b *limitedBuffer) String() string {
+// String returns the kept output, starting at a line once earlier output
+// has been dropped.
+func (b *tailBuffer) String() string {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	return string(b.data)
+	out := string(b.data)
+	if b.dropped {
+		if i := strings.IndexByte(out, '\n'); i >= 0 && i < len(out)-1 {
+			out = out[i+1:]
+		}
+		out = "...\n" + out
+	}
+	return out
+}
+
+// DevFailure classifies why the dev process failed to start.
+type DevFailure string
+
+const (
+	DevFailureUnknown       DevFailure = "unknown"
+	DevFailurePortInUse     DevFailure = "port_in_use"
+	DevFailureMissingConfig DevFailure = "missing_config"
+	DevFailureCgo           DevFailure = "cgo"
+	DevFailureNodeModules   DevFailure = "node_modules"
+	DevFailureDevCommand    DevFailure = "dev_command"
+	DevFailureTimeout       DevFailure = "timeout"
+)
+
+// DevStartError is returned by Start when the dev process does not come up.
+type DevStartError struct {
+	Kind   DevFailure
+	Reason string // what Start observed, such as an early exit
+	Hint   string // how to fix a known failure
+	Output string // the tail of the process's stderr
+}
+
+func (e *DevStartError) Error() string {
+	msg := fmt.Sprintf("%s [%s]", e.Reason, e.Kind)
+	if e.Hint != "" {
+		msg += "\n" + e.Hint
+	}
+	if e.Output != "" {
+		msg += "\n\n" + e.Output
+	}
+	return msg
+}
+
+// devFailurePatterns recognize common failures in the dev process's output,
+// checked in order.
+var devFailurePatterns = []struct {
+	kind     DevFailure
+	patterns []string
+	hint     string
+}{
+	{DevFailureMissingConfig, []string{"could not read lightshell.json", "invalid lightshell.json"},
+		"Check that the project directory has a valid lightshell.json, or create a project with lightshell_create_project."},
+	{DevFailureNodeModules, []string{"node_modules not found"},
+		"Run npm install in the project directory, then start again."},
+	{DevFailurePortInUse, []string{"address already in use", "only one usage of each socket address", "is already in use"},
+		"Another process is using the dev server's port. Stop it, or change the port of dev.command or dev.url."},
+	{DevFailureCgo, []string{"cgo:", "cgo_enabled", "c compiler", "xcrun: error", `exec: "gcc"`, `exec: "clang"`},
+		"The native toolchain failed. On macOS run xcode-select --install; elsewhere install a C compiler and make sure CGO_ENABLED=1."},
+	{DevFailureDevCommand, []string{"failed to start dev command", "dev server did not start"},
+		"The framework dev server in dev.command did not start, or nothing answered at dev.url; its output is above."},
+}
+
+// classifyDevFailure builds the error for a start failure from the
+// process's output.
+func classifyDevFailure(reason, output string) *DevStartError {
+	lower := strings.ToLower(output)
+	for _, f := range devFailurePatterns {
+		for _, p := range f.patterns {
+			if strings.Contains(lower, p) {
+				return &DevStartError{Kind: f.kind, Reason: reason, Hint: f.hint, Output: output}
+			}
+		}
+	}
+	return &DevStartError{Kind: DevFailureUnknown, Reason: reason, Output: output}
 }
 
 // NewDevProcessManager creates a new dev process manager for the given project directory.
 func NewDevProcessManager(projectDir string) *DevProcessManager {
 	return &DevProcessManager{
 		projectDir: projectDir,
-		stderr:     newLimitedBuffer(4096),
+		stderr:     newTailBuffer(16 * 1024),
 	}
 }
 
 // Start launches the lightshell dev process with MCP socket support.
-// It waits for the socket to become available and connects to it.
+// It waits for the socket to become available and connects to it. When the
+// process fails to come up, the error is a *DevStartError.
 func (d *DevProcessManager) Start() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -106,15 +236,19 @@ func (d *DevProcessManager) Start() error {
 		d.stopLocked()
 	}
 
+	// Clean up sockets left by MCP sessions that crashed
+	paths.RemoveStaleSockets("mcp-*.sock")
+
 	// Generate socket path with random token to prevent prediction
 	var token [8]byte
 	if _, err := rand.Read(token[:]); err != nil {
 		return fmt.Errorf("failed to generate socket token: %w", err)
 	}
-	d.socketPath = fmt.Sprintf("/tmp/lightshell-mcp-%d-%s.sock", os.Getpid(), hex.EncodeToString(token[:]))
-
-	// Clean up any stale socket file
-	os.Remove(d.socketPath)
+	socketPath, err := paths.SocketPath(fmt.Sprintf("mcp-%d-%s.sock", os.Getpid(), hex.EncodeToString(token[:])))
+	if err != nil {
+		return err
+	}
+	d.socketPath = socketPath
 
 	// Find the lightshell binary (self)
 	selfPath, err := os.Executable()
@@ -123,10 +257,14 @@ func (d *DevProcessManager) Start() error {
 	}
 
 	// Spawn child: lightshell dev --mcp-socket <path>
-	d.cmd = exec.Command(selfPath, "dev", "--mcp-socket", d.socketPath)
+	args := []string{"dev", "--mcp-socket", d.socketPath}
+	if d.Record {
+		args = append(args, "--record")
+	}
+	d.cmd = exec.Command(selfPath, args...)
 	d.cmd.Dir = d.projectDir
 	d.cmd.Stdout = os.Stderr // Forward child stdout to our stderr for debugging
-	d.stderr = newLimitedBuffer(4096)
+	d.stderr = newTailBuffer(16 * 1024)
 	d.cmd.Stderr = d.stderr
 
 	if err := d.cmd.Start(); err != nil {
@@ -135,9 +273,12 @@ func (d *DevProcessManager) Start() error {
 
 	// Start a goroutine to wait for the process to exit. This allows us to
 	// detect early exits, since cmd.ProcessState is only populated after Wait().
-	d.exitCh = make(chan error, 1)
+	cmd, exitCh := d.cmd, make(chan error, 1)
+	d.exitCh = exitCh
 	go func() {
-		d.exitCh <- d.cmd.Wait()
+		err := cmd.Wait()
+		exitCh <- err
+		d.handleExit(cmd, err)
 	}()
 
 	// Wait for the socket file to appear (poll every 100ms, up to 5 seconds)
@@ -149,7 +290,7 @@ func (d *DevProcessManager) Start() error {
 		// Check if process exited early
 		select {
 		case <-d.exitCh:
-			return fmt.Errorf("dev process exited early: %s", d.stderr.String())
+			return classifyDevFailure("dev process exited early", d.stderr.String())
 		default:
 		}
 		time.Sleep(100 * time.Millisecond)
@@ -160,7 +301,11 @@ func (d *DevProcessManager) Start() error {
 		// Process may have failed to start — kill and wait via exitCh
 		d.cmd.Process.Kill()
 		<-d.exitCh
-		return fmt.Errorf("dev process did not create socket within 5s: %s", d.stderr.String())
+		err := classifyDevFailure("dev process did not create socket within 5s", d.stderr.String())
+		if err.Kind == DevFailureUnknown {
+			err.Kind = DevFailureTimeout
+		}
+		return err
 	}
 
 	// Connect to the Unix socket
@@ -175,10 +320,83 @@ func (d *DevProcessManager) Start() error {
 	d.conn = conn
 	d.reader = bufio.NewReader(conn)
 	d.running = true
+	d.watching = d.cmd
+
+	// Ask for large responses compressed. A dev process that predates hello
+	// answers with an error and keeps sending them as they are.
+	d.roundTrip(MCPCommand{Cmd: "hello", Encodings: framing.Supported})
+
+	// A second connection streams console output as it happens
+	if events, err := net.DialTimeout("unix", d.socketPath, 2*time.Second); err == nil {
+		if _, err := events.Write([]byte(`{"cmd":"subscribe"}` + "\n")); err != nil {
+			events.Close()
+		} else {
+			d.events = events
+			go d.readEvents(events)
+		}
+	}
 
 	return nil
 }
 
+// readEvents passes the events streamed over conn to OnEvent until the
+// connection closes.
+func (d *DevProcessManager) readEvents(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var ev struct {
+			Event string        `json:"event"`
+			Entry *ConsoleEntry `json:"entry"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			continue
+		}
+		if ev.Event == "console" && ev.Entry != nil && d.OnEvent != nil {
+			d.OnEvent(DevEvent{Kind: "console", Console: ev.Entry})
+		}
+	}
+}
+
+// handleExit runs when cmd has exited. If nothing stopped it, the process
+// crashed: it is cleaned up after, and reported to OnEvent.
+func (d *DevProcessManager) handleExit(cmd *exec.Cmd, err error) {
+	d.mu.Lock()
+	if d.watching != cmd {
+		d.mu.Unlock()
+		return
+	}
+	d.watching = nil
+	d.running = false
+	d.closeConns()
+	os.Remove(d.socketPath)
+	d.cmd = nil
+	output := d.stderr.String()
+	d.mu.Unlock()
+
+	reason := "exited"
+	if err != nil {
+		reason = err.Error()
+	}
+	if d.OnEvent != nil {
+		d.OnEvent(DevEvent{Kind: "exit", Error: reason, Output: output})
+	}
+}
+
+// closeConns closes the command and event connections. Must be called with
+// d.mu held.
+func (d *DevProcessManager) closeConns() {
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+		d.reader = nil
+	}
+	if d.events != nil {
+		d.events.Close()
+		d.events = nil
+	}
+}
+
 // Stop gracefully stops the dev process.
 func (d *DevProcessManager) Stop() error {
 	d.mu.Lock()
@@ -191,13 +409,10 @@ func (d *DevProcessManager) stopLocked() error {
 	if !d.running {
 		return nil
 	}
+	d.watching = nil
 
-	// Close the socket connection
-	if d.conn != nil {
-		d.conn.Close()
-		d.conn = nil
-		d.reader = nil
-	}
+	// Close the socket connections
+	d.closeConns()
 
 	if d.cmd != nil && d.cmd.Process != nil {
 		// Send SIGTERM
@@ -244,7 +459,11 @@ func (d *DevProcessManager) SendCommand(cmd MCPCommand) (*MCPResponse, error) {
 	if !d.running {
 		return nil, fmt.Errorf("dev process is not running")
 	}
+	return d.roundTrip(cmd)
+}
 
+// roundTrip sends cmd and reads its response. The caller holds d.mu.
+func (d *DevProcessManager) roundTrip(cmd MCPCommand) (*MCPResponse, error) {
 	// Assign a command ID
 	cmd.ID = int(d.nextID.Add(1))
 
@@ -266,8 +485,11 @@ func (d *DevProcessManager) SendCommand(cmd MCPCommand) (*MCPResponse, error) {
 
 	// Set read deadline (longer for screenshot/eval which may take time)
 	timeout := 10 * time.Second
-	if cmd.Cmd == "screenshot" {
+	switch cmd.Cmd {
+	case "screenshot":
 		timeout = 15 * time.Second
+	case "export":
+		timeout = 20 * time.Second // the page snapshot, then a screenshot
 	}
 	d.conn.SetReadDeadline(time.Now().Add(timeout))
 
@@ -277,6 +499,9 @@ func (d *DevProcessManager) SendCommand(cmd MCPCommand) (*MCPResponse, error) {
 		d.running = false
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
+	if line, err = framing.Decode(line); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
 
 	var resp MCPResponse
 	if err := json.Unmarshal(line, &resp); err != nil {
@@ -0,0 +1,170 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/lightshell-dev/lightshell/internal/fsutil"
+)
+
+const (
+	// maxReadBytes bounds the content lightshell_read_file returns at once,
+	// so one call cannot flood the agent's context. Larger files are read
+	// in ranges.
+	maxReadBytes = 256 << 10
+	// maxWriteBytes bounds the content lightshell_write_file accepts.
+	maxWriteBytes = 5 << 20
+	// binarySniffBytes is how much of a file isBinary looks at, as git does.
+	binarySniffBytes = 8000
+	// maxLineCountBytes is the largest file lightshell_stat counts lines in.
+	maxLineCountBytes = 64 << 20
+)
+
+// sniff returns the start of f, for isBinary and content type detection.
+func sniff(f *os.File) ([]byte, error) {
+	buf := make([]byte, binarySniffBytes)
+	n, err := f.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// isBinary reports whether a file starting with sample is binary: like git,
+// it looks for a NUL byte, which text in any common encoding but UTF-16
+// does not contain.
+func isBinary(sample []byte) bool {
+	return bytes.IndexByte(sample, 0) >= 0
+}
+
+// readRange reads up to length bytes of f starting at offset.
+func readRange(f *os.File, offset, length int64) ([]byte, error) {
+	return io.ReadAll(io.NewSectionReader(f, offset, length))
+}
+
+// headLines reads the first n lines of r, stopping early after limit
+// bytes.
+func headLines(r io.Reader, n, limit int) (data []byte, truncated bool, err error) {
+	br := bufio.NewReader(r)
+	for lines := 0; lines < n; lines++ {
+		line, err := br.ReadBytes('\n')
+		data = append(data, line...)
+		if len(data) > limit {
+			return data[:limit], true, nil
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return data, false, nil
+}
+
+// tailLines reads the last n lines of f, which is size bytes long, keeping
+// at most the last limit bytes. A final newline does not start a new line.
+func tailLines(f *os.File, size int64, n, limit int) (data []byte, truncated bool, err error) {
+	const chunk = 64 << 10
+	end := size
+	if end > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, end-1); err != nil {
+			return nil, false, err
+		}
+		if last[0] == '\n' {
+			end--
+		}
+	}
+
+	// Read backwards until n newlines are in hand, or more than limit bytes
+	start := end
+	for start > 0 && end-start <= int64(limit) {
+		next := max(start-chunk, 0)
+		buf := make([]byte, start-next)
+		if _, err := f.ReadAt(buf, next); err != nil && !errors.Is(err, io.EOF) {
+			return nil, false, err
+		}
+		data = append(buf, data...)
+		start = next
+		if bytes.Count(data, []byte{'\n'}) >= n {
+			break
+		}
+	}
+
+	// Keep what follows the nth newline from the end
+	for i, seen := len(data)-1, 0; i >= 0; i-- {
+		if data[i] == '\n' {
+			if seen++; seen == n {
+				data = data[i+1:]
+				break
+			}
+		}
+	}
+	if size > end {
+		data = append(data, '\n')
+	}
+	if len(data) > limit {
+		return data[len(data)-limit:], true, nil
+	}
+	return data, false, nil
+}
+
+// countLines counts the lines in r; a final line without a newline counts.
+func countLines(r io.Reader) (int, error) {
+	buf := make([]byte, 64<<10)
+	lines, last := 0, byte('\n')
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if last != '\n' {
+		lines++
+	}
+	return lines, nil
+}
+
+// backupSuffix names the copy writeFile keeps of a file it replaces.
+const backupSuffix = ".bak"
+
+// writeFile replaces path with data atomically, so an interrupted write
+// leaves the old content rather than a truncated file. An existing file
+// keeps its mode; a new one gets 0644. With backup set, the old content is
+// first saved next to it with backupSuffix, and its path returned.
+func writeFile(path string, data []byte, backup bool) (backupPath string, err error) {
+	perm := os.FileMode(0o644)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		perm = info.Mode().Perm()
+		if backup {
+			old, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			backupPath = path + backupSuffix
+			if err := fsutil.WriteFileAtomic(backupPath, old, perm); err != nil {
+				return "", fmt.Errorf("failed to write backup: %w", err)
+			}
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return "", err
+	}
+	if err := fsutil.WriteFileAtomic(path, data, perm); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// logLevels are the MCP log levels, least severe first.
+var logLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// defaultLogLevel is the least severe level sent before the client picks
+// one with logging/setLevel: warnings and errors, but not every
+// console.log.
+const defaultLogLevel = "warning"
+
+func logLevelRank(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// consoleLogLevels maps console methods to MCP log levels.
+var consoleLogLevels = map[string]string{
+	"debug": "debug",
+	"log":   "info",
+	"info":  "info",
+	"warn":  "warning",
+	"error": "error",
+}
+
+// handleSetLevel sets the least severe level of the notifications/message
+// notifications sent to the client.
+func (s *Server) handleSetLevel(params json.RawMessage) (any, *jsonRPCError) {
+	var p struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &jsonRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Invalid params: %v", err),
+		}
+	}
+	if logLevelRank(p.Level) < 0 {
+		return nil, &jsonRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Unknown log level: %s", p.Level),
+		}
+	}
+	s.logMu.Lock()
+	s.logLevel = p.Level
+	s.logMu.Unlock()
+	return map[string]any{}, nil
+}
+
+// notifyLog sends a notifications/message notification if level is at
+// least the client's chosen level.
+func (s *Server) notifyLog(level, logger string, data any) {
+	s.logMu.Lock()
+	threshold := s.logLevel
+	s.logMu.Unlock()
+	if logLevelRank(level) < logLevelRank(threshold) {
+		return
+	}
+	s.sendNotification("notifications/message", map[string]any{
+		"level":  level,
+		"logger": logger,
+		"data":   data,
+	})
+}
+
+// newDevProcess returns a dev process manager for the project in dir that
+// forwards the page's console output and crashes to the client as they
+// happen.
+func (s *Server) newDevProcess(dir string) *DevProcessManager {
+	dev := NewDevProcessManager(dir)
+	dev.OnEvent = func(e DevEvent) {
+		switch e.Kind {
+		case "console":
+			level, ok := consoleLogLevels[e.Console.Level]
+			if !ok {
+				level = "info"
+			}
+			s.notifyLog(level, "console", map[string]any{
+				"project":   dir,
+				"level":     e.Console.Level,
+				"message":   e.Console.Message,
+				"timestamp": e.Console.Timestamp,
+				"window":    e.Console.Window,
+			})
+		case "exit":
+			s.notifyLog("critical", "dev", map[string]any{
+				"project": dir,
+				"message": "dev process exited unexpectedly: " + e.Error + " — call lightshell_dev_start to restart it",
+				"output":  e.Output,
+			})
+		}
+	}
+	return dev
+}
@@ -0,0 +1,209 @@
+package mcp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// textEdit replaces OldText with NewText: its single occurrence, or every
+// one with ReplaceAll.
+type textEdit struct {
+	OldText    string
+	NewText    string
+	ReplaceAll bool
+}
+
+// applyTextEdits applies edits in order and returns the result and the
+// number of replacements. An edit whose text is missing, or ambiguous
+// without ReplaceAll, fails the whole set.
+func applyTextEdits(content string, edits []textEdit) (string, int, error) {
+	total := 0
+	for i, e := range edits {
+		if e.OldText == "" {
+			return "", 0, fmt.Errorf("edit %d: oldText is required", i+1)
+		}
+		n := strings.Count(content, e.OldText)
+		switch {
+		case n == 0:
+			return "", 0, fmt.Errorf("edit %d: oldText not found", i+1)
+		case n > 1 && !e.ReplaceAll:
+			return "", 0, fmt.Errorf("edit %d: oldText matches %d times; include more context or set replaceAll", i+1, n)
+		}
+		content = strings.ReplaceAll(content, e.OldText, e.NewText)
+		total += n
+	}
+	return content, total, nil
+}
+
+// diffHunk is one "@@ -a,b +c,d @@" section of a unified diff.
+type diffHunk struct {
+	oldStart int
+	old      []string // context and removed lines
+	new      []string // context and added lines
+	// noNewline records a "\ No newline at end of file" marker: 1 when the
+	// new file ends without a newline, -1 when only the old one did
+	noNewline int
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,(\d+))? @@`)
+
+// parseUnifiedDiff reads the hunks of a single-file unified diff. File
+// headers (---/+++, diff, index) are skipped, and hunk line counts are not
+// trusted: a hunk runs until the next header. The counts only decide that
+// a removed "-- " line followed by an added "++ " one, which look like a
+// file header, are part of a hunk that has lines left.
+func parseUnifiedDiff(diff string) ([]diffHunk, error) {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	var hunks []diffHunk
+	var cur *diffHunk
+	var last byte
+	oldLeft, newLeft := 0, 0
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			start, _ := strconv.Atoi(m[1])
+			oldLeft, newLeft = hunkCount(m[2]), hunkCount(m[3])
+			hunks = append(hunks, diffHunk{oldStart: start})
+			cur = &hunks[len(hunks)-1]
+			continue
+		}
+		inHunk := cur != nil && (oldLeft > 0 || newLeft > 0)
+		if strings.HasPrefix(line, "diff ") || strings.HasPrefix(line, "index ") ||
+			(!inHunk && strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")) {
+			cur = nil
+			if strings.HasPrefix(line, "--- ") {
+				i++
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if line == "" {
+			// An empty context line whose leading space was stripped, or the
+			// end of the diff
+			if i == len(lines)-1 {
+				break
+			}
+			line = " "
+		}
+		switch line[0] {
+		case ' ':
+			cur.old = append(cur.old, line[1:])
+			cur.new = append(cur.new, line[1:])
+			oldLeft--
+			newLeft--
+		case '-':
+			cur.old = append(cur.old, line[1:])
+			oldLeft--
+		case '+':
+			cur.new = append(cur.new, line[1:])
+			newLeft--
+		case '\\':
+			if last == '-' {
+				cur.noNewline = -1
+			} else {
+				cur.noNewline = 1
+			}
+		default:
+			return nil, fmt.Errorf("line %d of the patch is not part of a hunk: %q", i+1, line)
+		}
+		last = line[0]
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("the patch has no @@ hunks")
+	}
+	return hunks, nil
+}
+
+// hunkCount reads a line count from a hunk header, which is 1 when left
+// out.
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// applyUnifiedDiff applies a unified diff to content and returns the result
+// and the number of hunks. Each hunk goes where its header says or, when
+// earlier changes have shifted the file, at the nearest place its context
+// and removed lines match exactly.
+func applyUnifiedDiff(content, diff string) (string, int, error) {
+	hunks, err := parseUnifiedDiff(diff)
+	if err != nil {
+		return "", 0, err
+	}
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	trailing := content == "" || strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	offset, from := 0, 0
+	for n, h := range hunks {
+		want := h.oldStart - 1 + offset
+		if len(h.old) == 0 {
+			// Pure insertion: "-a,0" inserts after line a
+			want = h.oldStart + offset
+		}
+		pos := findLines(lines, h.old, max(want, from), from)
+		if pos < 0 {
+			return "", 0, fmt.Errorf("hunk %d (@@ -%d) does not apply: its context and removed lines were not found", n+1, h.oldStart)
+		}
+		lines = append(lines[:pos], append(append([]string{}, h.new...), lines[pos+len(h.old):]...)...)
+		offset += len(h.new) - len(h.old)
+		from = pos + len(h.new)
+		switch h.noNewline {
+		case 1:
+			trailing = false
+		case -1:
+			trailing = true
+		}
+	}
+
+	out := strings.Join(lines, "\n")
+	if trailing && len(lines) > 0 {
+		out += "\n"
+	}
+	if newline != "\n" {
+		out = strings.ReplaceAll(out, "\n", newline)
+	}
+	return out, len(hunks), nil
+}
+
+// findLines returns where block occurs in lines at or after from, closest
+// to want, or -1.
+func findLines(lines, block []string, want, from int) int {
+	matches := func(pos int) bool {
+		if pos < from || pos+len(block) > len(lines) {
+			return false
+		}
+		for i, l := range block {
+			if lines[pos+i] != l {
+				return false
+			}
+		}
+		return true
+	}
+	if want > len(lines) {
+		want = len(lines)
+	}
+	for d := 0; want-d >= from || want+d <= len(lines); d++ {
+		if matches(want + d) {
+			return want + d
+		}
+		if d > 0 && matches(want-d) {
+			return want - d
+		}
+	}
+	return -1
+}
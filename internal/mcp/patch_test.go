@@ -0,0 +1,174 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		diff    string
+		want    string
+		hunks   int
+	}{
+		{
+			name:    "file headers",
+			content: "a\nb\nc\n",
+			diff:    "diff --git a/f b/f\nindex 1..2 100644\n--- a/f\n+++ b/f\n@@ -2 +2 @@\n-b\n+B\n",
+			want:    "a\nB\nc\n",
+			hunks:   1,
+		},
+		{
+			name:    "offset hunk",
+			content: "x\ny\na\nb\nc\nd\ne\n",
+			diff:    "@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n",
+			want:    "x\ny\na\nb\nC\nd\ne\n",
+			hunks:   1,
+		},
+		{
+			name:    "hunks shifted by earlier hunks",
+			content: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			diff:    "@@ -1,2 +1,3 @@\n 1\n+1.5\n 2\n@@ -8,2 +9,2 @@\n 8\n-9\n+nine\n",
+			want:    "1\n1.5\n2\n3\n4\n5\n6\n7\n8\nnine\n10\n",
+			hunks:   2,
+		},
+		{
+			name:    "empty context line without its space",
+			content: "a\n\nb\n",
+			diff:    "@@ -1,3 +1,3 @@\n a\n\n-b\n+B\n",
+			want:    "a\n\nB\n",
+			hunks:   1,
+		},
+		{
+			name:    "insertion after a line",
+			content: "a\nb\nc\n",
+			diff:    "@@ -2,0 +3 @@\n+new\n",
+			want:    "a\nb\nnew\nc\n",
+			hunks:   1,
+		},
+		{
+			name:    "insertion at the start",
+			content: "a\nb\n",
+			diff:    "@@ -0,0 +1 @@\n+first\n",
+			want:    "first\na\nb\n",
+			hunks:   1,
+		},
+		{
+			name:    "insertion into an empty file",
+			content: "",
+			diff:    "--- /dev/null\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+			want:    "a\nb\n",
+			hunks:   1,
+		},
+		{
+			name:    "CRLF file",
+			content: "a\r\nb\r\nc\r\n",
+			diff:    "@@ -2 +2,2 @@\n-b\n+B\n+B2\n",
+			want:    "a\r\nB\r\nB2\r\nc\r\n",
+			hunks:   1,
+		},
+		{
+			name:    "CRLF patch",
+			content: "a\nb\n",
+			diff:    "@@ -1,2 +1,2 @@\r\n a\r\n-b\r\n+B\r\n",
+			want:    "a\nB\n",
+			hunks:   1,
+		},
+		{
+			name:    "no newline at the end of either file",
+			content: "a\nb",
+			diff:    "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+B\n\\ No newline at end of file\n",
+			want:    "a\nB",
+			hunks:   1,
+		},
+		{
+			name:    "newline added at the end",
+			content: "a\nb",
+			diff:    "@@ -2 +2 @@\n-b\n\\ No newline at end of file\n+b\n",
+			want:    "a\nb\n",
+			hunks:   1,
+		},
+		{
+			name:    "newline removed at the end",
+			content: "a\nb\n",
+			diff:    "@@ -2 +2 @@\n-b\n+b\n\\ No newline at end of file\n",
+			want:    "a\nb",
+			hunks:   1,
+		},
+		{
+			name:    "ambiguous context goes to the header's line",
+			content: "x\nsame\nx\nsame\nx\n",
+			diff:    "@@ -4 +4 @@\n-same\n+SAME\n",
+			want:    "x\nsame\nx\nSAME\nx\n",
+			hunks:   1,
+		},
+		{
+			name:    "ambiguous context near the header's line",
+			content: "x\nsame\nx\nx\nx\nx\nsame\nx\n",
+			diff:    "@@ -3 +3 @@\n-same\n+SAME\n",
+			want:    "x\nSAME\nx\nx\nx\nx\nsame\nx\n",
+			hunks:   1,
+		},
+		{
+			name:    "removed and added lines that look like a file header",
+			content: "a\n-- old\nb\n",
+			diff:    "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n--- old\n+++ new\n b\n",
+			want:    "a\n++ new\nb\n",
+			hunks:   1,
+		},
+	}
+	for _, tt := range tests {
+		got, hunks, err := applyUnifiedDiff(tt.content, tt.diff)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want || hunks != tt.hunks {
+			t.Errorf("%s: got %q (%d hunks), want %q (%d hunks)", tt.name, got, hunks, tt.want, tt.hunks)
+		}
+	}
+}
+
+func TestApplyUnifiedDiffErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		diff    string
+		want    string
+	}{
+		{"no hunks", "a\n", "--- a/f\n+++ b/f\n", "no @@ hunks"},
+		{"stray line", "a\n", "@@ -1 +1 @@\n-a\n+b\nnot a diff line\n", "line 4 of the patch"},
+		{"missing context", "a\nb\n", "@@ -1 +1 @@\n-c\n+C\n", "hunk 1 (@@ -1)"},
+		// Hunks apply in order, so a later hunk can't match above an
+		// earlier one
+		{"failing later hunk", "a\nb\nc\n", "@@ -3 +3 @@\n-c\n+C\n@@ -1 +1 @@\n-a\n+A\n", "hunk 2 (@@ -1)"},
+	}
+	for _, tt := range tests {
+		_, _, err := applyUnifiedDiff(tt.content, tt.diff)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestApplyTextEdits(t *testing.T) {
+	got, n, err := applyTextEdits("a b a", []textEdit{{OldText: "b", NewText: "c"}, {OldText: "a", NewText: "x", ReplaceAll: true}})
+	if err != nil || got != "x c x" || n != 3 {
+		t.Errorf("applyTextEdits = %q, %d, %v", got, n, err)
+	}
+
+	for _, tt := range []struct {
+		edit textEdit
+		want string
+	}{
+		{textEdit{NewText: "x"}, "oldText is required"},
+		{textEdit{OldText: "z"}, "not found"},
+		{textEdit{OldText: "a"}, "matches 2 times"},
+	} {
+		if _, _, err := applyTextEdits("a b a", []textEdit{tt.edit}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("edit %+v: error = %v, want %q", tt.edit, err, tt.want)
+		}
+	}
+}
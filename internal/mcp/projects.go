@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// switchProject makes dir the active project, which the file, config, and
+// dev tools act on. Each project keeps its own dev process, so one left
+// running goes on running while another is active. It returns the project
+// that was active before.
+func (s *Server) switchProject(dir string) (previous string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous = s.projectDir
+	s.projectDir = dir
+	dev, ok := s.devProcesses[dir]
+	if !ok {
+		dev = s.newDevProcess(dir)
+		s.devProcesses[dir] = dev
+	}
+	s.devProcess = dev
+	return previous
+}
+
+// projectInfo describes a project opened in this session.
+type projectInfo struct {
+	Path       string `json:"path"`
+	Name       string `json:"name"`
+	Active     bool   `json:"active"`
+	DevRunning bool   `json:"devRunning"`
+	HasConfig  bool   `json:"hasConfig"`
+}
+
+// listProjects returns the projects opened in this session, sorted by path.
+func (s *Server) listProjects() []projectInfo {
+	s.mu.Lock()
+	active := s.projectDir
+	devs := make(map[string]*DevProcessManager, len(s.devProcesses))
+	for dir, dev := range s.devProcesses {
+		devs[dir] = dev
+	}
+	s.mu.Unlock()
+
+	projects := make([]projectInfo, 0, len(devs))
+	for dir, dev := range devs {
+		p := projectInfo{
+			Path:       dir,
+			Name:       filepath.Base(dir),
+			Active:     dir == active,
+			DevRunning: dev.IsRunning(),
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "lightshell.json")); err == nil {
+			p.HasConfig = true
+			var cfg struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(data, &cfg) == nil && cfg.Name != "" {
+				p.Name = cfg.Name
+			}
+		}
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Path < projects[j].Path })
+	return projects
+}
+
+// stopAllDev stops the dev process of every project in the session.
+func (s *Server) stopAllDev() (stopped []string) {
+	s.mu.Lock()
+	devs := make(map[string]*DevProcessManager, len(s.devProcesses))
+	for dir, dev := range s.devProcesses {
+		devs[dir] = dev
+	}
+	s.mu.Unlock()
+
+	stopped = []string{}
+	for dir, dev := range devs {
+		if dev.IsRunning() {
+			dev.Stop()
+			stopped = append(stopped, dir)
+		}
+	}
+	sort.Strings(stopped)
+	return stopped
+}
+
+// versionWarning checks the LightShell version the project in dir records
+// against this server's, returning what to do about a mismatch or "".
+func versionWarning(dir string) string {
+	cfg, err := runtime.LoadConfig(dir)
+	if err != nil {
+		return ""
+	}
+	return runtime.CheckVersion(cfg.LightShellVersion)
+}
@@ -1,5 +1,21 @@
 package mcp
 
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lightshell-dev/lightshell/internal/compat"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
+)
+
+// maxTreeEntries bounds the project-tree resource for projects with huge
+// asset folders.
+const maxTreeEntries = 5000
+
 // registerResources registers all MCP resources exposed by the server.
 func (s *Server) registerResources() {
 	s.registerResource(Resource{
@@ -24,6 +40,143 @@ func (s *Server) registerResources() {
 			return errorCatalog, nil
 		},
 	})
+
+	s.registerResource(Resource{
+		URI:         "lightshell://compat-rules",
+		Name:        "LightShell Compatibility Rules",
+		Description: "Every cross-platform rule lightshell doctor checks, with its severity, what it matches, the fix, and a before/after example.",
+		MimeType:    "text/plain",
+		Handler: func() (string, error) {
+			return compatRuleCatalog(), nil
+		},
+	})
+
+	s.registerResource(Resource{
+		URI:         "lightshell://project-tree",
+		Name:        "LightShell Project Tree",
+		Description: "The project's files and directories as a JSON tree with file sizes, skipping hidden files, node_modules, dist, and paths listed in .lightshellignore. Subscribe to be notified when files are added, removed, or changed.",
+		MimeType:    "application/json",
+		Handler:     s.projectTree,
+		Watch:       s.projectTreeFingerprint,
+	})
+
+	s.registerResource(Resource{
+		URI:         "lightshell://config",
+		Name:        "LightShell Project Config",
+		Description: "The project's lightshell.json as LightShell reads it, with defaults filled in and the configs it extends merged in. Subscribe to be notified when it or a config it extends changes.",
+		MimeType:    "application/json",
+		Handler: func() (string, error) {
+			cfg, err := runtime.LoadConfig(s.getProjectDir())
+			if err != nil {
+				return "", err
+			}
+			data, err := json.MarshalIndent(cfg, "", "  ")
+			return string(data), err
+		},
+		Watch: func() string {
+			files := []string{filepath.Join(s.getProjectDir(), "lightshell.json")}
+			if resolved, err := runtime.ResolveConfig(s.getProjectDir()); err == nil {
+				files = resolved.Files
+			}
+			h := fnv.New64a()
+			for _, f := range files {
+				data, _ := os.ReadFile(f)
+				h.Write(data)
+			}
+			return fmt.Sprintf("%x", h.Sum64())
+		},
+	})
+}
+
+// treeNode is a file or directory in the project-tree resource.
+type treeNode struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"` // "file" or "dir"
+	Size     int64       `json:"size,omitempty"`
+	Children []*treeNode `json:"children,omitempty"`
+}
+
+// projectTree renders the project as nested treeNodes, up to
+// maxTreeEntries entries.
+func (s *Server) projectTree() (string, error) {
+	projDir := s.getProjectDir()
+	root := &treeNode{Name: filepath.Base(projDir), Type: "dir"}
+	dirs := map[string]*treeNode{".": root}
+	entries, truncated := 0, false
+	err := s.walkProject(projDir, func(rel string, info os.FileInfo) {
+		parent := dirs[filepath.Dir(rel)]
+		if parent == nil {
+			return // under a directory cut off by the limit
+		}
+		if entries == maxTreeEntries {
+			truncated = true
+			return
+		}
+		entries++
+		node := &treeNode{Name: info.Name(), Type: "file", Size: info.Size()}
+		if info.IsDir() {
+			node.Type, node.Size = "dir", 0
+			dirs[rel] = node
+		}
+		parent.Children = append(parent.Children, node)
+	})
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(map[string]any{
+		"root":      projDir,
+		"entries":   entries,
+		"truncated": truncated,
+		"tree":      root,
+	}, "", "  ")
+	return string(data), err
+}
+
+// projectTreeFingerprint hashes the path, size, and modification time of
+// every entry projectTree could show.
+func (s *Server) projectTreeFingerprint() string {
+	h := fnv.New64a()
+	s.walkProject(s.getProjectDir(), func(rel string, info os.FileInfo) {
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", rel, info.Size(), info.ModTime().UnixNano())
+	})
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// compatRuleCatalog renders compat.Rules for agents fixing doctor findings,
+// which report only the rule ID and a one-line fix.
+func compatRuleCatalog() string {
+	var b strings.Builder
+	b.WriteString("# LightShell Compatibility Rules\n\n")
+	b.WriteString("lightshell doctor scans scripts line by line against these rules, and matches stylesheet rules against parsed CSS. Rules marked auto-fixed are handled at runtime and need no code change.\n")
+	for _, r := range compat.Rules {
+		fmt.Fprintf(&b, "\n## %s: %s\n", r.ID, r.Title)
+		fmt.Fprintf(&b, "Severity: %s\n", r.Severity)
+		fmt.Fprintf(&b, "Platforms: %s\n", strings.Join(r.Platforms, ", "))
+		if r.CSS != nil {
+			fmt.Fprintf(&b, "Matches: %s in %s files\n", r.CSS, strings.Join(r.FileTypes, ", "))
+		} else {
+			fmt.Fprintf(&b, "Matches: %s in %s files\n", strings.Join(r.Patterns, " | "), strings.Join(r.FileTypes, ", "))
+		}
+		if r.AutoFix {
+			fmt.Fprintf(&b, "Fix (auto-fixed): %s\n", r.Fix)
+		} else {
+			fmt.Fprintf(&b, "Fix: %s\n", r.Fix)
+		}
+		if req := r.Requirements(); req != "" {
+			fmt.Fprintf(&b, "Requires: %s\n", req)
+		}
+		if r.DocsURL != "" {
+			fmt.Fprintf(&b, "Docs: %s\n", r.DocsURL)
+		}
+		fmt.Fprintf(&b, "Before:\n%s\n", indent(r.Before))
+		fmt.Fprintf(&b, "After:\n%s\n", indent(r.After))
+	}
+	return b.String()
+}
+
+// indent prefixes each line of s with two spaces, as in errorCatalog.
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
 }
 
 const defaultAPIDocs = `# LightShell API Reference
@@ -43,18 +196,29 @@ Control the application window.
 - maximize() — maximize/restore window
 - fullscreen() — enter fullscreen
 - restore() — restore from minimize/maximize/fullscreen
-- close() — close the window
+- close(id?: number) — close this window, or the window with the given ID
+- id — this window's ID (1 is the main window)
+- create(options: {url, title?, width?, height?, minWidth?, minHeight?, resizable?, frameless?, alwaysOnTop?}) — open another window on a page of the app; returns its ID
+- list() — returns [{id, title, main}]
+- focus(id?: number) — bring a window to the front
+- onClosed(callback) — called with {id} when a created window closes
 
 ### lightshell.fs
 File system operations. Paths support $APP_DATA, $HOME, $TEMP, $DOWNLOADS, $DESKTOP variables.
 - readFile(path: string, options?: {encoding?: string}) — read file contents
 - writeFile(path: string, data: string, options?: {encoding?: string}) — write file
+- appendFile(path: string, data: string, encoding?: "utf-8"|"base64") — append to a file
+- copy(src: string, dest: string) — stream-copy a file
+- openRead(path: string) — open a file for chunked reading; returns {handle, size}
+- readChunk(handle: string, options?: {size?: number, encoding?: "utf-8"|"base64"}) — returns {data, bytesRead, eof}
+- close(handle: string) — close a handle from openRead
+- readStream(path: string, options?) — async iterator over chunks; use with for await for large files
 - readDir(path: string) — list directory entries
 - exists(path: string) — check if path exists
 - stat(path: string) — get file metadata
 - mkdir(path: string, options?: {recursive?: boolean}) — create directory
 - remove(path: string, options?: {recursive?: boolean}) — delete file/directory
-- watch(path: string, callback: function) — watch for file changes
+- watch(path: string, callback: function, options?: {recursive, debounce}) — watch for changes; callback receives {path, kind: "create"|"modify"|"delete"}; returns an unwatch function
 
 ### lightshell.dialog
 Native system dialogs.
@@ -79,9 +243,12 @@ Desktop notifications.
 
 ### lightshell.tray
 System tray icon.
-- set(options: {icon: string, tooltip?: string, menu?: MenuItem[]}) — set tray
+- set(options: {icon?: string, iconData?: string, tooltip?: string, menu?: MenuItem[], menuOn?: "click" | "rightClick"}) — set tray; icon defaults to themeIcons.tray, and an icon@dark.png sibling is shown in dark mode; iconData is base64 image bytes
+- setIcon(icon: string | {iconData: string}), setTooltip(tooltip: string), setMenu(menu: MenuItem[], options?: {menuOn}) — update the tray shown
 - remove() — remove tray icon
-- onClick(callback: function) — handle tray click
+- onClick(callback: function) — called with {id, checked} when a tray menu item is clicked; a {role: "quit"} item quits without code
+- onIconClick(callback: function) — called with {button: "left" | "right"} when the icon itself is clicked; with menuOn: "rightClick" left-clicks do not open the menu
+- Tray-only apps: "window": false in lightshell.json runs the entry page hidden (macOS: no Dock icon); open windows with lightshell.window.create
 
 ### lightshell.menu
 Application menu bar.
@@ -98,6 +265,10 @@ System information.
 ### lightshell.app
 Application lifecycle.
 - quit() — quit the application
+- hide() / show() — hide all windows / unhide the app and show the main window
+- onReopen(callback: function) — macOS Dock icon clicked; receives {hasVisibleWindows}
+- onOpenFiles(callback: function) — macOS, needs scripting.enabled; files opened from Finder or AppleScript; receives {paths}
+- onScriptAction(callback: function) — macOS, needs scripting.enabled; AppleScript "run action"; receives {action, argument}
 - version() — returns app version from lightshell.json
 - dataDir() — returns app data directory path
 - onOpenUrl(callback: function) — handle deep link URLs
@@ -158,6 +329,8 @@ Apps are configured via lightshell.json in the project root:
 ## CLI Commands
 - lightshell init [name] — create a new project
 - lightshell dev — run in dev mode with hot reload
+- lightshell dev --daemon — run dev mode in the background; lightshell dev status | logs [-f] | stop manage it
+- lightshell run [path] — run as the built app would (declared permissions, production CSP, no hot reload) without building
 - lightshell build — build for production
 - lightshell doctor — check for cross-platform issues
 - lightshell mcp — run MCP server for AI integration
@@ -9,6 +9,9 @@ import (
 	"os"
 	"sort"
 	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/framing"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
 )
 
 // Tool defines an MCP tool with its schema and handler function.
@@ -26,6 +29,9 @@ type Resource struct {
 	Description string `json:"description"`
 	MimeType    string `json:"mimeType"`
 	Handler     func() (string, error) `json:"-"`
+	// Watch, when set, returns a fingerprint of what the resource reflects.
+	// Clients may subscribe to the resource and are notified when it changes.
+	Watch func() string `json:"-"`
 }
 
 // DevProcess is an alias kept for backward compatibility within this package.
@@ -56,12 +62,22 @@ type jsonRPCError struct {
 type Server struct {
 	projectDir string
 	apiDocs    string
-	devProcess *DevProcessManager
+	devProcess *DevProcessManager // the active project's, from devProcesses
 	tools      map[string]Tool
 	resources  map[string]Resource
 	logger     *log.Logger
 	writer     io.Writer
 	mu         sync.Mutex
+	encodings  []string // what large messages may be compressed with, from initialize
+
+	watchMu   sync.Mutex
+	watched   map[string]string // subscribed resource URI -> last fingerprint
+	watchOnce sync.Once
+
+	devProcesses map[string]*DevProcessManager // project dir -> its dev process
+
+	logMu    sync.Mutex
+	logLevel string // least severe level sent as notifications/message
 }
 
 // NewServer creates a new MCP server for the given project directory.
@@ -70,12 +86,15 @@ func NewServer(projectDir string, apiDocs string) *Server {
 	s := &Server{
 		projectDir: projectDir,
 		apiDocs:    apiDocs,
-		devProcess: NewDevProcessManager(projectDir),
 		tools:      make(map[string]Tool),
 		resources:  make(map[string]Resource),
+		watched:    make(map[string]string),
 		logger:     log.New(os.Stderr, "[lightshell-mcp] ", log.LstdFlags),
 		writer:     os.Stdout,
+		logLevel:   defaultLogLevel,
 	}
+	s.devProcess = s.newDevProcess(projectDir)
+	s.devProcesses = map[string]*DevProcessManager{projectDir: s.devProcess}
 	s.registerTools()
 	s.registerResources()
 	return s
@@ -85,6 +104,7 @@ func NewServer(projectDir string, apiDocs string) *Server {
 // and writing responses to stdout.
 func (s *Server) Run() error {
 	s.logger.Println("MCP server starting")
+	defer s.stopAllDev()
 
 	scanner := bufio.NewScanner(os.Stdin)
 	// Allow up to 10MB per line for large messages
@@ -140,6 +160,12 @@ func (s *Server) handleRequest(req jsonRPCRequest) {
 		result = s.handleResourcesList()
 	case "resources/read":
 		result, rpcErr = s.handleResourcesRead(req.Params)
+	case "resources/subscribe":
+		result, rpcErr = s.handleResourcesSubscribe(req.Params, true)
+	case "resources/unsubscribe":
+		result, rpcErr = s.handleResourcesSubscribe(req.Params, false)
+	case "logging/setLevel":
+		result, rpcErr = s.handleSetLevel(req.Params)
 	default:
 		rpcErr = &jsonRPCError{
 			Code:    -32601,
@@ -163,18 +189,47 @@ func (s *Server) handleNotification(req jsonRPCRequest) {
 	}
 }
 
+// compressionCapability is the experimental capability under which client
+// and server agree to compress large messages; see package framing.
+const compressionCapability = "lightshell/compression"
+
 func (s *Server) handleInitialize(params json.RawMessage) any {
-	return map[string]any{
+	// A client listing the encodings it reads gets messages over
+	// framing.Threshold compressed with one of them
+	var p struct {
+		Capabilities struct {
+			Experimental map[string]struct {
+				Encodings []string `json:"encodings"`
+			} `json:"experimental"`
+		} `json:"capabilities"`
+	}
+	json.Unmarshal(params, &p)
+	encodings := framing.Negotiate(p.Capabilities.Experimental[compressionCapability].Encodings)
+	s.mu.Lock()
+	s.encodings = encodings
+	s.mu.Unlock()
+
+	result := map[string]any{
 		"protocolVersion": "2024-11-05",
 		"capabilities": map[string]any{
 			"tools":     map[string]any{},
-			"resources": map[string]any{},
+			"resources": map[string]any{"subscribe": true},
+			"logging":   map[string]any{},
+			"experimental": map[string]any{
+				compressionCapability: map[string]any{"encodings": framing.Supported, "threshold": framing.Threshold},
+			},
 		},
 		"serverInfo": map[string]any{
 			"name":    "lightshell",
-			"version": "0.1.0",
+			"version": runtime.Version,
 		},
 	}
+	// Tell the client up front when the project expects another release
+	if warning := versionWarning(s.getProjectDir()); warning != "" {
+		s.logger.Printf("Warning: %s", warning)
+		result["instructions"] = "LightShell version mismatch: " + warning
+	}
+	return result
 }
 
 func (s *Server) handleToolsList() any {
@@ -355,19 +410,33 @@ func (s *Server) sendError(id any, code int, message string) {
 }
 
 func (s *Server) send(resp jsonRPCResponse) {
+	s.write(resp)
+}
+
+// sendNotification writes a JSON-RPC notification, which has no ID and gets
+// no response, to stdout.
+func (s *Server) sendNotification(method string, params any) {
+	s.write(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (s *Server) write(msg any) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	data, err := json.Marshal(resp)
+	data, err := json.Marshal(msg)
 	if err != nil {
 		s.logger.Printf("Failed to marshal response: %v", err)
 		return
 	}
 
-	data = append(data, '\n')
+	data = append(framing.Encode(data, s.encodings), '\n')
 	if _, err := s.writer.Write(data); err != nil {
 		s.logger.Printf("Failed to write response: %v", err)
 	}
 }
 
-// registerTools is defined in tools.go — it registers all 16 MCP tools.
+// registerTools is defined in tools.go — it registers all 29 MCP tools.
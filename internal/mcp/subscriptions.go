@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// watchInterval is how often subscribed resources are checked for changes.
+const watchInterval = time.Second
+
+// handleResourcesSubscribe starts or stops change notifications for a
+// resource. Only resources with a Watch function can be subscribed to.
+func (s *Server) handleResourcesSubscribe(params json.RawMessage, subscribe bool) (any, *jsonRPCError) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &jsonRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Invalid params: %v", err),
+		}
+	}
+
+	resource, ok := s.resources[p.URI]
+	if !ok {
+		return nil, &jsonRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Unknown resource: %s", p.URI),
+		}
+	}
+	if resource.Watch == nil {
+		return nil, &jsonRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Resource does not change: %s", p.URI),
+		}
+	}
+
+	if !subscribe {
+		s.watchMu.Lock()
+		delete(s.watched, p.URI)
+		s.watchMu.Unlock()
+		return map[string]any{}, nil
+	}
+
+	fingerprint := resource.Watch()
+	s.watchMu.Lock()
+	if _, ok := s.watched[p.URI]; !ok {
+		s.watched[p.URI] = fingerprint
+	}
+	s.watchMu.Unlock()
+	s.watchOnce.Do(func() { go s.watchResources() })
+	return map[string]any{}, nil
+}
+
+// watchResources polls the subscribed resources and sends
+// notifications/resources/updated for each one whose fingerprint changed.
+// Clients then read the resource again.
+func (s *Server) watchResources() {
+	for range time.Tick(watchInterval) {
+		s.watchMu.Lock()
+		uris := make([]string, 0, len(s.watched))
+		for uri := range s.watched {
+			uris = append(uris, uri)
+		}
+		s.watchMu.Unlock()
+
+		for _, uri := range uris {
+			fingerprint := s.resources[uri].Watch()
+			s.watchMu.Lock()
+			last, subscribed := s.watched[uri]
+			changed := subscribed && last != fingerprint
+			if changed {
+				s.watched[uri] = fingerprint
+			}
+			s.watchMu.Unlock()
+			if changed {
+				s.sendNotification("notifications/resources/updated", map[string]any{"uri": uri})
+			}
+		}
+	}
+}
@@ -1,13 +1,27 @@
 package mcp
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/compat"
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+	"github.com/lightshell-dev/lightshell/internal/ignore"
+	"github.com/lightshell-dev/lightshell/internal/jsonedit"
+	"github.com/lightshell-dev/lightshell/internal/runtime"
+	"github.com/lightshell-dev/lightshell/internal/security"
 )
 
 // --- Parameter extraction helpers ---
@@ -102,6 +116,76 @@ func (s *Server) safePath(relPath string) (string, error) {
 	return abs, nil
 }
 
+// entryPath resolves a project path like safePath, except that a symlink
+// named by relPath is itself the result rather than its target, so deleting
+// or moving it leaves the target alone. The project root is never returned.
+func (s *Server) entryPath(relPath string) (string, error) {
+	clean := filepath.Clean(relPath)
+	if clean == "." || clean == ".." || filepath.IsAbs(clean) {
+		return "", fmt.Errorf("invalid path: %s", relPath)
+	}
+	parent, err := s.safePath(filepath.Dir(clean))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(clean)), nil
+}
+
+// walkProject calls fn for each project file and directory under root, in
+// lexical order, with its path relative to the project root. Hidden files,
+// dependency and build output directories, symlinks (which could lead
+// outside the project), and whatever .lightshellignore lists are skipped,
+// as are entries that cannot be read.
+func (s *Server) walkProject(root string, fn func(rel string, info os.FileInfo)) error {
+	projDir := s.getProjectDir()
+	ign, _ := ignore.Load(projDir)
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return nil // skip errors, keep walking
+		}
+
+		// Get path relative to project root for display
+		rel, _ := filepath.Rel(projDir, path)
+		if path == root {
+			return nil // skip the root itself
+		}
+
+		name := d.Name()
+
+		// Skip hidden files/dirs
+		if strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip common non-project dirs
+		if d.IsDir() && (name == "node_modules" || name == "dist" || name == "__pycache__") {
+			return filepath.SkipDir
+		}
+
+		if ign.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip symlinks to prevent leaking paths outside the project
+		if d.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			return nil // skip on error
+		}
+		fn(rel, info)
+		return nil
+	})
+}
+
 // requireDevRunning returns an error if the dev process is not running.
 func (s *Server) requireDevRunning() error {
 	if !s.devProcess.IsRunning() {
@@ -110,7 +194,7 @@ func (s *Server) requireDevRunning() error {
 	return nil
 }
 
-// registerTools registers all 16 MCP tools on the server.
+// registerTools registers all 29 MCP tools on the server.
 func (s *Server) registerTools() {
 	s.registerCreateProject()
 	s.registerWriteFile()
@@ -128,6 +212,19 @@ func (s *Server) registerTools() {
 	s.registerDoctor()
 	s.registerHotReload()
 	s.registerPackage()
+	s.registerGetMetrics()
+	s.registerSuggestPermissions()
+	s.registerRelease()
+	s.registerGetStore()
+	s.registerDeleteFile()
+	s.registerMoveFile()
+	s.registerEditFile()
+	s.registerStat()
+	s.registerGetNetwork()
+	s.registerOpenProject()
+	s.registerListProjects()
+	s.registerListWindows()
+	s.registerExportPage()
 }
 
 // --- Tool 1: lightshell_create_project ---
@@ -135,7 +232,7 @@ func (s *Server) registerTools() {
 func (s *Server) registerCreateProject() {
 	s.registerTool(Tool{
 		Name:        "lightshell_create_project",
-		Description: "Create a new LightShell desktop app project with the standard directory structure (lightshell.json, src/index.html, src/app.js, src/style.css). This initializes everything needed to run 'lightshell dev'.",
+		Description: "Create a new LightShell desktop app project with the standard directory structure (lightshell.json, src/index.html, src/app.js, src/style.css). This initializes everything needed to run 'lightshell dev'. The new project becomes the active one; the previous project stays open, and its dev server keeps running (see lightshell_list_projects).",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -215,6 +312,7 @@ func (s *Server) handleCreateProject(params map[string]any) (any, error) {
 			"icon":  "",
 			"appId": "com.lightshell." + name,
 		},
+		"lightshellVersion": runtime.Version,
 	}
 	configBytes, _ := json.MarshalIndent(config, "", "  ")
 
@@ -299,19 +397,12 @@ p {
 		createdFiles = append(createdFiles, relPath)
 	}
 
-	// Stop dev process if running before changing project directory
-	if s.devProcess.IsRunning() {
-		s.devProcess.Stop()
-	}
-
-	// Update server project directory to the new project (protected by mutex)
-	s.mu.Lock()
-	s.projectDir = projDir
-	s.mu.Unlock()
+	previous := s.switchProject(projDir)
 
 	return map[string]any{
-		"projectPath": projDir,
-		"files":       createdFiles,
+		"projectPath":     projDir,
+		"files":           createdFiles,
+		"previousProject": previous,
 	}, nil
 }
 
@@ -320,7 +411,7 @@ p {
 func (s *Server) registerWriteFile() {
 	s.registerTool(Tool{
 		Name:        "lightshell_write_file",
-		Description: "Write or overwrite a file in the LightShell project. Creates parent directories automatically. Path is relative to the project root.",
+		Description: "Write or overwrite a file in the LightShell project. Creates parent directories automatically. Path is relative to the project root. The file is replaced atomically, so an interrupted write never leaves it half-written.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -330,7 +421,16 @@ func (s *Server) registerWriteFile() {
 				},
 				"content": map[string]any{
 					"type":        "string",
-					"description": "File content to write",
+					"description": "File content to write (at most 5 MB)",
+				},
+				"encoding": map[string]any{
+					"type":        "string",
+					"description": "'text' (default), or 'base64' for binary content such as images",
+					"enum":        []string{"text", "base64"},
+				},
+				"backup": map[string]any{
+					"type":        "boolean",
+					"description": "Keep the previous content as <path>.bak (default false)",
 				},
 			},
 			"required": []string{"path", "content"},
@@ -345,6 +445,20 @@ func (s *Server) handleWriteFile(params map[string]any) (any, error) {
 	if relPath == "" {
 		return nil, fmt.Errorf("path is required")
 	}
+	switch getString(params, "encoding", "text") {
+	case "text":
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, fmt.Errorf("content is not valid base64: %w", err)
+		}
+		content = string(data)
+	default:
+		return nil, fmt.Errorf("encoding must be 'text' or 'base64'")
+	}
+	if len(content) > maxWriteBytes {
+		return nil, fmt.Errorf("content is %d bytes, over the %d-byte write limit", len(content), maxWriteBytes)
+	}
 
 	absPath, err := s.safePath(relPath)
 	if err != nil {
@@ -361,15 +475,20 @@ func (s *Server) handleWriteFile(params map[string]any) (any, error) {
 		return nil, fmt.Errorf("failed to create directories: %w", err)
 	}
 
-	if err := os.WriteFile(absPath, []byte(content), 0644); err != nil {
+	backupPath, err := writeFile(absPath, []byte(content), getBool(params, "backup", false))
+	if err != nil {
 		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return map[string]any{
+	result := map[string]any{
 		"path":    absPath,
 		"size":    len(content),
 		"created": !existed,
-	}, nil
+	}
+	if backupPath != "" {
+		result["backup"] = backupPath
+	}
+	return result, nil
 }
 
 // --- Tool 3: lightshell_read_file ---
@@ -377,7 +496,7 @@ func (s *Server) handleWriteFile(params map[string]any) (any, error) {
 func (s *Server) registerReadFile() {
 	s.registerTool(Tool{
 		Name:        "lightshell_read_file",
-		Description: "Read the contents of a file in the LightShell project. Path is relative to the project root.",
+		Description: "Read the contents of a file in the LightShell project. Path is relative to the project root. Returns at most 256 KB: read larger files in ranges, by bytes (offset, length) or lines (head, tail). Binary files are not returned as text; pass encoding: 'base64' to read them. Use lightshell_stat to check a file's size first.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -385,6 +504,27 @@ func (s *Server) registerReadFile() {
 					"type":        "string",
 					"description": "File path relative to project root (e.g. 'src/index.html')",
 				},
+				"offset": map[string]any{
+					"type":        "integer",
+					"description": "Byte offset to start reading at (default 0)",
+				},
+				"length": map[string]any{
+					"type":        "integer",
+					"description": "Number of bytes to read from offset (at most 262144)",
+				},
+				"head": map[string]any{
+					"type":        "integer",
+					"description": "Read only the first N lines",
+				},
+				"tail": map[string]any{
+					"type":        "integer",
+					"description": "Read only the last N lines",
+				},
+				"encoding": map[string]any{
+					"type":        "string",
+					"description": "'text' (default) or 'base64'. Binary files are only returned as base64",
+					"enum":        []string{"text", "base64"},
+				},
 			},
 			"required": []string{"path"},
 		},
@@ -397,25 +537,100 @@ func (s *Server) handleReadFile(params map[string]any) (any, error) {
 	if relPath == "" {
 		return nil, fmt.Errorf("path is required")
 	}
+	offset := int64(getInt(params, "offset", 0))
+	length := int64(getInt(params, "length", 0))
+	head := getInt(params, "head", 0)
+	tail := getInt(params, "tail", 0)
+	encoding := getString(params, "encoding", "text")
+	switch {
+	case encoding != "text" && encoding != "base64":
+		return nil, fmt.Errorf("encoding must be 'text' or 'base64'")
+	case offset < 0 || length < 0 || head < 0 || tail < 0:
+		return nil, fmt.Errorf("offset, length, head, and tail cannot be negative")
+	case head > 0 && tail > 0, (head > 0 || tail > 0) && (offset > 0 || length > 0):
+		return nil, fmt.Errorf("use only one of offset/length, head, or tail")
+	}
+	// Base64 grows content by a third, so less of the file fits
+	limit := int64(maxReadBytes)
+	if encoding == "base64" {
+		limit = maxReadBytes / 4 * 3
+	}
 
 	absPath, err := s.safePath(relPath)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := os.ReadFile(absPath)
+	f, err := os.Open(absPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("file not found: %s", relPath)
 		}
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory; use lightshell_list_files", relPath)
+	}
+	size := info.Size()
 
-	return map[string]any{
-		"path":    absPath,
-		"content": string(data),
-		"size":    len(data),
-	}, nil
+	result := map[string]any{
+		"path": absPath,
+		"size": size,
+	}
+	sample, err := sniff(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if isBinary(sample) && encoding == "text" {
+		result["binary"] = true
+		result["mimeType"] = http.DetectContentType(sample)
+		result["content"] = nil
+		result["note"] = "binary file not returned as text; pass encoding: 'base64' to read it"
+		return result, nil
+	}
+
+	var data []byte
+	truncated := false
+	switch {
+	case head > 0:
+		data, truncated, err = headLines(f, head, int(limit))
+	case tail > 0:
+		data, truncated, err = tailLines(f, size, tail, int(limit))
+	case length > 0 || offset > 0:
+		if length == 0 {
+			length = size - offset
+		}
+		if length > limit {
+			return nil, fmt.Errorf("length %d is over the %d-byte read limit", length, limit)
+		}
+		data, err = readRange(f, offset, length)
+		result["offset"] = offset
+	default:
+		if size > limit {
+			return nil, fmt.Errorf("%s is %d bytes, over the %d-byte read limit; read it in ranges with offset and length, or head or tail", relPath, size, limit)
+		}
+		data, err = io.ReadAll(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if encoding == "base64" {
+		result["content"] = base64.StdEncoding.EncodeToString(data)
+		result["encoding"] = "base64"
+	} else {
+		result["content"] = string(data)
+	}
+	result["length"] = len(data)
+	if truncated {
+		result["truncated"] = true
+	}
+	return result, nil
 }
 
 // --- Tool 4: lightshell_list_files ---
@@ -423,7 +638,7 @@ func (s *Server) handleReadFile(params map[string]any) (any, error) {
 func (s *Server) registerListFiles() {
 	s.registerTool(Tool{
 		Name:        "lightshell_list_files",
-		Description: "List all files and directories in the LightShell project (or a subdirectory). Excludes hidden files, node_modules, and dist/.",
+		Description: "List all files and directories in the LightShell project (or a subdirectory). Excludes hidden files, node_modules, dist/, and paths listed in .lightshellignore.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -457,53 +672,12 @@ func (s *Server) handleListFiles(params map[string]any) (any, error) {
 	}
 
 	var files []map[string]any
-
-	err = filepath.WalkDir(absPath, func(path string, d os.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return nil // skip errors, keep walking
-		}
-
-		// Get path relative to project root for display
-		rel, _ := filepath.Rel(s.projectDir, path)
-		if rel == "." {
-			return nil // skip the root itself
-		}
-
-		name := d.Name()
-
-		// Skip hidden files/dirs
-		if strings.HasPrefix(name, ".") {
-			if d.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Skip common non-project dirs
-		if d.IsDir() && (name == "node_modules" || name == "dist" || name == "__pycache__") {
-			return filepath.SkipDir
-		}
-
-		// Skip symlinks to prevent leaking paths outside the project
-		if d.Type()&os.ModeSymlink != 0 {
-			if d.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		info, infoErr := d.Info()
-		if infoErr != nil {
-			return nil // skip on error
-		}
-
+	err = s.walkProject(absPath, func(rel string, info os.FileInfo) {
 		files = append(files, map[string]any{
 			"path":  rel,
 			"size":  info.Size(),
-			"isDir": d.IsDir(),
+			"isDir": info.IsDir(),
 		})
-
-		return nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list files: %w", err)
@@ -523,10 +697,15 @@ func (s *Server) handleListFiles(params map[string]any) (any, error) {
 func (s *Server) registerDevStart() {
 	s.registerTool(Tool{
 		Name:        "lightshell_dev_start",
-		Description: "Start the LightShell dev server. This launches the app window with hot-reload enabled and opens a socket for MCP commands (screenshot, console, DOM inspection, JS execution).",
+		Description: "Start the LightShell dev server. This launches the app window with hot-reload enabled and opens a socket for MCP commands (screenshot, console, DOM inspection, JS execution). Set record to save the commands sent to it, and their responses, as a session that 'lightshell replay <session>' re-runs against a fresh dev server — to reproduce a bug found along the way.",
 		InputSchema: map[string]any{
-			"type":       "object",
-			"properties": map[string]any{},
+			"type": "object",
+			"properties": map[string]any{
+				"record": map[string]any{
+					"type":        "boolean",
+					"description": "Record this dev server's commands as a session in .lightshell/sessions (default: false)",
+				},
+			},
 		},
 		Handler: s.handleDevStart,
 	})
@@ -538,17 +717,28 @@ func (s *Server) handleDevStart(params map[string]any) (any, error) {
 		return nil, fmt.Errorf("no lightshell.json found in %s — create a project first with lightshell_create_project", s.projectDir)
 	}
 
-	// Create a fresh DevProcessManager pointing at the current projectDir
-	s.devProcess = NewDevProcessManager(s.projectDir)
-
+	s.devProcess.Record = getBool(params, "record", false)
 	if err := s.devProcess.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start dev server: %w", err)
 	}
 
-	return map[string]any{
+	result := map[string]any{
 		"status":     "running",
 		"projectDir": s.projectDir,
-	}, nil
+	}
+	if s.devProcess.Record {
+		// The session is named for when the dev process started recording
+		var status struct {
+			Session string `json:"session"`
+		}
+		if resp, err := s.devProcess.SendCommand(MCPCommand{Cmd: "status"}); err == nil {
+			json.Unmarshal(resp.Result, &status)
+		}
+		if status.Session != "" {
+			result["session"] = status.Session
+		}
+	}
+	return result, nil
 }
 
 // --- Tool 6: lightshell_dev_stop ---
@@ -556,16 +746,28 @@ func (s *Server) handleDevStart(params map[string]any) (any, error) {
 func (s *Server) registerDevStop() {
 	s.registerTool(Tool{
 		Name:        "lightshell_dev_stop",
-		Description: "Stop the running LightShell dev server and close the app window.",
+		Description: "Stop the active project's LightShell dev server and close the app window. Set all to stop the dev servers of every project opened in this session.",
 		InputSchema: map[string]any{
-			"type":       "object",
-			"properties": map[string]any{},
+			"type": "object",
+			"properties": map[string]any{
+				"all": map[string]any{
+					"type":        "boolean",
+					"description": "Stop every project's dev server, not just the active project's (default false)",
+				},
+			},
 		},
 		Handler: s.handleDevStop,
 	})
 }
 
 func (s *Server) handleDevStop(params map[string]any) (any, error) {
+	if getBool(params, "all", false) {
+		return map[string]any{
+			"status":  "stopped",
+			"stopped": s.stopAllDev(),
+		}, nil
+	}
+
 	if err := s.devProcess.Stop(); err != nil {
 		return nil, fmt.Errorf("failed to stop dev server: %w", err)
 	}
@@ -588,6 +790,10 @@ func (s *Server) registerScreenshot() {
 					"type":        "number",
 					"description": "Milliseconds to wait before capturing (default 500). Useful for animations or async rendering.",
 				},
+				"windowId": map[string]any{
+					"type":        "integer",
+					"description": "Window to capture, from lightshell_list_windows (default: the main window)",
+				},
 			},
 		},
 		Handler: s.handleScreenshot,
@@ -602,8 +808,9 @@ func (s *Server) handleScreenshot(params map[string]any) (any, error) {
 	delay := getInt(params, "delay", 500)
 
 	resp, err := s.devProcess.SendCommand(MCPCommand{
-		Cmd:   "screenshot",
-		Delay: delay,
+		Cmd:      "screenshot",
+		Delay:    delay,
+		WindowID: getInt(params, "windowId", 0),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("screenshot failed: %w", err)
@@ -694,14 +901,18 @@ func (s *Server) handleGetConsole(params map[string]any) (any, error) {
 func (s *Server) registerBuild() {
 	s.registerTool(Tool{
 		Name:        "lightshell_build",
-		Description: "Build the LightShell app for production. Creates a native .app bundle (macOS) or AppImage (Linux). Stops the dev server first if it's running.",
+		Description: "Build the LightShell app for production. Creates a native .app bundle (macOS), installer (Windows), or AppImage (Linux), or the package format target names. Stops the dev server first if it's running.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"target": map[string]any{
 					"type":        "string",
-					"description": "Build target: 'default' (native bundle), 'dmg', 'deb', 'rpm', 'all' (default: 'default')",
-					"enum":        []string{"default", "dmg", "deb", "rpm", "all"},
+					"description": "Build target: 'default' (the OS's usual format), 'app' or 'dmg' (macOS), 'nsis' (Windows), 'appimage', 'deb' or 'rpm' (Linux), 'all' (every format for the current OS) (default: 'default')",
+					"enum":        []string{"default", "app", "dmg", "nsis", "appimage", "deb", "rpm", "all"},
+				},
+				"platform": map[string]any{
+					"type":        "string",
+					"description": "Platforms to cross-compile for instead of this machine's, comma-separated, like 'darwin-arm64,windows-x64'. Each one's output goes to dist/<platform>. Fails listing any missing cross-compilers.",
 				},
 			},
 		},
@@ -721,64 +932,34 @@ func (s *Server) handleBuild(params map[string]any) (any, error) {
 	}
 
 	target := getString(params, "target", "default")
-
-	// Run the build by executing lightshell build as a subprocess
-	selfPath, err := os.Executable()
-	if err != nil {
-		return nil, fmt.Errorf("could not find lightshell binary: %w", err)
-	}
+	platform := getString(params, "platform", "")
 
 	args := []string{"build"}
 	if target != "" && target != "default" {
 		args = append(args, "--target", target)
 	}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
 
-	cmd := exec.Command(selfPath, args...)
-	cmd.Dir = s.projectDir
-	output, err := cmd.CombinedOutput()
-	outputStr := strings.TrimSpace(string(output))
-
+	var result cliBuildResult
+	output, err := s.runCLI(&result, args...)
 	if err != nil {
-		return nil, fmt.Errorf("build failed: %s\n%s", err, outputStr)
+		return nil, fmt.Errorf("build failed: %s\n%s", err, output)
 	}
 
-	// Try to find the output path in the dist/ directory
-	distDir := filepath.Join(s.projectDir, "dist")
 	var outputPath string
 	var outputSize int64
-
-	if entries, err := os.ReadDir(distDir); err == nil {
-		for _, e := range entries {
-			if !e.IsDir() {
-				continue
-			}
-			// Look for the .app bundle or other output
-			info, err := e.Info()
-			if err == nil {
-				outputPath = filepath.Join(distDir, e.Name())
-				outputSize = info.Size()
-			}
-		}
-		// If no dirs found, look for files (like .dmg, .deb, .rpm)
-		if outputPath == "" {
-			for _, e := range entries {
-				if e.IsDir() {
-					continue
-				}
-				info, err := e.Info()
-				if err == nil {
-					outputPath = filepath.Join(distDir, e.Name())
-					outputSize = info.Size()
-				}
-			}
-		}
+	if len(result.Artifacts) > 0 {
+		outputPath, outputSize = result.Artifacts[0].Path, result.Artifacts[0].Size
 	}
-
 	return map[string]any{
 		"target":     target,
 		"outputPath": outputPath,
 		"size":       outputSize,
-		"output":     outputStr,
+		"artifacts":  result.Artifacts,
+		"durationMs": result.DurationMs,
+		"output":     output,
 	}, nil
 }
 
@@ -787,7 +968,7 @@ func (s *Server) handleBuild(params map[string]any) (any, error) {
 func (s *Server) registerGetDOM() {
 	s.registerTool(Tool{
 		Name:        "lightshell_get_dom",
-		Description: "Inspect the DOM tree of the running LightShell app. Returns the HTML structure for a given CSS selector, useful for understanding app layout and debugging rendering.",
+		Description: "Inspect the DOM tree of the running LightShell app. Returns the HTML structure for a given CSS selector, useful for understanding app layout and debugging rendering. Large output is cut at maxBytes with a \"[truncated at N bytes]\" marker; page through the element's children with offset and limit.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -797,7 +978,23 @@ func (s *Server) registerGetDOM() {
 				},
 				"depth": map[string]any{
 					"type":        "number",
-					"description": "Maximum depth of DOM tree to return (default 5)",
+					"description": "Maximum depth of DOM tree to return (default 5, max 50)",
+				},
+				"offset": map[string]any{
+					"type":        "number",
+					"description": "Index of the first child of the selected element to include (default 0). Children are elements and non-blank text nodes",
+				},
+				"limit": map[string]any{
+					"type":        "number",
+					"description": "Maximum number of children of the selected element to include (default: all)",
+				},
+				"maxBytes": map[string]any{
+					"type":        "number",
+					"description": "Maximum size of the returned HTML (default 100000, max 1048576)",
+				},
+				"windowId": map[string]any{
+					"type":        "integer",
+					"description": "Window to inspect, from lightshell_list_windows (default: the main window)",
 				},
 			},
 		},
@@ -812,20 +1009,32 @@ func (s *Server) handleGetDOM(params map[string]any) (any, error) {
 
 	selector := getString(params, "selector", "body")
 	depth := getInt(params, "depth", 5)
+	offset := max(getInt(params, "offset", 0), 0)
+	limit := getInt(params, "limit", 0)
 
 	resp, err := s.devProcess.SendCommand(MCPCommand{
 		Cmd:      "dom",
 		Selector: selector,
 		Depth:    depth,
+		Offset:   offset,
+		Limit:    limit,
+		MaxBytes: getInt(params, "maxBytes", 0),
+		WindowID: getInt(params, "windowId", 0),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("DOM inspection failed: %w", err)
 	}
 
-	return map[string]any{
-		"html":     resp.HTML,
-		"selector": selector,
-	}, nil
+	result := map[string]any{
+		"html":       resp.HTML,
+		"selector":   selector,
+		"childCount": resp.Total,
+		"truncated":  resp.Truncated,
+	}
+	if limit > 0 && offset+limit < resp.Total {
+		result["nextOffset"] = offset + limit
+	}
+	return result, nil
 }
 
 // --- Tool 11: lightshell_execute_js ---
@@ -833,7 +1042,7 @@ func (s *Server) handleGetDOM(params map[string]any) (any, error) {
 func (s *Server) registerExecuteJS() {
 	s.registerTool(Tool{
 		Name:        "lightshell_execute_js",
-		Description: "Execute JavaScript code in the running LightShell app's webview context. The code runs in the page and can access the DOM, lightshell APIs, and all page-level variables. Returns the result of the last expression.",
+		Description: "Execute JavaScript code in the running LightShell app's webview context. The code runs in the page and can access the DOM, lightshell APIs, and all page-level variables. Returns the result of the last expression, serialized to JSON up to depth and maxBytes.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -841,6 +1050,18 @@ func (s *Server) registerExecuteJS() {
 					"type":        "string",
 					"description": "JavaScript code to execute in the webview",
 				},
+				"depth": map[string]any{
+					"type":        "number",
+					"description": "Nesting depth of the serialized result; deeper objects show as \"[Object]\" or \"[Array]\" (default 10, max 50)",
+				},
+				"maxBytes": map[string]any{
+					"type":        "number",
+					"description": "Maximum size of the serialized result, cut with a \"[truncated at N bytes]\" marker (default 100000, max 1048576)",
+				},
+				"windowId": map[string]any{
+					"type":        "integer",
+					"description": "Window to run the code in, from lightshell_list_windows (default: the main window)",
+				},
 			},
 			"required": []string{"code"},
 		},
@@ -859,8 +1080,11 @@ func (s *Server) handleExecuteJS(params map[string]any) (any, error) {
 	}
 
 	resp, err := s.devProcess.SendCommand(MCPCommand{
-		Cmd:  "eval",
-		Code: code,
+		Cmd:      "eval",
+		Code:     code,
+		Depth:    getInt(params, "depth", 0),
+		MaxBytes: getInt(params, "maxBytes", 0),
+		WindowID: getInt(params, "windowId", 0),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("JS execution failed: %w", err)
@@ -876,7 +1100,8 @@ func (s *Server) handleExecuteJS(params map[string]any) (any, error) {
 	}
 
 	return map[string]any{
-		"result": result,
+		"result":    result,
+		"truncated": resp.Truncated,
 	}, nil
 }
 
@@ -885,16 +1110,24 @@ func (s *Server) handleExecuteJS(params map[string]any) (any, error) {
 func (s *Server) registerGetConfig() {
 	s.registerTool(Tool{
 		Name:        "lightshell_get_config",
-		Description: "Read the current lightshell.json configuration file for the project. Returns the full config object including window settings, permissions, build options, etc.",
+		Description: "Read the current lightshell.json configuration file for the project. Returns the full config object including window settings, permissions, build options, etc. With resolved, returns the config the app actually runs with — configs it extends merged in and defaults applied — as {config, files, sources}, where sources maps each dotted setting to the file it came from, or \"(default)\".",
 		InputSchema: map[string]any{
-			"type":       "object",
-			"properties": map[string]any{},
+			"type": "object",
+			"properties": map[string]any{
+				"resolved": map[string]any{
+					"type":        "boolean",
+					"description": "Return the effective config with defaults and where each setting came from (default false)",
+				},
+			},
 		},
 		Handler: s.handleGetConfig,
 	})
 }
 
 func (s *Server) handleGetConfig(params map[string]any) (any, error) {
+	if getBool(params, "resolved", false) {
+		return s.resolvedConfig()
+	}
 	configPath := filepath.Join(s.projectDir, "lightshell.json")
 
 	data, err := os.ReadFile(configPath)
@@ -913,12 +1146,47 @@ func (s *Server) handleGetConfig(params map[string]any) (any, error) {
 	return config, nil
 }
 
+// resolvedConfig returns the effective config, with file paths relative to
+// the project.
+func (s *Server) resolvedConfig() (any, error) {
+	projDir := s.getProjectDir()
+	if _, err := os.Stat(filepath.Join(projDir, "lightshell.json")); os.IsNotExist(err) {
+		return nil, fmt.Errorf("no lightshell.json found in %s — create a project first", projDir)
+	}
+	eff, err := runtime.EffectiveConfig(projDir)
+	if err != nil {
+		return nil, err
+	}
+	rel := func(path string) string {
+		if r, err := filepath.Rel(projDir, path); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return path
+	}
+	files := make([]string, len(eff.Files))
+	for i, f := range eff.Files {
+		files[i] = rel(f)
+	}
+	sources := make(map[string]string, len(eff.Sources))
+	for k, f := range eff.Sources {
+		if f != runtime.DefaultSource {
+			f = rel(f)
+		}
+		sources[k] = f
+	}
+	return map[string]any{
+		"config":  eff.Values,
+		"files":   files,
+		"sources": sources,
+	}, nil
+}
+
 // --- Tool 13: lightshell_update_config ---
 
 func (s *Server) registerUpdateConfig() {
 	s.registerTool(Tool{
 		Name:        "lightshell_update_config",
-		Description: "Update the lightshell.json configuration. Provide a partial config object — it will be shallow-merged with the existing config. Set a key to null to delete it.",
+		Description: "Update the lightshell.json configuration. Provide a partial config object — it will be deep-merged with the existing config. Set a key to null to delete it. Only the changed keys are rewritten: the rest of the file keeps its key order and formatting, and the file is replaced atomically.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -926,6 +1194,10 @@ func (s *Server) registerUpdateConfig() {
 					"type":        "object",
 					"description": "Partial config to merge into lightshell.json. Keys with null values are deleted.",
 				},
+				"backup": map[string]any{
+					"type":        "boolean",
+					"description": "Keep the previous lightshell.json as lightshell.json.bak (default false)",
+				},
 			},
 			"required": []string{"patch"},
 		},
@@ -954,22 +1226,50 @@ func (s *Server) handleUpdateConfig(params map[string]any) (any, error) {
 		return nil, fmt.Errorf("failed to parse lightshell.json: %w", err)
 	}
 
-	// Deep merge patch into config
-	deepMerge(config, patch)
-
-	// Write back
-	out, err := json.MarshalIndent(config, "", "  ")
+	// Edit the file in place, then merge the same way for the result
+	out, err := applyConfigPatch(data, nil, config, patch)
 	if err != nil {
-		return nil, fmt.Errorf("failed to serialize config: %w", err)
+		return nil, fmt.Errorf("failed to update config: %w", err)
 	}
+	deepMerge(config, patch)
 
-	if err := os.WriteFile(configPath, out, 0644); err != nil {
+	if _, err := writeFile(configPath, out, getBool(params, "backup", false)); err != nil {
 		return nil, fmt.Errorf("failed to write config: %w", err)
 	}
 
 	return config, nil
 }
 
+// applyConfigPatch makes the changes deepMerge would make to current, the
+// parsed object at path in src, as edits to src's text.
+func applyConfigPatch(src []byte, path []string, current, patch map[string]any) ([]byte, error) {
+	keys := make([]string, 0, len(patch))
+	for k := range patch {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var err error
+	for _, k := range keys {
+		keyPath := append(path[:len(path):len(path)], k)
+		v := patch[k]
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := current[k].(map[string]any)
+		switch {
+		case v == nil:
+			src, err = jsonedit.Delete(src, keyPath)
+		case srcIsMap && dstIsMap:
+			src, err = applyConfigPatch(src, keyPath, dstMap, srcMap)
+		default:
+			src, err = jsonedit.Set(src, keyPath, v)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return src, nil
+}
+
 // deepMerge merges src into dst. For nested maps, it recurses. If a src value
 // is nil (JSON null), the key is deleted from dst.
 func deepMerge(dst, src map[string]any) {
@@ -999,39 +1299,100 @@ func deepMerge(dst, src map[string]any) {
 func (s *Server) registerDoctor() {
 	s.registerTool(Tool{
 		Name:        "lightshell_doctor",
-		Description: "Run diagnostics on the LightShell project. Checks for common issues like missing dependencies, invalid config, cross-platform compatibility problems, and unsupported API usage.",
+		Description: "Scan the LightShell project for cross-platform compatibility problems, as lightshell doctor does. Returns each issue as structured data: rule, file, line, column, severity, title, fix, autoFix (true when LightShell polyfills it at runtime), docsUrl, and minVersion (the first WebKitGTK and Safari releases that support the feature), plus the count of each rule's issues and a summary. passed is false when any issue is an error, or with failOn warning any issue at all. Issues in the project's doctor baseline are left out unless noBaseline is set. With fix, it first applies the fixes lightshell doctor --fix makes (the polyfill bundle loaded from the entry HTML, and simple rewrites such as showOpenFilePicker() to lightshell.dialog.open()) and reports them in fixes; with dryRun as well, each fix has a diff and nothing is written.",
 		InputSchema: map[string]any{
-			"type":       "object",
-			"properties": map[string]any{},
+			"type": "object",
+			"properties": map[string]any{
+				"noBaseline": map[string]any{
+					"type":        "boolean",
+					"description": "Report issues accepted in .lightshell/doctor-baseline.json too (default false)",
+				},
+				"failOn": map[string]any{
+					"type":        "string",
+					"enum":        []string{"error", "warning"},
+					"description": "The least severe issue that makes passed false (default: error)",
+				},
+				"fix": map[string]any{
+					"type":        "boolean",
+					"description": "Apply the automatic fixes before reporting (default false)",
+				},
+				"dryRun": map[string]any{
+					"type":        "boolean",
+					"description": "With fix, return the fixes as diffs without writing them (default false)",
+				},
+			},
 		},
 		Handler: s.handleDoctor,
 	})
 }
 
 func (s *Server) handleDoctor(params map[string]any) (any, error) {
+	projDir := s.getProjectDir()
 	// Verify we have a valid project
-	if _, err := os.Stat(filepath.Join(s.projectDir, "lightshell.json")); err != nil {
-		return nil, fmt.Errorf("no lightshell.json found in %s — create a project first", s.projectDir)
+	if _, err := os.Stat(filepath.Join(projDir, "lightshell.json")); err != nil {
+		return nil, fmt.Errorf("no lightshell.json found in %s — create a project first", projDir)
 	}
 
-	selfPath, err := os.Executable()
+	issues, err := compat.ScanProject(projDir)
 	if err != nil {
-		return nil, fmt.Errorf("could not find lightshell binary: %w", err)
+		return nil, fmt.Errorf("scan failed: %w", err)
 	}
 
-	cmd := exec.Command(selfPath, "doctor")
-	cmd.Dir = s.projectDir
-	output, err := cmd.CombinedOutput()
-	outputStr := strings.TrimSpace(string(output))
-
-	// doctor may exit non-zero if it finds issues — that's not an error for us
-	return map[string]any{
-		"output": outputStr,
-		"passed": err == nil,
-	}, nil
-}
-
-// --- Tool 15: lightshell_hot_reload ---
+	var fixes []map[string]any
+	if getBool(params, "fix", false) {
+		cfg, err := runtime.LoadConfig(projDir)
+		if err != nil {
+			return nil, err
+		}
+		planned, err := compat.PlanFixes(projDir, cfg.Entry, issues)
+		if err != nil {
+			return nil, fmt.Errorf("could not plan fixes: %w", err)
+		}
+		dryRun := getBool(params, "dryRun", false)
+		fixes = []map[string]any{}
+		for _, f := range planned {
+			fix := map[string]any{"file": filepath.ToSlash(f.File), "created": f.Created, "changes": f.Changes}
+			if dryRun {
+				fix["diff"] = f.Diff()
+			} else if err := f.Apply(projDir); err != nil {
+				return nil, err
+			}
+			fixes = append(fixes, fix)
+		}
+		if !dryRun && len(planned) > 0 {
+			if issues, err = compat.ScanProject(projDir); err != nil {
+				return nil, fmt.Errorf("scan failed: %w", err)
+			}
+		}
+	}
+
+	// Leave out the issues accepted in the project's baseline, as doctor
+	// does, unless it has expired
+	baselined := 0
+	if !getBool(params, "noBaseline", false) {
+		if b, err := compat.LoadBaseline(compat.BaselinePath(projDir)); err == nil && !b.Expired(time.Now()) {
+			issues, baselined = b.Filter(issues)
+		}
+	}
+
+	report := compat.NewReport(issues, baselined)
+	passed := report.Summary.Errors == 0
+	if getString(params, "failOn", "error") == "warning" {
+		passed = len(report.Issues) == 0
+	}
+	result := map[string]any{
+		"passed":  passed,
+		"issues":  report.Issues,
+		"rules":   report.Rules,
+		"summary": report.Summary,
+	}
+	if fixes != nil {
+		result["fixes"] = fixes
+	}
+	return result, nil
+}
+
+// --- Tool 15: lightshell_hot_reload ---
 
 func (s *Server) registerHotReload() {
 	s.registerTool(Tool{
@@ -1067,18 +1428,22 @@ func (s *Server) handleHotReload(params map[string]any) (any, error) {
 func (s *Server) registerPackage() {
 	s.registerTool(Tool{
 		Name:        "lightshell_package",
-		Description: "Package the LightShell app into a distributable format. Supports DMG (macOS), .deb (Debian/Ubuntu), .rpm (Fedora), or all formats for the current OS. Optionally code-sign on macOS.",
+		Description: "Package the LightShell app into a distributable format. Supports DMG (macOS), AppImage, .deb (Debian/Ubuntu), .rpm (Fedora), or all formats for the current OS. Optionally code-sign on macOS.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
 				"target": map[string]any{
 					"type":        "string",
-					"description": "Package format: 'dmg', 'deb', 'rpm', 'all'",
-					"enum":        []string{"dmg", "deb", "rpm", "all"},
+					"description": "Package format: 'dmg', 'appimage', 'deb', 'rpm', 'all'",
+					"enum":        []string{"dmg", "appimage", "deb", "rpm", "all"},
 				},
 				"sign": map[string]any{
 					"type":        "boolean",
-					"description": "Code-sign the package (macOS only, requires signing identity in config)",
+					"description": "Code-sign the package (macOS only, requires build.mac.identity in lightshell.json)",
+				},
+				"notarize": map[string]any{
+					"type":        "boolean",
+					"description": "Notarize the signed package with Apple and staple the ticket (macOS only, requires sign and notarization credentials in build.mac; takes several minutes)",
 				},
 			},
 			"required": []string{"target"},
@@ -1103,60 +1468,925 @@ func (s *Server) handlePackage(params map[string]any) (any, error) {
 		return nil, fmt.Errorf("target is required")
 	}
 	sign := getBool(params, "sign", false)
-
-	selfPath, err := os.Executable()
-	if err != nil {
-		return nil, fmt.Errorf("could not find lightshell binary: %w", err)
-	}
+	notarize := getBool(params, "notarize", false)
 
 	args := []string{"build", "--target", target}
 	if sign {
 		args = append(args, "--sign")
 	}
+	if notarize {
+		args = append(args, "--notarize")
+	}
 
-	cmd := exec.Command(selfPath, args...)
-	cmd.Dir = s.projectDir
-	output, err := cmd.CombinedOutput()
-	outputStr := strings.TrimSpace(string(output))
+	var result cliBuildResult
+	output, err := s.runCLI(&result, args...)
+	if err != nil {
+		return nil, fmt.Errorf("packaging failed: %s\n%s", err, output)
+	}
 
+	packages := []map[string]any{}
+	for _, a := range result.Artifacts {
+		packages = append(packages, map[string]any{
+			"path":   a.Path,
+			"size":   a.Size,
+			"name":   filepath.Base(a.Path),
+			"target": a.Target,
+		})
+	}
+
+	return map[string]any{
+		"target":    target,
+		"signed":    sign,
+		"notarized": notarize,
+		"packages":  packages,
+		"output":    output,
+	}, nil
+}
+
+// --- Tool 17: lightshell_get_metrics ---
+
+func (s *Server) registerGetMetrics() {
+	s.registerTool(Tool{
+		Name:        "lightshell_get_metrics",
+		Description: "Get a snapshot of runtime metrics from the running LightShell app: IPC call counts and timings per method, fs bytes read/written, http requests, and worker pool queues. Useful for finding slow or chatty API calls.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"format": map[string]any{
+					"type":        "string",
+					"description": "Output format: 'json' (list of samples) or 'prometheus' (text exposition format). Default: 'json'",
+					"enum":        []string{"json", "prometheus"},
+				},
+			},
+		},
+		Handler: s.handleGetMetrics,
+	})
+}
+
+func (s *Server) handleGetMetrics(params map[string]any) (any, error) {
+	if err := s.requireDevRunning(); err != nil {
+		return nil, err
+	}
+
+	format := getString(params, "format", "json")
+	if format != "json" && format != "prometheus" {
+		return nil, fmt.Errorf("invalid format %q — use 'json' or 'prometheus'", format)
+	}
+
+	resp, err := s.devProcess.SendCommand(MCPCommand{
+		Cmd:    "metrics",
+		Format: format,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("packaging failed: %s\n%s", err, outputStr)
+		return nil, fmt.Errorf("get metrics failed: %w", err)
 	}
 
-	// Collect output files from dist/
-	distDir := filepath.Join(s.projectDir, "dist")
-	var packages []map[string]any
+	if format == "prometheus" {
+		var text string
+		if err := json.Unmarshal(resp.Result, &text); err != nil {
+			return nil, fmt.Errorf("could not parse metrics: %w", err)
+		}
+		return map[string]any{"metrics": text}, nil
+	}
 
-	if entries, err := os.ReadDir(distDir); err == nil {
-		for _, e := range entries {
-			info, infoErr := e.Info()
-			if infoErr != nil {
-				continue
+	var samples []map[string]any
+	if len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, &samples); err != nil {
+			return nil, fmt.Errorf("could not parse metrics: %w", err)
+		}
+	}
+	if samples == nil {
+		samples = []map[string]any{}
+	}
+	return map[string]any{"samples": samples}, nil
+}
+
+// --- Tool 18: lightshell_suggest_permissions ---
+
+func (s *Server) registerSuggestPermissions() {
+	s.registerTool(Tool{
+		Name:        "lightshell_suggest_permissions",
+		Description: "Propose least-privilege permissions for the project by scanning src/ for lightshell.* API calls. Returns the permissions the code needs, suggested fs/http/process scopes built from literal paths, URLs, and commands, the calls behind each permission, and calls whose targets could not be determined. Set apply to true to write the permissions list to lightshell.json.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"apply": map[string]any{
+					"type":        "boolean",
+					"description": "Write the suggested permissions to lightshell.json. Default: false",
+				},
+			},
+		},
+		Handler: s.handleSuggestPermissions,
+	})
+}
+
+func (s *Server) handleSuggestPermissions(params map[string]any) (any, error) {
+	projDir := s.getProjectDir()
+	configPath := filepath.Join(projDir, "lightshell.json")
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no lightshell.json found in %s — create a project first", projDir)
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var config map[string]any
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse lightshell.json: %w", err)
+	}
+
+	calls, err := compat.ScanAPIUsage(projDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan project: %w", err)
+	}
+	suggestion := security.SuggestPermissions(calls)
+
+	// Compare with what is declared now
+	declared := map[string]bool{}
+	scoped := false // the object form, whose scopes must be kept
+	switch v := config["permissions"].(type) {
+	case []any:
+		for _, p := range v {
+			if name, ok := p.(string); ok {
+				declared[name] = true
+			}
+		}
+	case map[string]any:
+		scoped = true
+		for name, value := range v {
+			if value != false {
+				declared[name] = true
 			}
-			name := e.Name()
-			// Include recognized package formats and app bundles
-			if strings.HasSuffix(name, ".dmg") ||
-				strings.HasSuffix(name, ".deb") ||
-				strings.HasSuffix(name, ".rpm") ||
-				strings.HasSuffix(name, ".AppImage") ||
-				strings.HasSuffix(name, ".app") {
-				packages = append(packages, map[string]any{
-					"path": filepath.Join(distDir, name),
-					"size": info.Size(),
-					"name": name,
-				})
+		}
+	}
+	added, unused := []string{}, []string{}
+	suggested := map[string]bool{}
+	for _, p := range suggestion.Permissions {
+		suggested[p] = true
+		if !declared[p] {
+			added = append(added, p)
+		}
+	}
+	for p := range declared {
+		if !suggested[p] {
+			unused = append(unused, p)
+		}
+	}
+	sort.Strings(unused)
+
+	result := map[string]any{
+		"permissions": suggestion.Permissions,
+		"scopes":      suggestion.Scopes,
+		"evidence":    suggestion.Evidence,
+		"unresolved":  suggestion.Unresolved,
+		"added":       added,
+		"unused":      unused,
+		"applied":     false,
+		"note":        "Scopes are for review and are not written; add them under permissions.fs, permissions.http, or permissions.process to narrow those APIs. Calls made through a variable (const { fs } = lightshell) are not detected.",
+	}
+	if len(suggestion.Permissions) == 0 {
+		result["note"] = "No gated API calls found. An empty or missing permissions list leaves the app in permissive mode, so nothing is written."
+		return result, nil
+	}
+
+	if getBool(params, "apply", false) {
+		var out []byte
+		var err error
+		if scoped {
+			// Declare what is missing, leaving the existing scopes alone
+			out = data
+			for _, p := range added {
+				if out, err = jsonedit.Set(out, []string{"permissions", p}, true); err != nil {
+					break
+				}
+			}
+		} else {
+			out, err = jsonedit.Set(data, []string{"permissions"}, suggestion.Permissions)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update config: %w", err)
+		}
+		if _, err := writeFile(configPath, out, false); err != nil {
+			return nil, fmt.Errorf("failed to write config: %w", err)
+		}
+		result["applied"] = true
+	}
+	return result, nil
+}
+
+// --- Tool 19: lightshell_release ---
+
+func (s *Server) registerRelease() {
+	s.registerTool(Tool{
+		Name:        "lightshell_release",
+		Description: "Build, hash, and sign a release of the LightShell app and return its update manifest. The build's signed provenance must match the artifact (provenanceVerified in the result). Runs as a dry run by default, which uploads nothing and writes the manifest to dist/latest.json. To publish, set dryRun to false and confirm to true; review a dry run's manifest with the user first.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"dryRun": map[string]any{
+					"type":        "boolean",
+					"description": "Do everything except upload. Default: true",
+				},
+				"confirm": map[string]any{
+					"type":        "boolean",
+					"description": "Required to be true when dryRun is false; acknowledges that the release is uploaded to the release server",
+				},
+				"platform": map[string]any{
+					"type":        "string",
+					"description": "Target platform, e.g. 'darwin-arm64' or 'linux-x64'. Default: the current OS and architecture",
+				},
+				"notes": map[string]any{
+					"type":        "string",
+					"description": "Release notes. Default: 'Release <version>'",
+				},
+				"draft": map[string]any{
+					"type":        "boolean",
+					"description": "Mark the release as a draft",
+				},
+				"noBuild": map[string]any{
+					"type":        "boolean",
+					"description": "Use the existing artifact in dist/ instead of building",
+				},
+			},
+		},
+		Handler: s.handleRelease,
+	})
+}
+
+func (s *Server) handleRelease(params map[string]any) (any, error) {
+	if _, err := os.Stat(filepath.Join(s.projectDir, "lightshell.json")); err != nil {
+		return nil, fmt.Errorf("no lightshell.json found in %s — create a project first", s.projectDir)
+	}
+
+	dryRun := getBool(params, "dryRun", true)
+	if !dryRun && !getBool(params, "confirm", false) {
+		return nil, fmt.Errorf("publishing uploads the release to the release server — run with dryRun first, then call again with dryRun: false and confirm: true")
+	}
+
+	args := []string{"release"}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+	if platform := getString(params, "platform", ""); platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	if notes := getString(params, "notes", ""); notes != "" {
+		args = append(args, "--notes", notes)
+	}
+	if getBool(params, "draft", false) {
+		args = append(args, "--draft")
+	}
+	noBuild := getBool(params, "noBuild", false)
+	if noBuild {
+		args = append(args, "--no-build")
+	}
+
+	// The release builds the app, like lightshell_build
+	if !noBuild && s.devProcess.IsRunning() {
+		s.devProcess.Stop()
+	}
+
+	var release struct {
+		Artifact           string         `json:"artifact"`
+		ProvenanceVerified bool           `json:"provenanceVerified"`
+		Manifest           map[string]any `json:"manifest"`
+		ManifestPath       string         `json:"manifestPath"`
+	}
+	output, err := s.runCLI(&release, args...)
+	if err != nil {
+		return nil, fmt.Errorf("release failed: %s\n%s", err, output)
+	}
+
+	result := map[string]any{
+		"dryRun":             dryRun,
+		"published":          !dryRun,
+		"output":             output,
+		"artifact":           release.Artifact,
+		"provenanceVerified": release.ProvenanceVerified,
+		"manifest":           release.Manifest,
+	}
+	if release.ManifestPath != "" {
+		result["manifestPath"] = release.ManifestPath
+	}
+	return result, nil
+}
+
+// cliBuildResult is the part of lightshell build --json the tools use.
+type cliBuildResult struct {
+	DurationMs int64 `json:"durationMs"`
+	Artifacts  []struct {
+		Path     string `json:"path"`
+		Target   string `json:"target"`
+		Platform string `json:"platform"`
+		Size     int64  `json:"size"`
+	} `json:"artifacts"`
+}
+
+// runCLI runs a lightshell command with --json in the project and decodes
+// its result into v. The command's progress output, which --json sends to
+// stderr, is returned for the client to read.
+func (s *Server) runCLI(v any, args ...string) (string, error) {
+	selfPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("could not find lightshell binary: %w", err)
+	}
+
+	cmd := exec.Command(selfPath, append(args, "--json")...)
+	cmd.Dir = s.projectDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	output := strings.TrimSpace(stderr.String())
+
+	if runErr != nil {
+		var failure struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(stdout.Bytes(), &failure) == nil && failure.Error.Message != "" {
+			return output, fmt.Errorf("%s (%s)", failure.Error.Message, failure.Error.Code)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			return output, fmt.Errorf("lightshell %s failed with exit status %d (%s)", args[0], exitErr.ExitCode(), lserrors.ExitCategory(exitErr.ExitCode()))
+		}
+		return output, runErr
+	}
+	if err := json.Unmarshal(stdout.Bytes(), v); err != nil {
+		return output, fmt.Errorf("unexpected output from lightshell %s: %w", args[0], err)
+	}
+	return output, nil
+}
+
+// --- Tool 20: lightshell_get_store ---
+
+func (s *Server) registerGetStore() {
+	s.registerTool(Tool{
+		Name:        "lightshell_get_store",
+		Description: "Read the running app's persistent lightshell.store entries whose keys start with keyPrefix, as the app sees them. Useful for debugging state that is saved or restored incorrectly without writing eval snippets. Requires the dev server to be running.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"keyPrefix": map[string]any{
+					"type":        "string",
+					"description": "Only return keys starting with this prefix. Default: all keys",
+				},
+				"limit": map[string]any{
+					"type":        "number",
+					"description": "Maximum number of entries to return, in key order. Default: 100",
+				},
+			},
+		},
+		Handler: s.handleGetStore,
+	})
+}
+
+func (s *Server) handleGetStore(params map[string]any) (any, error) {
+	if err := s.requireDevRunning(); err != nil {
+		return nil, err
+	}
+
+	limit := getInt(params, "limit", 100)
+	if limit <= 0 {
+		limit = 100
+	}
+
+	resp, err := s.devProcess.SendCommand(MCPCommand{
+		Cmd:    "store",
+		Prefix: getString(params, "keyPrefix", ""),
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get store failed: %w", err)
+	}
+
+	var result struct {
+		Entries []map[string]any `json:"entries"`
+		Total   int              `json:"total"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("could not parse store entries: %w", err)
+	}
+	if result.Entries == nil {
+		result.Entries = []map[string]any{}
+	}
+	return map[string]any{
+		"entries":   result.Entries,
+		"total":     result.Total,
+		"truncated": result.Total > len(result.Entries),
+	}, nil
+}
+
+// --- Tool 21: lightshell_delete_file ---
+
+func (s *Server) registerDeleteFile() {
+	s.registerTool(Tool{
+		Name:        "lightshell_delete_file",
+		Description: "Delete a file or directory in the LightShell project. Path is relative to the project root. Directories that are not empty need recursive: true.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "File or directory path relative to project root (e.g. 'src/old.js')",
+				},
+				"recursive": map[string]any{
+					"type":        "boolean",
+					"description": "Delete a directory with everything in it (default false)",
+				},
+			},
+			"required": []string{"path"},
+		},
+		Handler: s.handleDeleteFile,
+	})
+}
+
+func (s *Server) handleDeleteFile(params map[string]any) (any, error) {
+	relPath := getString(params, "path", "")
+	if relPath == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	absPath, err := s.entryPath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", relPath)
+		}
+		return nil, err
+	}
+
+	if info.IsDir() && getBool(params, "recursive", false) {
+		err = os.RemoveAll(absPath)
+	} else {
+		err = os.Remove(absPath)
+	}
+	if err != nil {
+		if info.IsDir() {
+			return nil, fmt.Errorf("failed to delete directory (set recursive to delete its contents): %w", err)
+		}
+		return nil, fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return map[string]any{
+		"path":      absPath,
+		"directory": info.IsDir(),
+	}, nil
+}
+
+// --- Tool 22: lightshell_move_file ---
+
+func (s *Server) registerMoveFile() {
+	s.registerTool(Tool{
+		Name:        "lightshell_move_file",
+		Description: "Move or rename a file or directory within the LightShell project. Paths are relative to the project root. Creates the destination's parent directories automatically.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"from": map[string]any{
+					"type":        "string",
+					"description": "Current path relative to project root",
+				},
+				"to": map[string]any{
+					"type":        "string",
+					"description": "New path relative to project root",
+				},
+				"overwrite": map[string]any{
+					"type":        "boolean",
+					"description": "Replace an existing file at the destination (default false)",
+				},
+			},
+			"required": []string{"from", "to"},
+		},
+		Handler: s.handleMoveFile,
+	})
+}
+
+func (s *Server) handleMoveFile(params map[string]any) (any, error) {
+	from := getString(params, "from", "")
+	to := getString(params, "to", "")
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("from and to are required")
+	}
+
+	fromPath, err := s.entryPath(from)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(fromPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", from)
+		}
+		return nil, err
+	}
+	toPath, err := s.entryPath(to)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(toPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directories: %w", err)
+	}
+	// Resolve again now that the parent exists
+	if toPath, err = s.entryPath(to); err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Lstat(toPath); err == nil {
+		if info.IsDir() || !getBool(params, "overwrite", false) {
+			return nil, fmt.Errorf("destination already exists: %s", to)
+		}
+	}
+	if err := os.Rename(fromPath, toPath); err != nil {
+		return nil, fmt.Errorf("failed to move file: %w", err)
+	}
+
+	return map[string]any{
+		"from": fromPath,
+		"to":   toPath,
+	}, nil
+}
+
+// --- Tool 23: lightshell_edit_file ---
+
+func (s *Server) registerEditFile() {
+	s.registerTool(Tool{
+		Name:        "lightshell_edit_file",
+		Description: "Edit a file in the LightShell project without resending it whole: either replace exact text (edits) or apply a unified diff (patch). All changes apply or none do. Path is relative to the project root.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "File path relative to project root (e.g. 'src/app.js')",
+				},
+				"edits": map[string]any{
+					"type":        "array",
+					"description": "Replacements applied in order. oldText must occur exactly once unless replaceAll is set",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"oldText":    map[string]any{"type": "string"},
+							"newText":    map[string]any{"type": "string"},
+							"replaceAll": map[string]any{"type": "boolean"},
+						},
+						"required": []string{"oldText", "newText"},
+					},
+				},
+				"patch": map[string]any{
+					"type":        "string",
+					"description": "A unified diff of this file (@@ hunks; ---/+++ headers optional). Hunks may be off by some lines but their context must match exactly",
+				},
+				"backup": map[string]any{
+					"type":        "boolean",
+					"description": "Keep the previous content as <path>.bak (default false)",
+				},
+			},
+			"required": []string{"path"},
+		},
+		Handler: s.handleEditFile,
+	})
+}
+
+func (s *Server) handleEditFile(params map[string]any) (any, error) {
+	relPath := getString(params, "path", "")
+	if relPath == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	patch := getString(params, "patch", "")
+	rawEdits, _ := params["edits"].([]any)
+	if (patch == "") == (len(rawEdits) == 0) {
+		return nil, fmt.Errorf("pass either edits or patch")
+	}
+
+	absPath, err := s.safePath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", relPath)
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var content string
+	result := map[string]any{"path": absPath}
+	if patch != "" {
+		var hunks int
+		content, hunks, err = applyUnifiedDiff(string(data), patch)
+		result["hunks"] = hunks
+	} else {
+		edits := make([]textEdit, 0, len(rawEdits))
+		for i, raw := range rawEdits {
+			e, ok := raw.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("edit %d must be an object", i+1)
 			}
+			edits = append(edits, textEdit{
+				OldText:    getString(e, "oldText", ""),
+				NewText:    getString(e, "newText", ""),
+				ReplaceAll: getBool(e, "replaceAll", false),
+			})
+		}
+		var n int
+		content, n, err = applyTextEdits(string(data), edits)
+		result["replacements"] = n
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backupPath, err := writeFile(absPath, []byte(content), getBool(params, "backup", false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	if backupPath != "" {
+		result["backup"] = backupPath
+	}
+	result["size"] = len(content)
+	return result, nil
+}
+
+// --- Tool 24: lightshell_stat ---
+
+func (s *Server) registerStat() {
+	s.registerTool(Tool{
+		Name:        "lightshell_stat",
+		Description: "Get information about a file or directory in the LightShell project without reading it: size, modification time, whether it is binary, its MIME type, and for text files the line count. Use it to plan ranged reads of large files with lightshell_read_file.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "Path relative to project root (e.g. 'src/assets/logo.png')",
+				},
+			},
+			"required": []string{"path"},
+		},
+		Handler: s.handleStat,
+	})
+}
+
+func (s *Server) handleStat(params map[string]any) (any, error) {
+	relPath := getString(params, "path", "")
+	if relPath == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	absPath, err := s.safePath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", relPath)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{
+		"path":    absPath,
+		"isDir":   info.IsDir(),
+		"size":    info.Size(),
+		"modTime": info.ModTime().UTC().Format(time.RFC3339),
+		"mode":    info.Mode().Perm().String(),
+	}
+	if info.IsDir() {
+		return result, nil
+	}
+
+	sample, err := sniff(f)
+	if err != nil {
+		return nil, err
+	}
+	binary := isBinary(sample)
+	result["binary"] = binary
+	result["mimeType"] = http.DetectContentType(sample)
+	if !binary && info.Size() <= maxLineCountBytes {
+		if lines, err := countLines(f); err == nil {
+			result["lines"] = lines
 		}
 	}
+	return result, nil
+}
+
+// --- Tool 25: lightshell_get_network ---
 
-	if packages == nil {
-		packages = []map[string]any{}
+func (s *Server) registerGetNetwork() {
+	s.registerTool(Tool{
+		Name:        "lightshell_get_network",
+		Description: "Get recent fetch and XMLHttpRequest activity from the running LightShell app: each request's URL, method, status (0 when it failed, with the error), duration in ms, and response size when known. Requests made with lightshell.http run in Go and are not listed. The dev server must be running.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": "Number of most recent requests to return (default 50, max 500)",
+				},
+				"filter": map[string]any{
+					"type":        "string",
+					"description": "Only requests whose URL contains this text",
+				},
+				"clear": map[string]any{
+					"type":        "boolean",
+					"description": "Clear the recorded requests after reading",
+				},
+			},
+		},
+		Handler: s.handleGetNetwork,
+	})
+}
+
+func (s *Server) handleGetNetwork(params map[string]any) (any, error) {
+	if err := s.requireDevRunning(); err != nil {
+		return nil, err
+	}
+
+	limit := getInt(params, "limit", 50)
+	if limit > 500 {
+		limit = 500
+	}
+	if limit < 1 {
+		limit = 50
+	}
+
+	resp, err := s.devProcess.SendCommand(MCPCommand{
+		Cmd:    "network",
+		Limit:  limit,
+		Filter: getString(params, "filter", ""),
+		Clear:  getBool(params, "clear", false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network activity: %w", err)
+	}
+
+	requests := resp.Requests
+	if requests == nil {
+		requests = []NetworkEntry{}
 	}
 
 	return map[string]any{
-		"target":   target,
-		"signed":   sign,
-		"packages": packages,
-		"output":   outputStr,
+		"requests": requests,
+		"count":    len(requests),
 	}, nil
 }
+
+// --- Tool 26: lightshell_open_project ---
+
+func (s *Server) registerOpenProject() {
+	s.registerTool(Tool{
+		Name:        "lightshell_open_project",
+		Description: "Make an existing LightShell project the active one, which the file, config, build, and dev tools then act on. The previous project stays open, and its dev server keeps running, so you can switch back and forth between several apps in one session.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "Project directory containing lightshell.json: absolute, or relative to the active project's parent directory",
+				},
+			},
+			"required": []string{"path"},
+		},
+		Handler: s.handleOpenProject,
+	})
+}
+
+func (s *Server) handleOpenProject(params map[string]any) (any, error) {
+	path := getString(params, "path", "")
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(s.getProjectDir()), path)
+	}
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "lightshell.json")); err != nil {
+		return nil, fmt.Errorf("no lightshell.json found in %s — not a LightShell project", dir)
+	}
+
+	previous := s.switchProject(dir)
+	result := map[string]any{
+		"projectPath":     dir,
+		"previousProject": previous,
+		"devRunning":      s.devProcess.IsRunning(),
+	}
+	if warning := versionWarning(dir); warning != "" {
+		result["versionWarning"] = warning
+	}
+	return result, nil
+}
+
+// --- Tool 27: lightshell_list_projects ---
+
+func (s *Server) registerListProjects() {
+	s.registerTool(Tool{
+		Name:        "lightshell_list_projects",
+		Description: "List the projects opened in this session, with which one is active and whose dev servers are running.",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+		Handler: s.handleListProjects,
+	})
+}
+
+func (s *Server) handleListProjects(params map[string]any) (any, error) {
+	return map[string]any{
+		"projects": s.listProjects(),
+	}, nil
+}
+
+// --- Tool 28: lightshell_list_windows ---
+
+func (s *Server) registerListWindows() {
+	s.registerTool(Tool{
+		Name:        "lightshell_list_windows",
+		Description: "List the running LightShell app's open windows, including those opened with lightshell.window.create, with each one's id, title, and size. Pass an id as windowId to lightshell_screenshot, lightshell_get_dom, or lightshell_execute_js to inspect a secondary window or modal. The dev server must be running.",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+		Handler: s.handleListWindows,
+	})
+}
+
+func (s *Server) handleListWindows(params map[string]any) (any, error) {
+	if err := s.requireDevRunning(); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.devProcess.SendCommand(MCPCommand{Cmd: "windows"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows: %w", err)
+	}
+
+	windows := resp.Windows
+	if windows == nil {
+		windows = []WindowInfo{}
+	}
+	return map[string]any{
+		"windows": windows,
+		"count":   len(windows),
+	}, nil
+}
+
+// --- Tool 29: lightshell_export_page ---
+
+func (s *Server) registerExportPage() {
+	s.registerTool(Tool{
+		Name:        "lightshell_export_page",
+		Description: "Save a bug report of the running LightShell app's page: a zip in .lightshell/reports with page.html (the DOM with its stylesheets inlined, form values kept, and scripts removed, so it renders in a browser), screenshot.png, console.log (the console tail), and report.json (URL, window size, user agent, app and LightShell versions). Returns the zip's path, for attaching to an issue. The dev server must be running.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"consoleLines": map[string]any{
+					"type":        "integer",
+					"description": "Console entries to include, newest last (default and max: 200)",
+				},
+				"windowId": map[string]any{
+					"type":        "integer",
+					"description": "Window to capture, from lightshell_list_windows (default: the main window)",
+				},
+			},
+		},
+		Handler: s.handleExportPage,
+	})
+}
+
+func (s *Server) handleExportPage(params map[string]any) (any, error) {
+	if err := s.requireDevRunning(); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.devProcess.SendCommand(MCPCommand{
+		Cmd:      "export",
+		Lines:    getInt(params, "consoleLines", 0),
+		WindowID: getInt(params, "windowId", 0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export page: %w", err)
+	}
+
+	var report map[string]any
+	if err := json.Unmarshal(resp.Result, &report); err != nil {
+		return nil, fmt.Errorf("invalid export result: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,237 @@
+// Package metrics collects lightweight in-process counters and timers for
+// LightShell APIs (IPC calls, fs bytes, http requests, the worker pool) and
+// exports them in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric names recorded by the LightShell runtime.
+const (
+	IPCCalls       = "lightshell_ipc_calls_total"
+	IPCDuration    = "lightshell_ipc_call_duration_seconds"
+	FSReadBytes    = "lightshell_fs_read_bytes_total"
+	FSWrittenBytes = "lightshell_fs_written_bytes_total"
+	HTTPRequests   = "lightshell_http_requests_total"
+	HTTPDuration   = "lightshell_http_request_duration_seconds"
+	WorkerQueued   = "lightshell_worker_queued"
+	WorkerRunning  = "lightshell_worker_running"
+	WorkerRejected = "lightshell_worker_rejected_total"
+	WorkerWait     = "lightshell_worker_wait_seconds"
+)
+
+// help describes the known metrics for the Prometheus # HELP lines.
+var help = map[string]string{
+	IPCCalls:       "IPC calls from JavaScript, by method and status.",
+	IPCDuration:    "Time spent in IPC handlers, by method.",
+	FSReadBytes:    "Bytes read by lightshell.fs.",
+	FSWrittenBytes: "Bytes written by lightshell.fs.",
+	HTTPRequests:   "Requests made by lightshell.http, by method and status.",
+	HTTPDuration:   "Duration of lightshell.http requests.",
+	WorkerQueued:   "IPC calls waiting for a worker, by namespace.",
+	WorkerRunning:  "IPC calls running on the worker pool, by namespace.",
+	WorkerRejected: "IPC calls rejected because the worker queue was full, by namespace.",
+	WorkerWait:     "Time IPC calls waited for a worker, by namespace.",
+}
+
+// Labels are the label pairs of one series.
+type Labels map[string]string
+
+// Sample is one series value in a snapshot. Timers produce two samples per
+// series, with "_count" and "_sum" suffixes, as a Prometheus summary does.
+type Sample struct {
+	Name   string  `json:"name"`
+	Labels Labels  `json:"labels,omitempty"`
+	Value  float64 `json:"value"`
+}
+
+type series struct {
+	name   string
+	labels Labels
+	value  float64 // counters: total; timers: sum of seconds
+	count  uint64  // timers only
+}
+
+// Registry holds counters, gauges, and timers. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	counters map[string]*series
+	gauges   map[string]*series
+	timers   map[string]*series
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[string]*series),
+		gauges:   make(map[string]*series),
+		timers:   make(map[string]*series),
+	}
+}
+
+// Default is the process-wide registry used by the API handlers.
+var Default = NewRegistry()
+
+// Add increments a counter on the default registry.
+func Add(name string, labels Labels, v float64) { Default.Add(name, labels, v) }
+
+// Set sets a gauge on the default registry.
+func Set(name string, labels Labels, v float64) { Default.Set(name, labels, v) }
+
+// Observe records a duration on the default registry.
+func Observe(name string, labels Labels, d time.Duration) { Default.Observe(name, labels, d) }
+
+// Add increments the counter series identified by name and labels.
+func (r *Registry) Add(name string, labels Labels, v float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := lookup(r.counters, name, labels)
+	s.value += v
+}
+
+// Set sets the gauge series identified by name and labels to v.
+func (r *Registry) Set(name string, labels Labels, v float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := lookup(r.gauges, name, labels)
+	s.value = v
+}
+
+// Observe records one duration in the timer series identified by name and labels.
+func (r *Registry) Observe(name string, labels Labels, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := lookup(r.timers, name, labels)
+	s.value += d.Seconds()
+	s.count++
+}
+
+// Reset clears all series.
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters = make(map[string]*series)
+	r.gauges = make(map[string]*series)
+	r.timers = make(map[string]*series)
+}
+
+// Snapshot returns all series sorted by name, then labels.
+func (r *Registry) Snapshot() []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var samples []Sample
+	for _, m := range []map[string]*series{r.counters, r.gauges} {
+		for _, s := range m {
+			samples = append(samples, Sample{Name: s.name, Labels: copyLabels(s.labels), Value: s.value})
+		}
+	}
+	for _, s := range r.timers {
+		samples = append(samples,
+			Sample{Name: s.name + "_count", Labels: copyLabels(s.labels), Value: float64(s.count)},
+			Sample{Name: s.name + "_sum", Labels: copyLabels(s.labels), Value: s.value},
+		)
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Name != samples[j].Name {
+			return samples[i].Name < samples[j].Name
+		}
+		return labelKey(samples[i].Labels) < labelKey(samples[j].Labels)
+	})
+	return samples
+}
+
+// WritePrometheus writes all series in the Prometheus text exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	kinds := make(map[string]string)
+	for _, s := range r.counters {
+		kinds[s.name] = "counter"
+	}
+	for _, s := range r.gauges {
+		kinds[s.name] = "gauge"
+	}
+	for _, s := range r.timers {
+		kinds[s.name] = "summary"
+	}
+	r.mu.Unlock()
+
+	byBase := make(map[string][]Sample)
+	for _, s := range r.Snapshot() {
+		base := s.Name
+		if _, ok := kinds[base]; !ok {
+			base = strings.TrimSuffix(strings.TrimSuffix(base, "_count"), "_sum")
+		}
+		byBase[base] = append(byBase[base], s)
+	}
+
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if h, ok := help[name]; ok {
+			fmt.Fprintf(w, "# HELP %s %s\n", name, h)
+		}
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, kinds[name])
+		for _, s := range byBase[name] {
+			if _, err := fmt.Fprintf(w, "%s%s %g\n", s.Name, formatLabels(s.Labels), s.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookup returns the series for name+labels, creating it if needed.
+func lookup(m map[string]*series, name string, labels Labels) *series {
+	key := name + labelKey(labels)
+	s, ok := m[key]
+	if !ok {
+		s = &series{name: name, labels: copyLabels(labels)}
+		m[key] = s
+	}
+	return s
+}
+
+// labelKey returns a stable string for a label set.
+func labelKey(labels Labels) string {
+	return formatLabels(labels)
+}
+
+// formatLabels renders labels as {a="1",b="2"} with sorted keys.
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[k])
+		parts[i] = k + `="` + v + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func copyLabels(labels Labels) Labels {
+	if len(labels) == 0 {
+		return nil
+	}
+	out := make(Labels, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
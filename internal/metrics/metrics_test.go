@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCounters(t *testing.T) {
+	r := NewRegistry()
+	r.Add(IPCCalls, Labels{"method": "fs.readFile", "status": "ok"}, 1)
+	r.Add(IPCCalls, Labels{"status": "ok", "method": "fs.readFile"}, 1)
+	r.Add(IPCCalls, Labels{"method": "fs.readFile", "status": "error"}, 1)
+	r.Add(FSReadBytes, nil, 512)
+
+	samples := r.Snapshot()
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d: %+v", len(samples), samples)
+	}
+	if samples[0].Name != FSReadBytes || samples[0].Value != 512 {
+		t.Errorf("unexpected first sample: %+v", samples[0])
+	}
+	if samples[2].Labels["status"] != "ok" || samples[2].Value != 2 {
+		t.Errorf("expected label order not to split series, got %+v", samples[2])
+	}
+}
+
+func TestTimers(t *testing.T) {
+	r := NewRegistry()
+	r.Observe(IPCDuration, Labels{"method": "app.quit"}, 250*time.Millisecond)
+	r.Observe(IPCDuration, Labels{"method": "app.quit"}, 750*time.Millisecond)
+
+	samples := r.Snapshot()
+	if len(samples) != 2 {
+		t.Fatalf("expected count and sum samples, got %+v", samples)
+	}
+	if samples[0].Name != IPCDuration+"_count" || samples[0].Value != 2 {
+		t.Errorf("unexpected count sample: %+v", samples[0])
+	}
+	if samples[1].Name != IPCDuration+"_sum" || samples[1].Value != 1 {
+		t.Errorf("unexpected sum sample: %+v", samples[1])
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.Add(IPCCalls, Labels{"method": "fs.readFile", "status": "ok"}, 3)
+	r.Observe(IPCDuration, Labels{"method": "fs.readFile"}, time.Second)
+
+	var buf bytes.Buffer
+	if err := r.WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE lightshell_ipc_calls_total counter\n",
+		`lightshell_ipc_calls_total{method="fs.readFile",status="ok"} 3` + "\n",
+		"# TYPE lightshell_ipc_call_duration_seconds summary\n",
+		`lightshell_ipc_call_duration_seconds_count{method="fs.readFile"} 1` + "\n",
+		`lightshell_ipc_call_duration_seconds_sum{method="fs.readFile"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	got := formatLabels(Labels{"cmd": `say "hi"`})
+	want := `{cmd="say \"hi\""}`
+	if got != want {
+		t.Errorf("formatLabels() = %s, want %s", got, want)
+	}
+}
+
+func TestGauges(t *testing.T) {
+	r := NewRegistry()
+	r.Set(WorkerQueued, Labels{"namespace": "image"}, 3)
+	r.Set(WorkerQueued, Labels{"namespace": "image"}, 1)
+
+	samples := r.Snapshot()
+	if len(samples) != 1 || samples[0].Value != 1 {
+		t.Fatalf("expected gauge to hold the last value, got %+v", samples)
+	}
+
+	var buf bytes.Buffer
+	r.WritePrometheus(&buf)
+	if !strings.Contains(buf.String(), "# TYPE lightshell_worker_queued gauge\n") {
+		t.Errorf("expected gauge type line, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,78 @@
+// Package paths resolves per-app data, config, cache, and log directories
+// following each platform's conventions: Apple's Library folders on macOS,
+// the XDG Base Directory spec on Linux, and Known Folders on Windows. It
+// also places LightShell's Unix sockets in per-user directories.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+)
+
+// Dirs holds the standard directories for one app.
+type Dirs struct {
+	Data   string `json:"data"`
+	Config string `json:"config"`
+	Cache  string `json:"cache"`
+	Log    string `json:"log"`
+}
+
+// For returns the directories for appName on the current platform.
+// The directories are not created.
+func For(appName string) Dirs {
+	home, _ := os.UserHomeDir()
+	return resolve(goruntime.GOOS, appName, home, os.Getenv)
+}
+
+// DataDir returns the directory for persistent app data.
+func DataDir(appName string) string { return For(appName).Data }
+
+// ConfigDir returns the directory for app configuration and preferences.
+func ConfigDir(appName string) string { return For(appName).Config }
+
+// CacheDir returns the directory for regenerable cached data.
+func CacheDir(appName string) string { return For(appName).Cache }
+
+// LogDir returns the directory for app log files.
+func LogDir(appName string) string { return For(appName).Log }
+
+// resolve computes the directories for a given OS, home directory, and
+// environment lookup. It is separate from For so every platform can be tested.
+func resolve(goos, appName, home string, getenv func(string) string) Dirs {
+	// envOr returns the env var if it is set to an absolute path, else the fallback.
+	// XDG requires relative paths to be ignored.
+	envOr := func(key, fallback string) string {
+		if v := getenv(key); v != "" && filepath.IsAbs(v) {
+			return v
+		}
+		return fallback
+	}
+
+	switch goos {
+	case "darwin":
+		lib := filepath.Join(home, "Library")
+		return Dirs{
+			Data:   filepath.Join(lib, "Application Support", appName),
+			Config: filepath.Join(lib, "Application Support", appName),
+			Cache:  filepath.Join(lib, "Caches", appName),
+			Log:    filepath.Join(lib, "Logs", appName),
+		}
+	case "windows":
+		roaming := envOr("APPDATA", filepath.Join(home, "AppData", "Roaming"))
+		local := envOr("LOCALAPPDATA", filepath.Join(home, "AppData", "Local"))
+		return Dirs{
+			Data:   filepath.Join(roaming, appName),
+			Config: filepath.Join(roaming, appName),
+			Cache:  filepath.Join(local, appName, "Cache"),
+			Log:    filepath.Join(local, appName, "Logs"),
+		}
+	default:
+		return Dirs{
+			Data:   filepath.Join(envOr("XDG_DATA_HOME", filepath.Join(home, ".local", "share")), appName),
+			Config: filepath.Join(envOr("XDG_CONFIG_HOME", filepath.Join(home, ".config")), appName),
+			Cache:  filepath.Join(envOr("XDG_CACHE_HOME", filepath.Join(home, ".cache")), appName),
+			Log:    filepath.Join(envOr("XDG_STATE_HOME", filepath.Join(home, ".local", "state")), appName, "logs"),
+		}
+	}
+}
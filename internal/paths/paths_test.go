@@ -0,0 +1,81 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	noEnv := func(string) string { return "" }
+	home := filepath.FromSlash("/home/u")
+	j := filepath.Join
+
+	tests := []struct {
+		name   string
+		goos   string
+		getenv func(string) string
+		want   Dirs
+	}{
+		{
+			name:   "darwin",
+			goos:   "darwin",
+			getenv: noEnv,
+			want: Dirs{
+				Data:   j(home, "Library", "Application Support", "myapp"),
+				Config: j(home, "Library", "Application Support", "myapp"),
+				Cache:  j(home, "Library", "Caches", "myapp"),
+				Log:    j(home, "Library", "Logs", "myapp"),
+			},
+		},
+		{
+			name:   "linux defaults",
+			goos:   "linux",
+			getenv: noEnv,
+			want: Dirs{
+				Data:   j(home, ".local", "share", "myapp"),
+				Config: j(home, ".config", "myapp"),
+				Cache:  j(home, ".cache", "myapp"),
+				Log:    j(home, ".local", "state", "myapp", "logs"),
+			},
+		},
+		{
+			name: "linux XDG overrides",
+			goos: "linux",
+			getenv: func(k string) string {
+				switch k {
+				case "XDG_DATA_HOME":
+					return filepath.FromSlash("/xdg/data")
+				case "XDG_CACHE_HOME":
+					return "relative/ignored"
+				}
+				return ""
+			},
+			want: Dirs{
+				Data:   j(filepath.FromSlash("/xdg/data"), "myapp"),
+				Config: j(home, ".config", "myapp"),
+				Cache:  j(home, ".cache", "myapp"),
+				Log:    j(home, ".local", "state", "myapp", "logs"),
+			},
+		},
+		{
+			name:   "windows defaults",
+			goos:   "windows",
+			getenv: noEnv,
+			want: Dirs{
+				Data:   j(home, "AppData", "Roaming", "myapp"),
+				Config: j(home, "AppData", "Roaming", "myapp"),
+				Cache:  j(home, "AppData", "Local", "myapp", "Cache"),
+				Log:    j(home, "AppData", "Local", "myapp", "Logs"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolve(tt.goos, "myapp", home, tt.getenv)
+			if got != tt.want {
+				t.Errorf("resolve() =\n  %+v\nwant\n  %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+	"time"
+)
+
+// maxSocketPath is the longest Unix socket path that works everywhere:
+// sun_path holds 104 bytes on macOS and the BSDs (108 on Linux and
+// Windows), including the terminating NUL.
+const maxSocketPath = 103
+
+// staleSocketAge keeps RemoveStaleSockets away from a socket another
+// process has bound but not yet started listening on.
+const staleSocketAge = 10 * time.Second
+
+// SocketPath returns where to create the Unix socket name, in a directory
+// only the current user can enter: lightshell under $XDG_RUNTIME_DIR when
+// it is set, else a lightshell-<uid> directory made with mode 0700 in the
+// temp directory. A directory that would make the path longer than the
+// platform allows is passed over; an error means none fits.
+func SocketPath(name string) (string, error) {
+	var errs []error
+	for _, dir := range socketDirs(goruntime.GOOS, os.Getuid(), os.TempDir(), os.Getenv) {
+		path := filepath.Join(dir, name)
+		if err := checkSocketPath(path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := privateDir(dir); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return path, nil
+	}
+	return "", fmt.Errorf("no usable directory for socket %s: %w", name, errors.Join(errs...))
+}
+
+// RemoveStaleSockets deletes sockets matching pattern (a filepath.Match
+// pattern such as "mcp-*.sock") that nothing listens on any more, as left
+// behind by a process that crashed.
+func RemoveStaleSockets(pattern string) {
+	for _, dir := range socketDirs(goruntime.GOOS, os.Getuid(), os.TempDir(), os.Getenv) {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, path := range matches {
+			info, err := os.Lstat(path)
+			if err != nil || info.Mode().Type() != os.ModeSocket || time.Since(info.ModTime()) < staleSocketAge {
+				continue
+			}
+			conn, err := net.DialTimeout("unix", path, time.Second)
+			if err == nil {
+				conn.Close()
+				continue
+			}
+			os.Remove(path)
+		}
+	}
+}
+
+// socketDirs lists the candidate socket directories in order of preference.
+// It is separate from SocketPath so every platform can be tested.
+func socketDirs(goos string, uid int, tmp string, getenv func(string) string) []string {
+	// Windows has no uid, and its temp directory is already per user
+	if goos == "windows" {
+		return []string{filepath.Join(tmp, "lightshell")}
+	}
+	var dirs []string
+	if v := getenv("XDG_RUNTIME_DIR"); v != "" && filepath.IsAbs(v) {
+		dirs = append(dirs, filepath.Join(v, "lightshell"))
+	}
+	user := "lightshell-" + strconv.Itoa(uid)
+	dirs = append(dirs, filepath.Join(tmp, user))
+	// macOS's per-user $TMPDIR is long enough to crowd out a socket name
+	if tmp != "/tmp" {
+		dirs = append(dirs, filepath.Join("/tmp", user))
+	}
+	return dirs
+}
+
+func checkSocketPath(path string) error {
+	if len(path) > maxSocketPath {
+		return fmt.Errorf("socket path %s is %d bytes, over the %d-byte limit", path, len(path), maxSocketPath)
+	}
+	return nil
+}
+
+// privateDir creates dir with mode 0700, or makes sure the existing one is
+// a directory of the current user's and closed to everyone else, so no one
+// else can plant or intercept sockets in it.
+func privateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	// Fails if another user created the directory
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return fmt.Errorf("%s is not owned by this user: %w", dir, err)
+	}
+	return nil
+}
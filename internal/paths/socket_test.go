@@ -0,0 +1,119 @@
+package paths
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSocketDirs(t *testing.T) {
+	noEnv := func(string) string { return "" }
+	runtimeEnv := func(k string) string {
+		if k == "XDG_RUNTIME_DIR" {
+			return "/run/user/1000"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name   string
+		goos   string
+		tmp    string
+		getenv func(string) string
+		want   []string
+	}{
+		{"linux runtime dir", "linux", "/tmp", runtimeEnv, []string{"/run/user/1000/lightshell", "/tmp/lightshell-1000"}},
+		{"linux without runtime dir", "linux", "/tmp", noEnv, []string{"/tmp/lightshell-1000"}},
+		{"relative runtime dir ignored", "linux", "/tmp", func(string) string { return "run" }, []string{"/tmp/lightshell-1000"}},
+		{"darwin TMPDIR", "darwin", "/var/folders/ab/xyz/T", noEnv, []string{"/var/folders/ab/xyz/T/lightshell-1000", "/tmp/lightshell-1000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := socketDirs(tt.goos, 1000, tt.tmp, tt.getenv)
+			for i := range tt.want {
+				tt.want[i] = filepath.FromSlash(tt.want[i])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("socketDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("per-user directories are checked on Unix")
+	}
+	runtimeDir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	path, err := SocketPath("mcp-1-abc.sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(runtimeDir, "lightshell", "mcp-1-abc.sock"); path != want {
+		t.Errorf("SocketPath() = %s, want %s", path, want)
+	}
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Errorf("socket directory mode = %o, want 700", perm)
+	}
+
+	if _, err := SocketPath(strings.Repeat("x", maxSocketPath) + ".sock"); err == nil {
+		t.Error("SocketPath() accepted a name over the length limit")
+	}
+}
+
+func TestRemoveStaleSockets(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("Unix sockets are checked on Unix")
+	}
+	// A short base keeps socket paths under the limit
+	runtimeDir, err := os.MkdirTemp("/tmp", "ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(runtimeDir)
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	listen := func(name string) (string, net.Listener) {
+		path, err := SocketPath(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		old := time.Now().Add(-time.Minute)
+		os.Chtimes(path, old, old)
+		return path, l
+	}
+	live, l := listen("mcp-live.sock")
+	defer l.Close()
+	stale, l2 := listen("mcp-stale.sock")
+	l2.Close()
+	other, l3 := listen("dev-stale.sock")
+	l3.Close()
+
+	RemoveStaleSockets("mcp-*.sock")
+
+	if _, err := os.Stat(live); err != nil {
+		t.Errorf("live socket removed: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale socket kept: %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("socket not matching the pattern removed: %v", err)
+	}
+}
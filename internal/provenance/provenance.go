@@ -0,0 +1,172 @@
+// Package provenance records how a build artifact was made, as an in-toto
+// statement with an SLSA v1 provenance predicate, signed in a DSSE
+// envelope. The format is the one SLSA tooling reads, so
+// `slsa-verifier`-style tools and plain scripts can both audit it.
+package provenance
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// StatementType is the in-toto statement version.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PredicateType is the SLSA provenance version.
+	PredicateType = "https://slsa.dev/provenance/v1"
+	// BuildType identifies `lightshell build` as what made the artifact.
+	BuildType = "https://lightshell.dev/provenance/build/v1"
+	// PayloadType is the DSSE payload type of an in-toto statement.
+	PayloadType = "application/vnd.in-toto+json"
+)
+
+// ErrUnsigned is returned by Verify for an envelope without signatures.
+var ErrUnsigned = errors.New("provenance is not signed")
+
+// Digest maps an algorithm ("sha256", "gitCommit") to a hex digest.
+type Digest map[string]string
+
+// ResourceDescriptor names an artifact or input and its digest.
+type ResourceDescriptor struct {
+	Name   string `json:"name,omitempty"`
+	URI    string `json:"uri,omitempty"`
+	Digest Digest `json:"digest"`
+}
+
+// Statement is an in-toto statement about the subjects it lists.
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     Predicate            `json:"predicate"`
+}
+
+// Predicate is SLSA v1 provenance.
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition describes what was built: the project's parameters, the
+// toolchain, and the inputs.
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]any       `json:"externalParameters"`
+	InternalParameters   map[string]any       `json:"internalParameters,omitempty"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// RunDetails describes who built it and when.
+type RunDetails struct {
+	Builder  Builder       `json:"builder"`
+	Metadata BuildMetadata `json:"metadata"`
+}
+
+// Builder identifies the machine or CI system that ran the build.
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// BuildMetadata holds when the build ran.
+type BuildMetadata struct {
+	InvocationID string    `json:"invocationId,omitempty"`
+	StartedOn    time.Time `json:"startedOn"`
+	FinishedOn   time.Time `json:"finishedOn"`
+}
+
+// Envelope is a DSSE envelope around a statement.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"` // base64 of the statement's JSON
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is one signature over an envelope's payload.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// pae is DSSE's pre-authentication encoding, what the signature covers.
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// KeyID identifies a public key in envelopes: the base64 key itself, as
+// lightshell keys prints it and lightshell.json's updater.publicKey holds it.
+func KeyID(pub ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(pub)
+}
+
+// Seal wraps stmt in an envelope, signed with priv when it is not nil.
+func Seal(stmt Statement, priv ed25519.PrivateKey) (Envelope, error) {
+	payload, err := json.Marshal(stmt)
+	if err != nil {
+		return Envelope{}, err
+	}
+	env := Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{},
+	}
+	if priv != nil {
+		env.Signatures = append(env.Signatures, Signature{
+			KeyID: KeyID(priv.Public().(ed25519.PublicKey)),
+			Sig:   base64.StdEncoding.EncodeToString(ed25519.Sign(priv, pae(PayloadType, payload))),
+		})
+	}
+	return env, nil
+}
+
+// Open returns the statement in env without checking its signatures.
+func Open(env Envelope) (*Statement, error) {
+	if env.PayloadType != PayloadType {
+		return nil, fmt.Errorf("unexpected payload type %q", env.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	var stmt Statement
+	if err := json.Unmarshal(payload, &stmt); err != nil {
+		return nil, fmt.Errorf("invalid statement: %w", err)
+	}
+	if stmt.Type != StatementType || stmt.PredicateType != PredicateType {
+		return nil, fmt.Errorf("not SLSA provenance (%s, %s)", stmt.Type, stmt.PredicateType)
+	}
+	return &stmt, nil
+}
+
+// Verify returns the statement in env if pub signed it.
+func Verify(env Envelope, pub ed25519.PublicKey) (*Statement, error) {
+	if len(env.Signatures) == 0 {
+		return nil, ErrUnsigned
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	signed := pae(env.PayloadType, payload)
+	for _, s := range env.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err == nil && ed25519.Verify(pub, signed, sig) {
+			return Open(env)
+		}
+	}
+	return nil, errors.New("provenance signature does not match the signing key")
+}
+
+// SubjectDigest returns the sha256 digest the statement records for name.
+func (s *Statement) SubjectDigest(name string) (string, bool) {
+	for _, sub := range s.Subject {
+		if sub.Name == name {
+			d, ok := sub.Digest["sha256"]
+			return d, ok
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,98 @@
+package provenance
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func testStatement() Statement {
+	return Statement{
+		Type:          StatementType,
+		Subject:       []ResourceDescriptor{{Name: "Notes.app.tar", Digest: Digest{"sha256": "abc123"}}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{
+				BuildType:          BuildType,
+				ExternalParameters: map[string]any{"version": "1.2.0"},
+			},
+			RunDetails: RunDetails{
+				Builder:  Builder{ID: "https://github.com/acme/notes/actions/runs/1"},
+				Metadata: BuildMetadata{StartedOn: time.Unix(0, 0).UTC(), FinishedOn: time.Unix(60, 0).UTC()},
+			},
+		},
+	}
+}
+
+func TestSealVerify(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	env, err := Seal(testStatement(), priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env.Signatures) != 1 || env.Signatures[0].KeyID != KeyID(pub) {
+		t.Fatalf("signatures = %+v", env.Signatures)
+	}
+	stmt, err := Verify(env, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := stmt.SubjectDigest("Notes.app.tar"); !ok || d != "abc123" {
+		t.Errorf("SubjectDigest = %q, %v", d, ok)
+	}
+	if _, ok := stmt.SubjectDigest("Other.app.tar"); ok {
+		t.Error("SubjectDigest found a subject that is not there")
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	if _, err := Verify(env, other); err == nil {
+		t.Error("Verify accepted another key")
+	}
+
+	// A changed payload no longer matches the signature
+	tampered := testStatement()
+	tampered.Subject[0].Digest["sha256"] = "def456"
+	forged, _ := Seal(tampered, nil)
+	forged.Signatures = env.Signatures
+	if _, err := Verify(forged, pub); err == nil {
+		t.Error("Verify accepted a tampered payload")
+	}
+}
+
+func TestVerifyUnsigned(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	env, err := Seal(testStatement(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(env, pub); err != ErrUnsigned {
+		t.Errorf("Verify = %v, want ErrUnsigned", err)
+	}
+	if _, err := Open(env); err != nil {
+		t.Errorf("Open = %v", err)
+	}
+}
+
+func TestPAE(t *testing.T) {
+	// The example from the DSSE protocol description
+	got := string(pae("http://example.com/HelloWorld", []byte("hello world")))
+	want := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"
+	if got != want {
+		t.Errorf("pae = %q, want %q", got, want)
+	}
+}
+
+func TestOpenRejectsOtherStatements(t *testing.T) {
+	stmt := testStatement()
+	stmt.PredicateType = "https://example.com/other"
+	env, _ := Seal(stmt, nil)
+	if _, err := Open(env); err == nil {
+		t.Error("Open accepted a non-provenance predicate")
+	}
+	env.PayloadType = "text/plain"
+	env.Payload = base64.StdEncoding.EncodeToString([]byte("x"))
+	if _, err := Open(env); err == nil {
+		t.Error("Open accepted another payload type")
+	}
+}
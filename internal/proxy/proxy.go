@@ -0,0 +1,299 @@
+// Package proxy resolves which HTTP proxy outgoing requests should use and
+// builds clients that honor it. Settings come from an explicit override
+// first, then the standard proxy environment variables, then the OS.
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sources of resolved settings.
+const (
+	SourceConfig      = "config"
+	SourceEnvironment = "environment"
+	SourceSystem      = "system"
+	SourceNone        = "none"
+)
+
+// Direct is the override value that disables proxying even when the
+// environment or OS configures one.
+const Direct = "direct"
+
+// Settings are the proxies to use for each scheme. An empty proxy means
+// connect directly.
+type Settings struct {
+	HTTP    string   `json:"http,omitempty"`
+	HTTPS   string   `json:"https,omitempty"`
+	NoProxy []string `json:"noProxy,omitempty"` // hosts, domain suffixes, IPs, or CIDRs to reach directly
+	Source  string   `json:"source"`
+}
+
+// Resolve returns the settings to use. A non-empty override URL applies to
+// both schemes (or disables proxying if it is Direct); noProxy entries are
+// added to whatever bypass list the chosen source has.
+func Resolve(override string, noProxy []string) Settings {
+	var s Settings
+	switch {
+	case strings.EqualFold(override, Direct):
+		s = Settings{Source: SourceConfig}
+	case override != "":
+		s = Settings{HTTP: override, HTTPS: override, Source: SourceConfig}
+	default:
+		s = fromEnvironment()
+		if s.Source == SourceNone {
+			s = systemSettings()
+		}
+	}
+	s.NoProxy = append(s.NoProxy, noProxy...)
+	return s
+}
+
+// Detect returns the environment or OS settings, ignoring any override.
+func Detect() Settings {
+	return Resolve("", nil)
+}
+
+func fromEnvironment() Settings {
+	s := Settings{
+		HTTP:    getenv("HTTP_PROXY"),
+		HTTPS:   getenv("HTTPS_PROXY"),
+		NoProxy: SplitList(getenv("NO_PROXY")),
+	}
+	if all := getenv("ALL_PROXY"); all != "" {
+		if s.HTTP == "" {
+			s.HTTP = all
+		}
+		if s.HTTPS == "" {
+			s.HTTPS = all
+		}
+	}
+	if s.HTTP == "" && s.HTTPS == "" {
+		return Settings{Source: SourceNone}
+	}
+	s.Source = SourceEnvironment
+	return s
+}
+
+// getenv reads an upper- or lowercase proxy variable, preferring uppercase.
+func getenv(name string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return os.Getenv(strings.ToLower(name))
+}
+
+// SplitList parses a comma- or whitespace-separated host list.
+func SplitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
+
+// Func returns a function for http.Transport.Proxy.
+func (s Settings) Func() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		return s.ProxyFor(req.URL)
+	}
+}
+
+// ProxyFor returns the proxy for target, or nil to connect directly.
+func (s Settings) ProxyFor(target *url.URL) (*url.URL, error) {
+	raw := s.HTTP
+	if target.Scheme == "https" {
+		raw = s.HTTPS
+	}
+	if raw == "" || s.bypass(target.Hostname()) {
+		return nil, nil
+	}
+	return parseProxyURL(raw)
+}
+
+// bypass reports whether host should be reached directly. Loopback
+// addresses are never proxied.
+func (s Settings) bypass(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+	for _, entry := range s.NoProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h // ports in the list are ignored
+		}
+		switch {
+		case entry == "":
+		case entry == "*":
+			return true
+		case entry == "<local>":
+			// Plain hostnames without a domain, as macOS "Exclude simple hostnames"
+			if ip == nil && !strings.Contains(host, ".") {
+				return true
+			}
+		case strings.Contains(entry, "/"):
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && ip != nil && cidr.Contains(ip) {
+				return true
+			}
+		case strings.HasPrefix(entry, "*."):
+			if strings.HasSuffix(host, entry[1:]) {
+				return true
+			}
+		case strings.HasPrefix(entry, "."):
+			if strings.HasSuffix(host, entry) || host == entry[1:] {
+				return true
+			}
+		default:
+			if host == entry || strings.HasSuffix(host, "."+entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseProxyURL accepts full URLs and bare host:port, which means http.
+func parseProxyURL(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	return url.Parse(raw)
+}
+
+// Redacted returns proxy with any password masked, for display.
+func Redacted(proxy string) string {
+	u, err := parseProxyURL(proxy)
+	if err != nil || proxy == "" {
+		return proxy
+	}
+	return u.Redacted()
+}
+
+// Client returns an http.Client that routes requests through s.
+func (s Settings) Client(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = s.Func()
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// parseScutil extracts proxy settings from `scutil --proxy` output.
+func parseScutil(out string) Settings {
+	values := make(map[string]string)
+	var exceptions []string
+	inExceptions := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		key, value, ok := strings.Cut(line, " : ")
+		switch {
+		case strings.HasPrefix(line, "ExceptionsList"):
+			inExceptions = true
+		case inExceptions && line == "}":
+			inExceptions = false
+		case inExceptions && ok:
+			exceptions = append(exceptions, value)
+		case ok:
+			values[key] = value
+		}
+	}
+
+	s := Settings{NoProxy: exceptions}
+	if values["HTTPEnable"] == "1" && values["HTTPProxy"] != "" {
+		s.HTTP = hostPort(values["HTTPProxy"], values["HTTPPort"])
+	}
+	if values["HTTPSEnable"] == "1" && values["HTTPSProxy"] != "" {
+		s.HTTPS = hostPort(values["HTTPSProxy"], values["HTTPSPort"])
+	}
+	if values["ExcludeSimpleHostnames"] == "1" {
+		s.NoProxy = append(s.NoProxy, "<local>")
+	}
+	if s.HTTP == "" && s.HTTPS == "" {
+		return Settings{Source: SourceNone}
+	}
+	s.Source = SourceSystem
+	return s
+}
+
+// parseGSettings extracts proxy settings from the GNOME proxy schema. get
+// returns the raw `gsettings get` output for a schema and key.
+func parseGSettings(get func(schema, key string) string) Settings {
+	if unquote(get("org.gnome.system.proxy", "mode")) != "manual" {
+		return Settings{Source: SourceNone}
+	}
+	proxyFor := func(schema string) string {
+		host := unquote(get(schema, "host"))
+		port := strings.TrimSpace(get(schema, "port"))
+		if host == "" || port == "" || port == "0" {
+			return ""
+		}
+		return hostPort(host, port)
+	}
+
+	s := Settings{
+		HTTP:  proxyFor("org.gnome.system.proxy.http"),
+		HTTPS: proxyFor("org.gnome.system.proxy.https"),
+	}
+	// ignore-hosts is a GVariant string array: ['localhost', '127.0.0.0/8']
+	list := strings.Trim(strings.TrimSpace(get("org.gnome.system.proxy", "ignore-hosts")), "[]")
+	for _, h := range strings.Split(list, ",") {
+		if h = unquote(h); h != "" {
+			s.NoProxy = append(s.NoProxy, h)
+		}
+	}
+	if s.HTTP == "" && s.HTTPS == "" {
+		return Settings{Source: SourceNone}
+	}
+	s.Source = SourceSystem
+	return s
+}
+
+// parseWinINet extracts proxy settings from the Internet Settings registry
+// values. server is either host:port for every scheme or a list such as
+// "http=proxy:80;https=proxy:443"; override is the semicolon-separated
+// bypass list, which may include <local>.
+func parseWinINet(enabled bool, server, override string) Settings {
+	if !enabled || server == "" {
+		return Settings{Source: SourceNone}
+	}
+	var s Settings
+	if !strings.Contains(server, "=") {
+		s.HTTP, s.HTTPS = server, server
+	} else {
+		for _, entry := range strings.Split(server, ";") {
+			scheme, proxy, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			switch strings.ToLower(scheme) {
+			case "http":
+				s.HTTP = proxy
+			case "https":
+				s.HTTPS = proxy
+			}
+		}
+	}
+	for _, h := range strings.Split(override, ";") {
+		if h = strings.TrimSpace(h); h != "" {
+			s.NoProxy = append(s.NoProxy, h)
+		}
+	}
+	if s.HTTP == "" && s.HTTPS == "" {
+		return Settings{Source: SourceNone}
+	}
+	s.Source = SourceSystem
+	return s
+}
+
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), "'\"")
+}
+
+func hostPort(host, port string) string {
+	if port == "" {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,14 @@
+//go:build darwin
+
+package proxy
+
+import "os/exec"
+
+// systemSettings reads the proxies configured in System Settings > Network.
+func systemSettings() Settings {
+	out, err := exec.Command("scutil", "--proxy").Output()
+	if err != nil {
+		return Settings{Source: SourceNone}
+	}
+	return parseScutil(string(out))
+}
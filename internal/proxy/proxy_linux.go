@@ -0,0 +1,23 @@
+//go:build linux
+
+package proxy
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// systemSettings reads the GNOME proxy settings, which most desktops and
+// the XDG proxy portal mirror. Without gsettings there is no system proxy.
+func systemSettings() Settings {
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		return Settings{Source: SourceNone}
+	}
+	return parseGSettings(func(schema, key string) string {
+		out, err := exec.Command("gsettings", "get", schema, key).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	})
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package proxy
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// systemSettings reads the proxies configured in Settings > Network &
+// Internet > Proxy, which WinINet keeps in the current user's registry.
+func systemSettings() Settings {
+	var key syscall.Handle
+	path, _ := syscall.UTF16PtrFromString(`Software\Microsoft\Windows\CurrentVersion\Internet Settings`)
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_CURRENT_USER, path, 0, syscall.KEY_READ, &key); err != nil {
+		return Settings{Source: SourceNone}
+	}
+	defer syscall.RegCloseKey(key)
+
+	var enable uint32
+	size := uint32(unsafe.Sizeof(enable))
+	name, _ := syscall.UTF16PtrFromString("ProxyEnable")
+	if err := syscall.RegQueryValueEx(key, name, nil, nil, (*byte)(unsafe.Pointer(&enable)), &size); err != nil {
+		return Settings{Source: SourceNone}
+	}
+	return parseWinINet(enable != 0, regString(key, "ProxyServer"), regString(key, "ProxyOverride"))
+}
+
+// regString reads a REG_SZ value, or "" if it is missing.
+func regString(key syscall.Handle, value string) string {
+	name, _ := syscall.UTF16PtrFromString(value)
+	var size uint32
+	if err := syscall.RegQueryValueEx(key, name, nil, nil, nil, &size); err != nil || size == 0 {
+		return ""
+	}
+	buf := make([]uint16, size/2+1)
+	if err := syscall.RegQueryValueEx(key, name, nil, nil, (*byte)(unsafe.Pointer(&buf[0])), &size); err != nil {
+		return ""
+	}
+	return syscall.UTF16ToString(buf)
+}
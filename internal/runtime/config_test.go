@@ -1,9 +1,14 @@
 package runtime
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/lightshell-dev/lightshell/internal/security"
 )
 
 func TestLoadConfigDefaults(t *testing.T) {
@@ -106,8 +111,8 @@ func TestLoadConfigAllFields(t *testing.T) {
 	if cfg.Build.AppID != "com.example.myapp" {
 		t.Errorf("expected appId 'com.example.myapp', got %q", cfg.Build.AppID)
 	}
-	if len(cfg.Permissions) != 3 {
-		t.Errorf("expected 3 permissions, got %d", len(cfg.Permissions))
+	if len(cfg.Permissions.Names) != 3 {
+		t.Errorf("expected 3 permissions, got %d", len(cfg.Permissions.Names))
 	}
 	if cfg.DevCommand != "npm run dev" {
 		t.Errorf("expected devCommand 'npm run dev', got %q", cfg.DevCommand)
@@ -183,3 +188,472 @@ func TestLoadConfigCustomTitle(t *testing.T) {
 		t.Errorf("expected title 'Custom Title', got %q", cfg.Window.Title)
 	}
 }
+
+func TestLoadConfigHooks(t *testing.T) {
+	dir := t.TempDir()
+	config := `{
+		"name": "myapp",
+		"version": "1.0.0",
+		"hooks": {
+			"preBuild": "npm run lint",
+			"postBuild": "./scripts/upload-sourcemaps.sh",
+			"preRelease": "echo releasing $VERSION",
+			"postRelease": "curl -X POST https://hooks.example.com/notify"
+		}
+	}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Hooks.PreBuild != "npm run lint" {
+		t.Errorf("expected preBuild 'npm run lint', got %q", cfg.Hooks.PreBuild)
+	}
+	if cfg.Hooks.PostBuild != "./scripts/upload-sourcemaps.sh" {
+		t.Errorf("expected postBuild hook, got %q", cfg.Hooks.PostBuild)
+	}
+	if cfg.Hooks.PreRelease != "echo releasing $VERSION" {
+		t.Errorf("expected preRelease hook, got %q", cfg.Hooks.PreRelease)
+	}
+	if cfg.Hooks.PostRelease == "" {
+		t.Error("expected postRelease hook to be set")
+	}
+}
+
+func TestLoadConfigStartup(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "1.0.0", "startup": {"budgetMs": 1500, "milestone": "domContentLoaded"}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Startup.BudgetMs != 1500 {
+		t.Errorf("expected budgetMs 1500, got %d", cfg.Startup.BudgetMs)
+	}
+	if cfg.Startup.Milestone != "domContentLoaded" {
+		t.Errorf("expected milestone domContentLoaded, got %q", cfg.Startup.Milestone)
+	}
+}
+
+func TestLoadConfigWorkers(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "1.0.0", "workers": {"size": 4, "queue": 32, "namespaces": {"image": 2}}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Workers.Size != 4 || cfg.Workers.Queue != 32 {
+		t.Errorf("expected size 4 and queue 32, got %+v", cfg.Workers)
+	}
+	if cfg.Workers.Namespaces["image"] != 2 {
+		t.Errorf("expected image limit 2, got %d", cfg.Workers.Namespaces["image"])
+	}
+}
+
+func TestLoadConfigSecurityTLS(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "1.0.0", "security": {"tls": {"caFiles": ["certs/corp.pem"], "pins": {"releases.example.com": ["sha256/abc="]}}}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Security.TLS.CAFiles) != 1 || cfg.Security.TLS.CAFiles[0] != "certs/corp.pem" {
+		t.Errorf("unexpected caFiles: %v", cfg.Security.TLS.CAFiles)
+	}
+	if pins := cfg.Security.TLS.Pins["releases.example.com"]; len(pins) != 1 || pins[0] != "sha256/abc=" {
+		t.Errorf("unexpected pins: %v", cfg.Security.TLS.Pins)
+	}
+}
+
+func TestLoadConfigSecurityCSP(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "security": {"csp": "default-src 'self' https://cdn.example.com", "allowNavigation": ["https://auth.example.com/**"]}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Security.PageCSP(false); got != "default-src 'self' https://cdn.example.com" {
+		t.Errorf("unexpected production CSP: %q", got)
+	}
+	if got := cfg.Security.PageCSP(true); got != security.DevCSP {
+		t.Errorf("expected the default dev CSP, got %q", got)
+	}
+	if len(cfg.Security.AllowNavigation) != 1 {
+		t.Errorf("unexpected allowNavigation: %v", cfg.Security.AllowNavigation)
+	}
+	if got := (SecurityConfig{}).PageCSP(false); got != security.ProductionCSP {
+		t.Errorf("expected the default production CSP, got %q", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte("{\n  \"name\": \"myapp\",\n  \"security\": {\"allowNavigation\": [\"ftp://example.com\"]}\n}"), 0644)
+	_, err = LoadConfig(dir)
+	if err == nil || !strings.Contains(err.Error(), "line 3: security.allowNavigation:") {
+		t.Errorf("expected an allowNavigation error on line 3, got %v", err)
+	}
+}
+
+func TestLoadConfigProxy(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "proxy": {"url": "http://proxy.corp:8080", "noProxy": ["*.corp"]}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Proxy.URL != "http://proxy.corp:8080" || len(cfg.Proxy.NoProxy) != 1 || cfg.Proxy.NoProxy[0] != "*.corp" {
+		t.Errorf("unexpected proxy: %+v", cfg.Proxy)
+	}
+
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte("{\n  \"name\": \"myapp\",\n  \"proxy\": {\"url\": \"http://\"}\n}"), 0644)
+	_, err = LoadConfig(dir)
+	if err == nil || !strings.Contains(err.Error(), "line 3: proxy.url:") {
+		t.Errorf("expected a proxy.url error on line 3, got %v", err)
+	}
+}
+
+func TestLoadConfigAccelerators(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "1.0.0", "accelerators": {"CmdOrCtrl+R": "reload", "CmdOrCtrl+Shift+P": "openPalette"}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Accelerators["CmdOrCtrl+R"] != "reload" || cfg.Accelerators["CmdOrCtrl+Shift+P"] != "openPalette" {
+		t.Errorf("unexpected accelerators: %v", cfg.Accelerators)
+	}
+}
+
+func TestLoadConfigTitlebar(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "1.0.0", "window": {"titlebar": {"transparent": true, "fullSizeContent": true, "toolbarStyle": "unified"}}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tb := cfg.Window.Titlebar
+	if !tb.Transparent || !tb.FullSizeContent || tb.HideTitle || tb.ToolbarStyle != "unified" {
+		t.Errorf("unexpected titlebar: %+v", tb)
+	}
+	if err := tb.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestLoadConfigLaunchAtLogin(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "1.0.0", "launchAtLogin": true}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.LaunchAtLogin {
+		t.Error("expected launchAtLogin to be true")
+	}
+}
+
+func TestLoadConfigLaunchArgs(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "1.0.0", "launchArgs": {"file": {"type": "string", "short": "f"}, "zoom": {"type": "number", "default": 100}}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.LaunchArgs) != 2 || cfg.LaunchArgs["file"].Short != "f" || cfg.LaunchArgs["zoom"].Default != float64(100) {
+		t.Errorf("unexpected launchArgs: %+v", cfg.LaunchArgs)
+	}
+	if err := cfg.LaunchArgs.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestLoadConfigMigrations(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "2.0.0", "migrations": {"2.0.0": "migrations/v2.js"}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Migrations["2.0.0"] != "migrations/v2.js" {
+		t.Errorf("unexpected migrations: %v", cfg.Migrations)
+	}
+}
+
+func TestLoadConfigWindowFalse(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "1.0.0", "window": false}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Window.Disabled {
+		t.Error("expected window to be disabled")
+	}
+	// Windows opened later with window.create default to the main title
+	if cfg.Window.Title != "myapp" {
+		t.Errorf("expected title to default to name, got %q", cfg.Window.Title)
+	}
+
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(`{"name": "myapp", "window": true}`), 0644)
+	cfg, err = LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Window.Disabled || cfg.Window.Width != 1024 {
+		t.Errorf("expected \"window\": true to use the defaults, got %+v", cfg.Window)
+	}
+}
+
+func TestDecodeConfigRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "version": "1.0.0", "window": false, "permissions": ["tray"]}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded, err := DecodeConfig(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decoded.Window.Disabled || decoded.Window.Title != "myapp" || decoded.Entry != "src/index.html" {
+		t.Errorf("tray-only config did not survive a round trip: %+v", decoded)
+	}
+	if !reflect.DeepEqual(decoded.Permissions.Names, []string{"tray"}) {
+		t.Errorf("unexpected permissions: %v", decoded.Permissions)
+	}
+}
+
+func TestLoadConfigScopedPermissions(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "permissions": {
+		"fs": {"read": ["$APP_DATA/**"], "write": ["$APP_DATA/**"]},
+		"http": {"allow": ["https://api.example.com/**"]},
+		"process": {"exec": [{"cmd": "git", "args": ["status"]}]},
+		"dialog": true,
+		"clipboard": false
+	}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Permissions.Names, []string{"dialog", "fs", "http", "process"}) {
+		t.Errorf("unexpected permissions: %v", cfg.Permissions.Names)
+	}
+	if cfg.Permissions.HTTP == nil || cfg.Permissions.HTTP.Allow[0] != "https://api.example.com/**" {
+		t.Errorf("http scope not read: %+v", cfg.Permissions.HTTP)
+	}
+
+	// The scopes are what a built app enforces, so they must survive the
+	// config being embedded
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded, err := DecodeConfig(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Permissions, cfg.Permissions) {
+		t.Errorf("permissions did not survive a round trip: %+v", decoded.Permissions)
+	}
+
+	policy := decoded.Permissions.Policy(dir, "myapp")
+	if err := policy.CheckHTTP("https://api.example.com/items"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := policy.CheckHTTP("https://evil.com/"); err == nil {
+		t.Error("expected a URL outside the http scope to be denied")
+	}
+	if err := policy.CheckProcess("rm", []string{"-rf", "/"}); err == nil {
+		t.Error("expected a command outside the process scope to be denied")
+	}
+}
+
+func TestLoadConfigAskPermissions(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "permissions": {"fs": true, "process": {"exec": []}, "dialog": true, "ask": true}}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Permissions.Ask, []string{"fs", "process"}) {
+		t.Errorf("ask = %v, want the declared APIs that can ask", cfg.Permissions.Ask)
+	}
+	if !reflect.DeepEqual(cfg.Permissions.Names, []string{"dialog", "fs", "process"}) {
+		t.Errorf("ask was read as an API: %v", cfg.Permissions.Names)
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded, err := DecodeConfig(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Permissions, cfg.Permissions) {
+		t.Errorf("ask did not survive a round trip: %+v", decoded.Permissions)
+	}
+}
+
+func TestLoadConfigInvalidPermissions(t *testing.T) {
+	tests := []struct {
+		permissions string
+		want        []string // substrings of the error
+	}{
+		{`{"fs": "yes"}`, []string{"line 3:", "permissions.fs: must be true, false, or an object", `    3 |   "permissions": {"fs": "yes"}`}},
+		{`["fs", "fillesystem"]`, []string{"line 3:", `unknown API "fillesystem"`}},
+		{`{"dialogs": true}`, []string{"permissions.dialogs: unknown API"}},
+		{`{"fs": {"reed": ["$HOME/**"]}}`, []string{"permissions.fs.reed: unknown key; expected read or write"}},
+		{`{"fs": {"read": "$HOME/**"}}`, []string{"permissions.fs.read: cannot be a JSON string"}},
+		{`{"http": {"allow": ["https//api.example.com"]}}`, []string{"permissions.http.allow:", `"https//api.example.com"`}},
+		{`{"http": {"allow": ["ftp://files.example.com/**"]}}`, []string{"must start with http:// or https://"}},
+		{`{"process": {"exec": [{"args": ["status"]}]}}`, []string{"permissions.process.exec: rule 0 has no cmd"}},
+		{`{"dialog": {"allow": []}}`, []string{"permissions.dialog: only fs, http, and process take a scope"}},
+		{`{"dialog": true, "ask": ["dialog"]}`, []string{`permissions.ask: "dialog" cannot ask`}},
+		{`{"fs": true, "ask": ["http"]}`, []string{`permissions.ask: "http" is not declared`}},
+		{`{"fs": true, "ask": "fs"}`, []string{"permissions.ask: must be true, false, or a list"}},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		config := "{\n  \"name\": \"myapp\",\n  \"permissions\": " + tt.permissions + "\n}"
+		os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+		_, err := LoadConfig(dir)
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.permissions)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: error %q does not contain %q", tt.permissions, err, want)
+			}
+		}
+	}
+}
+
+func TestLoadConfigSyntaxErrorLine(t *testing.T) {
+	dir := t.TempDir()
+	config := "{\n  \"name\": \"myapp\",\n  \"window\": {\"width\": 800,}\n}"
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	_, err := LoadConfig(dir)
+	if err == nil || !strings.Contains(err.Error(), "line 3:") || !strings.Contains(err.Error(), `"width": 800,}`) {
+		t.Errorf("expected the error to point at line 3, got %v", err)
+	}
+}
+
+func TestLoadConfigWindowFalseNeedsTray(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"name": "myapp", "window": false, "permissions": ["fs"]}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+
+	if _, err := LoadConfig(dir); err == nil {
+		t.Fatal("expected an error for a tray-only app without the tray permission")
+	}
+
+	config = `{"name": "myapp", "window": false, "permissions": ["fs", "tray"]}`
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(config), 0644)
+	if _, err := LoadConfig(dir); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestQuitOnLastWindowClosed(t *testing.T) {
+	tests := []struct {
+		config string
+		want   bool
+	}{
+		{`{"name": "myapp"}`, true},
+		{`{"name": "myapp", "app": {"quitOnLastWindowClosed": false}}`, false},
+		{`{"name": "myapp", "window": false}`, false},
+		{`{"name": "myapp", "window": false, "app": {"quitOnLastWindowClosed": true}}`, true},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(tt.config), 0644)
+		cfg, err := LoadConfig(dir)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.config, err)
+		}
+		if got := cfg.QuitOnLastWindowClosed(); got != tt.want {
+			t.Errorf("%s: QuitOnLastWindowClosed() = %v, want %v", tt.config, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigScripting(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "lightshell.json"), []byte(`{
+		"name": "myapp",
+		"scripting": {"enabled": true, "actions": ["newNote", "sync"]}
+	}`), 0644)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Scripting.Enabled {
+		t.Error("expected scripting to be enabled")
+	}
+	if len(cfg.Scripting.Actions) != 2 || cfg.Scripting.Actions[1] != "sync" {
+		t.Errorf("Scripting.Actions = %v", cfg.Scripting.Actions)
+	}
+}
+
+func TestDevServer(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		command, url string
+		ok           bool
+	}{
+		{name: "none", cfg: Config{}},
+		{name: "devCommand with port", cfg: Config{DevCommand: "npm run dev -- --port 5188"},
+			command: "npm run dev -- --port 5188", url: "http://127.0.0.1:5188", ok: true},
+		{name: "devCommand default port", cfg: Config{DevCommand: "npm run dev"},
+			command: "npm run dev", url: "http://127.0.0.1:5173", ok: true},
+		{name: "dev.command overrides devCommand", cfg: Config{DevCommand: "old", Dev: DevConfig{Command: "npx webpack serve", URL: "http://localhost:8080"}},
+			command: "npx webpack serve", url: "http://localhost:8080", ok: true},
+		{name: "url only", cfg: Config{Dev: DevConfig{URL: "http://localhost:3000/app/"}},
+			url: "http://localhost:3000/app/", ok: true},
+	}
+	for _, tt := range tests {
+		command, url, ok := tt.cfg.DevServer()
+		if command != tt.command || url != tt.url || ok != tt.ok {
+			t.Errorf("%s: DevServer() = %q, %q, %v; want %q, %q, %v", tt.name, command, url, ok, tt.command, tt.url, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lightshell-dev/lightshell/internal/jsonedit"
+)
+
+// FieldError is an invalid value in lightshell.json, at Path, a list of
+// object keys from the root, so that it can be pointed out in the file.
+type FieldError struct {
+	Path []string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", strings.Join(e.Path, "."), e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldError returns err at path. A FieldError from deeper in the config
+// has path added in front of its own.
+func fieldError(err error, path ...string) error {
+	var inner *FieldError
+	if errors.As(err, &inner) {
+		return &FieldError{Path: slices.Concat(path, inner.Path), Err: inner.Err}
+	}
+	return &FieldError{Path: path, Err: err}
+}
+
+// configError describes an error decoding lightshell.json, data, with the
+// line it is about when that can be found.
+func configError(data []byte, err error) error {
+	offset := -1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var fieldErr *FieldError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = int(syntaxErr.Offset) - 1 // just past the bad byte
+	case errors.As(err, &typeErr):
+		offset = int(typeErr.Offset) - 1
+	case errors.As(err, &fieldErr):
+		if at, ok := jsonedit.KeyOffset(data, fieldErr.Path); ok {
+			offset = at
+		}
+	}
+	if offset < 0 || offset >= len(data) {
+		return fmt.Errorf("invalid lightshell.json: %w", err)
+	}
+
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	lineEnd := bytes.IndexByte(data[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(data)
+	} else {
+		lineEnd += offset
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	text := strings.TrimRight(string(data[lineStart:lineEnd]), "\r")
+	return fmt.Errorf("invalid lightshell.json: line %d: %w\n\n  %4d | %s", line, err, line, text)
+}
@@ -0,0 +1,263 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ResolvedConfig is a lightshell.json with the configs it extends merged in.
+type ResolvedConfig struct {
+	// Values is the merged config, without "extends".
+	Values map[string]any
+	// Files lists the config files read, bases first, ending with the
+	// project's lightshell.json.
+	Files []string
+	// Sources maps each setting, as a dotted key path such as
+	// "window.width", to the file its value came from. Arrays are set as a
+	// whole, so they have one entry.
+	Sources map[string]string
+
+	dir string // the project directory
+}
+
+// ResolveConfig reads dir's lightshell.json and merges in the configs named
+// by its "extends": a path relative to the extending file, or the name of a
+// shared preset. A preset is an npm package's lightshell.json, found in
+// node_modules next to the extending file or above it, or the project's
+// node_modules, or else
+// ~/.lightshell/presets/<name>.json. "extends" may also list several
+// configs, later ones overriding earlier ones, and bases may extend other
+// bases. Settings merge the way lightshell_update_config patches do: objects
+// merge key by key, any other value replaces the base's, and null removes
+// the base's value.
+func ResolveConfig(dir string) (*ResolvedConfig, error) {
+	path, err := filepath.Abs(filepath.Join(dir, "lightshell.json"))
+	if err != nil {
+		return nil, err
+	}
+	r := &ResolvedConfig{Values: map[string]any{}, Sources: map[string]string{}, dir: filepath.Dir(path)}
+	if err := r.load(path, nil); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DefaultSource is the source EffectiveConfig gives a setting that no
+// config file sets, or that LoadConfig filled in.
+const DefaultSource = "(default)"
+
+// EffectiveConfig resolves dir's config the way LoadConfig does, defaults
+// included. Values holds the Config the app runs with, so keys lightshell
+// does not know are left out, and Sources names DefaultSource for the
+// settings no config file decided.
+func EffectiveConfig(dir string) (*ResolvedConfig, error) {
+	resolved, err := ResolveConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	eff := &ResolvedConfig{Values: values, Files: resolved.Files, Sources: map[string]string{}, dir: resolved.dir}
+	effectiveSources(values, resolved.Values, "", resolved.Sources, eff.Sources)
+	return eff, nil
+}
+
+// effectiveSources records in out where each setting in values, under
+// prefix, came from: the file that set it when the files resolved into
+// files agree with it, and DefaultSource otherwise.
+func effectiveSources(values, files map[string]any, prefix string, fileSources, out map[string]string) {
+	for k, v := range values {
+		key := prefix + k
+		fv, inFiles := files[k]
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			fm, _ := fv.(map[string]any)
+			effectiveSources(m, fm, key+".", fileSources, out)
+			continue
+		}
+		if src, ok := fileSources[key]; ok && inFiles && reflect.DeepEqual(v, fv) {
+			out[key] = src
+		} else {
+			out[key] = DefaultSource
+		}
+	}
+}
+
+// load merges path and what it extends into r. chain holds the files
+// extending path, for cycle detection.
+func (r *ResolvedConfig) load(path string, chain []string) error {
+	for i, p := range chain {
+		if p == path {
+			var cycle []string
+			for _, c := range append(chain[i:len(chain):len(chain)], path) {
+				cycle = append(cycle, filepath.Base(c))
+			}
+			return fmt.Errorf("extends cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	chain = append(chain[:len(chain):len(chain)], path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if len(chain) == 1 {
+			return err
+		}
+		return fmt.Errorf("could not read %s, extended by %s: %w", path, chain[len(chain)-2], err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	bases, err := extendsList(values["extends"])
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	delete(values, "extends")
+	for _, base := range bases {
+		basePath, err := findBaseConfig(base, filepath.Dir(path), r.dir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := r.load(basePath, chain); err != nil {
+			return err
+		}
+	}
+
+	mergeConfig(r.Values, values, "", path, r.Sources)
+	// A base reached twice, through two configs, is listed once
+	for _, f := range r.Files {
+		if f == path {
+			return nil
+		}
+	}
+	r.Files = append(r.Files, path)
+	return nil
+}
+
+// extendsList reads an "extends" value: a string or an array of strings.
+func extendsList(v any) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf(`"extends" must be a string or an array of strings`)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf(`"extends" must be a string or an array of strings`)
+	}
+}
+
+// findBaseConfig locates the config named by an "extends" entry in a file
+// in dir, of the project in projectDir.
+func findBaseConfig(name, dir, projectDir string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf(`"extends" entry is empty`)
+	}
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name), nil
+	}
+	if strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../") {
+		return filepath.Join(dir, filepath.FromSlash(name)), nil
+	}
+
+	// A preset: "pkg" or "@scope/pkg" means the package's lightshell.json,
+	// and a name ending in .json a file in a package
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("invalid preset name %q", name)
+		}
+	}
+	rel := filepath.FromSlash(name)
+	if !strings.HasSuffix(name, ".json") {
+		rel = filepath.Join(rel, "lightshell.json")
+	}
+	for _, start := range []string{dir, projectDir} {
+		for d := start; ; d = filepath.Dir(d) {
+			candidate := filepath.Join(d, "node_modules", rel)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidate := filepath.Join(home, ".lightshell", "presets", filepath.FromSlash(strings.TrimSuffix(name, ".json"))+".json")
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("preset %q not found in node_modules or ~/.lightshell/presets (use ./ for a relative path)", name)
+}
+
+// mergeConfig merges src, read from file, into dst, recording in sources
+// which file set each value under prefix.
+func mergeConfig(dst, src map[string]any, prefix, file string, sources map[string]string) {
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := src[k]
+		key := prefix + k
+		if v == nil {
+			delete(dst, k)
+			clearSources(sources, key)
+			continue
+		}
+		srcMap, srcIsMap := v.(map[string]any)
+		if srcIsMap {
+			dstMap, dstIsMap := dst[k].(map[string]any)
+			if !dstIsMap {
+				dstMap = map[string]any{}
+				dst[k] = dstMap
+				clearSources(sources, key)
+			}
+			mergeConfig(dstMap, srcMap, key+".", file, sources)
+			if len(srcMap) == 0 && len(dstMap) == 0 {
+				sources[key] = file
+			}
+			continue
+		}
+		dst[k] = v
+		clearSources(sources, key)
+		sources[key] = file
+	}
+}
+
+// clearSources forgets the sources of key and everything under it.
+func clearSources(sources map[string]string, key string) {
+	for k := range sources {
+		if k == key || strings.HasPrefix(k, key+".") {
+			delete(sources, k)
+		}
+	}
+}
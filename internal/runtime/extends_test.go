@@ -0,0 +1,192 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigExtends(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "app")
+	writeConfigFile(t, filepath.Join(root, "shared", "base.json"), `{
+		"extends": "@acme/preset",
+		"window": {"width": 900, "frameless": true},
+		"permissions": ["fs", "dialog"]
+	}`)
+	writeConfigFile(t, filepath.Join(dir, "node_modules", "@acme", "preset", "lightshell.json"), `{
+		"window": {"width": 700, "height": 500, "title": "Acme"},
+		"build": {"appId": "com.acme.app"}
+	}`)
+	writeConfigFile(t, filepath.Join(dir, "lightshell.json"), `{
+		"name": "notes",
+		"extends": "../shared/base.json",
+		"window": {"height": 640, "frameless": null},
+		"permissions": ["fs"]
+	}`)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Window.Width != 900 || cfg.Window.Height != 640 {
+		t.Errorf("window size = %dx%d, want 900x640", cfg.Window.Width, cfg.Window.Height)
+	}
+	if cfg.Window.Title != "Acme" {
+		t.Errorf("title = %q, want the preset's", cfg.Window.Title)
+	}
+	if cfg.Window.Frameless {
+		t.Error("frameless should be removed by null")
+	}
+	if !reflect.DeepEqual(cfg.Permissions.Names, []string{"fs"}) {
+		t.Errorf("permissions = %v, want the project's array to replace the base's", cfg.Permissions)
+	}
+	if cfg.Build.AppID != "com.acme.app" {
+		t.Errorf("appId = %q", cfg.Build.AppID)
+	}
+
+	resolved, err := ResolveConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved.Files) != 3 || filepath.Base(resolved.Files[2]) != "lightshell.json" {
+		t.Errorf("files = %v", resolved.Files)
+	}
+	wantSources := map[string]string{
+		"name":         "lightshell.json",
+		"window.width": "base.json",
+		"window.title": "lightshell.json",
+		"permissions":  "lightshell.json",
+		"build.appId":  "lightshell.json",
+	}
+	wantDirs := map[string]string{"window.title": "preset", "build.appId": "preset"}
+	for key, file := range wantSources {
+		got := resolved.Sources[key]
+		if filepath.Base(got) != file {
+			t.Errorf("source of %s = %s, want %s", key, got, file)
+		}
+		if d, ok := wantDirs[key]; ok && filepath.Base(filepath.Dir(got)) != d {
+			t.Errorf("source of %s = %s, want the preset's", key, got)
+		}
+	}
+	if _, ok := resolved.Sources["window.frameless"]; ok {
+		t.Error("removed setting should have no source")
+	}
+}
+
+func TestResolveConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"lightshell.json": `{"extends": "./a.json"}`,
+				"a.json":          `{"extends": "./b.json"}`,
+				"b.json":          `{"extends": "./a.json"}`,
+			},
+			want: "extends cycle: a.json -> b.json -> a.json",
+		},
+		{
+			name:  "missing base",
+			files: map[string]string{"lightshell.json": `{"extends": "./missing.json"}`},
+			want:  "could not read",
+		},
+		{
+			name:  "missing preset",
+			files: map[string]string{"lightshell.json": `{"extends": "no-such-preset"}`},
+			want:  `preset "no-such-preset" not found`,
+		},
+		{
+			name:  "bad extends",
+			files: map[string]string{"lightshell.json": `{"extends": 1}`},
+			want:  "must be a string or an array of strings",
+		},
+	}
+	t.Setenv("HOME", t.TempDir())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeConfigFile(t, filepath.Join(dir, name), content)
+			}
+			_, err := ResolveConfig(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveConfigExtendsList(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, "a.json"), `{"window": {"width": 1}, "tray": true}`)
+	writeConfigFile(t, filepath.Join(dir, "b.json"), `{"window": {"width": 2}}`)
+	writeConfigFile(t, filepath.Join(dir, "lightshell.json"), `{"extends": ["./a.json", "./b.json"]}`)
+
+	resolved, err := ResolveConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"window": map[string]any{"width": 2.0}, "tray": true}
+	if !reflect.DeepEqual(resolved.Values, want) {
+		t.Errorf("values = %v, want %v", resolved.Values, want)
+	}
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "app")
+	base := filepath.Join(root, "base.json")
+	writeConfigFile(t, base, `{"window": {"width": 900, "title": ""}, "tray": true}`)
+	writeConfigFile(t, filepath.Join(dir, "lightshell.json"), `{
+		"extends": "../base.json",
+		"name": "notes",
+		"window": {"height": 640},
+		"unknownKey": 1
+	}`)
+
+	eff, err := EffectiveConfig(dir)
+	if err != nil {
+		t.Fatalf("EffectiveConfig: %v", err)
+	}
+	project := filepath.Join(dir, "lightshell.json")
+	want := map[string]string{
+		"name":             project,
+		"window.height":    project,
+		"window.width":     base,
+		"tray":             base,
+		"window.title":     DefaultSource, // "" in the base, then the app name
+		"window.resizable": DefaultSource,
+		"entry":            DefaultSource,
+	}
+	for key, src := range want {
+		if got := eff.Sources[key]; got != src {
+			t.Errorf("source of %s = %q, want %q", key, got, src)
+		}
+	}
+	window := eff.Values["window"].(map[string]any)
+	if window["title"] != "notes" || window["width"] != 900.0 || window["resizable"] != true {
+		t.Errorf("window = %v, want the defaults applied", window)
+	}
+	if _, ok := eff.Values["unknownKey"]; ok {
+		t.Error("keys lightshell does not read should be left out")
+	}
+	if _, ok := eff.Sources["unknownKey"]; ok {
+		t.Error("unknownKey should have no source")
+	}
+}
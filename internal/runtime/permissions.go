@@ -0,0 +1,283 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lightshell-dev/lightshell/internal/security"
+)
+
+// Permissions is the permissions key of lightshell.json. It is either a
+// list of the APIs the app may use:
+//
+//	"permissions": ["fs", "http", "dialog"]
+//
+// or an object keyed by them, whose fs, http, and process entries narrow
+// those APIs to the paths, URLs, and commands they list. Other APIs are
+// declared with true:
+//
+//	"permissions": {
+//	  "fs": {"read": ["$APP_DATA/**"], "write": ["$APP_DATA/**"]},
+//	  "http": {"allow": ["api.example.com"]},
+//	  "dialog": true
+//	}
+//
+// The object form may also turn on ask mode with "ask": true, or a list of
+// fs, http, and process, so that what their scopes deny is put to the user
+// in a permission prompt instead of failing.
+type Permissions struct {
+	Names   []string // the declared APIs, sorted when read from an object
+	FS      *security.FSScope
+	HTTP    *security.HTTPScope
+	Process *security.ProcessScope
+	Ask     []string // the APIs in ask mode, sorted
+}
+
+// Has reports whether the API name is declared.
+func (p Permissions) Has(name string) bool {
+	return hasPermission(p.Names, name)
+}
+
+// Policy returns the security policy that enforces p, with the fs API
+// confined to projectDir and the app's own directories where no fs scope
+// says otherwise.
+func (p Permissions) Policy(projectDir, appName string) *security.Policy {
+	policy := security.NewPolicy(p.Names, projectDir, appName, false)
+	if p.FS != nil {
+		policy.SetFSScope(*p.FS)
+	}
+	if p.HTTP != nil {
+		policy.SetHTTPScope(*p.HTTP)
+	}
+	if p.Process != nil {
+		policy.SetProcessScope(*p.Process)
+	}
+	return policy
+}
+
+// UnmarshalJSON accepts the list and the object form, and rejects APIs and
+// scope keys lightshell does not know, which would otherwise deny what the
+// config meant to allow. Its errors are FieldErrors.
+func (p *Permissions) UnmarshalJSON(data []byte) error {
+	*p = Permissions{}
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &p.Names); err != nil {
+			return fieldError(errors.New("must be a list of API names"), "permissions")
+		}
+		for _, name := range p.Names {
+			if err := checkPermissionName(name); err != nil {
+				return fieldError(err, "permissions")
+			}
+		}
+		return nil
+	}
+
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fieldError(errors.New("must be a list of APIs or an object keyed by API"), "permissions")
+	}
+	ask, hasAsk := entries["ask"]
+	delete(entries, "ask")
+	for name, value := range entries {
+		if err := checkPermissionName(name); err != nil {
+			return fieldError(err, "permissions", name)
+		}
+		value = bytes.TrimSpace(value)
+		switch {
+		case bytes.Equal(value, []byte("false")):
+			continue
+		case bytes.Equal(value, []byte("true")):
+		case len(value) > 0 && value[0] == '{':
+			var err error
+			switch name {
+			case "fs":
+				p.FS = new(security.FSScope)
+				err = decodeScope(value, p.FS, "read", "write")
+				if err == nil {
+					err = checkPatterns(p.FS.Read, "read", checkFSPattern)
+				}
+				if err == nil {
+					err = checkPatterns(p.FS.Write, "write", checkFSPattern)
+				}
+			case "http":
+				p.HTTP = new(security.HTTPScope)
+				err = decodeScope(value, p.HTTP, "allow", "deny")
+				if err == nil {
+					err = checkPatterns(p.HTTP.Allow, "allow", checkHTTPPattern)
+				}
+				if err == nil {
+					err = checkPatterns(p.HTTP.Deny, "deny", checkHTTPPattern)
+				}
+			case "process":
+				p.Process = new(security.ProcessScope)
+				err = decodeScope(value, p.Process, "exec")
+				for i, rule := range p.Process.Exec {
+					if err == nil && rule.Cmd == "" {
+						err = fieldError(fmt.Errorf("rule %d has no cmd", i), "exec")
+					}
+				}
+			default:
+				err = errors.New("only fs, http, and process take a scope; use true")
+			}
+			if err != nil {
+				return fieldError(err, "permissions", name)
+			}
+		default:
+			return fieldError(errors.New("must be true, false, or an object"), "permissions", name)
+		}
+		p.Names = append(p.Names, name)
+	}
+	sort.Strings(p.Names)
+	if hasAsk {
+		if err := p.decodeAsk(bytes.TrimSpace(ask)); err != nil {
+			return fieldError(err, "permissions", "ask")
+		}
+	}
+	return nil
+}
+
+// decodeAsk reads the ask key: true for each declared API that can ask, or
+// a list of them.
+func (p *Permissions) decodeAsk(data []byte) error {
+	switch {
+	case bytes.Equal(data, []byte("false")):
+		return nil
+	case bytes.Equal(data, []byte("true")):
+		for _, api := range security.AskableAPIs {
+			if p.Has(string(api)) {
+				p.Ask = append(p.Ask, string(api))
+			}
+		}
+		return nil
+	}
+	if err := json.Unmarshal(data, &p.Ask); err != nil {
+		return errors.New("must be true, false, or a list of fs, http, and process")
+	}
+	for _, name := range p.Ask {
+		if !slices.Contains(security.AskableAPIs, security.Permission(name)) {
+			return fmt.Errorf("%q cannot ask; only fs, http, and process can", name)
+		}
+		if !p.Has(name) {
+			return fmt.Errorf("%q is not declared in permissions", name)
+		}
+	}
+	sort.Strings(p.Ask)
+	return nil
+}
+
+// AskAPIs returns the APIs in ask mode.
+func (p Permissions) AskAPIs() []security.Permission {
+	apis := make([]security.Permission, len(p.Ask))
+	for i, name := range p.Ask {
+		apis[i] = security.Permission(name)
+	}
+	return apis
+}
+
+// checkPermissionName rejects names that are not a permission.
+func checkPermissionName(name string) error {
+	names := make([]string, len(security.AllPermissions))
+	for i, perm := range security.AllPermissions {
+		if string(perm) == name {
+			return nil
+		}
+		names[i] = string(perm)
+	}
+	return fmt.Errorf("unknown API %q; permissions are %s", name, strings.Join(names, ", "))
+}
+
+// decodeScope decodes a scope object into v, which has the given keys.
+func decodeScope(data []byte, v any, keys ...string) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for key := range fields {
+		if !slices.Contains(keys, key) {
+			return fieldError(fmt.Errorf("unknown key; expected %s", strings.Join(keys, " or ")), key)
+		}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return fieldError(fmt.Errorf("cannot be a JSON %s", typeErr.Value), strings.Split(typeErr.Field, ".")...)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkPatterns checks each pattern of the key with check.
+func checkPatterns(patterns []string, key string, check func(string) error) error {
+	for _, pattern := range patterns {
+		if err := check(pattern); err != nil {
+			return fieldError(fmt.Errorf("%q %w", pattern, err), key)
+		}
+	}
+	return nil
+}
+
+func checkFSPattern(pattern string) error {
+	if pattern == "" {
+		return errors.New("is empty")
+	}
+	if _, err := filepath.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return errors.New("is not a valid glob")
+	}
+	return nil
+}
+
+func checkHTTPPattern(pattern string) error {
+	if !strings.Contains(pattern, "://") {
+		if pattern == "" || strings.ContainsAny(pattern, "/:?#") {
+			return errors.New(`is not a host such as "api.example.com" or "*.example.com", or a URL pattern`)
+		}
+		return nil
+	}
+	scheme, rest, _ := strings.Cut(pattern, "://")
+	host, _, _ := strings.Cut(rest, "/")
+	if scheme != "http" && scheme != "https" {
+		return errors.New("must start with http:// or https://")
+	}
+	if host == "" {
+		return errors.New("has no host")
+	}
+	return nil
+}
+
+// MarshalJSON writes the list form unless there are scopes to keep.
+func (p Permissions) MarshalJSON() ([]byte, error) {
+	if p.FS == nil && p.HTTP == nil && p.Process == nil && p.Ask == nil {
+		if p.Names == nil {
+			return []byte("null"), nil
+		}
+		return json.Marshal(p.Names)
+	}
+	entries := make(map[string]any, len(p.Names))
+	for _, name := range p.Names {
+		entries[name] = true
+	}
+	if p.FS != nil {
+		entries["fs"] = p.FS
+	}
+	if p.HTTP != nil {
+		entries["http"] = p.HTTP
+	}
+	if p.Process != nil {
+		entries["process"] = p.Process
+	}
+	if p.Ask != nil {
+		entries["ask"] = p.Ask
+	}
+	return json.Marshal(entries)
+}
@@ -1,11 +1,18 @@
 package runtime
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/lightshell-dev/lightshell/internal/launchargs"
+	"github.com/lightshell-dev/lightshell/internal/security"
 	"github.com/lightshell-dev/lightshell/internal/webview"
 )
 
@@ -14,12 +21,31 @@ type Config struct {
 	Name        string       `json:"name"`
 	Version     string       `json:"version"`
 	Entry       string       `json:"entry"`
+	// Description, Author and Homepage describe the app in Linux packages.
+	Description string       `json:"description,omitempty"`
+	Author      string       `json:"author,omitempty"` // "Name <email>"
+	Homepage    string       `json:"homepage,omitempty"`
 	Window      WindowConfig `json:"window"`
 	Tray        bool         `json:"tray"`
 	Build        BuildConfig  `json:"build"`
-	Permissions  []string     `json:"permissions"`
+	Permissions  Permissions  `json:"permissions"`
 	DevCommand   string       `json:"devCommand,omitempty"`
 	BuildCommand string       `json:"buildCommand,omitempty"`
+	Hooks        HooksConfig  `json:"hooks,omitempty"`
+	Cache        CacheConfig  `json:"cache,omitempty"`
+	Startup      StartupConfig `json:"startup,omitempty"`
+	Workers      WorkersConfig `json:"workers,omitempty"`
+	Security     SecurityConfig `json:"security,omitempty"`
+	Proxy        ProxyConfig    `json:"proxy,omitempty"`
+	Accelerators map[string]string `json:"accelerators,omitempty"` // key combo -> built-in action or custom event name
+	LaunchAtLogin bool `json:"launchAtLogin,omitempty"` // default for app.setLaunchAtLogin until the user chooses
+	LaunchArgs   launchargs.Schema `json:"launchArgs,omitempty"` // command-line flags parsed for app.launchOptions
+	Migrations   map[string]string `json:"migrations,omitempty"` // app version -> migration module run by app.migrate
+	ThemeIcons   ThemeIconsConfig `json:"themeIcons,omitempty"`
+	App          AppConfig `json:"app,omitempty"`
+	Scripting    ScriptingConfig `json:"scripting,omitempty"`
+	Dev          DevConfig `json:"dev,omitempty"`
+	LightShellVersion string `json:"lightshellVersion,omitempty"` // LightShell release the project was last built with
 }
 
 type WindowConfig struct {
@@ -30,11 +56,256 @@ type WindowConfig struct {
 	MinHeight int    `json:"minHeight"`
 	Resizable *bool  `json:"resizable"`
 	Frameless bool   `json:"frameless"`
+	Titlebar  webview.TitlebarStyle `json:"titlebar,omitempty"` // macOS title bar customization
+	// Disabled is set by "window": false. The app runs tray-only: the entry
+	// page loads in a window that is never shown, sets up the tray, and
+	// opens windows on demand with window.create.
+	Disabled bool `json:"-"`
+}
+
+// UnmarshalJSON accepts false for a tray-only app as well as the window
+// options object.
+func (w *WindowConfig) UnmarshalJSON(data []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(data, &enabled); err == nil {
+		if enabled {
+			*w = WindowConfig{}
+			return nil
+		}
+		*w = WindowConfig{Disabled: true}
+		return nil
+	}
+	type plain WindowConfig
+	return json.Unmarshal(data, (*plain)(w))
+}
+
+// MarshalJSON writes false for a tray-only app, as UnmarshalJSON reads it.
+func (w WindowConfig) MarshalJSON() ([]byte, error) {
+	if w.Disabled {
+		return []byte("false"), nil
+	}
+	type plain WindowConfig
+	return json.Marshal(plain(w))
 }
 
 type BuildConfig struct {
-	Icon  string `json:"icon"`
-	AppID string `json:"appId"`
+	Icon           string        `json:"icon"`
+	AppID          string        `json:"appId"`
+	CompressAssets bool          `json:"compressAssets,omitempty"` // embed assets as a deduplicated, gzipped pack
+	Bundle         *BundleConfig `json:"bundle,omitempty"`
+	Mac            MacConfig     `json:"mac,omitempty"`
+	// CrossCompilers names the C compiler, with its flags, that builds for
+	// a platform such as "windows-x64" from this machine
+	CrossCompilers map[string]string `json:"crossCompilers,omitempty"`
+}
+
+// MacConfig holds macOS packaging, signing and notarization options.
+type MacConfig struct {
+	Identity      string         `json:"identity,omitempty"`      // codesign identity for build --sign
+	Entitlements  map[string]any `json:"entitlements,omitempty"`  // added to, or replacing, those generated from permissions
+	Sandbox       bool           `json:"sandbox,omitempty"`       // run the app in the App Sandbox
+	AppleID       string         `json:"appleId,omitempty"`       // notarization account; the password is read from APPLE_APP_SPECIFIC_PASSWORD
+	TeamID        string         `json:"teamId,omitempty"`        // defaults to the team in identity
+	NotaryProfile string         `json:"notaryProfile,omitempty"` // notarytool keychain profile, used instead of appleId
+	DMG           DMGConfig      `json:"dmg,omitempty"`
+}
+
+// DMGConfig lays out the Finder window of the disk image built by
+// --target dmg. Paths are relative to the project directory.
+type DMGConfig struct {
+	Background string    `json:"background,omitempty"` // window background image
+	Icon       string    `json:"icon,omitempty"`       // volume icon, an .icns file
+	Window     DMGWindow `json:"window,omitempty"`
+	IconSize   int       `json:"iconSize,omitempty"` // default 128
+}
+
+// DMGWindow is the size of the disk image's Finder window, in points.
+// It defaults to 540x380; match it to the background image.
+type DMGWindow struct {
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// BundleConfig bundles the pages' scripts with esbuild, so they can be
+// written in TypeScript and import packages from node_modules. Entry
+// points are relative to the project directory and inside the entry's
+// directory; each is served, and built, as a .js file at the same path.
+type BundleConfig struct {
+	EntryPoints []string          `json:"entryPoints"`
+	Minify      bool              `json:"minify,omitempty"`
+	Sourcemap   bool              `json:"sourcemap,omitempty"` // external .map files in builds; dev always inlines them
+	Define      map[string]string `json:"define,omitempty"`    // identifier -> JS expression to replace it with
+}
+
+// ThemeIconsConfig names icons that follow the system appearance. Paths are
+// relative to the project directory; when the system is dark, a sibling
+// with "@dark" before the extension (icon@dark.png) is shown instead, if
+// it exists.
+type ThemeIconsConfig struct {
+	Window string `json:"window,omitempty"` // window and Dock/taskbar icon
+	Tray   string `json:"tray,omitempty"`   // default tray icon for tray.set
+}
+
+// AppConfig holds app lifecycle options.
+type AppConfig struct {
+	// QuitOnLastWindowClosed quits the app when its last window closes.
+	// With false the app keeps running: app.show, or on macOS a click on
+	// the Dock icon, brings the main window back.
+	QuitOnLastWindowClosed *bool `json:"quitOnLastWindowClosed,omitempty"`
+}
+
+// ScriptingConfig makes the app scriptable on macOS. Opened files, "run
+// action" and quit Apple Events reach the app as IPC events, and a built
+// app declares them in a scripting dictionary so AppleScript and Shortcuts
+// can call it.
+type ScriptingConfig struct {
+	Enabled bool     `json:"enabled,omitempty"`
+	Actions []string `json:"actions,omitempty"` // names "run action" accepts; empty accepts any
+}
+
+// QuitOnLastWindowClosed reports the app.quitOnLastWindowClosed policy.
+// It defaults to true, except for a tray-only app, which has no window
+// to close.
+func (c Config) QuitOnLastWindowClosed() bool {
+	if c.App.QuitOnLastWindowClosed != nil {
+		return *c.App.QuitOnLastWindowClosed
+	}
+	return !c.Window.Disabled
+}
+
+// DevConfig adjusts lightshell dev.
+type DevConfig struct {
+	// FullReload reloads the page for every change. Otherwise changes to
+	// only stylesheets and images are swapped into the running page.
+	FullReload bool `json:"fullReload,omitempty"`
+	// HMR sends changed JS modules to the page, whose lightshell.hmr.accept
+	// handlers apply them, instead of reloading it.
+	HMR bool `json:"hmr,omitempty"`
+	// Command starts a framework dev server, such as Vite or webpack, whose
+	// page is loaded instead of the built-in static server's.
+	Command string `json:"command,omitempty"`
+	// URL is the framework dev server's page. Without Command, lightshell
+	// dev waits for a server started some other way.
+	URL string `json:"url,omitempty"`
+}
+
+// DevServer returns the framework dev server's command and URL, from dev
+// or the older top-level devCommand. Without a URL it is assumed on the
+// command's --port, or Vite's default 5173. ok is false when the project
+// has no dev server of its own.
+func (c Config) DevServer() (command, url string, ok bool) {
+	command, url = c.Dev.Command, c.Dev.URL
+	if command == "" {
+		command = c.DevCommand
+	}
+	if command == "" && url == "" {
+		return "", "", false
+	}
+	if url == "" {
+		port := 5173
+		fields := strings.Fields(command)
+		for i, f := range fields {
+			if f == "--port" && i+1 < len(fields) {
+				if p, err := strconv.Atoi(fields[i+1]); err == nil {
+					port = p
+				}
+			}
+		}
+		url = fmt.Sprintf("http://127.0.0.1:%d", port)
+	}
+	return command, url, true
+}
+
+// HooksConfig declares shell commands run at build and release lifecycle points.
+// Each command runs in the project directory with OUTPUT_PATH, VERSION and
+// PLATFORM set in its environment.
+type HooksConfig struct {
+	PreBuild    string `json:"preBuild,omitempty"`
+	PostBuild   string `json:"postBuild,omitempty"`
+	PreRelease  string `json:"preRelease,omitempty"`
+	PostRelease string `json:"postRelease,omitempty"`
+}
+
+// CacheConfig configures the managed app cache (lightshell.cache).
+type CacheConfig struct {
+	MaxSizeMB int `json:"maxSizeMB,omitempty"` // 0 uses the default (256MB)
+}
+
+// StartupConfig sets a cold-start time budget checked by lightshell doctor.
+type StartupConfig struct {
+	BudgetMs  int    `json:"budgetMs,omitempty"`  // 0 disables the check
+	Milestone string `json:"milestone,omitempty"` // milestone the budget applies to (default "firstPaint")
+}
+
+// WorkersConfig sizes the worker pool that runs CPU-heavy IPC handlers
+// (image processing, hashing, archives) off the message-dispatch path.
+type WorkersConfig struct {
+	Size       int            `json:"size,omitempty"`       // concurrent jobs (default: number of CPUs)
+	Queue      int            `json:"queue,omitempty"`      // jobs allowed to wait (default: 256)
+	Namespaces map[string]int `json:"namespaces,omitempty"` // per-namespace limits; listed namespaces always run on the pool
+}
+
+// SecurityConfig holds security hardening options.
+type SecurityConfig struct {
+	CSP             string    `json:"csp,omitempty"`             // replaces security.ProductionCSP in built apps and lightshell run
+	DevCSP          string    `json:"devCsp,omitempty"`          // replaces security.DevCSP in lightshell dev
+	AllowNavigation []string  `json:"allowNavigation,omitempty"` // http permission patterns the main window may navigate to besides the app
+	TLS             TLSConfig `json:"tls,omitempty"`
+}
+
+// PageCSP returns the Content-Security-Policy of the app's pages in dev
+// mode or as built: the configured one, or the default.
+func (s SecurityConfig) PageCSP(dev bool) string {
+	switch {
+	case dev && s.DevCSP != "":
+		return s.DevCSP
+	case dev:
+		return security.DevCSP
+	case s.CSP != "":
+		return s.CSP
+	}
+	return security.ProductionCSP
+}
+
+// TLSConfig adjusts certificate verification for outgoing HTTPS requests.
+type TLSConfig struct {
+	CAFiles []string            `json:"caFiles,omitempty"` // extra PEM root CAs, e.g. for a TLS-inspecting proxy
+	Pins    map[string][]string `json:"pins,omitempty"`    // host -> allowed base64 SHA-256 SPKI hashes
+	CAPEM   []string            `json:"caPEM,omitempty"`   // contents of CAFiles, embedded by lightshell build
+}
+
+// ClientConfig returns the TLS config for the app's HTTPS requests, or nil
+// when t leaves verification as it is. CA files resolve against dir.
+func (t TLSConfig) ClientConfig(dir string) (*tls.Config, error) {
+	if len(t.CAFiles) == 0 && len(t.CAPEM) == 0 && len(t.Pins) == 0 {
+		return nil, nil
+	}
+	bundles, err := security.ReadCAFiles(dir, t.CAFiles)
+	if err != nil {
+		return nil, err
+	}
+	return security.NewTLSConfigPEM(append(bundles, t.CAPEM...), t.Pins)
+}
+
+// ProxyConfig overrides the proxy of the app's lightshell.http requests,
+// which otherwise comes from the environment and the OS.
+type ProxyConfig struct {
+	URL     string   `json:"url,omitempty"`     // proxy for http and https, or "direct" to never use one
+	NoProxy []string `json:"noProxy,omitempty"` // hosts to reach directly, added to the detected list
+}
+
+// checkProxyURL accepts a proxy URL, a bare host:port, or "direct".
+func checkProxyURL(raw string) error {
+	if raw == "" || strings.EqualFold(raw, "direct") {
+		return nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	if u, err := url.Parse(raw); err != nil || u.Host == "" {
+		return errors.New(`is not a proxy URL such as "http://proxy.example.com:8080", or "direct"`)
+	}
+	return nil
 }
 
 // App is the main LightShell application.
@@ -56,10 +327,56 @@ func LoadConfig(dir string) (Config, error) {
 
 	var cfg Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
-		return Config{}, fmt.Errorf("invalid lightshell.json: %w", err)
+		return Config{}, configError(data, err)
+	}
+
+	// A config that extends others is decoded again with them merged in
+	var ext struct {
+		Extends any `json:"extends"`
+	}
+	json.Unmarshal(data, &ext)
+	if ext.Extends != nil {
+		resolved, err := ResolveConfig(dir)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid lightshell.json: %w", err)
+		}
+		merged, err := json.Marshal(resolved.Values)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid lightshell.json: %w", err)
+		}
+		cfg = Config{}
+		if err := json.Unmarshal(merged, &cfg); err != nil {
+			return Config{}, fmt.Errorf("invalid lightshell.json with the configs it extends: %w", err)
+		}
+	}
+
+	cfg.setDefaults()
+	if cfg.Window.Disabled && len(cfg.Permissions.Names) > 0 && !cfg.Permissions.Has("tray") {
+		return Config{}, fmt.Errorf("invalid lightshell.json: \"window\": false makes a tray-only app, which needs the \"tray\" permission")
+	}
+	if err := checkPatterns(cfg.Security.AllowNavigation, "allowNavigation", checkHTTPPattern); err != nil {
+		return Config{}, configError(data, fieldError(err, "security"))
+	}
+	if err := checkProxyURL(cfg.Proxy.URL); err != nil {
+		return Config{}, configError(data, fieldError(fmt.Errorf("%q %w", cfg.Proxy.URL, err), "proxy", "url"))
+	}
+
+	return cfg, nil
+}
+
+// DecodeConfig parses a config written out by a build, such as the one a
+// built app embeds, and applies the defaults LoadConfig does.
+func DecodeConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, err
 	}
+	cfg.setDefaults()
+	return cfg, nil
+}
 
-	// Defaults
+// setDefaults fills in what lightshell.json may leave out.
+func (cfg *Config) setDefaults() {
 	if cfg.Window.Width == 0 {
 		cfg.Window.Width = 1024
 	}
@@ -76,8 +393,15 @@ func LoadConfig(dir string) (Config, error) {
 	if cfg.Entry == "" {
 		cfg.Entry = "src/index.html"
 	}
+}
 
-	return cfg, nil
+func hasPermission(perms []string, name string) bool {
+	for _, p := range perms {
+		if p == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Run starts the LightShell application.
@@ -94,6 +418,8 @@ func (a *App) Run() error {
 		Resizable: *a.Config.Window.Resizable,
 		Frameless: a.Config.Window.Frameless,
 		DevTools:  a.DevMode,
+		Titlebar:  a.Config.Window.Titlebar,
+		Hidden:    a.Config.Window.Disabled,
 	}
 
 	if err := wv.Create(wcfg); err != nil {
@@ -0,0 +1,11 @@
+//go:build windows
+
+package runtime
+
+import "runtime"
+
+func init() {
+	// Win32 windows belong to the thread that creates them, and WebView2
+	// needs a single-threaded COM apartment on that thread
+	runtime.LockOSThread()
+}
@@ -0,0 +1,38 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/lightshell-dev/lightshell/internal/semver"
+)
+
+// Version is the LightShell release: the CLI's version, and that of the
+// runtime it builds into apps.
+const Version = "0.1.0"
+
+// releaseNotesURL lists what changed in each release.
+const releaseNotesURL = "https://github.com/lightshell-dev/lightshell/releases"
+
+// CheckVersion compares the LightShell version a project records in
+// "lightshellVersion" with this one. It returns a warning saying what to do
+// about a mismatch, or "" when there is nothing to do: no version is
+// recorded, or one this release stays compatible with. Releases are
+// compatible within a major version, and before 1.0 within a minor one.
+func CheckVersion(recorded string) string {
+	if recorded == "" {
+		return ""
+	}
+	cmp, err := semver.Compare(recorded, Version)
+	if err != nil {
+		return fmt.Sprintf("lightshellVersion %q in lightshell.json is not a valid version; set it to %q", recorded, Version)
+	}
+	if cmp > 0 {
+		return fmt.Sprintf("this project was made with LightShell %s, newer than this CLI (%s), and may use features it lacks. Update the CLI with: npm install -g @lightshell/cli", recorded, Version)
+	}
+	old, _ := semver.Parse(recorded)
+	cur, _ := semver.Parse(Version)
+	if old.Major != cur.Major || (cur.Major == 0 && old.Minor != cur.Minor) {
+		return fmt.Sprintf("this project was made with LightShell %s, and %s may have breaking changes since; check the release notes (%s). lightshell build records the new version.", recorded, Version, releaseNotesURL)
+	}
+	return ""
+}
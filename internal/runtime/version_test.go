@@ -0,0 +1,25 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		recorded string
+		want     string // substring of the warning; "" for none
+	}{
+		{"", ""},
+		{Version, ""},
+		{"99.0.0", "newer than this CLI"},
+		{"0.0.9", "breaking changes"},
+		{"latest", "not a valid version"},
+	}
+	for _, tt := range tests {
+		got := CheckVersion(tt.recorded)
+		if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+			t.Errorf("CheckVersion(%q) = %q, want %q", tt.recorded, got, tt.want)
+		}
+	}
+}
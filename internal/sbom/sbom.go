@@ -0,0 +1,211 @@
+// Package sbom writes software bills of materials for built apps in the
+// CycloneDX 1.5 JSON format: the Go modules compiled into the app's
+// executable and the npm packages its lockfile pins.
+package sbom
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SpecVersion is the CycloneDX version documents declare.
+const SpecVersion = "1.5"
+
+// Document is a CycloneDX BOM.
+type Document struct {
+	BOMFormat    string       `json:"bomFormat"`
+	SpecVersion  string       `json:"specVersion"`
+	SerialNumber string       `json:"serialNumber"`
+	Version      int          `json:"version"`
+	Metadata     Metadata     `json:"metadata"`
+	Components   []Component  `json:"components"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// Metadata describes the BOM itself and the app it is about.
+type Metadata struct {
+	Timestamp string    `json:"timestamp"`
+	Tools     Tools     `json:"tools"`
+	Component Component `json:"component"`
+}
+
+// Tools lists what generated the BOM.
+type Tools struct {
+	Components []Component `json:"components"`
+}
+
+// Component is a piece of software in the app.
+type Component struct {
+	Type    string `json:"type"` // "application" or "library"
+	BOMRef  string `json:"bom-ref,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Scope is "required" for what ships in the app and "excluded" for
+	// development-only dependencies.
+	Scope  string `json:"scope,omitempty"`
+	PURL   string `json:"purl,omitempty"`
+	Hashes []Hash `json:"hashes,omitempty"`
+}
+
+// Hash is a component's digest.
+type Hash struct {
+	Alg     string `json:"alg"` // "SHA-256", "SHA-512", ...
+	Content string `json:"content"`
+}
+
+// Dependency lists what a component depends on by bom-ref.
+type Dependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// New returns a BOM for app made of components, which app depends on
+// directly. tool and toolVersion name what generated it.
+func New(app Component, tool, toolVersion string, components []Component) Document {
+	if app.BOMRef == "" {
+		app.BOMRef = app.Name
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i].BOMRef < components[j].BOMRef })
+	refs := make([]string, len(components))
+	for i, c := range components {
+		refs[i] = c.BOMRef
+	}
+	return Document{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  SpecVersion,
+		SerialNumber: serialNumber(),
+		Version:      1,
+		Metadata: Metadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools:     Tools{Components: []Component{{Type: "application", Name: tool, Version: toolVersion}}},
+			Component: app,
+		},
+		Components:   components,
+		Dependencies: []Dependency{{Ref: app.BOMRef, DependsOn: refs}},
+	}
+}
+
+// serialNumber returns a random URN UUID, as CycloneDX asks for.
+func serialNumber() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0F | 0x40 // version 4
+	b[8] = b[8]&0x3F | 0x80 // RFC 4122 variant
+	h := hex.EncodeToString(b[:])
+	return fmt.Sprintf("urn:uuid:%s-%s-%s-%s-%s", h[:8], h[8:12], h[12:16], h[16:20], h[20:])
+}
+
+// GoComponents lists the Go standard library and the modules an
+// executable depends on, as its embedded build info records them. The
+// main module is the app itself, which the BOM's metadata describes.
+func GoComponents(info *debug.BuildInfo) []Component {
+	components := []Component{{
+		Type:    "library",
+		Name:    "stdlib",
+		Version: info.GoVersion,
+		Scope:   "required",
+		PURL:    "pkg:golang/stdlib@" + info.GoVersion,
+	}}
+	for _, dep := range info.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		components = append(components, goModule(dep))
+	}
+	for i := range components {
+		components[i].BOMRef = components[i].PURL
+	}
+	return components
+}
+
+func goModule(m *debug.Module) Component {
+	purl := "pkg:golang/" + m.Path
+	if m.Version != "" && m.Version != "(devel)" {
+		purl += "@" + m.Version
+	}
+	return Component{Type: "library", Name: m.Path, Version: m.Version, Scope: "required", PURL: purl}
+}
+
+// packageLock is the part of npm's package-lock.json the BOM needs. Lock
+// files from npm 7 on list every installed package under packages, keyed
+// by path; older ones nest them under dependencies.
+type packageLock struct {
+	Packages     map[string]lockedPackage `json:"packages"`
+	Dependencies map[string]lockedPackage `json:"dependencies"`
+}
+
+type lockedPackage struct {
+	Version      string                   `json:"version"`
+	Integrity    string                   `json:"integrity"`
+	Dev          bool                     `json:"dev"`
+	Link         bool                     `json:"link"`
+	Dependencies map[string]lockedPackage `json:"dependencies"` // lockfile v1 only
+}
+
+// NPMComponents lists the packages a package-lock.json pins. Development
+// dependencies are included with the "excluded" scope.
+func NPMComponents(data []byte) ([]Component, error) {
+	var lock packageLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid package-lock.json: %w", err)
+	}
+	seen := map[string]bool{}
+	var components []Component
+	add := func(name string, p lockedPackage) {
+		if name == "" || p.Link || p.Version == "" {
+			return
+		}
+		c := npmPackage(name, p)
+		if !seen[c.BOMRef] {
+			seen[c.BOMRef] = true
+			components = append(components, c)
+		}
+	}
+	if lock.Packages != nil {
+		for path, p := range lock.Packages {
+			// The root project is "", a package in node_modules/a/node_modules/b is b
+			i := strings.LastIndex(path, "node_modules/")
+			if i < 0 {
+				continue
+			}
+			add(path[i+len("node_modules/"):], p)
+		}
+		return components, nil
+	}
+	var walk func(deps map[string]lockedPackage)
+	walk = func(deps map[string]lockedPackage) {
+		for name, p := range deps {
+			add(name, p)
+			walk(p.Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+	return components, nil
+}
+
+func npmPackage(name string, p lockedPackage) Component {
+	// A scope's @ is escaped in package URLs: pkg:npm/%40types/node@20.0.0
+	purl := "pkg:npm/" + strings.Replace(name, "@", "%40", 1) + "@" + p.Version
+	c := Component{Type: "library", BOMRef: purl, Name: name, Version: p.Version, Scope: "required", PURL: purl}
+	if p.Dev {
+		c.Scope = "excluded"
+	}
+	// Subresource Integrity: "sha512-<base64>", maybe followed by others
+	integrity, _, _ := strings.Cut(p.Integrity, " ")
+	if alg, digest, ok := strings.Cut(integrity, "-"); ok {
+		if sum, err := base64.StdEncoding.DecodeString(digest); err == nil {
+			names := map[string]string{"sha1": "SHA-1", "sha256": "SHA-256", "sha384": "SHA-384", "sha512": "SHA-512"}
+			if name, ok := names[alg]; ok {
+				c.Hashes = []Hash{{Alg: name, Content: hex.EncodeToString(sum)}}
+			}
+		}
+	}
+	return c
+}
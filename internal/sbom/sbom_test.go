@@ -0,0 +1,109 @@
+package sbom
+
+import (
+	"encoding/json"
+	"regexp"
+	"runtime/debug"
+	"testing"
+)
+
+func TestNPMComponents(t *testing.T) {
+	lock := `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "notes", "version": "1.0.0"},
+    "node_modules/date-fns": {"version": "3.6.0", "integrity": "sha512-AAECAw=="},
+    "node_modules/@types/node": {"version": "20.11.0", "dev": true},
+    "node_modules/vite/node_modules/esbuild": {"version": "0.20.2", "dev": true, "integrity": "sha1-AAEC sha512-AAECAw=="},
+    "node_modules/local-lib": {"resolved": "../lib", "link": true}
+  }
+}`
+	got, err := NPMComponents([]byte(lock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byRef := map[string]Component{}
+	for _, c := range got {
+		byRef[c.BOMRef] = c
+	}
+	if len(byRef) != 3 {
+		t.Fatalf("got %d components, want 3: %+v", len(byRef), got)
+	}
+	dateFns := byRef["pkg:npm/date-fns@3.6.0"]
+	if dateFns.Scope != "required" || len(dateFns.Hashes) != 1 || dateFns.Hashes[0] != (Hash{"SHA-512", "00010203"}) {
+		t.Errorf("date-fns = %+v", dateFns)
+	}
+	if c := byRef["pkg:npm/%40types/node@20.11.0"]; c.Name != "@types/node" || c.Scope != "excluded" {
+		t.Errorf("@types/node = %+v", c)
+	}
+	if c := byRef["pkg:npm/esbuild@0.20.2"]; c.Name != "esbuild" || len(c.Hashes) != 1 || c.Hashes[0].Alg != "SHA-1" {
+		t.Errorf("nested esbuild = %+v", c)
+	}
+}
+
+func TestNPMComponentsV1(t *testing.T) {
+	lock := `{
+  "lockfileVersion": 1,
+  "dependencies": {
+    "a": {"version": "1.0.0", "dependencies": {"b": {"version": "2.0.0", "dev": true}}}
+  }
+}`
+	got, err := NPMComponents([]byte(lock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %+v, want a and b", got)
+	}
+}
+
+func TestGoComponents(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.23.4",
+		Main:      debug.Module{Path: "lightshell-app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/sys", Version: "v0.20.0"},
+			{Path: "example.com/old", Version: "v1.0.0", Replace: &debug.Module{Path: "example.com/fork", Version: "v1.0.1"}},
+		},
+	}
+	got := GoComponents(info)
+	var purls []string
+	for _, c := range got {
+		purls = append(purls, c.PURL)
+		if c.BOMRef != c.PURL {
+			t.Errorf("%s has bom-ref %q", c.PURL, c.BOMRef)
+		}
+	}
+	want := []string{"pkg:golang/stdlib@go1.23.4", "pkg:golang/golang.org/x/sys@v0.20.0", "pkg:golang/example.com/fork@v1.0.1"}
+	if len(purls) != len(want) {
+		t.Fatalf("purls = %v, want %v", purls, want)
+	}
+	for i := range want {
+		if purls[i] != want[i] {
+			t.Errorf("purls[%d] = %s, want %s", i, purls[i], want[i])
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	doc := New(Component{Type: "application", Name: "Notes", Version: "1.0.0"}, "lightshell", "0.1.0", []Component{
+		{Type: "library", BOMRef: "pkg:npm/b@1", Name: "b"},
+		{Type: "library", BOMRef: "pkg:npm/a@1", Name: "a"},
+	})
+	if doc.BOMFormat != "CycloneDX" || doc.SpecVersion != SpecVersion || doc.Version != 1 {
+		t.Errorf("header = %+v", doc)
+	}
+	if !regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(doc.SerialNumber) {
+		t.Errorf("serialNumber = %s", doc.SerialNumber)
+	}
+	if doc.Components[0].Name != "a" {
+		t.Errorf("components are not sorted: %+v", doc.Components)
+	}
+	deps := doc.Dependencies
+	if len(deps) != 1 || deps[0].Ref != "Notes" || len(deps[0].DependsOn) != 2 {
+		t.Errorf("dependencies = %+v", deps)
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package scripting describes the AppleScript interface of a scriptable
+// macOS app: the Apple Events it handles and the scripting dictionary
+// (.sdef) a built app ships so AppleScript and Shortcuts can see them.
+package scripting
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// Apple Event codes of the "run action" command. The suite code is shared
+// by every LightShell app, so a script can address any of them the same
+// way.
+const (
+	SuiteCode     = "LSap"
+	RunActionCode = "rAct"
+	ArgumentCode  = "wIth" // keyword of the optional "with" parameter
+)
+
+// DictionaryName returns the file name of the app's scripting dictionary
+// in Contents/Resources.
+func DictionaryName(appName string) string {
+	return appName + ".sdef"
+}
+
+// Dictionary returns the scripting dictionary of an app: the standard open
+// and quit commands plus "run action", which takes an action name and an
+// optional text argument. Listed actions are named in its description.
+func Dictionary(title string, actions []string) []byte {
+	actionDesc := "The name of the action to run."
+	if len(actions) > 0 {
+		actionDesc = "The action to run: " + strings.Join(actions, ", ") + "."
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE dictionary SYSTEM "file://localhost/System/Library/DTDs/sdef.dtd">
+`)
+	b.WriteString(`<dictionary title="` + escape(title) + ` Terminology">
+	<suite name="Standard Suite" code="????" description="Common commands.">
+		<command name="open" code="aevtodoc" description="Open files.">
+			<direct-parameter description="The file or files to open.">
+				<type type="file"/>
+				<type type="file" list="yes"/>
+			</direct-parameter>
+		</command>
+		<command name="quit" code="aevtquit" description="Quit the application."/>
+	</suite>
+`)
+	b.WriteString(`	<suite name="` + escape(title) + ` Suite" code="` + SuiteCode + `" description="Commands provided by ` + escape(title) + `.">
+		<command name="run action" code="` + SuiteCode + RunActionCode + `" description="Run an action in ` + escape(title) + `.">
+			<direct-parameter type="text" description="` + escape(actionDesc) + `"/>
+			<parameter name="with" code="` + ArgumentCode + `" type="text" optional="yes" description="A value passed to the action."/>
+		</command>
+	</suite>
+</dictionary>
+`)
+	return b.Bytes()
+}
+
+// Allows reports whether "run action" accepts action. An empty list
+// accepts any action.
+func Allows(actions []string, action string) bool {
+	if len(actions) == 0 {
+		return action != ""
+	}
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
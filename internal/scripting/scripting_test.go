@@ -0,0 +1,64 @@
+package scripting
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestDictionary(t *testing.T) {
+	data := Dictionary(`Notes & "Tasks"`, []string{"newNote", "sync"})
+
+	var dict struct {
+		Title  string `xml:"title,attr"`
+		Suites []struct {
+			Code     string `xml:"code,attr"`
+			Commands []struct {
+				Name string `xml:"name,attr"`
+				Code string `xml:"code,attr"`
+			} `xml:"command"`
+		} `xml:"suite"`
+	}
+	dec := xml.NewDecoder(strings.NewReader(string(data)))
+	dec.Strict = false
+	if err := dec.Decode(&dict); err != nil {
+		t.Fatalf("dictionary is not valid XML: %v\n%s", err, data)
+	}
+	if dict.Title != `Notes & "Tasks" Terminology` {
+		t.Errorf("title = %q", dict.Title)
+	}
+
+	codes := map[string]string{}
+	for _, s := range dict.Suites {
+		for _, c := range s.Commands {
+			codes[c.Name] = c.Code
+		}
+	}
+	want := map[string]string{"open": "aevtodoc", "quit": "aevtquit", "run action": "LSaprAct"}
+	for name, code := range want {
+		if codes[name] != code {
+			t.Errorf("command %q code = %q, want %q", name, codes[name], code)
+		}
+	}
+	if !strings.Contains(string(data), "newNote, sync") {
+		t.Errorf("run action description does not list the actions:\n%s", data)
+	}
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		actions []string
+		action  string
+		want    bool
+	}{
+		{nil, "anything", true},
+		{nil, "", false},
+		{[]string{"newNote", "sync"}, "sync", true},
+		{[]string{"newNote", "sync"}, "delete", false},
+	}
+	for _, tt := range tests {
+		if got := Allows(tt.actions, tt.action); got != tt.want {
+			t.Errorf("Allows(%v, %q) = %v, want %v", tt.actions, tt.action, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package security
+
+// ProductionCSP is the Content-Security-Policy of a built app's pages,
+// which lightshell run and apps embedding the runtime apply too.
+const ProductionCSP = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data: blob:; font-src 'self' data:; connect-src 'self'; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+// DevCSP is the Content-Security-Policy of lightshell dev. It allows inline
+// scripts and eval, which dev tools use, and the loopback origins of dev
+// servers and hot reload, but scripts from remote hosts are blocked as they
+// are in a built app.
+const DevCSP = "default-src 'self' 'unsafe-inline' 'unsafe-eval' data: blob: lightshell: http://localhost:* http://127.0.0.1:* ws://localhost:* ws://127.0.0.1:*"
@@ -0,0 +1,39 @@
+package security
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NavigationPolicy decides which URLs an app's main window may navigate
+// to: those on the origin of its pages, about: pages, and the http and
+// https URLs Allow lets through. Allow takes the patterns of the http
+// permission's scope, such as "*.example.com" or
+// "https://accounts.example.com/oauth/**".
+type NavigationPolicy struct {
+	PageURL string // a URL on the pages' origin, such as the entry page's
+	Allow   []string
+}
+
+// Allows reports whether the main window may navigate to rawURL.
+func (p NavigationPolicy) Allows(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "about" {
+		return true
+	}
+	if page, err := url.Parse(p.PageURL); err == nil && u.Scheme == page.Scheme && strings.EqualFold(u.Host, page.Host) {
+		return true
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	for _, pattern := range p.Allow {
+		if matchHTTP(u, pattern) {
+			return true
+		}
+	}
+	return false
+}
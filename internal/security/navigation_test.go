@@ -0,0 +1,41 @@
+package security
+
+import "testing"
+
+func TestNavigationPolicy(t *testing.T) {
+	policy := NavigationPolicy{
+		PageURL: "app://localhost/index.html",
+		Allow:   []string{"*.example.com", "https://auth.example.org/oauth/**"},
+	}
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"app://localhost/settings.html", true},
+		{"app://LOCALHOST/index.html#top", true},
+		{"about:blank", true},
+		{"https://docs.example.com/guide", true},
+		{"http://docs.example.com/guide", true},
+		{"https://auth.example.org/oauth/authorize?client=1", true},
+		{"https://auth.example.org/login", false},
+		{"http://auth.example.org/oauth/authorize", false},
+		{"https://evil.com/", false},
+		{"app://other/index.html", false},
+		{"file:///etc/passwd", false},
+		{"data:text/html,<script>alert(1)</script>", false},
+		{"javascript:alert(1)", false},
+	}
+	for _, tt := range tests {
+		if got := policy.Allows(tt.url); got != tt.want {
+			t.Errorf("Allows(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	dev := NavigationPolicy{PageURL: "http://127.0.0.1:5173/"}
+	if !dev.Allows("http://127.0.0.1:5173/about") {
+		t.Error("dev server pages should be allowed")
+	}
+	if dev.Allows("http://127.0.0.1:8080/") {
+		t.Error("another loopback port should not be allowed")
+	}
+}
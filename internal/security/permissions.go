@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/paths"
+	"github.com/lightshell-dev/lightshell/internal/tempspace"
 )
 
 // Permission represents an API permission that an app can request.
@@ -26,6 +29,11 @@ const (
 	PermStore        Permission = "store"
 	PermShortcuts    Permission = "shortcuts"
 	PermUpdater      Permission = "updater"
+	PermPower        Permission = "power"
+	PermImage        Permission = "image"
+	PermPDF          Permission = "pdf"
+	PermShare        Permission = "share"
+	PermCodes        Permission = "codes"
 	// window, system, and app are always allowed -- they're core APIs
 )
 
@@ -34,6 +42,7 @@ var AllPermissions = []Permission{
 	PermFS, PermDialog, PermClipboard, PermShell,
 	PermNotification, PermTray, PermMenu,
 	PermHTTP, PermProcess, PermStore, PermShortcuts, PermUpdater,
+	PermPower, PermImage, PermPDF, PermShare, PermCodes,
 }
 
 // FSScope holds scoped filesystem permission patterns.
@@ -44,8 +53,8 @@ type FSScope struct {
 
 // HTTPScope holds scoped HTTP permission patterns.
 type HTTPScope struct {
-	Allow []string `json:"allow"` // allowed domain patterns (e.g., "*.github.com", "api.example.com")
-	Deny  []string `json:"deny"`  // denied domain patterns (checked first)
+	Allow []string `json:"allow"` // allowed domain or URL patterns (e.g., "*.github.com", "https://api.example.com/**")
+	Deny  []string `json:"deny"`  // denied domain or URL patterns (checked first)
 }
 
 // ProcessScope holds scoped process execution permissions.
@@ -71,6 +80,8 @@ type Policy struct {
 	fsScope      *FSScope
 	httpScope    *HTTPScope
 	processScope *ProcessScope
+
+	asker *asker // set by SetAsk
 }
 
 // NewPolicy creates a security policy from the declared permissions.
@@ -88,13 +99,14 @@ func NewPolicy(perms []string, projectDir string, appName string, devMode bool)
 	// Build allowed directories for FS access
 	p.allowedDirs = []string{projectDir}
 
-	// Always allow app data dir
-	if home, err := os.UserHomeDir(); err == nil {
-		dataDir := filepath.Join(home, "Library", "Application Support", appName)
-		p.allowedDirs = append(p.allowedDirs, dataDir)
-		// Also allow Linux data dir
-		linuxDataDir := filepath.Join(home, ".local", "share", appName)
-		p.allowedDirs = append(p.allowedDirs, linuxDataDir)
+	// Always allow the app's own data, config, cache, and log dirs
+	if appName != "" {
+		dirs := paths.For(appName)
+		p.allowedDirs = append(p.allowedDirs, dirs.Data)
+		if dirs.Config != dirs.Data {
+			p.allowedDirs = append(p.allowedDirs, dirs.Config)
+		}
+		p.allowedDirs = append(p.allowedDirs, dirs.Cache, dirs.Log)
 	}
 
 	// Always allow temp dir
@@ -226,8 +238,14 @@ func (p *Policy) CheckPath(path string) error {
 	}
 }
 
-// CheckFSRead verifies that the path is allowed for reading.
+// CheckFSRead verifies that the path is allowed for reading, asking the
+// user about it in ask mode.
 func (p *Policy) CheckFSRead(path string) error {
+	req := normalize(Request{API: PermFS, Access: "read", Target: path})
+	return p.ask(req, p.checkFSRead(path))
+}
+
+func (p *Policy) checkFSRead(path string) error {
 	if p.devMode {
 		return nil
 	}
@@ -268,8 +286,14 @@ func (p *Policy) CheckFSRead(path string) error {
 	return p.checkPathAgainstDirs(resolved, "fs", "readFile", path)
 }
 
-// CheckFSWrite verifies that the path is allowed for writing.
+// CheckFSWrite verifies that the path is allowed for writing, asking the
+// user about it in ask mode.
 func (p *Policy) CheckFSWrite(path string) error {
+	req := normalize(Request{API: PermFS, Access: "write", Target: path})
+	return p.ask(req, p.checkFSWrite(path))
+}
+
+func (p *Policy) checkFSWrite(path string) error {
 	if p.devMode {
 		return nil
 	}
@@ -310,8 +334,13 @@ func (p *Policy) CheckFSWrite(path string) error {
 	return p.checkPathAgainstDirs(resolved, "fs", "writeFile", path)
 }
 
-// CheckHTTP verifies that an HTTP request to the given URL is allowed.
+// CheckHTTP verifies that an HTTP request to the given URL is allowed,
+// asking the user about its host in ask mode.
 func (p *Policy) CheckHTTP(rawURL string) error {
+	return p.ask(Request{API: PermHTTP, Target: rawURL}, p.checkHTTP(rawURL))
+}
+
+func (p *Policy) checkHTTP(rawURL string) error {
 	if p.devMode {
 		return nil
 	}
@@ -335,11 +364,9 @@ func (p *Policy) CheckHTTP(rawURL string) error {
 		return nil
 	}
 
-	host := parsed.Hostname()
-
 	// Check deny list first
 	for _, pattern := range p.httpScope.Deny {
-		if matchDomain(host, pattern) {
+		if matchHTTP(parsed, pattern) {
 			return &PermissionError{
 				Namespace: "http",
 				Method:    "fetch",
@@ -357,7 +384,7 @@ func (p *Policy) CheckHTTP(rawURL string) error {
 
 	// Check allow list
 	for _, pattern := range p.httpScope.Allow {
-		if matchDomain(host, pattern) {
+		if matchHTTP(parsed, pattern) {
 			return nil
 		}
 	}
@@ -371,8 +398,14 @@ func (p *Policy) CheckHTTP(rawURL string) error {
 	}
 }
 
-// CheckProcess verifies that a command execution is allowed.
+// CheckProcess verifies that a command execution is allowed, asking the
+// user about it in ask mode. There is no process API yet, so nothing calls
+// it; it keeps permissions.process meaningful for when one is added.
 func (p *Policy) CheckProcess(cmd string, args []string) error {
+	return p.ask(Request{API: PermProcess, Target: cmd, Args: args}, p.checkProcess(cmd, args))
+}
+
+func (p *Policy) checkProcess(cmd string, args []string) error {
 	if p.devMode {
 		return nil
 	}
@@ -445,6 +478,12 @@ func (p *Policy) CheckProcess(cmd string, args []string) error {
 	}
 }
 
+// ExpandPath expands path variables such as $DOWNLOADS in a path the app
+// passed in, using the same values as permission patterns.
+func (p *Policy) ExpandPath(path string) string {
+	return resolvePathVariable(path, p.appName)
+}
+
 // AllowDir adds an additional allowed directory (e.g., user-selected via dialog).
 func (p *Policy) AllowDir(dir string) {
 	p.mu.Lock()
@@ -546,28 +585,29 @@ func resolveRealPath(absPath string) string {
 	return filepath.Join(resolved, filepath.Base(absPath))
 }
 
-// resolvePathVariable expands path variables like $APP_DATA, $HOME, $TEMP, $DOWNLOADS, $DESKTOP.
+// resolvePathVariable expands path variables like $APP_DATA, $APP_CONFIG,
+// $APP_CACHE, $APP_LOG, $APP_TEMP, $HOME, $TEMP, $DOWNLOADS, $DESKTOP.
 func resolvePathVariable(pattern string, appName string) string {
 	home, _ := os.UserHomeDir()
-
-	replacements := map[string]string{
-		"$HOME":      home,
-		"$TEMP":      os.TempDir(),
-		"$DOWNLOADS": filepath.Join(home, "Downloads"),
-		"$DESKTOP":   filepath.Join(home, "Desktop"),
-	}
-
-	// Platform-specific app data directory
-	switch runtime.GOOS {
-	case "darwin":
-		replacements["$APP_DATA"] = filepath.Join(home, "Library", "Application Support", appName)
-	default:
-		replacements["$APP_DATA"] = filepath.Join(home, ".local", "share", appName)
+	dirs := paths.For(appName)
+
+	// Ordered so longer variables are replaced before any variable that is
+	// a prefix of them.
+	replacements := []struct{ variable, value string }{
+		{"$APP_DATA", dirs.Data},
+		{"$APP_CONFIG", dirs.Config},
+		{"$APP_CACHE", dirs.Cache},
+		{"$APP_LOG", dirs.Log},
+		{"$APP_TEMP", tempspace.Root(appName)},
+		{"$DOWNLOADS", filepath.Join(home, "Downloads")},
+		{"$DESKTOP", filepath.Join(home, "Desktop")},
+		{"$HOME", home},
+		{"$TEMP", os.TempDir()},
 	}
 
 	result := pattern
-	for variable, value := range replacements {
-		result = strings.ReplaceAll(result, variable, value)
+	for _, r := range replacements {
+		result = strings.ReplaceAll(result, r.variable, r.value)
 	}
 
 	return result
@@ -631,6 +671,52 @@ func matchDoubleStarGlob(realPath, pattern string) bool {
 	return strings.HasSuffix(realPath, suffix)
 }
 
+// matchHTTP matches a request URL against an HTTP scope pattern: a domain
+// pattern for matchDomain, or a URL pattern such as
+// "https://*.example.com/api/**" whose scheme must match exactly and whose
+// path is a glob, with a trailing ** matching any path below it. The path
+// is matched after unescaping and cleaning (see requestPath). A port must be
+// given to be allowed.
+func matchHTTP(u *url.URL, pattern string) bool {
+	if !strings.Contains(pattern, "://") {
+		return matchDomain(u.Hostname(), pattern)
+	}
+	scheme, rest, _ := strings.Cut(pattern, "://")
+	hostPattern, pathPattern, _ := strings.Cut(rest, "/")
+	pathPattern = "/" + pathPattern
+	if !strings.EqualFold(u.Scheme, scheme) {
+		return false
+	}
+	hostPattern, port, _ := strings.Cut(hostPattern, ":")
+	if u.Port() != port {
+		return false
+	}
+	if !matchDomain(u.Hostname(), hostPattern) {
+		return false
+	}
+
+	reqPath := requestPath(u)
+	if prefix, ok := strings.CutSuffix(pathPattern, "**"); ok {
+		return strings.HasPrefix(reqPath, prefix) || reqPath+"/" == prefix
+	}
+	matched, err := path.Match(pathPattern, reqPath)
+	return err == nil && matched
+}
+
+// requestPath returns the path a server will see for u: unescaped and
+// cleaned, so "/api/../admin" and "/api/%2e%2e/admin" both become "/admin"
+// rather than matching a rule for "/api/**". A trailing slash is kept.
+func requestPath(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	cleaned := path.Clean("/" + u.Path)
+	if strings.HasSuffix(u.Path, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
 // matchDomain matches a hostname against a domain pattern.
 // Supports wildcards: "*.example.com" matches "api.example.com" and "sub.api.example.com".
 // Exact match: "example.com" matches only "example.com".
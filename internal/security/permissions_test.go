@@ -1,6 +1,7 @@
 package security
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -273,6 +274,45 @@ func TestCheckHTTPDenyTakesPrecedence(t *testing.T) {
 	}
 }
 
+func TestCheckHTTPWithURLPatterns(t *testing.T) {
+	dir := resolvedTempDir(t)
+	p := NewPolicy([]string{"http"}, dir, "test-app", false)
+	p.SetHTTPScope(HTTPScope{
+		Allow: []string{"https://api.example.com/**", "https://*.cdn.example.com/assets/*.js"},
+		Deny:  []string{"https://api.example.com/admin/**"},
+	})
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://api.example.com", true},
+		{"https://api.example.com/items?page=2", true},
+		{"https://api.example.com/v1/items", true},
+		{"http://api.example.com/items", false},
+		{"https://api.example.com:8443/items", false},
+		{"https://api.example.com/admin", false},
+		{"https://api.example.com/admin/users", false},
+		{"https://eu.cdn.example.com/assets/app.js", true},
+		{"https://eu.cdn.example.com/assets/app.css", false},
+		{"https://eu.cdn.example.com/assets/lib/app.js", false},
+		{"https://other.example.com/items", false},
+		{"https://api.example.com/v1/../admin/users", false},
+		{"https://api.example.com/v1/%2e%2e/admin", false},
+		{"https://api.example.com/v1/%2E%2E/%2e%2e/admin/", false},
+		{"https://api.example.com/v1//admin/../items", true},
+		{"https://eu.cdn.example.com/assets/lib/../app.js", true},
+		{"https://eu.cdn.example.com/other/../assets/app.js", true},
+		{"https://eu.cdn.example.com/assets/%2e%2e/secret.js", false},
+	}
+	for _, tt := range tests {
+		err := p.CheckHTTP(tt.url)
+		if (err == nil) != tt.allowed {
+			t.Errorf("CheckHTTP(%s) = %v, want allowed %v", tt.url, err, tt.allowed)
+		}
+	}
+}
+
 func TestCheckHTTPInvalidURL(t *testing.T) {
 	dir := resolvedTempDir(t)
 	p := NewPolicy([]string{"http"}, dir, "test-app", false)
@@ -421,6 +461,14 @@ func TestResolvePathVariableTemp(t *testing.T) {
 	}
 }
 
+func TestResolvePathVariableAppTemp(t *testing.T) {
+	result := resolvePathVariable("$APP_TEMP/**", "myapp")
+	want := filepath.Join(os.TempDir(), fmt.Sprintf("lightshell-%d-myapp", os.Getuid())) + "/**"
+	if result != want {
+		t.Errorf("expected %q, got %q", want, result)
+	}
+}
+
 func TestPermissionErrorFormat(t *testing.T) {
 	err := &PermissionError{
 		Namespace: "fs",
@@ -473,7 +521,8 @@ func TestCheckPathWithFSScope(t *testing.T) {
 func TestAllPermissionsContainsExpected(t *testing.T) {
 	expected := []Permission{PermFS, PermDialog, PermClipboard, PermShell,
 		PermNotification, PermTray, PermMenu, PermHTTP, PermProcess,
-		PermStore, PermShortcuts, PermUpdater}
+		PermStore, PermShortcuts, PermUpdater, PermPower,
+		PermImage, PermPDF, PermShare, PermCodes}
 
 	for _, perm := range expected {
 		found := false
@@ -523,3 +572,14 @@ func TestResolveRealPathNonexistent(t *testing.T) {
 		t.Errorf("expected fallback to original, got %q", result)
 	}
 }
+
+func TestExpandPath(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	p := NewPolicy([]string{"http"}, t.TempDir(), "myapp", false)
+	if got, want := p.ExpandPath("$DOWNLOADS/report.pdf"), filepath.Join(home, "Downloads")+"/report.pdf"; got != want {
+		t.Errorf("ExpandPath = %q, want %q", got, want)
+	}
+	if got := p.ExpandPath("/tmp/x"); got != "/tmp/x" {
+		t.Errorf("ExpandPath changed a plain path: %q", got)
+	}
+}
@@ -0,0 +1,317 @@
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Request is a use of a scoped API that the user can be asked to allow:
+// a path to read or write, a URL to fetch, or a command to run.
+type Request struct {
+	API    Permission `json:"api"`              // PermFS, PermHTTP, or PermProcess
+	Access string     `json:"access,omitempty"` // for fs: "read" or "write"
+	Target string     `json:"target"`           // the path, URL, or command
+	Args   []string   `json:"args,omitempty"`   // the command's arguments
+}
+
+// Decision is the user's answer to a permission prompt.
+type Decision int
+
+const (
+	Deny        Decision = iota
+	Allow                // until the app quits
+	AlwaysAllow          // remembered in the grants file
+)
+
+// Prompter asks the user whether to allow req, showing them req.Prompt.
+type Prompter func(req Request) Decision
+
+// State is what a Request would meet, as lightshell.permissions.query
+// reports it.
+type State string
+
+const (
+	Granted State = "granted"
+	Denied  State = "denied"
+	Prompt  State = "prompt" // the user would be asked
+)
+
+// AskableAPIs are the APIs whose denials can be put to the user.
+var AskableAPIs = []Permission{PermFS, PermHTTP, PermProcess}
+
+// Prompt returns the question put to the user about req, such as "Notes
+// wants to read ~/Documents/report.txt".
+func (r Request) Prompt(appName string) string {
+	switch r.API {
+	case PermFS:
+		return fmt.Sprintf("%s wants to %s %s", appName, r.Access, tildePath(r.Target))
+	case PermHTTP:
+		return fmt.Sprintf("%s wants to connect to %s", appName, r.origin())
+	default:
+		return fmt.Sprintf("%s wants to run %s", appName, strings.Join(append([]string{r.Target}, r.Args...), " "))
+	}
+}
+
+// key identifies what a grant covers: a path with its access, the origin
+// of a URL, or a command with exactly its arguments.
+func (r Request) key() string {
+	target := r.Target
+	if r.API == PermHTTP {
+		target = r.origin()
+	}
+	return strings.Join(append([]string{string(r.API), r.Access, target}, r.Args...), "\x00")
+}
+
+// origin returns the scheme and host of an http request's URL.
+func (r Request) origin() string {
+	u, err := url.Parse(r.Target)
+	if err != nil || u.Host == "" {
+		return r.Target
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// tildePath shortens a path in the home directory to start with ~.
+func tildePath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(os.PathSeparator)); ok {
+		return "~" + string(os.PathSeparator) + rest
+	}
+	return path
+}
+
+// asker holds what ask mode needs: the APIs it covers, the prompt, and the
+// user's answers so far.
+type asker struct {
+	apis   map[Permission]bool
+	prompt Prompter
+	grants *Grants
+
+	promptMu  sync.Mutex // one prompt at a time
+	sessionMu sync.Mutex
+	session   map[string]bool // answers that last until the app quits
+}
+
+// SetAsk turns on ask mode for apis: a denied path, URL, or command of
+// theirs is put to the user with prompt instead of failing. Grants the
+// user makes with Always Allow are kept in the JSON file at grantsPath. A
+// nil prompt, as on platforms without the dialog, leaves denials as they
+// are.
+func (p *Policy) SetAsk(apis []Permission, prompt Prompter, grantsPath string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	a := &asker{
+		apis:    make(map[Permission]bool, len(apis)),
+		prompt:  prompt,
+		session: make(map[string]bool),
+		grants:  LoadGrants(grantsPath),
+	}
+	for _, api := range apis {
+		a.apis[api] = true
+	}
+	p.asker = a
+}
+
+// Query returns the state req is in, without asking the user.
+func (p *Policy) Query(req Request) State {
+	req = normalize(req)
+	err := p.check(req)
+	if err == nil {
+		return Granted
+	}
+	a := p.askerFor(req, err)
+	if a == nil {
+		return Denied
+	}
+	if state, ok := a.answered(req); ok {
+		return state
+	}
+	return Prompt
+}
+
+// Request asks the user about req if they would be asked when the app
+// used it, and returns the state it is in after their answer.
+func (p *Policy) Request(req Request) State {
+	req = normalize(req)
+	if p.ask(req, p.check(req)) == nil {
+		return Granted
+	}
+	return Denied
+}
+
+// normalize resolves the path of an fs request, as the fs checks do, so
+// that answers about it apply however the app spells it.
+func normalize(req Request) Request {
+	if req.API == PermFS {
+		if abs, err := filepath.Abs(req.Target); err == nil {
+			req.Target = resolveRealPath(abs)
+		}
+	}
+	return req
+}
+
+// check runs the checks of the API req uses.
+func (p *Policy) check(req Request) error {
+	if err := p.Check(req.API); err != nil {
+		return err
+	}
+	switch req.API {
+	case PermFS:
+		if req.Access == "write" {
+			return p.checkFSWrite(req.Target)
+		}
+		return p.checkFSRead(req.Target)
+	case PermHTTP:
+		return p.checkHTTP(req.Target)
+	case PermProcess:
+		return p.checkProcess(req.Target, req.Args)
+	}
+	return fmt.Errorf("permissions cannot be requested for %q; only fs, http, and process", req.API)
+}
+
+// ask returns err, the result of checking req, unless ask mode covers the
+// denial and the user allows req, now or before.
+func (p *Policy) ask(req Request, err error) error {
+	a := p.askerFor(req, err)
+	if a == nil {
+		return err
+	}
+	if state, ok := a.answered(req); ok {
+		if state == Granted {
+			return nil
+		}
+		return err
+	}
+
+	a.promptMu.Lock()
+	defer a.promptMu.Unlock()
+	// Another call may have asked while this one waited
+	if state, ok := a.answered(req); ok {
+		if state == Granted {
+			return nil
+		}
+		return err
+	}
+	decision := a.prompt(req)
+	a.sessionMu.Lock()
+	a.session[req.key()] = decision != Deny
+	a.sessionMu.Unlock()
+	if decision == AlwaysAllow {
+		if saveErr := a.grants.Add(req); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save permission grant: %v\n", saveErr)
+		}
+	}
+	if decision == Deny {
+		return err
+	}
+	return nil
+}
+
+// askerFor returns the asker that can put a denial of req to the user, or
+// nil. APIs that are not declared, hosts the http scope denies outright,
+// and URLs that do not parse are never asked about.
+func (p *Policy) askerFor(req Request, err error) *asker {
+	var permErr *PermissionError
+	if !errors.As(err, &permErr) || permErr.ConfigKey == "permissions.http.deny" || permErr.ConfigKey == "permissions.http" {
+		return nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	a := p.asker
+	if a == nil || a.prompt == nil || !a.apis[req.API] || !p.permissions[req.API] {
+		return nil
+	}
+	return a
+}
+
+// answered returns the user's earlier answer about req, if any.
+func (a *asker) answered(req Request) (State, bool) {
+	if a.grants.Has(req) {
+		return Granted, true
+	}
+	a.sessionMu.Lock()
+	allowed, ok := a.session[req.key()]
+	a.sessionMu.Unlock()
+	if !ok {
+		return "", false
+	}
+	if allowed {
+		return Granted, true
+	}
+	return Denied, true
+}
+
+// Grants are the requests the user chose to always allow, kept as JSON in
+// the app's data directory.
+type Grants struct {
+	mu    sync.Mutex
+	path  string
+	list  []Request
+	index map[string]bool
+}
+
+// LoadGrants reads the grants file at path. A missing or unreadable file
+// holds no grants.
+func LoadGrants(path string) *Grants {
+	g := &Grants{path: path, index: make(map[string]bool)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return g
+	}
+	var file struct {
+		Grants []Request `json:"grants"`
+	}
+	if json.Unmarshal(data, &file) != nil {
+		return g
+	}
+	for _, req := range file.Grants {
+		if !g.index[req.key()] {
+			g.index[req.key()] = true
+			g.list = append(g.list, req)
+		}
+	}
+	return g
+}
+
+// Has reports whether req was always allowed.
+func (g *Grants) Has(req Request) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.index[req.key()]
+}
+
+// Add records req and saves the file.
+func (g *Grants) Add(req Request) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.index[req.key()] {
+		return nil
+	}
+	if req.API == PermHTTP {
+		req.Target = req.origin()
+	}
+	g.index[req.key()] = true
+	g.list = append(g.list, req)
+	if g.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(struct {
+		Grants []Request `json:"grants"`
+	}{g.list}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(g.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(g.path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,149 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// askPolicy returns a policy confined to its project dir, asking about fs,
+// http, and process with prompts answered by decide, and the number of
+// prompts shown.
+func askPolicy(t *testing.T, grantsPath string, decide Decision) (*Policy, *int) {
+	t.Helper()
+	p := NewPolicy([]string{"fs", "http", "process"}, resolvedTempDir(t), "test-app", false)
+	p.SetHTTPScope(HTTPScope{Allow: []string{"api.example.com"}, Deny: []string{"evil.example.com"}})
+	prompts := new(int)
+	p.SetAsk(AskableAPIs, func(req Request) Decision {
+		*prompts++
+		return decide
+	}, grantsPath)
+	return p, prompts
+}
+
+func TestAskAllowLastsForTheSession(t *testing.T) {
+	outside := "/etc/passwd"
+	p, prompts := askPolicy(t, "", Allow)
+
+	if state := p.Query(Request{API: PermFS, Access: "read", Target: outside}); state != Prompt {
+		t.Errorf("Query before asking = %q, want prompt", state)
+	}
+	for i := 0; i < 2; i++ {
+		if err := p.CheckFSRead(outside); err != nil {
+			t.Fatalf("read allowed by the user was denied: %v", err)
+		}
+	}
+	if *prompts != 1 {
+		t.Errorf("prompted %d times, want once", *prompts)
+	}
+	if err := p.CheckFSWrite(outside); err != nil {
+		t.Fatalf("write allowed by the user was denied: %v", err)
+	}
+	if *prompts != 2 {
+		t.Errorf("a write was not asked about separately from a read")
+	}
+}
+
+func TestAskDenyIsRemembered(t *testing.T) {
+	p, prompts := askPolicy(t, "", Deny)
+
+	if err := p.CheckProcess("git", []string{"status"}); err == nil {
+		t.Fatal("expected the denied command to fail")
+	}
+	if state := p.Query(Request{API: PermProcess, Target: "git", Args: []string{"status"}}); state != Denied {
+		t.Errorf("Query after a denial = %q, want denied", state)
+	}
+	if state := p.Request(Request{API: PermProcess, Target: "git", Args: []string{"status"}}); state != Denied {
+		t.Errorf("Request after a denial = %q, want denied", state)
+	}
+	if *prompts != 1 {
+		t.Errorf("prompted %d times, want once", *prompts)
+	}
+}
+
+func TestAskAlwaysAllowIsSaved(t *testing.T) {
+	grants := filepath.Join(t.TempDir(), "permissions.json")
+	p, _ := askPolicy(t, grants, AlwaysAllow)
+
+	if state := p.Request(Request{API: PermHTTP, Target: "https://cdn.example.org/a.js"}); state != Granted {
+		t.Fatalf("Request = %q, want granted", state)
+	}
+	data, err := os.ReadFile(grants)
+	if err != nil {
+		t.Fatalf("grants were not saved: %v", err)
+	}
+	if !strings.Contains(string(data), `"https://cdn.example.org"`) {
+		t.Errorf("the grant should cover the origin, got %s", data)
+	}
+
+	// A later run finds the grant without asking
+	p, prompts := askPolicy(t, grants, Deny)
+	if err := p.CheckHTTP("https://cdn.example.org/b.js"); err != nil {
+		t.Errorf("saved grant was not applied: %v", err)
+	}
+	if err := p.CheckHTTP("http://cdn.example.org/b.js"); err == nil {
+		t.Error("the grant should not cover another scheme")
+	}
+	if *prompts != 1 {
+		t.Errorf("prompted %d times, want once for the other scheme", *prompts)
+	}
+}
+
+func TestAskNeverOverridesHTTPDeny(t *testing.T) {
+	p, prompts := askPolicy(t, "", AlwaysAllow)
+	if err := p.CheckHTTP("https://evil.example.com/"); err == nil {
+		t.Error("expected a host in http.deny to stay denied")
+	}
+	if state := p.Query(Request{API: PermHTTP, Target: "https://evil.example.com/"}); state != Denied {
+		t.Errorf("Query = %q, want denied", state)
+	}
+	if *prompts != 0 {
+		t.Errorf("prompted about a denied host")
+	}
+}
+
+func TestAskOnlyCoversItsAPIs(t *testing.T) {
+	p := NewPolicy([]string{"fs"}, resolvedTempDir(t), "test-app", false)
+	p.SetAsk([]Permission{PermHTTP}, func(Request) Decision { return Allow }, "")
+	if err := p.CheckFSRead("/etc/passwd"); err == nil {
+		t.Error("fs was asked about without being in ask mode")
+	}
+
+	p.SetAsk([]Permission{PermFS}, nil, "")
+	if state := p.Query(Request{API: PermFS, Access: "read", Target: "/etc/passwd"}); state != Denied {
+		t.Errorf("Query without a prompt = %q, want denied", state)
+	}
+
+	p.SetAsk([]Permission{PermProcess}, func(Request) Decision { return Allow }, "")
+	if state := p.Request(Request{API: PermProcess, Target: "git"}); state != Denied {
+		t.Errorf("Request for an undeclared API = %q, want denied", state)
+	}
+}
+
+func TestQueryGranted(t *testing.T) {
+	p, _ := askPolicy(t, "", Deny)
+	if state := p.Query(Request{API: PermHTTP, Target: "https://api.example.com/v1"}); state != Granted {
+		t.Errorf("Query = %q, want granted", state)
+	}
+}
+
+func TestRequestPrompt(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	tests := []struct {
+		req  Request
+		want string
+	}{
+		{Request{API: PermFS, Access: "read", Target: filepath.Join(home, "Documents", "report.txt")}, "Notes wants to read " + filepath.Join("~", "Documents", "report.txt")},
+		{Request{API: PermHTTP, Target: "https://api.example.com/v1?q=1"}, "Notes wants to connect to https://api.example.com"},
+		{Request{API: PermProcess, Target: "git", Args: []string{"status", "-s"}}, "Notes wants to run git status -s"},
+	}
+	for _, tt := range tests {
+		if got := tt.req.Prompt("Notes"); got != tt.want {
+			t.Errorf("Prompt() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package security
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/lightshell-dev/lightshell/internal/compat"
+)
+
+// namespacePermissions maps client API namespaces to the permission their
+// calls are checked against. Namespaces not listed are core APIs.
+var namespacePermissions = map[string]Permission{
+	"fs":        PermFS,
+	"image":     PermImage,
+	"pdf":       PermPDF,
+	"share":     PermShare,
+	"codes":     PermCodes,
+	"dialog":    PermDialog,
+	"clipboard": PermClipboard,
+	"shell":     PermShell,
+	"notify":    PermNotification,
+	"tray":      PermTray,
+	"menu":      PermMenu,
+	"http":      PermHTTP,
+	"process":   PermProcess,
+	"store":     PermStore,
+	"shortcuts": PermShortcuts,
+	"updater":   PermUpdater,
+	"power":     PermPower,
+}
+
+// fileNamespaces can read or write files, which takes fs as well.
+var fileNamespaces = map[string]bool{"image": true, "pdf": true, "share": true, "codes": true}
+
+// fsWriteMethods are the fs methods that need write access.
+var fsWriteMethods = map[string]bool{"writeFile": true, "appendFile": true, "mkdir": true, "remove": true, "createTempDir": true}
+
+// fsHandleMethods work on a handle from fs.openRead rather than a path,
+// so they add nothing beyond the openRead call.
+var fsHandleMethods = map[string]bool{"readChunk": true, "close": true}
+
+// Suggestion is a least-privilege permission set derived from API usage.
+type Suggestion struct {
+	Permissions []string            `json:"permissions"` // sorted
+	Scopes      SuggestedScopes     `json:"scopes"`
+	Evidence    map[string][]string `json:"evidence"`   // permission -> "file:line lightshell.ns.method"
+	Unresolved  []string            `json:"unresolved"` // calls whose target is not a literal, to review by hand
+}
+
+// SuggestedScopes narrows the fs, http, and process permissions.
+type SuggestedScopes struct {
+	FS      FSScope      `json:"fs"`
+	HTTP    HTTPScope    `json:"http"`
+	Process ProcessScope `json:"process"`
+}
+
+// SuggestPermissions derives the permissions and scopes that calls need.
+// Literal paths, URLs, and commands become scope entries; anything else is
+// listed in Unresolved.
+func SuggestPermissions(calls []compat.APICall) Suggestion {
+	s := Suggestion{
+		Permissions: []string{},
+		Scopes: SuggestedScopes{
+			FS:      FSScope{Read: []string{}, Write: []string{}},
+			HTTP:    HTTPScope{Allow: []string{}, Deny: []string{}},
+			Process: ProcessScope{Exec: []ProcessRule{}},
+		},
+		Evidence:   map[string][]string{},
+		Unresolved: []string{},
+	}
+	perms := map[string]bool{}
+	reads, writes, hosts := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	cmds := map[string]bool{}
+
+	for _, c := range calls {
+		perm, ok := namespacePermissions[c.Namespace]
+		if !ok {
+			continue
+		}
+		name := "lightshell." + c.Namespace
+		if c.Method != "" {
+			name += "." + c.Method
+		}
+		where := fmt.Sprintf("%s:%d %s", c.File, c.Line, name)
+		perms[string(perm)] = true
+		s.Evidence[string(perm)] = append(s.Evidence[string(perm)], where)
+		if fileNamespaces[c.Namespace] {
+			perms[string(PermFS)] = true
+			s.Evidence[string(PermFS)] = append(s.Evidence[string(PermFS)], where)
+		}
+
+		switch c.Namespace {
+		case "fs":
+			switch {
+			case c.Method == "createTempDir":
+				reads["$APP_TEMP/**"], writes["$APP_TEMP/**"] = true, true
+			case fsHandleMethods[c.Method]:
+			case c.Literal && (strings.HasPrefix(c.Arg, "$") || path.IsAbs(c.Arg)):
+				pattern := fsPattern(c.Method, c.Arg)
+				reads[pattern] = true
+				if fsWriteMethods[c.Method] {
+					writes[pattern] = true
+				}
+			default:
+				s.Unresolved = append(s.Unresolved, where+": path is not a literal absolute path or path variable")
+			}
+		case "http":
+			if u, err := url.Parse(c.Arg); c.Literal && err == nil && u.Hostname() != "" {
+				hosts[u.Hostname()] = true
+			} else {
+				s.Unresolved = append(s.Unresolved, where+": URL is not a literal")
+			}
+		case "process":
+			if c.Literal && c.Arg != "" {
+				cmds[c.Arg] = true
+			} else {
+				s.Unresolved = append(s.Unresolved, where+": command is not a literal")
+			}
+		}
+	}
+
+	s.Permissions = sortedKeys(perms)
+	s.Scopes.FS.Read = sortedKeys(reads)
+	s.Scopes.FS.Write = sortedKeys(writes)
+	s.Scopes.HTTP.Allow = sortedKeys(hosts)
+	for _, cmd := range sortedKeys(cmds) {
+		s.Scopes.Process.Exec = append(s.Scopes.Process.Exec, ProcessRule{Cmd: cmd, Args: []string{"*"}})
+	}
+	return s
+}
+
+// fsPattern turns a literal path into a glob: a directory the call works
+// on covers everything below it; a file covers its siblings, since apps
+// usually keep related files together.
+func fsPattern(method, p string) string {
+	p = strings.TrimSuffix(p, "/")
+	switch method {
+	case "readDir", "mkdir", "watch":
+		return p + "/**"
+	}
+	if !strings.Contains(p, "/") {
+		return p + "/**" // a bare path variable
+	}
+	return path.Dir(p) + "/*"
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,84 @@
+package security
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lightshell-dev/lightshell/internal/compat"
+)
+
+func TestSuggestPermissions(t *testing.T) {
+	calls := []compat.APICall{
+		{File: "src/app.js", Line: 1, Namespace: "fs", Method: "readFile", Arg: "$APP_DATA/notes.json", Literal: true},
+		{File: "src/app.js", Line: 2, Namespace: "fs", Method: "writeFile", Arg: "$APP_DATA/notes.json", Literal: true},
+		{File: "src/app.js", Line: 3, Namespace: "fs", Method: "readDir", Arg: "$HOME/Documents", Literal: true},
+		{File: "src/app.js", Line: 4, Namespace: "fs", Method: "createTempDir"},
+		{File: "src/app.js", Line: 5, Namespace: "fs", Method: "readFile"},
+		{File: "src/app.js", Line: 6, Namespace: "http", Method: "fetch", Arg: "https://api.example.com/v1", Literal: true},
+		{File: "src/app.js", Line: 7, Namespace: "process", Method: "exec", Arg: "git", Literal: true},
+		{File: "src/app.js", Line: 8, Namespace: "notify", Method: "send", Arg: "Done", Literal: true},
+		{File: "src/app.js", Line: 9, Namespace: "window", Method: "setTitle", Arg: "x", Literal: true},
+	}
+	s := SuggestPermissions(calls)
+
+	if want := []string{"fs", "http", "notification", "process"}; !reflect.DeepEqual(s.Permissions, want) {
+		t.Errorf("Permissions = %v, want %v", s.Permissions, want)
+	}
+	if want := []string{"$APP_DATA/*", "$APP_TEMP/**", "$HOME/Documents/**"}; !reflect.DeepEqual(s.Scopes.FS.Read, want) {
+		t.Errorf("FS.Read = %v, want %v", s.Scopes.FS.Read, want)
+	}
+	if want := []string{"$APP_DATA/*", "$APP_TEMP/**"}; !reflect.DeepEqual(s.Scopes.FS.Write, want) {
+		t.Errorf("FS.Write = %v, want %v", s.Scopes.FS.Write, want)
+	}
+	if want := []string{"api.example.com"}; !reflect.DeepEqual(s.Scopes.HTTP.Allow, want) {
+		t.Errorf("HTTP.Allow = %v, want %v", s.Scopes.HTTP.Allow, want)
+	}
+	if want := []ProcessRule{{Cmd: "git", Args: []string{"*"}}}; !reflect.DeepEqual(s.Scopes.Process.Exec, want) {
+		t.Errorf("Process.Exec = %v, want %v", s.Scopes.Process.Exec, want)
+	}
+	if len(s.Unresolved) != 1 || s.Unresolved[0][:26] != "src/app.js:5 lightshell.fs" {
+		t.Errorf("Unresolved = %v, want the non-literal readFile", s.Unresolved)
+	}
+	if len(s.Evidence["fs"]) != 5 {
+		t.Errorf("Evidence[fs] = %v, want 5 calls", s.Evidence["fs"])
+	}
+}
+
+func TestSuggestPermissionsNoCalls(t *testing.T) {
+	s := SuggestPermissions(nil)
+	if len(s.Permissions) != 0 || s.Permissions == nil {
+		t.Errorf("Permissions = %#v, want empty list", s.Permissions)
+	}
+}
+
+func TestSuggestPermissionsStreaming(t *testing.T) {
+	s := SuggestPermissions([]compat.APICall{
+		{File: "src/app.js", Line: 1, Namespace: "fs", Method: "openRead", Arg: "$HOME/logs/app.log", Literal: true},
+		{File: "src/app.js", Line: 2, Namespace: "fs", Method: "readChunk"},
+		{File: "src/app.js", Line: 3, Namespace: "fs", Method: "close"},
+		{File: "src/app.js", Line: 4, Namespace: "fs", Method: "appendFile", Arg: "$APP_DATA/out.log", Literal: true},
+	})
+	if len(s.Unresolved) != 0 {
+		t.Errorf("Unresolved = %v, want none for handle methods", s.Unresolved)
+	}
+	if want := []string{"$APP_DATA/*", "$HOME/logs/*"}; !reflect.DeepEqual(s.Scopes.FS.Read, want) {
+		t.Errorf("FS.Read = %v, want %v", s.Scopes.FS.Read, want)
+	}
+	if want := []string{"$APP_DATA/*"}; !reflect.DeepEqual(s.Scopes.FS.Write, want) {
+		t.Errorf("FS.Write = %v, want %v", s.Scopes.FS.Write, want)
+	}
+}
+
+func TestSuggestPermissionsFileAPIs(t *testing.T) {
+	s := SuggestPermissions([]compat.APICall{
+		{File: "src/app.js", Line: 1, Namespace: "image", Method: "resize"},
+		{File: "src/app.js", Line: 2, Namespace: "codes", Method: "generateQR", Arg: "hello", Literal: true},
+	})
+	// Either may read or write a file, which fs covers
+	if want := []string{"codes", "fs", "image"}; !reflect.DeepEqual(s.Permissions, want) {
+		t.Errorf("Permissions = %v, want %v", s.Permissions, want)
+	}
+	if len(s.Evidence["fs"]) != 2 || len(s.Evidence["image"]) != 1 {
+		t.Errorf("Evidence = %v", s.Evidence)
+	}
+}
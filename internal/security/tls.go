@@ -0,0 +1,189 @@
+package security
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+)
+
+// PinError reports a server whose certificate chain matched none of the
+// SPKI pins configured for its host.
+type PinError struct {
+	Host     string
+	Expected []string // configured pins
+	Got      []string // SPKI hashes of the presented chain, leaf first
+}
+
+func (e *PinError) Error() string {
+	return fmt.Sprintf("certificate pin mismatch for %s: server presented %s, expected one of %s",
+		e.Host, strings.Join(e.Got, ", "), strings.Join(e.Expected, ", "))
+}
+
+// NewTLSConfig builds a client TLS config that trusts the system roots plus
+// the PEM bundles in caFiles (relative paths resolve against baseDir), and
+// that rejects servers whose chain matches none of the pins for their host.
+//
+// pins maps a hostname, or "*.example.com" for its subdomains, to base64
+// SHA-256 hashes of a SubjectPublicKeyInfo, optionally prefixed "sha256/".
+// A pin may match any certificate in the verified chain, so pinning an
+// intermediate survives leaf renewals.
+func NewTLSConfig(baseDir string, caFiles []string, pins map[string][]string) (*tls.Config, error) {
+	bundles, err := ReadCAFiles(baseDir, caFiles)
+	if err != nil {
+		return nil, err
+	}
+	return NewTLSConfigPEM(bundles, pins)
+}
+
+// ReadCAFiles reads the PEM bundles in caFiles, resolving relative paths
+// against baseDir, and checks that each holds at least one certificate.
+// lightshell build embeds the result, since a built app has no project
+// directory to read them from.
+func ReadCAFiles(baseDir string, caFiles []string) ([]string, error) {
+	bundles := make([]string, 0, len(caFiles))
+	for _, f := range caFiles {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(baseDir, f)
+		}
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, lserrors.New("security", "tls", lserrors.ConfigInvalid, "cannot read CA bundle").
+				WithCause(err).
+				WithFix("Check the paths in security.tls.caFiles in lightshell.json")
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			return nil, lserrors.New("security", "tls", lserrors.ConfigInvalid, fmt.Sprintf("no PEM certificates found in %s", f)).
+				WithFix("security.tls.caFiles entries must be PEM files containing one or more \"BEGIN CERTIFICATE\" blocks")
+		}
+		bundles = append(bundles, string(data))
+	}
+	return bundles, nil
+}
+
+// NewTLSConfigPEM is NewTLSConfig with the CA bundles' contents rather
+// than their paths.
+func NewTLSConfigPEM(caPEM []string, pins map[string][]string) (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if len(caPEM) > 0 {
+		roots, err := x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		for i, data := range caPEM {
+			if !roots.AppendCertsFromPEM([]byte(data)) {
+				return nil, lserrors.New("security", "tls", lserrors.ConfigInvalid, fmt.Sprintf("no PEM certificates found in CA bundle %d", i+1)).
+					WithFix("Rebuild the app so it embeds the PEM files in security.tls.caFiles")
+			}
+		}
+		conf.RootCAs = roots
+	}
+
+	if len(pins) > 0 {
+		normalized := make(map[string][]string, len(pins))
+		for host, hashes := range pins {
+			for _, h := range hashes {
+				h = strings.TrimPrefix(h, "sha256/")
+				if b, err := base64.StdEncoding.DecodeString(h); err != nil || len(b) != sha256.Size {
+					return nil, lserrors.New("security", "tls", lserrors.ConfigInvalid, fmt.Sprintf("invalid pin %q for %s", h, host)).
+						WithFix("Pins are base64 SHA-256 hashes of the certificate's public key, e.g. \"sha256/AAAA...=\"")
+				}
+				normalized[strings.ToLower(host)] = append(normalized[strings.ToLower(host)], h)
+			}
+		}
+		conf.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPins(cs, normalized)
+		}
+	}
+	return conf, nil
+}
+
+// verifyPins runs after normal chain verification.
+func verifyPins(cs tls.ConnectionState, pins map[string][]string) error {
+	host := strings.ToLower(cs.ServerName)
+	expected, ok := pins[host]
+	if !ok {
+		for pattern, p := range pins {
+			if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+				expected, ok = p, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+
+	var certs []*x509.Certificate
+	if len(cs.VerifiedChains) > 0 {
+		certs = cs.VerifiedChains[0]
+	} else {
+		certs = cs.PeerCertificates
+	}
+	got := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		h := SPKIHash(cert)
+		for _, want := range expected {
+			if h == want {
+				return nil
+			}
+		}
+		got = append(got, h)
+	}
+	return &PinError{Host: host, Expected: expected, Got: got}
+}
+
+// SPKIHash returns the base64 SHA-256 hash of cert's SubjectPublicKeyInfo,
+// the value used in security.tls.pins.
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WrapTLSError turns TLS failures from a request into structured errors,
+// separating pin mismatches from general certificate and handshake
+// failures. Other errors are returned unchanged.
+func WrapTLSError(namespace, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pinErr *PinError
+	if errors.As(err, &pinErr) {
+		return lserrors.New(namespace, method, lserrors.TLSPinMismatch, fmt.Sprintf("certificate pin mismatch for %s", pinErr.Host)).
+			WithCause(err).
+			WithFix("The server's key is not pinned in security.tls.pins. If the server rotated its key, add the new hash; otherwise the connection may be intercepted").
+			WithDocs("https://lightshell.dev/docs/api/config/#security")
+	}
+
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalid          x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		verification     *tls.CertificateVerificationError
+		record           tls.RecordHeaderError
+	)
+	switch {
+	case errors.As(err, &hostname):
+		return lserrors.New(namespace, method, lserrors.TLSFailed, "server certificate does not match the host name").
+			WithCause(err).
+			WithDocs("https://lightshell.dev/docs/api/config/#security")
+	case errors.As(err, &unknownAuthority):
+		return lserrors.New(namespace, method, lserrors.TLSFailed, "server certificate is not trusted").
+			WithCause(err).
+			WithFix("If a corporate proxy inspects TLS traffic, add its root CA to security.tls.caFiles in lightshell.json").
+			WithDocs("https://lightshell.dev/docs/api/config/#security")
+	case errors.As(err, &invalid), errors.As(err, &verification), errors.As(err, &record):
+		return lserrors.New(namespace, method, lserrors.TLSFailed, "TLS handshake failed").
+			WithCause(err).
+			WithDocs("https://lightshell.dev/docs/api/config/#security")
+	}
+	return err
+}
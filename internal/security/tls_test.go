@@ -0,0 +1,133 @@
+package security
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	lserrors "github.com/lightshell-dev/lightshell/internal/errors"
+)
+
+// tlsClient returns a client using conf that sends every request for
+// example.com (the httptest certificate's name) to srv.
+func tlsClient(t *testing.T, srv *httptest.Server, caFiles []string, pins map[string][]string) *http.Client {
+	t.Helper()
+	conf, err := NewTLSConfig(t.TempDir(), caFiles, pins)
+	if err != nil {
+		t.Fatalf("NewTLSConfig: %v", err)
+	}
+	addr := srv.Listener.Addr().String()
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig: conf,
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+func writeServerCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTLSCustomCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := tlsClient(t, srv, nil, nil).Get("https://example.com/")
+	var lsErr *lserrors.LightShellError
+	if !errors.As(WrapTLSError("http", "fetch", err), &lsErr) || lsErr.Code != lserrors.TLSFailed {
+		t.Fatalf("expected %s without the CA, got %v", lserrors.TLSFailed, err)
+	}
+
+	resp, err := tlsClient(t, srv, []string{writeServerCA(t, srv)}, nil).Get("https://example.com/")
+	if err != nil {
+		t.Fatalf("expected the extra CA to be trusted: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestTLSPins(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	ca := []string{writeServerCA(t, srv)}
+	good := "sha256/" + SPKIHash(srv.Certificate())
+	wrong := "sha256/AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+	resp, err := tlsClient(t, srv, ca, map[string][]string{"example.com": {wrong, good}}).Get("https://example.com/")
+	if err != nil {
+		t.Fatalf("expected a matching pin to pass: %v", err)
+	}
+	resp.Body.Close()
+
+	_, err = tlsClient(t, srv, ca, map[string][]string{"*.com": {wrong}}).Get("https://example.com/")
+	var pinErr *PinError
+	if !errors.As(err, &pinErr) || pinErr.Host != "example.com" {
+		t.Fatalf("expected a PinError, got %v", err)
+	}
+	var lsErr *lserrors.LightShellError
+	if !errors.As(WrapTLSError("updater", "check", err), &lsErr) || lsErr.Code != lserrors.TLSPinMismatch {
+		t.Errorf("expected %s, got %v", lserrors.TLSPinMismatch, lsErr)
+	}
+
+	// Hosts without pins are only subject to normal verification
+	resp, err = tlsClient(t, srv, ca, map[string][]string{"releases.example.org": {wrong}}).Get("https://example.com/")
+	if err != nil {
+		t.Fatalf("expected unpinned host to pass: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "empty.pem"), []byte("not a certificate"), 0o644)
+
+	if _, err := NewTLSConfig(dir, []string{"missing.pem"}, nil); err == nil {
+		t.Error("expected an error for a missing CA file")
+	}
+	if _, err := NewTLSConfig(dir, []string{"empty.pem"}, nil); err == nil {
+		t.Error("expected an error for a file without certificates")
+	}
+	if _, err := NewTLSConfig(dir, nil, map[string][]string{"example.com": {"sha256/short"}}); err == nil {
+		t.Error("expected an error for a malformed pin")
+	}
+	if _, err := NewTLSConfigPEM([]string{"not a certificate"}, nil); err == nil {
+		t.Error("expected an error for an embedded bundle without certificates")
+	}
+}
+
+func TestReadCAFiles(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	path := writeServerCA(t, srv)
+	bundles, err := ReadCAFiles(filepath.Dir(path), []string{filepath.Base(path)})
+	if err != nil {
+		t.Fatalf("ReadCAFiles: %v", err)
+	}
+	if want, _ := os.ReadFile(path); len(bundles) != 1 || bundles[0] != string(want) {
+		t.Errorf("bundles = %q, want the file's contents", bundles)
+	}
+	conf, err := NewTLSConfigPEM(bundles, nil)
+	if err != nil || conf.RootCAs == nil {
+		t.Fatalf("NewTLSConfigPEM = %v, %v", conf, err)
+	}
+}
+
+func TestWrapTLSErrorPassesThrough(t *testing.T) {
+	err := errors.New("connection refused")
+	if got := WrapTLSError("http", "fetch", err); got != err {
+		t.Errorf("non-TLS errors should be unchanged, got %v", got)
+	}
+}
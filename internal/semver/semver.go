@@ -0,0 +1,85 @@
+// Package semver parses and compares major.minor.patch versions, the form
+// lightshell uses for app versions and its own.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed major.minor.patch version with an optional
+// pre-release suffix.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Parse parses a version like "1.2.3", "v1.2.3" or "1.2.3-beta.1". Build
+// metadata (+...) is ignored.
+func Parse(s string) (Version, error) {
+	var v Version
+	raw := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(raw, "+"); i >= 0 {
+		raw = raw[:i]
+	}
+	if i := strings.Index(raw, "-"); i >= 0 {
+		v.Pre = raw[i+1:]
+		raw = raw[:i]
+	}
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid version %q: expected major.minor.patch (e.g. 1.2.3)", s)
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q: %q is not a non-negative number", s, p)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 if a is older than, equal to, or newer than b.
+// A pre-release sorts before the corresponding release.
+func Compare(a, b string) (int, error) {
+	va, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	for _, pair := range [][2]int{{va.Major, vb.Major}, {va.Minor, vb.Minor}, {va.Patch, vb.Patch}} {
+		if pair[0] < pair[1] {
+			return -1, nil
+		}
+		if pair[0] > pair[1] {
+			return 1, nil
+		}
+	}
+	switch {
+	case va.Pre == vb.Pre:
+		return 0, nil
+	case va.Pre == "":
+		return 1, nil
+	case vb.Pre == "":
+		return -1, nil
+	case va.Pre < vb.Pre:
+		return -1, nil
+	default:
+		return 1, nil
+	}
+}
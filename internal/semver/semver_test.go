@@ -0,0 +1,53 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "1.2.3", want: "1.2.3"},
+		{in: "v0.4.0", want: "0.4.0"},
+		{in: "2.0.0-beta.1+build.7", want: "2.0.0-beta.1"},
+		{in: "1.2", err: true},
+		{in: "1.x.3", err: true},
+		{in: "", err: true},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.in)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Parse(%q) = %v, want an error", tt.in, v)
+			}
+			continue
+		}
+		if err != nil || v.String() != tt.want {
+			t.Errorf("Parse(%q) = %v, %v; want %s", tt.in, v, err, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "1.9.9", 1},
+		{"1.0.0-beta", "1.0.0", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+		{"v1.0.0", "1.0.0", 0},
+	}
+	for _, tt := range tests {
+		got, err := Compare(tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+	if _, err := Compare("1.0", "1.0.0"); err == nil {
+		t.Error("Compare of an invalid version should fail")
+	}
+}
@@ -0,0 +1,131 @@
+// Package startup records cold-start milestones for a LightShell app and
+// persists the most recent report so lightshell doctor can check it against
+// the project's startup budget.
+package startup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/fsutil"
+	"github.com/lightshell-dev/lightshell/internal/paths"
+)
+
+// Milestone names, in the order they normally occur. ProcessStart and WindowCreated
+// are recorded by Go; the rest are reported by the client library from the page.
+const (
+	ProcessStart     = "processStart"
+	WindowCreated    = "windowCreated"
+	LoadCommitted    = "loadCommitted"
+	Ready            = "ready"
+	DOMContentLoaded = "domContentLoaded"
+	FirstPaint       = "firstPaint"
+)
+
+// Milestones lists every known milestone in order.
+var Milestones = []string{ProcessStart, WindowCreated, LoadCommitted, Ready, DOMContentLoaded, FirstPaint}
+
+// DefaultBudgetMilestone is the milestone a budget applies to when none is configured.
+const DefaultBudgetMilestone = FirstPaint
+
+// processStart approximates when the process started: package initialization
+// runs before main, so it is within a few milliseconds of exec.
+var processStart = time.Now()
+
+// Report is a snapshot of the recorded milestones, in milliseconds since
+// process start.
+type Report struct {
+	Mode       string             `json:"mode"` // "dev" or "production"
+	StartedAt  time.Time          `json:"startedAt"`
+	Milestones map[string]float64 `json:"milestones"`
+}
+
+// Elapsed returns the milliseconds from process start to the named milestone.
+func (r Report) Elapsed(milestone string) (float64, bool) {
+	ms, ok := r.Milestones[milestone]
+	return ms, ok
+}
+
+// Tracker records milestones for one process. The first mark for a
+// milestone wins, so page reloads in dev do not overwrite cold-start numbers.
+type Tracker struct {
+	mu    sync.Mutex
+	mode  string
+	start time.Time
+	marks map[string]time.Time
+}
+
+// NewTracker creates a tracker whose ProcessStart is the package init time.
+func NewTracker(mode string) *Tracker {
+	return newTracker(mode, processStart)
+}
+
+func newTracker(mode string, start time.Time) *Tracker {
+	return &Tracker{
+		mode:  mode,
+		start: start,
+		marks: map[string]time.Time{ProcessStart: start},
+	}
+}
+
+// Mark records a milestone at the current time.
+func (t *Tracker) Mark(milestone string) {
+	t.MarkAt(milestone, time.Now())
+}
+
+// MarkAt records a milestone at the given time. Later marks for the same
+// milestone are ignored.
+func (t *Tracker) MarkAt(milestone string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.marks[milestone]; ok {
+		return
+	}
+	t.marks[milestone] = at
+}
+
+// Report returns the recorded milestones.
+func (t *Tracker) Report() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := Report{Mode: t.mode, StartedAt: t.start, Milestones: make(map[string]float64, len(t.marks))}
+	for name, at := range t.marks {
+		ms := float64(at.Sub(t.start).Microseconds()) / 1000
+		if ms < 0 {
+			ms = 0 // clock skew between the page and Go
+		}
+		r.Milestones[name] = ms
+	}
+	return r
+}
+
+// ReportPath returns where the most recent startup report for appName is stored.
+func ReportPath(appName string) string {
+	return filepath.Join(paths.CacheDir(appName), "startup.json")
+}
+
+// Save writes r to path atomically, creating the parent directory.
+func Save(path string, r Report) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, append(data, '\n'), 0o644)
+}
+
+// Load reads a report written by Save.
+func Load(path string) (Report, error) {
+	var r Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(data, &r)
+	return r, err
+}
@@ -0,0 +1,62 @@
+package startup
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrackerReport(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := newTracker("dev", start)
+
+	tr.MarkAt(WindowCreated, start.Add(120*time.Millisecond))
+	tr.MarkAt(FirstPaint, start.Add(900*time.Millisecond))
+	// A reload reports the milestone again; the cold-start value must stick.
+	tr.MarkAt(FirstPaint, start.Add(5*time.Second))
+
+	r := tr.Report()
+	if r.Mode != "dev" {
+		t.Errorf("expected mode dev, got %q", r.Mode)
+	}
+	tests := map[string]float64{ProcessStart: 0, WindowCreated: 120, FirstPaint: 900}
+	for name, want := range tests {
+		got, ok := r.Elapsed(name)
+		if !ok || got != want {
+			t.Errorf("Elapsed(%s) = %v, %v; want %v", name, got, ok, want)
+		}
+	}
+	if _, ok := r.Elapsed(Ready); ok {
+		t.Error("expected unrecorded milestone to be missing")
+	}
+}
+
+func TestTrackerClampsSkew(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := newTracker("production", start)
+	tr.MarkAt(LoadCommitted, start.Add(-5*time.Millisecond))
+	if ms, _ := tr.Report().Elapsed(LoadCommitted); ms != 0 {
+		t.Errorf("expected negative offset to clamp to 0, got %v", ms)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "startup.json")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := newTracker("production", start)
+	tr.MarkAt(DOMContentLoaded, start.Add(640*time.Millisecond))
+
+	if err := Save(path, tr.Report()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	r, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if ms, _ := r.Elapsed(DOMContentLoaded); ms != 640 {
+		t.Errorf("expected 640ms after round trip, got %v", ms)
+	}
+	if !r.StartedAt.Equal(start) {
+		t.Errorf("expected start %v, got %v", start, r.StartedAt)
+	}
+}
@@ -0,0 +1,169 @@
+// Package tempspace gives an app a private temp root. Each running process
+// gets a session directory under it that is removed when the app quits, and
+// sessions left behind by a crash are removed the next time the app starts.
+//
+// A session is alive while its process holds the lock file inside it; the OS
+// drops the lock when the process exits, however it exits.
+package tempspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/fsutil"
+)
+
+const (
+	sessionPrefix = "session-"
+	stagingPrefix = "new-" // sessions not yet locked
+	lockFile      = ".lock"
+)
+
+// stagingMaxAge is how long a staging directory may exist before Recover
+// treats it as abandoned. Staging lasts only between mkdir and rename.
+const stagingMaxAge = time.Minute
+
+// Root returns the managed temp root for appName. It is per user, since
+// the system temp directory may be shared.
+func Root(appName string) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("lightshell-%d-%s", os.Getuid(), Slug(appName)))
+}
+
+// Slug turns an app name into a directory name: letters, digits, '-', '_'
+// and '.', with everything else replaced by '-'.
+func Slug(appName string) string {
+	if appName == "" {
+		return "app"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '-'
+	}, appName)
+}
+
+// Session is one process's directory under the temp root.
+type Session struct {
+	Dir string
+
+	mu     sync.Mutex
+	unlock func()
+}
+
+// Open creates the temp root if needed, removes abandoned sessions, and
+// starts a new session.
+func Open(root string) (*Session, error) {
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, fmt.Errorf("tempspace: %w", err)
+	}
+	info, err := os.Lstat(root)
+	if err != nil {
+		return nil, fmt.Errorf("tempspace: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("tempspace: %s is not a directory", root)
+	}
+	// Fails if another user created the root
+	if err := os.Chmod(root, 0o700); err != nil {
+		return nil, fmt.Errorf("tempspace: %s is not owned by this user: %w", root, err)
+	}
+	Recover(root)
+
+	// Lock before the session is visible under its final name, so a
+	// concurrent Recover never sees it unlocked.
+	staging, err := os.MkdirTemp(root, stagingPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("tempspace: %w", err)
+	}
+	unlock, ok, err := fsutil.TryLock(filepath.Join(staging, lockFile))
+	if err != nil || !ok {
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("tempspace: could not lock session: %v", err)
+	}
+	dir := filepath.Join(root, sessionPrefix+strings.TrimPrefix(filepath.Base(staging), stagingPrefix))
+	if err := os.Rename(staging, dir); err != nil {
+		unlock()
+		os.RemoveAll(staging)
+		return nil, fmt.Errorf("tempspace: %w", err)
+	}
+	return &Session{Dir: dir, unlock: unlock}, nil
+}
+
+// Recover removes sessions whose process has exited, and staging
+// directories abandoned mid-creation. It returns how many were removed.
+func Recover(root string) int {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		switch {
+		case strings.HasPrefix(e.Name(), sessionPrefix):
+			unlock, ok, err := fsutil.TryLock(filepath.Join(dir, lockFile))
+			if err != nil || !ok {
+				continue // alive, or not ours to judge
+			}
+			os.RemoveAll(dir)
+			unlock()
+			removed++
+		case strings.HasPrefix(e.Name(), stagingPrefix):
+			if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > stagingMaxAge {
+				os.RemoveAll(dir)
+				removed++
+			}
+		}
+	}
+	return removed
+}
+
+// CreateTempDir creates a new directory in the session whose name starts
+// with prefix, and returns its path.
+func (s *Session) CreateTempDir(prefix string) (string, error) {
+	if err := validPrefix(prefix); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unlock == nil {
+		return "", fmt.Errorf("tempspace: session is closed")
+	}
+	return os.MkdirTemp(s.Dir, prefix)
+}
+
+func validPrefix(prefix string) error {
+	if len(prefix) > 64 {
+		return fmt.Errorf("tempspace: prefix is longer than 64 characters")
+	}
+	if strings.ContainsAny(prefix, `/\*`) || prefix == "." || prefix == ".." || strings.ContainsRune(prefix, 0) {
+		return fmt.Errorf("tempspace: invalid prefix %q: it must not contain '/', '\\', or '*'", prefix)
+	}
+	if strings.HasPrefix(prefix, ".") && strings.Trim(prefix, ".") == "" {
+		return fmt.Errorf("tempspace: invalid prefix %q", prefix)
+	}
+	return nil
+}
+
+// Close removes the session directory and everything in it. It is safe to
+// call more than once.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unlock == nil {
+		return nil
+	}
+	err := os.RemoveAll(s.Dir)
+	s.unlock()
+	s.unlock = nil
+	return err
+}
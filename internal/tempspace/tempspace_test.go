@@ -0,0 +1,111 @@
+package tempspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{"notes": "notes", "My App": "My-App", "a/b": "a-b", "": "app"}
+	for in, want := range tests {
+		if got := Slug(in); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "root")
+	s, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(filepath.Base(s.Dir), sessionPrefix) || filepath.Dir(s.Dir) != root {
+		t.Errorf("unexpected session dir %s", s.Dir)
+	}
+	info, err := os.Stat(root)
+	if err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("root mode = %v, %v; want 0700", info.Mode().Perm(), err)
+	}
+
+	dir, err := s.CreateTempDir("export-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(dir) != s.Dir || !strings.HasPrefix(filepath.Base(dir), "export-") {
+		t.Errorf("CreateTempDir = %s, want export-* in %s", dir, s.Dir)
+	}
+	os.WriteFile(filepath.Join(dir, "file"), []byte("x"), 0o600)
+
+	// A live session survives recovery, even from the same process
+	if n := Recover(root); n != 0 {
+		t.Errorf("Recover removed %d live sessions", n)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.Dir); !os.IsNotExist(err) {
+		t.Errorf("session dir still exists after Close: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if _, err := s.CreateTempDir("x"); err == nil {
+		t.Error("CreateTempDir after Close should fail")
+	}
+}
+
+func TestRecoverRemovesAbandoned(t *testing.T) {
+	root := t.TempDir()
+
+	// A session whose process is gone: the lock file exists but is not held
+	crashed := filepath.Join(root, sessionPrefix+"123")
+	os.MkdirAll(filepath.Join(crashed, "leftover"), 0o700)
+	os.WriteFile(filepath.Join(crashed, lockFile), nil, 0o600)
+
+	// Staging directories are only removed once they are old
+	oldStaging := filepath.Join(root, stagingPrefix+"old")
+	newStaging := filepath.Join(root, stagingPrefix+"new")
+	os.Mkdir(oldStaging, 0o700)
+	os.Mkdir(newStaging, 0o700)
+	past := time.Now().Add(-2 * stagingMaxAge)
+	os.Chtimes(oldStaging, past, past)
+
+	// Unrelated entries are left alone
+	other := filepath.Join(root, "other")
+	os.Mkdir(other, 0o700)
+
+	if n := Recover(root); n != 2 {
+		t.Errorf("Recover removed %d, want 2", n)
+	}
+	for _, p := range []string{crashed, oldStaging} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed", p)
+		}
+	}
+	for _, p := range []string{newStaging, other} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s should remain: %v", p, err)
+		}
+	}
+}
+
+func TestCreateTempDirInvalidPrefix(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, prefix := range []string{"../escape", "a/b", "a*", "..", strings.Repeat("x", 65)} {
+		if _, err := s.CreateTempDir(prefix); err == nil {
+			t.Errorf("CreateTempDir(%q): expected error", prefix)
+		}
+	}
+	if _, err := s.CreateTempDir(""); err != nil {
+		t.Errorf("empty prefix: %v", err)
+	}
+}
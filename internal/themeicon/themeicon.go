@@ -0,0 +1,30 @@
+// Package themeicon resolves the light and dark variants of an icon. A dark
+// variant sits next to the icon with "@dark" before the extension, so
+// icon.png pairs with icon@dark.png and tray-icon.png with
+// tray-icon@dark.png.
+package themeicon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DarkName returns the name of path's dark variant.
+func DarkName(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "@dark" + ext
+}
+
+// Resolve returns the icon to show for the given appearance: the dark
+// variant when dark is set and the file exists, otherwise path itself.
+func Resolve(path string, dark bool) string {
+	if path == "" || !dark {
+		return path
+	}
+	variant := DarkName(path)
+	if info, err := os.Stat(variant); err == nil && !info.IsDir() {
+		return variant
+	}
+	return path
+}
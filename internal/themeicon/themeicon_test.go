@@ -0,0 +1,46 @@
+package themeicon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDarkName(t *testing.T) {
+	tests := map[string]string{
+		"icon.png":             "icon@dark.png",
+		"assets/tray-icon.png": "assets/tray-icon@dark.png",
+		"icons/app.v2.icns":    "icons/app.v2@dark.icns",
+		"noext":                "noext@dark",
+	}
+	for in, want := range tests {
+		if got := DarkName(in); got != want {
+			t.Errorf("DarkName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	icon := filepath.Join(dir, "icon.png")
+	tray := filepath.Join(dir, "tray-icon.png")
+	for _, name := range []string{icon, DarkName(icon), tray} {
+		if err := os.WriteFile(name, []byte("png"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := Resolve(icon, false); got != icon {
+		t.Errorf("light: got %q, want %q", got, icon)
+	}
+	if got := Resolve(icon, true); got != DarkName(icon) {
+		t.Errorf("dark: got %q, want %q", got, DarkName(icon))
+	}
+	// Without a dark variant the light icon is used in both appearances
+	if got := Resolve(tray, true); got != tray {
+		t.Errorf("dark without variant: got %q, want %q", got, tray)
+	}
+	if got := Resolve("", true); got != "" {
+		t.Errorf("empty path: got %q", got)
+	}
+}
@@ -0,0 +1,243 @@
+// Package watch reports changes to the files under a directory. It uses
+// the operating system's change notifications: inotify on Linux, kqueue on
+// macOS, and ReadDirectoryChangesW on Windows. Elsewhere, or when those
+// fail, for example because a Linux user's inotify watch limit is used up,
+// it polls.
+package watch
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configure a Watcher.
+type Options struct {
+	// Skip, when set, leaves out a path, given relative to the root with
+	// slashes, and for a directory everything in it.
+	Skip func(rel string, isDir bool) bool
+	// Debounce is how long the Watcher waits after a change for more
+	// before reporting them together. The default is 50ms.
+	Debounce time.Duration
+	// PollInterval is how often the polling fallback scans the tree. The
+	// default is 500ms.
+	PollInterval time.Duration
+}
+
+// maxBatchDelay bounds how long a steady stream of changes can hold back a
+// report.
+const maxBatchDelay = time.Second
+
+// Watcher watches the files under a root directory.
+type Watcher struct {
+	// Changes receives the absolute paths of the files and directories
+	// that were created, changed, or removed, sorted, once a burst of
+	// changes settles. It is closed by Close.
+	Changes <-chan []string
+
+	root     string
+	skip     func(rel string, isDir bool) bool
+	debounce time.Duration
+	raw      chan string
+	changes  chan []string
+	done     chan struct{}
+	once     sync.Once
+	backend  io.Closer
+	native   bool
+}
+
+// New starts watching root and everything under it, including directories
+// created later.
+func New(root string, opts Options) (*Watcher, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = 50 * time.Millisecond
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 500 * time.Millisecond
+	}
+
+	changes := make(chan []string)
+	w := &Watcher{
+		Changes:  changes,
+		root:     root,
+		skip:     opts.Skip,
+		debounce: opts.Debounce,
+		raw:      make(chan string, 256),
+		changes:  changes,
+		done:     make(chan struct{}),
+	}
+	if b, err := newNative(w); err == nil {
+		w.backend, w.native = b, true
+	} else {
+		w.backend = newPoller(w, opts.PollInterval)
+	}
+	go w.batch()
+	return w, nil
+}
+
+// Native reports whether the Watcher uses the operating system's change
+// notifications rather than polling.
+func (w *Watcher) Native() bool {
+	return w.native
+}
+
+// Close stops watching and closes Changes.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.backend.Close()
+	})
+	return err
+}
+
+// skipped reports whether path is outside the root or left out by Skip,
+// itself or through a directory it is in.
+func (w *Watcher) skipped(path string, isDir bool) bool {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return true
+	}
+	if rel == "." || w.skip == nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' && w.skip(rel[:i], true) {
+			return true
+		}
+	}
+	return w.skip(rel, isDir)
+}
+
+// emit reports a changed path. Backends call it from their own goroutines.
+func (w *Watcher) emit(path string) {
+	select {
+	case w.raw <- path:
+	case <-w.done:
+	}
+}
+
+// batch collects emitted paths until none arrive for the debounce time,
+// then sends them on Changes.
+func (w *Watcher) batch() {
+	defer close(w.changes)
+	pending := map[string]bool{}
+	var timer *time.Timer
+	var fire <-chan time.Time
+	var first time.Time
+	for {
+		select {
+		case path := <-w.raw:
+			if len(pending) == 0 {
+				first = time.Now()
+			}
+			pending[path] = true
+			wait := min(w.debounce, maxBatchDelay-time.Since(first))
+			if timer == nil {
+				timer = time.NewTimer(wait)
+			} else {
+				timer.Stop()
+				timer.Reset(wait)
+			}
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			paths := make([]string, 0, len(pending))
+			for p := range pending {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			pending = map[string]bool{}
+			select {
+			case w.changes <- paths:
+			case <-w.done:
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// poller is the fallback backend: it scans the tree for changed
+// modification times and sizes.
+type poller struct {
+	w    *Watcher
+	done chan struct{}
+	once sync.Once
+}
+
+type fileState struct {
+	mod  time.Time
+	size int64
+}
+
+func newPoller(w *Watcher, interval time.Duration) *poller {
+	p := &poller{w: w, done: make(chan struct{})}
+	last := p.scan()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-p.done:
+				return
+			}
+			next := p.scan()
+			for path, st := range next {
+				if prev, ok := last[path]; !ok || prev != st {
+					p.w.emit(path)
+				}
+			}
+			for path := range last {
+				if _, ok := next[path]; !ok {
+					p.w.emit(path)
+				}
+			}
+			last = next
+		}
+	}()
+	return p
+}
+
+func (p *poller) scan() map[string]fileState {
+	files := map[string]fileState{}
+	filepath.WalkDir(p.w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if p.w.skipped(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files[path] = fileState{info.ModTime(), info.Size()}
+		}
+		return nil
+	})
+	return files
+}
+
+func (p *poller) Close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
@@ -0,0 +1,214 @@
+//go:build darwin
+
+package watch
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const kqueueNotes = syscall.NOTE_WRITE | syscall.NOTE_EXTEND | syscall.NOTE_ATTRIB |
+	syscall.NOTE_DELETE | syscall.NOTE_RENAME | syscall.NOTE_REVOKE
+
+// kqueueWait is how long the reader blocks in kevent before checking
+// whether the watcher was closed.
+const kqueueWait = 200 * time.Millisecond
+
+// kqueue watches every file and directory in the tree with its own
+// descriptor: a directory's events say only that its entries changed, and
+// a file's that its content did.
+type kqueue struct {
+	w    *Watcher
+	kq   int
+	done chan struct{}
+	once sync.Once
+
+	mu    sync.Mutex
+	paths map[int]string // descriptor -> path
+	fds   map[string]int // path -> descriptor
+	dirs  map[string]bool
+}
+
+func newNative(w *Watcher) (io.Closer, error) {
+	kq, err := syscall.Kqueue()
+	if err != nil {
+		return nil, os.NewSyscallError("kqueue", err)
+	}
+	syscall.CloseOnExec(kq)
+	k := &kqueue{
+		w:     w,
+		kq:    kq,
+		done:  make(chan struct{}),
+		paths: map[int]string{},
+		fds:   map[string]int{},
+		dirs:  map[string]bool{},
+	}
+	// Running out of descriptors here falls back to polling
+	if err := k.addTree(w.root, false); err != nil {
+		k.closeAll()
+		return nil, err
+	}
+	go k.read()
+	return k, nil
+}
+
+// add watches one file or directory.
+func (k *kqueue) add(path string, isDir bool) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.fds[path]; ok {
+		return nil
+	}
+	fd, err := syscall.Open(path, syscall.O_EVTONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return os.NewSyscallError("open", err)
+	}
+	var ev syscall.Kevent_t
+	syscall.SetKevent(&ev, fd, syscall.EVFILT_VNODE, syscall.EV_ADD|syscall.EV_CLEAR|syscall.EV_ENABLE)
+	ev.Fflags = kqueueNotes
+	if _, err := syscall.Kevent(k.kq, []syscall.Kevent_t{ev}, nil, nil); err != nil {
+		syscall.Close(fd)
+		return os.NewSyscallError("kevent", err)
+	}
+	k.paths[fd] = path
+	k.fds[path] = fd
+	k.dirs[path] = isDir
+	return nil
+}
+
+// addTree watches dir and everything in it. For a directory that appeared
+// while watching, report is set and the files in it are reported.
+func (k *kqueue) addTree(dir string, report bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !report && path == dir {
+				return err
+			}
+			return nil
+		}
+		if k.w.skipped(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if err := k.add(path, d.IsDir()); err != nil {
+			if report {
+				return nil
+			}
+			return err
+		}
+		if report && !d.IsDir() {
+			k.w.emit(path)
+		}
+		return nil
+	})
+}
+
+// scanDir watches entries that appeared in dir, reporting them.
+func (k *kqueue) scanDir(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		k.mu.Lock()
+		_, known := k.fds[path]
+		k.mu.Unlock()
+		if known || e.Type()&fs.ModeSymlink != 0 || k.w.skipped(path, e.IsDir()) {
+			continue
+		}
+		if e.IsDir() {
+			k.addTree(path, true)
+		} else if k.add(path, false) == nil {
+			k.w.emit(path)
+		}
+	}
+}
+
+// remove stops watching path and, for a directory, everything in it.
+func (k *kqueue) remove(path string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for p, fd := range k.fds {
+		if p == path || strings.HasPrefix(p, path+string(filepath.Separator)) {
+			syscall.Close(fd)
+			delete(k.fds, p)
+			delete(k.paths, fd)
+			delete(k.dirs, p)
+		}
+	}
+}
+
+func (k *kqueue) read() {
+	defer k.closeAll()
+	events := make([]syscall.Kevent_t, 64)
+	timeout := syscall.NsecToTimespec(int64(kqueueWait))
+	for {
+		select {
+		case <-k.done:
+			return
+		default:
+		}
+		n, err := syscall.Kevent(k.kq, nil, events, &timeout)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		for _, ev := range events[:n] {
+			k.handle(int(ev.Ident), ev.Fflags)
+		}
+	}
+}
+
+func (k *kqueue) handle(fd int, notes uint32) {
+	k.mu.Lock()
+	path, ok := k.paths[fd]
+	isDir := k.dirs[path]
+	k.mu.Unlock()
+	if !ok {
+		return
+	}
+	switch {
+	case notes&(syscall.NOTE_DELETE|syscall.NOTE_RENAME|syscall.NOTE_REVOKE) != 0:
+		// Gone from here; a replacement saved under the same name shows
+		// up in its directory's scan
+		k.remove(path)
+		k.w.emit(path)
+		if parent := filepath.Dir(path); parent != path {
+			k.scanDir(parent)
+		}
+	case isDir:
+		k.scanDir(path)
+	default:
+		k.w.emit(path)
+	}
+}
+
+func (k *kqueue) closeAll() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for fd := range k.paths {
+		syscall.Close(fd)
+	}
+	k.paths, k.fds, k.dirs = map[int]string{}, map[string]int{}, map[string]bool{}
+	syscall.Close(k.kq)
+}
+
+// Close stops the reader, which closes the descriptors on its way out.
+func (k *kqueue) Close() error {
+	k.once.Do(func() { close(k.done) })
+	return nil
+}
@@ -0,0 +1,152 @@
+//go:build linux
+
+package watch
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB | syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO | syscall.IN_ONLYDIR
+
+// inotify watches each directory in the tree; inotify is not recursive.
+type inotify struct {
+	w    *Watcher
+	fd   int
+	file *os.File // fd, read through the runtime poller so Close unblocks it
+
+	mu   sync.Mutex
+	dirs map[int]string // watch descriptor -> directory
+}
+
+func newNative(w *Watcher) (io.Closer, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	in := &inotify{w: w, fd: fd, file: os.NewFile(uintptr(fd), "inotify"), dirs: map[int]string{}}
+	// Running out of watches here falls back to polling
+	if err := in.addTree(w.root, false); err != nil {
+		in.file.Close()
+		return nil, err
+	}
+	go in.read()
+	return in, nil
+}
+
+// addTree watches dir and the directories in it. For a directory that
+// appeared while watching, report is set: files in it may have been
+// created before its watch was in place, so they are all reported.
+func (in *inotify) addTree(dir string, report bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !report && path == dir {
+				return err
+			}
+			return nil
+		}
+		if in.w.skipped(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			if report {
+				in.w.emit(path)
+			}
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(in.fd, path, inotifyMask)
+		if err != nil {
+			if report {
+				return nil
+			}
+			return os.NewSyscallError("inotify_add_watch", err)
+		}
+		in.mu.Lock()
+		in.dirs[wd] = path
+		in.mu.Unlock()
+		return nil
+	})
+}
+
+func (in *inotify) read() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := in.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + syscall.SizeofInotifyEvent
+			end := start + int(ev.Len)
+			if end > n {
+				break
+			}
+			name := strings.TrimRight(string(buf[start:end]), "\x00")
+			in.handle(int(ev.Wd), ev.Mask, name)
+			off = end
+		}
+	}
+}
+
+func (in *inotify) handle(wd int, mask uint32, name string) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		// Events were lost, so anything may have changed
+		in.w.emit(in.w.root)
+		return
+	}
+
+	in.mu.Lock()
+	dir, ok := in.dirs[wd]
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(in.dirs, wd)
+	}
+	in.mu.Unlock()
+	// Events about a watched directory itself are reported by its parent
+	if !ok || name == "" {
+		return
+	}
+
+	path := filepath.Join(dir, name)
+	isDir := mask&syscall.IN_ISDIR != 0
+	if in.w.skipped(path, isDir) {
+		return
+	}
+	if isDir {
+		switch {
+		case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+			in.addTree(path, true)
+		case mask&syscall.IN_MOVED_FROM != 0:
+			in.removeTree(path)
+		}
+	}
+	in.w.emit(path)
+}
+
+// removeTree stops watching dir and the directories in it, after it was
+// moved away: their watches follow it, under paths no longer valid.
+func (in *inotify) removeTree(dir string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for wd, path := range in.dirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			syscall.InotifyRmWatch(in.fd, uint32(wd))
+			delete(in.dirs, wd)
+		}
+	}
+}
+
+func (in *inotify) Close() error {
+	return in.file.Close()
+}
@@ -0,0 +1,12 @@
+//go:build !darwin && !linux && !windows
+
+package watch
+
+import (
+	"errors"
+	"io"
+)
+
+func newNative(w *Watcher) (io.Closer, error) {
+	return nil, errors.New("no native file watching on this platform")
+}
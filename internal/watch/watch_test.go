@@ -0,0 +1,136 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor reads batches from w until one reports want, failing after a
+// few seconds.
+func waitFor(t *testing.T, w *Watcher, want string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case paths, ok := <-w.Changes:
+			if !ok {
+				t.Fatalf("Changes closed before %s was reported", want)
+			}
+			for _, p := range paths {
+				if p == want {
+					return
+				}
+			}
+		case <-timeout:
+			t.Fatalf("%s was not reported", want)
+		}
+	}
+}
+
+// expectNone fails if w reports anything for a moment.
+func expectNone(t *testing.T, w *Watcher) {
+	t.Helper()
+	select {
+	case paths := <-w.Changes:
+		t.Fatalf("unexpected changes: %v", paths)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func skipHidden(rel string, isDir bool) bool {
+	return strings.HasPrefix(filepath.Base(rel), ".") || rel == "node_modules"
+}
+
+func testWatcher(t *testing.T, newWatcher func(dir string) *Watcher) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "app.js"), []byte("1"), 0o644)
+	os.Mkdir(filepath.Join(dir, "node_modules"), 0o755)
+	w := newWatcher(dir)
+	defer w.Close()
+
+	t.Run("modify", func(t *testing.T) {
+		path := filepath.Join(dir, "app.js")
+		os.WriteFile(path, []byte("22"), 0o644)
+		waitFor(t, w, path)
+	})
+	t.Run("create in new directory", func(t *testing.T) {
+		sub := filepath.Join(dir, "components", "nested")
+		os.MkdirAll(sub, 0o755)
+		path := filepath.Join(sub, "button.js")
+		os.WriteFile(path, []byte("x"), 0o644)
+		waitFor(t, w, path)
+
+		// The new directory is watched from now on
+		os.WriteFile(path, []byte("xy"), 0o644)
+		waitFor(t, w, path)
+	})
+	t.Run("remove", func(t *testing.T) {
+		path := filepath.Join(dir, "app.js")
+		os.Remove(path)
+		waitFor(t, w, path)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		os.WriteFile(filepath.Join(dir, ".swp"), []byte("x"), 0o644)
+		os.WriteFile(filepath.Join(dir, "node_modules", "dep.js"), []byte("x"), 0o644)
+		expectNone(t, w)
+	})
+}
+
+func TestWatcher(t *testing.T) {
+	testWatcher(t, func(dir string) *Watcher {
+		w, err := New(dir, Options{Skip: skipHidden})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("native: %v", w.Native())
+		return w
+	})
+}
+
+func TestPoller(t *testing.T) {
+	testWatcher(t, func(dir string) *Watcher {
+		w, err := New(dir, Options{Skip: skipHidden})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Swap the native backend for polling
+		w.backend.Close()
+		w.backend = newPoller(w, 20*time.Millisecond)
+		return w
+	})
+}
+
+func TestBatching(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New(dir, Options{Debounce: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.emit(filepath.Join(dir, "b"))
+	w.emit(filepath.Join(dir, "a"))
+	w.emit(filepath.Join(dir, "b"))
+	paths := <-w.Changes
+	if len(paths) != 2 || paths[0] != filepath.Join(dir, "a") || paths[1] != filepath.Join(dir, "b") {
+		t.Errorf("batch = %v, want a and b once each, sorted", paths)
+	}
+}
+
+func TestClose(t *testing.T) {
+	w, err := New(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	select {
+	case _, ok := <-w.Changes:
+		if ok {
+			t.Error("Changes delivered after Close")
+		}
+	case <-time.After(time.Second):
+		t.Error("Changes not closed")
+	}
+}
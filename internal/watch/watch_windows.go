@@ -0,0 +1,80 @@
+//go:build windows
+
+package watch
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const rdcwFilter = syscall.FILE_NOTIFY_CHANGE_FILE_NAME | syscall.FILE_NOTIFY_CHANGE_DIR_NAME |
+	syscall.FILE_NOTIFY_CHANGE_SIZE | syscall.FILE_NOTIFY_CHANGE_LAST_WRITE |
+	syscall.FILE_NOTIFY_CHANGE_CREATION
+
+// rdcw watches the whole tree through one directory handle, with
+// ReadDirectoryChangesW's subtree option.
+type rdcw struct {
+	w      *Watcher
+	handle syscall.Handle
+}
+
+func newNative(w *Watcher) (io.Closer, error) {
+	name, err := syscall.UTF16PtrFromString(w.root)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(name, syscall.FILE_LIST_DIRECTORY,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return nil, os.NewSyscallError("CreateFile", err)
+	}
+	r := &rdcw{w: w, handle: h}
+	go r.read()
+	return r, nil
+}
+
+func (r *rdcw) read() {
+	// FILE_NOTIFY_INFORMATION records must be DWORD-aligned
+	buf := make([]uint32, 16*1024)
+	for {
+		var n uint32
+		err := syscall.ReadDirectoryChanges(r.handle, (*byte)(unsafe.Pointer(&buf[0])),
+			uint32(len(buf)*4), true, rdcwFilter, &n, nil, 0)
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			// The buffer overflowed, so anything may have changed
+			r.w.emit(r.w.root)
+			continue
+		}
+		data := unsafe.Slice((*byte)(unsafe.Pointer(&buf[0])), n)
+		for off := uint32(0); off < n; {
+			info := (*syscall.FileNotifyInformation)(unsafe.Pointer(&data[off]))
+			name := syscall.UTF16ToString(unsafe.Slice(&info.FileName, info.FileNameLength/2))
+			path := filepath.Join(r.w.root, name)
+			isDir := false
+			if fi, err := os.Lstat(path); err == nil {
+				isDir = fi.IsDir()
+			}
+			if !r.w.skipped(path, isDir) {
+				r.w.emit(path)
+			}
+			if info.NextEntryOffset == 0 {
+				break
+			}
+			off += info.NextEntryOffset
+		}
+	}
+}
+
+// Close cancels the pending read, which then returns, and closes the
+// handle.
+func (r *rdcw) Close() error {
+	syscall.CancelIoEx(r.handle, nil)
+	return syscall.CloseHandle(r.handle)
+}
@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// The example from RFC 6455, section 1.3
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("AcceptKey = %q", got)
+	}
+}
+
+// dial performs a handshake with srv and returns the raw connection.
+func dial(t *testing.T, srv *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: example\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake response: %s %v", resp.Status, resp.Header)
+	}
+	return conn, br
+}
+
+// writeClientFrame sends a masked frame, as browsers do.
+func writeClientFrame(t *testing.T, w io.Writer, fin bool, op byte, payload []byte) {
+	t.Helper()
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := w.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readServerFrame reads one unmasked frame.
+func readServerFrame(t *testing.T, r io.Reader) (byte, []byte) {
+	t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(head[1] & 0x7F)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+func TestConn(t *testing.T) {
+	received := make(chan []byte, 4)
+	done := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		c.WriteText([]byte("hello"))
+		c.WriteText(bytes.Repeat([]byte("x"), 300))
+		for {
+			msg, err := c.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			received <- msg
+		}
+	}))
+	defer srv.Close()
+
+	conn, br := dial(t, srv)
+	if op, msg := readServerFrame(t, br); op != opText || string(msg) != "hello" {
+		t.Errorf("first message = %#x %q", op, msg)
+	}
+	if _, msg := readServerFrame(t, br); len(msg) != 300 {
+		t.Errorf("second message has %d bytes, want 300", len(msg))
+	}
+
+	// A fragmented message, with a ping in between
+	writeClientFrame(t, conn, false, opText, []byte("frag"))
+	writeClientFrame(t, conn, true, opPing, []byte("p"))
+	writeClientFrame(t, conn, true, opContinuation, []byte("mented"))
+	if op, msg := readServerFrame(t, br); op != opPong || string(msg) != "p" {
+		t.Errorf("ping answer = %#x %q", op, msg)
+	}
+	if msg := <-received; string(msg) != "fragmented" {
+		t.Errorf("received %q", msg)
+	}
+
+	writeClientFrame(t, conn, true, opClose, []byte{0x03, 0xE8})
+	if err := <-done; err != io.EOF {
+		t.Errorf("ReadMessage after close = %v, want io.EOF", err)
+	}
+	if op, _ := readServerFrame(t, br); op != opClose {
+		t.Errorf("server answered close with %#x", op)
+	}
+}
+
+func TestUpgradeRejects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Upgrade(w, r)
+	}))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("plain GET got %s, want 400", resp.Status)
+	}
+}
@@ -0,0 +1,34 @@
+package webview
+
+import "net/http"
+
+// AssetServer is implemented by the webviews that can answer requests for
+// an app's own origin from Go, so its pages are served without opening a
+// port. ServeAssets must be called before Create; the main window and
+// every window opened after it load the origin's URLs from handler.
+type AssetServer interface {
+	ServeAssets(origin string, handler http.Handler)
+}
+
+// assetRecorder collects what an asset handler writes.
+type assetRecorder struct {
+	header http.Header
+	status int
+	body   []byte
+}
+
+func (r *assetRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *assetRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *assetRecorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	r.body = append(r.body, p...)
+	return len(p), nil
+}
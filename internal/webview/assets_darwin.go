@@ -0,0 +1,64 @@
+//go:build darwin
+
+package webview
+
+/*
+#include <stdlib.h>
+
+extern void WebviewServeAssets(const char* scheme);
+*/
+import "C"
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"unsafe"
+)
+
+// assetHandler answers the asset scheme's requests; there is one
+// DarwinWebview, like the other native callbacks' handlers.
+var assetHandler http.Handler
+
+// ServeAssets answers requests for URLs under origin, such as
+// app://localhost, with handler instead of the network, which is how an
+// app serves its pages without opening a port. WebKit only takes custom
+// schemes when a webview is created, so it must be called before Create.
+func (w *DarwinWebview) ServeAssets(origin string, handler http.Handler) {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" {
+		return
+	}
+	assetHandler = handler
+	cScheme := C.CString(u.Scheme)
+	defer C.free(unsafe.Pointer(cScheme))
+	C.WebviewServeAssets(cScheme)
+}
+
+// goServeAsset answers a request of the asset scheme handler. The headers
+// come back one per line and, like the body, are freed by the caller.
+//
+//export goServeAsset
+func goServeAsset(method, rawURL *C.char, status *C.int, headers **C.char, length *C.int) unsafe.Pointer {
+	rec := &assetRecorder{header: http.Header{}}
+	req, err := http.NewRequest(C.GoString(method), C.GoString(rawURL), nil)
+	if err != nil || assetHandler == nil {
+		rec.WriteHeader(http.StatusBadRequest)
+	} else {
+		assetHandler.ServeHTTP(rec, req)
+	}
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	var lines []string
+	for name, values := range rec.header {
+		lines = append(lines, name+": "+strings.Join(values, ", "))
+	}
+	*status = C.int(rec.status)
+	*headers = C.CString(strings.Join(lines, "\n"))
+	*length = C.int(len(rec.body))
+	if len(rec.body) == 0 {
+		return nil
+	}
+	return C.CBytes(rec.body)
+}
@@ -0,0 +1,102 @@
+//go:build windows
+
+package webview
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+	"unsafe"
+)
+
+// ServeAssets answers requests for URLs under origin, such as
+// https://app.localhost, with handler instead of the network, which is how
+// a built app serves its pages without opening a port. WebView2 only lets
+// custom schemes be registered when the environment is created, so the
+// origin is an https one on a host that does not resolve. It must be
+// called before Create.
+func (w *WindowsWebview) ServeAssets(origin string, handler http.Handler) {
+	w.assetOrigin = strings.TrimSuffix(origin, "/")
+	w.assets = handler
+}
+
+// serveAssets registers the asset handler, if any, with a new webview.
+func (w *WindowsWebview) serveAssets() error {
+	top := w
+	if w.owner != nil {
+		top = w.owner
+	}
+	if top.assets == nil {
+		return nil
+	}
+	onRequest := newHandler(func(sender, args unsafe.Pointer) uintptr {
+		var req *comObject
+		if (*comObject)(args).call(resourceRequestedGetRequest, uintptr(unsafe.Pointer(&req))) != 0 || req == nil {
+			return 0
+		}
+		defer req.call(iunknownRelease)
+		var uri, method *uint16
+		req.call(resourceRequestGetURI, uintptr(unsafe.Pointer(&uri)))
+		req.call(resourceRequestGetMethod, uintptr(unsafe.Pointer(&method)))
+		if resp := w.assetResponse(top.assets, takeCoTaskString(method), takeCoTaskString(uri)); resp != nil {
+			(*comObject)(args).call(resourceRequestedPutResponse, uintptr(unsafe.Pointer(resp)))
+			resp.call(iunknownRelease)
+		}
+		return 0
+	})
+	keepAlive(onRequest)
+	var token int64
+	if err := hresultError("add WebResourceRequested", w.view.call(webviewAddWebResourceRequested, uintptr(unsafe.Pointer(onRequest)), uintptr(unsafe.Pointer(&token)))); err != nil {
+		return err
+	}
+	return hresultError("AddWebResourceRequestedFilter",
+		w.view.call(webviewAddWebResourceRequestedFilter, uintptr(unsafe.Pointer(utf16Ptr(top.assetOrigin+"/*"))), resourceContextAll))
+}
+
+// assetResponse runs handler for a request and wraps what it writes as a
+// WebView2 response, or returns nil to let the request fail.
+func (w *WindowsWebview) assetResponse(handler http.Handler, method, uri string) *comObject {
+	if method == "" {
+		method = http.MethodGet
+	}
+	rec := &assetRecorder{header: http.Header{}}
+	req, err := http.NewRequest(method, uri, nil)
+	if err != nil {
+		rec.WriteHeader(http.StatusBadRequest)
+	} else {
+		handler.ServeHTTP(rec, req)
+	}
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	var stream *comObject
+	if r, _, _ := procCreateStreamOnHGlobal.Call(0, 1, uintptr(unsafe.Pointer(&stream))); int32(r) < 0 {
+		return nil
+	}
+	defer stream.call(iunknownRelease)
+	if len(rec.body) > 0 {
+		var n uint32
+		if stream.call(streamWrite, uintptr(unsafe.Pointer(&rec.body[0])), uintptr(len(rec.body)), uintptr(unsafe.Pointer(&n))) != 0 {
+			return nil
+		}
+		// WebView2 reads the stream from where it is
+		seek := []uintptr{0, streamSeekSet, 0}
+		if runtime.GOARCH == "386" {
+			seek = []uintptr{0, 0, streamSeekSet, 0}
+		}
+		stream.call(streamSeek, seek...)
+	}
+	var lines []string
+	for name, values := range rec.header {
+		lines = append(lines, name+": "+strings.Join(values, ", "))
+	}
+	var resp *comObject
+	hr := w.env.call(envCreateWebResourceResponse, uintptr(unsafe.Pointer(stream)), uintptr(rec.status),
+		uintptr(unsafe.Pointer(utf16Ptr(http.StatusText(rec.status)))), uintptr(unsafe.Pointer(utf16Ptr(strings.Join(lines, "\r\n")))),
+		uintptr(unsafe.Pointer(&resp)))
+	if hresultError("CreateWebResourceResponse", hr) != nil {
+		return nil
+	}
+	return resp
+}
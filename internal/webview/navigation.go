@@ -0,0 +1,11 @@
+package webview
+
+// NavigationGuard is implemented by the webviews that can keep the main
+// window from navigating away from the app. allow is asked about each
+// navigation of the main window's page, including link clicks, form posts,
+// and location changes, and the ones it refuses are cancelled; frames and
+// additional windows are not guarded. GuardNavigation must be called
+// before Create.
+type NavigationGuard interface {
+	GuardNavigation(allow func(url string) bool)
+}
@@ -0,0 +1,28 @@
+//go:build darwin
+
+package webview
+
+/*
+extern void WebviewGuardNavigation(void);
+*/
+import "C"
+
+// allowNavigation decides the main window's navigations; there is one
+// DarwinWebview, like the other native callbacks' handlers.
+var allowNavigation func(url string) bool
+
+// GuardNavigation has the main window ask allow before it navigates. WebKit
+// takes a navigation delegate when the webview is created, so it must be
+// called before Create.
+func (w *DarwinWebview) GuardNavigation(allow func(url string) bool) {
+	allowNavigation = allow
+	C.WebviewGuardNavigation()
+}
+
+//export goAllowNavigation
+func goAllowNavigation(url *C.char) C.int {
+	if allowNavigation == nil || allowNavigation(C.GoString(url)) {
+		return 1
+	}
+	return 0
+}
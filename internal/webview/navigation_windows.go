@@ -0,0 +1,32 @@
+//go:build windows
+
+package webview
+
+import "unsafe"
+
+// GuardNavigation has the main window ask allow before it navigates. It
+// must be called before Create.
+func (w *WindowsWebview) GuardNavigation(allow func(url string) bool) {
+	w.allowNavigation = allow
+}
+
+// guardNavigation cancels the navigations of the main window that
+// allowNavigation refuses.
+func (w *WindowsWebview) guardNavigation() error {
+	if w.owner != nil || w.allowNavigation == nil {
+		return nil
+	}
+	onStarting := newHandler(func(sender, args unsafe.Pointer) uintptr {
+		var uri *uint16
+		if (*comObject)(args).call(navigationStartingGetURI, uintptr(unsafe.Pointer(&uri))) != 0 {
+			return 0
+		}
+		if !w.allowNavigation(takeCoTaskString(uri)) {
+			(*comObject)(args).call(navigationStartingPutCancel, 1)
+		}
+		return 0
+	})
+	keepAlive(onStarting)
+	var token int64
+	return hresultError("add NavigationStarting", w.view.call(webviewAddNavigationStarting, uintptr(unsafe.Pointer(onStarting)), uintptr(unsafe.Pointer(&token))))
+}
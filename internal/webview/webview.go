@@ -1,5 +1,7 @@
 package webview
 
+import "fmt"
+
 // Webview is the interface for platform-specific webview implementations.
 type Webview interface {
 	Create(config WindowConfig) error
@@ -22,13 +24,59 @@ type Webview interface {
 	SetContentProtection(enabled bool) error
 	SetVibrancy(style string) error
 	SetColorScheme(scheme string) error
+	SetIcon(data []byte) error // PNG or ICO data; nil restores the default icon
+	SetTitlebar(style TitlebarStyle) error
 	EnableFileDrop() error
 	OnMessage(handler func(msg string))
 	Screenshot() ([]byte, error)
 	Run() error
 	Destroy()
+
+	// Additional windows. Each loads url with the main window's user
+	// scripts, after one that sets window.__lightshell_window_id; the
+	// methods above act on the main window only.
+	CreateWindow(config WindowConfig, url string) (int, error)
+	EvalWindow(id int, js string) error
+	FocusWindow(id int) error // MainWindowID focuses the main window
+	CloseWindow(id int) error
+	// ScreenshotWindow and WindowSize take MainWindowID for the main
+	// window, like FocusWindow.
+	ScreenshotWindow(id int) ([]byte, error)
+	WindowSize(id int) (int, int, error)
+	// EvalResult evaluates the expression js, whose value must be a
+	// string, in the window id (MainWindowID for the main window) and
+	// passes the value to done. The value comes back from the webview
+	// itself, not through a message the page could also post. done runs
+	// on the main thread and must not block.
+	EvalResult(id int, js string, done func(result string, err error)) error
+	OnWindowMessage(handler func(id int, msg string))
+	OnWindowClosed(handler func(id int)) // not called for the main window
+
+	// App lifecycle. Closing the main window while other windows are open,
+	// or with quit false, only hides it: its page keeps running, and Show
+	// brings it back.
+	SetQuitOnLastWindowClosed(quit bool)
+	Hide() error // hide every window
+	Show() error // unhide the app and show the main window
+	Quit()       // end Run, whatever windows are open or hidden
+	// OnReopen is called when the macOS Dock icon is clicked, after the
+	// main window is shown again if no window was visible.
+	OnReopen(handler func(hasVisibleWindows bool))
+
+	// Isolated scripts run in the main window in a JavaScript world of
+	// their own, which shares the page's DOM but not its globals, so page
+	// scripts can neither see nor tamper with them. What they post to
+	// window.webkit.messageHandlers.lightshellIsolated reaches only the
+	// OnIsolatedMessage handler. EvalIsolated fails where there are no
+	// isolated worlds; macOS has them from 11.
+	EvalIsolated(js string) error
+	OnIsolatedMessage(handler func(msg string))
 }
 
+// MainWindowID identifies the window made by Create. Additional windows
+// are numbered from MainWindowID+1 and IDs are never reused.
+const MainWindowID = 1
+
 // WindowConfig holds the configuration for creating a webview window.
 type WindowConfig struct {
 	Title       string
@@ -41,4 +89,27 @@ type WindowConfig struct {
 	AlwaysOnTop bool
 	Transparent bool
 	DevTools    bool
+	Titlebar    TitlebarStyle
+	Hidden      bool // never shown; the page runs in the background (tray-only apps)
+}
+
+// TitlebarStyle customizes the macOS title bar without going frameless.
+// The zero value is the standard title bar.
+type TitlebarStyle struct {
+	Transparent     bool   `json:"transparent,omitempty"`     // titlebarAppearsTransparent
+	FullSizeContent bool   `json:"fullSizeContent,omitempty"` // content extends under the title bar
+	HideTitle       bool   `json:"hideTitle,omitempty"`       // hide the title text, keep the window controls
+	ToolbarStyle    string `json:"toolbarStyle,omitempty"`    // "automatic", "expanded", "preference", "unified", or "unifiedCompact"
+}
+
+var toolbarStyles = map[string]bool{
+	"": true, "automatic": true, "expanded": true, "preference": true, "unified": true, "unifiedCompact": true,
+}
+
+// Validate reports an unknown toolbar style.
+func (s TitlebarStyle) Validate() error {
+	if !toolbarStyles[s.ToolbarStyle] {
+		return fmt.Errorf("invalid toolbarStyle %q: must be one of automatic, expanded, preference, unified, unifiedCompact", s.ToolbarStyle)
+	}
+	return nil
 }
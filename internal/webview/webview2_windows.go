@@ -0,0 +1,328 @@
+//go:build windows
+
+package webview
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// WebView2 is reached through its COM interfaces from plain Go: each
+// interface pointer is a pointer to a vtable, and methods are called by
+// vtable index in the order they are declared in WebView2.h. Only the
+// methods this package uses are listed.
+
+var (
+	ole32                     = syscall.NewLazyDLL("ole32.dll")
+	procCoInitializeEx        = ole32.NewProc("CoInitializeEx")
+	procCoTaskMemFree         = ole32.NewProc("CoTaskMemFree")
+	procCreateStreamOnHGlobal = ole32.NewProc("CreateStreamOnHGlobal")
+
+	// WebView2Loader.dll finds the installed WebView2 Runtime. It is not
+	// part of Windows; it ships in the Microsoft.Web.WebView2 NuGet package
+	// and has to sit next to the executable.
+	webview2Loader                               = syscall.NewLazyDLL("WebView2Loader.dll")
+	procCreateCoreWebView2EnvironmentWithOptions = webview2Loader.NewProc("CreateCoreWebView2EnvironmentWithOptions")
+)
+
+// IUnknown
+const (
+	iunknownQueryInterface = 0
+	iunknownAddRef         = 1
+	iunknownRelease        = 2
+)
+
+// ICoreWebView2Environment
+const (
+	envCreateCoreWebView2Controller = 3
+	envCreateWebResourceResponse    = 4
+)
+
+// ICoreWebView2Controller and ICoreWebView2Controller2
+const (
+	controllerPutIsVisible                      = 4
+	controllerPutBounds                         = 6
+	controllerMoveFocus                         = 12
+	controllerNotifyParentWindowPositionChanged = 23
+	controllerClose                             = 24
+	controllerGetCoreWebView2                   = 25
+	controller2PutDefaultBackgroundColor        = 27
+)
+
+// ICoreWebView2
+const (
+	webviewGetSettings                         = 3
+	webviewNavigate                            = 5
+	webviewNavigateToString                    = 6
+	webviewAddNavigationStarting               = 7
+	webviewAddScriptToExecuteOnDocumentCreated = 27
+	webviewExecuteScript                       = 29
+	webviewCapturePreview                      = 30
+	webviewAddWebMessageReceived               = 34
+	webviewAddWebResourceRequested             = 55
+	webviewAddWebResourceRequestedFilter       = 57
+)
+
+// ICoreWebView2Settings
+const (
+	settingsPutIsStatusBarEnabled = 10
+	settingsPutAreDevToolsEnabled = 12
+)
+
+// ICoreWebView2WebMessageReceivedEventArgs
+const webMessageTryGetWebMessageAsString = 5
+
+// ICoreWebView2WebResourceRequestedEventArgs and ICoreWebView2WebResourceRequest
+const (
+	resourceRequestedGetRequest  = 3
+	resourceRequestedPutResponse = 5
+	resourceRequestGetURI        = 3
+	resourceRequestGetMethod     = 5
+)
+
+// ICoreWebView2NavigationStartingEventArgs
+const (
+	navigationStartingGetURI    = 3
+	navigationStartingPutCancel = 8
+)
+
+// IStream
+const (
+	streamRead    = 3
+	streamWrite   = 4
+	streamSeek    = 5
+	streamStat    = 12
+	streamSeekSet = 0
+)
+
+const (
+	coinitApartmentThreaded = 0x2
+	statflagNoName          = 0x1
+	capturePreviewPNG       = 0
+	moveFocusProgrammatic   = 0
+	resourceContextAll      = 0
+
+	hresultFileNotFound = 0x80070002
+	hresultChangedMode  = 0x80010106 // RPC_E_CHANGED_MODE
+)
+
+var iidICoreWebView2Controller2 = guid{0xc979903e, 0xd4ca, 0x4228, [8]byte{0x92, 0xeb, 0x47, 0xee, 0x3f, 0xa9, 0x6e, 0xab}}
+
+type guid struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+// comObject is the memory layout of any COM interface pointer.
+type comObject struct {
+	vtbl *[64]uintptr
+}
+
+// call invokes a method. Like LazyProc.Call, pointers converted to uintptr
+// in the argument list are kept alive and in place for the call.
+//
+//go:uintptrescapes
+func (o *comObject) call(method int, args ...uintptr) uintptr {
+	r, _, _ := syscall.SyscallN(o.vtbl[method], append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...)
+	return r
+}
+
+// hresultError reports a failed HRESULT, or nil for S_OK and S_FALSE.
+func hresultError(op string, hr uintptr) error {
+	if int32(hr) >= 0 {
+		return nil
+	}
+	return fmt.Errorf("%s failed: HRESULT 0x%08X", op, uint32(hr))
+}
+
+// comHandler implements the WebView2 completed-handler and event-handler
+// interfaces, which are all IUnknown plus a single Invoke method. Handlers
+// are owned by the Go side and kept alive by the webview, so AddRef and
+// Release do no counting.
+type comHandler struct {
+	vtbl   *[4]uintptr
+	invoke func(a, b unsafe.Pointer) uintptr
+}
+
+// Invoke takes two arguments after this for every handler used here except
+// CapturePreview's, which takes one. The argument count matters on 386,
+// where the callee pops its arguments.
+var (
+	handlerVtbl = [4]uintptr{
+		syscall.NewCallback(handlerQueryInterface),
+		syscall.NewCallback(handlerAddRef),
+		syscall.NewCallback(handlerAddRef),
+		syscall.NewCallback(func(h *comHandler, a, b unsafe.Pointer) uintptr { return h.invoke(a, b) }),
+	}
+	handler1Vtbl = [4]uintptr{
+		syscall.NewCallback(handlerQueryInterface),
+		syscall.NewCallback(handlerAddRef),
+		syscall.NewCallback(handlerAddRef),
+		syscall.NewCallback(func(h *comHandler, a unsafe.Pointer) uintptr { return h.invoke(a, nil) }),
+	}
+)
+
+func handlerQueryInterface(h *comHandler, riid unsafe.Pointer, out *unsafe.Pointer) uintptr {
+	*out = unsafe.Pointer(h)
+	return 0
+}
+
+func handlerAddRef(h *comHandler) uintptr {
+	return 1
+}
+
+func newHandler(invoke func(a, b unsafe.Pointer) uintptr) *comHandler {
+	return &comHandler{vtbl: &handlerVtbl, invoke: invoke}
+}
+
+func newHandler1(invoke func(a unsafe.Pointer) uintptr) *comHandler {
+	return &comHandler{vtbl: &handler1Vtbl, invoke: func(a, _ unsafe.Pointer) uintptr { return invoke(a) }}
+}
+
+// hresult recovers the HRESULT passed as a handler's first argument.
+func hresult(p unsafe.Pointer) uintptr {
+	return uintptr(uint32(uintptr(p)))
+}
+
+// rectArgs passes a RECT by value, which each ABI does differently: x64
+// passes a pointer to a copy, arm64 packs it into two registers, and 386
+// pushes its four fields.
+func rectArgs(r *rect) []uintptr {
+	switch runtime.GOARCH {
+	case "amd64":
+		return []uintptr{uintptr(unsafe.Pointer(r))}
+	case "arm64":
+		lo := uint64(uint32(r.Left)) | uint64(uint32(r.Top))<<32
+		hi := uint64(uint32(r.Right)) | uint64(uint32(r.Bottom))<<32
+		return []uintptr{uintptr(lo), uintptr(hi)}
+	default:
+		return []uintptr{uintptr(r.Left), uintptr(r.Top), uintptr(r.Right), uintptr(r.Bottom)}
+	}
+}
+
+// utf16Ptr converts s for a call; s must not contain NUL.
+func utf16Ptr(s string) *uint16 {
+	p, err := syscall.UTF16PtrFromString(s)
+	if err != nil {
+		p, _ = syscall.UTF16PtrFromString("")
+	}
+	return p
+}
+
+// takeCoTaskString converts and frees a string WebView2 allocated.
+func takeCoTaskString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	defer procCoTaskMemFree.Call(uintptr(unsafe.Pointer(p)))
+	return utf16String(p)
+}
+
+// utf16String converts a NUL-terminated string WebView2 lends a handler.
+func utf16String(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	n := 0
+	for *(*uint16)(unsafe.Add(unsafe.Pointer(p), n*2)) != 0 {
+		n++
+	}
+	return syscall.UTF16ToString(unsafe.Slice(p, n))
+}
+
+// createEnvironment starts creating the WebView2 environment. done runs on
+// the UI thread once the environment exists or creation fails.
+func createEnvironment(userDataDir string, done func(env *comObject, err error)) error {
+	if err := procCreateCoreWebView2EnvironmentWithOptions.Find(); err != nil {
+		return fmt.Errorf("WebView2Loader.dll not found: put it next to the executable (it ships in the Microsoft.Web.WebView2 NuGet package)")
+	}
+	h := newHandler(func(a, b unsafe.Pointer) uintptr {
+		hr := hresult(a)
+		if uint32(hr) == hresultFileNotFound {
+			done(nil, fmt.Errorf("the WebView2 Runtime is not installed: get it from https://developer.microsoft.com/microsoft-edge/webview2/"))
+			return 0
+		}
+		if err := hresultError("create WebView2 environment", hr); err != nil {
+			done(nil, err)
+			return 0
+		}
+		env := (*comObject)(b)
+		env.call(iunknownAddRef)
+		done(env, nil)
+		return 0
+	})
+	keepAlive(h)
+	hr, _, _ := procCreateCoreWebView2EnvironmentWithOptions.Call(0, uintptr(unsafe.Pointer(utf16Ptr(userDataDir))), 0, uintptr(unsafe.Pointer(h)))
+	if uint32(hr) == hresultFileNotFound {
+		return fmt.Errorf("the WebView2 Runtime is not installed: get it from https://developer.microsoft.com/microsoft-edge/webview2/")
+	}
+	return hresultError("create WebView2 environment", hr)
+}
+
+// createController starts creating the controller that hosts a webview
+// in hwnd.
+func createController(env *comObject, hwnd uintptr, done func(ctrl *comObject, err error)) error {
+	h := newHandler(func(a, b unsafe.Pointer) uintptr {
+		if err := hresultError("create WebView2 controller", hresult(a)); err != nil {
+			done(nil, err)
+			return 0
+		}
+		ctrl := (*comObject)(b)
+		ctrl.call(iunknownAddRef)
+		done(ctrl, nil)
+		return 0
+	})
+	keepAlive(h)
+	return hresultError("create WebView2 controller", env.call(envCreateCoreWebView2Controller, hwnd, uintptr(unsafe.Pointer(h))))
+}
+
+// handlers holds every handler passed to WebView2. Nothing on the Go side
+// references them after the call, but WebView2 may invoke them later.
+var handlers []*comHandler
+
+func keepAlive(h *comHandler) {
+	handlers = append(handlers, h)
+}
+
+// comBool converts b to a COM BOOL.
+func comBool(b bool) uintptr {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// streamBytes reads the whole of stream from the start.
+func streamBytes(stream *comObject) ([]byte, error) {
+	// STATSTG; only cbSize, at the same offset on every architecture, is read
+	var stat struct {
+		name   *uint16
+		typ    uint32
+		size   uint64
+		unused [64]byte
+	}
+	if err := hresultError("IStream::Stat", stream.call(streamStat, uintptr(unsafe.Pointer(&stat)), statflagNoName)); err != nil {
+		return nil, err
+	}
+	if stat.size == 0 {
+		return nil, fmt.Errorf("empty stream")
+	}
+	// Seek takes a LARGE_INTEGER by value: two slots on 386, one elsewhere
+	seek := []uintptr{0, streamSeekSet, 0}
+	if runtime.GOARCH == "386" {
+		seek = []uintptr{0, 0, streamSeekSet, 0}
+	}
+	if err := hresultError("IStream::Seek", stream.call(streamSeek, seek...)); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, stat.size)
+	var n uint32
+	if err := hresultError("IStream::Read", stream.call(streamRead, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), uintptr(unsafe.Pointer(&n)))); err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
@@ -6,14 +6,16 @@ package webview
 #cgo darwin CFLAGS: -x objective-c
 #cgo darwin LDFLAGS: -framework Cocoa -framework WebKit
 
+#include <stdint.h>
 #include <stdlib.h>
 
 extern void WebviewCreate(const char* title, int width, int height, int minWidth, int minHeight,
-	int resizable, int frameless, int alwaysOnTop, int transparent, int devTools);
+	int resizable, int frameless, int alwaysOnTop, int transparent, int devTools, int hidden);
 extern void WebviewLoadHTML(const char* html);
 extern void WebviewLoadURL(const char* url);
 extern void WebviewEval(const char* js);
 extern void WebviewAddUserScript(const char* js);
+extern int WebviewEvalIsolated(const char* js);
 extern void WebviewSetTitle(const char* title);
 extern void WebviewSetSize(int width, int height);
 extern void WebviewSetMinSize(int width, int height);
@@ -29,21 +31,50 @@ extern void WebviewDestroy();
 extern void WebviewSetContentProtection(int enabled);
 extern void WebviewSetVibrancy(const char* style);
 extern void WebviewSetColorScheme(const char* scheme);
+extern void WebviewSetIcon(const void* data, int len);
+extern void WebviewSetTitlebar(int transparent, int fullSizeContent, int hideTitle, const char* toolbarStyle);
 extern void WebviewEnableFileDrop(void);
 extern int WebviewGetWidth(void);
 extern int WebviewGetHeight(void);
 extern int WebviewGetX(void);
 extern int WebviewGetY(void);
 extern void* WebviewScreenshot(int* outLen);
+extern void WebviewWindowCreate(int wid, const char* title, int width, int height, int minWidth, int minHeight,
+	int resizable, int frameless, int alwaysOnTop, int devTools, const char* url);
+extern void WebviewWindowEval(int wid, const char* js);
+extern void WebviewWindowEvalResult(int wid, const char* js, uintptr_t handle);
+extern void WebviewWindowFocus(int wid);
+extern void WebviewWindowClose(int wid);
+extern void* WebviewWindowScreenshot(int wid, int* outLen);
+extern int WebviewWindowGetSize(int wid, int* width, int* height);
+extern void WebviewSetQuitOnLastWindowClosed(int quit);
+extern void WebviewAppHide(void);
+extern void WebviewAppShow(void);
+extern void WebviewQuit(void);
 */
 import "C"
 
 import (
 	"fmt"
+	"sync"
 	"unsafe"
 )
 
-var messageHandler func(string)
+// The native callbacks carry no webview, so the handlers they call are
+// package-level; there is one NSApplication and so one DarwinWebview.
+var (
+	messageHandler       func(string)
+	isolatedHandler      func(string)
+	windowMessageHandler func(id int, msg string)
+	windowClosedHandler  func(id int)
+	reopenHandler        func(hasVisibleWindows bool)
+	current              *DarwinWebview
+
+	// The callbacks of pending EvalResult calls, by handle
+	evalMu      sync.Mutex
+	evalLast    uintptr
+	evalPending = map[uintptr]func(string, error){}
+)
 
 //export goMessageHandler
 func goMessageHandler(msg *C.char) {
@@ -52,12 +83,66 @@ func goMessageHandler(msg *C.char) {
 	}
 }
 
+//export goIsolatedMessageHandler
+func goIsolatedMessageHandler(msg *C.char) {
+	if isolatedHandler != nil {
+		isolatedHandler(C.GoString(msg))
+	}
+}
+
+//export goEvalResult
+func goEvalResult(handle C.uintptr_t, result, errMsg *C.char) {
+	evalMu.Lock()
+	done := evalPending[uintptr(handle)]
+	delete(evalPending, uintptr(handle))
+	evalMu.Unlock()
+	if done == nil {
+		return
+	}
+	if errMsg != nil {
+		done("", fmt.Errorf("%s", C.GoString(errMsg)))
+		return
+	}
+	done(C.GoString(result), nil)
+}
+
+//export goAppReopen
+func goAppReopen(hasVisibleWindows C.int) {
+	if reopenHandler != nil {
+		reopenHandler(hasVisibleWindows != 0)
+	}
+}
+
+//export goWindowMessage
+func goWindowMessage(wid C.int, msg *C.char) {
+	if windowMessageHandler != nil {
+		windowMessageHandler(int(wid), C.GoString(msg))
+	}
+}
+
+//export goWindowClosed
+func goWindowClosed(wid C.int) {
+	if current != nil {
+		current.mu.Lock()
+		delete(current.windows, int(wid))
+		current.mu.Unlock()
+	}
+	if windowClosedHandler != nil {
+		windowClosedHandler(int(wid))
+	}
+}
+
 // DarwinWebview implements the Webview interface for macOS using WKWebView.
-type DarwinWebview struct{}
+type DarwinWebview struct {
+	mu      sync.Mutex
+	lastID  int
+	windows map[int]bool // open additional windows
+}
 
 // New creates a new macOS webview.
 func New() Webview {
-	return &DarwinWebview{}
+	current = &DarwinWebview{lastID: MainWindowID, windows: map[int]bool{}}
+	return current
 }
 
 func (w *DarwinWebview) Create(config WindowConfig) error {
@@ -84,11 +169,22 @@ func (w *DarwinWebview) Create(config WindowConfig) error {
 	if config.DevTools {
 		devTools = 1
 	}
+	hidden := 0
+	if config.Hidden {
+		hidden = 1
+	}
+
+	if err := config.Titlebar.Validate(); err != nil {
+		return err
+	}
 
 	C.WebviewCreate(cTitle, C.int(config.Width), C.int(config.Height),
 		C.int(config.MinWidth), C.int(config.MinHeight),
 		C.int(resizable), C.int(frameless), C.int(alwaysOnTop),
-		C.int(transparent), C.int(devTools))
+		C.int(transparent), C.int(devTools), C.int(hidden))
+	if config.Titlebar != (TitlebarStyle{}) {
+		return w.SetTitlebar(config.Titlebar)
+	}
 	return nil
 }
 
@@ -203,6 +299,26 @@ func (w *DarwinWebview) SetColorScheme(scheme string) error {
 	return nil
 }
 
+// SetIcon sets the Dock icon. Nil data restores the bundle's icon.
+func (w *DarwinWebview) SetIcon(data []byte) error {
+	if len(data) == 0 {
+		C.WebviewSetIcon(nil, 0)
+		return nil
+	}
+	C.WebviewSetIcon(unsafe.Pointer(&data[0]), C.int(len(data)))
+	return nil
+}
+
+func (w *DarwinWebview) SetTitlebar(style TitlebarStyle) error {
+	if err := style.Validate(); err != nil {
+		return err
+	}
+	cStyle := C.CString(style.ToolbarStyle)
+	defer C.free(unsafe.Pointer(cStyle))
+	C.WebviewSetTitlebar(cBool(style.Transparent), cBool(style.FullSizeContent), cBool(style.HideTitle), cStyle)
+	return nil
+}
+
 func (w *DarwinWebview) EnableFileDrop() error {
 	C.WebviewEnableFileDrop()
 	return nil
@@ -230,3 +346,147 @@ func (w *DarwinWebview) Run() error {
 func (w *DarwinWebview) Destroy() {
 	C.WebviewDestroy()
 }
+
+func (w *DarwinWebview) CreateWindow(config WindowConfig, url string) (int, error) {
+	w.mu.Lock()
+	w.lastID++
+	id := w.lastID
+	w.windows[id] = true
+	w.mu.Unlock()
+
+	cTitle := C.CString(config.Title)
+	defer C.free(unsafe.Pointer(cTitle))
+	cURL := C.CString(url)
+	defer C.free(unsafe.Pointer(cURL))
+	C.WebviewWindowCreate(C.int(id), cTitle, C.int(config.Width), C.int(config.Height),
+		C.int(config.MinWidth), C.int(config.MinHeight),
+		cBool(config.Resizable), cBool(config.Frameless), cBool(config.AlwaysOnTop),
+		cBool(config.DevTools), cURL)
+	return id, nil
+}
+
+// window checks that id is an open window.
+func (w *DarwinWebview) window(id int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if id != MainWindowID && !w.windows[id] {
+		return fmt.Errorf("no window with id %d", id)
+	}
+	return nil
+}
+
+func (w *DarwinWebview) EvalWindow(id int, js string) error {
+	if err := w.window(id); err != nil {
+		return err
+	}
+	cJS := C.CString(js)
+	defer C.free(unsafe.Pointer(cJS))
+	C.WebviewWindowEval(C.int(id), cJS)
+	return nil
+}
+
+func (w *DarwinWebview) EvalResult(id int, js string, done func(string, error)) error {
+	if err := w.window(id); err != nil {
+		return err
+	}
+	evalMu.Lock()
+	evalLast++
+	handle := evalLast
+	evalPending[handle] = done
+	evalMu.Unlock()
+
+	cJS := C.CString(js)
+	defer C.free(unsafe.Pointer(cJS))
+	C.WebviewWindowEvalResult(C.int(id), cJS, C.uintptr_t(handle))
+	return nil
+}
+
+func (w *DarwinWebview) FocusWindow(id int) error {
+	if err := w.window(id); err != nil {
+		return err
+	}
+	C.WebviewWindowFocus(C.int(id))
+	return nil
+}
+
+func (w *DarwinWebview) CloseWindow(id int) error {
+	if err := w.window(id); err != nil {
+		return err
+	}
+	C.WebviewWindowClose(C.int(id))
+	return nil
+}
+
+func (w *DarwinWebview) ScreenshotWindow(id int) ([]byte, error) {
+	if err := w.window(id); err != nil {
+		return nil, err
+	}
+	var outLen C.int
+	ptr := C.WebviewWindowScreenshot(C.int(id), &outLen)
+	if ptr == nil {
+		return nil, fmt.Errorf("screenshot failed: window %d closed or timed out", id)
+	}
+	defer C.free(ptr)
+	return C.GoBytes(ptr, outLen), nil
+}
+
+func (w *DarwinWebview) WindowSize(id int) (int, int, error) {
+	if err := w.window(id); err != nil {
+		return 0, 0, err
+	}
+	var width, height C.int
+	if C.WebviewWindowGetSize(C.int(id), &width, &height) == 0 {
+		return 0, 0, fmt.Errorf("no window with id %d", id)
+	}
+	return int(width), int(height), nil
+}
+
+func (w *DarwinWebview) OnWindowMessage(handler func(id int, msg string)) {
+	windowMessageHandler = handler
+}
+
+func (w *DarwinWebview) OnWindowClosed(handler func(id int)) {
+	windowClosedHandler = handler
+}
+
+func (w *DarwinWebview) SetQuitOnLastWindowClosed(quit bool) {
+	C.WebviewSetQuitOnLastWindowClosed(cBool(quit))
+}
+
+func (w *DarwinWebview) Hide() error {
+	C.WebviewAppHide()
+	return nil
+}
+
+func (w *DarwinWebview) Show() error {
+	C.WebviewAppShow()
+	return nil
+}
+
+func (w *DarwinWebview) Quit() {
+	C.WebviewQuit()
+}
+
+func (w *DarwinWebview) OnReopen(handler func(hasVisibleWindows bool)) {
+	reopenHandler = handler
+}
+
+func (w *DarwinWebview) EvalIsolated(js string) error {
+	cJS := C.CString(js)
+	defer C.free(unsafe.Pointer(cJS))
+	if C.WebviewEvalIsolated(cJS) == 0 {
+		return fmt.Errorf("isolated scripts need macOS 11 or later")
+	}
+	return nil
+}
+
+func (w *DarwinWebview) OnIsolatedMessage(handler func(msg string)) {
+	isolatedHandler = handler
+}
+
+func cBool(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
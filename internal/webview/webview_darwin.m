@@ -3,11 +3,21 @@
 
 // Forward declaration of Go callback
 extern void goMessageHandler(const char* msg);
+extern void goIsolatedMessageHandler(const char* msg);
+extern void goEvalResult(uintptr_t handle, const char* result, const char* error);
 
 static NSWindow *mainWindow = nil;
 static WKWebView *webView = nil;
 static NSApplication *app = nil;
 
+// App lifecycle: whether closing the last window quits, and whether the
+// main window is a tray-only app's hidden one, which is never shown.
+static BOOL quitOnLastWindowClosed = YES;
+static BOOL mainWindowBackground = NO;
+static BOOL otherWindowVisible(NSWindow *closing);
+static void quitApp(void);
+extern void goAppReopen(int hasVisibleWindows);
+
 // Script message handler — receives postMessage from JS
 @interface MessageHandler : NSObject <WKScriptMessageHandler>
 @end
@@ -22,25 +32,158 @@ static NSApplication *app = nil;
 }
 @end
 
+// Isolated message handler — receives postMessage from scripts run in the
+// isolated world, where page scripts cannot reach it
+@interface IsolatedMessageHandler : NSObject <WKScriptMessageHandler>
+@end
+
+@implementation IsolatedMessageHandler
+- (void)userContentController:(WKUserContentController *)controller
+      didReceiveScriptMessage:(WKScriptMessage *)message {
+    if ([message.body isKindOfClass:[NSString class]]) {
+        goIsolatedMessageHandler([message.body UTF8String]);
+    }
+}
+@end
+
+// The world EvalIsolated runs scripts in, apart from the page's
+static WKContentWorld *isolatedWorld(void) API_AVAILABLE(macos(11.0)) {
+    return [WKContentWorld worldWithName:@"lightshell-isolated"];
+}
+
 // Window delegate — handles close, resize, move, focus events
 @interface WindowDelegate : NSObject <NSWindowDelegate>
 @end
 
 @implementation WindowDelegate
 - (BOOL)windowShouldClose:(NSWindow *)sender {
-    [NSApp terminate:nil];
+    if (quitOnLastWindowClosed && !otherWindowVisible(sender)) {
+        quitApp();
+        return YES;
+    }
+    // The page keeps running in the hidden window; app.show brings it back
+    [sender orderOut:nil];
+    return NO;
+}
+@end
+
+// App delegate — handles the Dock icon being clicked
+@interface AppDelegate : NSObject <NSApplicationDelegate>
+@end
+
+@implementation AppDelegate
+- (BOOL)applicationShouldHandleReopen:(NSApplication *)sender hasVisibleWindows:(BOOL)flag {
+    if (!flag && !mainWindowBackground) {
+        [mainWindow makeKeyAndOrderFront:nil];
+    }
+    goAppReopen(flag ? 1 : 0);
     return YES;
 }
 @end
 
+// App scheme handler — serves the app's pages from Go, so no port is opened
+extern void* goServeAsset(const char* method, const char* url, int* status, char** headers, int* length);
+
+@interface AssetSchemeHandler : NSObject <WKURLSchemeHandler>
+@end
+
+@implementation AssetSchemeHandler
+// Assets are read from memory, so requests are answered right away on the
+// main thread
+- (void)webView:(WKWebView *)webView startURLSchemeTask:(id<WKURLSchemeTask>)task {
+    NSURL *url = task.request.URL;
+    NSString *method = task.request.HTTPMethod ?: @"GET";
+    int status = 0, length = 0;
+    char *headers = NULL;
+    void *body = goServeAsset([method UTF8String], [url.absoluteString UTF8String], &status, &headers, &length);
+
+    NSMutableDictionary *fields = [NSMutableDictionary dictionary];
+    for (NSString *line in [[NSString stringWithUTF8String:headers] componentsSeparatedByString:@"\n"]) {
+        NSRange sep = [line rangeOfString:@": "];
+        if (sep.location != NSNotFound) {
+            fields[[line substringToIndex:sep.location]] = [line substringFromIndex:sep.location + 2];
+        }
+    }
+    free(headers);
+
+    NSHTTPURLResponse *response = [[NSHTTPURLResponse alloc] initWithURL:url statusCode:status
+        HTTPVersion:@"HTTP/1.1" headerFields:fields];
+    [task didReceiveResponse:response];
+    [response release];
+    if (body != NULL) {
+        [task didReceiveData:[NSData dataWithBytesNoCopy:body length:length freeWhenDone:YES]];
+    }
+    [task didFinish];
+}
+
+- (void)webView:(WKWebView *)webView stopURLSchemeTask:(id<WKURLSchemeTask>)task {
+}
+@end
+
+// Navigation guard — asks Go about each navigation of the main window's page
+extern int goAllowNavigation(const char* url);
+
+@interface NavigationGuard : NSObject <WKNavigationDelegate>
+@end
+
+@implementation NavigationGuard
+- (void)webView:(WKWebView *)webView decidePolicyForNavigationAction:(WKNavigationAction *)action
+    decisionHandler:(void (^)(WKNavigationActionPolicy))decisionHandler {
+    // Only the top-level page is guarded; a nil target frame is a new window
+    if (action.targetFrame.isMainFrame) {
+        NSString *url = action.request.URL.absoluteString ?: @"";
+        if (!goAllowNavigation([url UTF8String])) {
+            decisionHandler(WKNavigationActionPolicyCancel);
+            return;
+        }
+    }
+    decisionHandler(WKNavigationActionPolicyAllow);
+}
+@end
+
 static MessageHandler *msgHandler = nil;
 static WindowDelegate *winDelegate = nil;
+static AppDelegate *appDelegate = nil;
+static AssetSchemeHandler *assetHandler = nil;
+static NSString *assetScheme = nil;
+static NavigationGuard *navGuard = nil;
+
+// WebviewServeAssets has the webviews created from now on send requests
+// for scheme to goServeAsset.
+void WebviewServeAssets(const char* scheme) {
+    [assetScheme release];
+    assetScheme = [[NSString alloc] initWithUTF8String:scheme];
+}
+
+// serveAssets routes asset requests of a webview made with config to Go;
+// WebKit only takes scheme handlers before the webview is created.
+static void serveAssets(WKWebViewConfiguration *config) {
+    if (assetScheme == nil) {
+        return;
+    }
+    if (assetHandler == nil) {
+        assetHandler = [[AssetSchemeHandler alloc] init];
+    }
+    [config setURLSchemeHandler:assetHandler forURLScheme:assetScheme];
+}
+
+// WebviewGuardNavigation has the main window created from now on ask
+// goAllowNavigation before it navigates.
+void WebviewGuardNavigation(void) {
+    if (navGuard == nil) {
+        navGuard = [[NavigationGuard alloc] init];
+    }
+}
 
 void WebviewCreate(const char* title, int width, int height, int minWidth, int minHeight,
-    int resizable, int frameless, int alwaysOnTop, int transparent, int devTools) {
+    int resizable, int frameless, int alwaysOnTop, int transparent, int devTools, int hidden) {
 
     app = [NSApplication sharedApplication];
-    [app setActivationPolicy:NSApplicationActivationPolicyRegular];
+    // A hidden main window means a tray-only app: no Dock icon or menu bar
+    [app setActivationPolicy:hidden ? NSApplicationActivationPolicyAccessory : NSApplicationActivationPolicyRegular];
+    mainWindowBackground = hidden;
+    appDelegate = [[AppDelegate alloc] init];
+    [app setDelegate:appDelegate];
 
     // Window style mask
     NSWindowStyleMask styleMask = NSWindowStyleMaskTitled | NSWindowStyleMaskClosable | NSWindowStyleMaskMiniaturizable;
@@ -82,10 +225,15 @@ void WebviewCreate(const char* title, int width, int height, int minWidth, int m
 
     // WKWebView configuration
     WKWebViewConfiguration *config = [[WKWebViewConfiguration alloc] init];
+    serveAssets(config);
     WKUserContentController *contentController = [[WKUserContentController alloc] init];
 
     msgHandler = [[MessageHandler alloc] init];
     [contentController addScriptMessageHandler:msgHandler name:@"lightshell"];
+    if (@available(macOS 11.0, *)) {
+        IsolatedMessageHandler *isolated = [[IsolatedMessageHandler alloc] init];
+        [contentController addScriptMessageHandler:isolated contentWorld:isolatedWorld() name:@"lightshellIsolated"];
+    }
     config.userContentController = contentController;
 
     // Enable DevTools in dev mode
@@ -96,14 +244,20 @@ void WebviewCreate(const char* title, int width, int height, int minWidth, int m
 
     webView = [[WKWebView alloc] initWithFrame:[mainWindow.contentView bounds] configuration:config];
     [webView setAutoresizingMask:NSViewWidthSizable | NSViewHeightSizable];
+    if (navGuard != nil) {
+        // navigationDelegate is weak; navGuard keeps the guard alive
+        webView.navigationDelegate = navGuard;
+    }
 
     if (transparent) {
         [webView setValue:@NO forKey:@"drawsBackground"];
     }
 
     [mainWindow.contentView addSubview:webView];
-    [mainWindow makeKeyAndOrderFront:nil];
-    [app activateIgnoringOtherApps:YES];
+    if (!hidden) {
+        [mainWindow makeKeyAndOrderFront:nil];
+        [app activateIgnoringOtherApps:YES];
+    }
 }
 
 void WebviewLoadHTML(const char* html) {
@@ -137,6 +291,21 @@ void WebviewEval(const char* js) {
     }
 }
 
+// WebviewEvalIsolated runs js in the isolated world, returning 0 when the
+// system has no isolated worlds.
+int WebviewEvalIsolated(const char* js) {
+    if (@available(macOS 11.0, *)) {
+        if (webView) {
+            NSString *nsJS = [NSString stringWithUTF8String:js];
+            dispatch_async(dispatch_get_main_queue(), ^{
+                [webView evaluateJavaScript:nsJS inFrame:nil inContentWorld:isolatedWorld() completionHandler:nil];
+            });
+        }
+        return 1;
+    }
+    return 0;
+}
+
 void WebviewAddUserScript(const char* js) {
     if (webView) {
         NSString *nsJS = [NSString stringWithUTF8String:js];
@@ -246,6 +415,31 @@ void WebviewRun(void) {
     [app run];
 }
 
+// quitApp stops the run loop, so WebviewRun returns and Go can run its
+// shutdown hooks; terminate: would exit the process first. Call on the
+// main thread.
+static void quitApp(void) {
+    [NSApp stop:nil];
+    // stop: takes effect once the current event has been handled
+    NSEvent *event = [NSEvent otherEventWithType:NSEventTypeApplicationDefined
+                                        location:NSZeroPoint
+                                   modifierFlags:0
+                                       timestamp:0
+                                    windowNumber:0
+                                         context:nil
+                                         subtype:0
+                                           data1:0
+                                           data2:0];
+    [NSApp postEvent:event atStart:YES];
+}
+
+// WebviewQuit quits the app, whatever windows are open or hidden.
+void WebviewQuit(void) {
+    dispatch_async(dispatch_get_main_queue(), ^{
+        quitApp();
+    });
+}
+
 void WebviewDestroy(void) {
     if (webView) {
         dispatch_async(dispatch_get_main_queue(), ^{
@@ -321,6 +515,52 @@ void WebviewSetColorScheme(const char* scheme) {
     });
 }
 
+// Sets the Dock icon from image data. With no data the bundle's icon is
+// restored. The bytes are copied before returning.
+void WebviewSetIcon(const void* data, int len) {
+    NSData *imageData = (data && len > 0) ? [NSData dataWithBytes:data length:len] : nil;
+    dispatch_async(dispatch_get_main_queue(), ^{
+        NSImage *image = imageData ? [[NSImage alloc] initWithData:imageData] : nil;
+        [NSApp setApplicationIconImage:image];
+    });
+}
+
+void WebviewSetTitlebar(int transparent, int fullSizeContent, int hideTitle, const char* toolbarStyle) {
+    if (mainWindow) {
+        NSString *style = [NSString stringWithUTF8String:toolbarStyle];
+        dispatch_async(dispatch_get_main_queue(), ^{
+            mainWindow.titlebarAppearsTransparent = transparent ? YES : NO;
+            if (fullSizeContent) {
+                mainWindow.styleMask |= NSWindowStyleMaskFullSizeContentView;
+            } else {
+                mainWindow.styleMask &= ~NSWindowStyleMaskFullSizeContentView;
+            }
+            mainWindow.titleVisibility = hideTitle ? NSWindowTitleHidden : NSWindowTitleVisible;
+
+            if (@available(macOS 11.0, *)) {
+                NSWindowToolbarStyle toolbar = NSWindowToolbarStyleAutomatic;
+                if ([style isEqualToString:@"expanded"]) {
+                    toolbar = NSWindowToolbarStyleExpanded;
+                } else if ([style isEqualToString:@"preference"]) {
+                    toolbar = NSWindowToolbarStylePreference;
+                } else if ([style isEqualToString:@"unified"]) {
+                    toolbar = NSWindowToolbarStyleUnified;
+                } else if ([style isEqualToString:@"unifiedCompact"]) {
+                    toolbar = NSWindowToolbarStyleUnifiedCompact;
+                }
+                mainWindow.toolbarStyle = toolbar;
+                // Unified styles only change the title bar when the window has
+                // a toolbar; an empty one gives the inset window controls look.
+                if ((toolbar == NSWindowToolbarStyleUnified || toolbar == NSWindowToolbarStyleUnifiedCompact) && mainWindow.toolbar == nil) {
+                    NSToolbar *empty = [[NSToolbar alloc] initWithIdentifier:@"lightshell.titlebar"];
+                    mainWindow.toolbar = empty;
+                    [empty release];
+                }
+            }
+        });
+    }
+}
+
 void WebviewEnableFileDrop(void) {
     if (webView) {
         dispatch_async(dispatch_get_main_queue(), ^{
@@ -361,20 +601,22 @@ int WebviewGetY(void) {
     return 0;
 }
 
-// Returns PNG data as a malloc'd buffer. Caller must free. Sets *outLen to data length.
+// Returns PNG data of the web view viewFor returns on the main thread as a
+// malloc'd buffer. Caller must free. Sets *outLen to data length.
 // Returns NULL on failure.
-void* WebviewScreenshot(int* outLen) {
+static void* snapshotPNG(WKWebView *(^viewFor)(void), int* outLen) {
     __block NSData *pngData = nil;
 
     dispatch_semaphore_t sem = dispatch_semaphore_create(0);
 
     dispatch_async(dispatch_get_main_queue(), ^{
-        if (webView == nil) {
+        WKWebView *view = viewFor();
+        if (view == nil) {
             dispatch_semaphore_signal(sem);
             return;
         }
         WKSnapshotConfiguration *config = [[WKSnapshotConfiguration alloc] init];
-        [webView takeSnapshotWithConfiguration:config completionHandler:^(NSImage *image, NSError *error) {
+        [view takeSnapshotWithConfiguration:config completionHandler:^(NSImage *image, NSError *error) {
             if (image && !error) {
                 CGImageRef cgRef = [image CGImageForProposedRect:NULL context:nil hints:nil];
                 if (cgRef) {
@@ -401,3 +643,291 @@ void* WebviewScreenshot(int* outLen) {
     [pngData release];
     return buf;
 }
+
+void* WebviewScreenshot(int* outLen) {
+    return snapshotPNG(^WKWebView *{ return webView; }, outLen);
+}
+
+// --- Additional windows ---
+//
+// Windows opened by WebviewWindowCreate live in these tables, keyed by the
+// ID Go assigned. The main window is not in them: the functions above keep
+// working on mainWindow, and MAIN_WINDOW_ID refers to it where an ID is
+// taken.
+
+#define MAIN_WINDOW_ID 1
+
+extern void goWindowMessage(int wid, const char* msg);
+extern void goWindowClosed(int wid);
+
+static NSMutableDictionary *extraWindows = nil;   // NSNumber -> NSWindow
+static NSMutableDictionary *extraWebViews = nil;  // NSNumber -> WKWebView
+static NSMutableDictionary *extraDelegates = nil; // NSNumber -> ExtraWindowDelegate
+
+@interface ExtraMessageHandler : NSObject <WKScriptMessageHandler>
+@property (nonatomic) int wid;
+@end
+
+@implementation ExtraMessageHandler
+- (void)userContentController:(WKUserContentController *)controller
+      didReceiveScriptMessage:(WKScriptMessage *)message {
+    if ([message.body isKindOfClass:[NSString class]]) {
+        goWindowMessage(self.wid, [message.body UTF8String]);
+    }
+}
+@end
+
+// Closing an additional window forgets it and tells Go. It quits the app
+// only when it was the last window and quitOnLastWindowClosed is set.
+@interface ExtraWindowDelegate : NSObject <NSWindowDelegate>
+@property (nonatomic) int wid;
+@end
+
+@implementation ExtraWindowDelegate
+- (void)windowWillClose:(NSNotification *)notification {
+    NSNumber *key = @(self.wid);
+    WKWebView *view = extraWebViews[key];
+    [view.configuration.userContentController removeScriptMessageHandlerForName:@"lightshell"];
+    [view removeFromSuperview];
+    [extraWebViews removeObjectForKey:key];
+    goWindowClosed(self.wid);
+    // The window and this delegate are released last, after the callback
+    [self retain];
+    NSWindow *window = [extraWindows[key] retain];
+    [extraWindows removeObjectForKey:key];
+    [extraDelegates removeObjectForKey:key];
+    window.delegate = nil;
+    dispatch_async(dispatch_get_main_queue(), ^{
+        [window release];
+        [self release];
+        if (quitOnLastWindowClosed && !otherWindowVisible(nil)) {
+            quitApp();
+        }
+    });
+}
+@end
+
+// otherWindowVisible reports whether any window besides closing is on
+// screen or minimized. A hidden main window does not count.
+static BOOL otherWindowVisible(NSWindow *closing) {
+    NSMutableArray *windows = [NSMutableArray arrayWithArray:extraWindows.allValues ?: @[]];
+    if (mainWindow != nil) {
+        [windows addObject:mainWindow];
+    }
+    for (NSWindow *window in windows) {
+        if (window != closing && (window.isVisible || window.isMiniaturized)) {
+            return YES;
+        }
+    }
+    return NO;
+}
+
+static NSWindow *windowForID(int wid) {
+    if (wid == MAIN_WINDOW_ID) {
+        return mainWindow;
+    }
+    return extraWindows[@(wid)];
+}
+
+void WebviewWindowCreate(int wid, const char* title, int width, int height, int minWidth, int minHeight,
+    int resizable, int frameless, int alwaysOnTop, int devTools, const char* url) {
+    NSString *nsTitle = [NSString stringWithUTF8String:title];
+    NSString *nsURL = [NSString stringWithUTF8String:url];
+    dispatch_async(dispatch_get_main_queue(), ^{
+        if (extraWindows == nil) {
+            extraWindows = [[NSMutableDictionary alloc] init];
+            extraWebViews = [[NSMutableDictionary alloc] init];
+            extraDelegates = [[NSMutableDictionary alloc] init];
+        }
+
+        NSWindowStyleMask styleMask = NSWindowStyleMaskTitled | NSWindowStyleMaskClosable | NSWindowStyleMaskMiniaturizable;
+        if (frameless) {
+            styleMask = NSWindowStyleMaskBorderless;
+        }
+        if (resizable) {
+            styleMask |= NSWindowStyleMaskResizable;
+        }
+        NSWindow *window = [[NSWindow alloc] initWithContentRect:NSMakeRect(0, 0, width, height)
+                                                       styleMask:styleMask
+                                                         backing:NSBackingStoreBuffered
+                                                           defer:NO];
+        window.releasedWhenClosed = NO;
+        [window setTitle:nsTitle];
+        if (minWidth > 0 && minHeight > 0) {
+            [window setMinSize:NSMakeSize(minWidth, minHeight)];
+        }
+        if (alwaysOnTop) {
+            [window setLevel:NSFloatingWindowLevel];
+        }
+        // Cascade from the window that is in front
+        NSWindow *front = NSApp.keyWindow ? NSApp.keyWindow : mainWindow;
+        if (front) {
+            NSPoint topLeft = NSMakePoint(NSMinX(front.frame), NSMaxY(front.frame));
+            [window cascadeTopLeftFromPoint:[window cascadeTopLeftFromPoint:topLeft]];
+        } else {
+            [window center];
+        }
+
+        ExtraWindowDelegate *delegate = [[ExtraWindowDelegate alloc] init];
+        delegate.wid = wid;
+        window.delegate = delegate;
+
+        // Tell the page its window ID, then share the main window's user
+        // scripts so it gets the client library
+        WKWebViewConfiguration *config = [[WKWebViewConfiguration alloc] init];
+        serveAssets(config);
+        WKUserContentController *contentController = [[WKUserContentController alloc] init];
+        WKUserScript *idScript = [[WKUserScript alloc]
+            initWithSource:[NSString stringWithFormat:@"window.__lightshell_window_id = %d;", wid]
+            injectionTime:WKUserScriptInjectionTimeAtDocumentStart
+            forMainFrameOnly:YES];
+        [contentController addUserScript:idScript];
+        [idScript release];
+        if (webView) {
+            for (WKUserScript *script in webView.configuration.userContentController.userScripts) {
+                [contentController addUserScript:script];
+            }
+        }
+        ExtraMessageHandler *handler = [[ExtraMessageHandler alloc] init];
+        handler.wid = wid;
+        [contentController addScriptMessageHandler:handler name:@"lightshell"];
+        [handler release];
+        config.userContentController = contentController;
+        [contentController release];
+        if (devTools) {
+            [config.preferences setValue:@YES forKey:@"developerExtrasEnabled"];
+        }
+
+        WKWebView *view = [[WKWebView alloc] initWithFrame:[window.contentView bounds] configuration:config];
+        [config release];
+        [view setAutoresizingMask:NSViewWidthSizable | NSViewHeightSizable];
+        [window.contentView addSubview:view];
+
+        NSNumber *key = @(wid);
+        extraWindows[key] = window;
+        extraWebViews[key] = view;
+        extraDelegates[key] = delegate;
+        [window release];
+        [view release];
+        [delegate release];
+
+        NSURL *nsurl = [NSURL URLWithString:nsURL];
+        if ([nsurl.scheme isEqualToString:@"file"]) {
+            [view loadFileURL:nsurl allowingReadAccessToURL:[nsurl URLByDeletingLastPathComponent]];
+        } else {
+            [view loadRequest:[NSURLRequest requestWithURL:nsurl]];
+        }
+        [window makeKeyAndOrderFront:nil];
+        [NSApp activateIgnoringOtherApps:YES];
+    });
+}
+
+void WebviewWindowEval(int wid, const char* js) {
+    NSString *nsJS = [NSString stringWithUTF8String:js];
+    dispatch_async(dispatch_get_main_queue(), ^{
+        WKWebView *view = wid == MAIN_WINDOW_ID ? webView : extraWebViews[@(wid)];
+        [view evaluateJavaScript:nsJS completionHandler:nil];
+    });
+}
+
+// WebviewWindowEvalResult evaluates js in the window wid and passes the
+// string it returns, or why there is none, to goEvalResult with handle.
+void WebviewWindowEvalResult(int wid, const char* js, uintptr_t handle) {
+    NSString *nsJS = [NSString stringWithUTF8String:js];
+    dispatch_async(dispatch_get_main_queue(), ^{
+        WKWebView *view = wid == MAIN_WINDOW_ID ? webView : extraWebViews[@(wid)];
+        if (!view) {
+            goEvalResult(handle, NULL, "the window is closed");
+            return;
+        }
+        [view evaluateJavaScript:nsJS completionHandler:^(id result, NSError *error) {
+            if (error) {
+                NSString *msg = error.userInfo[@"WKJavaScriptExceptionMessage"] ?: error.localizedDescription;
+                goEvalResult(handle, NULL, [msg UTF8String]);
+            } else if ([result isKindOfClass:[NSString class]]) {
+                goEvalResult(handle, [(NSString *)result UTF8String], NULL);
+            } else {
+                goEvalResult(handle, NULL, "the script did not return a string");
+            }
+        }];
+    });
+}
+
+void WebviewWindowFocus(int wid) {
+    dispatch_async(dispatch_get_main_queue(), ^{
+        NSWindow *window = windowForID(wid);
+        if (window) {
+            if ([window isMiniaturized]) {
+                [window deminiaturize:nil];
+            }
+            [window makeKeyAndOrderFront:nil];
+            [NSApp activateIgnoringOtherApps:YES];
+        }
+    });
+}
+
+void WebviewWindowClose(int wid) {
+    dispatch_async(dispatch_get_main_queue(), ^{
+        [windowForID(wid) close];
+    });
+}
+
+void* WebviewWindowScreenshot(int wid, int* outLen) {
+    return snapshotPNG(^WKWebView *{
+        return wid == MAIN_WINDOW_ID ? webView : extraWebViews[@(wid)];
+    }, outLen);
+}
+
+// Sets the size of the window's frame, like WebviewGetWidth, and returns 0
+// when there is no such window.
+int WebviewWindowGetSize(int wid, int* width, int* height) {
+    __block NSSize size = NSZeroSize;
+    __block int found = 0;
+    void (^measure)(void) = ^{
+        NSWindow *window = windowForID(wid);
+        if (window) {
+            size = window.frame.size;
+            found = 1;
+        }
+    };
+    if ([NSThread isMainThread]) {
+        measure();
+    } else {
+        dispatch_sync(dispatch_get_main_queue(), measure);
+    }
+    *width = (int)size.width;
+    *height = (int)size.height;
+    return found;
+}
+
+// ---------------------------------------------------------------------------
+// App lifecycle
+// ---------------------------------------------------------------------------
+
+void WebviewSetQuitOnLastWindowClosed(int quit) {
+    dispatch_async(dispatch_get_main_queue(), ^{
+        quitOnLastWindowClosed = quit != 0;
+    });
+}
+
+// WebviewAppHide hides every window of the app, like Cmd-H.
+void WebviewAppHide(void) {
+    dispatch_async(dispatch_get_main_queue(), ^{
+        [NSApp hide:nil];
+    });
+}
+
+// WebviewAppShow unhides the app and brings back the main window, whether
+// it was hidden, minimized, or closed while the app kept running.
+void WebviewAppShow(void) {
+    dispatch_async(dispatch_get_main_queue(), ^{
+        [NSApp unhide:nil];
+        if (!mainWindowBackground && mainWindow != nil) {
+            if ([mainWindow isMiniaturized]) {
+                [mainWindow deminiaturize:nil];
+            }
+            [mainWindow makeKeyAndOrderFront:nil];
+        }
+        [NSApp activateIgnoringOtherApps:YES];
+    });
+}
@@ -90,6 +90,14 @@ func (w *LinuxWebview) SetColorScheme(scheme string) error {
 	return fmt.Errorf("linux webview not yet implemented")
 }
 
+func (w *LinuxWebview) SetIcon(data []byte) error {
+	return fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) SetTitlebar(style TitlebarStyle) error {
+	return fmt.Errorf("linux webview not yet implemented")
+}
+
 func (w *LinuxWebview) EnableFileDrop() error {
 	return fmt.Errorf("linux webview not yet implemented")
 }
@@ -100,6 +108,58 @@ func (w *LinuxWebview) Screenshot() ([]byte, error) {
 	return nil, fmt.Errorf("screenshot not yet implemented on linux")
 }
 
+func (w *LinuxWebview) CreateWindow(config WindowConfig, url string) (int, error) {
+	return 0, fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) EvalWindow(id int, js string) error {
+	return fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) EvalResult(id int, js string, done func(string, error)) error {
+	return fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) FocusWindow(id int) error {
+	return fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) CloseWindow(id int) error {
+	return fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) ScreenshotWindow(id int) ([]byte, error) {
+	return nil, fmt.Errorf("screenshot not yet implemented on linux")
+}
+
+func (w *LinuxWebview) WindowSize(id int) (int, int, error) {
+	return 0, 0, fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) OnWindowMessage(handler func(id int, msg string)) {}
+
+func (w *LinuxWebview) OnWindowClosed(handler func(id int)) {}
+
+func (w *LinuxWebview) SetQuitOnLastWindowClosed(quit bool) {}
+
+func (w *LinuxWebview) Hide() error {
+	return fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) Show() error {
+	return fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) Quit() {}
+
+func (w *LinuxWebview) OnReopen(handler func(hasVisibleWindows bool)) {}
+
+func (w *LinuxWebview) EvalIsolated(js string) error {
+	return fmt.Errorf("linux webview not yet implemented")
+}
+
+func (w *LinuxWebview) OnIsolatedMessage(handler func(msg string)) {}
+
 func (w *LinuxWebview) Run() error {
 	return fmt.Errorf("linux webview not yet implemented")
 }
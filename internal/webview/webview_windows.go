@@ -0,0 +1,1254 @@
+//go:build windows
+
+package webview
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	user32                            = syscall.NewLazyDLL("user32.dll")
+	procRegisterClassExW              = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW               = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW                = user32.NewProc("DefWindowProcW")
+	procDestroyWindow                 = user32.NewProc("DestroyWindow")
+	procShowWindow                    = user32.NewProc("ShowWindow")
+	procUpdateWindow                  = user32.NewProc("UpdateWindow")
+	procSetForegroundWindow           = user32.NewProc("SetForegroundWindow")
+	procGetMessageW                   = user32.NewProc("GetMessageW")
+	procPeekMessageW                  = user32.NewProc("PeekMessageW")
+	procTranslateMessage              = user32.NewProc("TranslateMessage")
+	procDispatchMessageW              = user32.NewProc("DispatchMessageW")
+	procPostMessageW                  = user32.NewProc("PostMessageW")
+	procPostQuitMessage               = user32.NewProc("PostQuitMessage")
+	procMsgWaitForMultipleObjects     = user32.NewProc("MsgWaitForMultipleObjects")
+	procSetWindowTextW                = user32.NewProc("SetWindowTextW")
+	procSetWindowPos                  = user32.NewProc("SetWindowPos")
+	procGetWindowRect                 = user32.NewProc("GetWindowRect")
+	procGetClientRect                 = user32.NewProc("GetClientRect")
+	procAdjustWindowRectEx            = user32.NewProc("AdjustWindowRectEx")
+	procLoadCursorW                   = user32.NewProc("LoadCursorW")
+	procLoadIconW                     = user32.NewProc("LoadIconW")
+	procCreateIconFromResourceEx      = user32.NewProc("CreateIconFromResourceEx")
+	procDestroyIcon                   = user32.NewProc("DestroyIcon")
+	procSendMessageW                  = user32.NewProc("SendMessageW")
+	procIsIconic                      = user32.NewProc("IsIconic")
+	procIsWindowVisible               = user32.NewProc("IsWindowVisible")
+	procSystemParametersInfoW         = user32.NewProc("SystemParametersInfoW")
+	procMonitorFromWindow             = user32.NewProc("MonitorFromWindow")
+	procGetMonitorInfoW               = user32.NewProc("GetMonitorInfoW")
+	procSetWindowDisplayAffinity      = user32.NewProc("SetWindowDisplayAffinity")
+	procSetProcessDpiAwarenessContext = user32.NewProc("SetProcessDpiAwarenessContext") // Windows 10 1703
+	procGetDpiForWindow               = user32.NewProc("GetDpiForWindow")               // Windows 10 1607
+	procGetDpiForSystem               = user32.NewProc("GetDpiForSystem")
+	procGetWindowLong                 = user32.NewProc(windowLongProc("Get"))
+	procSetWindowLong                 = user32.NewProc(windowLongProc("Set"))
+
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procGetModuleHandleW   = kernel32.NewProc("GetModuleHandleW")
+	procGetCurrentThreadId = kernel32.NewProc("GetCurrentThreadId")
+
+	dwmapi                           = syscall.NewLazyDLL("dwmapi.dll")
+	procDwmSetWindowAttribute        = dwmapi.NewProc("DwmSetWindowAttribute")
+	procDwmExtendFrameIntoClientArea = dwmapi.NewProc("DwmExtendFrameIntoClientArea")
+)
+
+const (
+	wsOverlappedWindow = 0x00CF0000
+	wsPopup            = 0x80000000
+	wsCaption          = 0x00C00000
+	wsSysMenu          = 0x00080000
+	wsThickFrame       = 0x00040000
+	wsMinimizeBox      = 0x00020000
+	wsMaximizeBox      = 0x00010000
+	wsExTopmost        = 0x00000008
+
+	wmDestroy       = 0x0002
+	wmMove          = 0x0003
+	wmSize          = 0x0005
+	wmSetFocus      = 0x0007
+	wmQuit          = 0x0012
+	wmGetMinMaxInfo = 0x0024
+	wmDPIChanged    = 0x02E0
+	wmSetIcon       = 0x0080
+	wmClose         = 0x0010
+	wmDispatch      = 0x8000 + 1 // WM_APP + 1: run queued calls on the UI thread
+
+	swHide     = 0
+	swShow     = 5
+	swMaximize = 3
+	swMinimize = 6
+	swRestore  = 9
+
+	swpNoSize         = 0x0001
+	swpNoMove         = 0x0002
+	swpNoZOrder       = 0x0004
+	swpNoActivate     = 0x0010
+	swpFrameChanged   = 0x0020
+	swpNoOwnerZOrder  = 0x0200
+	pmRemove          = 0x0001
+	qsAllInput        = 0x04FF
+	spiGetWorkArea    = 0x0030
+	monitorNearest    = 2
+	idcArrow          = 32512
+	idiApplication    = 32512
+	iconSmall         = 0
+	iconBig           = 1
+	iconResVersion    = 0x00030000
+	colorWindow       = 5
+	wdaNone           = 0x00
+	wdaMonitor        = 0x01
+	wdaExcludeCapture = 0x11 // Windows 10 2004
+
+	dwmwaUseImmersiveDarkMode = 20
+	dwmwaSystemBackdropType   = 38 // Windows 11 22H2
+	dwmsbtNone                = 1
+	dwmsbtMainWindow          = 2 // Mica
+	dwmsbtTransientWindow     = 3 // Acrylic
+	dwmsbtTabbedWindow        = 4 // Mica Alt
+
+	userDefaultDPI = 96
+)
+
+// Negative handles and indexes, as the unsigned values calls pass
+var (
+	dpiAwarenessPerMonitorV2 = ^uintptr(3)  // DPI_AWARENESS_CONTEXT_PER_MONITOR_AWARE_V2, -4
+	gwlStyle                 = ^uintptr(15) // GWL_STYLE, -16
+)
+
+// windowLongProc names the Get/SetWindowLong variant for pointer-sized
+// values; the Ptr variants only exist in 64-bit user32.
+func windowLongProc(op string) string {
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		return op + "WindowLongPtrW"
+	}
+	return op + "WindowLongW"
+}
+
+type rect struct {
+	Left, Top, Right, Bottom int32
+}
+
+type point struct {
+	X, Y int32
+}
+
+type msg struct {
+	Hwnd    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      point
+	private uint32
+}
+
+type wndClassEx struct {
+	Size       uint32
+	Style      uint32
+	WndProc    uintptr
+	ClsExtra   int32
+	WndExtra   int32
+	Instance   uintptr
+	Icon       uintptr
+	Cursor     uintptr
+	Background uintptr
+	MenuName   *uint16
+	ClassName  *uint16
+	IconSm     uintptr
+}
+
+type minMaxInfo struct {
+	Reserved     point
+	MaxSize      point
+	MaxPosition  point
+	MinTrackSize point
+	MaxTrackSize point
+}
+
+type monitorInfo struct {
+	Size    uint32
+	Monitor rect
+	Work    rect
+	Flags   uint32
+}
+
+type margins struct {
+	Left, Right, Top, Bottom int32
+}
+
+// bridgeScript gives WebView2 pages the WKWebView message handler the
+// client library and dev scripts post to.
+const bridgeScript = `(function() {
+  var lightshell = { postMessage: function(msg) { window.chrome.webview.postMessage(msg) } };
+  window.webkit = window.webkit || {};
+  window.webkit.messageHandlers = window.webkit.messageHandlers || {};
+  window.webkit.messageHandlers.lightshell = lightshell;
+})();`
+
+// windowClass is the class every LightShell window is created with.
+const windowClass = "LightShellWindow"
+
+// windows maps window handles to the webviews the window procedure serves.
+// creating is the webview whose window CreateWindowEx is making, for the
+// messages sent before its handle is known. Both are only used on the UI
+// thread.
+var (
+	windows  = map[uintptr]*WindowsWebview{}
+	creating *WindowsWebview
+)
+
+var wndProcCallback = syscall.NewCallback(wndProc)
+
+// WindowsWebview implements the Webview interface for Windows using a Win32
+// window hosting WebView2. Sizes and positions are in device-independent
+// pixels, like points on macOS. The UI thread is the one that calls Create;
+// calls from other goroutines are posted to it.
+type WindowsWebview struct {
+	hwnd      uintptr
+	thread    uintptr
+	config    WindowConfig
+	env       *comObject // ICoreWebView2Environment
+	ctrl      *comObject // ICoreWebView2Controller
+	view      *comObject // ICoreWebView2
+	onMessage func(string)
+	bounds    rect // kept on the heap for put_Bounds
+
+	mu                     sync.Mutex
+	queue                  []func()
+	minW, minH, maxW, maxH int
+
+	fullscreen bool
+	savedStyle uintptr
+	savedRect  rect
+
+	classIcon  uintptr // the icon the window class was registered with
+	customIcon uintptr // the icon set by SetIcon, destroyed when replaced
+
+	// Additional windows are WindowsWebviews too, with owner set to the
+	// main one, which keeps the table and the handlers.
+	id              int
+	owner           *WindowsWebview
+	scripts         []string // user scripts, given to additional windows
+	lastID          int
+	extra           map[int]*WindowsWebview
+	onWindowMessage func(id int, msg string)
+	onWindowClosed  func(id int)
+
+	// A built app's pages come from assets rather than a server, for URLs
+	// under assetOrigin; additional windows use the main window's.
+	assetOrigin string
+	assets      http.Handler
+
+	// allowNavigation, if set, decides where the main window may navigate.
+	allowNavigation func(url string) bool
+
+	// keepAlive is the inverse of SetQuitOnLastWindowClosed, so the zero
+	// value quits as a single-window app always has.
+	keepAlive bool
+}
+
+// New creates a new Windows webview.
+func New() Webview {
+	return &WindowsWebview{}
+}
+
+func (w *WindowsWebview) Create(config WindowConfig) error {
+	if err := config.Titlebar.Validate(); err != nil {
+		return err
+	}
+	w.config = config
+	w.minW, w.minH = config.MinWidth, config.MinHeight
+	w.thread, _, _ = procGetCurrentThreadId.Call()
+
+	if hr, _, _ := procCoInitializeEx.Call(0, coinitApartmentThreaded); uint32(hr) == hresultChangedMode {
+		return fmt.Errorf("webview: COM is already initialized for multithreading on the UI thread")
+	}
+	if procSetProcessDpiAwarenessContext.Find() == nil {
+		procSetProcessDpiAwarenessContext.Call(dpiAwarenessPerMonitorV2)
+	}
+
+	instance, _, _ := procGetModuleHandleW.Call(0)
+	// A built app embeds its icon as resource 1
+	icon, _, _ := procLoadIconW.Call(instance, 1)
+	if icon == 0 {
+		icon, _, _ = procLoadIconW.Call(0, idiApplication)
+	}
+	w.classIcon = icon
+	cursor, _, _ := procLoadCursorW.Call(0, idcArrow)
+	className := utf16Ptr(windowClass)
+	wc := wndClassEx{
+		WndProc:   wndProcCallback,
+		Instance:  instance,
+		Icon:      icon,
+		Cursor:    cursor,
+		ClassName: className,
+		IconSm:    icon,
+	}
+	wc.Size = uint32(unsafe.Sizeof(wc))
+	if !config.Transparent {
+		wc.Background = colorWindow + 1
+	}
+	if r, _, err := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); r == 0 {
+		return fmt.Errorf("webview: RegisterClassEx: %w", err)
+	}
+	if err := w.open(config); err != nil {
+		return err
+	}
+	procSetForegroundWindow.Call(w.hwnd)
+	return w.createWebView()
+}
+
+// open creates and shows the window, centered on the primary monitor.
+func (w *WindowsWebview) open(config WindowConfig) error {
+	instance, _, _ := procGetModuleHandleW.Call(0)
+	style, exStyle := windowStyle(config)
+	scale := systemScale()
+	r := rect{Right: int32(float64(config.Width) * scale), Bottom: int32(float64(config.Height) * scale)}
+	procAdjustWindowRectEx.Call(uintptr(unsafe.Pointer(&r)), style, 0, exStyle)
+	width, height := r.Right-r.Left, r.Bottom-r.Top
+
+	// Center in the primary monitor's work area, like [NSWindow center]
+	var work rect
+	procSystemParametersInfoW.Call(spiGetWorkArea, 0, uintptr(unsafe.Pointer(&work)), 0)
+	x := work.Left + (work.Right-work.Left-width)/2
+	y := work.Top + (work.Bottom-work.Top-height)/2
+
+	creating = w
+	hwnd, _, err := procCreateWindowExW.Call(exStyle, uintptr(unsafe.Pointer(utf16Ptr(windowClass))), uintptr(unsafe.Pointer(utf16Ptr(config.Title))),
+		style, uintptr(x), uintptr(y), uintptr(width), uintptr(height), 0, 0, instance, 0)
+	creating = nil
+	if hwnd == 0 {
+		return fmt.Errorf("webview: CreateWindowEx: %w", err)
+	}
+	w.hwnd = hwnd
+	windows[hwnd] = w
+	if !config.Hidden {
+		procShowWindow.Call(hwnd, swShow)
+		procUpdateWindow.Call(hwnd)
+	}
+	return nil
+}
+
+// windowStyle maps the config to Win32 window styles.
+func windowStyle(config WindowConfig) (style, exStyle uintptr) {
+	style = wsOverlappedWindow
+	if config.Frameless {
+		style = wsPopup | wsSysMenu | wsMinimizeBox
+		if config.Resizable {
+			style |= wsThickFrame | wsMaximizeBox
+		}
+	} else if !config.Resizable {
+		style &^= wsThickFrame | wsMaximizeBox
+	}
+	if config.AlwaysOnTop {
+		exStyle |= wsExTopmost
+	}
+	return style, exStyle
+}
+
+// createWebView creates the WebView2 environment and controller, pumping
+// messages until they exist since WebView2 completes both asynchronously
+// on this thread.
+func (w *WindowsWebview) createWebView() error {
+	var initErr error
+	ready := false
+	err := createEnvironment(userDataDir(), func(env *comObject, err error) {
+		if err != nil {
+			initErr = err
+			return
+		}
+		w.env = env
+		err = createController(env, w.hwnd, func(ctrl *comObject, err error) {
+			if err == nil {
+				err = w.attach(ctrl, func() { ready = true })
+			}
+			initErr = err
+		})
+		if err != nil {
+			initErr = err
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("webview: %w", err)
+	}
+	if !w.pump(func() bool { return ready || initErr != nil }, 0) {
+		return fmt.Errorf("webview: window closed before WebView2 was ready")
+	}
+	if initErr != nil {
+		return fmt.Errorf("webview: %w", initErr)
+	}
+	return nil
+}
+
+// userDataDir is where WebView2 keeps the profile. The default, next to
+// the executable, is not writable for an installed app.
+func userDataDir() string {
+	base := os.Getenv("LOCALAPPDATA")
+	if base == "" {
+		base = os.TempDir()
+	}
+	name := "lightshell"
+	if exe, err := os.Executable(); err == nil {
+		name = strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+	}
+	return filepath.Join(base, name, "WebView2")
+}
+
+// attach configures a new controller and its webview. ready runs once the
+// message bridge script is registered, so it applies to the first page.
+func (w *WindowsWebview) attach(ctrl *comObject, ready func()) error {
+	w.ctrl = ctrl
+	var view *comObject
+	if err := hresultError("get CoreWebView2", ctrl.call(controllerGetCoreWebView2, uintptr(unsafe.Pointer(&view)))); err != nil {
+		return err
+	}
+	w.view = view
+
+	var settings *comObject
+	if view.call(webviewGetSettings, uintptr(unsafe.Pointer(&settings))) == 0 && settings != nil {
+		settings.call(settingsPutAreDevToolsEnabled, comBool(w.config.DevTools))
+		settings.call(settingsPutIsStatusBarEnabled, 0)
+		settings.call(iunknownRelease)
+	}
+	if w.config.Transparent {
+		w.setTransparentBackground()
+	}
+
+	onMessage := newHandler(func(sender, args unsafe.Pointer) uintptr {
+		var p *uint16
+		if (*comObject)(args).call(webMessageTryGetWebMessageAsString, uintptr(unsafe.Pointer(&p))) == 0 {
+			if msg := takeCoTaskString(p); w.onMessage != nil {
+				w.onMessage(msg)
+			}
+		}
+		return 0
+	})
+	keepAlive(onMessage)
+	var token int64
+	if err := hresultError("add WebMessageReceived", view.call(webviewAddWebMessageReceived, uintptr(unsafe.Pointer(onMessage)), uintptr(unsafe.Pointer(&token)))); err != nil {
+		return err
+	}
+	if err := w.serveAssets(); err != nil {
+		return err
+	}
+	if err := w.guardNavigation(); err != nil {
+		return err
+	}
+	if err := w.addScript(bridgeScript, ready); err != nil {
+		return err
+	}
+
+	ctrl.call(controllerPutIsVisible, 1)
+	w.resize()
+	ctrl.call(controllerMoveFocus, moveFocusProgrammatic)
+	return nil
+}
+
+// setTransparentBackground lets the window background show through pages
+// that do not paint their own.
+func (w *WindowsWebview) setTransparentBackground() {
+	var ctrl2 *comObject
+	if w.ctrl.call(iunknownQueryInterface, uintptr(unsafe.Pointer(&iidICoreWebView2Controller2)), uintptr(unsafe.Pointer(&ctrl2))) != 0 || ctrl2 == nil {
+		return
+	}
+	ctrl2.call(controller2PutDefaultBackgroundColor, 0) // COREWEBVIEW2_COLOR{A: 0}
+	ctrl2.call(iunknownRelease)
+}
+
+func (w *WindowsWebview) addScript(js string, done func()) error {
+	h := newHandler(func(a, b unsafe.Pointer) uintptr {
+		if done != nil {
+			done()
+		}
+		return 0
+	})
+	keepAlive(h)
+	return hresultError("AddScriptToExecuteOnDocumentCreated",
+		w.view.call(webviewAddScriptToExecuteOnDocumentCreated, uintptr(unsafe.Pointer(utf16Ptr(js))), uintptr(unsafe.Pointer(h))))
+}
+
+// resize fits the webview to the client area.
+func (w *WindowsWebview) resize() {
+	if w.ctrl == nil {
+		return
+	}
+	procGetClientRect.Call(w.hwnd, uintptr(unsafe.Pointer(&w.bounds)))
+	w.ctrl.call(controllerPutBounds, rectArgs(&w.bounds)...)
+}
+
+func wndProc(hwnd, message, wParam, lParam uintptr) uintptr {
+	w := windows[hwnd]
+	if w == nil {
+		w = creating
+	}
+	switch message {
+	case wmSize:
+		if w != nil {
+			w.resize()
+		}
+	case wmMove:
+		if w != nil && w.ctrl != nil {
+			w.ctrl.call(controllerNotifyParentWindowPositionChanged)
+		}
+	case wmSetFocus:
+		if w != nil && w.ctrl != nil {
+			w.ctrl.call(controllerMoveFocus, moveFocusProgrammatic)
+		}
+	case wmGetMinMaxInfo:
+		if w != nil {
+			w.applySizeLimits(hwnd, *(**minMaxInfo)(unsafe.Pointer(&lParam)))
+			return 0
+		}
+	case wmDPIChanged:
+		// Move to the size Windows suggests for the new monitor's scale
+		r := *(**rect)(unsafe.Pointer(&lParam))
+		procSetWindowPos.Call(hwnd, 0, uintptr(r.Left), uintptr(r.Top), uintptr(r.Right-r.Left), uintptr(r.Bottom-r.Top), swpNoZOrder|swpNoActivate)
+		return 0
+	case wmDispatch:
+		if w != nil {
+			w.drain()
+		}
+		return 0
+	case wmClose:
+		if w != nil && w.owner == nil && (w.keepAlive || w.otherWindowVisible(hwnd)) {
+			// The page keeps running in the hidden window; Show brings it back
+			procShowWindow.Call(hwnd, swHide)
+			return 0
+		}
+	case wmDestroy:
+		if w != nil && w.ctrl != nil {
+			w.ctrl.call(controllerClose)
+			w.ctrl, w.view = nil, nil
+		}
+		delete(windows, hwnd)
+		if w != nil && w.owner != nil {
+			// Closing an additional window quits only if it was the last
+			w.owner.windowClosed(w.id)
+			if !w.owner.keepAlive && !w.owner.otherWindowVisible(hwnd) {
+				procPostQuitMessage.Call(0)
+			}
+			return 0
+		}
+		procPostQuitMessage.Call(0)
+		return 0
+	}
+	r, _, _ := procDefWindowProcW.Call(hwnd, message, wParam, lParam)
+	return r
+}
+
+// applySizeLimits enforces the min and max window sizes.
+func (w *WindowsWebview) applySizeLimits(hwnd uintptr, mmi *minMaxInfo) {
+	w.mu.Lock()
+	minW, minH, maxW, maxH := w.minW, w.minH, w.maxW, w.maxH
+	w.mu.Unlock()
+	scale := windowScale(hwnd)
+	if minW > 0 && minH > 0 {
+		mmi.MinTrackSize = point{int32(float64(minW) * scale), int32(float64(minH) * scale)}
+	}
+	if maxW > 0 && maxH > 0 {
+		mmi.MaxTrackSize = point{int32(float64(maxW) * scale), int32(float64(maxH) * scale)}
+	}
+}
+
+// pump runs the message loop until done reports true, returning false if
+// the timeout (if any) passes or the app is quitting first.
+func (w *WindowsWebview) pump(done func() bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	var m msg
+	for !done() {
+		if timeout > 0 && time.Now().After(deadline) {
+			return false
+		}
+		if r, _, _ := procPeekMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0, pmRemove); r == 0 {
+			procMsgWaitForMultipleObjects.Call(0, 0, 0, 50, qsAllInput)
+			continue
+		}
+		if m.Message == wmQuit {
+			// Leave the quit for Run
+			procPostQuitMessage.Call(m.WParam)
+			return false
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+	}
+	return true
+}
+
+func (w *WindowsWebview) onUIThread() bool {
+	id, _, _ := procGetCurrentThreadId.Call()
+	return id == w.thread
+}
+
+// dispatch runs f on the UI thread: now if this is the UI thread,
+// otherwise from the message loop.
+func (w *WindowsWebview) dispatch(f func()) {
+	if w.owner != nil {
+		// Additional windows share the main window's queue
+		w.owner.dispatch(f)
+		return
+	}
+	if w.onUIThread() {
+		f()
+		return
+	}
+	w.mu.Lock()
+	w.queue = append(w.queue, f)
+	w.mu.Unlock()
+	procPostMessageW.Call(w.hwnd, wmDispatch, 0, 0)
+}
+
+func (w *WindowsWebview) drain() {
+	w.mu.Lock()
+	queue := w.queue
+	w.queue = nil
+	w.mu.Unlock()
+	for _, f := range queue {
+		f()
+	}
+}
+
+// withView dispatches f if the webview exists.
+func (w *WindowsWebview) withView(f func(view *comObject)) error {
+	if w.hwnd == 0 {
+		return fmt.Errorf("webview: window not created")
+	}
+	w.dispatch(func() {
+		if w.view != nil {
+			f(w.view)
+		}
+	})
+	return nil
+}
+
+// systemScale is the scale factor before there is a window to ask.
+func systemScale() float64 {
+	if procGetDpiForSystem.Find() != nil {
+		return 1
+	}
+	dpi, _, _ := procGetDpiForSystem.Call()
+	if dpi == 0 {
+		return 1
+	}
+	return float64(dpi) / userDefaultDPI
+}
+
+// windowScale is the scale factor of the monitor hwnd is on.
+func windowScale(hwnd uintptr) float64 {
+	if procGetDpiForWindow.Find() != nil {
+		return systemScale()
+	}
+	dpi, _, _ := procGetDpiForWindow.Call(hwnd)
+	if dpi == 0 {
+		return 1
+	}
+	return float64(dpi) / userDefaultDPI
+}
+
+func (w *WindowsWebview) LoadHTML(html string) error {
+	return w.withView(func(view *comObject) {
+		view.call(webviewNavigateToString, uintptr(unsafe.Pointer(utf16Ptr(html))))
+	})
+}
+
+func (w *WindowsWebview) LoadURL(url string) error {
+	return w.withView(func(view *comObject) {
+		view.call(webviewNavigate, uintptr(unsafe.Pointer(utf16Ptr(url))))
+	})
+}
+
+func (w *WindowsWebview) Eval(js string) error {
+	return w.withView(func(view *comObject) {
+		view.call(webviewExecuteScript, uintptr(unsafe.Pointer(utf16Ptr(js))), 0)
+	})
+}
+
+// AddUserScript registers js for every page. On the UI thread it waits for
+// WebView2 to confirm, so a LoadURL right after sees the script.
+func (w *WindowsWebview) AddUserScript(js string) error {
+	if w.view == nil {
+		return fmt.Errorf("webview: window not created")
+	}
+	w.mu.Lock()
+	w.scripts = append(w.scripts, js)
+	w.mu.Unlock()
+	if !w.onUIThread() {
+		return w.withView(func(view *comObject) { w.addScript(js, nil) })
+	}
+	added := false
+	if err := w.addScript(js, func() { added = true }); err != nil {
+		return fmt.Errorf("webview: %w", err)
+	}
+	w.pump(func() bool { return added }, 5*time.Second)
+	return nil
+}
+
+func (w *WindowsWebview) SetTitle(title string) error {
+	w.dispatch(func() {
+		procSetWindowTextW.Call(w.hwnd, uintptr(unsafe.Pointer(utf16Ptr(title))))
+	})
+	return nil
+}
+
+func (w *WindowsWebview) SetSize(width, height int) error {
+	w.dispatch(func() {
+		scale := windowScale(w.hwnd)
+		procSetWindowPos.Call(w.hwnd, 0, 0, 0, uintptr(float64(width)*scale), uintptr(float64(height)*scale),
+			swpNoMove|swpNoZOrder|swpNoActivate)
+	})
+	return nil
+}
+
+func (w *WindowsWebview) GetSize() (int, int) {
+	var r rect
+	if ok, _, _ := procGetWindowRect.Call(w.hwnd, uintptr(unsafe.Pointer(&r))); ok == 0 {
+		return 0, 0
+	}
+	scale := windowScale(w.hwnd)
+	return int(float64(r.Right-r.Left) / scale), int(float64(r.Bottom-r.Top) / scale)
+}
+
+func (w *WindowsWebview) SetMinSize(width, height int) error {
+	w.mu.Lock()
+	w.minW, w.minH = width, height
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *WindowsWebview) SetMaxSize(width, height int) error {
+	w.mu.Lock()
+	w.maxW, w.maxH = width, height
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *WindowsWebview) SetPosition(x, y int) error {
+	w.dispatch(func() {
+		scale := windowScale(w.hwnd)
+		procSetWindowPos.Call(w.hwnd, 0, uintptr(int32(float64(x)*scale)), uintptr(int32(float64(y)*scale)), 0, 0,
+			swpNoSize|swpNoZOrder|swpNoActivate)
+	})
+	return nil
+}
+
+func (w *WindowsWebview) GetPosition() (int, int) {
+	var r rect
+	if ok, _, _ := procGetWindowRect.Call(w.hwnd, uintptr(unsafe.Pointer(&r))); ok == 0 {
+		return 0, 0
+	}
+	scale := windowScale(w.hwnd)
+	return int(float64(r.Left) / scale), int(float64(r.Top) / scale)
+}
+
+// Fullscreen covers the monitor with a borderless window; Windows has no
+// separate fullscreen mode for ordinary windows.
+func (w *WindowsWebview) Fullscreen() error {
+	w.dispatch(func() {
+		if w.fullscreen {
+			return
+		}
+		w.savedStyle, _, _ = procGetWindowLong.Call(w.hwnd, gwlStyle)
+		procGetWindowRect.Call(w.hwnd, uintptr(unsafe.Pointer(&w.savedRect)))
+
+		mi := monitorInfo{}
+		mi.Size = uint32(unsafe.Sizeof(mi))
+		monitor, _, _ := procMonitorFromWindow.Call(w.hwnd, monitorNearest)
+		procGetMonitorInfoW.Call(monitor, uintptr(unsafe.Pointer(&mi)))
+
+		procSetWindowLong.Call(w.hwnd, gwlStyle, w.savedStyle&^(wsCaption|wsThickFrame))
+		m := mi.Monitor
+		procSetWindowPos.Call(w.hwnd, 0, uintptr(m.Left), uintptr(m.Top), uintptr(m.Right-m.Left), uintptr(m.Bottom-m.Top),
+			swpNoOwnerZOrder|swpFrameChanged)
+		w.fullscreen = true
+	})
+	return nil
+}
+
+func (w *WindowsWebview) exitFullscreen() {
+	if !w.fullscreen {
+		return
+	}
+	procSetWindowLong.Call(w.hwnd, gwlStyle, w.savedStyle)
+	r := w.savedRect
+	procSetWindowPos.Call(w.hwnd, 0, uintptr(r.Left), uintptr(r.Top), uintptr(r.Right-r.Left), uintptr(r.Bottom-r.Top),
+		swpNoOwnerZOrder|swpFrameChanged)
+	w.fullscreen = false
+}
+
+func (w *WindowsWebview) Minimize() error {
+	w.dispatch(func() { procShowWindow.Call(w.hwnd, swMinimize) })
+	return nil
+}
+
+func (w *WindowsWebview) Maximize() error {
+	w.dispatch(func() { procShowWindow.Call(w.hwnd, swMaximize) })
+	return nil
+}
+
+func (w *WindowsWebview) Restore() error {
+	w.dispatch(func() {
+		if w.fullscreen {
+			w.exitFullscreen()
+			return
+		}
+		procShowWindow.Call(w.hwnd, swRestore)
+	})
+	return nil
+}
+
+func (w *WindowsWebview) Close() error {
+	procPostMessageW.Call(w.hwnd, wmClose, 0, 0)
+	return nil
+}
+
+func (w *WindowsWebview) SetContentProtection(enabled bool) error {
+	w.dispatch(func() {
+		if !enabled {
+			procSetWindowDisplayAffinity.Call(w.hwnd, wdaNone)
+			return
+		}
+		// Excluding the window from capture needs Windows 10 2004; before
+		// that it can only be blacked out
+		if ok, _, _ := procSetWindowDisplayAffinity.Call(w.hwnd, wdaExcludeCapture); ok == 0 {
+			procSetWindowDisplayAffinity.Call(w.hwnd, wdaMonitor)
+		}
+	})
+	return nil
+}
+
+// vibrancyBackdrops maps the macOS materials this backend supports to DWM
+// system backdrops. Other styles remove the backdrop.
+var vibrancyBackdrops = map[string]uint32{
+	"sidebar": dwmsbtMainWindow,
+	"content": dwmsbtMainWindow,
+	"header":  dwmsbtTabbedWindow,
+	"sheet":   dwmsbtTransientWindow,
+}
+
+// SetVibrancy applies a Mica or Acrylic system backdrop, which needs
+// Windows 11 22H2; on older versions it does nothing.
+func (w *WindowsWebview) SetVibrancy(style string) error {
+	w.dispatch(func() {
+		backdrop, ok := vibrancyBackdrops[style]
+		if !ok {
+			backdrop = dwmsbtNone
+		}
+		if procDwmSetWindowAttribute.Find() != nil {
+			return
+		}
+		procDwmSetWindowAttribute.Call(w.hwnd, dwmwaSystemBackdropType, uintptr(unsafe.Pointer(&backdrop)), unsafe.Sizeof(backdrop))
+		if ok {
+			// The backdrop shows through the frame extended over the client area
+			m := margins{-1, -1, -1, -1}
+			procDwmExtendFrameIntoClientArea.Call(w.hwnd, uintptr(unsafe.Pointer(&m)))
+			if w.ctrl != nil {
+				w.setTransparentBackground()
+			}
+		}
+	})
+	return nil
+}
+
+// SetColorScheme switches the title bar between light and dark. Pages
+// follow the system setting through prefers-color-scheme.
+func (w *WindowsWebview) SetColorScheme(scheme string) error {
+	w.dispatch(func() {
+		var dark uint32
+		switch scheme {
+		case "dark":
+			dark = 1
+		case "light":
+		default:
+			if systemUsesDarkMode() {
+				dark = 1
+			}
+		}
+		procDwmSetWindowAttribute.Call(w.hwnd, dwmwaUseImmersiveDarkMode, uintptr(unsafe.Pointer(&dark)), unsafe.Sizeof(dark))
+	})
+	return nil
+}
+
+// systemUsesDarkMode reads the "Choose your app mode" setting.
+func systemUsesDarkMode() bool {
+	var key syscall.Handle
+	path := utf16Ptr(`Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`)
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_CURRENT_USER, path, 0, syscall.KEY_READ, &key); err != nil {
+		return false
+	}
+	defer syscall.RegCloseKey(key)
+	var light uint32
+	size := uint32(unsafe.Sizeof(light))
+	if err := syscall.RegQueryValueEx(key, utf16Ptr("AppsUseLightTheme"), nil, nil, (*byte)(unsafe.Pointer(&light)), &size); err != nil {
+		return false
+	}
+	return light == 0
+}
+
+// SetIcon replaces the title bar and taskbar icon with PNG data. Nil data
+// restores the app's own icon.
+func (w *WindowsWebview) SetIcon(data []byte) error {
+	var icon uintptr
+	if len(data) > 0 {
+		icon, _, _ = procCreateIconFromResourceEx.Call(uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), 1, iconResVersion, 0, 0, 0)
+		if icon == 0 {
+			return fmt.Errorf("window icon: not a PNG image")
+		}
+	}
+	w.dispatch(func() {
+		show := icon
+		if show == 0 {
+			show = w.classIcon
+		}
+		procSendMessageW.Call(w.hwnd, wmSetIcon, iconBig, show)
+		procSendMessageW.Call(w.hwnd, wmSetIcon, iconSmall, show)
+		if w.customIcon != 0 {
+			procDestroyIcon.Call(w.customIcon)
+		}
+		w.customIcon = icon
+	})
+	return nil
+}
+
+// SetTitlebar validates the style but has no effect: the options are
+// macOS title bar properties.
+func (w *WindowsWebview) SetTitlebar(style TitlebarStyle) error {
+	return style.Validate()
+}
+
+// EnableFileDrop has nothing to do: WebView2 accepts dropped files by
+// default and delivers them to the page as drop events.
+func (w *WindowsWebview) EnableFileDrop() error {
+	return nil
+}
+
+func (w *WindowsWebview) OnMessage(handler func(msg string)) {
+	w.onMessage = handler
+}
+
+// Screenshot captures the webview as PNG with CapturePreview.
+func (w *WindowsWebview) Screenshot() ([]byte, error) {
+	type result struct {
+		png []byte
+		err error
+	}
+	results := make(chan result, 1)
+	err := w.withView(func(view *comObject) {
+		var stream *comObject
+		if r, _, _ := procCreateStreamOnHGlobal.Call(0, 1, uintptr(unsafe.Pointer(&stream))); int32(r) < 0 {
+			results <- result{err: hresultError("CreateStreamOnHGlobal", r)}
+			return
+		}
+		h := newHandler1(func(a unsafe.Pointer) uintptr {
+			defer stream.call(iunknownRelease)
+			if err := hresultError("CapturePreview", hresult(a)); err != nil {
+				results <- result{err: err}
+				return 0
+			}
+			png, err := streamBytes(stream)
+			results <- result{png, err}
+			return 0
+		})
+		keepAlive(h)
+		if err := hresultError("CapturePreview", view.call(webviewCapturePreview, capturePreviewPNG, uintptr(unsafe.Pointer(stream)), uintptr(unsafe.Pointer(h)))); err != nil {
+			stream.call(iunknownRelease)
+			results <- result{err: err}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res result
+	received := func() bool {
+		select {
+		case res = <-results:
+			return true
+		default:
+			return false
+		}
+	}
+	// On the UI thread the capture completes from the message loop
+	if w.onUIThread() {
+		if !w.pump(received, 5*time.Second) {
+			return nil, fmt.Errorf("screenshot failed: webview not available or timed out")
+		}
+	} else {
+		select {
+		case res = <-results:
+		case <-time.After(5 * time.Second):
+			return nil, fmt.Errorf("screenshot failed: webview not available or timed out")
+		}
+	}
+	if res.err != nil {
+		return nil, fmt.Errorf("screenshot failed: %w", res.err)
+	}
+	return res.png, nil
+}
+
+// CreateWindow opens an additional window. It is created on the UI thread
+// after CreateWindow returns; if that fails, the window is reported as
+// closed.
+func (w *WindowsWebview) CreateWindow(config WindowConfig, url string) (int, error) {
+	if w.hwnd == 0 {
+		return 0, fmt.Errorf("webview: window not created")
+	}
+	w.mu.Lock()
+	if w.extra == nil {
+		w.lastID = MainWindowID
+		w.extra = map[int]*WindowsWebview{}
+	}
+	w.lastID++
+	child := &WindowsWebview{id: w.lastID, owner: w, thread: w.thread, config: config, classIcon: w.classIcon}
+	child.minW, child.minH = config.MinWidth, config.MinHeight
+	w.extra[child.id] = child
+	// The page learns its window ID before the client library runs
+	scripts := append([]string{fmt.Sprintf("window.__lightshell_window_id = %d;", child.id)}, w.scripts...)
+	w.mu.Unlock()
+
+	child.onMessage = func(msg string) {
+		w.mu.Lock()
+		handler := w.onWindowMessage
+		w.mu.Unlock()
+		if handler != nil {
+			handler(child.id, msg)
+		}
+	}
+	w.dispatch(func() {
+		if err := child.open(config); err != nil {
+			w.windowClosed(child.id)
+			return
+		}
+		procSetForegroundWindow.Call(child.hwnd)
+		if err := child.createWebView(); err != nil {
+			procDestroyWindow.Call(child.hwnd)
+			return
+		}
+		added := 0
+		for _, js := range scripts {
+			child.addScript(js, func() { added++ })
+		}
+		child.pump(func() bool { return added == len(scripts) }, 5*time.Second)
+		if child.view != nil {
+			child.view.call(webviewNavigate, uintptr(unsafe.Pointer(utf16Ptr(url))))
+		}
+	})
+	return child.id, nil
+}
+
+// window returns the webview of window id.
+func (w *WindowsWebview) window(id int) (*WindowsWebview, error) {
+	if id == MainWindowID {
+		return w, nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if child, ok := w.extra[id]; ok {
+		return child, nil
+	}
+	return nil, fmt.Errorf("no window with id %d", id)
+}
+
+// windowClosed forgets an additional window and reports it.
+func (w *WindowsWebview) windowClosed(id int) {
+	w.mu.Lock()
+	delete(w.extra, id)
+	handler := w.onWindowClosed
+	w.mu.Unlock()
+	if handler != nil {
+		handler(id)
+	}
+}
+
+func (w *WindowsWebview) EvalWindow(id int, js string) error {
+	win, err := w.window(id)
+	if err != nil {
+		return err
+	}
+	return win.Eval(js)
+}
+
+// EvalResult runs js with ExecuteScript, whose completion handler gets the
+// value as JSON.
+func (w *WindowsWebview) EvalResult(id int, js string, done func(string, error)) error {
+	win, err := w.window(id)
+	if err != nil {
+		return err
+	}
+	return win.withView(func(view *comObject) {
+		h := newHandler(func(a, b unsafe.Pointer) uintptr {
+			if err := hresultError("ExecuteScript", hresult(a)); err != nil {
+				done("", err)
+				return 0
+			}
+			var result string
+			if err := json.Unmarshal([]byte(utf16String((*uint16)(b))), &result); err != nil {
+				done("", fmt.Errorf("the script did not return a string"))
+				return 0
+			}
+			done(result, nil)
+			return 0
+		})
+		keepAlive(h)
+		if err := hresultError("ExecuteScript", view.call(webviewExecuteScript, uintptr(unsafe.Pointer(utf16Ptr(js))), uintptr(unsafe.Pointer(h)))); err != nil {
+			done("", err)
+		}
+	})
+}
+
+func (w *WindowsWebview) FocusWindow(id int) error {
+	win, err := w.window(id)
+	if err != nil {
+		return err
+	}
+	w.dispatch(func() {
+		if win.hwnd == 0 {
+			return
+		}
+		if iconic, _, _ := procIsIconic.Call(win.hwnd); iconic != 0 {
+			procShowWindow.Call(win.hwnd, swRestore)
+		}
+		procSetForegroundWindow.Call(win.hwnd)
+	})
+	return nil
+}
+
+func (w *WindowsWebview) CloseWindow(id int) error {
+	win, err := w.window(id)
+	if err != nil {
+		return err
+	}
+	return win.Close()
+}
+
+func (w *WindowsWebview) ScreenshotWindow(id int) ([]byte, error) {
+	win, err := w.window(id)
+	if err != nil {
+		return nil, err
+	}
+	return win.Screenshot()
+}
+
+func (w *WindowsWebview) WindowSize(id int) (int, int, error) {
+	win, err := w.window(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	width, height := win.GetSize()
+	return width, height, nil
+}
+
+func (w *WindowsWebview) OnWindowMessage(handler func(id int, msg string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onWindowMessage = handler
+}
+
+func (w *WindowsWebview) OnWindowClosed(handler func(id int)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onWindowClosed = handler
+}
+
+// otherWindowVisible reports whether a window besides the one with handle
+// closing is shown, minimized or not. Called on the UI thread of the main
+// webview w.
+func (w *WindowsWebview) otherWindowVisible(closing uintptr) bool {
+	for _, hwnd := range w.hwnds() {
+		if hwnd == 0 || hwnd == closing {
+			continue
+		}
+		if visible, _, _ := procIsWindowVisible.Call(hwnd); visible != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *WindowsWebview) SetQuitOnLastWindowClosed(quit bool) {
+	w.dispatch(func() { w.keepAlive = !quit })
+}
+
+func (w *WindowsWebview) Hide() error {
+	w.dispatch(func() {
+		for _, hwnd := range w.hwnds() {
+			procShowWindow.Call(hwnd, swHide)
+		}
+	})
+	return nil
+}
+
+func (w *WindowsWebview) Show() error {
+	w.dispatch(func() {
+		for _, hwnd := range w.hwnds() {
+			if hwnd == w.hwnd && w.config.Hidden {
+				continue
+			}
+			if iconic, _, _ := procIsIconic.Call(hwnd); iconic != 0 {
+				procShowWindow.Call(hwnd, swRestore)
+			} else {
+				procShowWindow.Call(hwnd, swShow)
+			}
+		}
+		if !w.config.Hidden {
+			procSetForegroundWindow.Call(w.hwnd)
+		}
+	})
+	return nil
+}
+
+func (w *WindowsWebview) Quit() {
+	w.dispatch(func() { procPostQuitMessage.Call(0) })
+}
+
+// OnReopen is never called: Windows has no Dock icon to click.
+func (w *WindowsWebview) OnReopen(handler func(hasVisibleWindows bool)) {}
+
+// EvalIsolated fails: WebView2 runs every script in the page's world.
+func (w *WindowsWebview) EvalIsolated(js string) error {
+	return fmt.Errorf("isolated scripts are not supported by WebView2")
+}
+
+func (w *WindowsWebview) OnIsolatedMessage(handler func(msg string)) {}
+
+// hwnds returns the main window's handle followed by the additional ones.
+func (w *WindowsWebview) hwnds() []uintptr {
+	hwnds := []uintptr{w.hwnd}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, child := range w.extra {
+		hwnds = append(hwnds, child.hwnd)
+	}
+	return hwnds
+}
+
+func (w *WindowsWebview) Run() error {
+	var m msg
+	for {
+		r, _, err := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		switch int32(r) {
+		case 0:
+			return nil
+		case -1:
+			return fmt.Errorf("webview: GetMessage: %w", err)
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+	}
+}
+
+func (w *WindowsWebview) Destroy() {
+	w.dispatch(func() {
+		if w.hwnd != 0 {
+			procDestroyWindow.Call(w.hwnd)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+// Package winres writes Windows resources as a COFF object file (.syso),
+// which the go tool links into executables built from the directory it is
+// in. A built app gets its icon this way: Explorer, the taskbar, and the
+// app's windows show icon group 1.
+package winres
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	rtIcon      = 3
+	rtGroupIcon = 14
+	langEnUS    = 0x0409
+)
+
+// machines maps GOARCH to the COFF machine type and the relocation that
+// stores an address relative to the image base.
+var machines = map[string]struct {
+	machine uint16
+	addr32  uint16
+}{
+	"amd64": {0x8664, 0x0003}, // IMAGE_REL_AMD64_ADDR32NB
+	"arm64": {0xaa64, 0x0002}, // IMAGE_REL_ARM64_ADDR32NB
+	"386":   {0x014c, 0x0007}, // IMAGE_REL_I386_DIR32NB
+}
+
+// SysoName returns the file name for a .syso for goarch; its suffix keeps
+// the go tool from linking it into builds for other platforms.
+func SysoName(goarch string) string {
+	return "rsrc_windows_" + goarch + ".syso"
+}
+
+// resource is one leaf of the resource tree.
+type resource struct {
+	kind, id uint32
+	data     []byte
+}
+
+// IconSyso returns a .syso for goarch holding the images of an .ico file
+// as icon group 1.
+func IconSyso(ico []byte, goarch string) ([]byte, error) {
+	if len(ico) < 6 || binary.LittleEndian.Uint16(ico[2:]) != 1 {
+		return nil, fmt.Errorf("not an .ico file")
+	}
+	count := int(binary.LittleEndian.Uint16(ico[4:]))
+	if count == 0 || len(ico) < 6+16*count {
+		return nil, fmt.Errorf("truncated .ico file")
+	}
+
+	// The group repeats the .ico's directory, with resource IDs in place
+	// of file offsets
+	var group bytes.Buffer
+	binary.Write(&group, binary.LittleEndian, [3]uint16{0, 1, uint16(count)})
+	resources := make([]resource, 0, count+1)
+	for i := 0; i < count; i++ {
+		entry := ico[6+16*i : 6+16*(i+1)]
+		size := binary.LittleEndian.Uint32(entry[8:])
+		offset := binary.LittleEndian.Uint32(entry[12:])
+		if uint64(offset)+uint64(size) > uint64(len(ico)) {
+			return nil, fmt.Errorf("truncated .ico file")
+		}
+		group.Write(entry[:12])
+		binary.Write(&group, binary.LittleEndian, uint16(i+1))
+		resources = append(resources, resource{rtIcon, uint32(i + 1), ico[offset : offset+size]})
+	}
+	resources = append(resources, resource{rtGroupIcon, 1, group.Bytes()})
+	return coff(resources, goarch)
+}
+
+// coff lays out resources, which come ordered by kind and then ID, as the
+// .rsrc section of an object file: a directory of kinds, one of IDs per kind,
+// one of languages per resource, the data entries, and then the data.
+// Data entries hold image-relative addresses, so each gets a relocation
+// against the section's symbol.
+func coff(resources []resource, goarch string) ([]byte, error) {
+	m, ok := machines[goarch]
+	if !ok {
+		return nil, fmt.Errorf("no Windows resources for GOARCH %s", goarch)
+	}
+
+	var kinds []uint32
+	byKind := map[uint32][]resource{}
+	for _, r := range resources {
+		if byKind[r.kind] == nil {
+			kinds = append(kinds, r.kind)
+		}
+		byKind[r.kind] = append(byKind[r.kind], r)
+	}
+
+	const dirSize, entrySize, dataEntrySize = 16, 8, 16
+	// Offsets of each part of the tree within the section
+	idDirs := dirSize + entrySize*len(kinds)
+	langDirs := idDirs
+	for _, k := range kinds {
+		langDirs += dirSize + entrySize*len(byKind[k])
+	}
+	dataEntries := langDirs + (dirSize+entrySize)*len(resources)
+	data := dataEntries + dataEntrySize*len(resources)
+
+	var sec bytes.Buffer
+	dir := func(n int) {
+		binary.Write(&sec, binary.LittleEndian, [4]uint32{0, 0, 0, uint32(n)}) // n ID entries, no named ones
+	}
+	entry := func(id uint32, offset int, subdir bool) {
+		if subdir {
+			offset |= 1 << 31
+		}
+		binary.Write(&sec, binary.LittleEndian, [2]uint32{id, uint32(offset)})
+	}
+
+	dir(len(kinds))
+	next := idDirs
+	for _, k := range kinds {
+		entry(k, next, true)
+		next += dirSize + entrySize*len(byKind[k])
+	}
+	lang := langDirs
+	var ordered []resource
+	for _, k := range kinds {
+		dir(len(byKind[k]))
+		for _, r := range byKind[k] {
+			entry(r.id, lang, true)
+			lang += dirSize + entrySize
+			ordered = append(ordered, r)
+		}
+	}
+	for i := range ordered {
+		dir(1)
+		entry(langEnUS, dataEntries+dataEntrySize*i, false)
+	}
+	var relocs []uint32
+	offset := data
+	for _, r := range ordered {
+		relocs = append(relocs, uint32(sec.Len()))
+		binary.Write(&sec, binary.LittleEndian, [4]uint32{uint32(offset), uint32(len(r.data)), 0, 0})
+		offset += align8(len(r.data))
+	}
+	for _, r := range ordered {
+		sec.Write(r.data)
+		sec.Write(make([]byte, align8(len(r.data))-len(r.data)))
+	}
+
+	const fileHeader, sectionHeader = 20, 40
+	rawData := fileHeader + sectionHeader
+	relocTable := rawData + sec.Len()
+	symbols := relocTable + 10*len(relocs)
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, struct {
+		Machine, Sections       uint16
+		Time, Symbols, NSymbols uint32
+		OptHeader, Flags        uint16
+	}{m.machine, 1, 0, uint32(symbols), 1, 0, 0})
+	binary.Write(&out, binary.LittleEndian, struct {
+		Name                                  [8]byte
+		VirtualSize, VirtualAddress           uint32
+		RawSize, RawData, Relocs, LineNumbers uint32
+		NRelocs, NLineNumbers                 uint16
+		Flags                                 uint32
+	}{[8]byte{'.', 'r', 's', 'r', 'c'}, 0, 0, uint32(sec.Len()), uint32(rawData), uint32(relocTable), 0,
+		uint16(len(relocs)), 0, 0x40000040}) // initialized data, readable
+	out.Write(sec.Bytes())
+	for _, r := range relocs {
+		binary.Write(&out, binary.LittleEndian, struct {
+			Address, Symbol uint32
+			Type            uint16
+		}{r, 0, m.addr32})
+	}
+	// The section's symbol, and an empty string table
+	binary.Write(&out, binary.LittleEndian, struct {
+		Name              [8]byte
+		Value             uint32
+		Section           int16
+		Type              uint16
+		Class, AuxSymbols uint8
+	}{[8]byte{'.', 'r', 's', 'r', 'c'}, 0, 1, 0, 3, 0}) // IMAGE_SYM_CLASS_STATIC
+	binary.Write(&out, binary.LittleEndian, uint32(4))
+	return out.Bytes(), nil
+}
+
+func align8(n int) int {
+	return (n + 7) &^ 7
+}
@@ -0,0 +1,104 @@
+package winres
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"testing"
+)
+
+// ico builds an .ico file whose images are the given payloads.
+func ico(images ...[]byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, [3]uint16{0, 1, uint16(len(images))})
+	offset := 6 + 16*len(images)
+	for i, img := range images {
+		b.Write([]byte{byte(16 * (i + 1)), byte(16 * (i + 1)), 0, 0})
+		binary.Write(&b, binary.LittleEndian, [2]uint16{1, 32})
+		binary.Write(&b, binary.LittleEndian, [2]uint32{uint32(len(img)), uint32(offset)})
+		offset += len(img)
+	}
+	for _, img := range images {
+		b.Write(img)
+	}
+	return b.Bytes()
+}
+
+// leaves walks the resource tree in sec and returns each resource's data
+// by its kind/id path. Data entries hold section offsets until linked.
+func leaves(t *testing.T, sec []byte) map[[2]uint32][]byte {
+	t.Helper()
+	out := map[[2]uint32][]byte{}
+	var walk func(off uint32, path []uint32)
+	walk = func(off uint32, path []uint32) {
+		n := uint32(binary.LittleEndian.Uint16(sec[off+12:])) + uint32(binary.LittleEndian.Uint16(sec[off+14:]))
+		for i := uint32(0); i < n; i++ {
+			id := binary.LittleEndian.Uint32(sec[off+16+8*i:])
+			next := binary.LittleEndian.Uint32(sec[off+20+8*i:])
+			if next&(1<<31) != 0 {
+				walk(next&^(1<<31), append(path, id))
+				continue
+			}
+			data, size := binary.LittleEndian.Uint32(sec[next:]), binary.LittleEndian.Uint32(sec[next+4:])
+			out[[2]uint32{path[0], path[1]}] = sec[data : data+size]
+		}
+	}
+	walk(0, nil)
+	return out
+}
+
+func TestIconSyso(t *testing.T) {
+	small, large := []byte("small image"), []byte("large image data")
+	obj, err := IconSyso(ico(small, large), "arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := pe.NewFile(bytes.NewReader(obj))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Machine != pe.IMAGE_FILE_MACHINE_ARM64 {
+		t.Errorf("machine %#x", f.Machine)
+	}
+	sec := f.Section(".rsrc")
+	if sec == nil {
+		t.Fatal("no .rsrc section")
+	}
+	if len(sec.Relocs) != 3 {
+		t.Errorf("%d relocations, want one per resource", len(sec.Relocs))
+	}
+	data, err := sec.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := leaves(t, data)
+	if !bytes.Equal(res[[2]uint32{rtIcon, 1}], small) || !bytes.Equal(res[[2]uint32{rtIcon, 2}], large) {
+		t.Errorf("icons: %q", res)
+	}
+	group := res[[2]uint32{rtGroupIcon, 1}]
+	if len(group) != 6+14*2 {
+		t.Fatalf("group is %d bytes", len(group))
+	}
+	for i, want := range []struct {
+		dim  byte
+		size int
+	}{{16, len(small)}, {32, len(large)}} {
+		e := group[6+14*i:]
+		if e[0] != want.dim || int(binary.LittleEndian.Uint32(e[8:])) != want.size || binary.LittleEndian.Uint16(e[12:]) != uint16(i+1) {
+			t.Errorf("group entry %d: % x", i, e[:14])
+		}
+	}
+}
+
+func TestIconSysoErrors(t *testing.T) {
+	if _, err := IconSyso([]byte("PNG"), "amd64"); err == nil {
+		t.Error("accepted a non-.ico file")
+	}
+	if _, err := IconSyso(ico([]byte("x"))[:10], "amd64"); err == nil {
+		t.Error("accepted a truncated .ico file")
+	}
+	if _, err := IconSyso(ico([]byte("x")), "mips"); err == nil {
+		t.Error("accepted an unknown GOARCH")
+	}
+}
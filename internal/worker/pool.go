@@ -0,0 +1,159 @@
+// Package worker runs CPU-heavy IPC handlers on a bounded pool so they do not
+// block message dispatch. Each namespace (the part of a method before the
+// first dot, e.g. "image") has its own concurrency limit within the pool.
+package worker
+
+import (
+	"errors"
+	goruntime "runtime"
+	"sync"
+	"time"
+
+	"github.com/lightshell-dev/lightshell/internal/metrics"
+)
+
+// ErrQueueFull is returned by Submit when the pool cannot accept more work.
+var ErrQueueFull = errors.New("worker queue is full")
+
+// DefaultQueue is the maximum number of waiting jobs when none is configured.
+const DefaultQueue = 256
+
+// Config sizes a pool. Zero values use defaults.
+type Config struct {
+	Size       int            // total concurrent jobs (default: number of CPUs)
+	Queue      int            // jobs allowed to wait before Submit fails (default: DefaultQueue)
+	Namespaces map[string]int // per-namespace concurrency limits (default: Size)
+}
+
+// Stats is the state of one namespace.
+type Stats struct {
+	Queued  int `json:"queued"`
+	Running int `json:"running"`
+}
+
+// Pool is a bounded worker pool. It is safe for concurrent use.
+type Pool struct {
+	slots chan struct{} // global concurrency
+	queue int
+
+	mu      sync.Mutex
+	limits  map[string]int
+	nsSlots map[string]chan struct{}
+	stats   map[string]*Stats
+	queued  int
+}
+
+// NewPool creates a pool from cfg.
+func NewPool(cfg Config) *Pool {
+	size := cfg.Size
+	if size <= 0 {
+		size = goruntime.NumCPU()
+	}
+	queue := cfg.Queue
+	if queue <= 0 {
+		queue = DefaultQueue
+	}
+	limits := make(map[string]int, len(cfg.Namespaces))
+	for ns, n := range cfg.Namespaces {
+		limits[ns] = n
+	}
+	return &Pool{
+		slots:   make(chan struct{}, size),
+		queue:   queue,
+		limits:  limits,
+		nsSlots: make(map[string]chan struct{}),
+		stats:   make(map[string]*Stats),
+	}
+}
+
+// Configured reports whether namespace has a limit in the pool's config,
+// meaning all of its methods should run on the pool.
+func (p *Pool) Configured(namespace string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.limits[namespace]
+	return ok
+}
+
+// Submit queues fn to run under namespace's limit. It returns ErrQueueFull
+// without running fn if too many jobs are already waiting.
+func (p *Pool) Submit(namespace string, fn func()) error {
+	p.mu.Lock()
+	if p.queued >= p.queue {
+		p.mu.Unlock()
+		metrics.Add(metrics.WorkerRejected, metrics.Labels{"namespace": namespace}, 1)
+		return ErrQueueFull
+	}
+	p.queued++
+	st := p.statsLocked(namespace)
+	st.Queued++
+	sem := p.nsSlotsLocked(namespace)
+	p.publishLocked(namespace)
+	p.mu.Unlock()
+
+	queuedAt := time.Now()
+	go func() {
+		sem <- struct{}{}
+		p.slots <- struct{}{}
+		metrics.Observe(metrics.WorkerWait, metrics.Labels{"namespace": namespace}, time.Since(queuedAt))
+
+		p.mu.Lock()
+		p.queued--
+		st.Queued--
+		st.Running++
+		p.publishLocked(namespace)
+		p.mu.Unlock()
+
+		defer func() {
+			p.mu.Lock()
+			st.Running--
+			p.publishLocked(namespace)
+			p.mu.Unlock()
+			<-p.slots
+			<-sem
+		}()
+		fn()
+	}()
+	return nil
+}
+
+// Stats returns a snapshot of every namespace that has used the pool.
+func (p *Pool) Stats() map[string]Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[string]Stats, len(p.stats))
+	for ns, st := range p.stats {
+		out[ns] = *st
+	}
+	return out
+}
+
+func (p *Pool) statsLocked(namespace string) *Stats {
+	st, ok := p.stats[namespace]
+	if !ok {
+		st = &Stats{}
+		p.stats[namespace] = st
+	}
+	return st
+}
+
+// nsSlotsLocked returns the semaphore for namespace, creating it on first use.
+func (p *Pool) nsSlotsLocked(namespace string) chan struct{} {
+	sem, ok := p.nsSlots[namespace]
+	if !ok {
+		limit := p.limits[namespace]
+		if limit <= 0 || limit > cap(p.slots) {
+			limit = cap(p.slots)
+		}
+		sem = make(chan struct{}, limit)
+		p.nsSlots[namespace] = sem
+	}
+	return sem
+}
+
+func (p *Pool) publishLocked(namespace string) {
+	st := p.stats[namespace]
+	labels := metrics.Labels{"namespace": namespace}
+	metrics.Set(metrics.WorkerQueued, labels, float64(st.Queued))
+	metrics.Set(metrics.WorkerRunning, labels, float64(st.Running))
+}
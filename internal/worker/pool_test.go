@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNamespaceLimit(t *testing.T) {
+	p := NewPool(Config{Size: 4, Namespaces: map[string]int{"image": 2}})
+
+	var running, peak int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		err := p.Submit("image", func() {
+			defer wg.Done()
+			n := atomic.AddInt32(&running, 1)
+			for {
+				old := atomic.LoadInt32(&peak)
+				if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent image jobs, saw %d", peak)
+	}
+	if st := p.Stats()["image"]; st.Queued != 0 || st.Running != 0 {
+		t.Errorf("expected idle namespace after completion, got %+v", st)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	p := NewPool(Config{Size: 1, Queue: 2})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Submit("zip", func() { close(started); <-release })
+	<-started
+
+	// The running job no longer counts against the queue; two more may wait.
+	for i := 0; i < 2; i++ {
+		if err := p.Submit("zip", func() {}); err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+	}
+	if err := p.Submit("zip", func() {}); err != ErrQueueFull {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+	close(release)
+}
+
+func TestConfigured(t *testing.T) {
+	p := NewPool(Config{Namespaces: map[string]int{"image": 1}})
+	if !p.Configured("image") {
+		t.Error("expected image to be configured")
+	}
+	if p.Configured("fs") {
+		t.Error("expected fs not to be configured")
+	}
+}
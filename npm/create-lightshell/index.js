@@ -63,6 +63,8 @@ fs.writeFileSync(
       build: {
         appId: `com.lightshell.${name}`,
       },
+      // Released in lockstep with the CLI
+      lightshellVersion: require("./package.json").version,
     },
     null,
     2
@@ -0,0 +1,231 @@
+// Package lightshell runs a LightShell app from a Go program that has its
+// own main, in place of the lightshell CLI: pages come from any fs.FS, Go
+// handlers are registered on a Router, and windows can be opened from Go
+// as well as from pages. The program is built with the go tool like any
+// other, so it links whatever Go libraries it needs.
+//
+// The pages get the same client library, defaults, and Content-Security-
+// Policy as a built app, and call the same APIs, gated by the permissions
+// in Options.
+//
+// This package is LightShell's supported Go API. Its exported identifiers
+// follow semantic versioning with the lightshell module: within a major
+// version they are not removed or changed incompatibly. The packages under
+// internal/ carry no such promise.
+package lightshell
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	goruntime "runtime"
+	"sync"
+
+	"github.com/lightshell-dev/lightshell/internal/accel"
+	"github.com/lightshell-dev/lightshell/internal/api"
+	"github.com/lightshell-dev/lightshell/internal/app"
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+	"github.com/lightshell-dev/lightshell/internal/runtime" // keeps main on the UI thread
+	"github.com/lightshell-dev/lightshell/internal/webview"
+)
+
+// Default size of the main window, as for a lightshell.json without one.
+const (
+	defaultWidth  = 1024
+	defaultHeight = 768
+)
+
+// Options configures an App. Name and Assets are required; every other
+// field has a default.
+type Options struct {
+	// Name identifies the app. It names the app's data, config, cache,
+	// and log directories, and is the main window's title unless
+	// Window.Title is set.
+	Name    string
+	Version string
+
+	// Assets holds the app's pages. Entry is the page the main window
+	// opens, a path in Assets; it defaults to index.html.
+	Assets fs.FS
+	Entry  string
+
+	Window WindowOptions
+
+	// Permissions lists the APIs pages may use, such as "fs" or "http",
+	// as in lightshell.json. None are granted by default.
+	Permissions []string
+
+	// Accelerators maps key combinations to built-in actions or custom
+	// events, as in lightshell.json.
+	Accelerators map[string]string
+
+	// DevTools enables the web inspector in every window.
+	DevTools bool
+}
+
+// App is a LightShell app: its main window, the windows opened from it,
+// and the Router their pages call.
+type App struct {
+	opts   Options
+	router *Router
+	wv     webview.Webview
+
+	mu      sync.Mutex
+	windows *api.WindowManager // set once Run has created the main window
+	onReady []func()
+}
+
+// New checks opts and returns an App ready to have handlers registered and
+// be run.
+func New(opts Options) (*App, error) {
+	if opts.Name == "" {
+		return nil, errors.New("lightshell: Options.Name is required")
+	}
+	if opts.Assets == nil {
+		return nil, errors.New("lightshell: Options.Assets is required")
+	}
+	if opts.Entry == "" {
+		opts.Entry = "index.html"
+	}
+	if !fs.ValidPath(opts.Entry) {
+		return nil, fmt.Errorf("lightshell: Options.Entry %q is not a path in Assets", opts.Entry)
+	}
+	if _, err := fs.Stat(opts.Assets, opts.Entry); err != nil {
+		return nil, fmt.Errorf("lightshell: Options.Entry: %w", err)
+	}
+	if opts.Window.Title == "" {
+		opts.Window.Title = opts.Name
+	}
+	if opts.Window.Width <= 0 {
+		opts.Window.Width = defaultWidth
+	}
+	if opts.Window.Height <= 0 {
+		opts.Window.Height = defaultHeight
+	}
+	if _, err := accel.Compile(opts.Accelerators, goruntime.GOOS); err != nil {
+		return nil, fmt.Errorf("lightshell: Options.Accelerators: %w", err)
+	}
+
+	// Run gives the router its worker pool
+	return &App{
+		opts:   opts,
+		router: &Router{r: ipc.NewRouter()},
+		wv:     webview.New(),
+	}, nil
+}
+
+// Router returns the router pages call through. Handlers may be added to
+// it at any time.
+func (a *App) Router() *Router {
+	return a.router
+}
+
+// OnReady registers fn to run once the main window exists and its page is
+// loading, before Run starts the event loop. Windows opened at startup are
+// opened from here.
+func (a *App) OnReady(fn func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onReady = append(a.onReady, fn)
+}
+
+// Emit sends an event to the pages of every window, where
+// lightshell.on(event) receives data.
+func (a *App) Emit(event string, data any) {
+	a.router.r.SendEvent(event, data)
+}
+
+// MainWindow returns the main window.
+func (a *App) MainWindow() *Window {
+	return &Window{app: a, id: webview.MainWindowID}
+}
+
+// OpenWindow opens a window on page, a path in Assets or a URL relative
+// to the entry page, like window.create in a page. It may be called once
+// the app is ready.
+func (a *App) OpenWindow(page string, opts WindowOptions) (*Window, error) {
+	a.mu.Lock()
+	windows := a.windows
+	a.mu.Unlock()
+	if windows == nil {
+		return nil, errors.New("lightshell: OpenWindow called before the app is ready")
+	}
+	id, err := windows.Open(opts.config(), page)
+	if err != nil {
+		return nil, err
+	}
+	return &Window{app: a, id: id}, nil
+}
+
+// Quit ends the event loop from any goroutine: Run then runs the shutdown
+// hooks and returns.
+func (a *App) Quit() {
+	a.wv.Quit()
+}
+
+// Run serves the pages, opens the main window, and runs the event loop
+// until the app quits. It must be called from the main goroutine, which
+// the windowing system requires; this package keeps main on its thread.
+//
+// The app runs on the same runtime as an app built with lightshell build,
+// with the same navigation guard, permission policy, and HTTP settings.
+func (a *App) Run() error {
+	cfg := runtime.Config{
+		Name:    a.opts.Name,
+		Version: a.opts.Version,
+		Entry:   a.opts.Entry,
+		Window: runtime.WindowConfig{
+			Title:     a.opts.Window.Title,
+			Width:     a.opts.Window.Width,
+			Height:    a.opts.Window.Height,
+			MinWidth:  a.opts.Window.MinWidth,
+			MinHeight: a.opts.Window.MinHeight,
+			Frameless: a.opts.Window.Frameless,
+		},
+		Permissions:  runtime.Permissions{Names: a.opts.Permissions},
+		Accelerators: a.opts.Accelerators,
+	}
+	httpOpts, err := app.HTTPOptions(cfg, "")
+	if err != nil {
+		return fmt.Errorf("lightshell: %w", err)
+	}
+	wcfg := a.opts.Window.config()
+	// Without a ProjectDir the app's data directory stands in for the
+	// project directory, as it does for a built app
+	err = app.Run(app.Options{
+		Config:   cfg,
+		Pages:    a.opts.Assets,
+		Entry:    path.Clean(a.opts.Entry),
+		Argv:     os.Args[1:],
+		Router:   a.router.r,
+		Webview:  a.wv,
+		Window:   &wcfg,
+		DevTools: a.opts.DevTools,
+		Register: func(x *app.App) {
+			api.RegisterFS(x.Router, x.Policy)
+			api.RegisterTempDirs(x.Router, x.Policy, cfg.Name)
+			api.RegisterHTTP(x.Router, x.Policy, httpOpts)
+			api.RegisterImage(x.Router, x.Policy)
+			api.RegisterPDF(x.Router, x.Policy)
+			api.RegisterShare(x.Router, x.Policy)
+			api.RegisterCodes(x.Router, x.Policy)
+			a.mu.Lock()
+			a.windows = x.Windows
+			a.mu.Unlock()
+		},
+		Ready: func(*app.App) {
+			a.mu.Lock()
+			ready := a.onReady
+			a.mu.Unlock()
+			for _, fn := range ready {
+				fn()
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("lightshell: %w", err)
+	}
+	return nil
+}
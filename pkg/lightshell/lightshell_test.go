@@ -0,0 +1,100 @@
+package lightshell
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var pages = fstest.MapFS{
+	"index.html":      {Data: []byte("<h1>Hello</h1>")},
+	"about/info.html": {Data: []byte("<h1>About</h1>")},
+}
+
+func TestNewDefaults(t *testing.T) {
+	app, err := New(Options{Name: "notes", Assets: pages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.opts.Entry != "index.html" {
+		t.Errorf("Entry = %q, want index.html", app.opts.Entry)
+	}
+	w := app.opts.Window
+	if w.Title != "notes" || w.Width != defaultWidth || w.Height != defaultHeight {
+		t.Errorf("Window = %+v, want the app's name and %dx%d", w, defaultWidth, defaultHeight)
+	}
+	if cfg := w.config(); !cfg.Resizable {
+		t.Error("windows should be resizable unless Fixed is set")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"no name", Options{Assets: pages}, "Name is required"},
+		{"no assets", Options{Name: "notes"}, "Assets is required"},
+		{"missing entry", Options{Name: "notes", Assets: pages, Entry: "main.html"}, "Options.Entry"},
+		{"entry outside assets", Options{Name: "notes", Assets: pages, Entry: "../index.html"}, "not a path in Assets"},
+		{"bad accelerator", Options{Name: "notes", Assets: pages, Accelerators: map[string]string{"Cmd+": "quit"}}, "Accelerators"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterHandle(t *testing.T) {
+	app, err := New(Options{Name: "notes", Assets: pages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Router().Handle("greet", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Name == "" {
+			return nil, errors.New("name is required")
+		}
+		return "Hello, " + p.Name, nil
+	})
+
+	call := func(payload string) (result any, errMsg string) {
+		raw := app.router.r.HandleMessage(`{"id":"1","method":"invoke","params":{"handler":"greet","payload":` + payload + `}}`)
+		var resp struct {
+			Result any    `json:"result"`
+			Error  string `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", raw, err)
+		}
+		return resp.Result, resp.Error
+	}
+	if result, errMsg := call(`{"name":"Ada"}`); result != "Hello, Ada" || errMsg != "" {
+		t.Errorf("greet = %v, %q; want Hello, Ada", result, errMsg)
+	}
+	if _, errMsg := call(`{}`); errMsg != "name is required" {
+		t.Errorf("greet without a name: error = %q, want the handler's", errMsg)
+	}
+}
+
+func TestOpenWindowBeforeRun(t *testing.T) {
+	app, err := New(Options{Name: "notes", Assets: pages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.OpenWindow("about/info.html", WindowOptions{}); err == nil {
+		t.Error("OpenWindow before Run should fail")
+	}
+}
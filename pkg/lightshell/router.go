@@ -0,0 +1,31 @@
+package lightshell
+
+import (
+	"encoding/json"
+
+	"github.com/lightshell-dev/lightshell/internal/ipc"
+)
+
+// HandlerFunc handles a call from a page. params is the payload the page
+// passed, as JSON; the result is sent back as JSON, and an error rejects
+// the page's promise with its message.
+type HandlerFunc func(params json.RawMessage) (any, error)
+
+// Router dispatches calls from pages to the LightShell APIs and to the
+// app's own handlers.
+type Router struct {
+	r *ipc.Router
+}
+
+// Handle registers handler under name, which pages call with
+// lightshell.invoke(name, payload). A later Handle with the same name
+// replaces it.
+func (r *Router) Handle(name string, handler HandlerFunc) {
+	r.r.HandleCustom(name, ipc.HandlerFunc(handler))
+}
+
+// OnShutdown registers fn to run when the app quits, after the event loop
+// ends. Hooks run in the order they were registered.
+func (r *Router) OnShutdown(fn func()) {
+	r.r.OnShutdown(fn)
+}
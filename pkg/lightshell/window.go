@@ -0,0 +1,69 @@
+package lightshell
+
+import "github.com/lightshell-dev/lightshell/internal/webview"
+
+// WindowOptions configures a window. A zero size or an empty title takes
+// the default: for the main window 1024x768 and the app's name, for others
+// 800x600 and the main window's title.
+type WindowOptions struct {
+	Title               string
+	Width, Height       int
+	MinWidth, MinHeight int
+	Fixed               bool // not resizable
+	Frameless           bool
+	AlwaysOnTop         bool
+}
+
+func (o WindowOptions) config() webview.WindowConfig {
+	return webview.WindowConfig{
+		Title:       o.Title,
+		Width:       o.Width,
+		Height:      o.Height,
+		MinWidth:    o.MinWidth,
+		MinHeight:   o.MinHeight,
+		Resizable:   !o.Fixed,
+		Frameless:   o.Frameless,
+		AlwaysOnTop: o.AlwaysOnTop,
+	}
+}
+
+// Window is one of the app's windows. Its methods fail once the window is
+// closed.
+type Window struct {
+	app *App
+	id  int
+}
+
+// ID returns the window's ID, which pages see as lightshell.window.id.
+func (w *Window) ID() int {
+	return w.id
+}
+
+// Eval runs js in the window's page without waiting for it.
+func (w *Window) Eval(js string) error {
+	return w.app.wv.EvalWindow(w.id, js)
+}
+
+// Focus brings the window to the front.
+func (w *Window) Focus() error {
+	return w.app.wv.FocusWindow(w.id)
+}
+
+// Close closes the window. Closing the main window with no other window
+// visible quits the app.
+func (w *Window) Close() error {
+	if w.id == webview.MainWindowID {
+		return w.app.wv.Close()
+	}
+	return w.app.wv.CloseWindow(w.id)
+}
+
+// Size returns the size of the window's content in points.
+func (w *Window) Size() (width, height int, err error) {
+	return w.app.wv.WindowSize(w.id)
+}
+
+// Screenshot returns a PNG of the window's content.
+func (w *Window) Screenshot() ([]byte, error) {
+	return w.app.wv.ScreenshotWindow(w.id)
+}
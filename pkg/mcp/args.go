@@ -0,0 +1,56 @@
+package mcp
+
+import "encoding/json"
+
+// Args holds a tool call's arguments as decoded from JSON, already checked
+// against the tool's input schema. The getters return def for arguments
+// that are absent or of another type.
+type Args map[string]any
+
+// String returns the string argument key.
+func (a Args) String(key, def string) string {
+	if s, ok := a[key].(string); ok {
+		return s
+	}
+	return def
+}
+
+// Int returns the number argument key, truncated to an int.
+func (a Args) Int(key string, def int) int {
+	if n, ok := number(a[key]); ok {
+		return int(n)
+	}
+	return def
+}
+
+// Float returns the number argument key.
+func (a Args) Float(key string, def float64) float64 {
+	if n, ok := number(a[key]); ok {
+		return n
+	}
+	return def
+}
+
+// Bool returns the boolean argument key.
+func (a Args) Bool(key string, def bool) bool {
+	if b, ok := a[key].(bool); ok {
+		return b
+	}
+	return def
+}
+
+// Has reports whether the argument key was given.
+func (a Args) Has(key string) bool {
+	_, ok := a[key]
+	return ok
+}
+
+// Decode stores the arguments in v, usually a pointer to a struct with
+// json tags, as encoding/json would.
+func (a Args) Decode(v any) error {
+	b, err := json.Marshal(map[string]any(a))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
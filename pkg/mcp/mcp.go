@@ -0,0 +1,171 @@
+// Package mcp runs LightShell's MCP server with a project's own tools added
+// to the built-in ones, for tasks only the project knows how to do, such as
+// seeding test data. A team builds it into a small command of its own and
+// points its AI tools at that command in place of lightshell mcp:
+//
+//	func main() {
+//		mcp.Main("notes", mcp.Tool{
+//			Name:        "seed_test_data",
+//			Description: "Fill the running app with sample notes",
+//			Input: mcp.Object(mcp.Props{
+//				"count": mcp.Integer("How many notes to add"),
+//			}, "count"),
+//			Handler: func(ctx *mcp.Context, args mcp.Args) (any, error) {
+//				return ctx.Eval(fmt.Sprintf("seedNotes(%d)", args.Int("count", 0)))
+//			},
+//		})
+//	}
+//
+// Project tools are named after a namespace, notes_seed_test_data here, so
+// they cannot collide with the lightshell_ tools or another team's. Their
+// arguments are checked against their input schemas before they run.
+//
+// Like pkg/lightshell, this package's exported identifiers follow semantic
+// versioning with the lightshell module.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/lightshell-dev/lightshell/internal/mcp"
+)
+
+// maxEvalBytes is the most JavaScript output Context.Eval returns, the
+// limit lightshell_execute_js allows.
+const maxEvalBytes = 1024 * 1024
+
+// Tool is a project-specific MCP tool.
+type Tool struct {
+	// Name is the tool's name within its namespace: lowercase letters,
+	// digits, and underscores, starting with a letter.
+	Name        string
+	Description string
+
+	// Input is the JSON Schema of the tool's arguments, usually built with
+	// Object. A nil Input takes no arguments.
+	Input Schema
+
+	// Handler does the tool's work. A string result is returned to the
+	// client as text and anything else as JSON; an error is returned as a
+	// failed tool call.
+	Handler func(ctx *Context, args Args) (any, error)
+}
+
+// Server is an MCP server with the built-in tools and any registered ones.
+type Server struct {
+	s *mcp.Server
+}
+
+// NewServer returns a server for the project in projectDir.
+func NewServer(projectDir string) *Server {
+	return &Server{s: mcp.NewServer(projectDir, "")}
+}
+
+// Register adds tools under namespace, lowercase letters and digits
+// starting with a letter, so that each is exposed as namespace_name. The
+// lightshell namespace is reserved. Tools must be registered before Run.
+func (s *Server) Register(namespace string, tools ...Tool) error {
+	for _, t := range tools {
+		if t.Handler == nil {
+			return fmt.Errorf("mcp: tool %s_%s has no handler", namespace, t.Name)
+		}
+		if err := s.s.AddTool(namespace, s.wrap(t)); err != nil {
+			return fmt.Errorf("mcp: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run serves MCP over stdin and stdout until stdin is closed, stopping any
+// dev processes the session started.
+func (s *Server) Run() error {
+	return s.s.Run()
+}
+
+// Main runs a server for the project in the working directory with tools
+// registered under namespace, and exits if it cannot start.
+func Main(namespace string, tools ...Tool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mcp:", err)
+		os.Exit(1)
+	}
+	s := NewServer(dir)
+	if err := s.Register(namespace, tools...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := s.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "mcp:", err)
+		os.Exit(1)
+	}
+}
+
+// wrap adapts t to the server's tools, checking arguments first.
+func (s *Server) wrap(t Tool) mcp.Tool {
+	input := t.Input
+	if input == nil {
+		input = Object(nil)
+	}
+	ctx := &Context{s: s.s}
+	return mcp.Tool{
+		Name:        t.Name,
+		Description: t.Description,
+		InputSchema: input,
+		Handler: func(params map[string]any) (any, error) {
+			if params == nil {
+				params = map[string]any{}
+			}
+			if err := input.Validate(params); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+			return t.Handler(ctx, Args(params))
+		},
+	}
+}
+
+// Context gives a tool's handler the server's view of the project: the
+// active project, which a client may switch, and its dev process.
+type Context struct {
+	s *mcp.Server
+}
+
+// ProjectDir returns the active project's directory.
+func (c *Context) ProjectDir() string {
+	return c.s.ProjectDir()
+}
+
+// SafePath resolves rel within the active project, as the built-in file
+// tools do, and fails for paths that lead outside it, including through
+// symlinks. Tools that take paths from the client should resolve them
+// here.
+func (c *Context) SafePath(rel string) (string, error) {
+	return c.s.SafePath(rel)
+}
+
+// DevRunning reports whether the active project's dev process is running,
+// which Eval needs. Clients start it with lightshell_dev_start.
+func (c *Context) DevRunning() bool {
+	return c.s.DevRunning()
+}
+
+// Eval runs code in the running app's main window and returns the value of
+// its last expression as JSON, as lightshell_execute_js does.
+func (c *Context) Eval(code string) (json.RawMessage, error) {
+	return c.EvalWindow(0, code)
+}
+
+// EvalWindow runs code in the window with the given ID, as listed by
+// lightshell_list_windows. Results over 1 MB are an error.
+func (c *Context) EvalWindow(id int, code string) (json.RawMessage, error) {
+	result, truncated, err := c.s.Eval(code, id, maxEvalBytes)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		return nil, fmt.Errorf("result is larger than %d bytes", maxEvalBytes)
+	}
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterErrors(t *testing.T) {
+	noop := func(*Context, Args) (any, error) { return nil, nil }
+	tests := []struct {
+		name      string
+		namespace string
+		tool      Tool
+		want      string
+	}{
+		{"reserved namespace", "lightshell", Tool{Name: "seed", Handler: noop}, "reserved"},
+		{"bad namespace", "My-App", Tool{Name: "seed", Handler: noop}, "invalid tool namespace"},
+		{"bad name", "notes", Tool{Name: "Seed Data", Handler: noop}, "invalid tool name"},
+		{"no handler", "notes", Tool{Name: "seed"}, "no handler"},
+		{"duplicate", "notes", Tool{Name: "seed", Handler: noop}, "already registered"},
+	}
+	s := NewServer(t.TempDir())
+	if err := s.Register("notes", Tool{Name: "seed", Handler: noop}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Register(tt.namespace, tt.tool)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Register() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerChecksArguments(t *testing.T) {
+	dir := t.TempDir()
+	s := NewServer(dir)
+	var got Args
+	tool := s.wrap(Tool{
+		Name:  "seed",
+		Input: Object(Props{"count": Integer("How many")}, "count"),
+		Handler: func(ctx *Context, args Args) (any, error) {
+			if ctx.ProjectDir() != dir {
+				t.Errorf("ProjectDir() = %q, want %q", ctx.ProjectDir(), dir)
+			}
+			got = args
+			return "ok", nil
+		},
+	})
+
+	if _, err := tool.Handler(map[string]any{"count": "ten"}); err == nil || !strings.Contains(err.Error(), "count: must be an integer") {
+		t.Errorf("string count: error = %v", err)
+	}
+	if got != nil {
+		t.Error("the handler ran with invalid arguments")
+	}
+	if result, err := tool.Handler(map[string]any{"count": float64(10)}); err != nil || result != "ok" {
+		t.Fatalf("valid call = %v, %v", result, err)
+	}
+	if n := got.Int("count", 0); n != 10 {
+		t.Errorf("Int(count) = %d, want 10", n)
+	}
+}
+
+func TestContextSafePath(t *testing.T) {
+	dir := t.TempDir()
+	ctx := &Context{s: NewServer(dir).s}
+	if _, err := ctx.SafePath("../outside.txt"); err == nil {
+		t.Error("SafePath should refuse paths outside the project")
+	}
+	if ctx.DevRunning() {
+		t.Error("DevRunning() = true with no dev process")
+	}
+	if _, err := ctx.Eval("1 + 1"); err == nil {
+		t.Error("Eval should fail with no dev process")
+	}
+}
@@ -0,0 +1,290 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON Schema, as MCP clients read tool inputs. The builders
+// below cover what tools usually take; a Schema may also be written out as
+// a map for anything they do not.
+type Schema map[string]any
+
+// Props maps an object's property names to their schemas.
+type Props map[string]Schema
+
+// Object is the schema of an object with properties, of which required
+// must be present. Properties not listed are allowed.
+func Object(props Props, required ...string) Schema {
+	properties := map[string]any{}
+	for name, p := range props {
+		properties[name] = p
+	}
+	s := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// String is the schema of a string.
+func String(description string) Schema {
+	return Schema{"type": "string", "description": description}
+}
+
+// Integer is the schema of a whole number.
+func Integer(description string) Schema {
+	return Schema{"type": "integer", "description": description}
+}
+
+// Number is the schema of any number.
+func Number(description string) Schema {
+	return Schema{"type": "number", "description": description}
+}
+
+// Boolean is the schema of true or false.
+func Boolean(description string) Schema {
+	return Schema{"type": "boolean", "description": description}
+}
+
+// Array is the schema of a list whose elements match items.
+func Array(items Schema, description string) Schema {
+	return Schema{"type": "array", "items": items, "description": description}
+}
+
+// Enum is the schema of a string that is one of values.
+func Enum(description string, values ...string) Schema {
+	return Schema{"type": "string", "enum": values, "description": description}
+}
+
+// Validate checks v, a decoded JSON value, against the schema. It knows the
+// keywords tools' schemas use: type, enum, properties, required,
+// additionalProperties, items, minimum, maximum, minLength, and maxLength.
+// Others are ignored.
+func (s Schema) Validate(v any) error {
+	return validate(map[string]any(s), v, "")
+}
+
+func validate(s map[string]any, v any, path string) error {
+	fail := func(format string, args ...any) error {
+		msg := fmt.Sprintf(format, args...)
+		if path == "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return fmt.Errorf("%s: %s", path, msg)
+	}
+
+	if types := stringList(s["type"]); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return hasType(v, t) }) {
+		return fail("must be %s", article(strings.Join(types, " or ")))
+	}
+	if enum, ok := s["enum"]; ok {
+		if !slices.ContainsFunc(anyList(enum), func(e any) bool { return equal(e, v) }) {
+			return fail("must be one of %s", jsonList(anyList(enum)))
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range stringList(s["required"]) {
+			if _, ok := v[name]; !ok {
+				return fail("%s is required", name)
+			}
+		}
+		props := schemaMap(s["properties"])
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p, ok := props[name]
+			if !ok {
+				if allowed, isBool := s["additionalProperties"].(bool); isBool && !allowed {
+					return fail("unknown property %s", name)
+				}
+				continue
+			}
+			if err := validate(p, v[name], join(path, name)); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if items := schemaOf(s["items"]); items != nil {
+			for i, e := range v {
+				if err := validate(items, e, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := len([]rune(v))
+		if min, ok := number(s["minLength"]); ok && float64(n) < min {
+			return fail("must be at least %v characters", min)
+		}
+		if max, ok := number(s["maxLength"]); ok && float64(n) > max {
+			return fail("must be at most %v characters", max)
+		}
+	default:
+		if n, ok := number(v); ok {
+			if min, ok := number(s["minimum"]); ok && n < min {
+				return fail("must be at least %v", min)
+			}
+			if max, ok := number(s["maximum"]); ok && n > max {
+				return fail("must be at most %v", max)
+			}
+		}
+	}
+	return nil
+}
+
+// hasType reports whether v is of the JSON Schema type t.
+func hasType(v any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := number(v)
+		return ok
+	case "integer":
+		n, ok := number(v)
+		return ok && n == math.Trunc(n)
+	}
+	return true // unknown types are not ours to reject
+}
+
+// number returns v as a float64 if it is a number, decoded from JSON or
+// written into a Schema by hand.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// equal compares JSON values, treating numbers of different Go types alike.
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func stringList(v any) []string {
+	switch l := v.(type) {
+	case string:
+		return []string{l}
+	case []string:
+		return l
+	case []any:
+		var out []string
+		for _, e := range l {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func anyList(v any) []any {
+	switch l := v.(type) {
+	case []any:
+		return l
+	case []string:
+		out := make([]any, len(l))
+		for i, s := range l {
+			out[i] = s
+		}
+		return out
+	}
+	return nil
+}
+
+// schemaOf returns v as a schema if it is one.
+func schemaOf(v any) map[string]any {
+	switch s := v.(type) {
+	case Schema:
+		return s
+	case map[string]any:
+		return s
+	}
+	return nil
+}
+
+func schemaMap(v any) map[string]map[string]any {
+	out := map[string]map[string]any{}
+	switch m := v.(type) {
+	case Props:
+		for name, s := range m {
+			out[name] = s
+		}
+	case map[string]Schema:
+		for name, s := range m {
+			out[name] = s
+		}
+	case map[string]any:
+		for name, s := range m {
+			if s := schemaOf(s); s != nil {
+				out[name] = s
+			}
+		}
+	}
+	return out
+}
+
+func jsonList(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		b, _ := json.Marshal(v)
+		parts[i] = string(b)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// article prefixes a type name for an error message.
+func article(t string) string {
+	if t == "null" {
+		return t
+	}
+	if strings.HasPrefix(t, "a") || strings.HasPrefix(t, "i") || strings.HasPrefix(t, "o") {
+		return "an " + t
+	}
+	return "a " + t
+}
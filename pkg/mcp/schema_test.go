@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	schema := Object(Props{
+		"name":  String("Who to greet"),
+		"count": Integer("How many"),
+		"mode":  Enum("How", "fast", "slow"),
+		"tags":  Array(String("A tag"), "Tags"),
+		"ratio": Schema{"type": "number", "minimum": 0, "maximum": 1},
+		"user":  Object(Props{"id": Integer("User ID")}, "id"),
+	}, "name")
+
+	tests := []struct {
+		args string
+		want string // empty for valid
+	}{
+		{`{"name": "Ada"}`, ""},
+		{`{"name": "Ada", "count": 3, "mode": "slow", "tags": ["a", "b"], "ratio": 0.5, "user": {"id": 7}, "extra": true}`, ""},
+		{`{}`, "name is required"},
+		{`{"name": 1}`, "name: must be a string"},
+		{`{"name": "Ada", "count": 1.5}`, "count: must be an integer"},
+		{`{"name": "Ada", "mode": "medium"}`, `mode: must be one of "fast", "slow"`},
+		{`{"name": "Ada", "tags": ["a", 2]}`, "tags[1]: must be a string"},
+		{`{"name": "Ada", "ratio": 2}`, "ratio: must be at most 1"},
+		{`{"name": "Ada", "user": {}}`, "user: id is required"},
+		{`{"name": "Ada", "user": {"id": "7"}}`, "user.id: must be an integer"},
+	}
+	for _, tt := range tests {
+		var args map[string]any
+		if err := json.Unmarshal([]byte(tt.args), &args); err != nil {
+			t.Fatal(err)
+		}
+		err := schema.Validate(args)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("Validate(%s) = %v, want nil", tt.args, err)
+			}
+		} else if err == nil || err.Error() != tt.want {
+			t.Errorf("Validate(%s) = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestValidateHandWritten(t *testing.T) {
+	// As a schema reads when written as JSON rather than with the builders
+	var schema Schema
+	json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {"id": {"type": ["string", "null"], "minLength": 2}},
+		"additionalProperties": false
+	}`), &schema)
+
+	if err := schema.Validate(map[string]any{"id": nil}); err != nil {
+		t.Errorf("null id: %v", err)
+	}
+	if err := schema.Validate(map[string]any{"id": "x"}); err == nil || !strings.Contains(err.Error(), "at least 2 characters") {
+		t.Errorf("short id: error = %v", err)
+	}
+	if err := schema.Validate(map[string]any{"id": 3}); err == nil || err.Error() != "id: must be a string or null" {
+		t.Errorf("numeric id: error = %v", err)
+	}
+	if err := schema.Validate(map[string]any{"other": 1}); err == nil || err.Error() != "unknown property other" {
+		t.Errorf("unknown property: error = %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+// Package lightshell holds the source of LightShell's runtime, for
+// lightshell build to compile into apps. See pkg/lightshell for running
+// apps from Go programs.
+package lightshell
+
+import "embed"
+
+// Source holds go.mod and the internal packages. A built app is compiled
+// as the main package of this module, importing the same runtime, API,
+// and security packages as lightshell dev and lightshell run.
+//
+//go:embed go.mod internal
+var Source embed.FS